@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	htmltemplate "html/template"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/events"
+)
+
+// checkOutSummary is what the summary email shows, after applying the
+// customization settings
+type checkOutSummary struct {
+	LogoURL       string
+	CheckInAt     string
+	CheckOutAt    string
+	HoursWorked   float64
+	ShowHours     bool
+	RegularHours  float64
+	OvertimeHours float64
+	ShowOvertime  bool
+	LaborCost     string // Empty when hidden or the record is unpriced
+	Footer        string
+}
+
+var checkOutTextTemplate = template.Must(template.New("checkout-text").Parse(`
+		Hello,
+		
+		You have successfully checked out.
+		
+		Check-in time: {{.CheckInAt}}
+		Check-out time: {{.CheckOutAt}}
+{{- if .ShowHours}}
+		Hours worked: {{printf "%.2f" .HoursWorked}}
+{{- end}}
+{{- if .ShowOvertime}}
+		Regular hours: {{printf "%.2f" .RegularHours}}
+		Overtime hours: {{printf "%.2f" .OvertimeHours}}
+{{- end}}
+{{- if .LaborCost}}
+		Labor cost: {{.LaborCost}}
+{{- end}}
+		
+		{{.Footer}}
+`))
+
+var checkOutHTMLTemplate = htmltemplate.Must(htmltemplate.New("checkout-html").Parse(`<!DOCTYPE html>
+<html>
+<body>
+{{- if .LogoURL}}
+<p><img src="{{.LogoURL}}" alt="" style="max-height:60px"></p>
+{{- end}}
+<p>Hello,</p>
+<p>You have successfully checked out.</p>
+<table>
+<tr><td>Check-in time</td><td>{{.CheckInAt}}</td></tr>
+<tr><td>Check-out time</td><td>{{.CheckOutAt}}</td></tr>
+{{- if .ShowHours}}
+<tr><td>Hours worked</td><td>{{printf "%.2f" .HoursWorked}}</td></tr>
+{{- end}}
+{{- if .ShowOvertime}}
+<tr><td>Regular hours</td><td>{{printf "%.2f" .RegularHours}}</td></tr>
+<tr><td>Overtime hours</td><td>{{printf "%.2f" .OvertimeHours}}</td></tr>
+{{- end}}
+{{- if .LaborCost}}
+<tr><td>Labor cost</td><td>{{.LaborCost}}</td></tr>
+{{- end}}
+</table>
+<p>{{.Footer}}</p>
+</body>
+</html>
+`))
+
+func newCheckOutSummary(event *events.EmployeeCheckedOutEvent, settings *entities.CheckOutEmailSettings) checkOutSummary {
+	// Times travel in UTC; show them on the employee's own clock
+	summary := checkOutSummary{
+		LogoURL:       settings.LogoURL,
+		CheckInAt:     entities.InTimeZone(event.CheckInAt, event.TimeZone).Format(time.RFC822),
+		CheckOutAt:    entities.InTimeZone(event.CheckOutAt, event.TimeZone).Format(time.RFC822),
+		HoursWorked:   event.HoursWorked,
+		ShowHours:     settings.ShowHours,
+		RegularHours:  event.RegularHours,
+		OvertimeHours: event.OvertimeHours,
+		ShowOvertime:  settings.ShowOvertime,
+		Footer:        settings.FooterText,
+	}
+	if settings.ShowCost && event.LaborCost != nil {
+		summary.LaborCost = event.LaborCost.Total + " " + event.LaborCost.Currency
+	}
+	if summary.Footer == "" {
+		summary.Footer = "Thank you!"
+	}
+	return summary
+}
+
+// render returns the plain text and HTML bodies of the summary
+func (s checkOutSummary) render() (string, string, error) {
+	var text, html strings.Builder
+	if err := checkOutTextTemplate.Execute(&text, s); err != nil {
+		return "", "", err
+	}
+	if err := checkOutHTMLTemplate.Execute(&html, s); err != nil {
+		return "", "", err
+	}
+	return text.String(), html.String(), nil
+}
@@ -4,20 +4,135 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/leo-andrei/check-in-service/domain/entities"
 	"github.com/leo-andrei/check-in-service/domain/events"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
 	"github.com/leo-andrei/check-in-service/infrastructure/external"
+	"go.uber.org/zap"
 )
 
+// ConsentChecker decides whether an employee's data may be processed for a purpose
+type ConsentChecker interface {
+	HasConsent(ctx context.Context, employeeID string, purpose entities.ConsentPurpose) (bool, error)
+}
+
+// EmailSettingsProvider returns how the check-out summary is customized
+type EmailSettingsProvider interface {
+	CheckOutEmail(ctx context.Context) (*entities.CheckOutEmailSettings, error)
+}
+
+// SentLedger records the emails that were sent, across worker instances.
+// services.IdempotencyService implements it.
+type SentLedger interface {
+	Begin(ctx context.Context, key, fingerprint string) (*repositories.IdempotencyRecord, error)
+	Finish(ctx context.Context, key string, statusCode int, contentType string, body []byte) error
+	Abandon(ctx context.Context, key string) error
+}
+
+// smtpAccepted is the reply code stored in the ledger for a sent email
+const smtpAccepted = 250
+
 type EmailNotifier struct {
 	emailClient *external.EmailClient
+	consents    ConsentChecker
+	settings    EmailSettingsProvider
+	ledger      SentLedger
 }
 
-func NewEmailNotifier(client *external.EmailClient) *EmailNotifier {
+func NewEmailNotifier(client *external.EmailClient, consents ConsentChecker, settings EmailSettingsProvider, ledger SentLedger) *EmailNotifier {
 	return &EmailNotifier{
 		emailClient: client,
+		consents:    consents,
+		settings:    settings,
+		ledger:      ledger,
+	}
+}
+
+// Handle dispatches events from the shared exchange; check-outs, approval
+// requests/decisions, record corrections/voids and no-shows produce an
+// email, everything else is acknowledged
+func (h *EmailNotifier) Handle(ctx context.Context, eventData []byte) error {
+	eventType, err := events.TypeOf(eventData)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	switch eventType {
+	case events.EventTypeEmployeeCheckedOut:
+		return h.HandleCheckedOut(ctx, eventData)
+	case events.EventTypeApprovalRequested:
+		return h.HandleApprovalRequested(ctx, eventData)
+	case events.EventTypeApprovalDecided:
+		return h.HandleApprovalDecided(ctx, eventData)
+	case events.EventTypeTimeRecordCorrected:
+		return h.HandleRecordCorrected(ctx, eventData)
+	case events.EventTypeTimeRecordVoided:
+		return h.HandleRecordVoided(ctx, eventData)
+	case events.EventTypeEmployeeNoShow:
+		return h.HandleNoShow(ctx, eventData)
+	}
+	return nil
+}
+
+// allowed reports whether the recipient consented to notifications
+func (h *EmailNotifier) allowed(ctx context.Context, recipientID string) (bool, error) {
+	return notificationsAllowed(ctx, h.consents, recipientID)
+}
+
+// notificationsAllowed checks the recipient's notification consent; without
+// a consent checker everyone is notified
+func notificationsAllowed(ctx context.Context, consents ConsentChecker, recipientID string) (bool, error) {
+	if consents == nil {
+		return true, nil
+	}
+	allowed, err := consents.HasConsent(ctx, recipientID, entities.PurposeNotifications)
+	if err != nil {
+		return false, fmt.Errorf("failed to check consent: %w", err)
+	}
+	if !allowed {
+		config.Logger.Info("Skipping email, no notification consent", zap.String("employee_id", recipientID))
+	}
+	return allowed, nil
+}
+
+// emailKey is the idempotency key of the email sent for an event, the same on
+// every redelivery; events from before event IDs have none
+func emailKey(eventID string) string {
+	if eventID == "" {
+		return ""
+	}
+	return "email-" + eventID
+}
+
+// sendOnce calls send unless the ledger shows the email under key was already
+// sent. A failed send is released so the redelivery retries it; when the
+// failure was a timeout and the email did go out, the provider drops the
+// retry by its idempotency key. Without a ledger or key it just sends.
+func sendOnce(ctx context.Context, ledger SentLedger, key string, send func() error) error {
+	if ledger == nil || key == "" {
+		return send()
+	}
+
+	sent, err := ledger.Begin(ctx, key, "")
+	if err != nil {
+		// Another worker is sending it, or the ledger is down: retry later
+		return fmt.Errorf("failed to check sent emails: %w", err)
 	}
+	if sent != nil {
+		config.Logger.Info("Skipping email, already sent", zap.String("key", key))
+		return nil
+	}
+
+	if err := send(); err != nil {
+		ledger.Abandon(ctx, key)
+		return err
+	}
+	ledger.Finish(ctx, key, smtpAccepted, "", nil)
+	return nil
 }
 
 func (h *EmailNotifier) HandleCheckedOut(ctx context.Context, eventData []byte) error {
@@ -26,25 +141,261 @@ func (h *EmailNotifier) HandleCheckedOut(ctx context.Context, eventData []byte)
 		return fmt.Errorf("failed to unmarshal event: %w", err)
 	}
 
+	// Acknowledge without sending if the employee opted out of notifications
+	allowed, err := h.allowed(ctx, event.EmployeeID)
+	if err != nil || !allowed {
+		return err
+	}
+
+	// Settings are read at send time so changes apply to queued check-outs too
+	settings := entities.DefaultCheckOutEmailSettings()
+	if h.settings != nil {
+		if settings, err = h.settings.CheckOutEmail(ctx); err != nil {
+			return fmt.Errorf("failed to load email settings: %w", err)
+		}
+	}
+
+	text, html, err := newCheckOutSummary(&event, settings).render()
+	if err != nil {
+		return fmt.Errorf("failed to render email: %w", err)
+	}
+
 	subject := "Your Work Hours Summary"
+	key := emailKey(event.EventID)
+	err = sendOnce(ctx, h.ledger, key, func() error {
+		return h.emailClient.SendEmailWithHTML(ctx, key, event.EmployeeID, subject, text, html)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
+
+// HandleApprovalRequested asks the employee's manager to review a request.
+// Requests without a manager are left to admins and send nothing.
+func (h *EmailNotifier) HandleApprovalRequested(ctx context.Context, eventData []byte) error {
+	var event events.ApprovalRequestedEvent
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	if event.ManagerID == "" {
+		return nil
+	}
+	allowed, err := h.allowed(ctx, event.ManagerID)
+	if err != nil || !allowed {
+		return err
+	}
+
+	checkInAt := entities.InTimeZone(event.ProposedCheckInAt, event.TimeZone)
+	checkOutAt := entities.InTimeZone(event.ProposedCheckOutAt, event.TimeZone)
+
+	subject := "Time Record Approval Requested"
+	body := fmt.Sprintf(`
+		Hello,
+		
+		Employee %s is asking for approval of a %s.
+		
+		Proposed check-in time: %s
+		Proposed check-out time: %s
+		Note: %s
+		
+		Request ID: %s
+	`, event.EmployeeID,
+		strings.ToLower(strings.ReplaceAll(event.Kind, "_", " ")),
+		checkInAt.Format(time.RFC822),
+		checkOutAt.Format(time.RFC822),
+		event.Note,
+		event.ApprovalID)
+
+	key := emailKey(event.EventID)
+	err = sendOnce(ctx, h.ledger, key, func() error {
+		return h.emailClient.SendEmail(ctx, key, event.ManagerID, subject, body)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
+
+// HandleNoShow tells the manager that the employee missed a scheduled shift
+func (h *EmailNotifier) HandleNoShow(ctx context.Context, eventData []byte) error {
+	var event events.EmployeeNoShowEvent
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	if event.ManagerID == "" {
+		return nil
+	}
+	allowed, err := h.allowed(ctx, event.ManagerID)
+	if err != nil || !allowed {
+		return err
+	}
+
+	start := entities.InTimeZone(event.ScheduledStart, event.TimeZone)
+	end := entities.InTimeZone(event.ScheduledEnd, event.TimeZone)
+
+	subject := "Scheduled Shift Missed"
+	body := fmt.Sprintf(`
+		Hello,
+		
+		Employee %s did not check in for their shift on %s.
+		
+		Scheduled: %s - %s
+		Location: %s
+		
+		Exception ID: %s
+	`, event.EmployeeID,
+		event.BusinessDate,
+		start.Format(time.RFC822),
+		end.Format(time.RFC822),
+		event.LocationID,
+		event.ExceptionID)
+
+	key := emailKey(event.EventID)
+	err = sendOnce(ctx, h.ledger, key, func() error {
+		return h.emailClient.SendEmail(ctx, key, event.ManagerID, subject, body)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
+
+// HandleApprovalDecided tells the employee how their request was decided
+func (h *EmailNotifier) HandleApprovalDecided(ctx context.Context, eventData []byte) error {
+	var event events.ApprovalDecidedEvent
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	allowed, err := h.allowed(ctx, event.EmployeeID)
+	if err != nil || !allowed {
+		return err
+	}
+
+	subject := "Your Approval Request: " + event.Status
+	body := fmt.Sprintf(`
+		Hello,
+		
+		Your request %s was %s by %s.
+		
+		Comment: %s
+		Hours worked: %.2f
+		
+		Thank you!
+	`, event.ApprovalID,
+		strings.ToLower(event.Status),
+		event.DecidedBy,
+		event.Comment,
+		event.HoursWorked)
+
+	key := emailKey(event.EventID)
+	err = sendOnce(ctx, h.ledger, key, func() error {
+		return h.emailClient.SendEmail(ctx, key, event.EmployeeID, subject, body)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
+
+// HandleRecordCorrected shows the employee their record before and after a correction
+func (h *EmailNotifier) HandleRecordCorrected(ctx context.Context, eventData []byte) error {
+	var event events.TimeRecordCorrectedEvent
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	allowed, err := h.allowed(ctx, event.EmployeeID)
+	if err != nil || !allowed {
+		return err
+	}
+
+	subject := "Your Time Record Was Corrected"
+	body := fmt.Sprintf(`
+		Hello,
+		
+		Your time record %s was corrected by %s.
+		
+		Before: %s - %s (%.2f hours)
+		After: %s - %s (%.2f hours)
+		Reason: %s
+		
+		Thank you!
+	`, event.RecordID,
+		event.Actor,
+		formatRecordTime(&event.Before.CheckInAt, event.TimeZone),
+		formatRecordTime(event.Before.CheckOutAt, event.TimeZone),
+		event.Before.HoursWorked,
+		formatRecordTime(&event.After.CheckInAt, event.TimeZone),
+		formatRecordTime(event.After.CheckOutAt, event.TimeZone),
+		event.After.HoursWorked,
+		event.Reason)
+
+	key := emailKey(event.EventID)
+	err = sendOnce(ctx, h.ledger, key, func() error {
+		return h.emailClient.SendEmail(ctx, key, event.EmployeeID, subject, body)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
+
+// HandleRecordVoided tells the employee a record no longer counts
+func (h *EmailNotifier) HandleRecordVoided(ctx context.Context, eventData []byte) error {
+	var event events.TimeRecordVoidedEvent
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	allowed, err := h.allowed(ctx, event.EmployeeID)
+	if err != nil || !allowed {
+		return err
+	}
+
+	subject := "Your Time Record Was Voided"
 	body := fmt.Sprintf(`
 		Hello,
 		
-		You have successfully checked out.
+		Your time record %s was voided by %s and no longer counts towards your hours.
 		
 		Check-in time: %s
 		Check-out time: %s
 		Hours worked: %.2f
+		Reason: %s
 		
 		Thank you!
-	`, event.CheckInAt.Format(time.RFC822),
-		event.CheckOutAt.Format(time.RFC822),
-		event.HoursWorked)
+	`, event.RecordID,
+		event.Actor,
+		formatRecordTime(&event.Before.CheckInAt, event.TimeZone),
+		formatRecordTime(event.Before.CheckOutAt, event.TimeZone),
+		event.Before.HoursWorked,
+		event.Reason)
 
-	err := h.emailClient.SendEmail(ctx, event.EmployeeID, subject, body)
+	key := emailKey(event.EventID)
+	err = sendOnce(ctx, h.ledger, key, func() error {
+		return h.emailClient.SendEmail(ctx, key, event.EmployeeID, subject, body)
+	})
 	if err != nil {
 		return fmt.Errorf("failed to send email: %w", err)
 	}
 
 	return nil
 }
+
+// formatRecordTime shows t on the employee's clock, or "open" for a record
+// that was not checked out
+func formatRecordTime(t *time.Time, timeZone string) string {
+	if t == nil || t.IsZero() {
+		return "open"
+	}
+	return entities.InTimeZone(*t, timeZone).Format(time.RFC822)
+}
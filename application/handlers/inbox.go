@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/leo-andrei/check-in-service/domain/events"
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"github.com/leo-andrei/check-in-service/infrastructure/metrics"
+	"go.uber.org/zap"
+)
+
+// Inbox remembers the events each consumer has processed, across worker
+// instances. persistence.PostgresProcessedEventRepository implements it.
+type Inbox interface {
+	ProcessOnce(ctx context.Context, consumer, eventID string, fn func(ctx context.Context) error) (bool, error)
+}
+
+// ProcessOnce wraps a consumer's handler so an event redelivered by RabbitMQ
+// (after a crash, a lost ack or a requeue) is acknowledged without running
+// the handler again. Events without an ID are handled every time.
+func ProcessOnce(inbox Inbox, consumer string, handle func(ctx context.Context, eventData []byte) error) func(ctx context.Context, eventData []byte) error {
+	return func(ctx context.Context, eventData []byte) error {
+		var header events.EventHeader
+		if err := json.Unmarshal(eventData, &header); err != nil || header.EventID == "" {
+			return handle(ctx, eventData)
+		}
+
+		ran, err := inbox.ProcessOnce(ctx, consumer, header.EventID, func(ctx context.Context) error {
+			return handle(ctx, eventData)
+		})
+		if err != nil {
+			return err
+		}
+		if !ran {
+			metrics.Incr("consumer."+consumer+".duplicates", 1)
+			config.Logger.Info("Skipping event already processed",
+				zap.String("consumer", consumer),
+				zap.String("event_id", header.EventID),
+				zap.String("event_type", header.EventType),
+			)
+		}
+		return nil
+	}
+}
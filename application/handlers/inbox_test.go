@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/persistence"
+)
+
+func TestProcessOnce(t *testing.T) {
+	ctx := context.Background()
+	event := []byte(`{"event_id":"evt-1","event_type":"employee.checked_out"}`)
+
+	t.Run("failed event is handled again", func(t *testing.T) {
+		var calls atomic.Int32
+		handle := ProcessOnce(persistence.NewMemoryProcessedEventRepository(), "email", func(ctx context.Context, eventData []byte) error {
+			if calls.Add(1) == 1 {
+				return errors.New("smtp down")
+			}
+			return nil
+		})
+
+		if err := handle(ctx, event); err == nil {
+			t.Fatal("first delivery succeeded, want the handler's error")
+		}
+		for i := 0; i < 2; i++ {
+			if err := handle(ctx, event); err != nil {
+				t.Fatalf("redelivery %d: %v", i+1, err)
+			}
+		}
+		if n := calls.Load(); n != 2 {
+			t.Fatalf("handler ran %d times, want 2", n)
+		}
+	})
+
+	t.Run("concurrent delivery waits and is skipped", func(t *testing.T) {
+		var calls atomic.Int32
+		started := make(chan struct{})
+		release := make(chan struct{})
+		handle := ProcessOnce(persistence.NewMemoryProcessedEventRepository(), "email", func(ctx context.Context, eventData []byte) error {
+			calls.Add(1)
+			close(started)
+			<-release
+			return nil
+		})
+
+		first := make(chan error, 1)
+		go func() { first <- handle(ctx, event) }()
+		<-started
+
+		second := make(chan error, 1)
+		go func() { second <- handle(ctx, event) }()
+		select {
+		case err := <-second:
+			t.Fatalf("second delivery returned %v while the first was running", err)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		close(release)
+		for _, done := range []chan error{first, second} {
+			if err := <-done; err != nil {
+				t.Fatal(err)
+			}
+		}
+		if n := calls.Load(); n != 1 {
+			t.Fatalf("handler ran %d times, want 1", n)
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+
+	"github.com/leo-andrei/check-in-service/domain/errors"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"github.com/leo-andrei/check-in-service/infrastructure/external"
+	"github.com/leo-andrei/check-in-service/infrastructure/metrics"
+	"github.com/leo-andrei/check-in-service/infrastructure/resilience"
+	"go.uber.org/zap"
+)
+
+// Breaker tells whether the circuit breaker guarding a backend lets calls
+// through; resilience.CircuitBreaker implements it
+type Breaker interface {
+	Ready() bool
+}
+
+// LaborCostDrainer submits the entries LaborCostReporter parked while the
+// circuit was open, once the breaker lets calls through again
+type LaborCostDrainer struct {
+	parked    repositories.ParkedLaborCostRepository
+	client    LaborCostClient
+	breaker   Breaker
+	batchSize int
+}
+
+func NewLaborCostDrainer(parked repositories.ParkedLaborCostRepository, client LaborCostClient, breaker Breaker, batchSize int) *LaborCostDrainer {
+	return &LaborCostDrainer{
+		parked:    parked,
+		client:    client,
+		breaker:   breaker,
+		batchSize: batchSize,
+	}
+}
+
+// Drain submits up to a batch of parked entries, oldest first, and returns
+// how many went through. It does nothing while the circuit is open; once the
+// breaker's timeout passed, the first entry is the trial call that closes it
+// again, so parked entries drain without other traffic. It stops at the
+// first entry that fails for a reason other than the entry itself,
+// since the ones after it would fail the same way. Entries the backend
+// refuses are marked failed and left for an operator.
+func (d *LaborCostDrainer) Drain(ctx context.Context) (int, error) {
+	if !d.breaker.Ready() {
+		return 0, nil
+	}
+
+	entries, err := d.parked.ListParked(ctx, d.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	submitted := 0
+	for _, parked := range entries {
+		var req external.LaborCostRequest
+		if err := json.Unmarshal(parked.Entry, &req); err != nil {
+			d.fail(ctx, parked.ID, fmt.Errorf("failed to unmarshal parked entry: %w", err))
+			continue
+		}
+
+		err := d.client.RecordLaborCost(ctx, req)
+		switch {
+		case err == nil:
+			if err := d.parked.DeleteParked(ctx, parked.ID); err != nil {
+				return submitted, err
+			}
+			submitted++
+			metrics.Incr("labor_cost.unparked", 1)
+		case errors.IsPermanent(err):
+			d.fail(ctx, parked.ID, err)
+		case stderrors.Is(err, resilience.ErrOpen):
+			// The circuit opened again; the next pass picks up from here
+			return submitted, nil
+		default:
+			if recordErr := d.parked.RecordParkedAttempt(ctx, parked.ID, err.Error()); recordErr != nil {
+				config.Logger.Error("Failed to record parked labor cost attempt", zap.Int64("id", parked.ID), zap.Error(recordErr))
+			}
+			return submitted, fmt.Errorf("failed to submit parked labor cost entry %d: %w", parked.ID, err)
+		}
+	}
+	return submitted, nil
+}
+
+func (d *LaborCostDrainer) fail(ctx context.Context, id int64, cause error) {
+	metrics.Incr("labor_cost.parked_failed", 1)
+	config.Logger.Warn("Parked labor cost entry refused, kept for review", zap.Int64("id", id), zap.Error(cause))
+	if err := d.parked.MarkParkedFailed(ctx, id, cause.Error()); err != nil {
+		config.Logger.Error("Failed to mark parked labor cost entry failed", zap.Int64("id", id), zap.Error(err))
+	}
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/infrastructure/external"
+	"github.com/leo-andrei/check-in-service/infrastructure/resilience"
+)
+
+// parkingLot is an in-memory ParkedLaborCostRepository
+type parkingLot struct {
+	mu      sync.Mutex
+	nextID  int64
+	entries map[int64]*entities.ParkedLaborCost
+	failed  map[int64]bool
+}
+
+func newParkingLot() *parkingLot {
+	return &parkingLot{entries: make(map[int64]*entities.ParkedLaborCost), failed: make(map[int64]bool)}
+}
+
+func (p *parkingLot) Park(ctx context.Context, parked *entities.ParkedLaborCost) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.nextID++
+	parked.ID = p.nextID
+	p.entries[parked.ID] = parked
+	return nil
+}
+
+func (p *parkingLot) ListParked(ctx context.Context, limit int) ([]*entities.ParkedLaborCost, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var list []*entities.ParkedLaborCost
+	for id, parked := range p.entries {
+		if !p.failed[id] {
+			list = append(list, parked)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
+func (p *parkingLot) DeleteParked(ctx context.Context, id int64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.entries, id)
+	return nil
+}
+
+func (p *parkingLot) RecordParkedAttempt(ctx context.Context, id int64, lastError string) error {
+	return nil
+}
+
+func (p *parkingLot) MarkParkedFailed(ctx context.Context, id int64, lastError string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failed[id] = true
+	return nil
+}
+
+func (p *parkingLot) CountParked(ctx context.Context) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.entries) - len(p.failed), nil
+}
+
+// breakerClient sends entries through the breaker, like the legacy client,
+// to a backend answering with err
+type breakerClient struct {
+	breaker *resilience.CircuitBreaker
+	err     error
+	sent    []external.LaborCostRequest
+}
+
+func (c *breakerClient) RecordLaborCost(ctx context.Context, req external.LaborCostRequest) error {
+	return c.breaker.Execute(ctx, func(ctx context.Context) error {
+		if c.err == nil {
+			c.sent = append(c.sent, req)
+		}
+		return c.err
+	})
+}
+
+func TestLaborCostDrainerRecovers(t *testing.T) {
+	tests := []struct {
+		name          string
+		elapsed       time.Duration
+		backendErr    error
+		wantSubmitted int
+		wantParked    int
+		wantState     resilience.CircuitState
+	}{
+		{name: "circuit still open", elapsed: 10 * time.Second, wantParked: 2, wantState: resilience.StateOpen},
+		{name: "backend recovered", elapsed: 31 * time.Second, wantSubmitted: 2, wantState: resilience.StateClosed},
+		{name: "backend still down", elapsed: 31 * time.Second, backendErr: errors.New("503"), wantParked: 2, wantState: resilience.StateOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+			breaker := resilience.NewCircuitBreaker("legacy-api", 1, 1, 30*time.Second).
+				WithClock(func() time.Time { return now })
+
+			// The backend fails once, which opens the circuit
+			client := &breakerClient{breaker: breaker, err: errors.New("503")}
+			_ = client.RecordLaborCost(ctx, external.LaborCostRequest{EmployeeID: "EMP001"})
+			if state := breaker.GetState(); state != resilience.StateOpen {
+				t.Fatalf("breaker is %s, want %s", state, resilience.StateOpen)
+			}
+
+			lot := newParkingLot()
+			for _, employeeID := range []string{"EMP001", "EMP002"} {
+				entry, _ := json.Marshal(external.LaborCostRequest{EmployeeID: employeeID, HoursWorked: 8})
+				if err := lot.Park(ctx, &entities.ParkedLaborCost{Entry: entry, ParkedAt: now}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// No other labor cost traffic: only the drainer calls the backend
+			now = now.Add(tt.elapsed)
+			client.err = tt.backendErr
+			drainer := NewLaborCostDrainer(lot, client, breaker, 10)
+			submitted, err := drainer.Drain(ctx)
+			if (err != nil) != (tt.backendErr != nil) {
+				t.Fatalf("Drain() error = %v", err)
+			}
+
+			if submitted != tt.wantSubmitted || len(client.sent) != tt.wantSubmitted {
+				t.Fatalf("submitted %d entries (%d sent), want %d", submitted, len(client.sent), tt.wantSubmitted)
+			}
+			if parked, _ := lot.CountParked(ctx); parked != tt.wantParked {
+				t.Fatalf("%d entries still parked, want %d", parked, tt.wantParked)
+			}
+			if state := breaker.GetState(); state != tt.wantState {
+				t.Fatalf("breaker is %s, want %s", state, tt.wantState)
+			}
+		})
+	}
+}
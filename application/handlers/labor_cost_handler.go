@@ -3,16 +3,38 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	stderrors "errors"
 	"fmt"
+	"strconv"
 	"time"
 
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
 	"github.com/leo-andrei/check-in-service/domain/events"
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
 	"github.com/leo-andrei/check-in-service/infrastructure/external"
+	"github.com/leo-andrei/check-in-service/infrastructure/metrics"
+	"github.com/leo-andrei/check-in-service/infrastructure/resilience"
+	"go.uber.org/zap"
 )
 
+// LaborCostClient records labor cost entries in the payroll system: the
+// legacy API, or another backend where there is none
+type LaborCostClient interface {
+	RecordLaborCost(ctx context.Context, req external.LaborCostRequest) error
+}
+
+// ParkingLot sets aside the entries that cannot be sent while the circuit to
+// the backend is open. persistence.PostgresParkedLaborCostRepository
+// implements it.
+type ParkingLot interface {
+	Park(ctx context.Context, parked *entities.ParkedLaborCost) error
+}
+
 type LaborCostReporter struct {
-	legacyClient *external.LegacyLaborCostClient
-	retryConfig  RetryConfig
+	client      LaborCostClient
+	retryConfig RetryConfig
+	parking     ParkingLot
 }
 
 type RetryConfig struct {
@@ -22,9 +44,9 @@ type RetryConfig struct {
 	BackoffMultiplier float64
 }
 
-func NewLaborCostReporter(client *external.LegacyLaborCostClient) *LaborCostReporter {
+func NewLaborCostReporter(client LaborCostClient) *LaborCostReporter {
 	return &LaborCostReporter{
-		legacyClient: client,
+		client: client,
 		retryConfig: RetryConfig{
 			MaxAttempts:       5,
 			InitialBackoff:    1 * time.Second,
@@ -34,31 +56,275 @@ func NewLaborCostReporter(client *external.LegacyLaborCostClient) *LaborCostRepo
 	}
 }
 
+// WithParkingLot parks the entries the circuit breaker rejects, so their
+// message is acknowledged instead of requeued again and again while the
+// backend is down. LaborCostDrainer submits them once the circuit closes.
+func (h *LaborCostReporter) WithParkingLot(parking ParkingLot) *LaborCostReporter {
+	h.parking = parking
+	return h
+}
+
+// Handle dispatches events from the shared exchange; unrelated events are acknowledged
+func (h *LaborCostReporter) Handle(ctx context.Context, eventData []byte) error {
+	eventType, err := events.TypeOf(eventData)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	switch eventType {
+	case events.EventTypeEmployeeCheckedOut:
+		return h.HandleCheckedOut(ctx, eventData)
+	case events.EventTypeEmployeeOvertimeDetected:
+		return h.HandleOvertimeDetected(ctx, eventData)
+	case events.EventTypeTimeRecordCorrected:
+		return h.HandleRecordCorrected(ctx, eventData)
+	case events.EventTypeTimeRecordVoided:
+		return h.HandleRecordVoided(ctx, eventData)
+	default:
+		return nil
+	}
+}
+
+// HandleCheckedOut reports the regular hours of a check-out. Overtime hours
+// are reported separately by HandleOvertimeDetected.
 func (h *LaborCostReporter) HandleCheckedOut(ctx context.Context, eventData []byte) error {
 	var event events.EmployeeCheckedOutEvent
 	if err := json.Unmarshal(eventData, &event); err != nil {
 		return fmt.Errorf("failed to unmarshal event: %w", err)
 	}
 
+	req := external.LaborCostRequest{
+		EmployeeID:     event.EmployeeID,
+		HoursWorked:    event.ReportableRegularHours(),
+		RecordedAt:     entities.InTimeZone(event.CheckOutAt, event.TimeZone).Format(time.RFC3339),
+		RecordID:       event.RecordID,
+		HourType:       external.HourTypeRegular,
+		ProjectCode:    event.ProjectCode,
+		IdempotencyKey: laborCostKey(event.EventID, event.RecordID, external.HourTypeRegular, 0),
+		// Premium classification covers the whole record, so it rides on the
+		// regular report only
+		HolidayHours: event.HolidayHours,
+		WeekendHours: event.WeekendHours,
+	}
+	if cost := event.LaborCost; cost != nil {
+		req.Cost, req.Currency, req.HourlyRate = cost.Regular, cost.Currency, cost.HourlyRate
+	}
+	return h.report(ctx, req)
+}
+
+// HandleOvertimeDetected reports the overtime part of a check-out
+func (h *LaborCostReporter) HandleOvertimeDetected(ctx context.Context, eventData []byte) error {
+	var event events.EmployeeOvertimeDetectedEvent
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	req := external.LaborCostRequest{
+		EmployeeID:     event.EmployeeID,
+		HoursWorked:    event.OvertimeHours,
+		RecordedAt:     entities.InTimeZone(event.CheckOutAt, event.TimeZone).Format(time.RFC3339),
+		RecordID:       event.RecordID,
+		HourType:       external.HourTypeOvertime,
+		ProjectCode:    event.ProjectCode,
+		IdempotencyKey: laborCostKey(event.EventID, event.RecordID, external.HourTypeOvertime, 0),
+	}
+	if cost := event.LaborCost; cost != nil {
+		req.Cost, req.Currency, req.HourlyRate = cost.Overtime, cost.Currency, cost.HourlyRate
+	}
+	return h.report(ctx, req)
+}
+
+// HandleRecordCorrected reports the difference between the corrected values
+// and what was reported before. Records that were still open when corrected
+// had reported nothing, so their new values are reported in full.
+func (h *LaborCostReporter) HandleRecordCorrected(ctx context.Context, eventData []byte) error {
+	var event events.TimeRecordCorrectedEvent
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	recordedAt := entities.InTimeZone(event.Timestamp, event.TimeZone).Format(time.RFC3339)
+	return h.compensate(ctx, event.EventID, event.EmployeeID, event.RecordID, event.ProjectCode, recordedAt, external.AdjustmentCorrection, event.Before, event.After)
+}
+
+// HandleRecordVoided reverses the hours and cost reported for a voided record
+func (h *LaborCostReporter) HandleRecordVoided(ctx context.Context, eventData []byte) error {
+	var event events.TimeRecordVoidedEvent
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	recordedAt := entities.InTimeZone(event.Timestamp, event.TimeZone).Format(time.RFC3339)
+	return h.compensate(ctx, event.EventID, event.EmployeeID, event.RecordID, event.ProjectCode, recordedAt, external.AdjustmentVoid, event.Before, events.RecordValues{})
+}
+
+// compensate sends one adjustment per hour type whose hours or cost changed
+// between the reported values (before) and the new ones (after)
+func (h *LaborCostReporter) compensate(ctx context.Context, eventID, employeeID, recordID, projectCode, recordedAt, adjustment string, before, after events.RecordValues) error {
+	var reported, current events.RecordValues
+	if before.Reported() {
+		reported = before
+	}
+	if after.Reported() {
+		current = after
+	}
+
+	parts := []struct {
+		hourType              string
+		reportedHours, hours  float64
+		reportedCost, newCost string
+	}{
+		{external.HourTypeRegular, reported.ReportableRegularHours(), current.ReportableRegularHours(), "", ""},
+		{external.HourTypeOvertime, reported.OvertimeHours, current.OvertimeHours, "", ""},
+	}
+	if cost := reported.LaborCost; cost != nil {
+		parts[0].reportedCost, parts[1].reportedCost = cost.Regular, cost.Overtime
+	}
+	if cost := current.LaborCost; cost != nil {
+		parts[0].newCost, parts[1].newCost = cost.Regular, cost.Overtime
+	}
+
+	for _, part := range parts {
+		deltas, err := laborCostDeltas(reported.LaborCost, current.LaborCost, part.reportedCost, part.newCost)
+		if err != nil {
+			return err
+		}
+
+		hoursDelta := part.hours - part.reportedHours
+		if len(deltas) == 0 && hoursDelta != 0 {
+			deltas = append(deltas, costDelta{})
+		}
+		for i, delta := range deltas {
+			req := external.LaborCostRequest{
+				EmployeeID:  employeeID,
+				HoursWorked: hoursDelta,
+				RecordedAt:  recordedAt,
+				RecordID:    recordID,
+				HourType:    part.hourType,
+				ProjectCode: projectCode,
+				Adjustment:  adjustment,
+				Cost:        delta.amount,
+				Currency:    delta.currency,
+			}
+			// Adjustments of one record differ by event, so they have no key
+			// without an event ID
+			if eventID != "" {
+				req.IdempotencyKey = laborCostKey(eventID, recordID, part.hourType, i)
+			}
+			// A change of currency reverses the old cost and adds the new one;
+			// the hours travel with the reversal
+			if len(deltas) == 2 {
+				req.HoursWorked = -part.reportedHours
+				if i == 1 {
+					req.HoursWorked = part.hours
+				}
+			}
+			if err := h.report(ctx, req); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// laborCostKey is the idempotency key of the part-th entry of an hour type
+// reported for an event, the same on every redelivery. Events from before
+// event IDs fall back to the record, which reports each hour type once.
+func laborCostKey(eventID, recordID, hourType string, part int) string {
+	key := "labor-cost-" + eventID
+	if eventID == "" {
+		if recordID == "" {
+			return ""
+		}
+		key = "labor-cost-record-" + recordID
+	}
+	key += "-" + hourType
+	if part > 0 {
+		key += "-" + strconv.Itoa(part)
+	}
+	return key
+}
+
+// costDelta is a signed amount of money in major units of currency
+type costDelta struct {
+	amount   string
+	currency string
+}
+
+// laborCostDeltas returns the cost adjustments from the reported to the new
+// amount: none when neither is priced or nothing changed, one when the
+// currency is the same, and a reversal plus a new amount when it changed
+func laborCostDeltas(reported, current *events.LaborCost, reportedAmount, newAmount string) ([]costDelta, error) {
+	if reported == nil && current == nil {
+		return nil, nil
+	}
+
+	var before, after entities.Money
+	var err error
+	if reported != nil {
+		if before, err = entities.ParseMoney(reportedAmount, reported.Currency); err != nil {
+			return nil, err
+		}
+	}
+	if current != nil {
+		if after, err = entities.ParseMoney(newAmount, current.Currency); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case reported == nil:
+		return []costDelta{{after.String(), after.Currency}}, nil
+	case current == nil:
+		reversal := entities.Money{Currency: before.Currency}.Sub(before)
+		return []costDelta{{reversal.String(), reversal.Currency}}, nil
+	case before.Currency != after.Currency:
+		reversal := entities.Money{Currency: before.Currency}.Sub(before)
+		return []costDelta{{reversal.String(), reversal.Currency}, {after.String(), after.Currency}}, nil
+	}
+
+	delta := after.Sub(before)
+	if delta.Amount == 0 {
+		return nil, nil
+	}
+	return []costDelta{{delta.String(), delta.Currency}}, nil
+}
+
+func (h *LaborCostReporter) report(ctx context.Context, req external.LaborCostRequest) error {
 	// Retry logic with exponential backoff
 	attempt := 0
 	backoff := h.retryConfig.InitialBackoff
 
 	for attempt < h.retryConfig.MaxAttempts {
-		err := h.legacyClient.RecordLaborCost(ctx, event.EmployeeID, event.HoursWorked)
+		err := h.client.RecordLaborCost(ctx, req)
 		if err == nil {
 			return nil
 		}
+		if h.parking != nil && stderrors.Is(err, resilience.ErrOpen) {
+			return h.park(ctx, req, err)
+		}
+		// A request the backend refused as invalid fails the same way again;
+		// the consumer sends the message to the DLQ at once
+		if errors.IsPermanent(err) {
+			return fmt.Errorf("labor cost rejected: %w", err)
+		}
 
 		attempt++
 		if attempt >= h.retryConfig.MaxAttempts {
 			return fmt.Errorf("failed after %d attempts: %w", attempt, err)
 		}
 
-		fmt.Printf("Retry %d/%d for employee %s after error: %v\n",
-			attempt, h.retryConfig.MaxAttempts, event.EmployeeID, err)
+		// The backend may ask for a longer wait, e.g. with 429 or 503
+		delay := max(backoff, external.RetryAfter(err))
+		config.Logger.Warn("Retrying labor cost report",
+			zap.Int("attempt", attempt), zap.Int("max_attempts", h.retryConfig.MaxAttempts),
+			zap.String("employee_id", req.EmployeeID), zap.Duration("delay", delay), zap.Error(err))
 
-		time.Sleep(backoff)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("gave up after %d attempts: %w", attempt, err)
+		case <-time.After(delay):
+		}
 		backoff = time.Duration(float64(backoff) * h.retryConfig.BackoffMultiplier)
 		if backoff > h.retryConfig.MaxBackoff {
 			backoff = h.retryConfig.MaxBackoff
@@ -67,3 +333,23 @@ func (h *LaborCostReporter) HandleCheckedOut(ctx context.Context, eventData []by
 
 	return fmt.Errorf("max retries exceeded")
 }
+
+func (h *LaborCostReporter) park(ctx context.Context, req external.LaborCostRequest, cause error) error {
+	entry, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal labor cost entry: %w", err)
+	}
+	parked := &entities.ParkedLaborCost{
+		IdempotencyKey: req.IdempotencyKey,
+		Entry:          entry,
+		Reason:         cause.Error(),
+	}
+	if err := h.parking.Park(ctx, parked); err != nil {
+		return fmt.Errorf("%w (and %w)", cause, err)
+	}
+
+	metrics.Incr("labor_cost.parked", 1)
+	config.Logger.Info("Labor cost entry parked until the circuit closes",
+		zap.String("employee_id", req.EmployeeID), zap.String("idempotency_key", req.IdempotencyKey), zap.Error(cause))
+	return nil
+}
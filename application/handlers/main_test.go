@@ -0,0 +1,12 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/config/configtest"
+)
+
+// TestMain gives the handlers the default configuration and a silent logger
+func TestMain(m *testing.M) {
+	configtest.Main(m)
+}
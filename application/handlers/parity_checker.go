@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/events"
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"github.com/leo-andrei/check-in-service/infrastructure/metrics"
+	"go.uber.org/zap"
+)
+
+// maxParitySamples is how many recent mismatches the report keeps
+const maxParitySamples = 50
+
+// ParityMismatch is one field whose value differs between the two versions
+type ParityMismatch struct {
+	EventID   string
+	EventType string
+	Field     string // Path in the current version
+	NextField string // Path in the next version, empty when the field is not mapped
+	Current   interface{}
+	Next      interface{}
+	At        time.Time
+}
+
+// ParityReport summarizes the comparisons since the checker started
+type ParityReport struct {
+	Since      time.Time
+	Compared   int
+	Matched    int
+	Mismatched int
+	// Mismatch count per event type and field, e.g. "EmployeeCheckedOut.hours_worked"
+	Fields  map[string]int
+	Samples []ParityMismatch // Most recent first
+}
+
+// ParityChecker consumes shadow envelopes and checks that every field of the
+// current event version carries the same value in the next version. The
+// report is kept in memory per instance.
+type ParityChecker struct {
+	mu     sync.Mutex
+	report ParityReport
+}
+
+func NewParityChecker() *ParityChecker {
+	return &ParityChecker{
+		report: ParityReport{
+			Since:  time.Now().UTC(),
+			Fields: make(map[string]int),
+		},
+	}
+}
+
+func (c *ParityChecker) Handle(ctx context.Context, eventData []byte) error {
+	var envelope events.ShadowEnvelope
+	if err := json.Unmarshal(eventData, &envelope); err != nil {
+		return fmt.Errorf("failed to unmarshal shadow envelope: %w", err)
+	}
+
+	var current, next interface{}
+	if err := json.Unmarshal(envelope.Current, &current); err != nil {
+		return fmt.Errorf("failed to unmarshal current version: %w", err)
+	}
+	if err := json.Unmarshal(envelope.Next, &next); err != nil {
+		return fmt.Errorf("failed to unmarshal next version: %w", err)
+	}
+
+	mismatches := compareVersions(envelope, events.ShadowFieldMap(envelope.EventType), flatten(current), flatten(next))
+	c.record(envelope, mismatches)
+
+	if len(mismatches) > 0 {
+		metrics.Incr("shadow.parity.mismatched", 1)
+		config.Logger.Warn("Shadow event differs from the current version",
+			zap.String("event_id", envelope.EventID), zap.String("type", envelope.EventType), zap.Int("fields", len(mismatches)))
+	} else {
+		metrics.Incr("shadow.parity.matched", 1)
+	}
+	return nil
+}
+
+// Report returns a copy of the report
+func (c *ParityChecker) Report() ParityReport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	report := c.report
+	report.Fields = make(map[string]int, len(c.report.Fields))
+	for field, count := range c.report.Fields {
+		report.Fields[field] = count
+	}
+	report.Samples = append([]ParityMismatch(nil), c.report.Samples...)
+	return report
+}
+
+func (c *ParityChecker) record(envelope events.ShadowEnvelope, mismatches []ParityMismatch) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.report.Compared++
+	if len(mismatches) == 0 {
+		c.report.Matched++
+		return
+	}
+
+	c.report.Mismatched++
+	for _, m := range mismatches {
+		c.report.Fields[envelope.EventType+"."+m.Field]++
+	}
+	c.report.Samples = append(mismatches, c.report.Samples...)
+	if len(c.report.Samples) > maxParitySamples {
+		c.report.Samples = c.report.Samples[:maxParitySamples]
+	}
+}
+
+// compareVersions checks every leaf of the current version against the path
+// it was mapped to in the next one. Fields only present in the next version
+// are new and not compared.
+func compareVersions(envelope events.ShadowEnvelope, fields map[string]string, current, next map[string]interface{}) []ParityMismatch {
+	var mismatches []ParityMismatch
+	now := time.Now().UTC()
+
+	for path, value := range current {
+		top, rest, _ := strings.Cut(path, ".")
+		if top == "version" {
+			continue
+		}
+
+		mapped, ok := fields[top]
+		if !ok {
+			mismatches = append(mismatches, ParityMismatch{EventID: envelope.EventID, EventType: envelope.EventType, Field: path, Current: value, At: now})
+			continue
+		}
+		nextPath := mapped
+		if rest != "" {
+			nextPath += "." + rest
+		}
+
+		nextValue, ok := next[nextPath]
+		if !ok || !reflect.DeepEqual(value, nextValue) {
+			mismatches = append(mismatches, ParityMismatch{EventID: envelope.EventID, EventType: envelope.EventType, Field: path, NextField: nextPath, Current: value, Next: nextValue, At: now})
+		}
+	}
+
+	return mismatches
+}
+
+// flatten turns decoded JSON into leaf paths, e.g. "day_segments.0.hours"
+func flatten(value interface{}) map[string]interface{} {
+	leaves := make(map[string]interface{})
+
+	var walk func(prefix string, v interface{})
+	walk = func(prefix string, v interface{}) {
+		join := func(key string) string {
+			if prefix == "" {
+				return key
+			}
+			return prefix + "." + key
+		}
+
+		switch t := v.(type) {
+		case map[string]interface{}:
+			if len(t) == 0 && prefix != "" {
+				leaves[prefix] = t
+			}
+			for key, child := range t {
+				walk(join(key), child)
+			}
+		case []interface{}:
+			if len(t) == 0 {
+				leaves[prefix] = t
+			}
+			for i, child := range t {
+				walk(join(strconv.Itoa(i)), child)
+			}
+		default:
+			leaves[prefix] = t
+		}
+	}
+	walk("", value)
+
+	return leaves
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/events"
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"github.com/leo-andrei/check-in-service/infrastructure/external"
+	"go.uber.org/zap"
+)
+
+// ReminderNotifier asks employees who missed their check-out to confirm when
+// they stopped working. The reply is read by the inbound email webhook.
+type ReminderNotifier struct {
+	emailClient *external.EmailClient
+	consents    ConsentChecker
+	ledger      SentLedger
+}
+
+func NewReminderNotifier(client *external.EmailClient, consents ConsentChecker, ledger SentLedger) *ReminderNotifier {
+	return &ReminderNotifier{
+		emailClient: client,
+		consents:    consents,
+		ledger:      ledger,
+	}
+}
+
+// Handle sends a reminder for missed check-outs; everything else is acknowledged
+func (h *ReminderNotifier) Handle(ctx context.Context, eventData []byte) error {
+	eventType, err := events.TypeOf(eventData)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	if eventType == events.EventTypeEmployeeMissedCheckout {
+		return h.HandleMissedCheckout(ctx, eventData)
+	}
+	return nil
+}
+
+func (h *ReminderNotifier) HandleMissedCheckout(ctx context.Context, eventData []byte) error {
+	var event events.EmployeeMissedCheckoutEvent
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	allowed, err := notificationsAllowed(ctx, h.consents, event.EmployeeID)
+	if err != nil || !allowed {
+		return err
+	}
+
+	subject := "Still checked in?"
+	body := fmt.Sprintf(`
+		Hello,
+		
+		You checked in at %s and have not checked out yet.
+		
+		If you already stopped working, reply to this email with the time you
+		left, e.g. "CHECKOUT 17:30", or with "STOP" if you stopped just now.
+		Your manager will be asked to approve the check-out.
+		
+		Record ID: %s
+	`, formatRecordTime(&event.CheckInAt, event.TimeZone),
+		event.RecordID)
+
+	key := emailKey(event.EventID)
+	err = sendOnce(ctx, h.ledger, key, func() error {
+		return h.emailClient.SendEmail(ctx, key, event.EmployeeID, subject, body)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	config.Logger.Info("Missed check-out reminder sent", zap.String("employee_id", event.EmployeeID), zap.String("record_id", event.RecordID), zap.Duration("open_for", time.Since(event.CheckInAt)))
+	return nil
+}
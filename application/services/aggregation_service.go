@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/hours"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+)
+
+// Dimensions hours can be aggregated by
+const (
+	GroupByEmployee = "employee"
+	GroupByTeam     = "team" // Employees sharing a manager
+	GroupByLocation = "location"
+)
+
+// AggregationService totals hours across employees per week, month or pay
+// period, for managers and payroll. Weeks and months only count employees
+// with the analytics consent; pay periods are payroll's and count everyone.
+type AggregationService struct {
+	records    repositories.TimeRecordRepository
+	employees  repositories.EmployeeRepository
+	payPeriods *PayPeriodService
+	consents   *ConsentService
+}
+
+func NewAggregationService(records repositories.TimeRecordRepository, employees repositories.EmployeeRepository, payPeriods *PayPeriodService, consents *ConsentService) *AggregationService {
+	return &AggregationService{
+		records:    records,
+		employees:  employees,
+		payPeriods: payPeriods,
+		consents:   consents,
+	}
+}
+
+// HoursGroup is the hours of one employee, team or location in one period
+type HoursGroup struct {
+	Key         string // Employee ID, manager ID or location ID; empty for none
+	Period      string
+	PeriodStart time.Time
+	Employees   int
+	HoursTotals
+}
+
+// AverageShiftHours is the mean hours worked per completed record
+func (g HoursGroup) AverageShiftHours() float64 {
+	if g.Records == 0 {
+		return 0
+	}
+	return g.HoursWorked / float64(g.Records)
+}
+
+// Aggregate totals the records that checked in during [from, to) by groupBy
+// and by week, month or pay period in loc, ordered by period and key. A
+// non-empty source keeps only records checked in or out through it.
+func (s *AggregationService) Aggregate(ctx context.Context, groupBy, period string, from, to time.Time, loc *time.Location, weekStart time.Weekday, source entities.PunchSource) ([]HoursGroup, error) {
+	if groupBy != GroupByEmployee && groupBy != GroupByTeam && groupBy != GroupByLocation {
+		return nil, fmt.Errorf("unknown group %q: expected employee, team or location", groupBy)
+	}
+
+	// Pay periods are not calendar units the database can truncate to, so
+	// days are totalled and rolled up into pay periods here
+	bucket := period
+	if period == hours.PeriodPay {
+		bucket = hours.PeriodDay
+	}
+	aggregates, err := s.records.AggregateHours(ctx, from, to, loc.String(), bucket, weekStart, source)
+	if err != nil {
+		return nil, err
+	}
+
+	var managers map[string]string
+	if groupBy == GroupByTeam {
+		if managers, err = s.managers(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	type groupKey struct {
+		key         string
+		periodStart string
+	}
+	groups := make(map[groupKey]*HoursGroup)
+	members := make(map[groupKey]map[string]bool)
+	consented := make(map[string]bool)
+	for _, a := range aggregates {
+		if period != hours.PeriodPay {
+			allowed, checked := consented[a.EmployeeID]
+			if !checked {
+				if allowed, err = s.consents.HasConsent(ctx, a.EmployeeID, entities.PurposeAnalytics); err != nil {
+					return nil, fmt.Errorf("failed to check consent: %w", err)
+				}
+				consented[a.EmployeeID] = allowed
+			}
+			if !allowed {
+				continue
+			}
+		}
+
+		if period == hours.PeriodPay {
+			day, err := time.ParseInLocation(entities.BusinessDateLayout, a.PeriodStart, loc)
+			if err != nil {
+				return nil, fmt.Errorf("invalid period start %q: %w", a.PeriodStart, err)
+			}
+			start, _ := s.payPeriods.Bounds(day)
+			a.PeriodStart = start.Format(entities.BusinessDateLayout)
+		}
+
+		k := groupKey{periodStart: a.PeriodStart}
+		switch groupBy {
+		case GroupByEmployee:
+			k.key = a.EmployeeID
+		case GroupByTeam:
+			k.key = managers[a.EmployeeID]
+		case GroupByLocation:
+			k.key = a.LocationID
+		}
+
+		group, ok := groups[k]
+		if !ok {
+			start, err := time.ParseInLocation(entities.BusinessDateLayout, a.PeriodStart, loc)
+			if err != nil {
+				return nil, fmt.Errorf("invalid period start %q: %w", a.PeriodStart, err)
+			}
+			group = &HoursGroup{Key: k.key, Period: hours.PeriodLabel(period, start), PeriodStart: start}
+			groups[k] = group
+			members[k] = make(map[string]bool)
+		}
+
+		group.Records += a.Records
+		group.HoursWorked += a.HoursWorked
+		group.RegularHours += a.RegularHours
+		group.OvertimeHours += a.OvertimeHours
+		if !members[k][a.EmployeeID] {
+			members[k][a.EmployeeID] = true
+			group.Employees++
+		}
+	}
+
+	result := make([]HoursGroup, 0, len(groups))
+	for _, group := range groups {
+		result = append(result, *group)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].PeriodStart.Equal(result[j].PeriodStart) {
+			return result[i].PeriodStart.Before(result[j].PeriodStart)
+		}
+		return result[i].Key < result[j].Key
+	})
+
+	return result, nil
+}
+
+// managers maps every employee on the roster to their manager
+func (s *AggregationService) managers(ctx context.Context) (map[string]string, error) {
+	employees, err := s.employees.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	managers := make(map[string]string, len(employees))
+	for _, employee := range employees {
+		managers[employee.ID] = employee.ManagerID
+	}
+	return managers, nil
+}
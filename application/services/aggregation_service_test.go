@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/hours"
+	"github.com/leo-andrei/check-in-service/infrastructure/persistence"
+)
+
+func TestAggregateAnalyticsConsent(t *testing.T) {
+	tests := []struct {
+		name          string
+		period        string
+		wantEmployees []string
+	}{
+		{name: "weeks leave out withdrawn consent", period: hours.PeriodWeek, wantEmployees: []string{"emp-1"}},
+		{name: "pay periods count everyone", period: hours.PeriodPay, wantEmployees: []string{"emp-1", "emp-2"}},
+	}
+
+	ctx := context.Background()
+	records := persistence.NewMemoryTimeRecordRepository(persistence.NewMemoryOutboxRepository())
+	checkIn := time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC)
+	for _, employeeID := range []string{"emp-1", "emp-2"} {
+		record, err := entities.NewManualTimeRecord(employeeID, checkIn, checkIn.Add(8*time.Hour), "UTC")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := records.Save(ctx, record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	consents := NewConsentService(persistence.NewMemoryConsentRepository(), false)
+	if _, err := consents.Withdraw(ctx, "emp-2", entities.PurposeAnalytics, "hr-portal"); err != nil {
+		t.Fatal(err)
+	}
+	payPeriods := NewPayPeriodService(hours.PaySchedule{Frequency: hours.PayWeekly}, time.UTC)
+	service := NewAggregationService(records, noEmployees{}, payPeriods, consents)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+			groups, err := service.Aggregate(ctx, GroupByEmployee, tt.period, from, from.AddDate(0, 1, 0), time.UTC, time.Monday, "")
+			if err != nil {
+				t.Fatalf("Aggregate error = %v", err)
+			}
+
+			var got []string
+			for _, group := range groups {
+				got = append(got, group.Key)
+			}
+			if len(got) != len(tt.wantEmployees) {
+				t.Fatalf("groups = %v, want %v", got, tt.wantEmployees)
+			}
+			for i := range got {
+				if got[i] != tt.wantEmployees[i] {
+					t.Fatalf("groups = %v, want %v", got, tt.wantEmployees)
+				}
+			}
+		})
+	}
+}
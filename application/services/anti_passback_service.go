@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"go.uber.org/zap"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+)
+
+// AntiPassbackService applies the anti-passback policy to a badge before it
+// is turned into a check-in or check-out (mode and transit time configurable)
+type AntiPassbackService struct {
+	repo repositories.TimeRecordRepository
+}
+
+func NewAntiPassbackService(repo repositories.TimeRecordRepository) *AntiPassbackService {
+	return &AntiPassbackService{
+		repo: repo,
+	}
+}
+
+// Check returns PassbackAllow or PassbackMove for a badge at locationID, and
+// an error when the policy rejects it
+func (s *AntiPassbackService) Check(ctx context.Context, employeeID, locationID string) (entities.PassbackDecision, error) {
+	policy := entities.AntiPassbackPolicy{
+		Mode:       config.Cfg.AntiPassback.Mode,
+		MinTransit: time.Duration(config.Cfg.AntiPassback.MinTransitMin) * time.Minute,
+	}
+	if policy.Mode == entities.PassbackOff || locationID == "" {
+		return entities.PassbackAllow, nil
+	}
+
+	now := time.Now().UTC()
+	active, err := s.repo.FindActiveByEmployeeID(ctx, employeeID)
+	if err != nil {
+		return entities.PassbackAllow, fmt.Errorf("failed to find active record: %w", err)
+	}
+
+	var last *entities.TimeRecord
+	if active == nil && policy.MinTransit > 0 {
+		recent, err := s.repo.FindByEmployeeInRange(ctx, employeeID, now.Add(-policy.MinTransit), now)
+		if err != nil {
+			return entities.PassbackAllow, fmt.Errorf("failed to find recent records: %w", err)
+		}
+		for _, record := range recent {
+			if record.IsCompleted() && (last == nil || record.CheckOutAt.After(*last.CheckOutAt)) {
+				last = record
+			}
+		}
+	}
+
+	switch decision := policy.Evaluate(active, last, locationID, now); decision {
+	case entities.PassbackOccupied:
+		config.Logger.Warn(errors.ErrAntiPassback, zap.String("employee_id", employeeID), zap.String("location_id", locationID), zap.String("checked_in_at", active.LocationID))
+		return decision, errors.ErrAntiPassbackConst
+	case entities.PassbackTransit:
+		config.Logger.Warn(errors.ErrAntiPassbackTransit, zap.String("employee_id", employeeID), zap.String("location_id", locationID), zap.String("checked_out_at", last.LocationID))
+		return decision, errors.ErrAntiPassbackTransitConst
+	case entities.PassbackMove:
+		config.Logger.Info("Transferring employee between locations", zap.String("employee_id", employeeID), zap.String("from_location_id", active.LocationID), zap.String("to_location_id", locationID))
+		return decision, nil
+	default:
+		return decision, nil
+	}
+}
@@ -0,0 +1,277 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"go.uber.org/zap"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+	"github.com/leo-andrei/check-in-service/domain/events"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+)
+
+// ApprovalService routes corrections and backdated entries through the
+// employee's manager. Time records only change once a request is approved.
+type ApprovalService struct {
+	records   repositories.TimeRecordRepository
+	approvals repositories.ApprovalRepository
+	employees repositories.EmployeeRepository
+	notes     repositories.NoteRepository
+	holidays  repositories.HolidayRepository
+}
+
+func NewApprovalService(records repositories.TimeRecordRepository, approvals repositories.ApprovalRepository, employees repositories.EmployeeRepository, notes repositories.NoteRepository, holidays repositories.HolidayRepository) *ApprovalService {
+	return &ApprovalService{
+		records:   records,
+		approvals: approvals,
+		employees: employees,
+		notes:     notes,
+		holidays:  holidays,
+	}
+}
+
+// ApprovalSubmission is a correction of an existing record or a manual entry
+type ApprovalSubmission struct {
+	Kind       entities.ApprovalKind
+	RecordID   string
+	CheckInAt  time.Time
+	CheckOutAt time.Time
+	TimeZone   string
+	Note       string
+}
+
+// Submit files a pending request and notifies the employee's manager
+func (s *ApprovalService) Submit(ctx context.Context, employeeID string, sub ApprovalSubmission) (*entities.Approval, error) {
+	timeZone := sub.TimeZone
+
+	if sub.Kind == entities.ApprovalCorrection {
+		record, err := s.records.FindByID(ctx, sub.RecordID)
+		if err == repositories.ErrRecordNotFound || (err == nil && record.EmployeeID != employeeID) {
+			return nil, errors.ErrRecordNotFoundConst
+		}
+		if err != nil {
+			return nil, err
+		}
+		if timeZone == "" {
+			timeZone = record.TimeZone
+		}
+	}
+	if timeZone == "" {
+		timeZone = config.Cfg.DefaultTimeZone
+	}
+	if _, err := entities.LoadTimeZone(timeZone); err != nil {
+		return nil, errors.ErrInvalidTimeZoneConst
+	}
+
+	managerID := ""
+	employee, err := s.employees.FindByID(ctx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	if employee != nil {
+		managerID = employee.ManagerID
+	}
+
+	approval, err := entities.NewApproval(employeeID, managerID, sub.Kind, sub.RecordID, sub.CheckInAt, sub.CheckOutAt, timeZone, sub.Note)
+	if err != nil {
+		config.Logger.Warn(errors.ErrInvalidApproval, zap.String("employee_id", employeeID), zap.Error(err))
+		return nil, errors.ErrInvalidApprovalConst
+	}
+
+	event := events.ApprovalRequestedEvent{
+		EventHeader: events.EventHeader{
+			EventID:   uuid.New().String(),
+			EventType: events.EventTypeApprovalRequested,
+			Version:   1,
+			Timestamp: time.Now(),
+		},
+		ApprovalID:         approval.ID,
+		EmployeeID:         approval.EmployeeID,
+		ManagerID:          approval.ManagerID,
+		Kind:               string(approval.Kind),
+		RecordID:           approval.RecordID,
+		ProposedCheckInAt:  approval.ProposedCheckInAt,
+		ProposedCheckOutAt: approval.ProposedCheckOutAt,
+		TimeZone:           approval.TimeZone,
+		Note:               approval.Note,
+	}
+
+	if err := s.approvals.SaveWithEvent(ctx, approval, event); err != nil {
+		config.Logger.Error("Failed to save approval", zap.String("employee_id", employeeID), zap.Error(err))
+		return nil, fmt.Errorf("failed to save approval: %w", err)
+	}
+
+	config.Logger.Info("Approval requested", zap.String("employee_id", employeeID), zap.String("approval_id", approval.ID), zap.String("kind", string(approval.Kind)))
+	return approval, nil
+}
+
+func (s *ApprovalService) Get(ctx context.Context, approvalID string) (*entities.Approval, error) {
+	approval, err := s.approvals.FindByID(ctx, approvalID)
+	if err != nil {
+		return nil, err
+	}
+	if approval == nil {
+		return nil, errors.ErrApprovalNotFoundConst
+	}
+	return approval, nil
+}
+
+// Pending lists requests waiting for a decision, for one manager or everyone
+func (s *ApprovalService) Pending(ctx context.Context, managerID string) ([]*entities.Approval, error) {
+	return s.approvals.FindPending(ctx, managerID)
+}
+
+// Notes returns the notes already on the record a correction targets, so the
+// manager sees the employee's explanations next to the request
+func (s *ApprovalService) Notes(ctx context.Context, approval *entities.Approval) ([]*entities.RecordNote, error) {
+	if approval.RecordID == "" {
+		return nil, nil
+	}
+	return s.notes.FindByRecords(ctx, approval.EmployeeID, []string{approval.RecordID})
+}
+
+// Decide approves or rejects a pending request. Only the manager recorded on
+// the request may decide it; requests without a manager can be decided by any
+// admin, but never by the requesting employee.
+func (s *ApprovalService) Decide(ctx context.Context, approvalID string, approve bool, decidedBy, comment string) (*entities.Approval, error) {
+	approval, err := s.Get(ctx, approvalID)
+	if err != nil {
+		return nil, err
+	}
+
+	if decidedBy == approval.EmployeeID || (approval.ManagerID != "" && decidedBy != approval.ManagerID) {
+		config.Logger.Warn(errors.ErrNotApprover, zap.String("approval_id", approvalID), zap.String("actor", decidedBy))
+		return nil, errors.ErrNotApproverConst
+	}
+
+	if err := approval.Decide(approve, decidedBy, comment); err != nil {
+		return nil, errors.ErrApprovalAlreadyDecidedConst
+	}
+
+	var (
+		record      *entities.TimeRecord
+		entries     []*entities.AuditEntry
+		recordEvent events.DomainEvent
+	)
+	if approve {
+		record, entries, recordEvent, err = s.apply(ctx, approval)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	decided := events.ApprovalDecidedEvent{
+		EventHeader: events.EventHeader{
+			EventID:   uuid.New().String(),
+			EventType: events.EventTypeApprovalDecided,
+			Version:   1,
+			Timestamp: time.Now(),
+		},
+		ApprovalID: approval.ID,
+		EmployeeID: approval.EmployeeID,
+		Kind:       string(approval.Kind),
+		Status:     string(approval.Status),
+		DecidedBy:  decidedBy,
+		Comment:    comment,
+	}
+	if record != nil {
+		decided.RecordID = record.ID
+		decided.HoursWorked = record.HoursWorked
+	}
+
+	decisionEvents := []events.DomainEvent{decided}
+	if recordEvent != nil {
+		decisionEvents = append(decisionEvents, recordEvent)
+		decisionEvents = append(decisionEvents, statusChangedEvents(record, decidedBy)...)
+	}
+
+	ctx = repositories.WithChangeOrigin(ctx, repositories.ChangeOrigin{Actor: decidedBy, Source: "approval"})
+	err = s.approvals.SaveDecision(ctx, approval, record, entries, decisionEvents)
+	if err == repositories.ErrAlreadyDecided {
+		// Another approver decided it after we loaded it
+		config.Logger.Warn(errors.ErrApprovalAlreadyDecided, zap.String("approval_id", approvalID), zap.String("actor", decidedBy))
+		return nil, errors.ErrApprovalAlreadyDecidedConst
+	}
+	if err != nil {
+		config.Logger.Error("Failed to save approval decision", zap.String("approval_id", approvalID), zap.Error(err))
+		return nil, fmt.Errorf("failed to save approval decision: %w", err)
+	}
+
+	config.Logger.Info("Approval decided", zap.String("approval_id", approvalID), zap.String("status", string(approval.Status)), zap.String("actor", decidedBy))
+	return approval, nil
+}
+
+// apply turns an approved request into the record change, its audit entry
+// and the event that reports it: a check-out for manual entries, a
+// correction otherwise
+func (s *ApprovalService) apply(ctx context.Context, approval *entities.Approval) (*entities.TimeRecord, []*entities.AuditEntry, events.DomainEvent, error) {
+	var (
+		record   *entities.TimeRecord
+		previous entities.TimeRecord
+		before   *entities.RecordSnapshot
+		action   entities.AuditAction
+		err      error
+	)
+
+	switch approval.Kind {
+	case entities.ApprovalCorrection:
+		record, err = s.records.FindByID(ctx, approval.RecordID)
+		if err == repositories.ErrRecordNotFound {
+			return nil, nil, nil, errors.ErrRecordNotFoundConst
+		}
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		previous = *record
+		before = entities.SnapshotOf(record)
+		change := record.Correct
+		if record.Status == entities.StatusLocked {
+			// The pay period was closed; the approval is what allows the change
+			change = record.Amend
+		}
+		if err := change(approval.ProposedCheckInAt, approval.ProposedCheckOutAt); err != nil {
+			return nil, nil, nil, errors.ErrInvalidApprovalConst
+		}
+		action = entities.AuditActionCorrection
+
+	case entities.ApprovalManualEntry:
+		record, err = entities.NewManualTimeRecord(approval.EmployeeID, approval.ProposedCheckInAt, approval.ProposedCheckOutAt, approval.TimeZone)
+		if err != nil {
+			return nil, nil, nil, errors.ErrInvalidApprovalConst
+		}
+		action = entities.AuditActionManualEntry
+	}
+
+	calc := calculateHours(record)
+	if err := classifyPremiumHours(ctx, s.holidays, record); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := priceLaborCost(ctx, s.employees, record); err != nil {
+		return nil, nil, nil, err
+	}
+
+	if approval.Note != "" {
+		note, err := entities.NewRecordNote(record, entities.NoteAppended, approval.EmployeeID, approval.Note)
+		if err == nil {
+			record.Notes = append(record.Notes, note)
+		}
+	}
+
+	entry := entities.NewAuditEntry(record.ID, record.EmployeeID, action, approval.DecidedBy, approval.DecisionComment, before, entities.SnapshotOf(record))
+
+	// Manual entries are reported like a check-out; corrections let consumers
+	// compensate for the change
+	var recordEvent events.DomainEvent
+	if approval.Kind == entities.ApprovalManualEntry {
+		recordEvent = checkedOutEvent(record, calc, approval.Note)
+	} else {
+		recordEvent = recordChangedEvent(&previous, record, approval.DecidedBy, approval.DecisionComment)
+	}
+
+	return record, []*entities.AuditEntry{entry}, recordEvent, nil
+}
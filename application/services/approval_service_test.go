@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+	"github.com/leo-andrei/check-in-service/domain/events"
+	"github.com/leo-andrei/check-in-service/infrastructure/persistence"
+)
+
+func TestApprovalDecideConcurrently(t *testing.T) {
+	tests := []struct {
+		name    string
+		approve bool
+		// Outbox events a single decision queues
+		wantEvents int
+	}{
+		{name: "approve manual entry", approve: true, wantEvents: 3},
+		{name: "reject manual entry", approve: false, wantEvents: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			outbox := persistence.NewMemoryOutboxRepository()
+			records := persistence.NewMemoryTimeRecordRepository(outbox)
+			approvals := persistence.NewMemoryApprovalRepository(records)
+			service := NewApprovalService(records, approvals, noEmployees{}, noNotes{}, noHolidays{})
+
+			checkIn := time.Now().Add(-10 * time.Hour).Truncate(time.Second)
+			approval, err := service.Submit(ctx, "emp-1", ApprovalSubmission{
+				Kind:       entities.ApprovalManualEntry,
+				CheckInAt:  checkIn,
+				CheckOutAt: checkIn.Add(8 * time.Hour),
+				TimeZone:   "UTC",
+			})
+			if err != nil {
+				t.Fatalf("Submit: %v", err)
+			}
+			submitted := len(outbox.Events())
+
+			const approvers = 8
+			var (
+				wg        sync.WaitGroup
+				mu        sync.Mutex
+				succeeded int
+				decided   int
+			)
+			for range approvers {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := service.Decide(ctx, approval.ID, tt.approve, "admin-1", "")
+					mu.Lock()
+					defer mu.Unlock()
+					switch err {
+					case nil:
+						succeeded++
+					case errors.ErrApprovalAlreadyDecidedConst:
+						decided++
+					default:
+						t.Errorf("Decide: %v", err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if succeeded != 1 || decided != approvers-1 {
+				t.Fatalf("got %d decisions and %d already decided, want 1 and %d", succeeded, decided, approvers-1)
+			}
+			queued := outbox.Events()[submitted:]
+			if len(queued) != tt.wantEvents {
+				t.Fatalf("decisions queued %d events, want %d", len(queued), tt.wantEvents)
+			}
+			checkedOut := 0
+			for _, event := range queued {
+				if event.EventType == events.EventTypeEmployeeCheckedOut {
+					checkedOut++
+				}
+			}
+			if tt.approve && checkedOut != 1 {
+				t.Fatalf("approval queued %d check-out events, want 1", checkedOut)
+			}
+		})
+	}
+}
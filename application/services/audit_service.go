@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/audit"
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"go.uber.org/zap"
+)
+
+// anchorSettleTime keeps anchors behind transactions that may still be
+// committing audit entries with an earlier created_at
+const anchorSettleTime = time.Minute
+
+// AnchorExporter copies anchors somewhere the database's operators cannot rewrite
+type AnchorExporter interface {
+	Export(ctx context.Context, anchor *entities.AuditAnchor) error
+}
+
+// AuditService verifies the audit log's hash chains and anchors them
+type AuditService struct {
+	repo     repositories.AuditRepository
+	exporter AnchorExporter
+}
+
+// NewAuditService creates the service; exporter may be nil to keep anchors in
+// the database only
+func NewAuditService(repo repositories.AuditRepository, exporter AnchorExporter) *AuditService {
+	return &AuditService{
+		repo:     repo,
+		exporter: exporter,
+	}
+}
+
+// Verify checks every chain and compares it with the stored anchors and any
+// extra anchors given (e.g. read back from object storage)
+func (s *AuditService) Verify(ctx context.Context, extra []*entities.AuditAnchor) (*audit.Report, error) {
+	entries, err := s.repo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report := audit.Verify(entries, []byte(config.Cfg.Audit.ChainKey))
+
+	anchors, err := s.repo.FindAnchors(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, anchor := range append(anchors, extra...) {
+		report.CheckAnchor(anchor)
+	}
+
+	return report, nil
+}
+
+// Anchor verifies the log and records a new anchor. It refuses to anchor a log
+// that already fails verification, which would vouch for the tampered state.
+func (s *AuditService) Anchor(ctx context.Context) (*entities.AuditAnchor, error) {
+	report, err := s.Verify(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !report.Intact() {
+		config.Logger.Error(errors.ErrAuditTampered, zap.Int("problems", len(report.Problems)))
+		return nil, errors.ErrAuditTamperedConst
+	}
+
+	anchor := report.Anchor(time.Now().Add(-anchorSettleTime))
+	if err := s.repo.SaveAnchor(ctx, anchor); err != nil {
+		return nil, err
+	}
+
+	if s.exporter != nil {
+		if err := s.exporter.Export(ctx, anchor); err != nil {
+			return nil, fmt.Errorf("failed to export audit anchor: %w", err)
+		}
+	}
+
+	config.Logger.Info("Audit log anchored", zap.String("anchor_id", anchor.ID), zap.Int("records", anchor.Records), zap.Int("entries", anchor.Entries))
+	return anchor, nil
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/audit"
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"github.com/leo-andrei/check-in-service/infrastructure/persistence"
+)
+
+func TestAuditVerifyStoredChain(t *testing.T) {
+	key := config.Cfg.Audit.ChainKey
+	config.Cfg.Audit.ChainKey = "test-audit-chain-key"
+	defer func() { config.Cfg.Audit.ChainKey = key }()
+
+	ctx := context.Background()
+	records := persistence.NewMemoryTimeRecordRepository(persistence.NewMemoryOutboxRepository())
+	checkIn := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	record, err := entities.NewManualTimeRecord("emp-1", checkIn, checkIn.Add(8*time.Hour), "UTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := records.Save(ctx, record); err != nil {
+		t.Fatal(err)
+	}
+
+	// Two corrections, chained by the repository as they are saved
+	for _, hours := range []time.Duration{7, 9} {
+		before := entities.SnapshotOf(record)
+		if err := record.Correct(checkIn, checkIn.Add(hours*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+		entry := entities.NewAuditEntry(record.ID, record.EmployeeID, entities.AuditActionCorrection, "mgr-1", "fix", before, entities.SnapshotOf(record))
+		if err := records.SaveAllWithAudit(ctx, []*entities.TimeRecord{record}, []*entities.AuditEntry{entry}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	service := NewAuditService(persistence.NewMemoryAuditRepository(records), nil)
+	report, err := service.Verify(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Intact() || report.Entries != 2 {
+		t.Fatalf("report = %+v, want 2 intact entries", report)
+	}
+
+	records.AuditEntries(record.ID)[0].Reason = "edited"
+
+	report, err = service.Verify(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Problems) != 1 || report.Problems[0].Kind != audit.ProblemHashMismatch {
+		t.Fatalf("problems = %+v, want one %s", report.Problems, audit.ProblemHashMismatch)
+	}
+	if _, err := service.Anchor(ctx); err != errors.ErrAuditTamperedConst {
+		t.Fatalf("Anchor error = %v, want %v", err, errors.ErrAuditTamperedConst)
+	}
+}
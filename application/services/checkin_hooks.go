@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"github.com/leo-andrei/check-in-service/infrastructure/metrics"
+	"go.uber.org/zap"
+)
+
+// CheckInHook is told about every completed check-in, e.g. to assign a locker
+// or desk, and may return metadata for the check-in response. The record is
+// already saved, so a failing hook does not undo the check-in.
+type CheckInHook interface {
+	Name() string
+	AfterCheckIn(ctx context.Context, record *entities.TimeRecord) (interface{}, error)
+}
+
+// AddHook registers a hook; hooks run synchronously, in registration order
+func (s *CheckInService) AddHook(hook CheckInHook) {
+	s.hooks = append(s.hooks, hook)
+}
+
+// runHooks calls every hook with CHECKIN_HOOK_TIMEOUT_MS each and collects
+// their metadata by hook name. Failures are logged and left out.
+func (s *CheckInService) runHooks(ctx context.Context, record *entities.TimeRecord) map[string]interface{} {
+	if len(s.hooks) == 0 {
+		return nil
+	}
+
+	metadata := make(map[string]interface{})
+	timeout := time.Duration(config.Cfg.CheckInHooks.TimeoutMs) * time.Millisecond
+	for _, hook := range s.hooks {
+		hookCtx, cancel := context.WithTimeout(ctx, timeout)
+		start := time.Now()
+		result, err := hook.AfterCheckIn(hookCtx, record)
+		cancel()
+		metrics.Timing("checkin.hook."+hook.Name(), time.Since(start))
+
+		if err != nil {
+			metrics.Incr("checkin.hook.failures", 1)
+			config.Logger.Warn("Check-in hook failed", zap.String("hook", hook.Name()), zap.String("record_id", record.ID), zap.Error(err))
+			continue
+		}
+		if result != nil {
+			metadata[hook.Name()] = result
+		}
+	}
+	return metadata
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/leo-andrei/check-in-service/domain/entities"
 	"github.com/leo-andrei/check-in-service/domain/errors"
 	"github.com/leo-andrei/check-in-service/domain/events"
+	"github.com/leo-andrei/check-in-service/domain/hours"
 	"github.com/leo-andrei/check-in-service/domain/repositories"
 )
 
@@ -22,29 +24,128 @@ type EventPublisher interface {
 
 type CheckInService struct {
 	repo      repositories.TimeRecordRepository
+	locations repositories.LocationRepository
+	projects  repositories.ProjectRepository
+	employees repositories.EmployeeRepository
 	publisher EventPublisher
+	hooks     []CheckInHook
 }
 
-func NewCheckInService(repo repositories.TimeRecordRepository, publisher EventPublisher) *CheckInService {
+func NewCheckInService(repo repositories.TimeRecordRepository, locations repositories.LocationRepository, projects repositories.ProjectRepository, employees repositories.EmployeeRepository, publisher EventPublisher) *CheckInService {
 	return &CheckInService{
 		repo:      repo,
+		locations: locations,
+		projects:  projects,
+		employees: employees,
 		publisher: publisher,
 	}
 }
 
-func (s *CheckInService) CheckIn(ctx context.Context, employeeID string) (*entities.TimeRecord, error) {
-	// Check if already checked in
-	existing, err := s.repo.FindActiveByEmployeeID(ctx, employeeID)
-	if err == nil && existing != nil {
-		config.Logger.Warn(errors.ErrEmployeeAlreadyCheckedIn, zap.String("employee_id", employeeID))
-		return nil, errors.ErrEmployeeAlreadyCheckedInConst
+// CheckInOptions carries the optional details of a check-in
+type CheckInOptions struct {
+	LocationID  string
+	TimeZone    string // IANA name; defaults to the location's zone, then DEFAULT_TIME_ZONE
+	ProjectCode string
+	Note        string // Optional free-text explanation from the employee
+	Source      entities.PunchSource
+	DeviceID    string // Terminal the punch came from, empty when unknown
+}
+
+// CheckIn opens a record for the employee and returns it with the metadata of
+// the check-in hooks, keyed by hook name (nil without hooks)
+func (s *CheckInService) CheckIn(ctx context.Context, employeeID string, opts CheckInOptions) (*entities.TimeRecord, map[string]interface{}, error) {
+	record, event, err := s.prepare(ctx, employeeID, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Save to database with event in single transaction (Transactional Outbox).
+	// The unique index on open records rejects a second check-in, even one
+	// racing this one.
+	if err := s.repo.SaveWithEvent(ctx, record, event); err != nil {
+		if stderrors.Is(err, repositories.ErrConflict) {
+			config.Logger.Warn(errors.ErrEmployeeAlreadyCheckedIn, zap.String("employee_id", employeeID))
+			return nil, nil, errors.ErrEmployeeAlreadyCheckedInConst
+		}
+		config.Logger.Error("Failed to save check-in", zap.String("employee_id", employeeID), zap.Error(err))
+		return nil, nil, fmt.Errorf("failed to save check-in: %w", err)
+	}
+
+	config.Logger.Info("Check-in successful", zap.String("employee_id", employeeID), zap.String("record_id", record.ID), zap.String("location_id", record.LocationID), zap.String("source", string(record.Source)), zap.String("device_id", record.DeviceID))
+
+	// Event is now safely stored in outbox table
+	// Outbox publisher will handle publishing to RabbitMQ
+
+	return record, s.runHooks(ctx, record), nil
+}
+
+// prepare validates a check-in and builds its record and event without saving them
+func (s *CheckInService) prepare(ctx context.Context, employeeID string, opts CheckInOptions) (*entities.TimeRecord, events.DomainEvent, error) {
+	// Only active employees on the roster can check in (enforcement configurable)
+	if config.Cfg.Roster.RequireActiveEmployee {
+		employee, err := s.employees.FindByID(ctx, employeeID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to find employee: %w", err)
+		}
+		if employee == nil || !employee.Active {
+			config.Logger.Warn(errors.ErrUnknownEmployee, zap.String("employee_id", employeeID))
+			return nil, nil, errors.ErrUnknownEmployeeConst
+		}
+	}
+
+	timeZone := opts.TimeZone
+
+	// Only known, active locations can be badged in at
+	if opts.LocationID != "" {
+		location, err := s.locations.FindByID(ctx, opts.LocationID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to find location: %w", err)
+		}
+		if location == nil || !location.Active {
+			config.Logger.Warn(errors.ErrUnknownLocation, zap.String("employee_id", employeeID), zap.String("location_id", opts.LocationID))
+			return nil, nil, errors.ErrUnknownLocationConst
+		}
+		if timeZone == "" {
+			timeZone = location.TimeZone
+		}
+	}
+
+	// Hours can only be attributed to known, active projects
+	if opts.ProjectCode != "" {
+		project, err := s.projects.FindByCode(ctx, opts.ProjectCode)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to find project: %w", err)
+		}
+		if project == nil || !project.Active {
+			config.Logger.Warn(errors.ErrUnknownProject, zap.String("employee_id", employeeID), zap.String("project_code", opts.ProjectCode))
+			return nil, nil, errors.ErrUnknownProjectConst
+		}
+	}
+
+	if timeZone == "" {
+		timeZone = config.Cfg.DefaultTimeZone
+	}
+	if _, err := entities.LoadTimeZone(timeZone); err != nil {
+		config.Logger.Warn(errors.ErrInvalidTimeZone, zap.String("employee_id", employeeID), zap.String("time_zone", timeZone))
+		return nil, nil, errors.ErrInvalidTimeZoneConst
 	}
 
 	// Create new time record
 	record, err := entities.NewTimeRecord(employeeID)
 	if err != nil {
 		config.Logger.Error("Failed to create time record", zap.String("employee_id", employeeID), zap.Error(err))
-		return nil, err
+		return nil, nil, err
+	}
+	record.LocationID = opts.LocationID
+	record.TimeZone = timeZone
+	record.ProjectCode = opts.ProjectCode
+	record.Source = opts.Source
+	record.DeviceID = opts.DeviceID
+	record.AssignBusinessDate()
+	if opts.Note != "" {
+		if err := record.AddNote(entities.NoteOnCheckIn, opts.Note); err != nil {
+			return nil, nil, errors.ErrInvalidNoteConst
+		}
 	}
 
 	// Create event
@@ -55,89 +156,290 @@ func (s *CheckInService) CheckIn(ctx context.Context, employeeID string) (*entit
 			Version:   1, // Current schema version
 			Timestamp: time.Now(),
 		},
-		EmployeeID: record.EmployeeID,
-		CheckInAt:  record.CheckInAt,
-		RecordID:   record.ID,
-	}
-
-	// Save to database with event in single transaction (Transactional Outbox)
-	if err := s.repo.SaveWithEvent(ctx, record, event); err != nil {
-		config.Logger.Error("Failed to save check-in", zap.String("employee_id", employeeID), zap.Error(err))
-		return nil, fmt.Errorf("failed to save check-in: %w", err)
+		EmployeeID:  record.EmployeeID,
+		CheckInAt:   record.CheckInAt,
+		RecordID:    record.ID,
+		LocationID:  record.LocationID,
+		TimeZone:    record.TimeZone,
+		ProjectCode: record.ProjectCode,
+		Note:        opts.Note,
+		Source:      string(record.Source),
+		DeviceID:    record.DeviceID,
 	}
 
-	config.Logger.Info("Check-in successful", zap.String("employee_id", employeeID), zap.String("record_id", record.ID))
-
-	// Event is now safely stored in outbox table
-	// Outbox publisher will handle publishing to RabbitMQ
-
-	return record, nil
+	return record, event, nil
 }
 
 type CheckOutService struct {
 	repo      repositories.TimeRecordRepository
+	holidays  repositories.HolidayRepository
+	employees repositories.EmployeeRepository
 	publisher EventPublisher
 }
 
-func NewCheckOutService(repo repositories.TimeRecordRepository, publisher EventPublisher) *CheckOutService {
+func NewCheckOutService(repo repositories.TimeRecordRepository, holidays repositories.HolidayRepository, employees repositories.EmployeeRepository, publisher EventPublisher) *CheckOutService {
 	return &CheckOutService{
 		repo:      repo,
+		holidays:  holidays,
+		employees: employees,
 		publisher: publisher,
 	}
 }
 
-func (s *CheckOutService) CheckOut(ctx context.Context, employeeID string) (*entities.TimeRecord, error) {
+// CheckOutOptions carries the optional details of a check-out
+type CheckOutOptions struct {
+	Note     string // Optional free-text explanation from the employee
+	Source   entities.PunchSource
+	DeviceID string // Terminal the punch came from, empty when unknown
+}
+
+func (s *CheckOutService) CheckOut(ctx context.Context, employeeID string, opts CheckOutOptions) (*entities.TimeRecord, error) {
+	record, recordEvents, err := s.prepare(ctx, employeeID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Save to database with events in single transaction (Transactional Outbox)
+	if err := s.repo.SaveWithEvents(ctx, record, recordEvents); err != nil {
+		config.Logger.Error("Failed to save check-out", zap.String("employee_id", employeeID), zap.String("record_id", record.ID), zap.Error(err))
+		return nil, fmt.Errorf("failed to save check-out: %w", err)
+	}
+
+	config.Logger.Info("Check-out successful", zap.String("employee_id", employeeID), zap.String("record_id", record.ID), zap.String("source", string(record.CheckOutSource)), zap.String("device_id", record.CheckOutDeviceID))
+
+	// Event is now safely stored in outbox table
+	// Outbox publisher will handle publishing to RabbitMQ
+
+	return record, nil
+}
+
+// prepare closes the employee's open record in memory and builds the events
+// reporting it, without saving either
+func (s *CheckOutService) prepare(ctx context.Context, employeeID string, opts CheckOutOptions) (*entities.TimeRecord, []events.DomainEvent, error) {
 	// Find active check-in
 	record, err := s.repo.FindActiveByEmployeeID(ctx, employeeID)
 	if err != nil {
-		config.Logger.Info(errors.ErrNoActiveCheckInFound, zap.String("employee_id", employeeID), zap.Error(err))
-		return nil, errors.ErrNoActiveCheckInFoundConst
+		config.Logger.Error("Failed to find active check-in", zap.String("employee_id", employeeID), zap.Error(err))
+		return nil, nil, err
 	}
 
 	// Check if record is nil
 	if record == nil {
 		config.Logger.Info(errors.ErrNoActiveCheckInFound, zap.String("employee_id", employeeID))
-		return nil, errors.ErrNoActiveCheckInFoundConst
+		return nil, nil, errors.ErrNoActiveCheckInFoundConst
 	}
 
 	// Check if it's a duplicate request - an user might double tap the card reader by mistake (window configurable)
 	dupWindow := config.Cfg.CheckOut.DuplicateWindowSec
 	if time.Since(record.CheckInAt) < time.Duration(dupWindow)*time.Second {
 		config.Logger.Warn(errors.ErrDuplicateCheckIn, zap.String("employee_id", employeeID), zap.String("record_id", record.ID))
-		return nil, errors.ErrDuplicateCheckInConst
+		return nil, nil, errors.ErrDuplicateCheckInConst
 	}
 
 	// Execute check-out
 	if err := record.CheckOut(); err != nil {
 		config.Logger.Error("Failed to check out", zap.String("employee_id", employeeID), zap.String("record_id", record.ID), zap.Error(err))
-		return nil, err
+		return nil, nil, err
+	}
+
+	record.CheckOutSource = opts.Source
+	record.CheckOutDeviceID = opts.DeviceID
+
+	if opts.Note != "" {
+		if err := record.AddNote(entities.NoteOnCheckOut, opts.Note); err != nil {
+			return nil, nil, errors.ErrInvalidNoteConst
+		}
+	}
+
+	calc := calculateHours(record)
+
+	// Holiday and weekend hours are paid at premium rates downstream
+	if err := classifyPremiumHours(ctx, s.holidays, record); err != nil {
+		config.Logger.Error("Failed to classify premium hours", zap.String("employee_id", employeeID), zap.String("record_id", record.ID), zap.Error(err))
+		return nil, nil, err
+	}
+
+	// Split regular and overtime hours (thresholds configurable)
+	overtime, err := s.applyOvertimePolicy(ctx, record)
+	if err != nil {
+		config.Logger.Error("Failed to compute overtime", zap.String("employee_id", employeeID), zap.String("record_id", record.ID), zap.Error(err))
+		return nil, nil, fmt.Errorf("failed to compute overtime: %w", err)
+	}
+
+	// Price the hours once the regular/overtime split is final
+	if err := priceLaborCost(ctx, s.employees, record); err != nil {
+		config.Logger.Error("Failed to compute labor cost", zap.String("employee_id", employeeID), zap.String("record_id", record.ID), zap.Error(err))
+		return nil, nil, err
 	}
 
 	// Create event (this triggers labor cost reporting and email)
-	event := events.EmployeeCheckedOutEvent{
+	event := checkedOutEvent(record, calc, opts.Note)
+	recordEvents := []events.DomainEvent{event}
+
+	if record.HasOvertime() {
+		config.Logger.Info("Overtime detected", zap.String("employee_id", employeeID), zap.String("record_id", record.ID), zap.Float64("overtime_hours", record.OvertimeHours))
+		recordEvents = append(recordEvents, events.EmployeeOvertimeDetectedEvent{
+			EventHeader: events.EventHeader{
+				EventID:   uuid.New().String(),
+				EventType: events.EventTypeEmployeeOvertimeDetected,
+				Version:   1,
+				Timestamp: time.Now(),
+				Priority:  events.PriorityRoutine,
+			},
+			EmployeeID:     record.EmployeeID,
+			RecordID:       record.ID,
+			CheckInAt:      record.CheckInAt,
+			CheckOutAt:     *record.CheckOutAt,
+			HoursWorked:    record.HoursWorked,
+			RegularHours:   record.RegularHours,
+			OvertimeHours:  record.OvertimeHours,
+			DailyTotal:     overtime.DailyTotal,
+			WeeklyTotal:    overtime.WeeklyTotal,
+			DailyOvertime:  overtime.DailyOvertime,
+			WeeklyOvertime: overtime.WeeklyOvertime,
+			TimeZone:       record.TimeZone,
+			ProjectCode:    record.ProjectCode,
+			LaborCost:      laborCostPayload(record.LaborCost),
+		})
+	}
+
+	recordEvents = append(recordEvents, statusChangedEvents(record, employeeID)...)
+
+	return record, recordEvents, nil
+}
+
+// calculateHours derives the record's payable hours through the policy chain
+// (breaks, rounding, splits), in local time so the night window matches the
+// employee's clock, then splits shifts crossing midnight when configured
+func calculateHours(record *entities.TimeRecord) *hours.Calculation {
+	calc := hoursCalculator().Calculate(record.Local(record.CheckInAt), record.Local(*record.CheckOutAt))
+	record.ApplyHoursCalculation(calc)
+	record.SplitByDay(config.Cfg.Hours.SplitOvernight)
+	return calc
+}
+
+// checkedOutEvent describes a completed record; it drives labor cost reporting and email
+func checkedOutEvent(record *entities.TimeRecord, calc *hours.Calculation, note string) events.EmployeeCheckedOutEvent {
+	return events.EmployeeCheckedOutEvent{
 		EventHeader: events.EventHeader{
 			EventID:   uuid.New().String(),
 			EventType: events.EventTypeEmployeeCheckedOut,
 			Version:   1, // Current schema version
 			Timestamp: time.Now(),
+			Priority:  events.PriorityPayroll, // Labor cost must reach payroll before the cut-off
 		},
-		EmployeeID:  record.EmployeeID,
-		CheckInAt:   record.CheckInAt,
-		CheckOutAt:  *record.CheckOutAt,
-		HoursWorked: record.HoursWorked,
-		RecordID:    record.ID,
+		EmployeeID:    record.EmployeeID,
+		CheckInAt:     record.CheckInAt,
+		CheckOutAt:    *record.CheckOutAt,
+		HoursWorked:   record.HoursWorked,
+		RecordID:      record.ID,
+		RegularHours:  record.RegularHours,
+		OvertimeHours: record.OvertimeHours,
+		LocationID:    record.LocationID,
+		TimeZone:      record.TimeZone,
+		ProjectCode:   record.ProjectCode,
+		Note:          note,
+		Breakdown: &events.HoursBreakdown{
+			GrossHours:         calc.GrossHours,
+			BreakHours:         calc.BreakHours,
+			RoundingAdjustment: calc.RoundingAdjustment,
+			PayableHours:       calc.PayableHours,
+			DayHours:           calc.DayHours,
+			NightHours:         calc.NightHours,
+		},
+		BusinessDate: record.BusinessDate,
+		Segments:     daySegmentPayloads(record.Segments),
+		HolidayHours: record.HolidayHours,
+		WeekendHours: record.WeekendHours,
+		LaborCost:    laborCostPayload(record.LaborCost),
+		Source:       string(record.CheckOutSource),
+		DeviceID:     record.CheckOutDeviceID,
 	}
+}
 
-	// Save to database with event in single transaction (Transactional Outbox)
-	if err := s.repo.SaveWithEvent(ctx, record, event); err != nil {
-		config.Logger.Error("Failed to save check-out", zap.String("employee_id", employeeID), zap.String("record_id", record.ID), zap.Error(err))
-		return nil, fmt.Errorf("failed to save check-out: %w", err)
+// priceLaborCost prices the record at the employee's current hourly rate, in
+// the employee's currency or LABOR_COST_CURRENCY. Employees without a rate
+// (or no longer on the roster) leave the record unpriced.
+func priceLaborCost(ctx context.Context, employees repositories.EmployeeRepository, record *entities.TimeRecord) error {
+	employee, err := employees.FindByID(ctx, record.EmployeeID)
+	if err != nil {
+		return fmt.Errorf("failed to load hourly rate: %w", err)
+	}
+	if employee == nil {
+		record.LaborCost = nil
+		return nil
 	}
 
-	config.Logger.Info("Check-out successful", zap.String("employee_id", employeeID), zap.String("record_id", record.ID))
+	currency := employee.Currency
+	if currency == "" {
+		currency = config.Cfg.LaborCost.Currency
+	}
+	record.ApplyLaborCost(employee.HourlyRate, currency)
+	return nil
+}
 
-	// Event is now safely stored in outbox table
-	// Outbox publisher will handle publishing to RabbitMQ
+func laborCostPayload(cost *entities.LaborCost) *events.LaborCost {
+	if cost == nil {
+		return nil
+	}
+	return &events.LaborCost{
+		Currency:   cost.Currency(),
+		HourlyRate: cost.HourlyRate,
+		Regular:    cost.Regular.String(),
+		Overtime:   cost.Overtime.String(),
+		Total:      cost.Total().String(),
+	}
+}
 
-	return record, nil
+func daySegmentPayloads(segments []entities.DaySegment) []events.DaySegment {
+	if len(segments) == 0 {
+		return nil
+	}
+	payloads := make([]events.DaySegment, 0, len(segments))
+	for _, s := range segments {
+		payloads = append(payloads, events.DaySegment{
+			BusinessDate: s.BusinessDate,
+			StartAt:      s.StartAt,
+			EndAt:        s.EndAt,
+			Hours:        s.Hours,
+		})
+	}
+	return payloads
+}
+
+// hoursCalculator builds the configured policy chain: breaks, then rounding,
+// then the day/night split of the final payable hours
+func hoursCalculator() *hours.Calculator {
+	cfg := config.Cfg.Hours
+	return hours.NewCalculator(
+		hours.BreakPolicy{MinShiftHours: cfg.BreakAfterHours, BreakMinutes: cfg.BreakMinutes},
+		hours.RoundingPolicy{IncrementMinutes: cfg.RoundingMinutes, Mode: hours.RoundingMode(cfg.RoundingMode)},
+		hours.NightSplitPolicy{NightStartHour: cfg.NightStartHour, NightEndHour: cfg.NightEndHour},
+	)
+}
+
+// applyOvertimePolicy splits the record's hours using the hours already worked
+// on the check-in day and in the check-in week (weeks start on Monday), both
+// taken in the record's local time zone
+func (s *CheckOutService) applyOvertimePolicy(ctx context.Context, record *entities.TimeRecord) (entities.OvertimeResult, error) {
+	policy := entities.OvertimePolicy{
+		DailyThresholdHours:  config.Cfg.Overtime.DailyThresholdHours,
+		WeeklyThresholdHours: config.Cfg.Overtime.WeeklyThresholdHours,
+	}
+
+	checkIn := record.Local(record.CheckInAt)
+	dayStart := hours.StartOfDay(checkIn)
+	weekStart := hours.StartOfWeek(checkIn, time.Monday)
+
+	workedToday, err := s.repo.SumHoursWorked(ctx, record.EmployeeID, dayStart, checkIn)
+	if err != nil {
+		return entities.OvertimeResult{}, err
+	}
+
+	workedThisWeek, err := s.repo.SumHoursWorked(ctx, record.EmployeeID, weekStart, checkIn)
+	if err != nil {
+		return entities.OvertimeResult{}, err
+	}
+
+	return policy.Apply(record, workedToday, workedThisWeek), nil
 }
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/infrastructure/persistence"
+)
+
+var errStoreDown = stderrors.New("store down")
+
+// unreachableRecords fails every lookup of an open record
+type unreachableRecords struct {
+	*persistence.MemoryTimeRecordRepository
+}
+
+func (unreachableRecords) FindActiveByEmployeeID(ctx context.Context, employeeID string) (*entities.TimeRecord, error) {
+	return nil, errStoreDown
+}
+
+func TestFindActiveFailurePropagates(t *testing.T) {
+	ctx := context.Background()
+	memory := persistence.NewMemoryTimeRecordRepository(persistence.NewMemoryOutboxRepository())
+	records := unreachableRecords{memory}
+
+	t.Run("check-out", func(t *testing.T) {
+		service := NewCheckOutService(records, noHolidays{}, roster{}, nil)
+		if _, err := service.CheckOut(ctx, "emp-1", CheckOutOptions{}); !stderrors.Is(err, errStoreDown) {
+			t.Fatalf("CheckOut error = %v, want %v", err, errStoreDown)
+		}
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		merges := persistence.NewMemoryEmployeeMergeRepository(memory, persistence.NewMemoryConsentRepository(), persistence.NewMemoryApprovalRepository(memory), persistence.NewMemoryShiftExceptionRepository(memory))
+		service := NewEmployeeMergeService(records, merges)
+		if _, err := service.Merge(ctx, "emp-old", "emp-new", "hr-1", "re-issued"); !stderrors.Is(err, errStoreDown) {
+			t.Fatalf("Merge error = %v, want %v", err, errStoreDown)
+		}
+		if id, err := merges.ResolveEmployeeID(ctx, "emp-old"); err != nil || id != "emp-old" {
+			t.Fatalf("emp-old resolves to %q (%v) after a failed merge", id, err)
+		}
+	})
+}
@@ -0,0 +1,300 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"github.com/leo-andrei/check-in-service/infrastructure/metrics"
+	"go.uber.org/zap"
+)
+
+// Command is a request to change time records, whatever entry point it came
+// from: HTTP, gRPC, a vendor terminal, an email reply or a bulk import
+type Command interface {
+	CommandName() string
+	// TargetEmployeeID is the employee whose records the command changes
+	TargetEmployeeID() string
+}
+
+const (
+	CommandCheckIn       = "check_in"
+	CommandCheckOut      = "check_out"
+	CommandCorrectRecord = "correct_record"
+	CommandTransfer      = "transfer"
+)
+
+// CheckInCommand opens a record; it fails when the employee is checked in
+type CheckInCommand struct {
+	EmployeeID string `validate:"required,max=255"`
+	Options    CheckInOptions
+}
+
+func (c CheckInCommand) CommandName() string      { return CommandCheckIn }
+func (c CheckInCommand) TargetEmployeeID() string { return c.EmployeeID }
+
+// CheckInResult is what CheckInCommand returns: the new record and the
+// results of the check-in hooks
+type CheckInResult struct {
+	Record   *entities.TimeRecord
+	Metadata map[string]interface{}
+}
+
+// CheckOutCommand closes the open record and returns it
+type CheckOutCommand struct {
+	EmployeeID string `validate:"required,max=255"`
+	Options    CheckOutOptions
+}
+
+func (c CheckOutCommand) CommandName() string      { return CommandCheckOut }
+func (c CheckOutCommand) TargetEmployeeID() string { return c.EmployeeID }
+
+// TransferCommand closes the open record and opens one at another location
+type TransferCommand struct {
+	EmployeeID string `validate:"required,max=255"`
+	CheckOut   CheckOutOptions
+	CheckIn    CheckInOptions
+}
+
+func (c TransferCommand) CommandName() string      { return CommandTransfer }
+func (c TransferCommand) TargetEmployeeID() string { return c.EmployeeID }
+
+// TransferResult is what TransferCommand returns: the closed record, the new
+// one and the results of the check-in hooks
+type TransferResult struct {
+	Closed   *entities.TimeRecord
+	Record   *entities.TimeRecord
+	Metadata map[string]interface{}
+}
+
+// CorrectRecordCommand submits a correction, or a manual entry, for the
+// manager's approval and returns the pending approval
+type CorrectRecordCommand struct {
+	EmployeeID string `validate:"required,max=255"`
+	Submission ApprovalSubmission
+}
+
+func (c CorrectRecordCommand) CommandName() string      { return CommandCorrectRecord }
+func (c CorrectRecordCommand) TargetEmployeeID() string { return c.EmployeeID }
+
+// ActorKind tells who issued a command
+type ActorKind string
+
+const (
+	ActorAPI      ActorKind = "api"      // unauthenticated API client
+	ActorDevice   ActorKind = "device"   // enrolled kiosk or terminal
+	ActorEmployee ActorKind = "employee" // the employee themselves, e.g. by email
+	ActorAdmin    ActorKind = "admin"
+	ActorImport   ActorKind = "import"
+)
+
+// Actor issued a command; ID is empty for anonymous API clients
+type Actor struct {
+	Kind ActorKind
+	ID   string
+}
+
+// String is the kind, followed by the ID when there is one, e.g. "device:kiosk-1"
+func (a Actor) String() string {
+	if a.ID == "" {
+		return string(a.Kind)
+	}
+	return string(a.Kind) + ":" + a.ID
+}
+
+type actorKey struct{}
+
+// WithActor attaches the actor issuing the commands dispatched with ctx
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom returns the actor of ctx, an anonymous API client when none was set
+func ActorFrom(ctx context.Context) Actor {
+	if actor, ok := ctx.Value(actorKey{}).(Actor); ok {
+		return actor
+	}
+	return Actor{Kind: ActorAPI}
+}
+
+// CommandHandler executes a command and returns its result
+type CommandHandler func(ctx context.Context, cmd Command) (interface{}, error)
+
+// CommandMiddleware wraps every handler of the bus
+type CommandMiddleware func(next CommandHandler) CommandHandler
+
+// CommandBus routes each command to its handler through the middleware, so
+// every entry point gets the same validation, authorization, audit and metrics
+type CommandBus struct {
+	handlers   map[string]CommandHandler
+	middleware []CommandMiddleware
+}
+
+// NewCommandBus applies middleware in order, the first one outermost
+func NewCommandBus(middleware ...CommandMiddleware) *CommandBus {
+	return &CommandBus{
+		handlers:   make(map[string]CommandHandler),
+		middleware: middleware,
+	}
+}
+
+// Register sets the handler of the named command; register all handlers
+// before dispatching
+func (b *CommandBus) Register(name string, handler CommandHandler) {
+	for i := len(b.middleware) - 1; i >= 0; i-- {
+		handler = b.middleware[i](handler)
+	}
+	b.handlers[name] = handler
+}
+
+func (b *CommandBus) Dispatch(ctx context.Context, cmd Command) (interface{}, error) {
+	handler, ok := b.handlers[cmd.CommandName()]
+	if !ok {
+		return nil, fmt.Errorf("no handler registered for command %s", cmd.CommandName())
+	}
+	return handler(ctx, cmd)
+}
+
+// CheckIn dispatches a CheckInCommand
+func (b *CommandBus) CheckIn(ctx context.Context, cmd CheckInCommand) (*entities.TimeRecord, map[string]interface{}, error) {
+	result, err := b.Dispatch(ctx, cmd)
+	if err != nil {
+		return nil, nil, err
+	}
+	checkedIn := result.(CheckInResult)
+	return checkedIn.Record, checkedIn.Metadata, nil
+}
+
+// CheckOut dispatches a CheckOutCommand
+func (b *CommandBus) CheckOut(ctx context.Context, cmd CheckOutCommand) (*entities.TimeRecord, error) {
+	result, err := b.Dispatch(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	return result.(*entities.TimeRecord), nil
+}
+
+// Transfer dispatches a TransferCommand
+func (b *CommandBus) Transfer(ctx context.Context, cmd TransferCommand) (TransferResult, error) {
+	result, err := b.Dispatch(ctx, cmd)
+	if err != nil {
+		return TransferResult{}, err
+	}
+	return result.(TransferResult), nil
+}
+
+// CorrectRecord dispatches a CorrectRecordCommand
+func (b *CommandBus) CorrectRecord(ctx context.Context, cmd CorrectRecordCommand) (*entities.Approval, error) {
+	result, err := b.Dispatch(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	return result.(*entities.Approval), nil
+}
+
+// RegisterTimeRecordCommands routes the time record commands to the services
+func RegisterTimeRecordCommands(bus *CommandBus, checkIn *CheckInService, checkOut *CheckOutService, transfers *TransferService, approvals *ApprovalService) {
+	bus.Register(CommandCheckIn, func(ctx context.Context, cmd Command) (interface{}, error) {
+		c := cmd.(CheckInCommand)
+		record, metadata, err := checkIn.CheckIn(ctx, c.EmployeeID, c.Options)
+		if err != nil {
+			return nil, err
+		}
+		return CheckInResult{Record: record, Metadata: metadata}, nil
+	})
+	bus.Register(CommandCheckOut, func(ctx context.Context, cmd Command) (interface{}, error) {
+		c := cmd.(CheckOutCommand)
+		record, err := checkOut.CheckOut(ctx, c.EmployeeID, c.Options)
+		if err != nil {
+			return nil, err
+		}
+		return record, nil
+	})
+	bus.Register(CommandTransfer, func(ctx context.Context, cmd Command) (interface{}, error) {
+		c := cmd.(TransferCommand)
+		closed, record, metadata, err := transfers.Transfer(ctx, c.EmployeeID, c.CheckOut, c.CheckIn)
+		if err != nil {
+			return nil, err
+		}
+		return TransferResult{Closed: closed, Record: record, Metadata: metadata}, nil
+	})
+	bus.Register(CommandCorrectRecord, func(ctx context.Context, cmd Command) (interface{}, error) {
+		c := cmd.(CorrectRecordCommand)
+		approval, err := approvals.Submit(ctx, c.EmployeeID, c.Submission)
+		if err != nil {
+			return nil, err
+		}
+		return approval, nil
+	})
+}
+
+var commandValidator = validator.New()
+
+// ValidateCommands rejects commands failing their struct tags
+func ValidateCommands(next CommandHandler) CommandHandler {
+	return func(ctx context.Context, cmd Command) (interface{}, error) {
+		if err := commandValidator.Struct(cmd); err != nil {
+			config.Logger.Warn(errors.ErrInvalidCommand, zap.String("command", cmd.CommandName()), zap.Error(err))
+			return nil, errors.ErrInvalidCommandConst
+		}
+		return next(ctx, cmd)
+	}
+}
+
+// AuthorizeCommands lets employees act only for themselves; devices, admins,
+// imports and API clients act for anyone, as their entry points
+// authenticated them already
+func AuthorizeCommands(next CommandHandler) CommandHandler {
+	return func(ctx context.Context, cmd Command) (interface{}, error) {
+		actor := ActorFrom(ctx)
+		if actor.Kind == ActorEmployee && actor.ID != cmd.TargetEmployeeID() {
+			config.Logger.Warn(errors.ErrCommandForbidden, zap.String("command", cmd.CommandName()),
+				zap.String("actor", actor.ID), zap.String("employee_id", cmd.TargetEmployeeID()))
+			return nil, errors.ErrCommandForbiddenConst
+		}
+		return next(ctx, cmd)
+	}
+}
+
+// AuditCommands logs every command with its actor and outcome, and names
+// them as the origin of the record changes the command makes
+func AuditCommands(next CommandHandler) CommandHandler {
+	return func(ctx context.Context, cmd Command) (interface{}, error) {
+		actor := ActorFrom(ctx)
+		ctx = repositories.WithChangeOrigin(ctx, repositories.ChangeOrigin{
+			Actor:  actor.String(),
+			Source: "command:" + cmd.CommandName(),
+		})
+		result, err := next(ctx, cmd)
+		fields := []zap.Field{
+			zap.String("command", cmd.CommandName()),
+			zap.String("employee_id", cmd.TargetEmployeeID()),
+			zap.String("actor_kind", string(actor.Kind)),
+			zap.String("actor", actor.ID),
+		}
+		if err != nil {
+			config.Logger.Info("Command rejected", append(fields, zap.Error(err))...)
+		} else {
+			config.Logger.Info("Command executed", fields...)
+		}
+		return result, err
+	}
+}
+
+// MeasureCommands times every command and counts the failed ones
+func MeasureCommands(next CommandHandler) CommandHandler {
+	return func(ctx context.Context, cmd Command) (interface{}, error) {
+		start := time.Now()
+		result, err := next(ctx, cmd)
+		metrics.Timing("command."+cmd.CommandName(), time.Since(start))
+		if err != nil {
+			metrics.Incr("command."+cmd.CommandName()+".failures", 1)
+		}
+		return result, err
+	}
+}
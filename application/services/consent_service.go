@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"go.uber.org/zap"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+)
+
+type ConsentService struct {
+	repo            repositories.ConsentRepository
+	requireExplicit bool
+}
+
+// NewConsentService creates the consent service. When requireExplicit is set,
+// processing is only allowed for employees that actively granted consent;
+// otherwise only an explicit withdrawal blocks processing.
+func NewConsentService(repo repositories.ConsentRepository, requireExplicit bool) *ConsentService {
+	return &ConsentService{
+		repo:            repo,
+		requireExplicit: requireExplicit,
+	}
+}
+
+func (s *ConsentService) Grant(ctx context.Context, employeeID string, purpose entities.ConsentPurpose, source string) (*entities.Consent, error) {
+	if !purpose.IsValid() {
+		return nil, errors.ErrInvalidConsentPurposeConst
+	}
+
+	consent, err := s.repo.FindByEmployeeAndPurpose(ctx, employeeID, purpose)
+	if err != nil {
+		return nil, err
+	}
+
+	if consent == nil {
+		consent, err = entities.NewConsent(employeeID, purpose, source)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		consent.Grant(source)
+	}
+
+	if err := s.repo.Save(ctx, consent); err != nil {
+		config.Logger.Error("Failed to save consent", zap.String("employee_id", employeeID), zap.String("purpose", string(purpose)), zap.Error(err))
+		return nil, fmt.Errorf("failed to grant consent: %w", err)
+	}
+
+	config.Logger.Info("Consent granted", zap.String("employee_id", employeeID), zap.String("purpose", string(purpose)), zap.String("source", source))
+	return consent, nil
+}
+
+func (s *ConsentService) Withdraw(ctx context.Context, employeeID string, purpose entities.ConsentPurpose, source string) (*entities.Consent, error) {
+	if !purpose.IsValid() {
+		return nil, errors.ErrInvalidConsentPurposeConst
+	}
+
+	consent, err := s.repo.FindByEmployeeAndPurpose(ctx, employeeID, purpose)
+	if err != nil {
+		return nil, err
+	}
+
+	if consent == nil {
+		// Record the withdrawal anyway so it is honoured even without a prior grant
+		consent, err = entities.NewConsent(employeeID, purpose, source)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !consent.IsActive() {
+		return consent, nil
+	}
+
+	if err := consent.Withdraw(source); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Save(ctx, consent); err != nil {
+		config.Logger.Error("Failed to save consent", zap.String("employee_id", employeeID), zap.String("purpose", string(purpose)), zap.Error(err))
+		return nil, fmt.Errorf("failed to withdraw consent: %w", err)
+	}
+
+	config.Logger.Info("Consent withdrawn", zap.String("employee_id", employeeID), zap.String("purpose", string(purpose)), zap.String("source", source))
+	return consent, nil
+}
+
+func (s *ConsentService) List(ctx context.Context, employeeID string) ([]*entities.Consent, error) {
+	return s.repo.FindByEmployee(ctx, employeeID)
+}
+
+// HasConsent reports whether data of the employee may be processed for the given purpose
+func (s *ConsentService) HasConsent(ctx context.Context, employeeID string, purpose entities.ConsentPurpose) (bool, error) {
+	consent, err := s.repo.FindByEmployeeAndPurpose(ctx, employeeID, purpose)
+	if err != nil {
+		return false, err
+	}
+
+	if consent == nil {
+		return !s.requireExplicit, nil
+	}
+
+	return consent.IsActive(), nil
+}
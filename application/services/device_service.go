@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"go.uber.org/zap"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+)
+
+// DeviceService provisions check-in kiosks and checks their credentials
+type DeviceService struct {
+	devices   repositories.DeviceRepository
+	locations repositories.LocationRepository
+}
+
+func NewDeviceService(devices repositories.DeviceRepository, locations repositories.LocationRepository) *DeviceService {
+	return &DeviceService{
+		devices:   devices,
+		locations: locations,
+	}
+}
+
+// Enrollment is what an admin hands to the person installing a kiosk
+type Enrollment struct {
+	Device    *entities.Device
+	Code      string
+	ExpiresAt time.Time
+}
+
+// DeviceCredentials are returned to the kiosk once; only their hash is stored
+type DeviceCredentials struct {
+	DeviceID  string
+	Secret    string
+	ExpiresAt time.Time
+}
+
+// Create registers a kiosk and returns its one-time enrollment code
+func (s *DeviceService) Create(ctx context.Context, name, locationID string) (*Enrollment, error) {
+	if locationID != "" {
+		location, err := s.locations.FindByID(ctx, locationID)
+		if err != nil {
+			return nil, err
+		}
+		if location == nil {
+			return nil, errors.ErrUnknownLocationConst
+		}
+	}
+
+	device, code, err := entities.NewDevice(name, locationID, enrollmentTTL())
+	if err != nil {
+		return nil, errors.ErrInvalidDeviceConst
+	}
+
+	if err := s.devices.Save(ctx, device); err != nil {
+		config.Logger.Error("Failed to save device", zap.String("device_id", device.ID), zap.Error(err))
+		return nil, fmt.Errorf("failed to save device: %w", err)
+	}
+
+	config.Logger.Info("Device created", zap.String("device_id", device.ID), zap.String("location_id", locationID))
+	return &Enrollment{Device: device, Code: code, ExpiresAt: *device.EnrollmentExpiresAt}, nil
+}
+
+func (s *DeviceService) List(ctx context.Context) ([]*entities.Device, error) {
+	return s.devices.FindAll(ctx)
+}
+
+// ResetEnrollment issues a new enrollment code and invalidates the kiosk's
+// current credentials
+func (s *DeviceService) ResetEnrollment(ctx context.Context, deviceID string) (*Enrollment, error) {
+	device, err := s.find(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if device.Status == entities.DeviceRevoked {
+		return nil, errors.ErrDeviceRevokedConst
+	}
+
+	code := device.ResetEnrollment(enrollmentTTL())
+	if err := s.devices.Save(ctx, device); err != nil {
+		return nil, fmt.Errorf("failed to save device: %w", err)
+	}
+
+	config.Logger.Info("Device enrollment reset", zap.String("device_id", deviceID))
+	return &Enrollment{Device: device, Code: code, ExpiresAt: *device.EnrollmentExpiresAt}, nil
+}
+
+// Enroll exchanges a one-time enrollment code for the kiosk's first credentials
+func (s *DeviceService) Enroll(ctx context.Context, deviceID, code string) (*DeviceCredentials, error) {
+	device, err := s.find(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := device.Enroll(code, credentialLifetime())
+	if err != nil {
+		config.Logger.Warn(errors.ErrInvalidEnrollmentCode, zap.String("device_id", deviceID), zap.Error(err))
+		return nil, errors.ErrInvalidEnrollmentCodeConst
+	}
+
+	if err := s.devices.Save(ctx, device); err != nil {
+		return nil, fmt.Errorf("failed to save device: %w", err)
+	}
+
+	config.Logger.Info("Device enrolled", zap.String("device_id", deviceID))
+	return &DeviceCredentials{DeviceID: device.ID, Secret: secret, ExpiresAt: *device.SecretExpiresAt}, nil
+}
+
+// Rotate replaces an authenticated device's secret
+func (s *DeviceService) Rotate(ctx context.Context, device *entities.Device) (*DeviceCredentials, error) {
+	grace := time.Duration(config.Cfg.Devices.RotationGraceMin) * time.Minute
+	secret, err := device.Rotate(credentialLifetime(), grace)
+	if err != nil {
+		return nil, errors.ErrDeviceUnauthorizedConst
+	}
+
+	if err := s.devices.Save(ctx, device); err != nil {
+		return nil, fmt.Errorf("failed to save device: %w", err)
+	}
+
+	config.Logger.Info("Device credentials rotated", zap.String("device_id", device.ID))
+	return &DeviceCredentials{DeviceID: device.ID, Secret: secret, ExpiresAt: *device.SecretExpiresAt}, nil
+}
+
+// Revoke blocks a compromised device for good
+func (s *DeviceService) Revoke(ctx context.Context, deviceID string) (*entities.Device, error) {
+	device, err := s.find(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	device.Revoke()
+	if err := s.devices.Save(ctx, device); err != nil {
+		return nil, fmt.Errorf("failed to save device: %w", err)
+	}
+
+	config.Logger.Warn("Device revoked", zap.String("device_id", deviceID))
+	return device, nil
+}
+
+// Authenticate returns the device the credentials belong to
+func (s *DeviceService) Authenticate(ctx context.Context, deviceID, secret string) (*entities.Device, error) {
+	device, err := s.devices.FindByID(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if device == nil || !device.Authenticate(secret) {
+		return nil, errors.ErrDeviceUnauthorizedConst
+	}
+	return device, nil
+}
+
+// RotationDue reports whether the device should rotate its credentials now,
+// i.e. they are in the last RotateBeforeHours of their lifetime
+func (s *DeviceService) RotationDue(device *entities.Device) bool {
+	if device.SecretExpiresAt == nil {
+		return false
+	}
+	window := time.Duration(config.Cfg.Devices.RotateBeforeHours) * time.Hour
+	return time.Until(*device.SecretExpiresAt) < window
+}
+
+func (s *DeviceService) find(ctx context.Context, deviceID string) (*entities.Device, error) {
+	device, err := s.devices.FindByID(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if device == nil {
+		return nil, errors.ErrDeviceNotFoundConst
+	}
+	return device, nil
+}
+
+func enrollmentTTL() time.Duration {
+	return time.Duration(config.Cfg.Devices.EnrollmentTTLMin) * time.Minute
+}
+
+func credentialLifetime() time.Duration {
+	return time.Duration(config.Cfg.Devices.CredentialTTLHours) * time.Hour
+}
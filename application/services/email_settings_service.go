@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"go.uber.org/zap"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+)
+
+// EmailSettingsService manages how the check-out summary email looks. The
+// email worker reads the settings for every message, so they are cached for
+// cacheTTL; changes made on another instance show up once the cache expires.
+type EmailSettingsService struct {
+	settings repositories.EmailSettingsRepository
+	cacheTTL time.Duration
+
+	mu        sync.Mutex
+	cached    *entities.CheckOutEmailSettings
+	fetchedAt time.Time
+}
+
+func NewEmailSettingsService(settings repositories.EmailSettingsRepository, cacheTTL time.Duration) *EmailSettingsService {
+	return &EmailSettingsService{
+		settings: settings,
+		cacheTTL: cacheTTL,
+	}
+}
+
+// CheckOutEmailDetails are the editable settings of the summary email
+type CheckOutEmailDetails struct {
+	LogoURL      string
+	FooterText   string
+	ShowHours    bool
+	ShowCost     bool
+	ShowOvertime bool
+}
+
+// CheckOutEmail returns the current settings, or the defaults when they were
+// never customized. A stale copy is served while the database is unreachable.
+func (s *EmailSettingsService) CheckOutEmail(ctx context.Context) (*entities.CheckOutEmailSettings, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cached != nil && time.Since(s.fetchedAt) < s.cacheTTL {
+		return s.cached, nil
+	}
+
+	settings, err := s.settings.FindCheckOutEmail(ctx)
+	if err != nil {
+		if s.cached != nil {
+			config.Logger.Warn("Serving cached check-out email settings", zap.Error(err))
+			return s.cached, nil
+		}
+		return nil, err
+	}
+	if settings == nil {
+		settings = entities.DefaultCheckOutEmailSettings()
+	}
+
+	s.cached = settings
+	s.fetchedAt = time.Now()
+	return settings, nil
+}
+
+// SaveCheckOutEmail replaces the settings and refreshes this instance's cache
+func (s *EmailSettingsService) SaveCheckOutEmail(ctx context.Context, details CheckOutEmailDetails, actor string) (*entities.CheckOutEmailSettings, error) {
+	settings := entities.DefaultCheckOutEmailSettings()
+	if err := settings.Update(details.LogoURL, details.FooterText, details.ShowHours, details.ShowCost, details.ShowOvertime, actor); err != nil {
+		return nil, errors.ErrInvalidEmailSettingsConst
+	}
+
+	if err := s.settings.SaveCheckOutEmail(ctx, settings); err != nil {
+		config.Logger.Error("Failed to save check-out email settings", zap.Error(err))
+		return nil, fmt.Errorf("failed to save check-out email settings: %w", err)
+	}
+
+	s.mu.Lock()
+	s.cached = settings
+	s.fetchedAt = time.Now()
+	s.mu.Unlock()
+
+	config.Logger.Info("Check-out email settings updated", zap.String("actor", actor))
+	return settings, nil
+}
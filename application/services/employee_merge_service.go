@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"go.uber.org/zap"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+	"github.com/leo-andrei/check-in-service/domain/events"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+)
+
+type EmployeeMergeService struct {
+	records repositories.TimeRecordRepository
+	merges  repositories.EmployeeMergeRepository
+}
+
+func NewEmployeeMergeService(records repositories.TimeRecordRepository, merges repositories.EmployeeMergeRepository) *EmployeeMergeService {
+	return &EmployeeMergeService{
+		records: records,
+		merges:  merges,
+	}
+}
+
+// MergeResult summarizes a completed merge
+type MergeResult struct {
+	SourceEmployeeID string `json:"source_employee_id"`
+	TargetEmployeeID string `json:"target_employee_id"`
+	RecordsMoved     int    `json:"records_moved"`
+}
+
+// Merge re-attributes everything recorded under sourceID to targetID. It is
+// refused while both IDs are checked in, since that would leave the target
+// with two open records.
+func (s *EmployeeMergeService) Merge(ctx context.Context, sourceID, targetID, actor, reason string) (*MergeResult, error) {
+	// Merging into an ID that was itself merged goes to its canonical ID
+	targetID, err := s.merges.ResolveEmployeeID(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = repositories.WithChangeOrigin(ctx, repositories.ChangeOrigin{Actor: actor, Source: "employee_merge"})
+
+	merge, err := entities.NewEmployeeMerge(sourceID, targetID, actor, reason)
+	if err != nil {
+		return nil, errors.ErrInvalidMergeConst
+	}
+
+	sourceActive, err := s.records.FindActiveByEmployeeID(ctx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	targetActive, err := s.records.FindActiveByEmployeeID(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if sourceActive != nil && targetActive != nil {
+		config.Logger.Warn(errors.ErrMergeConflict, zap.String("source_employee_id", sourceID), zap.String("target_employee_id", targetID))
+		return nil, errors.ErrMergeConflictConst
+	}
+
+	event := events.EmployeesMergedEvent{
+		EventHeader: events.EventHeader{
+			EventID:   uuid.New().String(),
+			EventType: events.EventTypeEmployeesMerged,
+			Version:   1,
+			Timestamp: time.Now(),
+		},
+		SourceEmployeeID: sourceID,
+		TargetEmployeeID: targetID,
+		Actor:            actor,
+		Reason:           reason,
+	}
+
+	moved, err := s.merges.Merge(ctx, merge, event)
+	if err != nil {
+		config.Logger.Error("Failed to merge employees", zap.String("source_employee_id", sourceID), zap.String("target_employee_id", targetID), zap.Error(err))
+		return nil, fmt.Errorf("failed to merge employees: %w", err)
+	}
+
+	config.Logger.Info("Employees merged",
+		zap.String("source_employee_id", sourceID),
+		zap.String("target_employee_id", targetID),
+		zap.Int("records_moved", moved),
+		zap.String("actor", actor),
+	)
+
+	return &MergeResult{
+		SourceEmployeeID: sourceID,
+		TargetEmployeeID: targetID,
+		RecordsMoved:     moved,
+	}, nil
+}
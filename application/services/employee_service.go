@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"go.uber.org/zap"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+)
+
+type EmployeeService struct {
+	employees repositories.EmployeeRepository
+}
+
+func NewEmployeeService(employees repositories.EmployeeRepository) *EmployeeService {
+	return &EmployeeService{
+		employees: employees,
+	}
+}
+
+// EmployeeDetails are the editable roster fields
+type EmployeeDetails struct {
+	Name       string
+	Email      string
+	ManagerID  string
+	HourlyRate float64
+	Currency   string // Empty for the default currency
+	Active     bool
+}
+
+// Save creates the employee or updates their roster details
+func (s *EmployeeService) Save(ctx context.Context, id string, details EmployeeDetails) (*entities.Employee, error) {
+	if details.ManagerID != "" {
+		manager, err := s.employees.FindByID(ctx, details.ManagerID)
+		if err != nil {
+			return nil, err
+		}
+		if manager == nil {
+			return nil, errors.ErrUnknownManagerConst
+		}
+	}
+
+	employee, err := s.employees.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if employee == nil {
+		employee, err = entities.NewEmployee(id, details.Name, details.Email)
+		if err != nil {
+			return nil, errors.ErrInvalidEmployeeConst
+		}
+	}
+	if err := employee.Update(details.Name, details.Email, details.ManagerID, details.HourlyRate, details.Currency, details.Active); err != nil {
+		return nil, errors.ErrInvalidEmployeeConst
+	}
+
+	if err := s.employees.Save(ctx, employee); err != nil {
+		config.Logger.Error("Failed to save employee", zap.String("employee_id", id), zap.Error(err))
+		return nil, fmt.Errorf("failed to save employee: %w", err)
+	}
+
+	return employee, nil
+}
+
+func (s *EmployeeService) Get(ctx context.Context, id string) (*entities.Employee, error) {
+	employee, err := s.employees.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if employee == nil {
+		return nil, errors.ErrEmployeeNotFoundConst
+	}
+	return employee, nil
+}
+
+func (s *EmployeeService) List(ctx context.Context) ([]*entities.Employee, error) {
+	return s.employees.FindAll(ctx)
+}
+
+func (s *EmployeeService) Delete(ctx context.Context, id string) error {
+	if _, err := s.Get(ctx, id); err != nil {
+		return err
+	}
+
+	if err := s.employees.Delete(ctx, id); err != nil {
+		config.Logger.Error("Failed to delete employee", zap.String("employee_id", id), zap.Error(err))
+		return err
+	}
+
+	config.Logger.Info("Employee removed from roster", zap.String("employee_id", id))
+	return nil
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/leo-andrei/check-in-service/domain/events"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"go.uber.org/zap"
+)
+
+// EventRecorder emits events that are not tied to saving a time record, e.g.
+// reminders. They go through the outbox like every other event, so they are
+// published even when the broker is down at the time.
+type EventRecorder struct {
+	outbox repositories.OutboxRepository
+}
+
+func NewEventRecorder(outbox repositories.OutboxRepository) *EventRecorder {
+	return &EventRecorder{outbox: outbox}
+}
+
+// Record stores the event for the outbox relay to publish
+func (r *EventRecorder) Record(ctx context.Context, event events.DomainEvent) error {
+	if err := r.outbox.SaveEvent(ctx, event); err != nil {
+		config.Logger.Error("Failed to record event", zap.String("event_type", event.EventType()), zap.Error(err))
+		return fmt.Errorf("failed to record event: %w", err)
+	}
+
+	config.Logger.Debug("Event recorded", zap.String("event_type", event.EventType()), zap.String("employee_id", events.EmployeeOf(event)))
+	return nil
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"go.uber.org/zap"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+	"github.com/leo-andrei/check-in-service/domain/events"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+)
+
+// ExceptionService compares the shift schedules with the actual check-ins,
+// records the shifts nobody showed up for and notifies the managers
+type ExceptionService struct {
+	schedules  repositories.ScheduleRepository
+	exceptions repositories.ShiftExceptionRepository
+	records    repositories.TimeRecordRepository
+	employees  repositories.EmployeeRepository
+	holidays   repositories.HolidayRepository
+	grace      time.Duration
+}
+
+func NewExceptionService(schedules repositories.ScheduleRepository, exceptions repositories.ShiftExceptionRepository, records repositories.TimeRecordRepository, employees repositories.EmployeeRepository, holidays repositories.HolidayRepository, grace time.Duration) *ExceptionService {
+	return &ExceptionService{
+		schedules:  schedules,
+		exceptions: exceptions,
+		records:    records,
+		employees:  employees,
+		holidays:   holidays,
+		grace:      grace,
+	}
+}
+
+// ShiftInput is one weekly shift of a schedule being set
+type ShiftInput struct {
+	Weekday    time.Weekday
+	Start      string
+	End        string
+	LocationID string
+	TimeZone   string // the default zone when empty
+}
+
+// SetSchedule replaces the employee's weekly schedule; no shifts clears it
+func (s *ExceptionService) SetSchedule(ctx context.Context, employeeID string, inputs []ShiftInput) ([]*entities.ScheduledShift, error) {
+	employee, err := s.employees.FindByID(ctx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	if employee == nil {
+		return nil, errors.ErrEmployeeNotFoundConst
+	}
+
+	shifts := make([]*entities.ScheduledShift, 0, len(inputs))
+	for _, input := range inputs {
+		timeZone := input.TimeZone
+		if timeZone == "" {
+			timeZone = config.Cfg.DefaultTimeZone
+		}
+		shift, err := entities.NewScheduledShift(employeeID, input.Weekday, input.Start, input.End, input.LocationID, timeZone)
+		if err != nil {
+			config.Logger.Warn(errors.ErrInvalidSchedule, zap.String("employee_id", employeeID), zap.Error(err))
+			return nil, errors.ErrInvalidScheduleConst
+		}
+		shifts = append(shifts, shift)
+	}
+
+	if err := s.schedules.ReplaceForEmployee(ctx, employeeID, shifts); err != nil {
+		config.Logger.Error("Failed to save schedule", zap.String("employee_id", employeeID), zap.Error(err))
+		return nil, fmt.Errorf("failed to save schedule: %w", err)
+	}
+	return shifts, nil
+}
+
+func (s *ExceptionService) Schedule(ctx context.Context, employeeID string) ([]*entities.ScheduledShift, error) {
+	return s.schedules.FindByEmployee(ctx, employeeID)
+}
+
+// DetectRecent checks the shifts of yesterday and today in the default zone,
+// so overnight shifts and late scans are covered; shifts already checked
+// are skipped
+func (s *ExceptionService) DetectRecent(ctx context.Context) (int, error) {
+	loc, err := entities.LoadTimeZone(config.Cfg.DefaultTimeZone)
+	if err != nil {
+		return 0, err
+	}
+	today := time.Now().In(loc)
+
+	detected := 0
+	for _, day := range []time.Time{today.AddDate(0, 0, -1), today} {
+		n, err := s.Detect(ctx, day.Format(entities.BusinessDateLayout))
+		detected += n
+		if err != nil {
+			return detected, err
+		}
+	}
+	return detected, nil
+}
+
+// Detect records a no-show for every shift scheduled on date (YYYY-MM-DD)
+// that ended more than the grace period ago without a check-in overlapping
+// it, and returns how many it recorded. Voided records do not count as
+// attendance; inactive employees are not expected, and nobody is on a holiday.
+func (s *ExceptionService) Detect(ctx context.Context, date string) (int, error) {
+	day, err := time.Parse(entities.BusinessDateLayout, date)
+	if err != nil {
+		return 0, errors.ErrInvalidExceptionDateConst
+	}
+
+	holidays, err := s.holidays.FindInRange(ctx, date, date)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load holidays: %w", err)
+	}
+	if len(holidays) > 0 {
+		config.Logger.Debug("Skipping no-show detection on a holiday", zap.String("date", date), zap.String("holiday", holidays[0].Name))
+		return 0, nil
+	}
+
+	shifts, err := s.schedules.FindByWeekday(ctx, day.Weekday())
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	detected := 0
+	for _, shift := range shifts {
+		start, end, err := shift.On(date)
+		if err != nil {
+			config.Logger.Warn(errors.ErrInvalidSchedule, zap.String("employee_id", shift.EmployeeID), zap.Error(err))
+			continue
+		}
+		if now.Before(end.Add(s.grace)) {
+			continue
+		}
+
+		attended, err := s.attended(ctx, shift.EmployeeID, start, end)
+		if err != nil {
+			return detected, err
+		}
+		if attended {
+			continue
+		}
+
+		employee, err := s.employees.FindByID(ctx, shift.EmployeeID)
+		if err != nil {
+			return detected, err
+		}
+		if employee != nil && !employee.Active {
+			continue
+		}
+		managerID := ""
+		if employee != nil {
+			managerID = employee.ManagerID
+		}
+
+		exception := entities.NewNoShow(shift, managerID, date, start, end)
+		event := events.EmployeeNoShowEvent{
+			EventHeader: events.EventHeader{
+				EventID:   uuid.New().String(),
+				EventType: events.EventTypeEmployeeNoShow,
+				Version:   1,
+				Timestamp: now,
+				Priority:  events.PriorityRoutine,
+			},
+			ExceptionID:    exception.ID,
+			EmployeeID:     exception.EmployeeID,
+			ManagerID:      exception.ManagerID,
+			BusinessDate:   exception.BusinessDate,
+			ScheduledStart: exception.ScheduledStart,
+			ScheduledEnd:   exception.ScheduledEnd,
+			LocationID:     exception.LocationID,
+			TimeZone:       exception.TimeZone,
+		}
+
+		saved, err := s.exceptions.SaveWithEvent(ctx, exception, event)
+		if err != nil {
+			return detected, fmt.Errorf("failed to record no-show of %s: %w", shift.EmployeeID, err)
+		}
+		if saved {
+			detected++
+			config.Logger.Info("No-show detected", zap.String("employee_id", shift.EmployeeID), zap.String("date", date), zap.Time("scheduled_start", start))
+		}
+	}
+
+	return detected, nil
+}
+
+func (s *ExceptionService) attended(ctx context.Context, employeeID string, start, end time.Time) (bool, error) {
+	records, err := s.records.FindByEmployeeInRange(ctx, employeeID, start, end)
+	if err != nil {
+		return false, fmt.Errorf("failed to load records: %w", err)
+	}
+	for _, record := range records {
+		if record.Status != entities.StatusVoided {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Report returns the exceptions of shifts scheduled on date (YYYY-MM-DD)
+func (s *ExceptionService) Report(ctx context.Context, date string) ([]*entities.ShiftException, error) {
+	if _, err := time.Parse(entities.BusinessDateLayout, date); err != nil {
+		return nil, errors.ErrInvalidExceptionDateConst
+	}
+	return s.exceptions.FindByDate(ctx, date)
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/infrastructure/persistence"
+)
+
+func TestDetectSkipsHolidays(t *testing.T) {
+	tests := []struct {
+		name    string
+		holiday string
+		want    int
+	}{
+		{name: "working day", want: 1},
+		{name: "holiday", holiday: "2026-03-02", want: 0},
+		{name: "holiday on another day", holiday: "2026-03-03", want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			records := persistence.NewMemoryTimeRecordRepository(persistence.NewMemoryOutboxRepository())
+			schedules := persistence.NewMemoryScheduleRepository()
+			holidays := persistence.NewMemoryHolidayRepository()
+			employees := roster{"emp-1": {ID: "emp-1", Active: true}}
+			service := NewExceptionService(schedules, persistence.NewMemoryShiftExceptionRepository(records), records, employees, holidays, time.Minute)
+
+			if _, err := service.SetSchedule(ctx, "emp-1", []ShiftInput{{Weekday: time.Monday, Start: "09:00", End: "17:00", TimeZone: "UTC"}}); err != nil {
+				t.Fatal(err)
+			}
+			if tt.holiday != "" {
+				holiday, err := entities.NewHoliday(tt.holiday, "Company day")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := holidays.Save(ctx, holiday); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			detected, err := service.Detect(ctx, "2026-03-02")
+			if err != nil {
+				t.Fatalf("Detect error = %v", err)
+			}
+			if detected != tt.want {
+				t.Fatalf("detected %d no-shows, want %d", detected, tt.want)
+			}
+		})
+	}
+}
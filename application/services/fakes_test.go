@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+type noEmployees struct{}
+
+func (noEmployees) Save(ctx context.Context, employee *entities.Employee) error { return nil }
+func (noEmployees) FindByID(ctx context.Context, id string) (*entities.Employee, error) {
+	return nil, nil
+}
+func (noEmployees) FindByEmail(ctx context.Context, email string) (*entities.Employee, error) {
+	return nil, nil
+}
+func (noEmployees) FindAll(ctx context.Context) ([]*entities.Employee, error) { return nil, nil }
+func (noEmployees) Delete(ctx context.Context, id string) error               { return nil }
+
+type noHolidays struct{}
+
+func (noHolidays) Save(ctx context.Context, holiday *entities.Holiday) error { return nil }
+func (noHolidays) Delete(ctx context.Context, date string) (bool, error)     { return false, nil }
+func (noHolidays) FindInRange(ctx context.Context, from, to string) ([]*entities.Holiday, error) {
+	return nil, nil
+}
+
+type noNotes struct{}
+
+func (noNotes) Save(ctx context.Context, note *entities.RecordNote) error { return nil }
+func (noNotes) FindByRecords(ctx context.Context, employeeID string, recordIDs []string) ([]*entities.RecordNote, error) {
+	return nil, nil
+}
+
+// locations serves a fixed set of sites
+type locations map[string]*entities.Location
+
+func (l locations) Save(ctx context.Context, location *entities.Location) error { return nil }
+func (l locations) FindByID(ctx context.Context, id string) (*entities.Location, error) {
+	return l[id], nil
+}
+func (l locations) FindAll(ctx context.Context) ([]*entities.Location, error) { return nil, nil }
+
+type noProjects struct{}
+
+func (noProjects) Save(ctx context.Context, project *entities.Project) error { return nil }
+func (noProjects) FindByCode(ctx context.Context, code string) (*entities.Project, error) {
+	return nil, nil
+}
+func (noProjects) FindAll(ctx context.Context) ([]*entities.Project, error) { return nil, nil }
+
+// roster serves a fixed set of employees
+type roster map[string]*entities.Employee
+
+func (r roster) Save(ctx context.Context, employee *entities.Employee) error { return nil }
+func (r roster) FindByID(ctx context.Context, id string) (*entities.Employee, error) {
+	return r[id], nil
+}
+func (r roster) FindByEmail(ctx context.Context, email string) (*entities.Employee, error) {
+	return nil, nil
+}
+func (r roster) FindAll(ctx context.Context) ([]*entities.Employee, error) { return nil, nil }
+func (r roster) Delete(ctx context.Context, id string) error               { return nil }
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"go.uber.org/zap"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+)
+
+type HolidayService struct {
+	holidays repositories.HolidayRepository
+}
+
+func NewHolidayService(holidays repositories.HolidayRepository) *HolidayService {
+	return &HolidayService{
+		holidays: holidays,
+	}
+}
+
+// Save adds a holiday to the calendar or renames the one on that date
+func (s *HolidayService) Save(ctx context.Context, date, name string) (*entities.Holiday, error) {
+	holiday, err := entities.NewHoliday(date, name)
+	if err != nil {
+		return nil, errors.ErrInvalidHolidayConst
+	}
+
+	if err := s.holidays.Save(ctx, holiday); err != nil {
+		config.Logger.Error("Failed to save holiday", zap.String("date", date), zap.Error(err))
+		return nil, fmt.Errorf("failed to save holiday: %w", err)
+	}
+
+	return holiday, nil
+}
+
+func (s *HolidayService) Delete(ctx context.Context, date string) error {
+	deleted, err := s.holidays.Delete(ctx, date)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errors.ErrHolidayNotFoundConst
+	}
+	return nil
+}
+
+// List returns the holidays of one calendar year
+func (s *HolidayService) List(ctx context.Context, year int) ([]*entities.Holiday, error) {
+	return s.holidays.FindInRange(ctx, fmt.Sprintf("%04d-01-01", year), fmt.Sprintf("%04d-12-31", year))
+}
+
+// Import saves holidays given as YYYY-MM-DD=Name, e.g. from configuration.
+// Existing holidays on the same dates are renamed.
+func (s *HolidayService) Import(ctx context.Context, entries []string) (int, error) {
+	imported := 0
+	for _, entry := range entries {
+		date, name, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			return imported, fmt.Errorf("invalid holiday %q: expected YYYY-MM-DD=Name", entry)
+		}
+		if _, err := s.Save(ctx, strings.TrimSpace(date), strings.TrimSpace(name)); err != nil {
+			return imported, fmt.Errorf("invalid holiday %q: %w", entry, err)
+		}
+		imported++
+	}
+	return imported, nil
+}
+
+// classifyPremiumHours fills in the holiday and weekend hours of a closed
+// record from the holiday calendar and the configured weekend days
+func classifyPremiumHours(ctx context.Context, holidays repositories.HolidayRepository, record *entities.TimeRecord) error {
+	if record.CheckOutAt == nil {
+		return nil
+	}
+
+	// Local dates can be a day off from UTC either way
+	from := record.CheckInAt.AddDate(0, 0, -1).Format(entities.BusinessDateLayout)
+	to := record.CheckOutAt.AddDate(0, 0, 1).Format(entities.BusinessDateLayout)
+	found, err := holidays.FindInRange(ctx, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to load holidays: %w", err)
+	}
+
+	dates := make(map[string]bool, len(found))
+	for _, holiday := range found {
+		dates[holiday.Date] = true
+	}
+
+	record.ClassifyPremiumHours(dates, weekendDays())
+	return nil
+}
+
+func weekendDays() map[time.Weekday]bool {
+	days := make(map[time.Weekday]bool)
+	for _, name := range config.Cfg.Holidays.WeekendDays {
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			if strings.EqualFold(day.String(), name) {
+				days[day] = true
+			}
+		}
+	}
+	return days
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"go.uber.org/zap"
+
+	"github.com/leo-andrei/check-in-service/domain/errors"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+)
+
+// IdempotencyService deduplicates retried requests and redelivered messages
+// across API instances. A key is locked for lockTimeout while its first
+// request runs, so a crashed instance does not hold it for the whole ttl.
+type IdempotencyService struct {
+	store       repositories.IdempotencyRepository
+	ttl         time.Duration
+	lockTimeout time.Duration
+}
+
+func NewIdempotencyService(store repositories.IdempotencyRepository, ttl, lockTimeout time.Duration) *IdempotencyService {
+	return &IdempotencyService{
+		store:       store,
+		ttl:         ttl,
+		lockTimeout: lockTimeout,
+	}
+}
+
+// Begin claims key for a request with the given fingerprint. It returns nil
+// when the request should run, or the stored response when it already ran.
+func (s *IdempotencyService) Begin(ctx context.Context, key, fingerprint string) (*repositories.IdempotencyRecord, error) {
+	existing, err := s.store.Reserve(ctx, key, fingerprint, time.Now().Add(s.lockTimeout))
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, nil
+	}
+
+	if existing.Fingerprint != fingerprint {
+		config.Logger.Warn(errors.ErrIdempotencyKeyReused, zap.String("key", key))
+		return nil, errors.ErrIdempotencyKeyReusedConst
+	}
+	if existing.Pending() {
+		return nil, errors.ErrIdempotencyKeyInProgressConst
+	}
+	return existing, nil
+}
+
+// Finish stores the response of the request that claimed key for replay
+func (s *IdempotencyService) Finish(ctx context.Context, key string, statusCode int, contentType string, body []byte) error {
+	if err := s.store.Complete(ctx, key, statusCode, contentType, body, time.Now().Add(s.ttl)); err != nil {
+		config.Logger.Error("Failed to store idempotent response", zap.String("key", key), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Abandon frees key after its request failed, so a retry runs it again
+func (s *IdempotencyService) Abandon(ctx context.Context, key string) error {
+	if err := s.store.Release(ctx, key); err != nil {
+		config.Logger.Error("Failed to release idempotency key", zap.String("key", key), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Once reports whether key is seen for the first time within the ttl, e.g.
+// a message ID from a webhook the provider may deliver more than once. Call
+// Abandon when processing the message fails.
+func (s *IdempotencyService) Once(ctx context.Context, key string) (bool, error) {
+	existing, err := s.store.Reserve(ctx, key, "", time.Now().Add(s.ttl))
+	if err != nil {
+		return false, err
+	}
+	return existing == nil, nil
+}
+
+// PurgeExpired removes expired keys from the log
+func (s *IdempotencyService) PurgeExpired(ctx context.Context) (int64, error) {
+	return s.store.DeleteExpired(ctx, time.Now())
+}
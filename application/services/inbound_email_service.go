@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"net/mail"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"go.uber.org/zap"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+)
+
+// InboundEmailService applies commands employees send by replying to our
+// emails, e.g. confirming a forgotten check-out. Commands never change records
+// directly: they file a correction for the manager to approve.
+type InboundEmailService struct {
+	employees repositories.EmployeeRepository
+	records   repositories.TimeRecordRepository
+	commands  *CommandBus
+	// Providers may deliver a message more than once, to any instance
+	idempotency *IdempotencyService
+}
+
+func NewInboundEmailService(employees repositories.EmployeeRepository, records repositories.TimeRecordRepository, commands *CommandBus, idempotency *IdempotencyService) *InboundEmailService {
+	return &InboundEmailService{
+		employees:   employees,
+		records:     records,
+		commands:    commands,
+		idempotency: idempotency,
+	}
+}
+
+// InboundEmail is a reply delivered by the email provider's inbound webhook
+type InboundEmail struct {
+	MessageID  string // Message-ID header; redeliveries of a message are ignored
+	From       string
+	Text       string
+	ReceivedAt time.Time
+}
+
+// Process identifies the employee by the sender address and turns a check-out
+// command into a correction of their open record
+func (s *InboundEmailService) Process(ctx context.Context, email InboundEmail) (*entities.Approval, error) {
+	if email.MessageID == "" {
+		return s.process(ctx, email)
+	}
+
+	key := "inbound-email:" + email.MessageID
+	first, err := s.idempotency.Once(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if !first {
+		config.Logger.Info(errors.ErrDuplicateInboundEmail, zap.String("message_id", email.MessageID))
+		return nil, errors.ErrDuplicateInboundEmailConst
+	}
+
+	approval, err := s.process(ctx, email)
+	if err != nil {
+		s.idempotency.Abandon(ctx, key)
+	}
+	return approval, err
+}
+
+func (s *InboundEmailService) process(ctx context.Context, email InboundEmail) (*entities.Approval, error) {
+	address, err := mail.ParseAddress(email.From)
+	if err != nil {
+		return nil, errors.ErrUnknownSenderConst
+	}
+
+	employee, err := s.employees.FindByEmail(ctx, address.Address)
+	if err != nil {
+		return nil, err
+	}
+	if employee == nil {
+		config.Logger.Warn(errors.ErrUnknownSender, zap.String("from", address.Address))
+		return nil, errors.ErrUnknownSenderConst
+	}
+
+	record, err := s.records.FindActiveByEmployeeID(ctx, employee.ID)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		config.Logger.Info(errors.ErrNoActiveCheckInFound, zap.String("employee_id", employee.ID))
+		return nil, errors.ErrNoActiveCheckInFoundConst
+	}
+
+	command, err := entities.ParseEmailCommand(email.Text, email.ReceivedAt, record.Local(email.ReceivedAt).Location())
+	if err != nil {
+		config.Logger.Info(errors.ErrInvalidEmailCommand, zap.String("employee_id", employee.ID), zap.Error(err))
+		return nil, errors.ErrInvalidEmailCommandConst
+	}
+	if !command.CheckOutAt.After(record.CheckInAt) {
+		config.Logger.Info(errors.ErrInvalidEmailCommand, zap.String("employee_id", employee.ID), zap.String("record_id", record.ID), zap.String("reason", "check-out before check-in"))
+		return nil, errors.ErrInvalidEmailCommandConst
+	}
+
+	// The sender address identified the employee, who acts for themselves
+	ctx = WithActor(ctx, Actor{Kind: ActorEmployee, ID: employee.ID})
+	approval, err := s.commands.CorrectRecord(ctx, CorrectRecordCommand{
+		EmployeeID: employee.ID,
+		Submission: ApprovalSubmission{
+			Kind:       entities.ApprovalCorrection,
+			RecordID:   record.ID,
+			CheckInAt:  record.CheckInAt,
+			CheckOutAt: command.CheckOutAt,
+			TimeZone:   record.TimeZone,
+			Note:       "Check-out confirmed by email reply: " + command.Line,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	config.Logger.Info("Email check-out submitted for approval", zap.String("employee_id", employee.ID), zap.String("record_id", record.ID), zap.String("approval_id", approval.ID))
+	return approval, nil
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"go.uber.org/zap"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+)
+
+type LocationService struct {
+	locations repositories.LocationRepository
+	records   repositories.TimeRecordRepository
+}
+
+func NewLocationService(locations repositories.LocationRepository, records repositories.TimeRecordRepository) *LocationService {
+	return &LocationService{
+		locations: locations,
+		records:   records,
+	}
+}
+
+// Save creates a location or updates its name, address, time zone and active flag
+func (s *LocationService) Save(ctx context.Context, id, name, address, timeZone string, active bool) (*entities.Location, error) {
+	if _, err := entities.LoadTimeZone(timeZone); err != nil {
+		return nil, errors.ErrInvalidTimeZoneConst
+	}
+
+	location, err := s.locations.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if location == nil {
+		location, err = entities.NewLocation(id, name, address, timeZone)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		location.Name = name
+		location.Address = address
+		location.TimeZone = timeZone
+	}
+	location.Active = active
+
+	if err := s.locations.Save(ctx, location); err != nil {
+		config.Logger.Error("Failed to save location", zap.String("location_id", id), zap.Error(err))
+		return nil, fmt.Errorf("failed to save location: %w", err)
+	}
+
+	return location, nil
+}
+
+func (s *LocationService) List(ctx context.Context) ([]*entities.Location, error) {
+	return s.locations.FindAll(ctx)
+}
+
+// Presence lists who is currently checked in, at one location or everywhere
+func (s *LocationService) Presence(ctx context.Context, locationID string) ([]*entities.TimeRecord, error) {
+	if locationID != "" {
+		location, err := s.locations.FindByID(ctx, locationID)
+		if err != nil {
+			return nil, err
+		}
+		if location == nil {
+			return nil, errors.ErrUnknownLocationConst
+		}
+	}
+
+	return s.records.FindActive(ctx, locationID)
+}
@@ -0,0 +1,12 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/config/configtest"
+)
+
+// TestMain gives the services the default configuration and a silent logger
+func TestMain(m *testing.M) {
+	configtest.Main(m)
+}
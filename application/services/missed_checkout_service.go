@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"go.uber.org/zap"
+
+	"github.com/leo-andrei/check-in-service/domain/events"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+)
+
+// MissedCheckoutService finds records left open past the expected end of the
+// shift and publishes EmployeeMissedCheckout for each of them, once
+type MissedCheckoutService struct {
+	repo       repositories.TimeRecordRepository
+	shiftHours time.Duration
+	batchSize  int
+}
+
+func NewMissedCheckoutService(repo repositories.TimeRecordRepository, shiftHours time.Duration, batchSize int) *MissedCheckoutService {
+	return &MissedCheckoutService{
+		repo:       repo,
+		shiftHours: shiftHours,
+		batchSize:  batchSize,
+	}
+}
+
+// Detect flags the open records older than the shift length and returns how
+// many events were stored. Records another instance flagged first, or that
+// were checked out in the meantime, are skipped.
+func (s *MissedCheckoutService) Detect(ctx context.Context) (int, error) {
+	if s.shiftHours <= 0 {
+		return 0, nil
+	}
+
+	now := time.Now().UTC()
+	records, err := s.repo.FindMissedCheckouts(ctx, now.Add(-s.shiftHours), s.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx = repositories.WithChangeOrigin(ctx, repositories.ChangeOrigin{Source: "missed_checkout"})
+	flagged := 0
+	for _, record := range records {
+		event := events.EmployeeMissedCheckoutEvent{
+			EventHeader: events.EventHeader{
+				EventID:   uuid.New().String(),
+				EventType: events.EventTypeEmployeeMissedCheckout,
+				Version:   1,
+				Timestamp: now,
+				Priority:  events.PriorityRoutine,
+			},
+			EmployeeID:         record.EmployeeID,
+			RecordID:           record.ID,
+			CheckInAt:          record.CheckInAt,
+			ExpectedCheckOutAt: record.CheckInAt.Add(s.shiftHours),
+			LocationID:         record.LocationID,
+			TimeZone:           record.TimeZone,
+		}
+
+		marked, err := s.repo.MarkMissedCheckout(ctx, record, event)
+		if err != nil {
+			return flagged, fmt.Errorf("failed to flag record %s: %w", record.ID, err)
+		}
+		if marked {
+			flagged++
+			config.Logger.Info("Missed check-out detected", zap.String("employee_id", record.EmployeeID), zap.String("record_id", record.ID), zap.Time("check_in_at", record.CheckInAt))
+		}
+	}
+
+	return flagged, nil
+}
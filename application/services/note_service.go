@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"go.uber.org/zap"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+)
+
+type NoteService struct {
+	records repositories.TimeRecordRepository
+	notes   repositories.NoteRepository
+}
+
+func NewNoteService(records repositories.TimeRecordRepository, notes repositories.NoteRepository) *NoteService {
+	return &NoteService{
+		records: records,
+		notes:   notes,
+	}
+}
+
+// Append adds a note to an existing record of the employee, e.g. to explain
+// a forgotten check-out after the fact
+func (s *NoteService) Append(ctx context.Context, employeeID, recordID, author, body string) (*entities.RecordNote, error) {
+	record, err := s.records.FindByID(ctx, recordID)
+	if err == repositories.ErrRecordNotFound || (err == nil && record.EmployeeID != employeeID) {
+		return nil, errors.ErrRecordNotFoundConst
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	note, err := entities.NewRecordNote(record, entities.NoteAppended, author, body)
+	if err != nil {
+		return nil, errors.ErrInvalidNoteConst
+	}
+
+	if err := s.notes.Save(ctx, note); err != nil {
+		config.Logger.Error("Failed to save note", zap.String("employee_id", employeeID), zap.String("record_id", recordID), zap.Error(err))
+		return nil, fmt.Errorf("failed to save note: %w", err)
+	}
+
+	config.Logger.Info("Note appended", zap.String("employee_id", employeeID), zap.String("record_id", recordID))
+	return note, nil
+}
+
+// ForRecords groups the notes of the given records by record ID
+func (s *NoteService) ForRecords(ctx context.Context, employeeID string, records []*entities.TimeRecord) (map[string][]*entities.RecordNote, error) {
+	recordIDs := make([]string, 0, len(records))
+	for _, record := range records {
+		recordIDs = append(recordIDs, record.ID)
+	}
+
+	notes, err := s.notes.FindByRecords(ctx, employeeID, recordIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	byRecord := make(map[string][]*entities.RecordNote)
+	for _, note := range notes {
+		byRecord[note.RecordID] = append(byRecord[note.RecordID], note)
+	}
+	return byRecord, nil
+}
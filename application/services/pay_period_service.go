@@ -0,0 +1,51 @@
+package services
+
+import (
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/errors"
+	"github.com/leo-andrei/check-in-service/domain/hours"
+)
+
+const payPeriodDateLayout = "2006-01-02"
+
+// PayPeriodService answers which pay period a date falls in. Pay periods
+// follow the company calendar, in one time zone for everyone.
+type PayPeriodService struct {
+	schedule hours.PaySchedule
+	loc      *time.Location
+}
+
+func NewPayPeriodService(schedule hours.PaySchedule, loc *time.Location) *PayPeriodService {
+	return &PayPeriodService{
+		schedule: schedule,
+		loc:      loc,
+	}
+}
+
+// PayPeriod is one pay period, [Start, End) in the company time zone
+type PayPeriod struct {
+	Frequency string
+	Start     time.Time
+	End       time.Time
+}
+
+// Bounds returns the pay period containing t, in t's location
+func (s *PayPeriodService) Bounds(t time.Time) (time.Time, time.Time) {
+	return s.schedule.Bounds(t)
+}
+
+// ForDate returns the pay period containing the local date (YYYY-MM-DD), or
+// the current one when date is empty
+func (s *PayPeriodService) ForDate(date string) (*PayPeriod, error) {
+	at := time.Now().In(s.loc)
+	if date != "" {
+		var err error
+		if at, err = time.ParseInLocation(payPeriodDateLayout, date, s.loc); err != nil {
+			return nil, errors.ErrInvalidPayPeriodDateConst
+		}
+	}
+
+	start, end := s.schedule.Bounds(at)
+	return &PayPeriod{Frequency: s.schedule.Frequency, Start: start, End: end}, nil
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"github.com/leo-andrei/check-in-service/infrastructure/messaging"
+	"github.com/leo-andrei/check-in-service/infrastructure/metrics"
+	"go.uber.org/zap"
+)
+
+// OutboxBacklogReader counts the events the outbox relay still has to publish
+type OutboxBacklogReader interface {
+	Backlog(ctx context.Context) (repositories.OutboxBacklog, error)
+}
+
+// QueueDepthReader reports how many messages the consumers still have to process
+type QueueDepthReader interface {
+	Depths(ctx context.Context) ([]messaging.QueueDepth, error)
+}
+
+// PreflightCheck is one condition of the payroll go/no-go signal
+type PreflightCheck struct {
+	Name   string
+	OK     bool
+	Detail string
+}
+
+// PreflightResult tells whether the events of a pay period made it all the
+// way through: published from the outbox and processed by every consumer
+type PreflightResult struct {
+	PeriodStart string
+	PeriodEnd   string
+	CheckedAt   time.Time
+	Go          bool
+	Checks      []PreflightCheck
+}
+
+// PayrollPreflightService checks before the payroll cut-off that nothing a
+// pay period's payroll depends on is still in flight. The broker does not
+// tell the age of queued messages, so any message waiting in a consumer queue
+// or dead letter queue fails the check, whichever period it belongs to.
+type PayrollPreflightService struct {
+	outbox     OutboxBacklogReader
+	queues     QueueDepthReader
+	payPeriods *PayPeriodService
+
+	mu   sync.Mutex
+	last *PreflightResult
+}
+
+func NewPayrollPreflightService(outbox OutboxBacklogReader, queues QueueDepthReader, payPeriods *PayPeriodService) *PayrollPreflightService {
+	return &PayrollPreflightService{
+		outbox:     outbox,
+		queues:     queues,
+		payPeriods: payPeriods,
+	}
+}
+
+// Run checks the pay period containing date (YYYY-MM-DD), or the last period
+// that ended when date is empty, and remembers the result
+func (s *PayrollPreflightService) Run(ctx context.Context, date string) (*PreflightResult, error) {
+	if date == "" {
+		start, _ := s.payPeriods.Bounds(time.Now().In(s.payPeriods.loc))
+		date = start.AddDate(0, 0, -1).Format(payPeriodDateLayout)
+	}
+	period, err := s.payPeriods.ForDate(date)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PreflightResult{
+		PeriodStart: period.Start.Format(payPeriodDateLayout),
+		PeriodEnd:   period.End.Format(payPeriodDateLayout),
+		CheckedAt:   time.Now().UTC(),
+		Go:          true,
+	}
+	result.add(s.checkOutbox(ctx, period.End))
+	result.add(s.checkQueues(ctx)...)
+
+	s.mu.Lock()
+	s.last = result
+	s.mu.Unlock()
+
+	if result.Go {
+		metrics.Gauge("payroll.preflight.go", 1)
+		config.Logger.Info("Payroll preflight passed", zap.String("period_start", result.PeriodStart))
+	} else {
+		metrics.Gauge("payroll.preflight.go", 0)
+		metrics.Incr("payroll.preflight.failures", 1)
+		config.Logger.Error("Payroll preflight failed", zap.String("period_start", result.PeriodStart), zap.String("failed", result.Summary()))
+	}
+	return result, nil
+}
+
+// Last returns the result of the last run, nil before the first one
+func (s *PayrollPreflightService) Last() *PreflightResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last
+}
+
+// checkOutbox fails when an event created before the end of the period has
+// not been published yet, or an event ran out of retries
+func (s *PayrollPreflightService) checkOutbox(ctx context.Context, cutoff time.Time) PreflightCheck {
+	check := PreflightCheck{Name: "outbox"}
+
+	backlog, err := s.outbox.Backlog(ctx)
+	switch {
+	case err != nil:
+		check.Detail = fmt.Sprintf("failed to read outbox backlog: %v", err)
+	case backlog.Failed > 0:
+		check.Detail = fmt.Sprintf("%d events out of retries, requeue them first", backlog.Failed)
+	case backlog.OldestAt != nil && backlog.OldestAt.Before(cutoff):
+		check.Detail = fmt.Sprintf("%d events pending, oldest from %s", backlog.Pending, backlog.OldestAt.UTC().Format(time.RFC3339))
+	default:
+		check.OK = true
+		check.Detail = fmt.Sprintf("no events from before %s pending", cutoff.UTC().Format(time.RFC3339))
+	}
+	return check
+}
+
+// checkQueues fails for every consumer queue or dead letter queue that is not
+// empty; there are none to check when queues is nil
+func (s *PayrollPreflightService) checkQueues(ctx context.Context) []PreflightCheck {
+	if s.queues == nil {
+		return nil
+	}
+
+	depths, err := s.queues.Depths(ctx)
+	if err != nil {
+		return []PreflightCheck{{Name: "queues", Detail: fmt.Sprintf("failed to read queue depths: %v", err)}}
+	}
+
+	checks := make([]PreflightCheck, 0, len(depths))
+	for _, depth := range depths {
+		check := PreflightCheck{
+			Name:   depth.Queue,
+			OK:     depth.Messages == 0 && depth.DLQMessages == 0,
+			Detail: fmt.Sprintf("%d waiting, %d dead-lettered", depth.Messages, depth.DLQMessages),
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+func (r *PreflightResult) add(checks ...PreflightCheck) {
+	for _, check := range checks {
+		r.Checks = append(r.Checks, check)
+		if !check.OK {
+			r.Go = false
+		}
+	}
+}
+
+// Summary lists the failed checks
+func (r *PreflightResult) Summary() string {
+	summary := ""
+	for _, check := range r.Checks {
+		if check.OK {
+			continue
+		}
+		if summary != "" {
+			summary += "; "
+		}
+		summary += check.Name + ": " + check.Detail
+	}
+	return summary
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"go.uber.org/zap"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+)
+
+type ProjectService struct {
+	projects repositories.ProjectRepository
+}
+
+func NewProjectService(projects repositories.ProjectRepository) *ProjectService {
+	return &ProjectService{
+		projects: projects,
+	}
+}
+
+// Save creates a project or updates its name and active flag
+func (s *ProjectService) Save(ctx context.Context, code, name string, active bool) (*entities.Project, error) {
+	project, err := s.projects.FindByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	if project == nil {
+		project, err = entities.NewProject(code, name)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		project.Name = name
+	}
+	project.Active = active
+
+	if err := s.projects.Save(ctx, project); err != nil {
+		config.Logger.Error("Failed to save project", zap.String("project_code", code), zap.Error(err))
+		return nil, fmt.Errorf("failed to save project: %w", err)
+	}
+
+	return project, nil
+}
+
+func (s *ProjectService) List(ctx context.Context) ([]*entities.Project, error) {
+	return s.projects.FindAll(ctx)
+}
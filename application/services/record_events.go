@@ -0,0 +1,85 @@
+package services
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/events"
+)
+
+// recordChangedEvent describes a change made to a record after the fact:
+// a void when it ends up voided, otherwise a correction from before to after.
+// Both change paid hours, so they go ahead of routine events.
+func recordChangedEvent(before, after *entities.TimeRecord, actor, reason string) events.DomainEvent {
+	header := func(eventType string) events.EventHeader {
+		return events.EventHeader{
+			EventID:   uuid.New().String(),
+			EventType: eventType,
+			Version:   1,
+			Timestamp: time.Now(),
+			Priority:  events.PriorityPayroll,
+		}
+	}
+
+	if after.Status == entities.StatusVoided {
+		return events.TimeRecordVoidedEvent{
+			EventHeader: header(events.EventTypeTimeRecordVoided),
+			EmployeeID:  after.EmployeeID,
+			RecordID:    after.ID,
+			TimeZone:    after.TimeZone,
+			ProjectCode: after.ProjectCode,
+			Actor:       actor,
+			Reason:      reason,
+			Before:      recordValues(before),
+		}
+	}
+
+	return events.TimeRecordCorrectedEvent{
+		EventHeader: header(events.EventTypeTimeRecordCorrected),
+		EmployeeID:  after.EmployeeID,
+		RecordID:    after.ID,
+		TimeZone:    after.TimeZone,
+		ProjectCode: after.ProjectCode,
+		Actor:       actor,
+		Reason:      reason,
+		Before:      recordValues(before),
+		After:       recordValues(after),
+	}
+}
+
+// statusChangedEvents reports the lifecycle transitions applied to the record
+// since it was loaded
+func statusChangedEvents(record *entities.TimeRecord, actor string) []events.DomainEvent {
+	var changes []events.DomainEvent
+	for _, t := range record.TakeTransitions() {
+		changes = append(changes, events.TimeRecordStatusChangedEvent{
+			EventHeader: events.EventHeader{
+				EventID:   uuid.New().String(),
+				EventType: events.EventTypeTimeRecordStatusChanged,
+				Version:   1,
+				Timestamp: time.Now(),
+			},
+			EmployeeID: record.EmployeeID,
+			RecordID:   record.ID,
+			Transition: string(t.Transition),
+			From:       string(t.From),
+			To:         string(t.To),
+			ChangedAt:  t.At,
+			Actor:      actor,
+		})
+	}
+	return changes
+}
+
+func recordValues(record *entities.TimeRecord) events.RecordValues {
+	return events.RecordValues{
+		CheckInAt:     record.CheckInAt,
+		CheckOutAt:    record.CheckOutAt,
+		Status:        string(record.Status),
+		HoursWorked:   record.HoursWorked,
+		RegularHours:  record.RegularHours,
+		OvertimeHours: record.OvertimeHours,
+		LaborCost:     laborCostPayload(record.LaborCost),
+	}
+}
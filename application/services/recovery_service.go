@@ -0,0 +1,160 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"github.com/leo-andrei/check-in-service/infrastructure/metrics"
+	"go.uber.org/zap"
+)
+
+// BrokerReconnector reopens the publisher's broker connection when it died
+type BrokerReconnector interface {
+	Reconnect() (bool, error)
+}
+
+// ConsumerResumer restarts consumers that stopped
+type ConsumerResumer interface {
+	Resume() []string
+}
+
+// ResettableBreaker is a circuit breaker that can be closed by hand
+type ResettableBreaker interface {
+	OpenFor() time.Duration
+	Reset()
+}
+
+// RecoveryStep is the outcome of one action of the recovery sequence
+type RecoveryStep struct {
+	Name   string
+	OK     bool
+	Detail string
+}
+
+// RecoveryReport lists the steps of a recovery run in the order they ran
+type RecoveryReport struct {
+	StartedAt time.Time
+	OK        bool
+	Steps     []RecoveryStep
+}
+
+// RecoveryService runs the on-call runbook for a stuck event pipeline in one
+// go: reconnect the publisher to the broker, resume stopped consumers, reset
+// circuit breakers open for longer than breakerOpenAfter and wake the outbox
+// relay. Every step is safe to repeat and runs even when an earlier one
+// failed, so the report shows everything that is still wrong.
+type RecoveryService struct {
+	broker           BrokerReconnector
+	consumers        ConsumerResumer
+	breakers         map[string]ResettableBreaker
+	breakerOpenAfter time.Duration
+	outboxKick       chan<- struct{}
+}
+
+// NewRecoveryService takes the channel the outbox relay polls on besides its ticker
+func NewRecoveryService(broker BrokerReconnector, consumers ConsumerResumer, breakers map[string]ResettableBreaker, breakerOpenAfter time.Duration, outboxKick chan<- struct{}) *RecoveryService {
+	return &RecoveryService{
+		broker:           broker,
+		consumers:        consumers,
+		breakers:         breakers,
+		breakerOpenAfter: breakerOpenAfter,
+		outboxKick:       outboxKick,
+	}
+}
+
+// Recover runs the recovery sequence; actor is logged with the result
+func (s *RecoveryService) Recover(actor string) *RecoveryReport {
+	report := &RecoveryReport{StartedAt: time.Now().UTC(), OK: true}
+	report.add(s.reconnectBroker())
+	report.add(s.resumeConsumers())
+	report.add(s.resetBreakers())
+	report.add(s.kickOutbox())
+
+	metrics.Incr("recovery.runs", 1)
+	if report.OK {
+		config.Logger.Info("Pipeline recovery completed", zap.String("actor", actor))
+	} else {
+		metrics.Incr("recovery.failures", 1)
+		config.Logger.Error("Pipeline recovery incomplete", zap.String("actor", actor), zap.String("failed", report.Summary()))
+	}
+	return report
+}
+
+func (s *RecoveryService) reconnectBroker() RecoveryStep {
+	step := RecoveryStep{Name: "reconnect_broker"}
+	reconnected, err := s.broker.Reconnect()
+	switch {
+	case err != nil:
+		step.Detail = fmt.Sprintf("failed to reconnect: %v", err)
+	case reconnected:
+		step.OK = true
+		step.Detail = "publisher reconnected"
+	default:
+		step.OK = true
+		step.Detail = "publisher connection is open"
+	}
+	return step
+}
+
+func (s *RecoveryService) resumeConsumers() RecoveryStep {
+	step := RecoveryStep{Name: "resume_consumers", OK: true}
+	resumed := s.consumers.Resume()
+	if len(resumed) == 0 {
+		step.Detail = "no consumer had stopped"
+	} else {
+		step.Detail = "resumed " + strings.Join(resumed, ", ")
+	}
+	return step
+}
+
+func (s *RecoveryService) resetBreakers() RecoveryStep {
+	step := RecoveryStep{Name: "reset_breakers", OK: true}
+
+	var reset []string
+	for name, breaker := range s.breakers {
+		if openFor := breaker.OpenFor(); openFor > 0 && openFor >= s.breakerOpenAfter {
+			breaker.Reset()
+			reset = append(reset, name)
+		}
+	}
+	if len(reset) == 0 {
+		step.Detail = fmt.Sprintf("no breaker open for %s or longer", s.breakerOpenAfter)
+	} else {
+		sort.Strings(reset)
+		step.Detail = "reset " + strings.Join(reset, ", ")
+	}
+	return step
+}
+
+// kickOutbox does not wait for the poll; a kick already pending is enough
+func (s *RecoveryService) kickOutbox() RecoveryStep {
+	step := RecoveryStep{Name: "kick_outbox", OK: true}
+	select {
+	case s.outboxKick <- struct{}{}:
+		step.Detail = "outbox relay polling now"
+	default:
+		step.Detail = "outbox relay poll already pending"
+	}
+	return step
+}
+
+func (r *RecoveryReport) add(step RecoveryStep) {
+	r.Steps = append(r.Steps, step)
+	if !step.OK {
+		r.OK = false
+	}
+}
+
+// Summary lists the failed steps
+func (r *RecoveryReport) Summary() string {
+	var failed []string
+	for _, step := range r.Steps {
+		if !step.OK {
+			failed = append(failed, step.Name+": "+step.Detail)
+		}
+	}
+	return strings.Join(failed, "; ")
+}
@@ -0,0 +1,258 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"go.uber.org/zap"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+	"github.com/leo-andrei/check-in-service/domain/events"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+)
+
+type RepairActionType string
+
+const (
+	RepairClose RepairActionType = "CLOSE" // Close a record left open before a later record
+	RepairMerge RepairActionType = "MERGE" // Extend a record to cover an overlapping one
+	RepairVoid  RepairActionType = "VOID"  // Void a duplicate or contradictory record
+)
+
+type RepairAction struct {
+	Type           RepairActionType         `json:"type"`
+	RecordID       string                   `json:"record_id"`
+	RelatedID      string                   `json:"related_record_id,omitempty"`
+	Reason         string                   `json:"reason"`
+	Before         *entities.RecordSnapshot `json:"before"`
+	After          *entities.RecordSnapshot `json:"after"`
+	changedRecord  *entities.TimeRecord
+	previousRecord *entities.TimeRecord
+	auditAction    entities.AuditAction
+}
+
+// RepairPlan is the proposed resolution for an employee's records in a date
+// range. Hash identifies the plan: applying it requires the hash of the
+// preview, so a plan that changed since is not applied unseen.
+type RepairPlan struct {
+	EmployeeID string         `json:"employee_id"`
+	From       time.Time      `json:"from"`
+	To         time.Time      `json:"to"`
+	Actions    []RepairAction `json:"actions"`
+	Hash       string         `json:"hash"`
+	// The records as read, before any action changed them
+	read []*entities.TimeRecord
+}
+
+// RepairService analyzes overlapping and contradictory records (e.g. after a
+// split-brain incident) and applies a consistent resolution
+type RepairService struct {
+	repo     repositories.TimeRecordRepository
+	holidays repositories.HolidayRepository
+}
+
+func NewRepairService(repo repositories.TimeRecordRepository, holidays repositories.HolidayRepository) *RepairService {
+	return &RepairService{repo: repo, holidays: holidays}
+}
+
+// Analyze proposes a resolution without changing anything
+func (s *RepairService) Analyze(ctx context.Context, employeeID string, from, to time.Time) (*RepairPlan, error) {
+	if !from.Before(to) {
+		return nil, fmt.Errorf("invalid range: from must be before to")
+	}
+
+	records, err := s.repo.FindByEmployeeInRange(ctx, employeeID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &RepairPlan{
+		EmployeeID: employeeID,
+		From:       from,
+		To:         to,
+		Actions:    []RepairAction{},
+		read:       make([]*entities.TimeRecord, 0, len(records)),
+	}
+	for _, record := range records {
+		plan.read = append(plan.read, copyRecordState(record))
+	}
+
+	var survivor *entities.TimeRecord
+	for _, record := range records {
+		// Locked records belong to a closed pay period and only change through approvals
+		if record.Status == entities.StatusVoided || record.Status == entities.StatusLocked {
+			continue
+		}
+
+		if survivor == nil {
+			survivor = record
+			continue
+		}
+
+		// An open record followed by another record was never checked out
+		if survivor.Status == entities.StatusCheckedIn {
+			before := *survivor
+			if err := survivor.CloseAt(record.CheckInAt); err != nil {
+				return nil, err
+			}
+			survivor.SplitByDay(config.Cfg.Hours.SplitOvernight)
+			if err := classifyPremiumHours(ctx, s.holidays, survivor); err != nil {
+				return nil, err
+			}
+			survivor.RepriceLaborCost()
+			plan.add(RepairClose, survivor, record.ID, "left open before a later check-in", &before, entities.AuditActionRepairClose)
+			survivor = record
+			continue
+		}
+
+		// No overlap with the previous record
+		if !record.CheckInAt.Before(*survivor.CheckOutAt) {
+			survivor = record
+			continue
+		}
+
+		switch {
+		case record.Status == entities.StatusCheckedIn:
+			before := *record
+			if err := record.Void(); err != nil {
+				return nil, err
+			}
+			plan.add(RepairVoid, record, survivor.ID, "open record overlaps a completed record", &before, entities.AuditActionRepairVoid)
+
+		case !record.CheckOutAt.After(*survivor.CheckOutAt):
+			before := *record
+			if err := record.Void(); err != nil {
+				return nil, err
+			}
+			plan.add(RepairVoid, record, survivor.ID, "fully contained in another record", &before, entities.AuditActionRepairVoid)
+
+		default:
+			survivorBefore := *survivor
+			if err := survivor.ExtendCheckOut(*record.CheckOutAt); err != nil {
+				return nil, err
+			}
+			survivor.SplitByDay(config.Cfg.Hours.SplitOvernight)
+			if err := classifyPremiumHours(ctx, s.holidays, survivor); err != nil {
+				return nil, err
+			}
+			survivor.RepriceLaborCost()
+			plan.add(RepairMerge, survivor, record.ID, "extended to cover an overlapping record", &survivorBefore, entities.AuditActionRepairMerge)
+
+			before := *record
+			if err := record.Void(); err != nil {
+				return nil, err
+			}
+			plan.add(RepairVoid, record, survivor.ID, "merged into overlapping record", &before, entities.AuditActionRepairVoid)
+		}
+	}
+
+	plan.Hash = plan.hash()
+	return plan, nil
+}
+
+// copyRecordState copies the record with its own check-out time, so actions
+// changing the record leave the copy as it was read
+func copyRecordState(record *entities.TimeRecord) *entities.TimeRecord {
+	copied := *record
+	if record.CheckOutAt != nil {
+		checkOutAt := *record.CheckOutAt
+		copied.CheckOutAt = &checkOutAt
+	}
+	return &copied
+}
+
+// hash digests the employee, range and actions of the plan
+func (p *RepairPlan) hash() string {
+	data, _ := json.Marshal(struct {
+		EmployeeID string         `json:"employee_id"`
+		From       time.Time      `json:"from"`
+		To         time.Time      `json:"to"`
+		Actions    []RepairAction `json:"actions"`
+	}{p.EmployeeID, p.From.UTC(), p.To.UTC(), p.Actions})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Apply recomputes the plan against the current state and applies it
+// transactionally, writing an audit entry for every change. planHash is the
+// hash of the previewed plan; when the records changed since, so that the
+// plan differs, nothing is applied and ErrRepairPlanChangedConst returned.
+// The records the plan was computed from are locked in the apply transaction
+// and must not have changed meanwhile either.
+func (s *RepairService) Apply(ctx context.Context, employeeID string, from, to time.Time, planHash, actor, reason string) (*RepairPlan, error) {
+	plan, err := s.Analyze(ctx, employeeID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if plan.Hash != planHash {
+		config.Logger.Warn(errors.ErrRepairPlanChanged, zap.String("employee_id", employeeID), zap.String("plan_hash", planHash), zap.String("current_hash", plan.Hash))
+		return nil, errors.ErrRepairPlanChangedConst
+	}
+
+	if len(plan.Actions) == 0 {
+		return plan, nil
+	}
+
+	// One event per changed record, from its state before the first action to
+	// its final state, so consumers compensate each record exactly once
+	changed := make(map[string]*entities.TimeRecord)
+	var (
+		order  []*entities.TimeRecord
+		before []*entities.TimeRecord
+	)
+	entries := make([]*entities.AuditEntry, 0, len(plan.Actions))
+	for _, action := range plan.Actions {
+		if _, ok := changed[action.RecordID]; !ok {
+			changed[action.RecordID] = action.changedRecord
+			order = append(order, action.changedRecord)
+			before = append(before, action.previousRecord)
+		}
+
+		auditReason := action.Reason
+		if reason != "" {
+			auditReason = reason + ": " + action.Reason
+		}
+		entries = append(entries, entities.NewAuditEntry(action.RecordID, employeeID, action.auditAction, actor, auditReason, action.Before, action.After))
+	}
+
+	recordEvents := make([]events.DomainEvent, 0, len(order))
+	for i, record := range order {
+		recordEvents = append(recordEvents, recordChangedEvent(before[i], record, actor, reason))
+		recordEvents = append(recordEvents, statusChangedEvents(record, actor)...)
+	}
+
+	ctx = repositories.WithChangeOrigin(ctx, repositories.ChangeOrigin{Actor: actor, Source: "repair"})
+	if err := s.repo.SaveRepair(ctx, plan.read, order, entries, recordEvents); err != nil {
+		if stderrors.Is(err, repositories.ErrConflict) {
+			config.Logger.Warn(errors.ErrRepairPlanChanged, zap.String("employee_id", employeeID), zap.Error(err))
+			return nil, errors.ErrRepairPlanChangedConst
+		}
+		config.Logger.Error("Failed to apply repair", zap.String("employee_id", employeeID), zap.Error(err))
+		return nil, fmt.Errorf("failed to apply repair: %w", err)
+	}
+
+	config.Logger.Info("Repair applied", zap.String("employee_id", employeeID), zap.String("actor", actor), zap.Int("actions", len(plan.Actions)))
+	return plan, nil
+}
+
+// add records an action; before is a copy of the record taken before the change
+func (p *RepairPlan) add(actionType RepairActionType, record *entities.TimeRecord, relatedID, reason string, before *entities.TimeRecord, auditAction entities.AuditAction) {
+	p.Actions = append(p.Actions, RepairAction{
+		Type:           actionType,
+		RecordID:       record.ID,
+		RelatedID:      relatedID,
+		Reason:         reason,
+		Before:         entities.SnapshotOf(before),
+		After:          entities.SnapshotOf(record),
+		changedRecord:  record,
+		previousRecord: before,
+		auditAction:    auditAction,
+	})
+}
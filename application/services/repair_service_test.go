@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+	"github.com/leo-andrei/check-in-service/infrastructure/persistence"
+)
+
+func TestRepairApplyPlanHash(t *testing.T) {
+	day := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	from, to := day, day.AddDate(0, 0, 1)
+
+	tests := []struct {
+		name string
+		// change runs between the preview and the apply
+		change  func(t *testing.T, records *persistence.MemoryTimeRecordRepository)
+		wantErr error
+	}{
+		{name: "unchanged plan is applied"},
+		{
+			name: "plan changed since the preview",
+			change: func(t *testing.T, records *persistence.MemoryTimeRecordRepository) {
+				extra, err := entities.NewManualTimeRecord("emp-1", day.Add(10*time.Hour+30*time.Minute), day.Add(11*time.Hour), "UTC")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := records.Save(context.Background(), extra); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: errors.ErrRepairPlanChangedConst,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			records := persistence.NewMemoryTimeRecordRepository(persistence.NewMemoryOutboxRepository())
+			// The second record lies within the first and gets voided
+			for _, hours := range [][2]int{{9, 12}, {10, 11}} {
+				record, err := entities.NewManualTimeRecord("emp-1", day.Add(time.Duration(hours[0])*time.Hour), day.Add(time.Duration(hours[1])*time.Hour), "UTC")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := records.Save(ctx, record); err != nil {
+					t.Fatal(err)
+				}
+			}
+			service := NewRepairService(records, noHolidays{})
+
+			preview, err := service.Analyze(ctx, "emp-1", from, to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(preview.Actions) != 1 || preview.Hash == "" {
+				t.Fatalf("preview has %d actions and hash %q, want 1 action and a hash", len(preview.Actions), preview.Hash)
+			}
+			if tt.change != nil {
+				tt.change(t, records)
+			}
+
+			plan, err := service.Apply(ctx, "emp-1", from, to, preview.Hash, "admin", "")
+			if err != tt.wantErr {
+				t.Fatalf("Apply error = %v, want %v", err, tt.wantErr)
+			}
+
+			voided, err := records.FindByID(ctx, preview.Actions[0].RecordID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if wantVoided := tt.wantErr == nil; (voided.Status == entities.StatusVoided) != wantVoided {
+				t.Fatalf("record status = %s, voided want %v", voided.Status, wantVoided)
+			}
+			if tt.wantErr == nil && plan.Hash != preview.Hash {
+				t.Fatalf("applied plan hash = %s, want %s", plan.Hash, preview.Hash)
+			}
+		})
+	}
+}
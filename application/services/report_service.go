@@ -0,0 +1,311 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+	"github.com/leo-andrei/check-in-service/domain/hours"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+)
+
+type ReportService struct {
+	repo       repositories.TimeRecordRepository
+	merges     repositories.EmployeeMergeRepository
+	payPeriods *PayPeriodService
+	consents   *ConsentService
+	schedules  repositories.ScheduleRepository
+	holidays   repositories.HolidayRepository
+}
+
+func NewReportService(repo repositories.TimeRecordRepository, merges repositories.EmployeeMergeRepository, payPeriods *PayPeriodService, consents *ConsentService, schedules repositories.ScheduleRepository, holidays repositories.HolidayRepository) *ReportService {
+	return &ReportService{
+		repo:       repo,
+		merges:     merges,
+		payPeriods: payPeriods,
+		consents:   consents,
+		schedules:  schedules,
+		holidays:   holidays,
+	}
+}
+
+// HoursTotals are the hours of completed records summed over a period
+type HoursTotals struct {
+	Records       int
+	HoursWorked   float64
+	RegularHours  float64
+	OvertimeHours float64
+}
+
+// add counts one day share of a record. The record itself is counted with its
+// first share only; regular and overtime hours are prorated by the share.
+func (t *HoursTotals) add(record *entities.TimeRecord, share entities.DaySegment, first bool) {
+	if first {
+		t.Records++
+	}
+
+	fraction := 1.0
+	if record.HoursWorked > 0 {
+		fraction = share.Hours / record.HoursWorked
+	}
+	t.HoursWorked += share.Hours
+	t.RegularHours += record.RegularHours * fraction
+	t.OvertimeHours += record.OvertimeHours * fraction
+}
+
+// WeeklyHours is the hours an employee worked in one week
+type WeeklyHours struct {
+	Week      string
+	WeekStart time.Time
+	HoursTotals
+}
+
+// PeriodHours is the hours an employee worked in one week or month. The
+// scheduled hours and the variance of the worked hours from them are nil for
+// employees without a schedule.
+type PeriodHours struct {
+	Period         string
+	Label          string
+	Start          time.Time
+	End            time.Time
+	ScheduledHours *float64
+	VarianceHours  *float64
+	HoursTotals
+}
+
+// CanonicalEmployeeID maps an ID merged into another one to the surviving ID,
+// so reports asked for by an old ID still find its history
+func (s *ReportService) CanonicalEmployeeID(ctx context.Context, employeeID string) (string, error) {
+	return s.merges.ResolveEmployeeID(ctx, employeeID)
+}
+
+// RecordsPage returns up to limit records overlapping [from, to) after the
+// opaque cursor ("" for the first page), and the cursor of the next page ("" on
+// the last page). A non-empty source keeps only records checked in or out
+// through it.
+func (s *ReportService) RecordsPage(ctx context.Context, employeeID string, from, to time.Time, source entities.PunchSource, cursor string, limit int) ([]*entities.TimeRecord, string, error) {
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, "", errors.ErrInvalidCursorConst
+	}
+
+	// Fetch one extra row to learn whether another page exists
+	records, err := s.repo.FindPageByEmployeeInRange(ctx, employeeID, from, to, source, after, limit+1)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(records) <= limit {
+		return records, "", nil
+	}
+
+	records = records[:limit]
+	return records, encodeCursor(records[len(records)-1]), nil
+}
+
+// StreamRecords calls fn for every record overlapping [from, to), oldest
+// first, holding at most one page of pageSize records in memory
+func (s *ReportService) StreamRecords(ctx context.Context, employeeID string, from, to time.Time, pageSize int, fn func(*entities.TimeRecord) error) error {
+	var after *repositories.RecordCursor
+	for {
+		records, err := s.repo.FindPageByEmployeeInRange(ctx, employeeID, from, to, "", after, pageSize)
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			if err := fn(record); err != nil {
+				return err
+			}
+		}
+		if len(records) < pageSize {
+			return nil
+		}
+		last := records[len(records)-1]
+		after = &repositories.RecordCursor{CheckInAt: last.CheckInAt, ID: last.ID}
+	}
+}
+
+func encodeCursor(record *entities.TimeRecord) string {
+	data, _ := json.Marshal(repositories.RecordCursor{CheckInAt: record.CheckInAt, ID: record.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(cursor string) (*repositories.RecordCursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+	var after repositories.RecordCursor
+	if err := json.Unmarshal(data, &after); err != nil {
+		return nil, err
+	}
+	if after.ID == "" || after.CheckInAt.IsZero() {
+		return nil, fmt.Errorf("incomplete cursor")
+	}
+	return &after, nil
+}
+
+// requireAnalytics fails with ErrConsentNotFoundConst unless the employee's
+// hours may be reported beyond payroll
+func (s *ReportService) requireAnalytics(ctx context.Context, employeeID string) error {
+	allowed, err := s.consents.HasConsent(ctx, employeeID, entities.PurposeAnalytics)
+	if err != nil {
+		return fmt.Errorf("failed to check consent: %w", err)
+	}
+	if !allowed {
+		return errors.ErrConsentNotFoundConst
+	}
+	return nil
+}
+
+// WeeklyHours totals completed records per week. Records are bucketed by
+// their check-in in loc, or per day segment for split overnight shifts, with
+// weeks starting on weekStart. It needs the employee's analytics consent.
+func (s *ReportService) WeeklyHours(ctx context.Context, employeeID string, from, to time.Time, loc *time.Location, weekStart time.Weekday) ([]WeeklyHours, error) {
+	if err := s.requireAnalytics(ctx, employeeID); err != nil {
+		return nil, err
+	}
+
+	weeks := make(map[time.Time]*WeeklyHours)
+	err := s.StreamRecords(ctx, employeeID, from, to, config.Cfg.Reports.PageSize, func(record *entities.TimeRecord) error {
+		if !record.IsCompleted() {
+			return nil
+		}
+
+		// Shifts split at midnight contribute each segment to its own week;
+		// the record itself is counted in the week of its check-in
+		for i, share := range record.DayShares() {
+			start := hours.StartOfWeek(share.StartAt.In(loc), weekStart)
+			week, ok := weeks[start]
+			if !ok {
+				week = &WeeklyHours{Week: hours.WeekLabel(start), WeekStart: start}
+				weeks[start] = week
+			}
+			week.add(record, share, i == 0)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]WeeklyHours, 0, len(weeks))
+	for _, week := range weeks {
+		result = append(result, *week)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].WeekStart.Before(result[j].WeekStart)
+	})
+
+	return result, nil
+}
+
+// PeriodHours totals the completed records of the week, month or pay period
+// containing at, in at's location. Only the day segments starting inside the
+// period count, so shifts split at midnight are divided between adjacent periods.
+// Pay periods are payroll's; weeks and months need the analytics consent.
+func (s *ReportService) PeriodHours(ctx context.Context, employeeID, period string, at time.Time, weekStart time.Weekday) (*PeriodHours, error) {
+	var (
+		start, end time.Time
+		err        error
+	)
+	if period == hours.PeriodPay {
+		start, end = s.payPeriods.Bounds(at)
+	} else if err = s.requireAnalytics(ctx, employeeID); err != nil {
+		return nil, err
+	} else if start, end, err = hours.PeriodBounds(period, at, weekStart); err != nil {
+		return nil, err
+	}
+
+	result := &PeriodHours{
+		Period: period,
+		Label:  hours.PeriodLabel(period, start),
+		Start:  start,
+		End:    end,
+	}
+	err = s.StreamRecords(ctx, employeeID, start, end, config.Cfg.Reports.PageSize, func(record *entities.TimeRecord) error {
+		if !record.IsCompleted() {
+			return nil
+		}
+
+		first := true
+		for _, share := range record.DayShares() {
+			if share.StartAt.Before(start) || !share.StartAt.Before(end) {
+				continue
+			}
+			result.add(record, share, first)
+			first = false
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.ScheduledHours, err = s.scheduledHours(ctx, employeeID, start, end); err != nil {
+		return nil, err
+	}
+	if result.ScheduledHours != nil {
+		variance := result.HoursWorked - *result.ScheduledHours
+		result.VarianceHours = &variance
+	}
+
+	return result, nil
+}
+
+// scheduledHours totals the employee's weekly shifts starting in [start, end),
+// like the worked hours are counted by the day segments starting in it.
+// Holidays are not scheduled, as no-shows are not expected on them. It is nil
+// when the employee has no schedule.
+func (s *ReportService) scheduledHours(ctx context.Context, employeeID string, start, end time.Time) (*float64, error) {
+	shifts, err := s.schedules.FindByEmployee(ctx, employeeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load schedule: %w", err)
+	}
+	if len(shifts) == 0 {
+		return nil, nil
+	}
+
+	// Shifts are in their own zones, so walk the dates from one day before
+	// the period to one day after it
+	first := start.UTC().AddDate(0, 0, -1)
+	last := end.UTC().AddDate(0, 0, 1)
+	holidays, err := s.holidays.FindInRange(ctx, first.Format(entities.BusinessDateLayout), last.Format(entities.BusinessDateLayout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load holidays: %w", err)
+	}
+	off := make(map[string]bool, len(holidays))
+	for _, holiday := range holidays {
+		off[holiday.Date] = true
+	}
+
+	scheduled := 0.0
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		date := day.Format(entities.BusinessDateLayout)
+		if off[date] {
+			continue
+		}
+		for _, shift := range shifts {
+			if shift.Weekday != day.Weekday() {
+				continue
+			}
+			shiftStart, shiftEnd, err := shift.On(date)
+			if err != nil {
+				return nil, err
+			}
+			if shiftStart.Before(start) || !shiftStart.Before(end) {
+				continue
+			}
+			scheduled += shiftEnd.Sub(shiftStart).Hours()
+		}
+	}
+	return &scheduled, nil
+}
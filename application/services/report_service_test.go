@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/hours"
+	"github.com/leo-andrei/check-in-service/infrastructure/persistence"
+)
+
+func TestPeriodHoursScheduled(t *testing.T) {
+	ctx := context.Background()
+	records := persistence.NewMemoryTimeRecordRepository(persistence.NewMemoryOutboxRepository())
+	schedules := persistence.NewMemoryScheduleRepository()
+	holidays := persistence.NewMemoryHolidayRepository()
+
+	// Monday to Friday 09:00-17:00; the Tuesday of the week is a holiday
+	var shifts []*entities.ScheduledShift
+	for weekday := time.Monday; weekday <= time.Friday; weekday++ {
+		shift, err := entities.NewScheduledShift("emp-1", weekday, "09:00", "17:00", "", "UTC")
+		if err != nil {
+			t.Fatal(err)
+		}
+		shifts = append(shifts, shift)
+	}
+	if err := schedules.ReplaceForEmployee(ctx, "emp-1", shifts); err != nil {
+		t.Fatal(err)
+	}
+	holiday, err := entities.NewHoliday("2026-03-03", "Company day")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := holidays.Save(ctx, holiday); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, employeeID := range []string{"emp-1", "emp-2"} {
+		checkIn := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+		record, err := entities.NewManualTimeRecord(employeeID, checkIn, checkIn.Add(8*time.Hour), "UTC")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := records.Save(ctx, record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	payPeriods := NewPayPeriodService(hours.PaySchedule{Frequency: hours.PayWeekly}, time.UTC)
+	service := NewReportService(records, nil, payPeriods, NewConsentService(persistence.NewMemoryConsentRepository(), false), schedules, holidays)
+	at := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+
+	totals, err := service.PeriodHours(ctx, "emp-1", hours.PeriodWeek, at, time.Monday)
+	if err != nil {
+		t.Fatalf("PeriodHours error = %v", err)
+	}
+	if totals.ScheduledHours == nil || *totals.ScheduledHours != 32 {
+		t.Fatalf("scheduled hours = %v, want 32", totals.ScheduledHours)
+	}
+	if totals.VarianceHours == nil || *totals.VarianceHours != -24 {
+		t.Fatalf("variance hours = %v, want -24", totals.VarianceHours)
+	}
+
+	unscheduled, err := service.PeriodHours(ctx, "emp-2", hours.PeriodWeek, at, time.Monday)
+	if err != nil {
+		t.Fatalf("PeriodHours error = %v", err)
+	}
+	if unscheduled.ScheduledHours != nil || unscheduled.VarianceHours != nil {
+		t.Fatalf("employee without a schedule has scheduled hours %v, variance %v", unscheduled.ScheduledHours, unscheduled.VarianceHours)
+	}
+}
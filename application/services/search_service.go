@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/leo-andrei/check-in-service/domain/errors"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+)
+
+// SearchService lets support find records and audit entries by partial
+// employee ID, device, note text and date
+type SearchService struct {
+	repo repositories.SearchRepository
+}
+
+func NewSearchService(repo repositories.SearchRepository) *SearchService {
+	return &SearchService{
+		repo: repo,
+	}
+}
+
+// Search returns up to limit hits after the opaque cursor ("" for the first
+// page), newest first, and the cursor of the next page ("" on the last page).
+// A query without any filter is rejected rather than listing everything.
+func (s *SearchService) Search(ctx context.Context, query repositories.SearchQuery, cursor string, limit int) ([]repositories.SearchHit, string, error) {
+	if query.Text == "" && query.EmployeeID == "" && query.DeviceID == "" && query.Note == "" &&
+		query.From.IsZero() && query.To.IsZero() {
+		return nil, "", errors.ErrInvalidSearchConst
+	}
+	for _, t := range query.Types {
+		if t != repositories.SearchTypeRecord && t != repositories.SearchTypeAudit {
+			return nil, "", errors.ErrInvalidSearchConst
+		}
+	}
+
+	after, err := decodeSearchCursor(cursor)
+	if err != nil {
+		return nil, "", errors.ErrInvalidCursorConst
+	}
+
+	// Fetch one extra hit to learn whether another page exists
+	hits, err := s.repo.Search(ctx, query, after, limit+1)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(hits) <= limit {
+		return hits, "", nil
+	}
+
+	hits = hits[:limit]
+	return hits, encodeSearchCursor(hits[len(hits)-1]), nil
+}
+
+func encodeSearchCursor(hit repositories.SearchHit) string {
+	data, _ := json.Marshal(repositories.SearchCursor{At: hit.At, ID: hit.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeSearchCursor(cursor string) (*repositories.SearchCursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+	var after repositories.SearchCursor
+	if err := json.Unmarshal(data, &after); err != nil {
+		return nil, err
+	}
+	if after.ID == "" || after.At.IsZero() {
+		return nil, fmt.Errorf("incomplete cursor")
+	}
+	return &after, nil
+}
@@ -0,0 +1,236 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+	"github.com/leo-andrei/check-in-service/domain/events"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+)
+
+// TimelineKind tells what a timeline entry is about
+type TimelineKind string
+
+const (
+	TimelineCheckIn      TimelineKind = "check_in"
+	TimelineCheckOut     TimelineKind = "check_out"
+	TimelineBreak        TimelineKind = "break"
+	TimelineCorrection   TimelineKind = "correction"
+	TimelineNote         TimelineKind = "note"
+	TimelineNotification TimelineKind = "notification"
+	TimelineAnomaly      TimelineKind = "anomaly"
+)
+
+// Status of a notification entry: whether its event left the outbox yet. A
+// published event may still wait in the notifier's queue.
+const (
+	NotificationPublished = "published"
+	NotificationPending   = "pending"
+)
+
+// notifyingEvents are the events the email and reminder notifiers act on
+var notifyingEvents = map[string]bool{
+	events.EventTypeEmployeeCheckedOut:     true,
+	events.EventTypeEmployeeMissedCheckout: true,
+	events.EventTypeTimeRecordCorrected:    true,
+	events.EventTypeTimeRecordVoided:       true,
+}
+
+// TimelineEntry is one thing that happened to a record of the employee
+type TimelineEntry struct {
+	At       time.Time
+	Kind     TimelineKind
+	RecordID string
+	Summary  string
+	// Details depend on the kind, e.g. the location of a tap or the actor of a correction
+	Details map[string]string
+}
+
+// Timeline is everything that happened for an employee on a local date
+type Timeline struct {
+	EmployeeID string
+	Date       string
+	TimeZone   string
+	Entries    []TimelineEntry
+}
+
+// TimelineService assembles the timeline of an employee from the records,
+// their notes, the audit log and the events written to the outbox
+type TimelineService struct {
+	records repositories.TimeRecordRepository
+	notes   repositories.NoteRepository
+	audit   repositories.AuditRepository
+	outbox  repositories.OutboxHistory
+}
+
+func NewTimelineService(records repositories.TimeRecordRepository, notes repositories.NoteRepository, audit repositories.AuditRepository, outbox repositories.OutboxHistory) *TimelineService {
+	return &TimelineService{
+		records: records,
+		notes:   notes,
+		audit:   audit,
+		outbox:  outbox,
+	}
+}
+
+// ForDate returns the timeline of the employee on date (YYYY-MM-DD) in
+// timeZone, the default zone when empty. It covers the records overlapping
+// the day, and everything about them, even when it happened later, e.g. a
+// correction made the next morning.
+func (s *TimelineService) ForDate(ctx context.Context, employeeID, date, timeZone string) (*Timeline, error) {
+	if timeZone == "" {
+		timeZone = config.Cfg.DefaultTimeZone
+	}
+	loc, err := entities.LoadTimeZone(timeZone)
+	if err != nil {
+		return nil, errors.ErrInvalidTimeZoneConst
+	}
+	from, err := time.ParseInLocation(entities.BusinessDateLayout, date, loc)
+	if err != nil {
+		return nil, errors.ErrInvalidTimelineDateConst
+	}
+
+	records, err := s.records.FindByEmployeeInRange(ctx, employeeID, from, from.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load records: %w", err)
+	}
+
+	timeline := &Timeline{
+		EmployeeID: employeeID,
+		Date:       date,
+		TimeZone:   timeZone,
+		Entries:    []TimelineEntry{},
+	}
+	if len(records) == 0 {
+		return timeline, nil
+	}
+
+	recordIDs := make([]string, 0, len(records))
+	for _, record := range records {
+		recordIDs = append(recordIDs, record.ID)
+		timeline.Entries = append(timeline.Entries, tapEntries(record)...)
+	}
+
+	notes, err := s.notes.FindByRecords(ctx, employeeID, recordIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load notes: %w", err)
+	}
+	for _, note := range notes {
+		timeline.Entries = append(timeline.Entries, TimelineEntry{
+			At:       note.CreatedAt,
+			Kind:     TimelineNote,
+			RecordID: note.RecordID,
+			Summary:  note.Body,
+			Details:  map[string]string{"kind": string(note.Kind), "author": note.Author},
+		})
+	}
+
+	auditEntries, err := s.audit.FindByRecords(ctx, employeeID, recordIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load audit entries: %w", err)
+	}
+	for _, entry := range auditEntries {
+		timeline.Entries = append(timeline.Entries, TimelineEntry{
+			At:       entry.CreatedAt,
+			Kind:     TimelineCorrection,
+			RecordID: entry.RecordID,
+			Summary:  string(entry.Action),
+			Details:  map[string]string{"actor": entry.Actor, "reason": entry.Reason},
+		})
+	}
+
+	outboxEvents, err := s.outbox.FindByAggregates(ctx, employeeID, recordIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load events: %w", err)
+	}
+	for _, event := range outboxEvents {
+		timeline.Entries = append(timeline.Entries, eventEntries(event)...)
+	}
+
+	sort.SliceStable(timeline.Entries, func(i, j int) bool {
+		return timeline.Entries[i].At.Before(timeline.Entries[j].At)
+	})
+	return timeline, nil
+}
+
+func tapEntries(record *entities.TimeRecord) []TimelineEntry {
+	entries := []TimelineEntry{{
+		At:       record.CheckInAt,
+		Kind:     TimelineCheckIn,
+		RecordID: record.ID,
+		Summary:  "checked in",
+		Details:  map[string]string{"location_id": record.LocationID, "source": string(record.Source), "device_id": record.DeviceID},
+	}}
+	if record.CheckOutAt != nil {
+		entries = append(entries, TimelineEntry{
+			At:       *record.CheckOutAt,
+			Kind:     TimelineCheckOut,
+			RecordID: record.ID,
+			Summary:  fmt.Sprintf("checked out after %.2fh", record.HoursWorked),
+			Details:  map[string]string{"status": string(record.Status), "source": string(record.CheckOutSource), "device_id": record.CheckOutDeviceID},
+		})
+	}
+	return entries
+}
+
+// eventEntries turns an outbox event into its notification entry, for the
+// events that trigger one, and the breaks and anomalies it reports
+func eventEntries(event repositories.OutboxEvent) []TimelineEntry {
+	var entries []TimelineEntry
+
+	switch event.EventType {
+	case events.EventTypeEmployeeCheckedOut:
+		var checkedOut events.EmployeeCheckedOutEvent
+		if err := json.Unmarshal(event.Payload, &checkedOut); err == nil && checkedOut.Breakdown != nil && checkedOut.Breakdown.BreakHours > 0 {
+			// Breaks are deducted, not punched, so they are shown at the check-out
+			entries = append(entries, TimelineEntry{
+				At:       checkedOut.CheckOutAt,
+				Kind:     TimelineBreak,
+				RecordID: event.AggregateID,
+				Summary:  fmt.Sprintf("%.2fh break deducted", checkedOut.Breakdown.BreakHours),
+				Details:  map[string]string{},
+			})
+		}
+	case events.EventTypeEmployeeMissedCheckout:
+		entries = append(entries, TimelineEntry{
+			At:       event.CreatedAt,
+			Kind:     TimelineAnomaly,
+			RecordID: event.AggregateID,
+			Summary:  "missed check-out",
+			Details:  map[string]string{},
+		})
+	case events.EventTypeEmployeeOvertimeDetected:
+		var overtime events.EmployeeOvertimeDetectedEvent
+		summary := "overtime detected"
+		if err := json.Unmarshal(event.Payload, &overtime); err == nil {
+			summary = fmt.Sprintf("%.2fh overtime detected", overtime.OvertimeHours)
+		}
+		entries = append(entries, TimelineEntry{
+			At:       event.CreatedAt,
+			Kind:     TimelineAnomaly,
+			RecordID: event.AggregateID,
+			Summary:  summary,
+			Details:  map[string]string{},
+		})
+	}
+
+	if notifyingEvents[event.EventType] {
+		status := NotificationPending
+		if event.Published {
+			status = NotificationPublished
+		}
+		entries = append(entries, TimelineEntry{
+			At:       event.CreatedAt,
+			Kind:     TimelineNotification,
+			RecordID: event.AggregateID,
+			Summary:  event.EventType,
+			Details:  map[string]string{"event_id": event.ID, "status": status},
+		})
+	}
+	return entries
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"go.uber.org/zap"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+	"github.com/leo-andrei/check-in-service/domain/events"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+)
+
+// TimesheetService collects records into per pay period timesheets, routes
+// them through the manager and closes them at the payroll cut-off
+type TimesheetService struct {
+	records    repositories.TimeRecordRepository
+	timesheets repositories.TimesheetRepository
+	employees  repositories.EmployeeRepository
+	payPeriods *PayPeriodService
+	cutoff     time.Duration
+	// When set, periods are only closed once the payroll preflight passes
+	preflight *PayrollPreflightService
+}
+
+func NewTimesheetService(records repositories.TimeRecordRepository, timesheets repositories.TimesheetRepository, employees repositories.EmployeeRepository, payPeriods *PayPeriodService, cutoff time.Duration) *TimesheetService {
+	return &TimesheetService{
+		records:    records,
+		timesheets: timesheets,
+		employees:  employees,
+		payPeriods: payPeriods,
+		cutoff:     cutoff,
+	}
+}
+
+// load returns the employee's timesheet for the period containing date, a new
+// one when none was saved yet, with its records. Totals of timesheets that are
+// not closed are brought up to date.
+func (s *TimesheetService) load(ctx context.Context, employeeID, date string) (*entities.Timesheet, []*entities.TimeRecord, error) {
+	period, err := s.payPeriods.ForDate(date)
+	if err != nil {
+		return nil, nil, err
+	}
+	start, end := period.Start, period.End
+
+	sheet, err := s.timesheets.FindByPeriod(ctx, employeeID, start.Format(payPeriodDateLayout))
+	if err != nil {
+		return nil, nil, err
+	}
+	if sheet == nil {
+		sheet, err = entities.NewTimesheet(employeeID, start.Format(payPeriodDateLayout), end.Format(payPeriodDateLayout))
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	found, err := s.records.FindByEmployeeInRange(ctx, employeeID, start, end)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Records belong to the period they were checked in
+	var records []*entities.TimeRecord
+	for _, record := range found {
+		if !record.CheckInAt.Before(start) && record.CheckInAt.Before(end) {
+			records = append(records, record)
+		}
+	}
+
+	sheet.Collect(records)
+	return sheet, records, nil
+}
+
+// ForDate returns the employee's timesheet for the pay period containing date
+func (s *TimesheetService) ForDate(ctx context.Context, employeeID, date string) (*entities.Timesheet, error) {
+	sheet, _, err := s.load(ctx, employeeID, date)
+	return sheet, err
+}
+
+// Submit hands the employee's timesheet of the period containing date to the manager
+func (s *TimesheetService) Submit(ctx context.Context, employeeID, date string) (*entities.Timesheet, error) {
+	sheet, _, err := s.load(ctx, employeeID, date)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := sheet.Submit(); err != nil {
+		return nil, errors.ErrTimesheetTransitionConst
+	}
+
+	if err := s.timesheets.Save(ctx, sheet); err != nil {
+		return nil, fmt.Errorf("failed to save timesheet: %w", err)
+	}
+
+	config.Logger.Info("Timesheet submitted", zap.String("employee_id", employeeID), zap.String("period_start", sheet.PeriodStart))
+	return sheet, nil
+}
+
+// Decide approves or rejects a submitted timesheet. As for approvals, only the
+// employee's manager may decide it when there is one, and never the employee.
+func (s *TimesheetService) Decide(ctx context.Context, id string, approve bool, decidedBy, comment string) (*entities.Timesheet, error) {
+	sheet, err := s.timesheets.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sheet == nil {
+		return nil, errors.ErrTimesheetNotFoundConst
+	}
+
+	employee, err := s.employees.FindByID(ctx, sheet.EmployeeID)
+	if err != nil {
+		return nil, err
+	}
+	if decidedBy == sheet.EmployeeID || (employee != nil && employee.ManagerID != "" && decidedBy != employee.ManagerID) {
+		config.Logger.Warn(errors.ErrNotApprover, zap.String("timesheet_id", id), zap.String("actor", decidedBy))
+		return nil, errors.ErrNotApproverConst
+	}
+
+	if err := sheet.Decide(approve, decidedBy, comment); err != nil {
+		return nil, errors.ErrTimesheetTransitionConst
+	}
+
+	if err := s.timesheets.Save(ctx, sheet); err != nil {
+		return nil, fmt.Errorf("failed to save timesheet: %w", err)
+	}
+
+	config.Logger.Info("Timesheet decided", zap.String("timesheet_id", id), zap.String("status", string(sheet.Status)), zap.String("actor", decidedBy))
+	return sheet, nil
+}
+
+// Close closes the employee's timesheet of the period containing date and
+// locks its completed records, once the payroll preflight passes
+func (s *TimesheetService) Close(ctx context.Context, employeeID, date, actor string) (*entities.Timesheet, error) {
+	if _, err := s.payPeriods.ForDate(date); err != nil {
+		return nil, err
+	}
+	if err := s.checkPreflight(ctx, date); err != nil {
+		return nil, err
+	}
+	return s.close(ctx, employeeID, date, actor)
+}
+
+// close closes the timesheet without running the preflight
+func (s *TimesheetService) close(ctx context.Context, employeeID, date, actor string) (*entities.Timesheet, error) {
+	sheet, records, err := s.load(ctx, employeeID, date)
+	if err != nil {
+		return nil, err
+	}
+
+	before := make(map[string]*entities.RecordSnapshot, len(records))
+	for _, record := range records {
+		before[record.ID] = entities.SnapshotOf(record)
+	}
+
+	locked, err := sheet.Close(records)
+	if err != nil {
+		return nil, errors.ErrTimesheetTransitionConst
+	}
+
+	var (
+		entries []*entities.AuditEntry
+		evts    []events.DomainEvent
+	)
+	reason := fmt.Sprintf("pay period %s closed", sheet.PeriodStart)
+	for _, record := range locked {
+		entries = append(entries, entities.NewAuditEntry(record.ID, record.EmployeeID, entities.AuditActionPeriodLock, actor, reason, before[record.ID], entities.SnapshotOf(record)))
+		evts = append(evts, statusChangedEvents(record, actor)...)
+	}
+
+	ctx = repositories.WithChangeOrigin(ctx, repositories.ChangeOrigin{Actor: actor, Source: "timesheet_close"})
+	if err := s.timesheets.SaveClosed(ctx, sheet, locked, entries, evts); err != nil {
+		config.Logger.Error("Failed to close timesheet", zap.String("employee_id", employeeID), zap.String("period_start", sheet.PeriodStart), zap.Error(err))
+		return nil, fmt.Errorf("failed to close timesheet: %w", err)
+	}
+
+	config.Logger.Info("Timesheet closed", zap.String("employee_id", employeeID), zap.String("period_start", sheet.PeriodStart), zap.Int("locked_records", len(locked)), zap.String("actor", actor))
+	return sheet, nil
+}
+
+// RequirePreflight blocks Close and ClosePeriod until the payroll preflight
+// of the period passes
+func (s *TimesheetService) RequirePreflight(preflight *PayrollPreflightService) {
+	s.preflight = preflight
+}
+
+// checkPreflight runs the payroll preflight of the period containing date,
+// when one is required
+func (s *TimesheetService) checkPreflight(ctx context.Context, date string) error {
+	if s.preflight == nil {
+		return nil
+	}
+
+	result, err := s.preflight.Run(ctx, date)
+	if err != nil {
+		return err
+	}
+	if !result.Go {
+		config.Logger.Warn(errors.ErrPayrollPreflightFailed, zap.String("period_start", result.PeriodStart), zap.String("failed", result.Summary()))
+		return errors.ErrPayrollPreflightFailedConst
+	}
+	return nil
+}
+
+// ClosePeriod closes the timesheets of every employee on the roster for the
+// period containing date and returns how many it closed; timesheets closed
+// before are skipped
+func (s *TimesheetService) ClosePeriod(ctx context.Context, date, actor string) (int, error) {
+	if _, err := s.payPeriods.ForDate(date); err != nil {
+		return 0, err
+	}
+
+	if err := s.checkPreflight(ctx, date); err != nil {
+		return 0, err
+	}
+
+	employees, err := s.employees.FindAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	closed := 0
+	for _, employee := range employees {
+		_, err := s.close(ctx, employee.ID, date, actor)
+		if err == errors.ErrTimesheetTransitionConst {
+			continue
+		}
+		if err != nil {
+			return closed, fmt.Errorf("failed to close timesheet of %s: %w", employee.ID, err)
+		}
+		closed++
+	}
+
+	return closed, nil
+}
+
+// CloseDue closes the last pay period whose cut-off has passed: the period
+// ended at least the cut-off delay ago
+func (s *TimesheetService) CloseDue(ctx context.Context) (int, error) {
+	start, _ := s.payPeriods.Bounds(time.Now().In(s.payPeriods.loc).Add(-s.cutoff))
+	return s.ClosePeriod(ctx, start.AddDate(0, 0, -1).Format(payPeriodDateLayout), "system")
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+	"github.com/leo-andrei/check-in-service/domain/hours"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+	"github.com/leo-andrei/check-in-service/infrastructure/persistence"
+)
+
+// backlog is an outbox with a fixed backlog
+type backlog repositories.OutboxBacklog
+
+func (b *backlog) Backlog(ctx context.Context) (repositories.OutboxBacklog, error) {
+	return repositories.OutboxBacklog(*b), nil
+}
+
+func TestCloseRequiresPreflight(t *testing.T) {
+	ctx := context.Background()
+	records := persistence.NewMemoryTimeRecordRepository(persistence.NewMemoryOutboxRepository())
+	checkIn := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	record, err := entities.NewManualTimeRecord("emp-1", checkIn, checkIn.Add(8*time.Hour), "UTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := records.Save(ctx, record); err != nil {
+		t.Fatal(err)
+	}
+
+	payPeriods := NewPayPeriodService(hours.PaySchedule{Frequency: hours.PayWeekly}, time.UTC)
+	service := NewTimesheetService(records, persistence.NewMemoryTimesheetRepository(records), roster{}, payPeriods, 0)
+	oldest := checkIn
+	outbox := &backlog{Pending: 1, OldestAt: &oldest}
+	service.RequirePreflight(NewPayrollPreflightService(outbox, nil, payPeriods))
+
+	if _, err := service.Close(ctx, "emp-1", "2026-03-02", "hr-1"); err != errors.ErrPayrollPreflightFailedConst {
+		t.Fatalf("Close error = %v, want %v", err, errors.ErrPayrollPreflightFailedConst)
+	}
+	sheet, err := service.ForDate(ctx, "emp-1", "2026-03-02")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sheet.Status == entities.TimesheetClosed {
+		t.Fatal("timesheet closed while the preflight failed")
+	}
+
+	*outbox = backlog{}
+	sheet, err = service.Close(ctx, "emp-1", "2026-03-02", "hr-1")
+	if err != nil {
+		t.Fatalf("Close error = %v", err)
+	}
+	if sheet.Status != entities.TimesheetClosed {
+		t.Fatalf("status = %s, want %s", sheet.Status, entities.TimesheetClosed)
+	}
+}
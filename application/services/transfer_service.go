@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"go.uber.org/zap"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+)
+
+// TransferService moves a checked-in employee to another location: the open
+// record is closed and a new one opened in a single transaction, so a
+// check-in that fails leaves the employee checked in where they were
+type TransferService struct {
+	repo     repositories.TimeRecordRepository
+	checkIn  *CheckInService
+	checkOut *CheckOutService
+}
+
+func NewTransferService(repo repositories.TimeRecordRepository, checkIn *CheckInService, checkOut *CheckOutService) *TransferService {
+	return &TransferService{
+		repo:     repo,
+		checkIn:  checkIn,
+		checkOut: checkOut,
+	}
+}
+
+// Transfer closes the employee's open record and opens one with checkInOpts;
+// it returns the closed record, the new one and the metadata of the
+// check-in hooks, which only run once both records are saved
+func (s *TransferService) Transfer(ctx context.Context, employeeID string, checkOutOpts CheckOutOptions, checkInOpts CheckInOptions) (*entities.TimeRecord, *entities.TimeRecord, map[string]interface{}, error) {
+	closed, closeEvents, err := s.checkOut.prepare(ctx, employeeID, checkOutOpts)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	opened, openEvent, err := s.checkIn.prepare(ctx, employeeID, checkInOpts)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	evts := append(closeEvents, openEvent)
+	if err := s.repo.SaveTransfer(ctx, closed, opened, evts); err != nil {
+		if stderrors.Is(err, repositories.ErrConflict) {
+			config.Logger.Warn(errors.ErrEmployeeAlreadyCheckedIn, zap.String("employee_id", employeeID))
+			return nil, nil, nil, errors.ErrEmployeeAlreadyCheckedInConst
+		}
+		config.Logger.Error("Failed to save transfer", zap.String("employee_id", employeeID), zap.String("record_id", closed.ID), zap.Error(err))
+		return nil, nil, nil, fmt.Errorf("failed to save transfer: %w", err)
+	}
+
+	config.Logger.Info("Transfer successful", zap.String("employee_id", employeeID), zap.String("closed_record_id", closed.ID),
+		zap.String("record_id", opened.ID), zap.String("from_location_id", closed.LocationID), zap.String("location_id", opened.LocationID))
+
+	return closed, opened, s.checkIn.runHooks(ctx, opened), nil
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+	"github.com/leo-andrei/check-in-service/infrastructure/persistence"
+)
+
+func TestTransfer(t *testing.T) {
+	tests := []struct {
+		name       string
+		toLocation string
+		wantErr    error
+	}{
+		{name: "known location", toLocation: "warehouse"},
+		{name: "unknown location", toLocation: "nowhere", wantErr: errors.ErrUnknownLocationConst},
+		{name: "inactive location", toLocation: "closed", wantErr: errors.ErrUnknownLocationConst},
+	}
+
+	sites := locations{
+		"office":    {ID: "office", Active: true, TimeZone: "UTC"},
+		"warehouse": {ID: "warehouse", Active: true, TimeZone: "UTC"},
+		"closed":    {ID: "closed", Active: false, TimeZone: "UTC"},
+	}
+
+	employees := roster{"emp-1": {ID: "emp-1", Active: true}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			outbox := persistence.NewMemoryOutboxRepository()
+			records := persistence.NewMemoryTimeRecordRepository(outbox)
+			checkIn := NewCheckInService(records, sites, noProjects{}, employees, nil)
+			checkOut := NewCheckOutService(records, noHolidays{}, employees, nil)
+			service := NewTransferService(records, checkIn, checkOut)
+
+			open, err := entities.NewTimeRecord("emp-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			open.CheckInAt = time.Now().UTC().Add(-2 * time.Hour)
+			open.LocationID = "office"
+			if err := records.Save(ctx, open); err != nil {
+				t.Fatal(err)
+			}
+
+			closed, opened, _, err := service.Transfer(ctx, "emp-1", CheckOutOptions{}, CheckInOptions{LocationID: tt.toLocation})
+			if err != tt.wantErr {
+				t.Fatalf("Transfer error = %v, want %v", err, tt.wantErr)
+			}
+
+			active, err := records.FindActiveByEmployeeID(ctx, "emp-1")
+			if err != nil || active == nil {
+				t.Fatalf("employee has no open record after the transfer: %v", err)
+			}
+
+			if tt.wantErr != nil {
+				// Nothing changed: still checked in at the old site, no events
+				if active.ID != open.ID || active.LocationID != "office" {
+					t.Fatalf("open record is %s at %s, want %s at office", active.ID, active.LocationID, open.ID)
+				}
+				if n := len(outbox.Events()); n != 0 {
+					t.Fatalf("failed transfer queued %d events", n)
+				}
+				return
+			}
+
+			if closed.ID != open.ID || !closed.IsCompleted() {
+				t.Fatalf("transfer did not close the open record")
+			}
+			if active.ID != opened.ID || active.LocationID != tt.toLocation {
+				t.Fatalf("open record is %s at %s, want %s at %s", active.ID, active.LocationID, opened.ID, tt.toLocation)
+			}
+			stored, err := records.FindByID(ctx, open.ID)
+			if err != nil || !stored.IsCompleted() {
+				t.Fatalf("closed record was not saved: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+//go:build kafka
+
+package main
+
+import (
+	"time"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"github.com/leo-andrei/check-in-service/infrastructure/messaging"
+)
+
+func newKafkaPublisher(brokers []string, topic string) (closablePublisher, error) {
+	return messaging.NewKafkaPublisher(brokers, topic)
+}
+
+func newKafkaConsumer(brokers []string, topic, groupID string) (messageConsumer, error) {
+	consumer, err := messaging.NewKafkaConsumer(brokers, topic, groupID, messaging.Backoff{
+		Initial: time.Duration(config.Cfg.Kafka.RetryInitialMs) * time.Millisecond,
+		Max:     time.Duration(config.Cfg.Kafka.RetryMaxSec) * time.Second,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return withQuarantine(consumer)
+}
@@ -0,0 +1,16 @@
+//go:build !kafka
+
+package main
+
+import "errors"
+
+// The Kafka client is opt-in, like the MySQL driver
+var errKafkaNotCompiled = errors.New("MESSAGING_BACKEND=kafka needs a build with -tags kafka")
+
+func newKafkaPublisher(brokers []string, topic string) (closablePublisher, error) {
+	return nil, errKafkaNotCompiled
+}
+
+func newKafkaConsumer(brokers []string, topic, groupID string) (messageConsumer, error) {
+	return nil, errKafkaNotCompiled
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"database/sql"
 	"fmt"
@@ -8,17 +9,29 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/leo-andrei/check-in-service/application/handlers"
 	"github.com/leo-andrei/check-in-service/application/services"
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/events"
+	"github.com/leo-andrei/check-in-service/domain/hours"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
 	"github.com/leo-andrei/check-in-service/infrastructure/config"
 	"github.com/leo-andrei/check-in-service/infrastructure/external"
+	"github.com/leo-andrei/check-in-service/infrastructure/incidents"
+	"github.com/leo-andrei/check-in-service/infrastructure/leader"
 	"github.com/leo-andrei/check-in-service/infrastructure/messaging"
+	"github.com/leo-andrei/check-in-service/infrastructure/metrics"
 	"github.com/leo-andrei/check-in-service/infrastructure/persistence"
+	"github.com/leo-andrei/check-in-service/infrastructure/resilience"
+	"github.com/leo-andrei/check-in-service/infrastructure/schedule"
+	"github.com/leo-andrei/check-in-service/infrastructure/selfcheck"
 	httphandlers "github.com/leo-andrei/check-in-service/presentation/http"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 
 	_ "github.com/lib/pq"
@@ -48,45 +61,475 @@ func main() {
 		logger.Fatal("Failed to load config", zap.Error(err))
 	}
 
+	// Optional statsd metrics for legacy monitoring
+	if err := metrics.Init(cfg.StatsD.Addr, cfg.StatsD.Prefix); err != nil {
+		logger.Fatal("Failed to initialize statsd metrics", zap.Error(err))
+	}
+	defer metrics.Default.Close()
+
 	dbConnStr := cfg.Database.URL
 	rabbitURL := cfg.RabbitMQ.URL
 	legacyAPIURL := cfg.LegacyAPI.URL
 	smtpHost := cfg.SMTP.Host
 
-	// Initialize database
-	db, err := sql.Open("postgres", dbConnStr)
-	if err != nil {
-		logger.Fatal("Failed to connect to database", zap.Error(err))
+	// Initialize database; wait for it to accept connections on cold starts.
+	// Local mode keeps every store in memory and opens no database.
+	local := cfg.Environment == config.EnvironmentLocal
+	pool := persistence.PoolSettings{
+		MaxOpen:         cfg.Database.MaxConnections,
+		MaxIdle:         cfg.Database.MaxIdleConnections,
+		MaxLifetime:     time.Duration(cfg.Database.ConnMaxLifetimeMin) * time.Minute,
+		ConnectTimeout:  time.Duration(cfg.Database.ConnectionTimeout) * time.Second,
+		ConnectAttempts: cfg.Database.ConnectAttempts,
 	}
-	defer db.Close()
+	var db *sql.DB
+	shards := persistence.NewShardSet()
+	readDB := persistence.NewReadReplica(nil, nil, 0)
+	if !local {
+		db, err = sql.Open("postgres", dbConnStr)
+		if err != nil {
+			logger.Fatal("Failed to connect to database", zap.Error(err))
+		}
+		defer db.Close()
+		if err := pool.Connect(ctx, "database", db); err != nil {
+			logger.Fatal("Failed to connect to database", zap.Error(err))
+		}
+
+		// Apply pending schema migrations
+		if cfg.Database.AutoMigrate {
+			if err := persistence.MigratePostgres(ctx, db); err != nil {
+				logger.Fatal("Failed to migrate database", zap.Error(err))
+			}
+		}
+
+		// Time records are sharded by employee ID when shard URLs are configured
+		shards = persistence.NewShardSet(db)
+		if len(cfg.Database.ShardURLs) > 0 {
+			shards, err = persistence.OpenShardSet(cfg.Database.ShardURLs)
+			if err != nil {
+				logger.Fatal("Failed to connect to database shards", zap.Error(err))
+			}
+			defer shards.Close()
+			if err := shards.Connect(ctx, pool); err != nil {
+				logger.Fatal("Failed to connect to database shards", zap.Error(err))
+			}
 
-	// Create tables
-	if err := initDatabase(db); err != nil {
-		logger.Fatal("Failed to initialize database", zap.Error(err))
+			if cfg.Database.AutoMigrate {
+				for i, shardDB := range shards.All() {
+					if err := persistence.MigratePostgres(ctx, shardDB); err != nil {
+						logger.Fatal("Failed to migrate database shard", zap.Int("shard", i), zap.Error(err))
+					}
+				}
+			}
+			logger.Info("Database sharding enabled", zap.Int("shards", shards.Len()))
+		}
+
+		// Consumers read consents and the roster from the replica when one is configured
+		readDB = persistence.NewReadReplica(db, nil, 0)
+		if cfg.Database.ReplicaURL != "" {
+			replicaDB, err := sql.Open("postgres", cfg.Database.ReplicaURL)
+			if err != nil {
+				logger.Fatal("Failed to connect to read replica", zap.Error(err))
+			}
+			defer replicaDB.Close()
+			// The replica is optional: reads fall back to the primary until it answers
+			pool.Configure(replicaDB)
+
+			readDB = persistence.NewReadReplica(db, replicaDB, time.Duration(cfg.Database.ReplicaMaxLagSec)*time.Second)
+			logger.Info("Read replica enabled for consumers", zap.Int("max_lag_sec", cfg.Database.ReplicaMaxLagSec))
+		}
 	}
 
 	// Initialize repositories
-	timeRecordRepo := persistence.NewPostgresTimeRecordRepository(db)
-	outboxRepo := persistence.NewPostgresOutboxRepository(db)
+	var (
+		timeRecordRepo      repositories.TimeRecordRepository
+		outboxRepo          outboxStore
+		consentRepo         repositories.ConsentRepository
+		consumerConsentRepo repositories.ConsentRepository
+		locationRepo        repositories.LocationRepository
+		projectRepo         repositories.ProjectRepository
+		noteRepo            repositories.NoteRepository
+		mergeRepo           repositories.EmployeeMergeRepository
+		employeeRepo        repositories.EmployeeRepository
+		approvalRepo        repositories.ApprovalRepository
+		timesheetRepo       repositories.TimesheetRepository
+		auditRepo           repositories.AuditRepository
+		recordChangeRepo    repositories.RecordChangeRepository
+		searchRepo          repositories.SearchRepository
+		deviceRepo          repositories.DeviceRepository
+		holidayRepo         repositories.HolidayRepository
+		scheduleRepo        repositories.ScheduleRepository
+		shiftExceptionRepo  repositories.ShiftExceptionRepository
+		idempotencyRepo     repositories.IdempotencyRepository
+		processedEventRepo  repositories.ProcessedEventRepository
+		parkedLaborCostRepo repositories.ParkedLaborCostRepository
+		emailSettingsRepo   repositories.EmailSettingsRepository
+	)
+	if local {
+		memoryOutbox := persistence.NewMemoryOutboxRepository()
+		memoryRecords := persistence.NewMemoryTimeRecordRepository(memoryOutbox)
+		memoryConsents := persistence.NewMemoryConsentRepository()
+		memoryApprovals := persistence.NewMemoryApprovalRepository(memoryRecords)
+		memoryExceptions := persistence.NewMemoryShiftExceptionRepository(memoryRecords)
+		timeRecordRepo = memoryRecords
+		outboxRepo = memoryOutbox
+		consentRepo = memoryConsents
+		consumerConsentRepo = memoryConsents
+		locationRepo = persistence.NewMemoryLocationRepository()
+		projectRepo = persistence.NewMemoryProjectRepository()
+		noteRepo = persistence.NewMemoryNoteRepository(memoryRecords)
+		mergeRepo = persistence.NewMemoryEmployeeMergeRepository(memoryRecords, memoryConsents, memoryApprovals, memoryExceptions)
+		employeeRepo = persistence.NewMemoryEmployeeRepository()
+		approvalRepo = memoryApprovals
+		timesheetRepo = persistence.NewMemoryTimesheetRepository(memoryRecords)
+		auditRepo = persistence.NewMemoryAuditRepository(memoryRecords)
+		recordChangeRepo = persistence.NewMemoryRecordChangeRepository(memoryRecords)
+		searchRepo = persistence.NewMemorySearchRepository(memoryRecords)
+		deviceRepo = persistence.NewMemoryDeviceRepository()
+		holidayRepo = persistence.NewMemoryHolidayRepository()
+		scheduleRepo = persistence.NewMemoryScheduleRepository()
+		shiftExceptionRepo = memoryExceptions
+		idempotencyRepo = persistence.NewMemoryIdempotencyRepository()
+		processedEventRepo = persistence.NewMemoryProcessedEventRepository()
+		parkedLaborCostRepo = persistence.NewMemoryParkedLaborCostRepository()
+		emailSettingsRepo = persistence.NewMemoryEmailSettingsRepository()
+		logger.Info("Local mode: every store, the outbox and events kept in memory")
+	} else {
+		timeRecordRepo = persistence.NewShardedTimeRecordRepository(shards)
+		outboxRepo = persistence.NewShardedOutboxRepository(shards)
+		consentRepo = persistence.NewPostgresConsentRepository(db)
+		consumerConsentRepo = persistence.NewPostgresConsentRepository(readDB)
+		locationRepo = persistence.NewPostgresLocationRepository(db)
+		projectRepo = persistence.NewPostgresProjectRepository(db)
+		noteRepo = persistence.NewShardedNoteRepository(shards)
+		mergeRepo = persistence.NewPostgresEmployeeMergeRepository(shards)
+		employeeRepo = persistence.NewPostgresEmployeeRepository(db)
+		approvalRepo = persistence.NewShardedApprovalRepository(shards)
+		timesheetRepo = persistence.NewShardedTimesheetRepository(shards)
+		auditRepo = persistence.NewShardedAuditRepository(shards)
+		recordChangeRepo = persistence.NewShardedRecordChangeRepository(shards)
+		searchRepo = persistence.NewShardedSearchRepository(shards)
+		deviceRepo = persistence.NewPostgresDeviceRepository(db)
+		holidayRepo = persistence.NewPostgresHolidayRepository(db)
+		scheduleRepo = persistence.NewPostgresScheduleRepository(db)
+		shiftExceptionRepo = persistence.NewShardedShiftExceptionRepository(shards)
+		idempotencyRepo = persistence.NewPostgresIdempotencyRepository(db)
+		processedEventRepo = persistence.NewPostgresProcessedEventRepository(db, time.Duration(cfg.Inbox.ClaimTimeoutSec)*time.Second)
+		parkedLaborCostRepo = persistence.NewPostgresParkedLaborCostRepository(db)
+		emailSettingsRepo = persistence.NewPostgresEmailSettingsRepository(db)
+	}
+	if cfg.Database.Driver == "mysql" && !local {
+		mysqlURLs := cfg.Database.MySQLShardURLs
+		if len(mysqlURLs) == 0 {
+			mysqlURLs = []string{cfg.Database.MySQLURL}
+		}
+		mysqlShards, err := persistence.OpenMySQLShardSet(mysqlURLs)
+		if err != nil {
+			logger.Fatal("Failed to connect to MySQL", zap.Error(err))
+		}
+		defer mysqlShards.Close()
+		if err := mysqlShards.Connect(ctx, pool); err != nil {
+			logger.Fatal("Failed to connect to MySQL", zap.Error(err))
+		}
 
-	// Initialize event publisher
-	publisher, err := messaging.NewRabbitMQPublisher(rabbitURL, "checkout-events")
+		if cfg.Database.AutoMigrate {
+			for i, mysqlDB := range mysqlShards.All() {
+				if err := persistence.MigrateMySQL(ctx, mysqlDB); err != nil {
+					logger.Fatal("Failed to migrate MySQL", zap.Int("shard", i), zap.Error(err))
+				}
+			}
+		}
+		timeRecordRepo = persistence.NewShardedMySQLTimeRecordRepository(mysqlShards)
+		outboxRepo = persistence.NewShardedMySQLOutboxRepository(mysqlShards)
+		logger.Info("Time records stored in MySQL", zap.Int("shards", mysqlShards.Len()))
+	}
+	timeRecordRepo = persistence.NewRetryingTimeRecordRepository(timeRecordRepo, persistence.RetryPolicy{
+		MaxAttempts: cfg.Database.RetryAttempts,
+		BaseDelay:   time.Duration(cfg.Database.RetryBaseDelayMs) * time.Millisecond,
+		MaxDelay:    time.Duration(cfg.Database.RetryMaxDelayMs) * time.Millisecond,
+	})
+
+	// Initialize event publisher; local mode keeps published events in memory
+	var publisher eventPublisher = messaging.NewMemoryPublisher("checkout-events")
+	kafka := cfg.Messaging.Backend == config.MessagingKafka
+	inProcess := cfg.Messaging.Backend == config.MessagingInProcess
+	if !local && kafka {
+		kafkaPublisher, err := newKafkaPublisher(cfg.Kafka.Brokers, "checkout-events")
+		if err != nil {
+			logger.Fatal("Failed to create publisher", zap.Error(err))
+		}
+		defer kafkaPublisher.Close()
+		if cfg.ShadowEvents.Enabled {
+			logger.Warn("Shadow events need RabbitMQ and stay off with Kafka")
+		}
+		publisher = kafkaPublisher
+	}
+	if !local && inProcess {
+		bus = messaging.NewInProcessBus("checkout-events", cfg.InProcess.QueueSize)
+		bus.Declare(messaging.DefaultTopology(cfg.RabbitMQ.DLQTTL))
+		defer bus.Close()
+		if cfg.ShadowEvents.Enabled {
+			logger.Warn("Shadow events need RabbitMQ and stay off with the in-process bus")
+		}
+		publisher = bus
+	}
+	if !local && !kafka && !inProcess {
+		rabbitPublisher, err := messaging.NewRabbitMQPublisher(rabbitURL, "checkout-events")
+		if err != nil {
+			logger.Fatal("Failed to create publisher", zap.Error(err))
+		}
+		defer rabbitPublisher.Close()
+		if cfg.ShadowEvents.Enabled {
+			if err := rabbitPublisher.EnableShadow(cfg.ShadowEvents.Exchange); err != nil {
+				logger.Fatal("Failed to enable shadow events", zap.Error(err))
+			}
+			logger.Info("Shadow events enabled", zap.String("exchange", cfg.ShadowEvents.Exchange))
+		}
+		publisher = rabbitPublisher
+	}
+	// Malformed routes are reported by the config self-check
+	for _, route := range cfg.OutboxRoutes() {
+		if err := publisher.Route(route.EventType, route.Exchange, route.RoutingKey); err != nil {
+			logger.Fatal("Failed to route event type", zap.String("event_type", route.EventType), zap.String("exchange", route.Exchange), zap.Error(err))
+		}
+		logger.Info("Event type routed", zap.String("event_type", route.EventType), zap.String("exchange", route.Exchange), zap.String("routing_key", route.RoutingKey))
+	}
+	if cfg.Messaging.ValidateSchemas {
+		if err := publisher.EnableQuarantine(cfg.Messaging.QuarantineQueue); err != nil {
+			logger.Fatal("Failed to declare quarantine queue", zap.Error(err))
+		}
+	}
+	publisher.SetDryRun(cfg.Outbox.DryRun)
+
+	// The RabbitMQ checks are skipped in local mode, which has no broker, and
+	// with Kafka or the in-process bus
+	checkedBrokerURL := rabbitURL
+	if local || kafka || inProcess {
+		checkedBrokerURL = ""
+	}
+
+	// Verify schema, broker topology and config before serving traffic
+	startupReport := &selfcheck.Report{Mode: cfg.StartupCheckMode, Healthy: true}
+	if cfg.StartupCheckMode != selfcheck.ModeOff && !local {
+		checker := selfcheck.NewChecker(shards, checkedBrokerURL, messaging.DefaultTopology(cfg.RabbitMQ.DLQTTL), cfg)
+		startupReport = checker.Run(ctx, cfg.StartupCheckMode)
+		if !startupReport.Healthy && cfg.StartupCheckMode == selfcheck.ModeStrict {
+			logger.Fatal("Startup self-check failed", zap.String("failed", startupReport.Summary()))
+		}
+	}
+
+	// Readiness waits for the warm-up started once the workers' context exists,
+	// then follows the cached dependency checks
+	readiness := selfcheck.NewReadiness()
+	warmer := selfcheck.NewChecker(shards, checkedBrokerURL, messaging.DefaultTopology(cfg.RabbitMQ.DLQTTL), cfg)
+	dependencyHealth := selfcheck.NewDependencyHealth(warmer,
+		time.Duration(cfg.HealthCheck.IntervalSec)*time.Second,
+		time.Duration(cfg.HealthCheck.JitterSec)*time.Second,
+		time.Duration(cfg.HealthCheck.TimeoutSec)*time.Second)
+
+	// Pay periods follow the company calendar in the default time zone
+	payWeekStart := time.Monday
+	if cfg.Reports.WeekStart == "sunday" {
+		payWeekStart = time.Sunday
+	}
+	paySchedule, err := hours.NewPaySchedule(cfg.PayPeriod.Frequency, cfg.PayPeriod.Anchor, cfg.PayPeriod.SemimonthlyDays, payWeekStart)
 	if err != nil {
-		logger.Fatal("Failed to create publisher", zap.Error(err))
+		logger.Fatal("Invalid pay period configuration", zap.Error(err))
+	}
+	payLoc, err := entities.LoadTimeZone(cfg.DefaultTimeZone)
+	if err != nil {
+		logger.Fatal("Invalid default time zone", zap.Error(err))
 	}
-	defer publisher.Close()
 
 	// Initialize application services
-	checkInService := services.NewCheckInService(timeRecordRepo, publisher)
-	checkOutService := services.NewCheckOutService(timeRecordRepo, publisher)
+	payPeriodService := services.NewPayPeriodService(paySchedule, payLoc)
+	checkInService := services.NewCheckInService(timeRecordRepo, locationRepo, projectRepo, employeeRepo, publisher)
+	for _, hook := range cfg.CheckInHooks.URLs {
+		name, url, ok := strings.Cut(hook, "=")
+		if !ok {
+			continue // Reported by the config self-check
+		}
+		checkInService.AddHook(external.NewHTTPCheckInHook(name, url, cfg.CheckInHooks.Token))
+		logger.Info("Check-in hook registered", zap.String("hook", name))
+	}
+	checkOutService := services.NewCheckOutService(timeRecordRepo, holidayRepo, employeeRepo, publisher)
+	consentService := services.NewConsentService(consentRepo, cfg.Consent.RequireExplicit)
+	consumerConsents := services.NewConsentService(consumerConsentRepo, cfg.Consent.RequireExplicit)
+	repairService := services.NewRepairService(timeRecordRepo, holidayRepo)
+	locationService := services.NewLocationService(locationRepo, timeRecordRepo)
+	projectService := services.NewProjectService(projectRepo)
+	reportService := services.NewReportService(timeRecordRepo, mergeRepo, payPeriodService, consentService, scheduleRepo, holidayRepo)
+	aggregationService := services.NewAggregationService(timeRecordRepo, employeeRepo, payPeriodService, consentService)
+	noteService := services.NewNoteService(timeRecordRepo, noteRepo)
+	mergeService := services.NewEmployeeMergeService(timeRecordRepo, mergeRepo)
+	employeeService := services.NewEmployeeService(employeeRepo)
+	approvalService := services.NewApprovalService(timeRecordRepo, approvalRepo, employeeRepo, noteRepo, holidayRepo)
+	var anchorExporter services.AnchorExporter
+	if cfg.Audit.AnchorURL != "" {
+		anchorExporter = external.NewAnchorStore(cfg.Audit.AnchorURL, cfg.Audit.AnchorToken)
+	}
+	auditService := services.NewAuditService(auditRepo, anchorExporter)
+	deviceService := services.NewDeviceService(deviceRepo, locationRepo)
+	holidayService := services.NewHolidayService(holidayRepo)
+	idempotencyService := services.NewIdempotencyService(idempotencyRepo, time.Duration(cfg.Idempotency.TTLHours)*time.Hour, time.Duration(cfg.Idempotency.LockTimeoutSec)*time.Second)
+	antiPassbackService := services.NewAntiPassbackService(timeRecordRepo)
+	emailSettingsService := services.NewEmailSettingsService(emailSettingsRepo, time.Duration(cfg.Notifications.SettingsCacheSec)*time.Second)
+	// Every entry point changing time records goes through the command bus
+	commandBus := services.NewCommandBus(services.MeasureCommands, services.AuditCommands, services.AuthorizeCommands, services.ValidateCommands)
+	transferService := services.NewTransferService(timeRecordRepo, checkInService, checkOutService)
+	services.RegisterTimeRecordCommands(commandBus, checkInService, checkOutService, transferService, approvalService)
+	inboundEmailService := services.NewInboundEmailService(employeeRepo, timeRecordRepo, commandBus, idempotencyService)
+	exceptionService := services.NewExceptionService(scheduleRepo, shiftExceptionRepo, timeRecordRepo, employeeRepo, holidayRepo, time.Duration(cfg.Exceptions.GraceMin)*time.Minute)
+	timesheetService := services.NewTimesheetService(timeRecordRepo, timesheetRepo, employeeRepo, payPeriodService, time.Duration(cfg.Timesheets.CutoffDays)*24*time.Hour)
+	searchService := services.NewSearchService(searchRepo)
+	timelineService := services.NewTimelineService(timeRecordRepo, noteRepo, auditRepo, outboxRepo)
+	queueInspector := messaging.NewQueueInspector(rabbitURL, messaging.DefaultTopology(cfg.RabbitMQ.DLQTTL))
+	// Local mode has no consumers, and Kafka and the in-process bus no
+	// RabbitMQ queues, so their preflight only checks the outbox
+	var consumerQueues services.QueueDepthReader
+	if !local && !kafka && !inProcess {
+		consumerQueues = queueInspector
+	}
+	preflightService := services.NewPayrollPreflightService(outboxRepo, consumerQueues, payPeriodService)
+	if cfg.Payroll.BlockClose {
+		timesheetService.RequirePreflight(preflightService)
+	}
+	missedCheckoutService := services.NewMissedCheckoutService(timeRecordRepo, time.Duration(cfg.MissedCheckout.AfterHours*float64(time.Hour)), cfg.MissedCheckout.BatchSize)
+
+	// Import the configured holidays into the calendar
+	if imported, err := holidayService.Import(ctx, cfg.Holidays.Import); err != nil {
+		logger.Fatal("Failed to import holidays", zap.Error(err))
+	} else if imported > 0 {
+		logger.Info("Imported holidays", zap.Int("count", imported))
+	}
 
 	// Initialize HTTP handlers
-	checkInHandler := httphandlers.NewCheckInHandler(checkInService, checkOutService)
+	checkInHandler := httphandlers.NewCheckInHandler(commandBus, antiPassbackService)
+	consentHandler := httphandlers.NewConsentHandler(consentService)
+	repairHandler := httphandlers.NewRepairHandler(repairService)
+	locationHandler := httphandlers.NewLocationHandler(locationService)
+	projectHandler := httphandlers.NewProjectHandler(projectService)
+	reportHandler := httphandlers.NewReportHandler(reportService, noteService, aggregationService, payPeriodService)
+	noteHandler := httphandlers.NewNoteHandler(noteService)
+	mergeHandler := httphandlers.NewMergeHandler(mergeService)
+	employeeHandler := httphandlers.NewEmployeeHandler(employeeService)
+	approvalHandler := httphandlers.NewApprovalHandler(approvalService, commandBus)
+	timesheetHandler := httphandlers.NewTimesheetHandler(timesheetService)
+	exceptionHandler := httphandlers.NewExceptionHandler(exceptionService)
+	payPeriodHandler := httphandlers.NewPayPeriodHandler(payPeriodService)
+	deviceHandler := httphandlers.NewDeviceHandler(deviceService)
+	holidayHandler := httphandlers.NewHolidayHandler(holidayService)
+	outboxHandler := httphandlers.NewOutboxHandler(publisher, outboxRepo)
+	auditHandler := httphandlers.NewAuditHandler(recordChangeRepo)
+	parityChecker := handlers.NewParityChecker()
+	shadowHandler := httphandlers.NewShadowHandler(parityChecker)
+	searchHandler := httphandlers.NewSearchHandler(searchService)
+	// The legacy API breaker is shared with the labor cost worker so its state can be shown
+	legacyBreaker := newCircuitBreaker("legacy-api").WithFailureFilter(external.IsServiceFailure)
+	// And the SMTP breaker with the email and reminder workers
+	smtpBreaker := newCircuitBreaker("smtp").WithCallTimeout(time.Duration(cfg.SMTP.TimeoutSec) * time.Second)
+	// So is the rate limiter, which must outlive worker restarts to keep
+	// its count
+	var legacyLimiter *external.RateLimiter
+	if cfg.LegacyAPI.RateLimit > 0 {
+		legacyLimiter = external.NewRateLimiter(cfg.LegacyAPI.RateLimit)
+	}
+	// And the bulkhead, so restarts do not lose track of calls in flight
+	var legacyBulkhead *resilience.Bulkhead
+	if cfg.LegacyAPI.MaxConcurrent > 0 {
+		legacyBulkhead = resilience.NewBulkhead("legacy-api", cfg.LegacyAPI.MaxConcurrent, time.Duration(cfg.LegacyAPI.QueueTimeoutMs)*time.Millisecond)
+	}
+	breakerHandler := httphandlers.NewBreakerHandler(map[string]httphandlers.ForcibleBreaker{"legacy-api": legacyBreaker, "smtp": smtpBreaker})
+	opsHandler := httphandlers.NewOpsHandler(outboxRepo, queueInspector, map[string]httphandlers.CircuitStateReader{"legacy-api": legacyBreaker, "smtp": smtpBreaker})
+	inboundEmailHandler := httphandlers.NewInboundEmailHandler(inboundEmailService, cfg.InboundEmail.Token)
+	emailSettingsHandler := httphandlers.NewEmailSettingsHandler(emailSettingsService)
+	payrollHandler := httphandlers.NewPayrollHandler(preflightService)
+	timelineHandler := httphandlers.NewTimelineHandler(timelineService)
+	// Consumers run under a supervisor that restarts them when the broker
+	// goes away; the recovery endpoint restarts them without waiting
+	brokerBackoff := messaging.Backoff{
+		Initial: time.Duration(cfg.RabbitMQ.ReconnectInitialMs) * time.Millisecond,
+		Max:     time.Duration(cfg.RabbitMQ.ReconnectMaxSec) * time.Second,
+	}
+	consumers := messaging.NewConsumerSupervisor(brokerBackoff)
+	outboxKick := make(chan struct{}, 1)
+	recoveryService := services.NewRecoveryService(publisher, consumers, map[string]services.ResettableBreaker{"legacy-api": legacyBreaker, "smtp": smtpBreaker},
+		time.Duration(cfg.Recovery.BreakerOpenSec)*time.Second, outboxKick)
+	recoveryHandler := httphandlers.NewRecoveryHandler(recoveryService)
 
 	// Setup HTTP routes
 	mux := http.NewServeMux()
-	mux.HandleFunc("/api/checkin", checkInHandler.HandleCheckIn)
+	mux.HandleFunc("/api/checkin", httphandlers.RequireDevice(deviceService, cfg.Devices.RequireAuth, httphandlers.Idempotent(idempotencyService, checkInHandler.HandleCheckIn)))
+	mux.HandleFunc("POST /api/devices/{id}/enroll", deviceHandler.Enroll)
+	mux.HandleFunc("POST /api/devices/rotate", httphandlers.RequireDevice(deviceService, true, deviceHandler.Rotate))
 	mux.HandleFunc("/health", checkInHandler.HealthCheck)
+	mux.HandleFunc("GET /ready", httphandlers.ReadinessCheck(readiness, dependencyHealth))
+	mux.HandleFunc("GET /api/employees/{id}/consents", consentHandler.ListConsents)
+	mux.HandleFunc("PUT /api/employees/{id}/consents/{purpose}", consentHandler.GrantConsent)
+	mux.HandleFunc("DELETE /api/employees/{id}/consents/{purpose}", consentHandler.WithdrawConsent)
+	mux.HandleFunc("GET /api/locations", locationHandler.ListLocations)
+	mux.HandleFunc("GET /api/presence", locationHandler.Presence)
+	mux.HandleFunc("GET /api/projects", projectHandler.ListProjects)
+	mux.HandleFunc("GET /api/holidays", holidayHandler.ListHolidays)
+	mux.HandleFunc("GET /api/pay-periods/current", payPeriodHandler.CurrentPeriod)
+	mux.HandleFunc("GET /api/reports/employees/{id}/records", reportHandler.RecordsReport)
+	mux.HandleFunc("GET /api/reports/employees/{id}/weekly", reportHandler.WeeklyReport)
+	mux.HandleFunc("GET /api/employees/{id}/hours", reportHandler.HoursSummary)
+	mux.HandleFunc("GET /api/reports/hours", reportHandler.HoursAggregate)
+	mux.HandleFunc("GET /api/reports/exceptions", exceptionHandler.ExceptionReport)
+	mux.HandleFunc("GET /api/employees/{id}/schedule", exceptionHandler.GetSchedule)
+	mux.HandleFunc("POST /api/employees/{id}/records/{recordId}/notes", httphandlers.Idempotent(idempotencyService, noteHandler.AppendNote))
+	mux.HandleFunc("POST /api/employees/{id}/approvals", httphandlers.Idempotent(idempotencyService, approvalHandler.SubmitApproval))
+	mux.HandleFunc("GET /api/employees/{id}/timesheet", timesheetHandler.GetTimesheet)
+	mux.HandleFunc("POST /api/employees/{id}/timesheet/submit", timesheetHandler.SubmitTimesheet)
+	mux.HandleFunc("GET /api/employees/{id}/timeline", timelineHandler.GetTimeline)
+	mux.HandleFunc("POST /api/inbound/email", inboundEmailHandler.ReceiveEmail)
+
+	// Admin routes
+	adminKey := cfg.Admin.APIKey
+	mux.HandleFunc("GET /api/search", httphandlers.RequireAdmin(adminKey, searchHandler.Search))
+	mux.HandleFunc("GET /admin/ui", httphandlers.AdminUI)
+	mux.HandleFunc("GET /api/admin/ops/status", httphandlers.RequireAdmin(adminKey, opsHandler.GetStatus))
+	mux.HandleFunc("POST /api/admin/queues/{queue}/dlq/replay", httphandlers.RequireAdmin(adminKey, opsHandler.ReplayDLQ))
+	mux.HandleFunc("GET /api/admin/queues/{queue}/dlq", httphandlers.RequireAdmin(adminKey, opsHandler.PeekDLQ))
+	mux.HandleFunc("DELETE /api/admin/queues/{queue}/dlq", httphandlers.RequireAdmin(adminKey, opsHandler.PurgeDLQ))
+	mux.HandleFunc("POST /api/admin/recover", httphandlers.RequireAdmin(adminKey, recoveryHandler.Recover))
+	mux.HandleFunc("PUT /api/admin/breakers/{name}", httphandlers.RequireAdmin(adminKey, breakerHandler.SetState))
+	mux.HandleFunc("GET /api/admin/selfcheck", httphandlers.RequireAdmin(adminKey, httphandlers.StaticJSON(startupReport)))
+	mux.HandleFunc("POST /api/admin/employees/{id}/repair", httphandlers.RequireAdmin(adminKey, repairHandler.HandleRepair))
+	mux.HandleFunc("PUT /api/admin/locations/{id}", httphandlers.RequireAdmin(adminKey, locationHandler.SaveLocation))
+	mux.HandleFunc("PUT /api/admin/projects/{code}", httphandlers.RequireAdmin(adminKey, projectHandler.SaveProject))
+	mux.HandleFunc("PUT /api/admin/holidays/{date}", httphandlers.RequireAdmin(adminKey, holidayHandler.SaveHoliday))
+	mux.HandleFunc("DELETE /api/admin/holidays/{date}", httphandlers.RequireAdmin(adminKey, holidayHandler.DeleteHoliday))
+	mux.HandleFunc("PUT /api/admin/employees/{id}/schedule", httphandlers.RequireAdmin(adminKey, exceptionHandler.SetSchedule))
+	mux.HandleFunc("POST /api/admin/employees/{id}/merge", httphandlers.RequireAdmin(adminKey, mergeHandler.HandleMerge))
+	mux.HandleFunc("GET /api/admin/employees", httphandlers.RequireAdmin(adminKey, employeeHandler.ListEmployees))
+	mux.HandleFunc("GET /api/admin/employees/{id}", httphandlers.RequireAdmin(adminKey, employeeHandler.GetEmployee))
+	mux.HandleFunc("PUT /api/admin/employees/{id}", httphandlers.RequireAdmin(adminKey, employeeHandler.SaveEmployee))
+	mux.HandleFunc("DELETE /api/admin/employees/{id}", httphandlers.RequireAdmin(adminKey, employeeHandler.DeleteEmployee))
+	mux.HandleFunc("GET /api/admin/approvals", httphandlers.RequireAdmin(adminKey, approvalHandler.ListPending))
+	mux.HandleFunc("POST /api/admin/approvals/{id}/approve", httphandlers.RequireAdmin(adminKey, approvalHandler.Approve))
+	mux.HandleFunc("POST /api/admin/approvals/{id}/reject", httphandlers.RequireAdmin(adminKey, approvalHandler.Reject))
+	mux.HandleFunc("POST /api/admin/timesheets/{id}/approve", httphandlers.RequireAdmin(adminKey, timesheetHandler.Approve))
+	mux.HandleFunc("POST /api/admin/timesheets/{id}/reject", httphandlers.RequireAdmin(adminKey, timesheetHandler.Reject))
+	mux.HandleFunc("POST /api/admin/timesheets/close", httphandlers.RequireAdmin(adminKey, timesheetHandler.ClosePeriod))
+	mux.HandleFunc("POST /api/admin/employees/{id}/timesheet/close", httphandlers.RequireAdmin(adminKey, timesheetHandler.CloseTimesheet))
+	mux.HandleFunc("GET /api/admin/payroll/preflight", httphandlers.RequireAdmin(adminKey, payrollHandler.Preflight))
+	mux.HandleFunc("GET /api/admin/payroll/preflight/last", httphandlers.RequireAdmin(adminKey, payrollHandler.LastPreflight))
+	mux.HandleFunc("GET /api/admin/devices", httphandlers.RequireAdmin(adminKey, deviceHandler.ListDevices))
+	mux.HandleFunc("POST /api/admin/devices", httphandlers.RequireAdmin(adminKey, deviceHandler.CreateDevice))
+	mux.HandleFunc("POST /api/admin/devices/{id}/enrollment", httphandlers.RequireAdmin(adminKey, deviceHandler.ResetEnrollment))
+	mux.HandleFunc("POST /api/admin/devices/{id}/revoke", httphandlers.RequireAdmin(adminKey, deviceHandler.RevokeDevice))
+	mux.HandleFunc("GET /api/admin/outbox/dry-run", httphandlers.RequireAdmin(adminKey, outboxHandler.GetDryRun))
+	mux.HandleFunc("PUT /api/admin/outbox/dry-run", httphandlers.RequireAdmin(adminKey, outboxHandler.SetDryRun))
+	mux.HandleFunc("GET /api/admin/outbox/failed/export", httphandlers.RequireAdmin(adminKey, outboxHandler.ExportFailed))
+	mux.HandleFunc("GET /api/admin/outbox", httphandlers.RequireAdmin(adminKey, outboxHandler.List))
+	mux.HandleFunc("POST /api/admin/outbox/requeue", httphandlers.RequireAdmin(adminKey, outboxHandler.RequeueBulk))
+	mux.HandleFunc("POST /api/admin/outbox/{id}/requeue", httphandlers.RequireAdmin(adminKey, outboxHandler.Requeue))
+	mux.HandleFunc("GET /api/admin/audit", httphandlers.RequireAdmin(adminKey, auditHandler.RecordChanges))
+	mux.HandleFunc("GET /api/admin/shadow/parity", httphandlers.RequireAdmin(adminKey, shadowHandler.GetParityReport))
+	mux.HandleFunc("GET /api/admin/notifications/checkout-email", httphandlers.RequireAdmin(adminKey, emailSettingsHandler.GetCheckOutEmail))
+	mux.HandleFunc("PUT /api/admin/notifications/checkout-email", httphandlers.RequireAdmin(adminKey, emailSettingsHandler.SaveCheckOutEmail))
 
 	// Start HTTP server with configurable port
 	httpPort := cfg.Server.Port
@@ -106,14 +549,146 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Start Outbox Publisher (polls outbox and publishes to RabbitMQ)
-	go startOutboxPublisher(ctx, outboxRepo, publisher)
+	go readDB.Monitor(ctx, time.Duration(cfg.Database.ReplicaCheckIntervalS)*time.Second)
+
+	// Warm up the database and the broker before reporting ready; local mode
+	// has neither to warm up
+	go startWarmUp(ctx, warmer, readiness, time.Duration(cfg.WarmUp.StepTimeoutSec)*time.Second, time.Duration(cfg.WarmUp.RetrySec)*time.Second)
+
+	// Keep checking them in the background for the readiness probe
+	go dependencyHealth.Run(ctx)
+
+	// Singleton jobs run on one instance at a time, the leader; local mode
+	// runs a single instance
+	var leaderLock leader.Lock = leader.SingleInstance{}
+	if !local {
+		leaderLock = persistence.NewPostgresLeaderLock(db, cfg.Leader.LockKey)
+	}
+	elector := leader.NewElector(leaderLock, time.Duration(cfg.Leader.RenewIntervalSec)*time.Second)
+	go elector.Run(ctx)
+
+	// Reconnect the publisher whenever the broker closes its connection
+	go publisher.KeepConnected(ctx, brokerBackoff)
+
+	// Start Outbox Publisher (polls outbox and publishes to RabbitMQ); every
+	// instance runs it, as claims keep them from publishing an event twice
+	go startOutboxPublisher(ctx, outboxRepo, publisher, outboxKick)
+
+	// Delete published events past their retention and report the table size
+	go elector.RunWhileLeader(ctx, "outbox-pruner", func(ctx context.Context) {
+		startOutboxPruner(ctx, outboxRepo, time.Duration(cfg.Outbox.RetentionHours)*time.Hour, cfg.Outbox.PruneBatchSize, time.Duration(cfg.Outbox.PruneIntervalMin)*time.Minute)
+	})
 
-	// Labor cost worker
-	go startLaborCostWorker(ctx, rabbitURL, legacyAPIURL)
+	// Report the size and age of the outbox backlog
+	go elector.RunWhileLeader(ctx, "outbox-health", func(ctx context.Context) {
+		startOutboxHealthReporter(ctx, outboxRepo, time.Duration(cfg.Outbox.HealthIntervalSec)*time.Second)
+	})
 
-	// Email worker
-	go startEmailWorker(ctx, rabbitURL, smtpHost)
+	// Periodically anchor the audit log's hash chains
+	go elector.RunWhileLeader(ctx, "audit-anchor", func(ctx context.Context) {
+		startAuditAnchorWorker(ctx, auditService, time.Duration(cfg.Audit.AnchorIntervalMin)*time.Minute)
+	})
+
+	// Purge expired idempotency keys
+	go elector.RunWhileLeader(ctx, "idempotency-cleanup", func(ctx context.Context) {
+		startIdempotencyCleanup(ctx, idempotencyService, time.Duration(cfg.Idempotency.CleanupIntervalMin)*time.Minute)
+	})
+
+	// Forget processed event IDs past their retention
+	go elector.RunWhileLeader(ctx, "inbox-cleanup", func(ctx context.Context) {
+		startInboxCleanup(ctx, processedEventRepo, time.Duration(cfg.Inbox.RetentionHours)*time.Hour, time.Duration(cfg.Inbox.CleanupIntervalMin)*time.Minute)
+	})
+
+	// Report records left open past the expected end of the shift
+	go elector.RunWhileLeader(ctx, "missed-checkout-detector", func(ctx context.Context) {
+		startMissedCheckoutDetector(ctx, missedCheckoutService, time.Duration(cfg.MissedCheckout.ScanIntervalMin)*time.Minute)
+	})
+
+	// Close pay periods once their payroll cut-off has passed
+	go elector.RunWhileLeader(ctx, "timesheet-closer", func(ctx context.Context) {
+		startTimesheetCloser(ctx, timesheetService, time.Duration(cfg.Timesheets.CloseIntervalMin)*time.Minute)
+	})
+
+	// Open and resolve incidents for the open breaker, DLQ depth, outbox backlog and failed outbox events
+	incidentRules := map[string]incidents.Rule{}
+	incidentSink := func(provider string) incidents.Sink {
+		switch provider {
+		case incidents.ProviderPagerDuty:
+			if cfg.Incidents.PagerDutyRoutingKey == "" {
+				logger.Fatal("INCIDENTS_PAGERDUTY_ROUTING_KEY is required to send incidents to PagerDuty")
+			}
+			return incidents.NewPagerDuty(cfg.Incidents.PagerDutyURL, cfg.Incidents.PagerDutyRoutingKey)
+		case incidents.ProviderOpsgenie:
+			if cfg.Incidents.OpsgenieAPIKey == "" {
+				logger.Fatal("INCIDENTS_OPSGENIE_API_KEY is required to send incidents to Opsgenie")
+			}
+			return incidents.NewOpsgenie(cfg.Incidents.OpsgenieURL, cfg.Incidents.OpsgenieAPIKey)
+		}
+		return nil
+	}
+	incidentRules[incidents.AlertBreakerOpen] = incidents.Rule{Sink: incidentSink(cfg.Incidents.BreakerOpenProvider), Severity: cfg.Incidents.BreakerOpenSeverity}
+	incidentRules[incidents.AlertDLQDepth] = incidents.Rule{Sink: incidentSink(cfg.Incidents.DLQProvider), Severity: cfg.Incidents.DLQSeverity, Threshold: cfg.Incidents.DLQThreshold}
+	incidentRules[incidents.AlertOutboxBacklog] = incidents.Rule{Sink: incidentSink(cfg.Incidents.OutboxProvider), Severity: cfg.Incidents.OutboxSeverity,
+		Threshold: cfg.Incidents.OutboxThreshold, MaxLag: time.Duration(cfg.Incidents.OutboxMaxLagSec) * time.Second}
+	incidentRules[incidents.AlertOutboxFailed] = incidents.Rule{Sink: incidentSink(cfg.Incidents.OutboxFailedProvider), Severity: cfg.Incidents.OutboxFailedSeverity}
+	var incidentQueues incidents.QueueDepths = queueInspector
+	if local {
+		incidentQueues = nil
+	}
+	incidentMonitor := incidents.NewMonitor(cfg.Incidents.Source, incidentRules, map[string]incidents.Breaker{"legacy-api": legacyBreaker, "smtp": smtpBreaker}, incidentQueues, outboxRepo)
+	go startIncidentMonitor(ctx, incidentMonitor, time.Duration(cfg.Incidents.CheckIntervalSec)*time.Second)
+
+	// Record no-shows of scheduled shifts and notify the managers
+	go elector.RunWhileLeader(ctx, "exception-detector", func(ctx context.Context) {
+		startExceptionDetector(ctx, exceptionService, time.Duration(cfg.Exceptions.ScanIntervalMin)*time.Minute)
+	})
+
+	// Create the monthly time_records partitions before check-ins reach them
+	if cfg.Database.Driver == "postgres" && !local {
+		go elector.RunWhileLeader(ctx, "partition-maintenance", func(ctx context.Context) {
+			startPartitionMaintenance(ctx, persistence.NewPartitionMaintainer(shards), cfg.Database.PartitionMonthsAhead, time.Duration(cfg.Database.PartitionCheckIntervalM)*time.Minute)
+		})
+	}
+
+	// Check ahead of the payroll cut-off that the last period's events went through
+	go elector.RunWhileLeader(ctx, "payroll-preflight", func(ctx context.Context) {
+		startPayrollPreflight(ctx, preflightService, cfg.Payroll.PreflightCron, payLoc)
+	})
+
+	// RabbitMQ consumers; local mode has no broker to consume from
+	if !local {
+		// Labor cost worker
+		consumers.Start(ctx, "labor-cost", func(ctx context.Context) error {
+			return startLaborCostWorker(ctx, rabbitURL, legacyAPIURL, legacyBreaker, legacyLimiter, legacyBulkhead, processedEventRepo, parkedLaborCostRepo)
+		})
+
+		// Submit the labor cost entries parked while the legacy API's circuit
+		// was open
+		if cfg.LaborCost.Parking && cfg.LaborCost.Backend == config.LaborCostLegacy {
+			drainer := handlers.NewLaborCostDrainer(parkedLaborCostRepo, newLaborCostClient(legacyAPIURL, legacyBreaker, legacyLimiter, legacyBulkhead), legacyBreaker, cfg.LaborCost.DrainBatchSize)
+			go elector.RunWhileLeader(ctx, "labor-cost-drainer", func(ctx context.Context) {
+				startLaborCostDrainer(ctx, drainer, parkedLaborCostRepo, time.Duration(cfg.LaborCost.DrainIntervalSec)*time.Second)
+			})
+		}
+
+		// Email worker
+		consumers.Start(ctx, "email", func(ctx context.Context) error {
+			return startEmailWorker(ctx, rabbitURL, smtpHost, smtpBreaker, consumerConsents, emailSettingsService, idempotencyService, processedEventRepo)
+		})
+
+		// Missed check-out reminder worker
+		consumers.Start(ctx, "reminder", func(ctx context.Context) error {
+			return startReminderWorker(ctx, rabbitURL, smtpHost, smtpBreaker, consumerConsents, idempotencyService)
+		})
+
+		// Compare shadow events with the current version; only RabbitMQ
+		// carries them
+		if cfg.ShadowEvents.Enabled && !kafka && !inProcess {
+			consumers.Start(ctx, "shadow-parity", func(ctx context.Context) error {
+				return startParityWorker(ctx, rabbitURL, parityChecker)
+			})
+		}
+	}
 
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
@@ -132,21 +707,53 @@ func main() {
 
 	logger.Info("Server stopped")
 
-	// Cancel workers
+	// Cancel workers; consumers stop taking messages and drain the ones in flight
 	cancel()
 
-	// Give some time for goroutines to finish
-	time.Sleep(2 * time.Second)
+	// Wait for the consumers to settle their messages and close their
+	// connections; a handler ignoring its cancelled context is not waited for
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), time.Duration(cfg.RabbitMQ.DrainTimeoutSec)*time.Second+5*time.Second)
+	defer drainCancel()
+	if err := consumers.Wait(drainCtx); err != nil {
+		logger.Warn("Consumers did not stop in time", zap.Error(err))
+	}
 	logger.Info("Application exited")
 
 }
 
-func startOutboxPublisher(ctx context.Context, outboxRepo *persistence.PostgresOutboxRepository, publisher *messaging.RabbitMQPublisher) {
+// outboxStore reads the outbox of the configured database
+type outboxStore interface {
+	repositories.OutboxRepository
+	repositories.OutboxHistory
+	repositories.OutboxFailures
+	repositories.OutboxPruner
+}
+
+// eventPublisher sends events to RabbitMQ, or keeps them in memory in local mode
+type eventPublisher interface {
+	services.EventPublisher
+	PublishBatch(ctx context.Context, batch []messaging.RawEvent) []error
+	Preview(eventType string, body []byte) (messaging.PublishPreview, error)
+	Route(eventType, exchange, routingKey string) error
+	DryRun() bool
+	SetDryRun(enabled bool)
+	Reconnect() (bool, error)
+	Connected() bool
+	KeepConnected(ctx context.Context, backoff messaging.Backoff)
+	EnableQuarantine(queue string) error
+	Quarantine(ctx context.Context, eventType string, body []byte, reason string) error
+}
+
+func startOutboxPublisher(ctx context.Context, outboxRepo repositories.OutboxReader, publisher eventPublisher, kick <-chan struct{}) {
 	pollInterval := config.Cfg.Outbox.PollIntervalSec
 	ticker := time.NewTicker(time.Duration(pollInterval) * time.Second)
 	defer ticker.Stop()
 
-	config.Logger.Info("Outbox publisher started")
+	// Claims name the instance, so operators can tell who holds an event
+	hostname, _ := os.Hostname()
+	claimant := fmt.Sprintf("%s-%d", hostname, os.Getpid())
+
+	config.Logger.Info("Outbox publisher started", zap.String("claimant", claimant))
 
 	for {
 		select {
@@ -155,121 +762,688 @@ func startOutboxPublisher(ctx context.Context, outboxRepo *persistence.PostgresO
 			return
 
 		case <-ticker.C:
-			// Start a new OpenTelemetry span for each poll cycle
-			tracer := otel.Tracer("check-in-service")
-			pollCtx, span := tracer.Start(ctx, "OutboxPublisherPoll")
-			defer span.End()
-
-			// Fetch unpublished events
-			maxEvents := config.Cfg.Outbox.FetchLimit
-			events, err := outboxRepo.GetUnpublishedEvents(pollCtx, maxEvents)
+		case <-kick:
+			// Woken up by the recovery endpoint instead of waiting for the ticker
+		}
+
+		// Events stay pending while the broker is away, instead of using up
+		// their retries
+		if !publisher.Connected() {
+			config.Logger.Warn("Outbox publisher waiting for RabbitMQ")
+			continue
+		}
+		publishOutboxEvents(ctx, outboxRepo, publisher, claimant)
+	}
+}
+
+// publishOutboxEvents runs one poll cycle of the outbox relay
+func publishOutboxEvents(ctx context.Context, outboxRepo repositories.OutboxReader, publisher eventPublisher, claimant string) {
+	// Start a new OpenTelemetry span for each poll cycle
+	tracer := otel.Tracer("check-in-service")
+	pollCtx, span := tracer.Start(ctx, "OutboxPublisherPoll")
+	defer span.End()
+
+	// Claim unpublished events, so other instances do not publish them too
+	maxEvents := config.Cfg.Outbox.FetchLimit
+	lease := time.Duration(config.Cfg.Outbox.ClaimLeaseSec) * time.Second
+	events, err := outboxRepo.ClaimUnpublished(pollCtx, claimant, maxEvents, lease)
+	if err != nil {
+		config.Logger.Error("Error fetching unpublished events", zap.Error(err))
+		span.RecordError(err)
+		return
+	}
+	// Heartbeat of this instance's relay: the last poll that reached the
+	// database, as a Unix time
+	metrics.Gauge("outbox.publisher.heartbeat", float64(time.Now().Unix()))
+
+	if len(events) == 0 {
+		span.AddEvent("No unpublished events found")
+		return
+	}
+
+	// Dry-run: show what would be published and leave the events pending
+	if publisher.DryRun() {
+		previewOutboxEvents(events, publisher)
+		ids := make([]string, len(events))
+		for i, event := range events {
+			ids[i] = event.ID
+		}
+		if err := outboxRepo.ReleaseClaims(pollCtx, ids); err != nil {
+			config.Logger.Warn("Failed to release outbox claims", zap.Error(err))
+		}
+		return
+	}
+
+	config.Logger.Info("Publishing events from outbox", zap.Int("count", len(events)))
+	span.SetAttributes(attribute.Int("outbox.claimed", len(events)))
+
+	// One event per aggregate is claimed at a time, so the whole batch can go out
+	batch := events
+
+	// Events that do not match their schema never reach the consumers
+	if config.Cfg.Messaging.ValidateSchemas {
+		batch = quarantineInvalidEvents(pollCtx, outboxRepo, publisher, batch)
+	}
+
+	// Publish the batch to RabbitMQ and wait for the broker to confirm it
+	raw := make([]messaging.RawEvent, len(batch))
+	for i, event := range batch {
+		raw[i] = messaging.RawEvent{Type: event.EventType, Body: event.Payload}
+	}
+	confirmCtx, cancelConfirm := context.WithTimeout(pollCtx, time.Duration(config.Cfg.Outbox.ConfirmTimeoutMs)*time.Millisecond)
+	results := publisher.PublishBatch(confirmCtx, raw)
+	cancelConfirm()
+
+	var published []string
+	for i, event := range batch {
+		err := results[i]
+		if err == nil {
+			published = append(published, event.ID)
+			continue
+		}
+
+		config.Logger.Error("Failed to publish event", zap.String("event_id", event.ID), zap.Error(err))
+		span.RecordError(err)
+		metrics.Incr("outbox.publish_failures", 1)
+		// Increment retry count; out of retries, the event waits to be requeued
+		failed, incErr := outboxRepo.IncrementRetryCount(pollCtx, event.ID, err.Error(), config.Cfg.Outbox.MaxRetries)
+		if incErr != nil {
+			config.Logger.Error("Failed to record publish failure", zap.String("event_id", event.ID), zap.Error(incErr))
+		}
+		if failed {
+			metrics.Incr("outbox.failed", 1)
+			config.Logger.Error("Outbox event out of retries, marked failed",
+				zap.String("event_id", event.ID),
+				zap.String("aggregate_id", event.AggregateID),
+				zap.String("type", event.EventType),
+				zap.Int("retries", event.RetryCount+1))
+		}
+	}
+
+	// Confirmed - mark the whole batch as published at once
+	if len(published) > 0 {
+		if err := outboxRepo.MarkAsPublished(pollCtx, published); err != nil {
+			// Left claimed, the events are published again once the lease runs out
+			config.Logger.Error("Failed to mark events as published", zap.Int("count", len(published)), zap.Error(err))
+			span.RecordError(err)
+		} else {
+			metrics.Incr("outbox.published", int64(len(published)))
+			config.Logger.Info("Successfully published events", zap.Int("count", len(published)))
+			span.AddEvent("Published events")
+		}
+	}
+}
+
+// quarantineInvalidEvents sends the events of batch whose payload does not
+// match their schema to the quarantine queue, marks them failed so the later
+// events of their aggregate wait for an operator, and returns the others
+func quarantineInvalidEvents(ctx context.Context, outboxRepo repositories.OutboxReader, publisher eventPublisher, batch []repositories.OutboxEvent) []repositories.OutboxEvent {
+	valid := batch[:0]
+	for _, event := range batch {
+		invalid := events.ValidatePayload(event.Payload)
+		if invalid == nil {
+			valid = append(valid, event)
+			continue
+		}
+
+		reason := "quarantined: " + invalid.Error()
+		maxRetries := 1
+		if err := publisher.Quarantine(ctx, event.EventType, event.Payload, invalid.Error()); err != nil {
+			// Retried like a failed publish, until it reaches the quarantine
+			config.Logger.Error("Failed to quarantine event", zap.String("event_id", event.ID), zap.Error(err))
+			reason, maxRetries = err.Error(), config.Cfg.Outbox.MaxRetries
+		} else {
+			metrics.Incr("outbox.quarantined", 1)
+			config.Logger.Error("Outbox event does not match its schema, quarantined",
+				zap.String("event_id", event.ID),
+				zap.String("aggregate_id", event.AggregateID),
+				zap.String("type", event.EventType),
+				zap.Error(invalid))
+		}
+		if _, err := outboxRepo.IncrementRetryCount(ctx, event.ID, reason, maxRetries); err != nil {
+			config.Logger.Error("Failed to record publish failure", zap.String("event_id", event.ID), zap.Error(err))
+		}
+	}
+	return valid
+}
+
+// previewOutboxEvents logs the message of each event at debug; the events
+// stay pending, so every poll previews them again
+func previewOutboxEvents(events []repositories.OutboxEvent, publisher eventPublisher) {
+	config.Logger.Info("Outbox dry-run: previewing events", zap.Int("count", len(events)))
+
+	for _, event := range events {
+		preview, err := publisher.Preview(event.EventType, event.Payload)
+		if err != nil {
+			config.Logger.Warn("Outbox dry-run: event would fail to publish", zap.String("event_id", event.ID), zap.String("type", event.EventType), zap.Error(err))
+			metrics.Incr("outbox.dry_run_failures", 1)
+			continue
+		}
+
+		config.Logger.Debug("Outbox dry-run: would publish event",
+			zap.String("event_id", event.ID),
+			zap.String("aggregate_id", event.AggregateID),
+			zap.String("type", preview.Type),
+			zap.String("exchange", preview.Exchange),
+			zap.String("routing_key", preview.RoutingKey),
+			zap.String("content_type", preview.ContentType),
+			zap.Int("bytes", preview.Bytes),
+			zap.Int("retry_count", event.RetryCount),
+		)
+		metrics.Incr("outbox.dry_run_previewed", 1)
+	}
+}
+
+func startWarmUp(ctx context.Context, checker *selfcheck.Checker, readiness *selfcheck.Readiness, stepTimeout, retry time.Duration) {
+	for {
+		report := checker.WarmUp(ctx, stepTimeout)
+		readiness.Record(report)
+		if report.Healthy {
+			config.Logger.Info("Warm-up completed, ready for traffic")
+			return
+		}
+		config.Logger.Warn("Warm-up failed, retrying", zap.String("failed", report.Summary()), zap.Duration("retry_in", retry))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retry):
+		}
+	}
+}
+
+func startAuditAnchorWorker(ctx context.Context, auditService *services.AuditService, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := auditService.Anchor(ctx); err != nil {
+				config.Logger.Error("Failed to anchor audit log", zap.Error(err))
+			}
+		}
+	}
+}
+
+func startIdempotencyCleanup(ctx context.Context, idempotencyService *services.IdempotencyService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := idempotencyService.PurgeExpired(ctx)
 			if err != nil {
-				config.Logger.Error("Error fetching unpublished events", zap.Error(err))
-				span.RecordError(err)
-				continue
+				config.Logger.Error("Failed to purge idempotency keys", zap.Error(err))
+			} else if purged > 0 {
+				config.Logger.Info("Purged expired idempotency keys", zap.Int64("count", purged))
 			}
+		}
+	}
+}
+
+func startInboxCleanup(ctx context.Context, repo repositories.ProcessedEventRepository, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
-			if len(events) == 0 {
-				span.AddEvent("No unpublished events found")
-				continue
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := repo.DeleteProcessedBefore(ctx, time.Now().Add(-retention))
+			if err != nil {
+				config.Logger.Error("Failed to purge processed events", zap.Error(err))
+			} else if purged > 0 {
+				config.Logger.Info("Purged processed events", zap.Int64("count", purged))
 			}
+		}
+	}
+}
 
-			config.Logger.Info("Publishing events from outbox", zap.Int("count", len(events)))
-			span.SetAttributes()
+func startMissedCheckoutDetector(ctx context.Context, missedCheckoutService *services.MissedCheckoutService, interval time.Duration) {
+	if config.Cfg.MissedCheckout.AfterHours <= 0 {
+		return
+	}
 
-			for _, event := range events {
-				// Try to publish to RabbitMQ
-				err := publisher.PublishRaw(pollCtx, event.EventType, event.Payload)
-				if err != nil {
-					config.Logger.Error("Failed to publish event", zap.String("event_id", event.ID), zap.Error(err))
-					span.RecordError(err)
-					// Increment retry count
-					outboxRepo.IncrementRetryCount(pollCtx, event.ID, err.Error())
-					continue
-				}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := missedCheckoutService.Detect(ctx); err != nil {
+				config.Logger.Error("Failed to detect missed check-outs", zap.Error(err))
+			}
+		}
+	}
+}
+
+func startPartitionMaintenance(ctx context.Context, maintainer *persistence.PartitionMaintainer, monthsAhead int, interval time.Duration) {
+	ensure := func() {
+		created, err := maintainer.EnsureMonthsAhead(ctx, monthsAhead)
+		if err != nil {
+			config.Logger.Error("Failed to create time record partitions", zap.Error(err))
+		} else if created > 0 {
+			config.Logger.Info("Created time record partitions", zap.Int("count", created))
+		}
+	}
+
+	ensure()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ensure()
+		}
+	}
+}
+
+// startOutboxHealthReporter reports the whole backlog on every shard, not
+// only the events one relay claimed, so a stalled relay shows as a growing
+// pending count and age
+func startOutboxHealthReporter(ctx context.Context, outboxRepo repositories.OutboxReader, interval time.Duration) {
+	reportOutboxBacklog(ctx, outboxRepo)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reportOutboxBacklog(ctx, outboxRepo)
+		}
+	}
+}
+
+// reportOutboxBacklog counts the unpublished events. outbox.pending and
+// outbox.lag_seconds are the whole backlog too: all unpublished events, and
+// the age of the oldest one still being retried.
+func reportOutboxBacklog(ctx context.Context, outboxRepo repositories.OutboxReader) {
+	backlog, err := outboxRepo.Backlog(ctx)
+	if err != nil {
+		config.Logger.Error("Failed to measure outbox backlog", zap.Error(err))
+		return
+	}
+	metrics.Gauge("outbox.backlog.pending", float64(backlog.Pending))
+	metrics.Gauge("outbox.backlog.failed", float64(backlog.Failed))
+	age := 0.0
+	if backlog.OldestAt != nil {
+		age = time.Since(*backlog.OldestAt).Seconds()
+	}
+	metrics.Gauge("outbox.backlog.oldest_age_seconds", age)
+	metrics.Gauge("outbox.pending", float64(backlog.Pending+backlog.Failed))
+	metrics.Gauge("outbox.lag_seconds", age)
+}
 
-				// Successfully published - mark as published
-				err = outboxRepo.MarkAsPublished(pollCtx, event.ID)
+func startOutboxPruner(ctx context.Context, outboxRepo repositories.OutboxPruner, retention time.Duration, batchSize int, interval time.Duration) {
+	prune := func() {
+		if retention > 0 {
+			cutoff := time.Now().Add(-retention)
+			total := 0
+			// Small batches keep each delete short; stop at the first partial one
+			for ctx.Err() == nil {
+				deleted, err := outboxRepo.PrunePublished(ctx, cutoff, batchSize)
+				total += deleted
+				metrics.Incr("outbox.pruned", int64(deleted))
 				if err != nil {
-					config.Logger.Error("Failed to mark event as published", zap.String("event_id", event.ID), zap.Error(err))
-					span.RecordError(err)
-					continue
+					config.Logger.Error("Failed to prune outbox events", zap.Error(err))
+					break
+				}
+				if deleted < batchSize {
+					break
 				}
+			}
+			if total > 0 {
+				config.Logger.Info("Pruned published outbox events", zap.Int("count", total), zap.Time("published_before", cutoff))
+			}
+		}
+
+		size, err := outboxRepo.Size(ctx)
+		if err != nil {
+			config.Logger.Error("Failed to measure outbox", zap.Error(err))
+			return
+		}
+		metrics.Gauge("outbox.rows", float64(size.Rows))
+		metrics.Gauge("outbox.bytes", float64(size.Bytes))
+	}
+
+	prune()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			prune()
+		}
+	}
+}
+
+func startIncidentMonitor(ctx context.Context, monitor *incidents.Monitor, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			monitor.Check(ctx)
+		}
+	}
+}
+
+func startExceptionDetector(ctx context.Context, exceptionService *services.ExceptionService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := exceptionService.DetectRecent(ctx); err != nil {
+				config.Logger.Error("Failed to detect shift exceptions", zap.Error(err))
+			}
+		}
+	}
+}
+
+func startTimesheetCloser(ctx context.Context, timesheetService *services.TimesheetService, interval time.Duration) {
+	if !config.Cfg.Timesheets.AutoClose {
+		return
+	}
 
-				config.Logger.Info("Successfully published event", zap.String("event_id", event.ID), zap.String("type", event.EventType))
-				span.AddEvent("Published event") // You can add attributes here if you want
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := timesheetService.CloseDue(ctx); err != nil {
+				config.Logger.Error("Failed to close pay period", zap.Error(err))
+			}
+		}
+	}
+}
+
+func startPayrollPreflight(ctx context.Context, preflightService *services.PayrollPreflightService, cronExpr string, loc *time.Location) {
+	if cronExpr == "" {
+		return
+	}
+	cron, err := schedule.ParseCron(cronExpr)
+	if err != nil {
+		config.Logger.Error("Payroll preflight disabled", zap.Error(err))
+		return
+	}
+
+	for {
+		next := cron.Next(time.Now().In(loc))
+		if next.IsZero() {
+			config.Logger.Error("Payroll preflight disabled: cron expression never matches", zap.String("cron", cronExpr))
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			// Failures are logged and counted by the service
+			if _, err := preflightService.Run(ctx, ""); err != nil {
+				config.Logger.Error("Payroll preflight error", zap.Error(err))
 			}
 		}
 	}
 }
 
-func startLaborCostWorker(ctx context.Context, rabbitURL, legacyAPIURL string) {
-	consumer, err := messaging.NewRabbitMQConsumer(rabbitURL, "checkout-events", "labor-cost-queue")
+func startLaborCostWorker(ctx context.Context, rabbitURL, legacyAPIURL string, cb *resilience.CircuitBreaker, limiter *external.RateLimiter, bulkhead *resilience.Bulkhead, inbox handlers.Inbox, parking handlers.ParkingLot) error {
+	consumer, err := openConsumer(rabbitURL, "checkout-events", "labor-cost-queue", func(consumer *messaging.RabbitMQConsumer) {
+		consumer.WithBatchAck(config.Cfg.RabbitMQ.LaborCostAckBatchSize, time.Duration(config.Cfg.RabbitMQ.LaborCostAckBatchMs)*time.Millisecond)
+		consumer.WithConcurrency(cmp.Or(config.Cfg.RabbitMQ.LaborCostWorkers, config.Cfg.RabbitMQ.Workers))
+	})
 	if err != nil {
-		log.Fatalf("Failed to create labor cost consumer: %v", err)
+		return fmt.Errorf("failed to create labor cost consumer: %w", err)
 	}
 	defer consumer.Close()
-	cbFailures := config.Cfg.CircuitBreaker.MaxFailures
-	cbReset := config.Cfg.CircuitBreaker.ResetTimeoutS
-	cb := external.NewCircuitBreaker(cbFailures, 1, time.Duration(cbReset)*time.Second)
-	legacyClient := external.NewLegacyLaborCostClient(legacyAPIURL, cb)
-	handler := handlers.NewLaborCostReporter(legacyClient)
+	client := newLaborCostClient(legacyAPIURL, cb, limiter, bulkhead)
+	if legacyClient, ok := client.(*external.LegacyLaborCostClient); ok && config.Cfg.LaborCost.BatchSize > 0 {
+		// Stopped once Consume returned, when no handler waits on it anymore
+		batchCtx, stopBatcher := context.WithCancel(context.Background())
+		defer stopBatcher()
+		batcher := external.NewLaborCostBatcher(legacyClient, config.Cfg.LaborCost.BatchSize, time.Duration(config.Cfg.LaborCost.BatchFlushMs)*time.Millisecond)
+		go batcher.Run(batchCtx)
+		client = batcher
+	}
+	handler := handlers.NewLaborCostReporter(client)
+	if config.Cfg.LaborCost.Parking && config.Cfg.LaborCost.Backend == config.LaborCostLegacy {
+		handler.WithParkingLot(parking)
+	}
+
+	config.Logger.Info("Labor cost worker started", zap.String("backend", config.Cfg.LaborCost.Backend))
+	return consumer.Consume(ctx, handlers.ProcessOnce(inbox, "labor-cost", handler.Handle))
+}
+
+// startLaborCostDrainer submits parked labor cost entries every interval,
+// batch after batch while whole batches go through
+func startLaborCostDrainer(ctx context.Context, drainer *handlers.LaborCostDrainer, repo repositories.ParkedLaborCostRepository, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			total := 0
+			for {
+				submitted, err := drainer.Drain(ctx)
+				total += submitted
+				if err != nil {
+					config.Logger.Warn("Failed to drain parked labor cost entries", zap.Error(err))
+				}
+				if err != nil || submitted < config.Cfg.LaborCost.DrainBatchSize {
+					break
+				}
+			}
+			if total > 0 {
+				config.Logger.Info("Submitted parked labor cost entries", zap.Int("count", total))
+			}
+			if pending, err := repo.CountParked(ctx); err == nil {
+				metrics.Gauge("labor_cost.parked_pending", float64(pending))
+			}
+		}
+	}
+}
+
+// newLaborCostClient returns the backend LABOR_COST_BACKEND selects; the
+// breaker, limiter and bulkhead only guard the legacy API
+func newLaborCostClient(legacyAPIURL string, cb *resilience.CircuitBreaker, limiter *external.RateLimiter, bulkhead *resilience.Bulkhead) handlers.LaborCostClient {
+	settings := config.Cfg.LaborCost
+	switch settings.Backend {
+	case config.LaborCostFile:
+		return external.NewFileLaborCostExporter(settings.ExportPath)
+	case config.LaborCostWebhook:
+		return external.NewWebhookLaborCostClient(settings.WebhookURL, settings.WebhookToken, time.Duration(settings.WebhookTimeoutMs)*time.Millisecond)
+	case config.LaborCostNone:
+		return external.NopLaborCostClient{}
+	}
 
-	config.Logger.Info("Labor cost worker started")
-	if err := consumer.Consume(ctx, handler.HandleCheckedOut); err != nil {
-		config.Logger.Error("Labor cost consumer error", zap.Error(err))
+	legacyClient := external.NewLegacyLaborCostClient(legacyAPIURL, cb)
+	if limiter != nil {
+		legacyClient.WithRateLimiter(limiter)
+	}
+	if bulkhead != nil {
+		legacyClient.WithBulkhead(bulkhead)
+	}
+	if auth := external.NewLegacyAuth(); auth != nil {
+		legacyClient.WithAuth(auth)
 	}
+	return legacyClient
 }
 
-func startEmailWorker(ctx context.Context, rabbitURL, smtpHost string) {
-	consumer, err := messaging.NewRabbitMQConsumer(rabbitURL, "checkout-events", "email-queue")
+func startEmailWorker(ctx context.Context, rabbitURL, smtpHost string, cb *resilience.CircuitBreaker, consents handlers.ConsentChecker, settings handlers.EmailSettingsProvider, ledger handlers.SentLedger, inbox handlers.Inbox) error {
+	consumer, err := openConsumer(rabbitURL, "checkout-events", "email-queue", func(consumer *messaging.RabbitMQConsumer) {
+		consumer.WithBatchAck(config.Cfg.RabbitMQ.EmailAckBatchSize, time.Duration(config.Cfg.RabbitMQ.EmailAckBatchMs)*time.Millisecond)
+		consumer.WithConcurrency(cmp.Or(config.Cfg.RabbitMQ.EmailWorkers, config.Cfg.RabbitMQ.Workers))
+	})
 	if err != nil {
-		log.Fatalf("Failed to create email consumer: %v", err)
+		return fmt.Errorf("failed to create email consumer: %w", err)
 	}
 	defer consumer.Close()
 
 	smtpPort := config.Cfg.SMTP.Port
-	emailClient := external.NewEmailClient(smtpHost, smtpPort)
-	handler := handlers.NewEmailNotifier(emailClient)
+	emailClient := external.NewEmailClient(smtpHost, smtpPort).WithCircuitBreaker(cb)
+	handler := handlers.NewEmailNotifier(emailClient, consents, settings, ledger)
 
 	config.Logger.Info("Email worker started")
-	if err := consumer.Consume(ctx, handler.HandleCheckedOut); err != nil {
-		config.Logger.Error("Email consumer error", zap.Error(err))
-	}
-}
-
-func initDatabase(db *sql.DB) error {
-	schema := `
-	CREATE TABLE IF NOT EXISTS time_records (
-		id VARCHAR(255) PRIMARY KEY,
-		employee_id VARCHAR(255) NOT NULL,
-		check_in_at TIMESTAMP NOT NULL,
-		check_out_at TIMESTAMP,
-		status VARCHAR(50) NOT NULL,
-		hours_worked DECIMAL(10, 2) DEFAULT 0,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_employee_status ON time_records(employee_id, status);
-
-	-- Outbox pattern table for guaranteed event delivery
-	CREATE TABLE IF NOT EXISTS outbox_events (
-		id VARCHAR(255) PRIMARY KEY,
-		event_type VARCHAR(100) NOT NULL,
-		aggregate_id VARCHAR(255) NOT NULL,
-		payload JSONB NOT NULL,
-		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-		published BOOLEAN DEFAULT FALSE,
-		published_at TIMESTAMP,
-		retry_count INT DEFAULT 0,
-		last_error TEXT
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_outbox_unpublished ON outbox_events(published, created_at) WHERE published = FALSE;
-	`
-
-	_, err := db.Exec(schema)
-	return err
+	return consumer.Consume(ctx, handlers.ProcessOnce(inbox, "email", handler.Handle))
+}
+
+func startReminderWorker(ctx context.Context, rabbitURL, smtpHost string, cb *resilience.CircuitBreaker, consents handlers.ConsentChecker, ledger handlers.SentLedger) error {
+	consumer, err := openConsumer(rabbitURL, "checkout-events", "reminder-queue", func(consumer *messaging.RabbitMQConsumer) {
+		consumer.WithConcurrency(config.Cfg.RabbitMQ.Workers)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create reminder consumer: %w", err)
+	}
+	defer consumer.Close()
+
+	emailClient := external.NewEmailClient(smtpHost, config.Cfg.SMTP.Port).WithCircuitBreaker(cb)
+	handler := handlers.NewReminderNotifier(emailClient, consents, ledger)
+
+	config.Logger.Info("Reminder worker started")
+	return consumer.Consume(ctx, handler.Handle)
+}
+
+// newCircuitBreaker creates a breaker tripping as CB_MODE says
+func newCircuitBreaker(name string) *resilience.CircuitBreaker {
+	settings := config.Cfg.CircuitBreaker
+	breaker := resilience.NewCircuitBreaker(name, settings.MaxFailures, 1, time.Duration(settings.ResetTimeoutS)*time.Second).
+		WithHalfOpenRequests(settings.HalfOpenRequests).
+		OnStateChange(logBreakerChange).
+		OnStateChange(recordBreakerChange)
+	metrics.Gauge("circuit_breaker."+name+".state", breakerLevel(resilience.StateClosed))
+	if settings.Mode == config.BreakerRate {
+		breaker.WithFailureRate(settings.FailureRate, time.Duration(settings.WindowSec)*time.Second, settings.MinRequests)
+	}
+	return breaker
+}
+
+// logBreakerChange logs the transitions of the circuit breakers
+func logBreakerChange(change resilience.StateChange) {
+	config.Logger.Warn("Circuit breaker state changed",
+		zap.String("breaker", change.Name), zap.String("from", string(change.From)), zap.String("to", string(change.To)),
+		zap.String("reason", change.Reason))
+}
+
+// recordBreakerChange counts the transitions of the circuit breakers by
+// target state and gauges their current state
+func recordBreakerChange(change resilience.StateChange) {
+	metrics.Incr("circuit_breaker."+change.Name+".transitions."+strings.ToLower(string(change.To)), 1)
+	metrics.Gauge("circuit_breaker."+change.Name+".state", breakerLevel(change.To))
+}
+
+// breakerLevel is the value of the state gauge: 0 closed, 1 half-open, 2
+// open, forced states included
+func breakerLevel(state resilience.CircuitState) float64 {
+	switch state {
+	case resilience.StateHalf:
+		return 1
+	case resilience.StateOpen, resilience.StateForcedOpen:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// closablePublisher is a publisher holding broker connections
+type closablePublisher interface {
+	eventPublisher
+	Close() error
+}
+
+// messageConsumer consumes a queue of the configured messaging backend
+type messageConsumer interface {
+	Consume(ctx context.Context, handler messaging.MessageHandler) error
+	Close() error
+}
+
+// bus is the in-process bus of MESSAGING_BACKEND=inprocess, nil otherwise
+var bus *messaging.InProcessBus
+
+// openConsumer connects the consumer of queue, bound to exchange. With Kafka
+// the exchange is a topic and the queue a consumer group; tune only applies
+// to RabbitMQ consumers.
+func openConsumer(rabbitURL, exchange, queue string, tune func(consumer *messaging.RabbitMQConsumer)) (messageConsumer, error) {
+	switch config.Cfg.Messaging.Backend {
+	case config.MessagingKafka:
+		return newKafkaConsumer(config.Cfg.Kafka.Brokers, exchange, config.Cfg.Kafka.GroupPrefix+"."+queue)
+	case config.MessagingInProcess:
+		return withQuarantine(bus.Consumer(exchange, queue))
+	}
+
+	consumer, err := messaging.NewRabbitMQConsumer(rabbitURL, exchange, queue)
+	if err != nil {
+		return nil, err
+	}
+	tune(consumer)
+	return withQuarantine(consumer)
+}
+
+// quarantinableConsumer is a consumer that can check messages before
+// handling them
+type quarantinableConsumer interface {
+	messageConsumer
+	WithQuarantine(queue string, validate messaging.Validator) error
+}
+
+// withQuarantine has the consumer quarantine events that do not match their
+// schema, when EVENT_SCHEMA_VALIDATION is on
+func withQuarantine(consumer quarantinableConsumer) (messageConsumer, error) {
+	if !config.Cfg.Messaging.ValidateSchemas {
+		return consumer, nil
+	}
+	if err := consumer.WithQuarantine(config.Cfg.Messaging.QuarantineQueue, events.ValidatePayload); err != nil {
+		consumer.Close()
+		return nil, err
+	}
+	return consumer, nil
+}
+
+func startParityWorker(ctx context.Context, rabbitURL string, checker *handlers.ParityChecker) error {
+	consumer, err := messaging.NewRabbitMQConsumer(rabbitURL, config.Cfg.ShadowEvents.Exchange, "shadow-parity-queue")
+	if err != nil {
+		return fmt.Errorf("failed to create parity consumer: %w", err)
+	}
+	defer consumer.Close()
+	consumer.WithConcurrency(config.Cfg.RabbitMQ.Workers)
+
+	config.Logger.Info("Shadow parity worker started")
+	return consumer.Consume(ctx, checker.Handle)
 }
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/events"
+	"github.com/leo-andrei/check-in-service/infrastructure/metrics"
+	"github.com/leo-andrei/check-in-service/infrastructure/persistence"
+)
+
+// gauges records the last value of every gauge
+type gauges struct {
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+func (g *gauges) Incr(string, int64)           {}
+func (g *gauges) Timing(string, time.Duration) {}
+func (g *gauges) Close() error                 { return nil }
+func (g *gauges) Gauge(name string, value float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.values[name] = value
+}
+
+func TestReportOutboxBacklogCountsAllUnpublished(t *testing.T) {
+	recorder := &gauges{values: make(map[string]float64)}
+	metrics.Default = recorder
+	defer func() { metrics.Default = metrics.Nop{} }()
+
+	ctx := context.Background()
+	outbox := persistence.NewMemoryOutboxRepository()
+	for _, employeeID := range []string{"emp-1", "emp-2", "emp-3"} {
+		event := events.EmployeeCheckedInEvent{
+			EventHeader: events.EventHeader{EventID: employeeID, EventType: events.EventTypeEmployeeCheckedIn, Version: 1, Timestamp: time.Now()},
+			EmployeeID:  employeeID,
+			RecordID:    "rec-" + employeeID,
+		}
+		if err := outbox.SaveEvent(ctx, event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// One poll claims a single event, which runs out of retries
+	claimed, err := outbox.ClaimUnpublished(ctx, "relay-1", 1, time.Minute)
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("claimed %d events, err %v; want 1", len(claimed), err)
+	}
+	if _, err := outbox.IncrementRetryCount(ctx, claimed[0].ID, "broker down", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	reportOutboxBacklog(ctx, outbox)
+
+	if got := recorder.values["outbox.pending"]; got != 3 {
+		t.Fatalf("outbox.pending = %v, want 3", got)
+	}
+	if got := recorder.values["outbox.backlog.failed"]; got != 1 {
+		t.Fatalf("outbox.backlog.failed = %v, want 1", got)
+	}
+}
@@ -0,0 +1,73 @@
+// Command dlq inspects, replays or purges the dead letter queue of a
+// consumer queue on RABBITMQ_URL.
+//
+//	dlq -limit 20 list email-queue     show the oldest dead letters
+//	dlq -limit 100 replay email-queue  move dead letters back to the queue
+//	dlq -yes purge email-queue         delete every dead letter
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"github.com/leo-andrei/check-in-service/infrastructure/messaging"
+)
+
+func main() {
+	limit := flag.Int("limit", 20, "number of dead letters to list or replay")
+	yes := flag.Bool("yes", false, "confirm purge, which cannot be undone")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: dlq [-limit N] [-yes] list|replay|purge QUEUE\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 || *limit < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	command, queue := flag.Arg(0), flag.Arg(1)
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	ctx := context.Background()
+	inspector := messaging.NewQueueInspector(cfg.RabbitMQ.URL, messaging.DefaultTopology(cfg.RabbitMQ.DLQTTL))
+
+	switch command {
+	case "list":
+		letters, err := inspector.PeekDLQ(ctx, queue, *limit)
+		if err != nil {
+			log.Fatalf("%s: %v", queue, err)
+		}
+		for _, letter := range letters {
+			fmt.Printf("%s %s reason=%s deaths=%d since=%s error=%q\n%s\n",
+				letter.Type, letter.MessageID, letter.Reason, letter.DeathCount, letter.DeadSince.Format(time.RFC3339), letter.Error, letter.Body)
+		}
+		log.Printf("%s: %d dead letters shown", queue, len(letters))
+	case "replay":
+		replayed, err := inspector.ReplayDLQ(ctx, queue, *limit)
+		log.Printf("%s: replayed %d dead letters", queue, replayed)
+		if err != nil {
+			log.Fatalf("%s: %v", queue, err)
+		}
+	case "purge":
+		if !*yes {
+			log.Fatalf("%s: purge deletes every dead letter for good; run again with -yes", queue)
+		}
+		purged, err := inspector.PurgeDLQ(ctx, queue)
+		if err != nil {
+			log.Fatalf("%s: %v", queue, err)
+		}
+		log.Printf("%s: purged %d dead letters", queue, purged)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,95 @@
+// Command migrate applies, reverts or lists the schema migrations on
+// DATABASE_URL and every database in DATABASE_SHARD_URLS.
+//
+//	migrate up            apply pending migrations
+//	migrate -steps 1 down revert the last migration
+//	migrate status        list migrations and when they were applied
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"github.com/leo-andrei/check-in-service/infrastructure/persistence"
+
+	_ "github.com/lib/pq"
+)
+
+func main() {
+	steps := flag.Int("steps", 1, "number of migrations to revert with down")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: migrate [-steps N] up|down|status\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	command := flag.Arg(0)
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	ctx := context.Background()
+	urls := append([]string{cfg.Database.URL}, cfg.Database.ShardURLs...)
+	for i, url := range urls {
+		name := "primary"
+		if i > 0 {
+			name = fmt.Sprintf("shard %d", i-1)
+		}
+		if err := run(ctx, url, command, *steps, name); err != nil {
+			log.Fatalf("%s: %v", name, err)
+		}
+	}
+}
+
+func run(ctx context.Context, url, command string, steps int, name string) error {
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer db.Close()
+
+	migrator, err := persistence.NewPostgresMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "up":
+		versions, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		log.Printf("%s: applied %d migrations %v", name, len(versions), versions)
+	case "down":
+		versions, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		log.Printf("%s: reverted %d migrations %v", name, len(versions), versions)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			if status.Applied {
+				log.Printf("%s: %s applied %s", name, status.Version, status.AppliedAt.Format("2006-01-02 15:04:05Z07:00"))
+			} else {
+				log.Printf("%s: %s pending", name, status.Version)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+	return nil
+}
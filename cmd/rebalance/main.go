@@ -0,0 +1,60 @@
+// Command rebalance moves employees' time records, outbox events and audit
+// entries to the shard that owns them after DATABASE_SHARD_URLS changed.
+// It only prints the plan unless -apply is given.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"github.com/leo-andrei/check-in-service/infrastructure/persistence"
+
+	_ "github.com/lib/pq"
+)
+
+func main() {
+	apply := flag.Bool("apply", false, "move the data instead of only printing the plan")
+	flag.Parse()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	if len(cfg.Database.ShardURLs) < 2 {
+		log.Fatalf("DATABASE_SHARD_URLS must list at least two shards")
+	}
+
+	shards, err := persistence.OpenShardSet(cfg.Database.ShardURLs)
+	if err != nil {
+		log.Fatalf("Failed to connect to shards: %v", err)
+	}
+	defer shards.Close()
+
+	ctx := context.Background()
+	rebalancer := persistence.NewShardRebalancer(shards)
+
+	moves, err := rebalancer.Plan(ctx)
+	if err != nil {
+		log.Fatalf("Failed to plan rebalance: %v", err)
+	}
+
+	log.Printf("%d employees to move across %d shards", len(moves), shards.Len())
+	for _, move := range moves {
+		log.Printf("employee %s: shard %d -> %d (%d records)", move.EmployeeID, move.From, move.To, move.Records)
+	}
+
+	if !*apply {
+		log.Printf("Dry run, re-run with -apply to move the data")
+		return
+	}
+
+	for _, move := range moves {
+		if err := rebalancer.Move(ctx, move); err != nil {
+			log.Fatalf("Failed to move employee %s: %v", move.EmployeeID, err)
+		}
+		log.Printf("Moved employee %s", move.EmployeeID)
+	}
+}
@@ -0,0 +1,204 @@
+// Package audit verifies the hash chains of audit entries and computes the
+// digests anchored outside the database.
+package audit
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+type ProblemKind string
+
+const (
+	// The stored hash does not match the entry content
+	ProblemHashMismatch ProblemKind = "HASH_MISMATCH"
+	// An entry written after chaining began carries no hash
+	ProblemMissingHash ProblemKind = "MISSING_HASH"
+	// The entry points at a previous hash that is not in the chain
+	ProblemBrokenLink ProblemKind = "BROKEN_LINK"
+	// Several entries claim the same predecessor
+	ProblemFork ProblemKind = "FORK"
+	// The chains no longer produce the digest of an anchor
+	ProblemAnchorMismatch ProblemKind = "ANCHOR_MISMATCH"
+)
+
+// Problem is a single sign of tampering
+type Problem struct {
+	Kind     ProblemKind `json:"kind"`
+	RecordID string      `json:"record_id,omitempty"`
+	EntryID  string      `json:"entry_id,omitempty"`
+	Detail   string      `json:"detail"`
+}
+
+// Report is the outcome of a verification
+type Report struct {
+	Records        int       `json:"records"`
+	Entries        int       `json:"entries"`
+	Unchained      int       `json:"unchained"` // Entries written before chaining was introduced
+	AnchorsChecked int       `json:"anchors_checked"`
+	Problems       []Problem `json:"problems"`
+
+	chains map[string][]*entities.AuditEntry
+}
+
+// Intact reports whether no tampering was found
+func (r *Report) Intact() bool {
+	return len(r.Problems) == 0
+}
+
+// Verify rebuilds every record's chain from its links and checks each hash
+// against key. Entries without a hash are only accepted from before the
+// first chained entry; a later one had its hash stripped or bypassed chaining.
+func Verify(entries []*entities.AuditEntry, key []byte) *Report {
+	report := &Report{chains: make(map[string][]*entities.AuditEntry)}
+
+	var chainedSince time.Time
+	byRecord := make(map[string][]*entities.AuditEntry)
+	for _, entry := range entries {
+		if entry.Hash == "" {
+			continue
+		}
+		if chainedSince.IsZero() || entry.CreatedAt.Before(chainedSince) {
+			chainedSince = entry.CreatedAt
+		}
+		byRecord[entry.RecordID] = append(byRecord[entry.RecordID], entry)
+	}
+
+	for _, entry := range entries {
+		if entry.Hash != "" {
+			continue
+		}
+		if chainedSince.IsZero() || !entry.CreatedAt.After(chainedSince) {
+			report.Unchained++
+			continue
+		}
+		report.Problems = append(report.Problems, Problem{
+			Kind:     ProblemMissingHash,
+			RecordID: entry.RecordID,
+			EntryID:  entry.ID,
+			Detail:   fmt.Sprintf("entry has no hash but was written after chaining began at %s", chainedSince.Format(time.RFC3339)),
+		})
+	}
+
+	for recordID, recordEntries := range byRecord {
+		report.Records++
+		report.Entries += len(recordEntries)
+		report.chains[recordID] = report.walk(recordID, recordEntries, key)
+	}
+
+	sort.Slice(report.Problems, func(i, j int) bool {
+		if report.Problems[i].RecordID != report.Problems[j].RecordID {
+			return report.Problems[i].RecordID < report.Problems[j].RecordID
+		}
+		return report.Problems[i].EntryID < report.Problems[j].EntryID
+	})
+	return report
+}
+
+// walk follows the links from the first entry and returns the chain in order
+func (r *Report) walk(recordID string, entries []*entities.AuditEntry, key []byte) []*entities.AuditEntry {
+	byPrev := make(map[string][]*entities.AuditEntry)
+	for _, entry := range entries {
+		byPrev[entry.PrevHash] = append(byPrev[entry.PrevHash], entry)
+		if !hmac.Equal([]byte(entry.ComputeHash(key)), []byte(entry.Hash)) {
+			r.Problems = append(r.Problems, Problem{
+				Kind:     ProblemHashMismatch,
+				RecordID: recordID,
+				EntryID:  entry.ID,
+				Detail:   "entry content does not match its hash",
+			})
+		}
+	}
+
+	var chain []*entities.AuditEntry
+	visited := make(map[string]bool)
+	for prev := ""; ; {
+		next := byPrev[prev]
+		if len(next) == 0 {
+			break
+		}
+		if len(next) > 1 {
+			r.Problems = append(r.Problems, Problem{
+				Kind:     ProblemFork,
+				RecordID: recordID,
+				EntryID:  next[1].ID,
+				Detail:   fmt.Sprintf("%d entries follow the same predecessor", len(next)),
+			})
+		}
+		entry := next[0]
+		if visited[entry.ID] {
+			break
+		}
+		visited[entry.ID] = true
+		chain = append(chain, entry)
+		prev = entry.Hash
+	}
+
+	for _, entry := range entries {
+		if !visited[entry.ID] && len(byPrev[entry.PrevHash]) == 1 {
+			r.Problems = append(r.Problems, Problem{
+				Kind:     ProblemBrokenLink,
+				RecordID: recordID,
+				EntryID:  entry.ID,
+				Detail:   "previous entry is missing or was altered",
+			})
+		}
+	}
+
+	return chain
+}
+
+// CheckAnchor compares the chains with an anchor taken earlier
+func (r *Report) CheckAnchor(anchor *entities.AuditAnchor) {
+	r.AnchorsChecked++
+
+	digest, records, entries := digest(r.chains, anchor.UpTo)
+	if digest != anchor.Digest {
+		r.Problems = append(r.Problems, Problem{
+			Kind: ProblemAnchorMismatch,
+			Detail: fmt.Sprintf("anchor %s (up to %s) covered %d entries in %d records, found %d entries in %d records",
+				anchor.ID, anchor.UpTo.Format(time.RFC3339), anchor.Entries, anchor.Records, entries, records),
+		})
+	}
+}
+
+// Anchor computes a new anchor over the verified chains, covering every entry
+// created at or before upTo
+func (r *Report) Anchor(upTo time.Time) *entities.AuditAnchor {
+	upTo = upTo.UTC().Truncate(time.Microsecond)
+	digest, records, entries := digest(r.chains, upTo)
+	return entities.NewAuditAnchor(digest, records, entries, upTo)
+}
+
+// digest hashes the head of every chain as of upTo, in record ID order
+func digest(chains map[string][]*entities.AuditEntry, upTo time.Time) (string, int, int) {
+	heads := make(map[string]string)
+	entries := 0
+	for recordID, chain := range chains {
+		for _, entry := range chain {
+			if entry.CreatedAt.After(upTo) {
+				break
+			}
+			heads[recordID] = entry.Hash
+			entries++
+		}
+	}
+
+	recordIDs := make([]string, 0, len(heads))
+	for recordID := range heads {
+		recordIDs = append(recordIDs, recordID)
+	}
+	sort.Strings(recordIDs)
+
+	h := sha256.New()
+	for _, recordID := range recordIDs {
+		fmt.Fprintf(h, "%s:%s\n", recordID, heads[recordID])
+	}
+	return hex.EncodeToString(h.Sum(nil)), len(recordIDs), entries
+}
@@ -0,0 +1,129 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+var testKey = []byte("test-audit-chain-key")
+
+// chain returns n chained entries of one record, a minute apart
+func chain(recordID string, n int, start time.Time) []*entities.AuditEntry {
+	var entries []*entities.AuditEntry
+	head := ""
+	for i := 0; i < n; i++ {
+		entry := entities.NewAuditEntry(recordID, "emp-1", entities.AuditActionCorrection, "mgr-1", "fix", nil, nil)
+		entry.CreatedAt = start.Add(time.Duration(i) * time.Minute)
+		entry.Chain(head, testKey)
+		head = entry.Hash
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// unchained is an entry of recordID without a hash, created at createdAt
+func unchained(recordID string, createdAt time.Time) *entities.AuditEntry {
+	entry := entities.NewAuditEntry(recordID, "emp-1", entities.AuditActionRepairClose, "system", "legacy", nil, nil)
+	entry.CreatedAt = createdAt
+	return entry
+}
+
+func TestVerify(t *testing.T) {
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		entries       func() []*entities.AuditEntry
+		key           []byte
+		wantProblems  []ProblemKind
+		wantUnchained int
+	}{
+		{
+			name:    "intact chain",
+			entries: func() []*entities.AuditEntry { return chain("rec-1", 3, start) },
+			key:     testKey,
+		},
+		{
+			name: "unchained entries before chaining began",
+			entries: func() []*entities.AuditEntry {
+				return append([]*entities.AuditEntry{unchained("rec-0", start.Add(-time.Hour))}, chain("rec-1", 2, start)...)
+			},
+			key:           testKey,
+			wantUnchained: 1,
+		},
+		{
+			name: "stripped hash after chaining began",
+			entries: func() []*entities.AuditEntry {
+				entries := chain("rec-1", 3, start)
+				entries[2].Hash = ""
+				return entries
+			},
+			key:          testKey,
+			wantProblems: []ProblemKind{ProblemMissingHash},
+		},
+		{
+			name: "unchained entry of another record after chaining began",
+			entries: func() []*entities.AuditEntry {
+				return append(chain("rec-1", 2, start), unchained("rec-2", start.Add(time.Hour)))
+			},
+			key:          testKey,
+			wantProblems: []ProblemKind{ProblemMissingHash},
+		},
+		{
+			name: "edited entry",
+			entries: func() []*entities.AuditEntry {
+				entries := chain("rec-1", 3, start)
+				entries[1].Reason = "edited"
+				return entries
+			},
+			key:          testKey,
+			wantProblems: []ProblemKind{ProblemHashMismatch},
+		},
+		{
+			name: "entry resealed without the key",
+			entries: func() []*entities.AuditEntry {
+				entries := chain("rec-1", 1, start)
+				entries[0].Reason = "edited"
+				entries[0].Chain("", nil)
+				return entries
+			},
+			key:          testKey,
+			wantProblems: []ProblemKind{ProblemHashMismatch},
+		},
+		{
+			name: "removed entry",
+			entries: func() []*entities.AuditEntry {
+				entries := chain("rec-1", 3, start)
+				return append(entries[:1], entries[2])
+			},
+			key:          testKey,
+			wantProblems: []ProblemKind{ProblemBrokenLink},
+		},
+		{
+			name:         "verified with another key",
+			entries:      func() []*entities.AuditEntry { return chain("rec-1", 1, start) },
+			key:          []byte("another-key"),
+			wantProblems: []ProblemKind{ProblemHashMismatch},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := Verify(tt.entries(), tt.key)
+
+			if len(report.Problems) != len(tt.wantProblems) {
+				t.Fatalf("problems = %+v, want %v", report.Problems, tt.wantProblems)
+			}
+			for i, problem := range report.Problems {
+				if problem.Kind != tt.wantProblems[i] {
+					t.Fatalf("problems = %+v, want %v", report.Problems, tt.wantProblems)
+				}
+			}
+			if report.Unchained != tt.wantUnchained {
+				t.Fatalf("unchained = %d, want %d", report.Unchained, tt.wantUnchained)
+			}
+		})
+	}
+}
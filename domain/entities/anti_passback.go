@@ -0,0 +1,54 @@
+package entities
+
+import "time"
+
+// Anti-passback modes
+const (
+	PassbackOff      = "off"
+	PassbackReject   = "reject"   // Refuse a badge at another site while checked in
+	PassbackTransfer = "transfer" // Check out at the old site and in at the new one
+)
+
+// PassbackDecision is the outcome of a badge under the anti-passback policy
+type PassbackDecision int
+
+const (
+	PassbackAllow    PassbackDecision = iota
+	PassbackMove                      // Close the open record and check in at the new site
+	PassbackOccupied                  // Rejected: still checked in at another site
+	PassbackTransit                   // Rejected: left another site too recently
+)
+
+// AntiPassbackPolicy keeps an employee from being on the books at two sites
+// at once. Badges without a location, and records without one, are never
+// compared.
+type AntiPassbackPolicy struct {
+	Mode string
+	// Minimum time between checking out at one site and checking in at
+	// another; zero disables the rule
+	MinTransit time.Duration
+}
+
+// Evaluate decides a badge at locationID at time at, given the employee's
+// open record and their most recently closed one (either may be nil)
+func (p AntiPassbackPolicy) Evaluate(active, last *TimeRecord, locationID string, at time.Time) PassbackDecision {
+	if p.Mode == PassbackOff || p.Mode == "" || locationID == "" {
+		return PassbackAllow
+	}
+
+	if active != nil {
+		if active.LocationID == "" || active.LocationID == locationID {
+			return PassbackAllow
+		}
+		if p.Mode == PassbackTransfer {
+			return PassbackMove
+		}
+		return PassbackOccupied
+	}
+
+	if p.MinTransit > 0 && last != nil && last.CheckOutAt != nil &&
+		last.LocationID != "" && last.LocationID != locationID && at.Sub(*last.CheckOutAt) < p.MinTransit {
+		return PassbackTransit
+	}
+	return PassbackAllow
+}
@@ -0,0 +1,99 @@
+package entities
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ApprovalKind string
+
+const (
+	ApprovalCorrection  ApprovalKind = "CORRECTION"   // Change the times of an existing record
+	ApprovalManualEntry ApprovalKind = "MANUAL_ENTRY" // Add a backdated record
+)
+
+func (k ApprovalKind) IsValid() bool {
+	return k == ApprovalCorrection || k == ApprovalManualEntry
+}
+
+type ApprovalStatus string
+
+const (
+	ApprovalPending  ApprovalStatus = "PENDING"
+	ApprovalApproved ApprovalStatus = "APPROVED"
+	ApprovalRejected ApprovalStatus = "REJECTED"
+)
+
+// Approval is a correction or manual entry waiting for the employee's
+// manager. Nothing changes on the time records until it is approved.
+type Approval struct {
+	ID                 string
+	EmployeeID         string
+	ManagerID          string // From the roster at submission time, empty when unknown
+	Kind               ApprovalKind
+	RecordID           string // Record to correct, empty for manual entries
+	ProposedCheckInAt  time.Time
+	ProposedCheckOutAt time.Time
+	TimeZone           string
+	Note               string // Employee's explanation
+	Status             ApprovalStatus
+	DecidedBy          string
+	DecisionComment    string
+	DecidedAt          *time.Time
+	CreatedAt          time.Time
+}
+
+func NewApproval(employeeID, managerID string, kind ApprovalKind, recordID string, checkInAt, checkOutAt time.Time, timeZone, note string) (*Approval, error) {
+	if employeeID == "" {
+		return nil, errors.New("employee ID cannot be empty")
+	}
+	if !kind.IsValid() {
+		return nil, errors.New("invalid approval kind")
+	}
+	if kind == ApprovalCorrection && recordID == "" {
+		return nil, errors.New("a correction needs the record to correct")
+	}
+	if !checkOutAt.After(checkInAt) {
+		return nil, errors.New("check-out must be after check-in")
+	}
+	if checkOutAt.After(time.Now()) {
+		return nil, errors.New("entries cannot end in the future")
+	}
+
+	return &Approval{
+		ID:                 uuid.New().String(),
+		EmployeeID:         employeeID,
+		ManagerID:          managerID,
+		Kind:               kind,
+		RecordID:           recordID,
+		ProposedCheckInAt:  checkInAt.UTC(),
+		ProposedCheckOutAt: checkOutAt.UTC(),
+		TimeZone:           timeZone,
+		Note:               note,
+		Status:             ApprovalPending,
+		CreatedAt:          time.Now().UTC(),
+	}, nil
+}
+
+func (a *Approval) IsPending() bool {
+	return a.Status == ApprovalPending
+}
+
+// Decide approves or rejects the request; decisions are final
+func (a *Approval) Decide(approve bool, decidedBy, comment string) error {
+	if !a.IsPending() {
+		return errors.New("approval already decided")
+	}
+
+	now := time.Now().UTC()
+	a.Status = ApprovalRejected
+	if approve {
+		a.Status = ApprovalApproved
+	}
+	a.DecidedBy = decidedBy
+	a.DecisionComment = comment
+	a.DecidedAt = &now
+	return nil
+}
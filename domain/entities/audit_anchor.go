@@ -0,0 +1,31 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditAnchor pins the state of every audit chain at a point in time. Anchors
+// are exported outside the database so a rewrite of the audit log (including
+// dropping the newest entries, which a hash chain alone cannot reveal) shows up
+// when the chains are verified against them.
+type AuditAnchor struct {
+	ID        string    `json:"id"`
+	Digest    string    `json:"digest"`  // Hash over the head of every record's chain
+	Records   int       `json:"records"` // Chains covered
+	Entries   int       `json:"entries"` // Chained entries covered
+	UpTo      time.Time `json:"up_to"`   // Entries created at or before this time are covered
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewAuditAnchor(digest string, records, entries int, upTo time.Time) *AuditAnchor {
+	return &AuditAnchor{
+		ID:        uuid.New().String(),
+		Digest:    digest,
+		Records:   records,
+		Entries:   entries,
+		UpTo:      upTo.UTC().Truncate(time.Microsecond),
+		CreatedAt: time.Now().UTC(),
+	}
+}
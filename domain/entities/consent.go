@@ -0,0 +1,82 @@
+package entities
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ConsentPurpose string
+
+const (
+	PurposeNotifications ConsentPurpose = "notifications" // Check-out summaries and reminders
+	PurposeAnalytics     ConsentPurpose = "analytics"     // Aggregated reporting beyond payroll
+)
+
+// IsValid reports whether the purpose is one the service knows how to enforce
+func (p ConsentPurpose) IsValid() bool {
+	switch p {
+	case PurposeNotifications, PurposeAnalytics:
+		return true
+	}
+	return false
+}
+
+// Consent is the current consent state of an employee for a single purpose
+type Consent struct {
+	ID          string
+	EmployeeID  string
+	Purpose     ConsentPurpose
+	Source      string // Where the consent was captured (kiosk, hr-portal, api, ...)
+	GrantedAt   time.Time
+	WithdrawnAt *time.Time
+	UpdatedAt   time.Time
+}
+
+func NewConsent(employeeID string, purpose ConsentPurpose, source string) (*Consent, error) {
+	if employeeID == "" {
+		return nil, errors.New("employee ID cannot be empty")
+	}
+	if !purpose.IsValid() {
+		return nil, errors.New("unknown consent purpose")
+	}
+	if source == "" {
+		return nil, errors.New("consent source cannot be empty")
+	}
+
+	now := time.Now()
+	return &Consent{
+		ID:         uuid.New().String(),
+		EmployeeID: employeeID,
+		Purpose:    purpose,
+		Source:     source,
+		GrantedAt:  now,
+		UpdatedAt:  now,
+	}, nil
+}
+
+// Grant (re-)grants a previously withdrawn consent
+func (c *Consent) Grant(source string) {
+	now := time.Now()
+	c.Source = source
+	c.GrantedAt = now
+	c.WithdrawnAt = nil
+	c.UpdatedAt = now
+}
+
+func (c *Consent) Withdraw(source string) error {
+	if c.WithdrawnAt != nil {
+		return errors.New("consent already withdrawn")
+	}
+
+	now := time.Now()
+	c.Source = source
+	c.WithdrawnAt = &now
+	c.UpdatedAt = now
+	return nil
+}
+
+func (c *Consent) IsActive() bool {
+	return c.WithdrawnAt == nil
+}
@@ -0,0 +1,102 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/hours"
+)
+
+// BusinessDateLayout is the ISO 8601 calendar date a record is reported under
+const BusinessDateLayout = "2006-01-02"
+
+// DaySegment is the part of a shift that falls on one local calendar day
+type DaySegment struct {
+	BusinessDate string    `json:"business_date"`
+	StartAt      time.Time `json:"start_at"`
+	EndAt        time.Time `json:"end_at"`
+	Hours        float64   `json:"hours"` // Share of the record's HoursWorked
+}
+
+// AssignBusinessDate sets the business date from the check-in in the record's
+// time zone
+func (tr *TimeRecord) AssignBusinessDate() {
+	tr.BusinessDate = tr.Local(tr.CheckInAt).Format(BusinessDateLayout)
+}
+
+// SplitByDay tags the record with its business date (the local date of the
+// check-in) and, when split is set and the record is closed, cuts it at local
+// midnight into per-day segments. HoursWorked is shared out in proportion to
+// the time in each segment, so breaks and rounding stay with the record.
+func (tr *TimeRecord) SplitByDay(split bool) {
+	tr.AssignBusinessDate()
+	tr.Segments = nil
+
+	if !split || tr.CheckOutAt == nil {
+		return
+	}
+
+	if segments := tr.localDays(); len(segments) > 1 {
+		tr.Segments = segments
+	}
+}
+
+// localDays cuts a closed record at local midnight, sharing HoursWorked out in
+// proportion to the time in each day
+func (tr *TimeRecord) localDays() []DaySegment {
+	checkIn := tr.Local(tr.CheckInAt)
+	checkOut := tr.Local(*tr.CheckOutAt)
+	total := checkOut.Sub(checkIn)
+	if total <= 0 {
+		return []DaySegment{{
+			BusinessDate: checkIn.Format(BusinessDateLayout),
+			StartAt:      checkIn.UTC(),
+			EndAt:        checkOut.UTC(),
+			Hours:        tr.HoursWorked,
+		}}
+	}
+
+	var segments []DaySegment
+	remaining := tr.HoursWorked
+	for start := checkIn; start.Before(checkOut); {
+		end := hours.StartOfDay(start).AddDate(0, 0, 1)
+		if end.After(checkOut) {
+			end = checkOut
+		}
+
+		share := tr.HoursWorked * float64(end.Sub(start)) / float64(total)
+		if !end.Before(checkOut) {
+			share = remaining // Last segment absorbs rounding drift
+		}
+		remaining -= share
+
+		segments = append(segments, DaySegment{
+			BusinessDate: start.Format(BusinessDateLayout),
+			StartAt:      start.UTC(),
+			EndAt:        end.UTC(),
+			Hours:        share,
+		})
+		start = end
+	}
+	return segments
+}
+
+// DayShares returns the record's hours per business day: its segments when it
+// was split, otherwise a single share on the check-in day
+func (tr *TimeRecord) DayShares() []DaySegment {
+	if len(tr.Segments) > 0 {
+		return tr.Segments
+	}
+
+	share := DaySegment{
+		BusinessDate: tr.BusinessDate,
+		StartAt:      tr.CheckInAt,
+		Hours:        tr.HoursWorked,
+	}
+	if share.BusinessDate == "" {
+		share.BusinessDate = tr.Local(tr.CheckInAt).Format(BusinessDateLayout)
+	}
+	if tr.CheckOutAt != nil {
+		share.EndAt = *tr.CheckOutAt
+	}
+	return []DaySegment{share}
+}
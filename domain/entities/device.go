@@ -0,0 +1,172 @@
+package entities
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DeviceStatus string
+
+const (
+	DevicePending DeviceStatus = "PENDING" // Waiting for the kiosk to enroll
+	DeviceActive  DeviceStatus = "ACTIVE"
+	DeviceRevoked DeviceStatus = "REVOKED"
+)
+
+// Device is a check-in kiosk. An admin creates it and hands the one-time
+// enrollment code to whoever installs the kiosk; the kiosk trades the code for
+// a secret it sends with every check-in. Only hashes of the code and secrets
+// are kept.
+type Device struct {
+	ID         string
+	Name       string
+	LocationID string // Default location for check-ins from this kiosk, empty for none
+	Status     DeviceStatus
+
+	EnrollmentCodeHash  string
+	EnrollmentExpiresAt *time.Time
+
+	SecretHash      string
+	SecretExpiresAt *time.Time
+	// The secret replaced by the last rotation stays valid until
+	// PreviousExpiresAt, so a kiosk that missed the rotation response can retry
+	PreviousSecretHash string
+	PreviousExpiresAt  *time.Time
+
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+// NewDevice creates a pending device and returns its enrollment code
+func NewDevice(name, locationID string, enrollmentTTL time.Duration) (*Device, string, error) {
+	if name == "" {
+		return nil, "", errors.New("device name cannot be empty")
+	}
+
+	device := &Device{
+		ID:         uuid.New().String(),
+		Name:       name,
+		LocationID: locationID,
+		CreatedAt:  time.Now().UTC(),
+	}
+	code := device.ResetEnrollment(enrollmentTTL)
+	return device, code, nil
+}
+
+// ResetEnrollment issues a new enrollment code and drops any credentials, e.g.
+// to replace a kiosk's hardware. Revoked devices stay revoked.
+func (d *Device) ResetEnrollment(ttl time.Duration) string {
+	code := newDeviceToken(8)
+	expiresAt := time.Now().UTC().Add(ttl)
+
+	if d.Status != DeviceRevoked {
+		d.Status = DevicePending
+	}
+	d.EnrollmentCodeHash = hashDeviceToken(code)
+	d.EnrollmentExpiresAt = &expiresAt
+	d.SecretHash = ""
+	d.SecretExpiresAt = nil
+	d.PreviousSecretHash = ""
+	d.PreviousExpiresAt = nil
+	return code
+}
+
+// Enroll consumes the enrollment code and issues the first secret
+func (d *Device) Enroll(code string, lifetime time.Duration) (string, error) {
+	if d.Status != DevicePending || d.EnrollmentCodeHash == "" {
+		return "", errors.New("device is not waiting for enrollment")
+	}
+	if d.EnrollmentExpiresAt == nil || time.Now().After(*d.EnrollmentExpiresAt) {
+		return "", errors.New("enrollment code expired")
+	}
+	if !tokenMatches(code, d.EnrollmentCodeHash) {
+		return "", errors.New("wrong enrollment code")
+	}
+
+	d.Status = DeviceActive
+	d.EnrollmentCodeHash = ""
+	d.EnrollmentExpiresAt = nil
+	return d.issueSecret(lifetime, 0), nil
+}
+
+// Rotate replaces the secret. The old one keeps working for grace.
+func (d *Device) Rotate(lifetime, grace time.Duration) (string, error) {
+	if d.Status != DeviceActive {
+		return "", errors.New("only active devices can rotate credentials")
+	}
+	return d.issueSecret(lifetime, grace), nil
+}
+
+func (d *Device) issueSecret(lifetime, grace time.Duration) string {
+	now := time.Now().UTC()
+
+	d.PreviousSecretHash = ""
+	d.PreviousExpiresAt = nil
+	if d.SecretHash != "" && grace > 0 {
+		previousExpiresAt := now.Add(grace)
+		if d.SecretExpiresAt != nil && d.SecretExpiresAt.Before(previousExpiresAt) {
+			previousExpiresAt = *d.SecretExpiresAt
+		}
+		d.PreviousSecretHash = d.SecretHash
+		d.PreviousExpiresAt = &previousExpiresAt
+	}
+
+	secret := newDeviceToken(32)
+	expiresAt := now.Add(lifetime)
+	d.SecretHash = hashDeviceToken(secret)
+	d.SecretExpiresAt = &expiresAt
+	return secret
+}
+
+// Authenticate reports whether the secret is a current credential of the device
+func (d *Device) Authenticate(secret string) bool {
+	if d.Status != DeviceActive || secret == "" {
+		return false
+	}
+
+	now := time.Now()
+	if d.SecretExpiresAt != nil && now.Before(*d.SecretExpiresAt) && tokenMatches(secret, d.SecretHash) {
+		return true
+	}
+	return d.PreviousExpiresAt != nil && now.Before(*d.PreviousExpiresAt) && tokenMatches(secret, d.PreviousSecretHash)
+}
+
+// Revoke permanently blocks the device, e.g. when a kiosk was stolen
+func (d *Device) Revoke() {
+	now := time.Now().UTC()
+	d.Status = DeviceRevoked
+	d.RevokedAt = &now
+	d.EnrollmentCodeHash = ""
+	d.EnrollmentExpiresAt = nil
+	d.SecretHash = ""
+	d.SecretExpiresAt = nil
+	d.PreviousSecretHash = ""
+	d.PreviousExpiresAt = nil
+}
+
+func newDeviceToken(size int) string {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		panic(err) // crypto/rand never fails on supported platforms
+	}
+	return hex.EncodeToString(b)
+}
+
+// Codes and secrets are random, so a plain SHA-256 is enough to store them
+func hashDeviceToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func tokenMatches(token, hash string) bool {
+	if hash == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(hashDeviceToken(token)), []byte(hash)) == 1
+}
@@ -0,0 +1,75 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EmailCommand is an instruction an employee sent by replying to an email
+type EmailCommand struct {
+	Line       string    // The command line as written
+	CheckOutAt time.Time // When the employee says they stopped working
+}
+
+// Replies look like "STOP", "CHECKOUT" or "CHECKOUT 17:30" ("CHECKOUT AT
+// 5:30pm" also works); the keyword is case-insensitive
+var emailCommandPattern = regexp.MustCompile(`(?i)^(stop|check-?out)(?:\s+(?:at\s+)?(\d{1,2}):(\d{2})\s*(am|pm)?)?[.!]?$`)
+
+// ParseEmailCommand reads the command from the first line of a reply that is
+// neither blank nor quoted. Without a time the employee stopped when they
+// replied (receivedAt); a time of day is taken in loc on the latest day that
+// does not put it after receivedAt.
+func ParseEmailCommand(body string, receivedAt time.Time, loc *time.Location) (*EmailCommand, error) {
+	line := firstReplyLine(body)
+	if line == "" {
+		return nil, errors.New("the reply is empty")
+	}
+
+	match := emailCommandPattern.FindStringSubmatch(line)
+	if match == nil {
+		return nil, fmt.Errorf("unknown command %q", line)
+	}
+
+	command := &EmailCommand{Line: line, CheckOutAt: receivedAt.UTC()}
+	if match[2] == "" {
+		return command, nil
+	}
+
+	hour, _ := strconv.Atoi(match[2])
+	minute, _ := strconv.Atoi(match[3])
+	if suffix := strings.ToLower(match[4]); suffix != "" {
+		if hour < 1 || hour > 12 {
+			return nil, fmt.Errorf("invalid time in %q", line)
+		}
+		hour %= 12
+		if suffix == "pm" {
+			hour += 12
+		}
+	}
+	if hour > 23 || minute > 59 {
+		return nil, fmt.Errorf("invalid time in %q", line)
+	}
+
+	local := receivedAt.In(loc)
+	at := time.Date(local.Year(), local.Month(), local.Day(), hour, minute, 0, 0, loc)
+	if at.After(receivedAt) {
+		at = at.AddDate(0, 0, -1)
+	}
+	command.CheckOutAt = at.UTC()
+	return command, nil
+}
+
+func firstReplyLine(body string) string {
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, ">") {
+			continue
+		}
+		return line
+	}
+	return ""
+}
@@ -0,0 +1,46 @@
+package entities
+
+import (
+	"errors"
+	"net/url"
+	"time"
+)
+
+// CheckOutEmailSettings customize the summary email employees get at
+// check-out. Each deployment serves one tenant, so there is a single set.
+type CheckOutEmailSettings struct {
+	LogoURL      string // https URL of the logo shown above the summary, empty for none
+	FooterText   string // Replaces the default sign-off when set
+	ShowHours    bool
+	ShowCost     bool // Labor cost, when the employee has an hourly rate
+	ShowOvertime bool // Regular/overtime split
+	UpdatedBy    string
+	UpdatedAt    time.Time
+}
+
+// DefaultCheckOutEmailSettings is the summary sent until an admin customizes it
+func DefaultCheckOutEmailSettings() *CheckOutEmailSettings {
+	return &CheckOutEmailSettings{ShowHours: true}
+}
+
+// Update replaces the settings; the logo must be an absolute https URL
+func (s *CheckOutEmailSettings) Update(logoURL, footerText string, showHours, showCost, showOvertime bool, actor string) error {
+	if logoURL != "" {
+		parsed, err := url.Parse(logoURL)
+		if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+			return errors.New("logo URL must be an absolute https URL")
+		}
+	}
+	if len(footerText) > 2000 {
+		return errors.New("footer text cannot be longer than 2000 characters")
+	}
+
+	s.LogoURL = logoURL
+	s.FooterText = footerText
+	s.ShowHours = showHours
+	s.ShowCost = showCost
+	s.ShowOvertime = showOvertime
+	s.UpdatedBy = actor
+	s.UpdatedAt = time.Now().UTC()
+	return nil
+}
@@ -0,0 +1,63 @@
+package entities
+
+import (
+	"errors"
+	"time"
+)
+
+// Employee is an entry of the company roster. Only active employees can check in.
+type Employee struct {
+	ID         string
+	Name       string
+	Email      string
+	ManagerID  string // Employee ID of the manager, empty for none
+	HourlyRate float64
+	Currency   string // ISO 4217 code of HourlyRate, empty for the default currency
+	Active     bool
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func NewEmployee(id, name, email string) (*Employee, error) {
+	if id == "" {
+		return nil, errors.New("employee ID cannot be empty")
+	}
+	if name == "" {
+		return nil, errors.New("employee name cannot be empty")
+	}
+
+	now := time.Now().UTC()
+	return &Employee{
+		ID:        id,
+		Name:      name,
+		Email:     email,
+		Active:    true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, nil
+}
+
+// Update changes the roster details of the employee
+func (e *Employee) Update(name, email, managerID string, hourlyRate float64, currency string, active bool) error {
+	if name == "" {
+		return errors.New("employee name cannot be empty")
+	}
+	if managerID == e.ID {
+		return errors.New("an employee cannot be their own manager")
+	}
+	if hourlyRate < 0 {
+		return errors.New("hourly rate cannot be negative")
+	}
+	if currency != "" && !ValidCurrency(currency) {
+		return errors.New("currency must be an ISO 4217 code")
+	}
+
+	e.Name = name
+	e.Email = email
+	e.ManagerID = managerID
+	e.HourlyRate = hourlyRate
+	e.Currency = currency
+	e.Active = active
+	e.UpdatedAt = time.Now().UTC()
+	return nil
+}
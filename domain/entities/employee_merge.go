@@ -0,0 +1,33 @@
+package entities
+
+import (
+	"errors"
+	"time"
+)
+
+// EmployeeMerge folds a duplicate employee ID into the canonical one, e.g.
+// after HR re-issued an ID to the same person
+type EmployeeMerge struct {
+	SourceID string // Duplicate ID, kept as an alias afterwards
+	TargetID string // Canonical ID that receives the records
+	Actor    string
+	Reason   string
+	MergedAt time.Time
+}
+
+func NewEmployeeMerge(sourceID, targetID, actor, reason string) (*EmployeeMerge, error) {
+	if sourceID == "" || targetID == "" {
+		return nil, errors.New("employee IDs cannot be empty")
+	}
+	if sourceID == targetID {
+		return nil, errors.New("cannot merge an employee into itself")
+	}
+
+	return &EmployeeMerge{
+		SourceID: sourceID,
+		TargetID: targetID,
+		Actor:    actor,
+		Reason:   reason,
+		MergedAt: time.Now().UTC(),
+	}, nil
+}
@@ -0,0 +1,52 @@
+package entities
+
+import (
+	"errors"
+	"time"
+)
+
+// Holiday is a day on the company calendar whose hours are paid at a premium
+type Holiday struct {
+	Date      string // ISO 8601 calendar date (YYYY-MM-DD), in each record's local time
+	Name      string
+	CreatedAt time.Time
+}
+
+func NewHoliday(date, name string) (*Holiday, error) {
+	if _, err := time.Parse(BusinessDateLayout, date); err != nil {
+		return nil, errors.New("holiday date must be YYYY-MM-DD")
+	}
+	if name == "" {
+		return nil, errors.New("holiday name cannot be empty")
+	}
+
+	return &Holiday{
+		Date:      date,
+		Name:      name,
+		CreatedAt: time.Now().UTC(),
+	}, nil
+}
+
+// ClassifyPremiumHours splits the worked hours of a closed record into
+// holiday and weekend hours, per local calendar day. A holiday falling on a
+// weekend counts as holiday only.
+func (tr *TimeRecord) ClassifyPremiumHours(holidays map[string]bool, weekend map[time.Weekday]bool) {
+	tr.HolidayHours = 0
+	tr.WeekendHours = 0
+	if tr.CheckOutAt == nil {
+		return
+	}
+
+	for _, day := range tr.localDays() {
+		date, err := time.Parse(BusinessDateLayout, day.BusinessDate)
+		if err != nil {
+			continue
+		}
+		switch {
+		case holidays[day.BusinessDate]:
+			tr.HolidayHours += day.Hours
+		case weekend[date.Weekday()]:
+			tr.WeekendHours += day.Hours
+		}
+	}
+}
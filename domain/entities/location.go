@@ -0,0 +1,37 @@
+package entities
+
+import (
+	"errors"
+	"time"
+)
+
+// Location is a site where employees badge in (office, warehouse, store, ...)
+type Location struct {
+	ID        string
+	Name      string
+	Address   string
+	Active    bool
+	TimeZone  string // IANA time zone, used for check-ins at this location
+	CreatedAt time.Time
+}
+
+func NewLocation(id, name, address, timeZone string) (*Location, error) {
+	if id == "" {
+		return nil, errors.New("location ID cannot be empty")
+	}
+	if name == "" {
+		return nil, errors.New("location name cannot be empty")
+	}
+	if _, err := LoadTimeZone(timeZone); err != nil {
+		return nil, err
+	}
+
+	return &Location{
+		ID:        id,
+		Name:      name,
+		Address:   address,
+		Active:    true,
+		TimeZone:  timeZone,
+		CreatedAt: time.Now().UTC(),
+	}, nil
+}
@@ -0,0 +1,120 @@
+package entities
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+)
+
+var currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Currencies whose minor unit is not a hundredth (ISO 4217); the rest use two
+// decimals
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// ValidCurrency reports whether code looks like an ISO 4217 currency code
+func ValidCurrency(code string) bool {
+	return currencyCodePattern.MatchString(code)
+}
+
+// CurrencyExponent is the number of decimals of the currency's minor unit
+func CurrencyExponent(code string) int {
+	if exp, ok := currencyExponents[code]; ok {
+		return exp
+	}
+	return 2
+}
+
+// Money is an amount in the minor unit of its currency (e.g. cents), so sums
+// do not drift
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// MoneyOf rounds a decimal amount to the currency's minor unit, half away
+// from zero
+func MoneyOf(amount float64, currency string) Money {
+	scale := math.Pow10(CurrencyExponent(currency))
+	return Money{Amount: int64(math.Round(amount * scale)), Currency: currency}
+}
+
+// ParseMoney reads a decimal amount in major units as written by String
+func ParseMoney(amount, currency string) (Money, error) {
+	value, err := strconv.ParseFloat(amount, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid amount %q: %w", amount, err)
+	}
+	return MoneyOf(value, currency), nil
+}
+
+func (m Money) Add(other Money) Money {
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}
+}
+
+func (m Money) Sub(other Money) Money {
+	return Money{Amount: m.Amount - other.Amount, Currency: m.Currency}
+}
+
+// String renders the amount as a decimal in major units, e.g. "123.45"
+func (m Money) String() string {
+	exp := CurrencyExponent(m.Currency)
+	if exp == 0 {
+		return fmt.Sprintf("%d", m.Amount)
+	}
+
+	sign, amount := "", m.Amount
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	scale := int64(math.Pow10(exp))
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/scale, exp, amount%scale)
+}
+
+// LaborCost is the money value of a record's hours at the employee's hourly
+// rate when it was checked out
+type LaborCost struct {
+	HourlyRate float64
+	Regular    Money
+	Overtime   Money
+}
+
+func (c *LaborCost) Currency() string {
+	return c.Regular.Currency
+}
+
+func (c *LaborCost) Total() Money {
+	return c.Regular.Add(c.Overtime)
+}
+
+// ApplyLaborCost prices the record's hours at rate × hours. The overtime part
+// is rounded on its own and the regular part takes the remainder, so the two
+// always add up to the rounded total. A zero rate clears the cost.
+func (tr *TimeRecord) ApplyLaborCost(rate float64, currency string) {
+	if rate <= 0 || !tr.IsCompleted() {
+		tr.LaborCost = nil
+		return
+	}
+
+	total := MoneyOf(rate*tr.HoursWorked, currency)
+	overtime := MoneyOf(rate*tr.OvertimeHours, currency)
+	tr.LaborCost = &LaborCost{
+		HourlyRate: rate,
+		Regular:    total.Sub(overtime),
+		Overtime:   overtime,
+	}
+}
+
+// RepriceLaborCost recomputes the cost after the hours changed, at the rate
+// the record was priced with
+func (tr *TimeRecord) RepriceLaborCost() {
+	if tr.LaborCost == nil {
+		return
+	}
+	tr.ApplyLaborCost(tr.LaborCost.HourlyRate, tr.LaborCost.Currency())
+}
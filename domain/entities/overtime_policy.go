@@ -0,0 +1,55 @@
+package entities
+
+import "math"
+
+// OvertimePolicy splits worked hours into regular and overtime hours based on
+// daily and weekly thresholds. A zero threshold disables that rule.
+type OvertimePolicy struct {
+	DailyThresholdHours  float64
+	WeeklyThresholdHours float64
+}
+
+// OvertimeResult describes why and by how much a record exceeded the thresholds
+type OvertimeResult struct {
+	DailyOvertime  float64
+	WeeklyOvertime float64
+	DailyTotal     float64
+	WeeklyTotal    float64
+}
+
+// Apply computes the regular/overtime split of a checked-out record, given the
+// hours already worked earlier that day and week. Daily and weekly overtime
+// are not cumulative: the larger of the two is what counts as overtime.
+func (p OvertimePolicy) Apply(tr *TimeRecord, workedToday, workedThisWeek float64) OvertimeResult {
+	result := OvertimeResult{
+		DailyTotal:  workedToday + tr.HoursWorked,
+		WeeklyTotal: workedThisWeek + tr.HoursWorked,
+	}
+
+	if p.DailyThresholdHours > 0 {
+		result.DailyOvertime = clampHours(result.DailyTotal-p.DailyThresholdHours, tr.HoursWorked)
+	}
+	if p.WeeklyThresholdHours > 0 {
+		result.WeeklyOvertime = clampHours(result.WeeklyTotal-p.WeeklyThresholdHours, tr.HoursWorked)
+	}
+
+	tr.OvertimeHours = math.Max(result.DailyOvertime, result.WeeklyOvertime)
+	tr.RegularHours = tr.HoursWorked - tr.OvertimeHours
+
+	return result
+}
+
+// HasOvertime reports whether the record has any overtime hours
+func (tr *TimeRecord) HasOvertime() bool {
+	return tr.OvertimeHours > 0
+}
+
+func clampHours(hours, max float64) float64 {
+	if hours < 0 {
+		return 0
+	}
+	if hours > max {
+		return max
+	}
+	return hours
+}
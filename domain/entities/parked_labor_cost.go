@@ -0,0 +1,18 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ParkedLaborCost is a labor cost entry set aside while the payroll system
+// was unavailable, to be submitted once it is back
+type ParkedLaborCost struct {
+	ID             int64
+	IdempotencyKey string          // Empty for entries without one
+	Entry          json.RawMessage // The entry as sent to the labor cost backend
+	Reason         string          // Why it was parked, e.g. the open circuit
+	Attempts       int             // Failed submissions since it was parked
+	LastError      string
+	ParkedAt       time.Time
+}
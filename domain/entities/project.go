@@ -0,0 +1,30 @@
+package entities
+
+import (
+	"errors"
+	"time"
+)
+
+// Project is a job or cost code that worked hours can be attributed to
+type Project struct {
+	Code      string
+	Name      string
+	Active    bool
+	CreatedAt time.Time
+}
+
+func NewProject(code, name string) (*Project, error) {
+	if code == "" {
+		return nil, errors.New("project code cannot be empty")
+	}
+	if name == "" {
+		return nil, errors.New("project name cannot be empty")
+	}
+
+	return &Project{
+		Code:      code,
+		Name:      name,
+		Active:    true,
+		CreatedAt: time.Now().UTC(),
+	}, nil
+}
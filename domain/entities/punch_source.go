@@ -0,0 +1,21 @@
+package entities
+
+// PunchSource is the channel a check-in or check-out came through
+type PunchSource string
+
+const (
+	SourceKiosk  PunchSource = "kiosk"  // Badge reader or kiosk terminal
+	SourceMobile PunchSource = "mobile" // Employee's phone
+	SourceWeb    PunchSource = "web"    // Browser self-service
+	SourceAPI    PunchSource = "api"    // Integrations calling the API directly
+	SourceImport PunchSource = "import" // Bulk imports of historic punches
+)
+
+// IsValid reports whether the source is one of the known channels
+func (s PunchSource) IsValid() bool {
+	switch s {
+	case SourceKiosk, SourceMobile, SourceWeb, SourceAPI, SourceImport:
+		return true
+	}
+	return false
+}
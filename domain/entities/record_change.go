@@ -0,0 +1,30 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ChangeOperation is the kind of write that changed a time record
+type ChangeOperation string
+
+const (
+	ChangeInsert ChangeOperation = "INSERT"
+	ChangeUpdate ChangeOperation = "UPDATE"
+	ChangeDelete ChangeOperation = "DELETE"
+)
+
+// RecordChange is one write to a time record row, captured by the database
+// in the transaction of the write. Old and New hold the changed columns only
+// (every column for inserts and deletes), keyed by column name.
+type RecordChange struct {
+	ID         string
+	RecordID   string
+	EmployeeID string
+	Operation  ChangeOperation
+	Old        map[string]json.RawMessage
+	New        map[string]json.RawMessage
+	Actor      string
+	Source     string
+	ChangedAt  time.Time
+}
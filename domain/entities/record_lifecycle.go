@@ -0,0 +1,106 @@
+package entities
+
+import (
+	"fmt"
+	"time"
+)
+
+// RecordTransition names a move of a time record from one status to another
+type RecordTransition string
+
+const (
+	TransitionCheckOut RecordTransition = "CHECK_OUT"
+	TransitionCorrect  RecordTransition = "CORRECT"
+	TransitionVoid     RecordTransition = "VOID"
+	TransitionLock     RecordTransition = "LOCK"
+	TransitionAmend    RecordTransition = "AMEND"
+)
+
+// recordLifecycle is the time record state machine: for every transition the
+// statuses it may start from and the status it ends in. New states (e.g. an
+// ON_BREAK between check-in and check-out) are added here and nowhere else.
+var recordLifecycle = map[RecordTransition]struct {
+	from []TimeRecordStatus
+	to   TimeRecordStatus
+}{
+	TransitionCheckOut: {from: []TimeRecordStatus{StatusCheckedIn}, to: StatusCheckedOut},
+	TransitionCorrect:  {from: []TimeRecordStatus{StatusCheckedIn, StatusCheckedOut, StatusCorrected}, to: StatusCorrected},
+	TransitionVoid:     {from: []TimeRecordStatus{StatusCheckedIn, StatusCheckedOut, StatusCorrected}, to: StatusVoided},
+	TransitionLock:     {from: []TimeRecordStatus{StatusCheckedOut, StatusCorrected}, to: StatusLocked},
+	// Locked records only change through an approved correction and stay locked
+	TransitionAmend: {from: []TimeRecordStatus{StatusLocked}, to: StatusLocked},
+}
+
+// CompletedStatuses are the statuses of records that count as worked time:
+// checked out, possibly corrected or locked afterwards
+var CompletedStatuses = []TimeRecordStatus{StatusCheckedOut, StatusCorrected, StatusLocked}
+
+// StatusTransition is a status change applied to a record and not yet
+// reported as an event
+type StatusTransition struct {
+	Transition RecordTransition
+	From       TimeRecordStatus
+	To         TimeRecordStatus
+	At         time.Time
+}
+
+// CanTransition reports whether the transition is allowed from the record's
+// current status
+func (tr *TimeRecord) CanTransition(t RecordTransition) bool {
+	rule, ok := recordLifecycle[t]
+	if !ok {
+		return false
+	}
+	for _, from := range rule.from {
+		if tr.Status == from {
+			return true
+		}
+	}
+	return false
+}
+
+// transition validates the move and sets the new status, remembering the
+// change so the caller can publish it
+func (tr *TimeRecord) transition(t RecordTransition) error {
+	if !tr.CanTransition(t) {
+		return fmt.Errorf("cannot %s a %s record", t, tr.Status)
+	}
+
+	change := StatusTransition{
+		Transition: t,
+		From:       tr.Status,
+		To:         recordLifecycle[t].to,
+		At:         time.Now().UTC(),
+	}
+	tr.Status = change.To
+	tr.transitions = append(tr.transitions, change)
+	return nil
+}
+
+// TakeTransitions returns the status changes applied since the last call and
+// forgets them
+func (tr *TimeRecord) TakeTransitions() []StatusTransition {
+	changes := tr.transitions
+	tr.transitions = nil
+	return changes
+}
+
+// IsCompleted reports whether the record counts as worked time
+func (tr *TimeRecord) IsCompleted() bool {
+	return tr.Status.IsCompleted()
+}
+
+// IsCompleted reports whether records in this status count as worked time
+func (s TimeRecordStatus) IsCompleted() bool {
+	for _, status := range CompletedStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Lock freezes a completed record, e.g. once its pay period was exported
+func (tr *TimeRecord) Lock() error {
+	return tr.transition(TransitionLock)
+}
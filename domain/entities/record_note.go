@@ -0,0 +1,55 @@
+package entities
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NoteKind tells when a note was written
+type NoteKind string
+
+const (
+	NoteOnCheckIn  NoteKind = "CHECK_IN"
+	NoteOnCheckOut NoteKind = "CHECK_OUT"
+	NoteAppended   NoteKind = "APPENDED" // Added after the fact, e.g. to explain an irregular entry
+)
+
+// MaxNoteLength bounds the free text of a note
+const MaxNoteLength = 1000
+
+// RecordNote is a free-text comment on a time record. Notes are append-only.
+type RecordNote struct {
+	ID         string
+	RecordID   string
+	EmployeeID string
+	Kind       NoteKind
+	Author     string
+	Body       string
+	CreatedAt  time.Time
+}
+
+func NewRecordNote(record *TimeRecord, kind NoteKind, author, body string) (*RecordNote, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return nil, errors.New("note cannot be empty")
+	}
+	if len(body) > MaxNoteLength {
+		return nil, errors.New("note is too long")
+	}
+	if author == "" {
+		author = record.EmployeeID
+	}
+
+	return &RecordNote{
+		ID:         uuid.New().String(),
+		RecordID:   record.ID,
+		EmployeeID: record.EmployeeID,
+		Kind:       kind,
+		Author:     author,
+		Body:       body,
+		CreatedAt:  time.Now().UTC(),
+	}, nil
+}
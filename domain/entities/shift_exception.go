@@ -0,0 +1,46 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExceptionKind tells how an employee deviated from their schedule
+type ExceptionKind string
+
+const (
+	// ExceptionNoShow is a scheduled shift without any check-in during it
+	ExceptionNoShow ExceptionKind = "NO_SHOW"
+)
+
+// ShiftException is a scheduled shift that did not happen as planned,
+// reported to the employee's manager
+type ShiftException struct {
+	ID             string
+	EmployeeID     string
+	ManagerID      string
+	Kind           ExceptionKind
+	BusinessDate   string // local date the shift was scheduled on
+	ScheduledStart time.Time
+	ScheduledEnd   time.Time
+	LocationID     string
+	TimeZone       string
+	CreatedAt      time.Time
+}
+
+// NewNoShow records that the employee never checked in for the shift on date
+func NewNoShow(shift *ScheduledShift, managerID, date string, start, end time.Time) *ShiftException {
+	return &ShiftException{
+		ID:             uuid.New().String(),
+		EmployeeID:     shift.EmployeeID,
+		ManagerID:      managerID,
+		Kind:           ExceptionNoShow,
+		BusinessDate:   date,
+		ScheduledStart: start,
+		ScheduledEnd:   end,
+		LocationID:     shift.LocationID,
+		TimeZone:       shift.TimeZone,
+		CreatedAt:      time.Now().UTC(),
+	}
+}
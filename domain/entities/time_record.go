@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/leo-andrei/check-in-service/domain/hours"
 )
 
 type TimeRecordStatus string
@@ -12,6 +13,9 @@ type TimeRecordStatus string
 const (
 	StatusCheckedIn  TimeRecordStatus = "CHECKED_IN"
 	StatusCheckedOut TimeRecordStatus = "CHECKED_OUT"
+	StatusCorrected  TimeRecordStatus = "CORRECTED"
+	StatusVoided     TimeRecordStatus = "VOIDED"
+	StatusLocked     TimeRecordStatus = "LOCKED"
 )
 
 type TimeRecord struct {
@@ -21,6 +25,37 @@ type TimeRecord struct {
 	CheckOutAt  *time.Time
 	Status      TimeRecordStatus
 	HoursWorked float64
+	// Split of HoursWorked, filled in by the overtime policy at check-out
+	RegularHours  float64
+	OvertimeHours float64
+	LocationID    string // Site where the employee badged in, empty when unknown
+	// IANA time zone of the employee/location at check-in; times are stored in UTC
+	TimeZone    string
+	ProjectCode string // Project/cost code the hours are attributed to, empty when none
+	// Breakdown of HoursWorked computed at check-out; not loaded back from storage
+	Calculation *hours.Calculation
+	// Notes written with this check-in/check-out, saved together with the record
+	Notes []*RecordNote
+	// Local date of the check-in (YYYY-MM-DD) the record is reported under
+	BusinessDate string
+	// Per-day parts of a shift crossing midnight, when overnight splitting is on
+	Segments []DaySegment
+	// Parts of HoursWorked worked on company holidays and on weekends, for
+	// premium rates; they overlap the regular/overtime split
+	HolidayHours float64
+	WeekendHours float64
+	// Money value of the hours, nil when the employee has no hourly rate
+	LaborCost *LaborCost
+	// Channel and terminal of the check-in and check-out punches; device IDs
+	// are empty when the punch did not name a terminal
+	Source           PunchSource
+	DeviceID         string
+	CheckOutSource   PunchSource
+	CheckOutDeviceID string
+	// When the record was voided, nil for records that are not
+	VoidedAt *time.Time
+	// Status changes not yet reported, see TakeTransitions
+	transitions []StatusTransition
 }
 
 func NewTimeRecord(employeeID string) (*TimeRecord, error) {
@@ -31,24 +66,155 @@ func NewTimeRecord(employeeID string) (*TimeRecord, error) {
 	return &TimeRecord{
 		ID:         uuid.New().String(),
 		EmployeeID: employeeID,
-		CheckInAt:  time.Now(),
+		CheckInAt:  time.Now().UTC(),
 		Status:     StatusCheckedIn,
+		TimeZone:   "UTC",
 	}, nil
 }
 
 func (tr *TimeRecord) CheckOut() error {
-	if tr.Status == StatusCheckedOut {
-		return errors.New("already checked out")
+	if err := tr.transition(TransitionCheckOut); err != nil {
+		return err
 	}
 
-	now := time.Now()
+	now := time.Now().UTC()
 	tr.CheckOutAt = &now
-	tr.Status = StatusCheckedOut
 	tr.HoursWorked = now.Sub(tr.CheckInAt).Hours()
+	tr.RegularHours = tr.HoursWorked
+	tr.OvertimeHours = 0
 
 	return nil
 }
 
+// NewManualTimeRecord creates a completed record for a backdated entry
+func NewManualTimeRecord(employeeID string, checkInAt, checkOutAt time.Time, timeZone string) (*TimeRecord, error) {
+	record, err := NewTimeRecord(employeeID)
+	if err != nil {
+		return nil, err
+	}
+	record.CheckInAt = checkInAt.UTC()
+	record.TimeZone = timeZone
+	if err := record.CloseAt(checkOutAt); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// Correct replaces the check-in and check-out times of the record, e.g. after
+// an approved correction. The record ends up corrected.
+func (tr *TimeRecord) Correct(checkInAt, checkOutAt time.Time) error {
+	return tr.retime(TransitionCorrect, checkInAt, checkOutAt)
+}
+
+// Amend replaces the times of a locked record; it stays locked. Only approved
+// corrections amend records of a closed pay period.
+func (tr *TimeRecord) Amend(checkInAt, checkOutAt time.Time) error {
+	return tr.retime(TransitionAmend, checkInAt, checkOutAt)
+}
+
+func (tr *TimeRecord) retime(t RecordTransition, checkInAt, checkOutAt time.Time) error {
+	if !checkOutAt.After(checkInAt) {
+		return errors.New("check-out time must be after check-in time")
+	}
+	if err := tr.transition(t); err != nil {
+		return err
+	}
+
+	checkInAt = checkInAt.UTC()
+	checkOutAt = checkOutAt.UTC()
+	tr.CheckInAt = checkInAt
+	tr.CheckOutAt = &checkOutAt
+	tr.HoursWorked = checkOutAt.Sub(checkInAt).Hours()
+	tr.RegularHours = tr.HoursWorked
+	tr.OvertimeHours = 0
+
+	return nil
+}
+
+// ApplyHoursCalculation replaces the raw worked hours with the payable hours
+// of the calculation (after breaks and rounding)
+func (tr *TimeRecord) ApplyHoursCalculation(calc *hours.Calculation) {
+	tr.Calculation = calc
+	tr.HoursWorked = calc.PayableHours
+	tr.RegularHours = tr.HoursWorked
+	tr.OvertimeHours = 0
+}
+
+// AddNote attaches a note written by the employee at check-in or check-out
+func (tr *TimeRecord) AddNote(kind NoteKind, body string) error {
+	note, err := NewRecordNote(tr, kind, tr.EmployeeID, body)
+	if err != nil {
+		return err
+	}
+	tr.Notes = append(tr.Notes, note)
+	return nil
+}
+
+// Local returns t in the record's time zone
+func (tr *TimeRecord) Local(t time.Time) time.Time {
+	return InTimeZone(t, tr.TimeZone)
+}
+
 func (tr *TimeRecord) IsCheckedIn() bool {
 	return tr.Status == StatusCheckedIn
 }
+
+// CloseAt checks the record out at the given time, used when repairing records
+// that were left open by mistake
+func (tr *TimeRecord) CloseAt(at time.Time) error {
+	if at.Before(tr.CheckInAt) {
+		return errors.New("check-out time cannot be before check-in time")
+	}
+	if err := tr.transition(TransitionCheckOut); err != nil {
+		return err
+	}
+
+	at = at.UTC()
+	tr.CheckOutAt = &at
+	tr.HoursWorked = at.Sub(tr.CheckInAt).Hours()
+	tr.RegularHours = tr.HoursWorked
+	tr.OvertimeHours = 0
+
+	return nil
+}
+
+// ExtendCheckOut moves the check-out of a closed record to a later time; the
+// record ends up corrected
+func (tr *TimeRecord) ExtendCheckOut(at time.Time) error {
+	if !tr.IsCompleted() || tr.CheckOutAt == nil {
+		return errors.New("only checked-out records can be extended")
+	}
+	if !at.After(*tr.CheckOutAt) {
+		return nil
+	}
+	if err := tr.transition(TransitionCorrect); err != nil {
+		return err
+	}
+
+	at = at.UTC()
+	tr.CheckOutAt = &at
+	tr.HoursWorked = at.Sub(tr.CheckInAt).Hours()
+	tr.RegularHours = tr.HoursWorked
+	tr.OvertimeHours = 0
+
+	return nil
+}
+
+// Void invalidates the record without deleting it
+func (tr *TimeRecord) Void() error {
+	if err := tr.transition(TransitionVoid); err != nil {
+		return err
+	}
+
+	tr.Segments = nil
+	tr.HolidayHours = 0
+	tr.WeekendHours = 0
+	tr.LaborCost = nil
+	tr.HoursWorked = 0
+	tr.RegularHours = 0
+	tr.OvertimeHours = 0
+	voidedAt := time.Now().UTC()
+	tr.VoidedAt = &voidedAt
+
+	return nil
+}
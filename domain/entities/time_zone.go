@@ -0,0 +1,29 @@
+package entities
+
+import (
+	"fmt"
+	"time"
+)
+
+// LoadTimeZone resolves an IANA time zone name (e.g. "Europe/Bucharest"),
+// treating an empty name as UTC
+func LoadTimeZone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q: %w", name, err)
+	}
+	return loc, nil
+}
+
+// InTimeZone renders t in the named zone, falling back to UTC for unknown names
+func InTimeZone(t time.Time, name string) time.Time {
+	loc, err := LoadTimeZone(name)
+	if err != nil {
+		loc = time.UTC
+	}
+	return t.In(loc)
+}
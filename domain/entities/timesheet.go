@@ -0,0 +1,148 @@
+package entities
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TimesheetStatus string
+
+const (
+	TimesheetOpen      TimesheetStatus = "OPEN"
+	TimesheetSubmitted TimesheetStatus = "SUBMITTED"
+	TimesheetApproved  TimesheetStatus = "APPROVED"
+	// Closed after the payroll cut-off; its records are locked
+	TimesheetClosed TimesheetStatus = "CLOSED"
+)
+
+// Timesheet collects an employee's records of one pay period for submission
+// and approval. Closing it locks the records, after which they only change
+// through approved corrections.
+type Timesheet struct {
+	ID         string
+	EmployeeID string
+	// Pay period as local dates (YYYY-MM-DD), end exclusive
+	PeriodStart string
+	PeriodEnd   string
+	Status      TimesheetStatus
+	// Completed records of the period, as of the last Collect
+	RecordIDs       []string
+	HoursWorked     float64
+	RegularHours    float64
+	OvertimeHours   float64
+	SubmittedAt     *time.Time
+	DecidedBy       string
+	DecisionComment string
+	DecidedAt       *time.Time
+	ClosedAt        *time.Time
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+func NewTimesheet(employeeID, periodStart, periodEnd string) (*Timesheet, error) {
+	if employeeID == "" {
+		return nil, errors.New("employee ID cannot be empty")
+	}
+	if periodStart == "" || periodEnd <= periodStart {
+		return nil, errors.New("invalid pay period")
+	}
+
+	now := time.Now().UTC()
+	return &Timesheet{
+		ID:          uuid.New().String(),
+		EmployeeID:  employeeID,
+		PeriodStart: periodStart,
+		PeriodEnd:   periodEnd,
+		Status:      TimesheetOpen,
+		RecordIDs:   []string{},
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}, nil
+}
+
+func (t *Timesheet) IsClosed() bool {
+	return t.Status == TimesheetClosed
+}
+
+// Collect recomputes the totals from the period's records; open and voided
+// records are left out. Closed timesheets keep the totals they were closed with.
+func (t *Timesheet) Collect(records []*TimeRecord) {
+	if t.IsClosed() {
+		return
+	}
+
+	t.RecordIDs = []string{}
+	t.HoursWorked, t.RegularHours, t.OvertimeHours = 0, 0, 0
+	for _, record := range records {
+		if !record.IsCompleted() {
+			continue
+		}
+		t.RecordIDs = append(t.RecordIDs, record.ID)
+		t.HoursWorked += record.HoursWorked
+		t.RegularHours += record.RegularHours
+		t.OvertimeHours += record.OvertimeHours
+	}
+	t.UpdatedAt = time.Now().UTC()
+}
+
+// Submit hands an open timesheet to the manager
+func (t *Timesheet) Submit() error {
+	if t.Status != TimesheetOpen {
+		return errors.New("only open timesheets can be submitted")
+	}
+
+	now := time.Now().UTC()
+	t.Status = TimesheetSubmitted
+	t.SubmittedAt = &now
+	t.UpdatedAt = now
+	return nil
+}
+
+// Decide approves a submitted timesheet, or sends it back to the employee
+// as open when rejected
+func (t *Timesheet) Decide(approve bool, decidedBy, comment string) error {
+	if t.Status != TimesheetSubmitted {
+		return errors.New("only submitted timesheets can be decided")
+	}
+
+	now := time.Now().UTC()
+	t.Status = TimesheetOpen
+	if approve {
+		t.Status = TimesheetApproved
+	}
+	t.DecidedBy = decidedBy
+	t.DecisionComment = comment
+	t.DecidedAt = &now
+	t.UpdatedAt = now
+	return nil
+}
+
+// Close collects the records a last time and locks the completed ones,
+// returning the records it changed. Timesheets close whether or not they were
+// approved; payroll runs on what was recorded by the cut-off.
+func (t *Timesheet) Close(records []*TimeRecord) ([]*TimeRecord, error) {
+	if t.IsClosed() {
+		return nil, errors.New("timesheet is already closed")
+	}
+
+	t.Collect(records)
+
+	var locked []*TimeRecord
+	for _, record := range records {
+		if !record.CanTransition(TransitionLock) {
+			continue
+		}
+		if err := record.Lock(); err != nil {
+			return nil, err
+		}
+		locked = append(locked, record)
+	}
+
+	now := time.Now().UTC()
+	t.Status = TimesheetClosed
+	t.ClosedAt = &now
+	t.UpdatedAt = now
+	return locked, nil
+}
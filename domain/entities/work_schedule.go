@@ -0,0 +1,67 @@
+package entities
+
+import (
+	"errors"
+	"time"
+)
+
+// shiftClockLayout is the local wall-clock time a scheduled shift starts or ends at
+const shiftClockLayout = "15:04"
+
+// ScheduledShift is a weekly recurring shift of an employee
+type ScheduledShift struct {
+	EmployeeID string
+	Weekday    time.Weekday
+	Start      string // HH:MM local time
+	End        string // HH:MM local time; at or before Start means the next day
+	LocationID string
+	TimeZone   string
+}
+
+func NewScheduledShift(employeeID string, weekday time.Weekday, start, end, locationID, timeZone string) (*ScheduledShift, error) {
+	if employeeID == "" {
+		return nil, errors.New("employee ID cannot be empty")
+	}
+	if weekday < time.Sunday || weekday > time.Saturday {
+		return nil, errors.New("weekday must be 0 (Sunday) to 6 (Saturday)")
+	}
+	if _, err := time.Parse(shiftClockLayout, start); err != nil {
+		return nil, errors.New("shift start must be HH:MM")
+	}
+	if _, err := time.Parse(shiftClockLayout, end); err != nil {
+		return nil, errors.New("shift end must be HH:MM")
+	}
+	if _, err := LoadTimeZone(timeZone); err != nil {
+		return nil, err
+	}
+
+	return &ScheduledShift{
+		EmployeeID: employeeID,
+		Weekday:    weekday,
+		Start:      start,
+		End:        end,
+		LocationID: locationID,
+		TimeZone:   timeZone,
+	}, nil
+}
+
+// On returns when the shift starts and ends on the local date (YYYY-MM-DD),
+// which should fall on the shift's weekday
+func (s *ScheduledShift) On(date string) (time.Time, time.Time, error) {
+	loc, err := LoadTimeZone(s.TimeZone)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	start, err := time.ParseInLocation(BusinessDateLayout+" "+shiftClockLayout, date+" "+s.Start, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end, err := time.ParseInLocation(BusinessDateLayout+" "+shiftClockLayout, date+" "+s.End, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if !end.After(start) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return start, end, nil
+}
@@ -12,10 +12,140 @@ const (
 	ErrNoActiveCheckInFound     = "no active check-in found for employee"
 	ErrEmployeeAlreadyCheckedIn = "employee is already checked in"
 	ErrDuplicateCheckIn         = "duplicate check-in request (already checked in within 60 seconds)"
+	ErrInvalidConsentPurpose    = "invalid consent purpose"
+	ErrConsentNotFound          = "employee has not consented to this purpose"
+	ErrAdminAPIDisabled         = "admin API is disabled"
+	ErrUnauthorized             = "unauthorized"
+	ErrUnknownLocation          = "unknown or inactive location"
+	ErrInvalidTimeZone          = "invalid time zone"
+	ErrUnknownProject           = "unknown or inactive project code"
+	ErrInvalidDateRange         = "invalid date range"
+	ErrRecordNotFound           = "time record not found"
+	ErrInvalidNote              = "invalid note"
+	ErrInvalidMerge             = "invalid merge: employee IDs must differ"
+	ErrMergeConflict            = "both employees are checked in; check one out before merging"
+	ErrUnknownEmployee          = "unknown or inactive employee"
+	ErrEmployeeNotFound         = "employee not found"
+	ErrInvalidEmployee          = "invalid employee details"
+	ErrUnknownManager           = "unknown manager"
+	ErrInvalidApproval          = "invalid approval request"
+	ErrApprovalNotFound         = "approval not found"
+	ErrApprovalAlreadyDecided   = "approval already decided"
+	ErrNotApprover              = "only the employee's manager can decide this approval"
+	ErrInvalidCursor            = "invalid cursor"
+	ErrAuditTampered            = "audit log failed verification"
+	ErrDeviceNotFound           = "device not found"
+	ErrInvalidDevice            = "invalid device"
+	ErrInvalidEnrollmentCode    = "invalid or expired enrollment code"
+	ErrDeviceRevoked            = "device has been revoked"
+	ErrDeviceUnauthorized       = "invalid device credentials"
+	ErrDeviceNotAuthenticated   = "source kiosk and device_id need device credentials"
+	ErrInvalidHoliday           = "invalid holiday: date must be YYYY-MM-DD and name is required"
+	ErrHolidayNotFound          = "holiday not found"
+	ErrUnknownSender            = "sender is not an employee on the roster"
+	ErrInvalidEmailCommand      = "unrecognized email command: reply STOP, CHECKOUT or CHECKOUT HH:MM"
+	ErrInboundEmailDisabled     = "inbound email is disabled"
+	ErrInvalidIdempotencyKey    = "invalid Idempotency-Key: expected 1-255 characters"
+	ErrIdempotencyKeyInProgress = "a request with this Idempotency-Key is still in progress"
+	ErrIdempotencyKeyReused     = "Idempotency-Key was already used for a different request"
+	ErrDuplicateInboundEmail    = "inbound email already processed"
+	ErrInvalidEmailSettings     = "invalid email settings: logo must be an https URL and footer at most 2000 characters"
+	ErrAntiPassback             = "employee is checked in at another location"
+	ErrAntiPassbackTransit      = "employee checked out at another location too recently"
+	ErrTimesheetNotFound        = "timesheet not found"
+	ErrTimesheetTransition      = "timesheet cannot be changed in its current status"
+	ErrInvalidPayPeriodDate     = "invalid pay period date: expected YYYY-MM-DD"
+	ErrUnknownQueue             = "unknown queue"
+	ErrUnknownBreaker           = "unknown circuit breaker"
+	ErrInvalidBreakerState      = "invalid breaker state: expected open, closed or auto"
+	ErrInvalidSearch            = "invalid search: give at least one of q, employee, device, note or a date, and type record or audit"
+	ErrPayrollPreflightFailed   = "payroll preflight failed: events of the pay period are still being published or processed"
+	ErrInvalidTimelineDate      = "invalid timeline date: expected YYYY-MM-DD"
+	ErrInvalidCommand           = "invalid command"
+	ErrCommandForbidden         = "not allowed to act for another employee"
+	ErrInvalidSchedule          = "invalid schedule: each shift needs a weekday 0-6, start and end as HH:MM and a known time zone"
+	ErrInvalidExceptionDate     = "invalid exception date: expected YYYY-MM-DD"
+	ErrOutboxEventNotFound      = "outbox event not found or already published"
+	ErrRepairPlanChanged        = "records changed since the repair was previewed; preview it again"
 )
 
 var (
 	ErrEmployeeAlreadyCheckedInConst = errors.New(ErrEmployeeAlreadyCheckedIn)
 	ErrDuplicateCheckInConst         = errors.New(ErrDuplicateCheckIn)
 	ErrNoActiveCheckInFoundConst     = errors.New(ErrNoActiveCheckInFound)
+	ErrInvalidConsentPurposeConst    = errors.New(ErrInvalidConsentPurpose)
+	ErrConsentNotFoundConst          = errors.New(ErrConsentNotFound)
+	ErrUnknownLocationConst          = errors.New(ErrUnknownLocation)
+	ErrInvalidTimeZoneConst          = errors.New(ErrInvalidTimeZone)
+	ErrUnknownProjectConst           = errors.New(ErrUnknownProject)
+	ErrRecordNotFoundConst           = errors.New(ErrRecordNotFound)
+	ErrInvalidNoteConst              = errors.New(ErrInvalidNote)
+	ErrInvalidMergeConst             = errors.New(ErrInvalidMerge)
+	ErrMergeConflictConst            = errors.New(ErrMergeConflict)
+	ErrUnknownEmployeeConst          = errors.New(ErrUnknownEmployee)
+	ErrEmployeeNotFoundConst         = errors.New(ErrEmployeeNotFound)
+	ErrInvalidEmployeeConst          = errors.New(ErrInvalidEmployee)
+	ErrUnknownManagerConst           = errors.New(ErrUnknownManager)
+	ErrInvalidApprovalConst          = errors.New(ErrInvalidApproval)
+	ErrApprovalNotFoundConst         = errors.New(ErrApprovalNotFound)
+	ErrApprovalAlreadyDecidedConst   = errors.New(ErrApprovalAlreadyDecided)
+	ErrNotApproverConst              = errors.New(ErrNotApprover)
+	ErrInvalidCursorConst            = errors.New(ErrInvalidCursor)
+	ErrAuditTamperedConst            = errors.New(ErrAuditTampered)
+	ErrDeviceNotFoundConst           = errors.New(ErrDeviceNotFound)
+	ErrInvalidDeviceConst            = errors.New(ErrInvalidDevice)
+	ErrInvalidEnrollmentCodeConst    = errors.New(ErrInvalidEnrollmentCode)
+	ErrDeviceRevokedConst            = errors.New(ErrDeviceRevoked)
+	ErrDeviceUnauthorizedConst       = errors.New(ErrDeviceUnauthorized)
+	ErrDeviceNotAuthenticatedConst   = errors.New(ErrDeviceNotAuthenticated)
+	ErrInvalidHolidayConst           = errors.New(ErrInvalidHoliday)
+	ErrHolidayNotFoundConst          = errors.New(ErrHolidayNotFound)
+	ErrUnknownSenderConst            = errors.New(ErrUnknownSender)
+	ErrInvalidEmailCommandConst      = errors.New(ErrInvalidEmailCommand)
+	ErrInboundEmailDisabledConst     = errors.New(ErrInboundEmailDisabled)
+	ErrIdempotencyKeyInProgressConst = errors.New(ErrIdempotencyKeyInProgress)
+	ErrIdempotencyKeyReusedConst     = errors.New(ErrIdempotencyKeyReused)
+	ErrDuplicateInboundEmailConst    = errors.New(ErrDuplicateInboundEmail)
+	ErrInvalidEmailSettingsConst     = errors.New(ErrInvalidEmailSettings)
+	ErrAntiPassbackConst             = errors.New(ErrAntiPassback)
+	ErrAntiPassbackTransitConst      = errors.New(ErrAntiPassbackTransit)
+	ErrTimesheetNotFoundConst        = errors.New(ErrTimesheetNotFound)
+	ErrTimesheetTransitionConst      = errors.New(ErrTimesheetTransition)
+	ErrInvalidPayPeriodDateConst     = errors.New(ErrInvalidPayPeriodDate)
+	ErrUnknownQueueConst             = errors.New(ErrUnknownQueue)
+	ErrInvalidSearchConst            = errors.New(ErrInvalidSearch)
+	ErrPayrollPreflightFailedConst   = errors.New(ErrPayrollPreflightFailed)
+	ErrInvalidTimelineDateConst      = errors.New(ErrInvalidTimelineDate)
+	ErrInvalidCommandConst           = errors.New(ErrInvalidCommand)
+	ErrCommandForbiddenConst         = errors.New(ErrCommandForbidden)
+	ErrInvalidScheduleConst          = errors.New(ErrInvalidSchedule)
+	ErrInvalidExceptionDateConst     = errors.New(ErrInvalidExceptionDate)
+	ErrOutboxEventNotFoundConst      = errors.New(ErrOutboxEventNotFound)
+	ErrRepairPlanChangedConst        = errors.New(ErrRepairPlanChanged)
 )
+
+// PermanentError is a failure retrying cannot fix, such as a request the
+// receiving service rejected as invalid. Consumers send the message to the
+// DLQ at once instead of retrying it.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// Permanent marks err as a failure retrying cannot fix
+func Permanent(err error) error {
+	return &PermanentError{Err: err}
+}
+
+// IsPermanent tells whether err, or an error it wraps, is permanent
+func IsPermanent(err error) bool {
+	var permanent *PermanentError
+	return errors.As(err, &permanent)
+}
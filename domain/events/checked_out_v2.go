@@ -0,0 +1,167 @@
+package events
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// EmployeeCheckedOutEventV2 regroups the check-out payload into the record,
+// its hours and the punch. Until consumers switch over it is only produced in
+// shadow mode, next to the version 1 event, to verify nothing is lost.
+type EmployeeCheckedOutEventV2 struct {
+	EventHeader
+	EmployeeID string           `json:"employee_id"`
+	Record     CheckedOutRecord `json:"record"`
+	Hours      CheckedOutHours  `json:"hours"`
+	LaborCost  *LaborCost       `json:"labor_cost,omitempty"`
+	Punch      Punch            `json:"punch"`
+	Note       string           `json:"note,omitempty"`
+}
+
+type CheckedOutRecord struct {
+	ID           string    `json:"id"`
+	CheckInAt    time.Time `json:"check_in_at"`
+	CheckOutAt   time.Time `json:"check_out_at"`
+	BusinessDate string    `json:"business_date,omitempty"`
+	LocationID   string    `json:"location_id,omitempty"`
+	TimeZone     string    `json:"time_zone,omitempty"`
+	ProjectCode  string    `json:"project_code,omitempty"`
+}
+
+type CheckedOutHours struct {
+	Worked    float64         `json:"worked"`
+	Regular   float64         `json:"regular"`
+	Overtime  float64         `json:"overtime"`
+	Holiday   float64         `json:"holiday,omitempty"`
+	Weekend   float64         `json:"weekend,omitempty"`
+	Breakdown *HoursBreakdown `json:"breakdown,omitempty"`
+	Segments  []DaySegment    `json:"segments,omitempty"`
+}
+
+// Punch is the channel and terminal of a check-in or check-out
+type Punch struct {
+	Source   string `json:"source,omitempty"`
+	DeviceID string `json:"device_id,omitempty"`
+}
+
+func (e EmployeeCheckedOutEventV2) EventType() string {
+	return EventTypeEmployeeCheckedOut
+}
+
+func (e EmployeeCheckedOutEventV2) OccurredAt() time.Time {
+	return e.Timestamp
+}
+
+func (e EmployeeCheckedOutEventV2) Version() int {
+	return e.EventHeader.Version
+}
+
+// UpgradeCheckedOut converts a version 1 check-out event to version 2,
+// keeping its ID so both versions can be matched
+func UpgradeCheckedOut(e EmployeeCheckedOutEvent) EmployeeCheckedOutEventV2 {
+	header := e.EventHeader
+	header.Version = 2
+
+	return EmployeeCheckedOutEventV2{
+		EventHeader: header,
+		EmployeeID:  e.EmployeeID,
+		Record: CheckedOutRecord{
+			ID:           e.RecordID,
+			CheckInAt:    e.CheckInAt,
+			CheckOutAt:   e.CheckOutAt,
+			BusinessDate: e.BusinessDate,
+			LocationID:   e.LocationID,
+			TimeZone:     e.TimeZone,
+			ProjectCode:  e.ProjectCode,
+		},
+		Hours: CheckedOutHours{
+			Worked:    e.HoursWorked,
+			Regular:   e.RegularHours,
+			Overtime:  e.OvertimeHours,
+			Holiday:   e.HolidayHours,
+			Weekend:   e.WeekendHours,
+			Breakdown: e.Breakdown,
+			Segments:  e.Segments,
+		},
+		LaborCost: e.LaborCost,
+		Punch: Punch{
+			Source:   e.Source,
+			DeviceID: e.DeviceID,
+		},
+		Note: e.Note,
+	}
+}
+
+// checkedOutV2Fields maps every top-level field of the version 1 check-out
+// event to where it lives in version 2
+var checkedOutV2Fields = map[string]string{
+	"event_id":        "event_id",
+	"event_type":      "event_type",
+	"timestamp":       "timestamp",
+	"employee_id":     "employee_id",
+	"record_id":       "record.id",
+	"check_in_at":     "record.check_in_at",
+	"check_out_at":    "record.check_out_at",
+	"business_date":   "record.business_date",
+	"location_id":     "record.location_id",
+	"time_zone":       "record.time_zone",
+	"project_code":    "record.project_code",
+	"hours_worked":    "hours.worked",
+	"regular_hours":   "hours.regular",
+	"overtime_hours":  "hours.overtime",
+	"holiday_hours":   "hours.holiday",
+	"weekend_hours":   "hours.weekend",
+	"hours_breakdown": "hours.breakdown",
+	"day_segments":    "hours.segments",
+	"labor_cost":      "labor_cost",
+	"source":          "punch.source",
+	"device_id":       "punch.device_id",
+	"note":            "note",
+}
+
+// shadowVersion is the next version of an event type: how to produce it from
+// the current payload and where the current fields moved
+type shadowVersion struct {
+	upgrade func(payload []byte) (DomainEvent, error)
+	fields  map[string]string
+}
+
+var shadowVersions = map[string]shadowVersion{
+	EventTypeEmployeeCheckedOut: {
+		upgrade: func(payload []byte) (DomainEvent, error) {
+			var v1 EmployeeCheckedOutEvent
+			if err := json.Unmarshal(payload, &v1); err != nil {
+				return nil, err
+			}
+			return UpgradeCheckedOut(v1), nil
+		},
+		fields: checkedOutV2Fields,
+	},
+}
+
+// UpgradeForShadow returns the next version of a serialized event, or false
+// when the event type has none
+func UpgradeForShadow(eventType string, payload []byte) (DomainEvent, bool, error) {
+	version, ok := shadowVersions[eventType]
+	if !ok {
+		return nil, false, nil
+	}
+	next, err := version.upgrade(payload)
+	return next, true, err
+}
+
+// ShadowFieldMap returns where each top-level field of the current version
+// moved in the next one, nil when the event type has no next version. The
+// version field is left out since it differs by design.
+func ShadowFieldMap(eventType string) map[string]string {
+	return shadowVersions[eventType].fields
+}
+
+// ShadowEnvelope carries both versions of one event to the verification
+// exchange, so they can be compared without pairing separate messages
+type ShadowEnvelope struct {
+	EventID   string          `json:"event_id"`
+	EventType string          `json:"event_type"`
+	Current   json.RawMessage `json:"current"`
+	Next      json.RawMessage `json:"next"`
+}
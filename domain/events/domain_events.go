@@ -1,12 +1,22 @@
 package events
 
 import (
+	"encoding/json"
 	"time"
 )
 
 const (
-	EventTypeEmployeeCheckedIn  = "EmployeeCheckedIn"
-	EventTypeEmployeeCheckedOut = "EmployeeCheckedOut"
+	EventTypeEmployeeCheckedIn        = "EmployeeCheckedIn"
+	EventTypeEmployeeCheckedOut       = "EmployeeCheckedOut"
+	EventTypeEmployeeOvertimeDetected = "EmployeeOvertimeDetected"
+	EventTypeEmployeesMerged          = "EmployeesMerged"
+	EventTypeApprovalRequested        = "ApprovalRequested"
+	EventTypeApprovalDecided          = "ApprovalDecided"
+	EventTypeTimeRecordCorrected      = "TimeRecordCorrected"
+	EventTypeTimeRecordVoided         = "TimeRecordVoided"
+	EventTypeTimeRecordStatusChanged  = "TimeRecordStatusChanged"
+	EventTypeEmployeeMissedCheckout   = "EmployeeMissedCheckout"
+	EventTypeEmployeeNoShow           = "EmployeeNoShow"
 )
 
 type DomainEvent interface {
@@ -22,13 +32,88 @@ type EventHeader struct {
 	EventType string    `json:"event_type"`
 	Version   int       `json:"version"` // For schema evolution
 	Timestamp time.Time `json:"timestamp"`
+	// Outbox priority chosen by the emitting service; 0 is PriorityNormal.
+	// It orders publishing only and is not part of the message.
+	Priority int `json:"-"`
+}
+
+// Outbox priorities; lower values are published first
+const (
+	PriorityPayroll = 1 // Needed by payroll before its cut-off
+	PriorityNormal  = 5
+	PriorityRoutine = 9 // Notifications that can wait, e.g. emails
+)
+
+// OutboxPriority is promoted to every event embedding the header
+func (h EventHeader) OutboxPriority() int {
+	if h.Priority == 0 {
+		return PriorityNormal
+	}
+	return h.Priority
+}
+
+// PriorityOf returns the outbox priority of an event, PriorityNormal for
+// events without a header
+func PriorityOf(event DomainEvent) int {
+	if prioritized, ok := event.(interface{ OutboxPriority() int }); ok {
+		return prioritized.OutboxPriority()
+	}
+	return PriorityNormal
+}
+
+// EmployeeOf returns the employee an event is about, the target for merges,
+// or "" for events about no employee
+func EmployeeOf(event DomainEvent) string {
+	switch e := event.(type) {
+	case EmployeeCheckedInEvent:
+		return e.EmployeeID
+	case EmployeeCheckedOutEvent:
+		return e.EmployeeID
+	case EmployeeCheckedOutEventV2:
+		return e.EmployeeID
+	case EmployeeOvertimeDetectedEvent:
+		return e.EmployeeID
+	case EmployeesMergedEvent:
+		return e.TargetEmployeeID
+	case ApprovalRequestedEvent:
+		return e.EmployeeID
+	case ApprovalDecidedEvent:
+		return e.EmployeeID
+	case TimeRecordCorrectedEvent:
+		return e.EmployeeID
+	case TimeRecordVoidedEvent:
+		return e.EmployeeID
+	case TimeRecordStatusChangedEvent:
+		return e.EmployeeID
+	case EmployeeMissedCheckoutEvent:
+		return e.EmployeeID
+	case EmployeeNoShowEvent:
+		return e.EmployeeID
+	}
+	return ""
+}
+
+// TypeOf reads the event type from a serialized event, so consumers sharing an
+// exchange can dispatch on it before decoding the full payload
+func TypeOf(data []byte) (string, error) {
+	var header EventHeader
+	if err := json.Unmarshal(data, &header); err != nil {
+		return "", err
+	}
+	return header.EventType, nil
 }
 
 type EmployeeCheckedInEvent struct {
 	EventHeader
-	EmployeeID string    `json:"employee_id"`
-	CheckInAt  time.Time `json:"check_in_at"`
-	RecordID   string    `json:"record_id"`
+	EmployeeID  string    `json:"employee_id"`
+	CheckInAt   time.Time `json:"check_in_at"`
+	RecordID    string    `json:"record_id"`
+	LocationID  string    `json:"location_id,omitempty"`
+	TimeZone    string    `json:"time_zone,omitempty"` // Times are UTC; render them in this zone
+	ProjectCode string    `json:"project_code,omitempty"`
+	Note        string    `json:"note,omitempty"`
+	Source      string    `json:"source,omitempty"` // kiosk, mobile, web, api or import
+	DeviceID    string    `json:"device_id,omitempty"`
 }
 
 func (e EmployeeCheckedInEvent) EventType() string {
@@ -50,6 +135,65 @@ type EmployeeCheckedOutEvent struct {
 	CheckOutAt  time.Time `json:"check_out_at"`
 	HoursWorked float64   `json:"hours_worked"`
 	RecordID    string    `json:"record_id"`
+	// Split of HoursWorked; both are zero in events produced before overtime detection
+	RegularHours  float64 `json:"regular_hours"`
+	OvertimeHours float64 `json:"overtime_hours"`
+	LocationID    string  `json:"location_id,omitempty"`
+	TimeZone      string  `json:"time_zone,omitempty"` // Times are UTC; render them in this zone
+	ProjectCode   string  `json:"project_code,omitempty"`
+	Note          string  `json:"note,omitempty"`
+	// How HoursWorked was derived from the raw check-in/check-out times
+	Breakdown *HoursBreakdown `json:"hours_breakdown,omitempty"`
+	// Local date of the check-in the hours are reported under (YYYY-MM-DD)
+	BusinessDate string `json:"business_date,omitempty"`
+	// Per-day parts of HoursWorked, only for shifts split at midnight
+	Segments []DaySegment `json:"day_segments,omitempty"`
+	// Parts of HoursWorked worked on company holidays and on weekends, paid at
+	// premium rates; they overlap the regular/overtime split
+	HolidayHours float64 `json:"holiday_hours,omitempty"`
+	WeekendHours float64 `json:"weekend_hours,omitempty"`
+	// Money value of HoursWorked; absent when the employee has no hourly rate
+	LaborCost *LaborCost `json:"labor_cost,omitempty"`
+	// Channel and terminal of the check-out punch
+	Source   string `json:"source,omitempty"`
+	DeviceID string `json:"device_id,omitempty"`
+}
+
+// LaborCost prices hours at the employee's hourly rate. Amounts are decimal
+// strings in major units of Currency (ISO 4217), e.g. "123.45".
+type LaborCost struct {
+	Currency   string  `json:"currency"`
+	HourlyRate float64 `json:"hourly_rate"`
+	Regular    string  `json:"regular"`
+	Overtime   string  `json:"overtime"`
+	Total      string  `json:"total"`
+}
+
+// DaySegment is the part of a shift worked on one business date
+type DaySegment struct {
+	BusinessDate string    `json:"business_date"`
+	StartAt      time.Time `json:"start_at"`
+	EndAt        time.Time `json:"end_at"`
+	Hours        float64   `json:"hours"`
+}
+
+// HoursBreakdown is the result of the hours calculation policy chain
+type HoursBreakdown struct {
+	GrossHours         float64 `json:"gross_hours"`
+	BreakHours         float64 `json:"break_hours"`
+	RoundingAdjustment float64 `json:"rounding_adjustment"`
+	PayableHours       float64 `json:"payable_hours"`
+	DayHours           float64 `json:"day_hours"`
+	NightHours         float64 `json:"night_hours"`
+}
+
+// ReportableRegularHours returns the regular hours, falling back to the total
+// for events that predate the overtime split
+func (e EmployeeCheckedOutEvent) ReportableRegularHours() float64 {
+	if e.RegularHours == 0 && e.OvertimeHours == 0 {
+		return e.HoursWorked
+	}
+	return e.RegularHours
 }
 
 func (e EmployeeCheckedOutEvent) EventType() string {
@@ -63,3 +207,268 @@ func (e EmployeeCheckedOutEvent) OccurredAt() time.Time {
 func (e EmployeeCheckedOutEvent) Version() int {
 	return e.EventHeader.Version
 }
+
+// EmployeeOvertimeDetectedEvent is emitted at check-out when a record pushes
+// the employee over the daily or weekly overtime threshold
+type EmployeeOvertimeDetectedEvent struct {
+	EventHeader
+	EmployeeID     string     `json:"employee_id"`
+	RecordID       string     `json:"record_id"`
+	CheckInAt      time.Time  `json:"check_in_at"`
+	CheckOutAt     time.Time  `json:"check_out_at"`
+	HoursWorked    float64    `json:"hours_worked"`
+	RegularHours   float64    `json:"regular_hours"`
+	OvertimeHours  float64    `json:"overtime_hours"`
+	DailyTotal     float64    `json:"daily_total_hours"`
+	WeeklyTotal    float64    `json:"weekly_total_hours"`
+	DailyOvertime  float64    `json:"daily_overtime_hours"`
+	WeeklyOvertime float64    `json:"weekly_overtime_hours"`
+	TimeZone       string     `json:"time_zone,omitempty"`
+	ProjectCode    string     `json:"project_code,omitempty"`
+	LaborCost      *LaborCost `json:"labor_cost,omitempty"`
+}
+
+func (e EmployeeOvertimeDetectedEvent) EventType() string {
+	return EventTypeEmployeeOvertimeDetected
+}
+
+func (e EmployeeOvertimeDetectedEvent) OccurredAt() time.Time {
+	return e.Timestamp
+}
+
+func (e EmployeeOvertimeDetectedEvent) Version() int {
+	return e.EventHeader.Version
+}
+
+// EmployeesMergedEvent is emitted when a duplicate employee ID is folded into
+// the canonical one; downstream systems should re-key their data the same way
+type EmployeesMergedEvent struct {
+	EventHeader
+	SourceEmployeeID string `json:"source_employee_id"`
+	TargetEmployeeID string `json:"target_employee_id"`
+	Actor            string `json:"actor"`
+	Reason           string `json:"reason,omitempty"`
+}
+
+func (e EmployeesMergedEvent) EventType() string {
+	return EventTypeEmployeesMerged
+}
+
+func (e EmployeesMergedEvent) OccurredAt() time.Time {
+	return e.Timestamp
+}
+
+func (e EmployeesMergedEvent) Version() int {
+	return e.EventHeader.Version
+}
+
+// ApprovalRequestedEvent notifies the manager of a pending correction or manual entry
+type ApprovalRequestedEvent struct {
+	EventHeader
+	ApprovalID         string    `json:"approval_id"`
+	EmployeeID         string    `json:"employee_id"`
+	ManagerID          string    `json:"manager_id,omitempty"`
+	Kind               string    `json:"kind"`
+	RecordID           string    `json:"record_id,omitempty"`
+	ProposedCheckInAt  time.Time `json:"proposed_check_in_at"`
+	ProposedCheckOutAt time.Time `json:"proposed_check_out_at"`
+	TimeZone           string    `json:"time_zone,omitempty"`
+	Note               string    `json:"note,omitempty"`
+}
+
+func (e ApprovalRequestedEvent) EventType() string {
+	return EventTypeApprovalRequested
+}
+
+func (e ApprovalRequestedEvent) OccurredAt() time.Time {
+	return e.Timestamp
+}
+
+func (e ApprovalRequestedEvent) Version() int {
+	return e.EventHeader.Version
+}
+
+// ApprovalDecidedEvent is emitted when a manager approves or rejects a request
+type ApprovalDecidedEvent struct {
+	EventHeader
+	ApprovalID  string  `json:"approval_id"`
+	EmployeeID  string  `json:"employee_id"`
+	Kind        string  `json:"kind"`
+	Status      string  `json:"status"`
+	RecordID    string  `json:"record_id,omitempty"` // Corrected or created record, when approved
+	DecidedBy   string  `json:"decided_by"`
+	Comment     string  `json:"comment,omitempty"`
+	HoursWorked float64 `json:"hours_worked,omitempty"`
+}
+
+func (e ApprovalDecidedEvent) EventType() string {
+	return EventTypeApprovalDecided
+}
+
+func (e ApprovalDecidedEvent) OccurredAt() time.Time {
+	return e.Timestamp
+}
+
+func (e ApprovalDecidedEvent) Version() int {
+	return e.EventHeader.Version
+}
+
+// RecordValues are the reportable values of a time record at one point in time
+type RecordValues struct {
+	CheckInAt     time.Time  `json:"check_in_at"`
+	CheckOutAt    *time.Time `json:"check_out_at,omitempty"`
+	Status        string     `json:"status"`
+	HoursWorked   float64    `json:"hours_worked"`
+	RegularHours  float64    `json:"regular_hours"`
+	OvertimeHours float64    `json:"overtime_hours"`
+	LaborCost     *LaborCost `json:"labor_cost,omitempty"`
+}
+
+// ReportableRegularHours returns the regular hours, falling back to the total
+// for records that predate the overtime split
+func (v RecordValues) ReportableRegularHours() float64 {
+	if v.RegularHours == 0 && v.OvertimeHours == 0 {
+		return v.HoursWorked
+	}
+	return v.RegularHours
+}
+
+// Reported reports whether downstream systems were already told about these
+// values, i.e. the record had been completed. Compensation only undoes
+// reported values.
+func (v RecordValues) Reported() bool {
+	switch v.Status {
+	case "CHECKED_OUT", "CORRECTED", "LOCKED":
+		return true
+	}
+	return false
+}
+
+// TimeRecordCorrectedEvent is published when the times of a record change
+// after the fact: an approved correction or a repair. Consumers compensate for
+// the difference between Before and After.
+type TimeRecordCorrectedEvent struct {
+	EventHeader
+	EmployeeID  string       `json:"employee_id"`
+	RecordID    string       `json:"record_id"`
+	TimeZone    string       `json:"time_zone,omitempty"` // Times are UTC; render them in this zone
+	ProjectCode string       `json:"project_code,omitempty"`
+	Actor       string       `json:"actor"`
+	Reason      string       `json:"reason,omitempty"`
+	Before      RecordValues `json:"before"`
+	After       RecordValues `json:"after"`
+}
+
+func (e TimeRecordCorrectedEvent) EventType() string {
+	return EventTypeTimeRecordCorrected
+}
+
+func (e TimeRecordCorrectedEvent) OccurredAt() time.Time {
+	return e.Timestamp
+}
+
+func (e TimeRecordCorrectedEvent) Version() int {
+	return e.EventHeader.Version
+}
+
+// TimeRecordVoidedEvent is published when a record is invalidated. Consumers
+// reverse whatever they did for Before.
+type TimeRecordVoidedEvent struct {
+	EventHeader
+	EmployeeID  string       `json:"employee_id"`
+	RecordID    string       `json:"record_id"`
+	TimeZone    string       `json:"time_zone,omitempty"`
+	ProjectCode string       `json:"project_code,omitempty"`
+	Actor       string       `json:"actor"`
+	Reason      string       `json:"reason,omitempty"`
+	Before      RecordValues `json:"before"`
+}
+
+func (e TimeRecordVoidedEvent) EventType() string {
+	return EventTypeTimeRecordVoided
+}
+
+func (e TimeRecordVoidedEvent) OccurredAt() time.Time {
+	return e.Timestamp
+}
+
+func (e TimeRecordVoidedEvent) Version() int {
+	return e.EventHeader.Version
+}
+
+// TimeRecordStatusChangedEvent is published for every lifecycle transition of
+// a record (check-out, correction, void, lock), alongside the event that
+// carries the hours
+type TimeRecordStatusChangedEvent struct {
+	EventHeader
+	EmployeeID string    `json:"employee_id"`
+	RecordID   string    `json:"record_id"`
+	Transition string    `json:"transition"`
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+	ChangedAt  time.Time `json:"changed_at"`
+	Actor      string    `json:"actor,omitempty"`
+}
+
+func (e TimeRecordStatusChangedEvent) EventType() string {
+	return EventTypeTimeRecordStatusChanged
+}
+
+func (e TimeRecordStatusChangedEvent) OccurredAt() time.Time {
+	return e.Timestamp
+}
+
+func (e TimeRecordStatusChangedEvent) Version() int {
+	return e.EventHeader.Version
+}
+
+// EmployeeMissedCheckoutEvent is published once for a record still open past
+// the expected end of the shift, so the employee can be asked to confirm when
+// they stopped working
+type EmployeeMissedCheckoutEvent struct {
+	EventHeader
+	EmployeeID         string    `json:"employee_id"`
+	RecordID           string    `json:"record_id"`
+	CheckInAt          time.Time `json:"check_in_at"`
+	ExpectedCheckOutAt time.Time `json:"expected_check_out_at"`
+	LocationID         string    `json:"location_id,omitempty"`
+	TimeZone           string    `json:"time_zone,omitempty"`
+}
+
+func (e EmployeeMissedCheckoutEvent) EventType() string {
+	return EventTypeEmployeeMissedCheckout
+}
+
+func (e EmployeeMissedCheckoutEvent) OccurredAt() time.Time {
+	return e.Timestamp
+}
+
+func (e EmployeeMissedCheckoutEvent) Version() int {
+	return e.EventHeader.Version
+}
+
+// EmployeeNoShowEvent tells the manager that the employee never checked in
+// for a scheduled shift
+type EmployeeNoShowEvent struct {
+	EventHeader
+	ExceptionID    string    `json:"exception_id"`
+	EmployeeID     string    `json:"employee_id"`
+	ManagerID      string    `json:"manager_id,omitempty"`
+	BusinessDate   string    `json:"business_date"`
+	ScheduledStart time.Time `json:"scheduled_start"`
+	ScheduledEnd   time.Time `json:"scheduled_end"`
+	LocationID     string    `json:"location_id,omitempty"`
+	TimeZone       string    `json:"time_zone,omitempty"`
+}
+
+func (e EmployeeNoShowEvent) EventType() string {
+	return EventTypeEmployeeNoShow
+}
+
+func (e EmployeeNoShowEvent) OccurredAt() time.Time {
+	return e.Timestamp
+}
+
+func (e EmployeeNoShowEvent) Version() int {
+	return e.EventHeader.Version
+}
@@ -0,0 +1,257 @@
+package events
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Every event type and version published has a JSON Schema in schemas/,
+// named <EventType>.v<Version>.json. Schemas are the contract with
+// consumers: add a new version instead of changing a published one.
+//
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+// schema is the subset of JSON Schema (draft 2020-12) the event schemas use:
+// type, required, properties, items, enum, const, format (date-time, date),
+// minLength, minimum and local $ref to #/$defs. Unknown keywords are ignored
+// and unknown properties allowed, so consumers accept fields added later.
+type schema struct {
+	Type       schemaTypes        `json:"type"`
+	Required   []string           `json:"required"`
+	Properties map[string]*schema `json:"properties"`
+	Items      *schema            `json:"items"`
+	Enum       []interface{}      `json:"enum"`
+	Const      interface{}        `json:"const"`
+	Format     string             `json:"format"`
+	MinLength  *int               `json:"minLength"`
+	Minimum    *float64           `json:"minimum"`
+	Ref        string             `json:"$ref"`
+	Defs       map[string]*schema `json:"$defs"`
+}
+
+// schemaTypes is the type keyword, a single type or a list of them
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = schemaTypes{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*t = many
+	return nil
+}
+
+// schemas maps <EventType>.v<Version> to its parsed schema
+var schemas = loadSchemas()
+
+func loadSchemas() map[string]*schema {
+	files, err := schemaFiles.ReadDir("schemas")
+	if err != nil {
+		panic(fmt.Sprintf("events: read schemas: %v", err))
+	}
+
+	loaded := make(map[string]*schema, len(files))
+	for _, file := range files {
+		data, err := schemaFiles.ReadFile(path.Join("schemas", file.Name()))
+		if err != nil {
+			panic(fmt.Sprintf("events: read schema %s: %v", file.Name(), err))
+		}
+		var s schema
+		if err := json.Unmarshal(data, &s); err != nil {
+			panic(fmt.Sprintf("events: parse schema %s: %v", file.Name(), err))
+		}
+		loaded[strings.TrimSuffix(file.Name(), ".json")] = &s
+	}
+	return loaded
+}
+
+// SchemaError is a payload that does not match the schema of its event type
+// and version, or that has no schema
+type SchemaError struct {
+	EventType string
+	Version   int
+	Problems  []string
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("%s v%d does not match its schema: %s", e.EventType, e.Version, strings.Join(e.Problems, "; "))
+}
+
+// HasSchema reports whether a version of an event type has a schema
+func HasSchema(eventType string, version int) bool {
+	_, ok := schemas[schemaName(eventType, version)]
+	return ok
+}
+
+func schemaName(eventType string, version int) string {
+	return fmt.Sprintf("%s.v%d", eventType, version)
+}
+
+// ValidatePayload checks a serialized event against the schema of the type
+// and version in its header. It returns a *SchemaError for payloads that do
+// not match, or whose type and version have no schema.
+func ValidatePayload(data []byte) error {
+	// Only what picks the schema: the schema reports on the other fields
+	var header struct {
+		EventType string `json:"event_type"`
+		Version   int    `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return &SchemaError{Problems: []string{"not an event: " + err.Error()}}
+	}
+
+	s, ok := schemas[schemaName(header.EventType, header.Version)]
+	if !ok {
+		return &SchemaError{EventType: header.EventType, Version: header.Version, Problems: []string{"no schema for this event type and version"}}
+	}
+
+	var payload interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&payload); err != nil {
+		return &SchemaError{EventType: header.EventType, Version: header.Version, Problems: []string{err.Error()}}
+	}
+
+	v := validation{root: s}
+	v.check(s, payload, "$")
+	if len(v.problems) > 0 {
+		return &SchemaError{EventType: header.EventType, Version: header.Version, Problems: v.problems}
+	}
+	return nil
+}
+
+// validation collects every problem of one payload, with its JSON path
+type validation struct {
+	root     *schema
+	problems []string
+}
+
+func (v *validation) fail(at, format string, args ...interface{}) {
+	v.problems = append(v.problems, at+": "+fmt.Sprintf(format, args...))
+}
+
+func (v *validation) check(s *schema, value interface{}, at string) {
+	if s.Ref != "" {
+		name, ok := strings.CutPrefix(s.Ref, "#/$defs/")
+		def := v.root.Defs[name]
+		if !ok || def == nil {
+			v.fail(at, "unresolved $ref %s", s.Ref)
+			return
+		}
+		s = def
+	}
+
+	if len(s.Type) > 0 && !slices.ContainsFunc(s.Type, func(t string) bool { return hasType(value, t) }) {
+		v.fail(at, "is %s, want %s", typeOf(value), strings.Join(s.Type, " or "))
+		return
+	}
+	if s.Const != nil && !sameValue(value, s.Const) {
+		v.fail(at, "must be %v", s.Const)
+	}
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(allowed interface{}) bool { return sameValue(value, allowed) }) {
+		v.fail(at, "must be one of %v", s.Enum)
+	}
+
+	switch value := value.(type) {
+	case string:
+		if s.MinLength != nil && len([]rune(value)) < *s.MinLength {
+			v.fail(at, "must be at least %d characters", *s.MinLength)
+		}
+		if !validFormat(s.Format, value) {
+			v.fail(at, "is not a valid %s", s.Format)
+		}
+	case json.Number:
+		if n, _ := value.Float64(); s.Minimum != nil && n < *s.Minimum {
+			v.fail(at, "must be at least %v", *s.Minimum)
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := value[name]; !ok {
+				v.fail(at, "missing required property %s", name)
+			}
+		}
+		for _, name := range slices.Sorted(maps.Keys(s.Properties)) {
+			if field, ok := value[name]; ok {
+				v.check(s.Properties[name], field, at+"."+name)
+			}
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range value {
+				v.check(s.Items, item, fmt.Sprintf("%s[%d]", at, i))
+			}
+		}
+	}
+}
+
+func hasType(value interface{}, t string) bool {
+	switch t {
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		f, err := n.Float64()
+		return err == nil && f == math.Trunc(f)
+	default:
+		return typeOf(value) == t
+	}
+}
+
+func typeOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// sameValue compares a payload value with one from a schema, where numbers
+// were decoded as float64
+func sameValue(value, want interface{}) bool {
+	if n, ok := value.(json.Number); ok {
+		f, err := n.Float64()
+		wantF, isNumber := want.(float64)
+		return err == nil && isNumber && f == wantF
+	}
+	switch value.(type) {
+	case string, bool, nil:
+		return value == want
+	}
+	return false
+}
+
+func validFormat(format, value string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339Nano, value)
+		return err == nil
+	case "date":
+		_, err := time.Parse(time.DateOnly, value)
+		return err == nil
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+// Package hours computes payable hours from check-in/check-out times through
+// a chain of policies (breaks, rounding, day/night splits). Every step is
+// recorded so the result can be audited later.
+package hours
+
+import (
+	"math"
+	"time"
+)
+
+// Inputs are the facts a calculation starts from
+type Inputs struct {
+	CheckInAt  time.Time `json:"check_in_at"`
+	CheckOutAt time.Time `json:"check_out_at"`
+	Policies   []string  `json:"policies"` // Descriptions of the applied policy chain
+}
+
+// Step records the effect of a single policy
+type Step struct {
+	Policy      string  `json:"policy"`
+	Description string  `json:"description"`
+	HoursBefore float64 `json:"hours_before"`
+	HoursAfter  float64 `json:"hours_after"`
+}
+
+// Calculation is the outcome of running the policy chain
+type Calculation struct {
+	Inputs             Inputs  `json:"inputs"`
+	GrossHours         float64 `json:"gross_hours"`
+	BreakHours         float64 `json:"break_hours"`
+	RoundingAdjustment float64 `json:"rounding_adjustment"`
+	PayableHours       float64 `json:"payable_hours"`
+	DayHours           float64 `json:"day_hours"`
+	NightHours         float64 `json:"night_hours"`
+	Steps              []Step  `json:"steps"`
+}
+
+// Policy adjusts a calculation in place
+type Policy interface {
+	Name() string
+	Describe() string
+	Apply(c *Calculation)
+}
+
+// Calculator runs policies in order. The usual chain is breaks, then
+// rounding, then splits, since splits distribute the final payable hours.
+type Calculator struct {
+	policies []Policy
+}
+
+func NewCalculator(policies ...Policy) *Calculator {
+	return &Calculator{policies: policies}
+}
+
+func (calc *Calculator) Calculate(checkInAt, checkOutAt time.Time) *Calculation {
+	gross := checkOutAt.Sub(checkInAt).Hours()
+	if gross < 0 {
+		gross = 0
+	}
+
+	c := &Calculation{
+		Inputs: Inputs{
+			CheckInAt:  checkInAt,
+			CheckOutAt: checkOutAt,
+			Policies:   make([]string, 0, len(calc.policies)),
+		},
+		GrossHours:   gross,
+		PayableHours: gross,
+		DayHours:     gross,
+		Steps:        make([]Step, 0, len(calc.policies)),
+	}
+
+	for _, policy := range calc.policies {
+		c.Inputs.Policies = append(c.Inputs.Policies, policy.Describe())
+		policy.Apply(c)
+	}
+
+	return c
+}
+
+func (c *Calculation) record(policy Policy, description string, before float64) {
+	c.Steps = append(c.Steps, Step{
+		Policy:      policy.Name(),
+		Description: description,
+		HoursBefore: before,
+		HoursAfter:  c.PayableHours,
+	})
+}
+
+// rebalanceDayHours keeps day + night hours equal to the payable hours when
+// an earlier policy changed the payable hours
+func (c *Calculation) rebalanceDayHours() {
+	c.DayHours = math.Max(c.PayableHours-c.NightHours, 0)
+}
@@ -0,0 +1,97 @@
+package hours
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Pay period frequencies
+const (
+	PayWeekly      = "weekly"
+	PayBiweekly    = "biweekly"
+	PaySemimonthly = "semimonthly"
+)
+
+// PaySchedule divides the calendar into pay periods. Weekly and bi-weekly
+// periods repeat from Anchor, the first day of any period; semi-monthly
+// periods start on the two SemimonthlyDays of every month.
+type PaySchedule struct {
+	Frequency       string
+	Anchor          time.Time
+	SemimonthlyDays [2]int
+}
+
+// NewPaySchedule validates a schedule. Weekly periods without an anchor start
+// on weekStart; bi-weekly periods need one to know which week they start in.
+func NewPaySchedule(frequency, anchor string, semimonthlyDays []int, weekStart time.Weekday) (PaySchedule, error) {
+	schedule := PaySchedule{Frequency: frequency}
+
+	switch frequency {
+	case PayWeekly, PayBiweekly:
+		if anchor == "" {
+			if frequency == PayBiweekly {
+				return PaySchedule{}, errors.New("bi-weekly pay periods need an anchor date")
+			}
+			// 2024-01-01 was a Monday
+			monday := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			schedule.Anchor = monday.AddDate(0, 0, (int(weekStart)-int(time.Monday)+7)%7)
+			return schedule, nil
+		}
+		t, err := time.Parse(dateLayout, anchor)
+		if err != nil {
+			return PaySchedule{}, fmt.Errorf("invalid anchor %q: expected YYYY-MM-DD", anchor)
+		}
+		schedule.Anchor = t
+
+	case PaySemimonthly:
+		if len(semimonthlyDays) != 2 || semimonthlyDays[0] < 1 || semimonthlyDays[0] >= semimonthlyDays[1] || semimonthlyDays[1] > 28 {
+			return PaySchedule{}, errors.New("semi-monthly pay periods need two increasing days of the month between 1 and 28")
+		}
+		schedule.SemimonthlyDays = [2]int{semimonthlyDays[0], semimonthlyDays[1]}
+
+	default:
+		return PaySchedule{}, fmt.Errorf("unknown pay period frequency %q: expected weekly, biweekly or semimonthly", frequency)
+	}
+
+	return schedule, nil
+}
+
+// Bounds returns the half-open interval [start, end) of the pay period
+// containing t, in t's location
+func (p PaySchedule) Bounds(t time.Time) (time.Time, time.Time) {
+	day := StartOfDay(t)
+
+	if p.Frequency == PaySemimonthly {
+		first, second := p.SemimonthlyDays[0], p.SemimonthlyDays[1]
+		date := func(months, d int) time.Time {
+			return time.Date(day.Year(), day.Month()+time.Month(months), d, 0, 0, 0, 0, day.Location())
+		}
+		switch {
+		case day.Day() >= second:
+			return date(0, second), date(1, first)
+		case day.Day() >= first:
+			return date(0, first), date(0, second)
+		default:
+			return date(-1, second), date(0, first)
+		}
+	}
+
+	length := 7
+	if p.Frequency == PayBiweekly {
+		length = 14
+	}
+	anchor := time.Date(p.Anchor.Year(), p.Anchor.Month(), p.Anchor.Day(), 0, 0, 0, 0, day.Location())
+	// Count calendar days, not hours, so DST changes do not shift periods
+	days := civilDay(day) - civilDay(anchor)
+	periods := days / length
+	if days%length < 0 {
+		periods--
+	}
+	start := anchor.AddDate(0, 0, periods*length)
+	return start, start.AddDate(0, 0, length)
+}
+
+func civilDay(t time.Time) int {
+	return int(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400)
+}
@@ -0,0 +1,107 @@
+package hours
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// StartOfDay returns local midnight of t's day in t's location
+func StartOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// StartOfWeek returns local midnight of the first day of t's week, for weeks
+// starting on weekStart (time.Monday for ISO 8601 weeks)
+func StartOfWeek(t time.Time, weekStart time.Weekday) time.Time {
+	day := StartOfDay(t)
+	offset := (int(day.Weekday()) - int(weekStart) + 7) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// StartOfMonth returns local midnight of the first day of t's month
+func StartOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// Reporting periods
+const (
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+	// The configured pay period, see PaySchedule; PeriodBounds does not know it
+	PeriodPay = "pay"
+)
+
+// PeriodBounds returns the half-open interval [start, end) of the day, week
+// or month containing t, in t's location
+func PeriodBounds(period string, t time.Time, weekStart time.Weekday) (time.Time, time.Time, error) {
+	switch period {
+	case PeriodDay:
+		start := StartOfDay(t)
+		return start, start.AddDate(0, 0, 1), nil
+	case PeriodWeek:
+		start := StartOfWeek(t, weekStart)
+		return start, start.AddDate(0, 0, 7), nil
+	case PeriodMonth:
+		start := StartOfMonth(t)
+		return start, start.AddDate(0, 1, 0), nil
+	default:
+		return time.Time{}, time.Time{}, fmt.Errorf("unknown period %q: expected day, week or month", period)
+	}
+}
+
+// PeriodLabel names the period starting at start: the week label, the
+// month as YYYY-MM, or the start date for days and pay periods
+func PeriodLabel(period string, start time.Time) string {
+	switch period {
+	case PeriodMonth:
+		return start.Format("2006-01")
+	case PeriodDay, PeriodPay:
+		return start.Format(dateLayout)
+	}
+	return WeekLabel(start)
+}
+
+// WeekLabel names the week starting at start: the ISO 8601 week (e.g.
+// "2026-W07") for Monday weeks, otherwise the start date
+func WeekLabel(start time.Time) string {
+	if start.Weekday() != time.Monday {
+		return start.Format(dateLayout)
+	}
+	year, week := start.ISOWeek()
+	return fmt.Sprintf("%04d-W%02d", year, week)
+}
+
+// ParseDateRange parses an inclusive range of dates (YYYY-MM-DD) or RFC 3339
+// timestamps into the half-open interval [from, to) in loc
+func ParseDateRange(from, to string, loc *time.Location) (time.Time, time.Time, error) {
+	start, _, err := parseBound(from, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid from: %w", err)
+	}
+	end, isDate, err := parseBound(to, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid to: %w", err)
+	}
+	if isDate {
+		end = end.AddDate(0, 0, 1)
+	}
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, errors.New("to must be after from")
+	}
+	return start, end, nil
+}
+
+func parseBound(value string, loc *time.Location) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation(dateLayout, value, loc); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("expected YYYY-MM-DD or RFC 3339, got %q", value)
+	}
+	return t.In(loc), false, nil
+}
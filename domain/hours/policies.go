@@ -0,0 +1,137 @@
+package hours
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// BreakPolicy deducts an unpaid break from shifts of at least MinShiftHours
+type BreakPolicy struct {
+	MinShiftHours float64
+	BreakMinutes  int
+}
+
+func (p BreakPolicy) Name() string { return "breaks" }
+
+func (p BreakPolicy) Describe() string {
+	return fmt.Sprintf("breaks: deduct %d min from shifts of %.2fh or more", p.BreakMinutes, p.MinShiftHours)
+}
+
+func (p BreakPolicy) Apply(c *Calculation) {
+	before := c.PayableHours
+	if p.BreakMinutes <= 0 || c.GrossHours < p.MinShiftHours {
+		c.record(p, "no break deducted", before)
+		return
+	}
+
+	c.BreakHours = math.Min(float64(p.BreakMinutes)/60.0, c.PayableHours)
+	c.PayableHours -= c.BreakHours
+	c.rebalanceDayHours()
+	c.record(p, fmt.Sprintf("deducted %.2fh break", c.BreakHours), before)
+}
+
+type RoundingMode string
+
+const (
+	RoundNearest RoundingMode = "nearest"
+	RoundUp      RoundingMode = "up"
+	RoundDown    RoundingMode = "down"
+)
+
+// RoundingPolicy rounds payable hours to a fixed increment (e.g. 15 minutes)
+type RoundingPolicy struct {
+	IncrementMinutes int
+	Mode             RoundingMode
+}
+
+func (p RoundingPolicy) Name() string { return "rounding" }
+
+func (p RoundingPolicy) Describe() string {
+	return fmt.Sprintf("rounding: %s %d min", p.Mode, p.IncrementMinutes)
+}
+
+func (p RoundingPolicy) Apply(c *Calculation) {
+	before := c.PayableHours
+	if p.IncrementMinutes <= 0 {
+		c.record(p, "no rounding", before)
+		return
+	}
+
+	increments := c.PayableHours * 60 / float64(p.IncrementMinutes)
+	switch p.Mode {
+	case RoundUp:
+		increments = math.Ceil(increments)
+	case RoundDown:
+		increments = math.Floor(increments)
+	default:
+		increments = math.Round(increments)
+	}
+
+	c.PayableHours = increments * float64(p.IncrementMinutes) / 60
+	c.RoundingAdjustment = c.PayableHours - before
+	c.rebalanceDayHours()
+	c.record(p, fmt.Sprintf("rounded by %+.2fh", c.RoundingAdjustment), before)
+}
+
+// NightSplitPolicy splits payable hours into day and night hours, in
+// proportion to the part of the shift between NightStartHour and NightEndHour
+// (e.g. 22 to 6) in the check-in's time zone
+type NightSplitPolicy struct {
+	NightStartHour int
+	NightEndHour   int
+}
+
+func (p NightSplitPolicy) Name() string { return "splits" }
+
+func (p NightSplitPolicy) Describe() string {
+	return fmt.Sprintf("splits: night from %02d:00 to %02d:00", p.NightStartHour, p.NightEndHour)
+}
+
+func (p NightSplitPolicy) Apply(c *Calculation) {
+	before := c.PayableHours
+	if c.GrossHours == 0 || p.NightStartHour == p.NightEndHour {
+		c.record(p, "no night split", before)
+		return
+	}
+
+	nightGross := p.nightOverlap(c.Inputs.CheckInAt, c.Inputs.CheckOutAt).Hours()
+	c.NightHours = c.PayableHours * nightGross / c.GrossHours
+	c.DayHours = c.PayableHours - c.NightHours
+	c.record(p, fmt.Sprintf("%.2fh day, %.2fh night", c.DayHours, c.NightHours), before)
+}
+
+// nightOverlap returns how much of [from, to) falls into night windows
+func (p NightSplitPolicy) nightOverlap(from, to time.Time) time.Duration {
+	var total time.Duration
+
+	// Walk the days the shift touches, starting the day before so a night
+	// window that began yesterday evening is included
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location()).AddDate(0, 0, -1)
+	for !day.After(to) {
+		start := day.Add(time.Duration(p.NightStartHour) * time.Hour)
+		end := day.Add(time.Duration(p.NightEndHour) * time.Hour)
+		if p.NightEndHour < p.NightStartHour {
+			end = end.AddDate(0, 0, 1)
+		}
+		total += overlap(from, to, start, end)
+		day = day.AddDate(0, 0, 1)
+	}
+
+	return total
+}
+
+func overlap(aStart, aEnd, bStart, bEnd time.Time) time.Duration {
+	start := aStart
+	if bStart.After(start) {
+		start = bStart
+	}
+	end := aEnd
+	if bEnd.Before(end) {
+		end = bEnd
+	}
+	if !end.After(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
@@ -0,0 +1,24 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/events"
+)
+
+// ApprovalRepository stores approvals next to the employee's time records, so
+// a decision and the record change it causes commit together
+type ApprovalRepository interface {
+	// SaveWithEvent saves a new approval and its notification event in one transaction
+	SaveWithEvent(ctx context.Context, approval *entities.Approval, event events.DomainEvent) error
+	// FindByID returns (nil, nil) for unknown IDs
+	FindByID(ctx context.Context, id string) (*entities.Approval, error)
+	// FindPending lists pending approvals, only those of one manager when managerID is set
+	FindPending(ctx context.Context, managerID string) ([]*entities.Approval, error)
+	// SaveDecision saves the decided approval, the record it changes (nil when
+	// rejected), audit entries and events in one transaction. It only
+	// decides approvals that are still pending in the store, and returns
+	// ErrAlreadyDecided without saving anything otherwise.
+	SaveDecision(ctx context.Context, approval *entities.Approval, record *entities.TimeRecord, entries []*entities.AuditEntry, evts []events.DomainEvent) error
+}
@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+// AuditRepository reads the audit log for verification and keeps its anchors.
+// Entries themselves are written together with the records they describe.
+type AuditRepository interface {
+	FindAll(ctx context.Context) ([]*entities.AuditEntry, error)
+	// FindByRecords returns the entries of the given records of an employee, oldest first
+	FindByRecords(ctx context.Context, employeeID string, recordIDs []string) ([]*entities.AuditEntry, error)
+	SaveAnchor(ctx context.Context, anchor *entities.AuditAnchor) error
+	// FindAnchors returns all anchors, oldest first
+	FindAnchors(ctx context.Context) ([]*entities.AuditAnchor, error)
+}
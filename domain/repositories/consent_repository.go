@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+// ConsentRepository stores the consent state of employees per purpose.
+// Implementations return (nil, nil) when no consent has been recorded.
+type ConsentRepository interface {
+	Save(ctx context.Context, consent *entities.Consent) error
+	FindByEmployeeAndPurpose(ctx context.Context, employeeID string, purpose entities.ConsentPurpose) (*entities.Consent, error)
+	FindByEmployee(ctx context.Context, employeeID string) ([]*entities.Consent, error)
+}
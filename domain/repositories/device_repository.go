@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+// DeviceRepository stores check-in kiosks. FindByID returns (nil, nil) for unknown IDs.
+type DeviceRepository interface {
+	Save(ctx context.Context, device *entities.Device) error
+	FindByID(ctx context.Context, id string) (*entities.Device, error)
+	FindAll(ctx context.Context) ([]*entities.Device, error)
+}
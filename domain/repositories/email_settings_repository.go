@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+// EmailSettingsRepository stores how notification emails are customized
+type EmailSettingsRepository interface {
+	// FindCheckOutEmail returns nil when the summary was never customized
+	FindCheckOutEmail(ctx context.Context) (*entities.CheckOutEmailSettings, error)
+	SaveCheckOutEmail(ctx context.Context, settings *entities.CheckOutEmailSettings) error
+}
@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/events"
+)
+
+// EmployeeMergeRepository re-attributes an employee's data to another ID and
+// keeps the old-to-new mapping for historical queries
+type EmployeeMergeRepository interface {
+	// Merge moves records, notes, calculations, audit entries and consents from
+	// the source to the target ID and stores the event in the target's outbox.
+	// It returns the number of time records moved. Re-running a merge is safe.
+	Merge(ctx context.Context, merge *entities.EmployeeMerge, event events.DomainEvent) (int, error)
+	// ResolveEmployeeID returns the canonical ID for a merged ID, or the ID itself
+	ResolveEmployeeID(ctx context.Context, employeeID string) (string, error)
+}
@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+// EmployeeRepository stores the employee roster. FindByID returns (nil, nil) for unknown IDs.
+type EmployeeRepository interface {
+	Save(ctx context.Context, employee *entities.Employee) error
+	FindByID(ctx context.Context, id string) (*entities.Employee, error)
+	// FindByEmail matches the address case-insensitively; (nil, nil) when no one has it
+	FindByEmail(ctx context.Context, email string) (*entities.Employee, error)
+	FindAll(ctx context.Context) ([]*entities.Employee, error)
+	// Delete removes the employee from the roster; their time records are kept
+	Delete(ctx context.Context, id string) error
+}
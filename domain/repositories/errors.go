@@ -0,0 +1,15 @@
+package repositories
+
+import "errors"
+
+var (
+	// ErrRecordNotFound is returned by lookups that expect a row, e.g.
+	// FindByID, when there is none; other failures are database errors
+	ErrRecordNotFound = errors.New("record not found")
+	// ErrConflict is returned by writes rejected by a unique constraint, e.g.
+	// a second timesheet of an employee for the same pay period
+	ErrConflict = errors.New("conflicting write")
+	// ErrAlreadyDecided is returned by ApprovalRepository.SaveDecision when
+	// the approval was decided by someone else since it was loaded
+	ErrAlreadyDecided = errors.New("approval already decided")
+)
@@ -0,0 +1,16 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+// HolidayRepository stores the company holiday calendar. Dates are YYYY-MM-DD.
+type HolidayRepository interface {
+	Save(ctx context.Context, holiday *entities.Holiday) error
+	// Delete returns false when there was no holiday on that date
+	Delete(ctx context.Context, date string) (bool, error)
+	// FindInRange returns the holidays from one date to another, both inclusive
+	FindInRange(ctx context.Context, from, to string) ([]*entities.Holiday, error)
+}
@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"context"
+	"time"
+)
+
+// IdempotencyRecord is a request or message seen under an idempotency key.
+// StatusCode is 0 while the first request for the key is still running.
+type IdempotencyRecord struct {
+	Key         string
+	Fingerprint string // Hash of the request, to reject a key reused for another one
+	StatusCode  int
+	ContentType string
+	Body        []byte
+	ExpiresAt   time.Time
+}
+
+// Pending reports whether the first request has not finished yet
+func (r *IdempotencyRecord) Pending() bool {
+	return r.StatusCode == 0
+}
+
+// IdempotencyRepository is the deduplication log shared by all API instances
+type IdempotencyRepository interface {
+	// Reserve claims key until expiresAt. It returns nil when the key was free
+	// or its previous claim had expired, otherwise the existing record.
+	Reserve(ctx context.Context, key, fingerprint string, expiresAt time.Time) (*IdempotencyRecord, error)
+	// Complete stores the response for key and keeps it until expiresAt
+	Complete(ctx context.Context, key string, statusCode int, contentType string, body []byte, expiresAt time.Time) error
+	// Release frees a key whose request failed so it can be retried
+	Release(ctx context.Context, key string) error
+	// DeleteExpired removes keys that expired before now
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
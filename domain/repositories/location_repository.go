@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+// LocationRepository stores company sites. FindByID returns (nil, nil) for unknown IDs.
+type LocationRepository interface {
+	Save(ctx context.Context, location *entities.Location) error
+	FindByID(ctx context.Context, id string) (*entities.Location, error)
+	FindAll(ctx context.Context) ([]*entities.Location, error)
+}
@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+// NoteRepository stores notes on time records, next to the employee's records
+type NoteRepository interface {
+	Save(ctx context.Context, note *entities.RecordNote) error
+	// FindByRecords returns the notes of the given records of an employee, oldest first
+	FindByRecords(ctx context.Context, employeeID string, recordIDs []string) ([]*entities.RecordNote, error)
+}
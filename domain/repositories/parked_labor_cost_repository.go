@@ -0,0 +1,27 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+// ParkedLaborCostRepository holds the labor cost entries that wait for the
+// payroll system to come back, shared by all worker instances
+type ParkedLaborCostRepository interface {
+	// Park stores an entry; an entry already parked under the same
+	// idempotency key is kept as it is
+	Park(ctx context.Context, parked *entities.ParkedLaborCost) error
+	// ListParked returns up to limit entries to submit, oldest first,
+	// skipping those marked failed
+	ListParked(ctx context.Context, limit int) ([]*entities.ParkedLaborCost, error)
+	// DeleteParked forgets an entry once it was submitted
+	DeleteParked(ctx context.Context, id int64) error
+	// RecordParkedAttempt counts a submission that failed and may be retried
+	RecordParkedAttempt(ctx context.Context, id int64, lastError string) error
+	// MarkParkedFailed stops submitting an entry the backend refused; it
+	// stays for an operator to look at
+	MarkParkedFailed(ctx context.Context, id int64, lastError string) error
+	// CountParked returns the entries waiting to be submitted
+	CountParked(ctx context.Context) (int, error)
+}
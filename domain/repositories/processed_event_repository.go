@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+	"time"
+)
+
+// ProcessedEventRepository is the inbox of the RabbitMQ consumers: the events
+// each consumer has already handled, shared by all worker instances
+type ProcessedEventRepository interface {
+	// ProcessOnce runs fn unless consumer already processed eventID, and
+	// records the event only when fn succeeds. A concurrent delivery of the
+	// same event waits for the first one and is then skipped. No transaction
+	// is held while fn runs. It reports whether fn ran.
+	ProcessOnce(ctx context.Context, consumer, eventID string, fn func(ctx context.Context) error) (bool, error)
+	// DeleteProcessedBefore forgets the events processed before cutoff
+	DeleteProcessedBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+// ProjectRepository stores project/cost codes. FindByCode returns (nil, nil) for unknown codes.
+type ProjectRepository interface {
+	Save(ctx context.Context, project *entities.Project) error
+	FindByCode(ctx context.Context, code string) (*entities.Project, error)
+	FindAll(ctx context.Context) ([]*entities.Project, error)
+}
@@ -0,0 +1,41 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+// RecordChangeRepository reads the change log of time records, which the
+// database writes itself as records are inserted, updated or deleted
+type RecordChangeRepository interface {
+	// FindByRecordID returns the changes of a record, oldest first
+	FindByRecordID(ctx context.Context, recordID string) ([]*entities.RecordChange, error)
+}
+
+// ChangeOrigin tells who made the writes done with a context, and through
+// which part of the service, for the change log
+type ChangeOrigin struct {
+	Actor  string // e.g. "admin:alice", "device:kiosk-1"
+	Source string // e.g. "command:check_in", "approval", "repair"
+}
+
+type changeOriginKey struct{}
+
+// WithChangeOrigin attaches the origin of the time record writes done with ctx
+func WithChangeOrigin(ctx context.Context, origin ChangeOrigin) context.Context {
+	return context.WithValue(ctx, changeOriginKey{}, origin)
+}
+
+// ChangeOriginFrom returns the origin of ctx; writes without one are logged
+// as made by the system
+func ChangeOriginFrom(ctx context.Context) ChangeOrigin {
+	origin, _ := ctx.Value(changeOriginKey{}).(ChangeOrigin)
+	if origin.Actor == "" {
+		origin.Actor = "system"
+	}
+	if origin.Source == "" {
+		origin.Source = "system"
+	}
+	return origin
+}
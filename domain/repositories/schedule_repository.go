@@ -0,0 +1,28 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/events"
+)
+
+// ScheduleRepository stores the weekly shift schedule of employees
+type ScheduleRepository interface {
+	// ReplaceForEmployee swaps the employee's whole schedule for shifts
+	ReplaceForEmployee(ctx context.Context, employeeID string, shifts []*entities.ScheduledShift) error
+	FindByEmployee(ctx context.Context, employeeID string) ([]*entities.ScheduledShift, error)
+	// FindByWeekday returns the shifts of every employee on that weekday
+	FindByWeekday(ctx context.Context, weekday time.Weekday) ([]*entities.ScheduledShift, error)
+}
+
+// ShiftExceptionRepository stores exceptions next to the employee's time
+// records, with the event notifying their manager
+type ShiftExceptionRepository interface {
+	// SaveWithEvent returns false, saving nothing, when the exception was
+	// already recorded for that shift
+	SaveWithEvent(ctx context.Context, exception *entities.ShiftException, event events.DomainEvent) (bool, error)
+	// FindByDate returns the exceptions of shifts scheduled on the local date
+	FindByDate(ctx context.Context, date string) ([]*entities.ShiftException, error)
+}
@@ -0,0 +1,50 @@
+package repositories
+
+import (
+	"context"
+	"time"
+)
+
+// Search result types
+const (
+	SearchTypeRecord = "record"
+	SearchTypeAudit  = "audit"
+)
+
+// SearchQuery filters time records and audit entries. Text filters match
+// anywhere in the value, case-insensitively; empty fields match everything.
+type SearchQuery struct {
+	Text       string // Employee ID, device ID, note text or audit reason
+	EmployeeID string
+	DeviceID   string    // Check-in or check-out device of the record
+	Note       string    // Text of a note on the record
+	From, To   time.Time // [From, To) on check-in time for records and creation time for audit entries; zero for no bound
+	Types      []string  // Result types to search, all when empty
+}
+
+// SearchHit is one record or audit entry matching a search. Audit entries
+// match the device and note filters through the record they belong to.
+type SearchHit struct {
+	Type       string
+	ID         string
+	EmployeeID string
+	RecordID   string
+	At         time.Time // Check-in time of a record, creation time of an audit entry
+	Status     string    // Records only
+	DeviceID   string    // Records only
+	Action     string    // Audit entries only
+	Actor      string    // Audit entries only
+	Reason     string    // Audit entries only
+}
+
+// SearchCursor is the keyset position of a hit in (at, id) descending order
+type SearchCursor struct {
+	At time.Time `json:"t"`
+	ID string    `json:"id"`
+}
+
+type SearchRepository interface {
+	// Search returns up to limit hits after the cursor (nil for the first
+	// page), newest first
+	Search(ctx context.Context, query SearchQuery, after *SearchCursor, limit int) ([]SearchHit, error)
+}
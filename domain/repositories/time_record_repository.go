@@ -11,15 +11,183 @@ import (
 type TimeRecordRepository interface {
 	Save(ctx context.Context, record *entities.TimeRecord) error
 	SaveWithEvent(ctx context.Context, record *entities.TimeRecord, event events.DomainEvent) error
+	SaveWithEvents(ctx context.Context, record *entities.TimeRecord, evts []events.DomainEvent) error
 	FindActiveByEmployeeID(ctx context.Context, employeeID string) (*entities.TimeRecord, error)
+	// FindByID returns ErrRecordNotFound when there is no record with the ID
 	FindByID(ctx context.Context, id string) (*entities.TimeRecord, error)
+	// FindByEmployeeInRange returns the records of an employee overlapping [from, to), oldest first
+	FindByEmployeeInRange(ctx context.Context, employeeID string, from, to time.Time) ([]*entities.TimeRecord, error)
+	// FindPageByEmployeeInRange returns up to limit records of FindByEmployeeInRange
+	// that sort after the cursor (nil for the first page), optionally only those
+	// checked in or out through source
+	FindPageByEmployeeInRange(ctx context.Context, employeeID string, from, to time.Time, source entities.PunchSource, after *RecordCursor, limit int) ([]*entities.TimeRecord, error)
+	// FindActive returns all checked-in records, optionally filtered by location
+	FindActive(ctx context.Context, locationID string) ([]*entities.TimeRecord, error)
+	// SumHoursWorked returns the hours of completed records of an employee that started in [from, to)
+	SumHoursWorked(ctx context.Context, employeeID string, from, to time.Time) (float64, error)
+	// AggregateHours sums completed records of all employees that checked in
+	// during [from, to), per employee, location and week or month in timeZone,
+	// optionally only those checked in or out through source
+	AggregateHours(ctx context.Context, from, to time.Time, timeZone, period string, weekStart time.Weekday, source entities.PunchSource) ([]HoursAggregate, error)
+	// SaveAllWithAudit saves the records, their audit entries and events in a single transaction
+	SaveAllWithAudit(ctx context.Context, records []*entities.TimeRecord, entries []*entities.AuditEntry, evts []events.DomainEvent) error
+	// SaveRepair is SaveAllWithAudit for changes computed from the records
+	// read: it locks them for update in its transaction and fails with
+	// ErrConflict, saving nothing, when any was changed or deleted since
+	SaveRepair(ctx context.Context, read, records []*entities.TimeRecord, entries []*entities.AuditEntry, evts []events.DomainEvent) error
+	// SaveTransfer saves the closed record of an employee and the record
+	// opened in its place, with their hours calculations, notes and events,
+	// in a single transaction; ErrConflict when the employee has another open
+	// record, and then neither record changes
+	SaveTransfer(ctx context.Context, closed, opened *entities.TimeRecord, evts []events.DomainEvent) error
+	// FindMissedCheckouts returns up to limit records per shard still checked in
+	// that started before openedBefore and were not flagged yet, oldest first
+	FindMissedCheckouts(ctx context.Context, openedBefore time.Time, limit int) ([]*entities.TimeRecord, error)
+	// MarkMissedCheckout flags the record and stores the event in one
+	// transaction; false when the record was flagged or checked out meanwhile
+	MarkMissedCheckout(ctx context.Context, record *entities.TimeRecord, event events.DomainEvent) (bool, error)
+	// SoftDelete hides the record from every read while keeping the row, and
+	// its audit entries, in storage; ErrRecordNotFound when there is no such
+	// record or it is already deleted
+	SoftDelete(ctx context.Context, id string) error
+	// Restore makes a soft-deleted record visible again; ErrRecordNotFound when
+	// there is no deleted record with the ID, ErrConflict when it is open and
+	// the employee has checked in again meanwhile
+	Restore(ctx context.Context, id string) error
+}
+
+// HoursAggregate sums the completed records of one employee at one location
+// that checked in during one period
+type HoursAggregate struct {
+	EmployeeID    string
+	LocationID    string // Empty for records without a location
+	PeriodStart   string // Local date the period starts on (YYYY-MM-DD)
+	Records       int
+	HoursWorked   float64
+	RegularHours  float64
+	OvertimeHours float64
+}
+
+// RecordCursor is the keyset position of a record in (check_in_at, id) order
+type RecordCursor struct {
+	CheckInAt time.Time `json:"t"`
+	ID        string    `json:"id"`
 }
 
 type OutboxRepository interface {
 	SaveEvent(ctx context.Context, event events.DomainEvent) error
-	GetUnpublishedEvents(ctx context.Context, limit int) ([]OutboxEvent, error)
-	MarkAsPublished(ctx context.Context, eventID string) error
-	IncrementRetryCount(ctx context.Context, eventID string, errorMsg string) error
+	OutboxReader
+}
+
+// OutboxReader is the part of the outbox the publisher polls; events are
+// written by the time record repository, in the transaction of their record
+type OutboxReader interface {
+	// ClaimUnpublished leases up to limit pending events per shard to
+	// claimant, most urgent first, and returns them. Other relay instances
+	// skip claimed events until they are published, failed or released, or
+	// the lease ran out, e.g. because the claimant crashed. Only the oldest
+	// pending event of an aggregate is claimed, so the events of a time
+	// record are published in the order they were written, and one that
+	// keeps failing holds back the later ones.
+	ClaimUnpublished(ctx context.Context, claimant string, limit int, lease time.Duration) ([]OutboxEvent, error)
+	// MarkAsPublished and IncrementRetryCount also end the events' claims;
+	// MarkAsPublished marks a whole batch with one statement per shard
+	MarkAsPublished(ctx context.Context, eventIDs []string) error
+	// IncrementRetryCount records a failed attempt. Once maxRetries attempts
+	// failed (0 for no limit) the event is marked failed: it is no longer
+	// claimed, and neither are the later events of its aggregate, until it
+	// is requeued. failed reports whether this attempt marked it.
+	IncrementRetryCount(ctx context.Context, eventID string, errorMsg string, maxRetries int) (failed bool, err error)
+	// ReleaseClaims hands claimed events back unchanged, e.g. after a dry run
+	ReleaseClaims(ctx context.Context, eventIDs []string) error
+	Backlog(ctx context.Context) (OutboxBacklog, error)
+}
+
+// OutboxHistory reads the events written with an employee's records,
+// published or not, e.g. to show what was sent about them
+type OutboxHistory interface {
+	// FindByAggregates returns the events of the given aggregates of an
+	// employee, oldest first
+	FindByAggregates(ctx context.Context, employeeID string, aggregateIDs []string) ([]OutboxEvent, error)
+}
+
+// OutboxFailures reads the events the relay has not published, for
+// postmortems and recovery, and hands stuck ones back to the relay; pages
+// are in (created_at, id) order across shards
+type OutboxFailures interface {
+	// FindFailed returns up to limit unpublished events matching filter that
+	// sort after the cursor (nil for the first page)
+	FindFailed(ctx context.Context, filter OutboxFailureFilter, after *OutboxCursor, limit int) ([]FailedOutboxEvent, error)
+	// Requeue clears the failed mark and retry count of the given
+	// unpublished events and returns how many it found
+	Requeue(ctx context.Context, eventIDs []string) (int, error)
+	// RequeueFailed requeues every failed event, or those of eventType
+	RequeueFailed(ctx context.Context, eventType string) (int, error)
+}
+
+// Statuses of an unpublished outbox event
+const (
+	OutboxStatusPending = "pending" // Still retried by the relay
+	OutboxStatusFailed  = "failed"  // Out of retries, waiting to be requeued
+)
+
+// OutboxPruner removes events the relay has published, so the table keeps
+// the pending events and a retention window of history
+type OutboxPruner interface {
+	// PrunePublished deletes up to limit events per shard published before
+	// the given time and returns how many were deleted
+	PrunePublished(ctx context.Context, publishedBefore time.Time, limit int) (int, error)
+	// Size estimates the rows and disk usage of the outbox table
+	Size(ctx context.Context) (OutboxSize, error)
+}
+
+// OutboxSize is an estimate of the outbox table, summed over shards
+type OutboxSize struct {
+	Rows  int64
+	Bytes int64 // 0 when the database does not report it
+}
+
+// OutboxFailureFilter selects unpublished events; zero fields do not filter
+type OutboxFailureFilter struct {
+	From       *time.Time // Created at or after
+	To         *time.Time // Created before
+	EventType  string
+	MinRetries int    // Failed publish attempts
+	Status     string // OutboxStatusPending or OutboxStatusFailed
+}
+
+// FailedOutboxEvent is an unpublished event with its last publish error and
+// the employee of its aggregate, when known
+type FailedOutboxEvent struct {
+	ID          string
+	EventType   string
+	AggregateID string
+	EmployeeID  string
+	CreatedAt   time.Time
+	RetryCount  int
+	LastError   string
+	FailedAt    *time.Time // When it ran out of retries, nil while pending
+}
+
+// Status is OutboxStatusFailed once the event ran out of retries
+func (e FailedOutboxEvent) Status() string {
+	if e.FailedAt != nil {
+		return OutboxStatusFailed
+	}
+	return OutboxStatusPending
+}
+
+// OutboxCursor is the keyset position of an event in (created_at, id) order
+type OutboxCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// OutboxBacklog is what the relay still has to publish
+type OutboxBacklog struct {
+	Pending  int        // Unpublished events still being retried
+	OldestAt *time.Time // Creation time of the oldest pending event, nil when none
+	Failed   int        // Unpublished events out of retries, waiting to be requeued
 }
 
 type OutboxEvent struct {
@@ -30,4 +198,5 @@ type OutboxEvent struct {
 	CreatedAt   time.Time
 	Published   bool
 	RetryCount  int
+	Priority    int // Lower is published first, see events.PriorityNormal
 }
@@ -0,0 +1,21 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/events"
+)
+
+// TimesheetRepository stores timesheets next to the employee's time records,
+// so closing a period and locking its records commit together
+type TimesheetRepository interface {
+	Save(ctx context.Context, sheet *entities.Timesheet) error
+	// FindByPeriod returns (nil, nil) when the employee has no timesheet for the period yet
+	FindByPeriod(ctx context.Context, employeeID, periodStart string) (*entities.Timesheet, error)
+	// FindByID returns (nil, nil) for unknown IDs
+	FindByID(ctx context.Context, id string) (*entities.Timesheet, error)
+	// SaveClosed saves a closed timesheet, the records it locked, their audit
+	// entries and events in one transaction
+	SaveClosed(ctx context.Context, sheet *entities.Timesheet, records []*entities.TimeRecord, entries []*entities.AuditEntry, evts []events.DomainEvent) error
+}
@@ -0,0 +1,24 @@
+// Package configtest sets up the config globals for tests of packages that
+// read config.Cfg and config.Logger
+package configtest
+
+import (
+	"os"
+	"testing"
+
+	"github.com/caarlos0/env/v10"
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"go.uber.org/zap"
+)
+
+// Main runs the package's tests with the default configuration and a silent
+// logger; call it from TestMain
+func Main(m *testing.M) {
+	cfg := &config.Config{}
+	if err := env.Parse(cfg); err != nil {
+		panic(err)
+	}
+	config.Cfg = cfg
+	config.Logger = zap.NewNop()
+	os.Exit(m.Run())
+}
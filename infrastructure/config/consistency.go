@@ -0,0 +1,168 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/schedule"
+)
+
+// Inconsistencies returns configuration combinations that are valid on their
+// own but contradict each other or are likely a mistake
+func (c *Config) Inconsistencies() []string {
+	var problems []string
+
+	if c.OpenTelemetry.Exporter == "otlp" && c.OpenTelemetry.OtlpEndpoint == "" {
+		problems = append(problems, "OTEL_EXPORTER=otlp requires OTEL_EXPORTER_OTLP_ENDPOINT")
+	}
+
+	if c.LegacyAPI.Timeout != c.LegacyAPI.TimeoutSec {
+		problems = append(problems, fmt.Sprintf("LEGACY_API_TIMEOUT (%d) and LEGACY_API_TIMEOUT_SEC (%d) differ; LEGACY_API_TIMEOUT_SEC is used",
+			c.LegacyAPI.Timeout, c.LegacyAPI.TimeoutSec))
+	}
+
+	if c.LegacyAPI.CircuitThreshold != c.CircuitBreaker.MaxFailures {
+		problems = append(problems, fmt.Sprintf("LEGACY_API_CIRCUIT_THRESHOLD (%d) and CB_MAX_FAILURES (%d) differ; CB_MAX_FAILURES is used",
+			c.LegacyAPI.CircuitThreshold, c.CircuitBreaker.MaxFailures))
+	}
+
+	if c.SMTP.Host == "" {
+		problems = append(problems, "SMTP_HOST is empty, check-out emails cannot be sent")
+	}
+
+	if c.Messaging.Backend == MessagingKafka && c.Environment != EnvironmentLocal && len(c.Kafka.Brokers) == 0 {
+		problems = append(problems, "MESSAGING_BACKEND=kafka requires KAFKA_BROKERS")
+	}
+
+	rabbitTLS := c.RabbitMQ.TLSCAFile != "" || c.RabbitMQ.TLSCertFile != "" || c.RabbitMQ.TLSServerName != "" || c.RabbitMQ.TLSExternalAuth
+	if rabbitTLS && c.RabbitMQ.URL != "" && !strings.HasPrefix(c.RabbitMQ.URL, "amqps://") {
+		problems = append(problems, "RABBITMQ_TLS_* settings only apply to an amqps:// RABBITMQ_URL")
+	}
+	if (c.RabbitMQ.TLSCertFile == "") != (c.RabbitMQ.TLSKeyFile == "") {
+		problems = append(problems, "RABBITMQ_TLS_CERT_FILE and RABBITMQ_TLS_KEY_FILE must be set together")
+	}
+	if c.RabbitMQ.TLSExternalAuth && c.RabbitMQ.TLSCertFile == "" {
+		problems = append(problems, "RABBITMQ_TLS_EXTERNAL_AUTH requires a client certificate in RABBITMQ_TLS_CERT_FILE")
+	}
+	if c.RabbitMQ.TLSInsecureSkipVerify {
+		problems = append(problems, "RABBITMQ_TLS_INSECURE_SKIP_VERIFY disables verification of the broker certificate; use it for testing only")
+	}
+
+	if c.RabbitMQ.DLQTTL <= 0 {
+		problems = append(problems, "RABBITMQ_DLQ_TTL_MS must be positive")
+	}
+
+	if c.RabbitMQ.PrefetchCount <= 0 {
+		problems = append(problems, "RABBITMQ_PREFETCH_COUNT must be positive")
+	}
+
+	if c.RabbitMQ.LaborCostAckBatchSize > c.RabbitMQ.PrefetchCount || c.RabbitMQ.EmailAckBatchSize > c.RabbitMQ.PrefetchCount {
+		problems = append(problems, "RABBITMQ_*_ACK_BATCH_SIZE is greater than RABBITMQ_PREFETCH_COUNT; batches only fill up on the timer")
+	}
+
+	if max(c.RabbitMQ.Workers, c.RabbitMQ.LaborCostWorkers, c.RabbitMQ.EmailWorkers) > c.RabbitMQ.PrefetchCount {
+		problems = append(problems, "RABBITMQ_*WORKERS is greater than RABBITMQ_PREFETCH_COUNT; the broker does not deliver enough messages to keep the workers busy")
+	}
+
+	if c.Outbox.PollIntervalSec <= 0 || c.Outbox.FetchLimit <= 0 {
+		problems = append(problems, "OUTBOX_POLL_INTERVAL_SEC and OUTBOX_FETCH_LIMIT must be positive")
+	}
+
+	if c.Overtime.WeeklyThresholdHours > 0 && c.Overtime.DailyThresholdHours > c.Overtime.WeeklyThresholdHours {
+		problems = append(problems, "OVERTIME_DAILY_HOURS is greater than OVERTIME_WEEKLY_HOURS")
+	}
+
+	if c.PayPeriod.Frequency == "biweekly" && c.PayPeriod.Anchor == "" {
+		problems = append(problems, "PAY_PERIOD_FREQUENCY=biweekly requires PAY_PERIOD_ANCHOR")
+	}
+
+	if _, err := time.LoadLocation(c.DefaultTimeZone); err != nil {
+		problems = append(problems, fmt.Sprintf("DEFAULT_TIME_ZONE %q is not a known time zone", c.DefaultTimeZone))
+	}
+
+	if len(c.Database.ShardURLs) == 1 {
+		problems = append(problems, "DATABASE_SHARD_URLS lists a single shard; leave it empty to disable sharding")
+	}
+
+	for _, hook := range c.CheckInHooks.URLs {
+		if name, url, ok := strings.Cut(hook, "="); !ok || name == "" || url == "" {
+			problems = append(problems, fmt.Sprintf("CHECKIN_HOOK_URLS entry %q is not name=url", hook))
+		}
+	}
+
+	for _, route := range c.Outbox.Routes {
+		if _, ok := ParseOutboxRoute(route); !ok {
+			problems = append(problems, fmt.Sprintf("OUTBOX_ROUTES entry %q is not EventType=exchange or EventType=exchange:routing.key", route))
+		}
+	}
+	// An exchange is declared fanout, or topic for routes with a key; it
+	// cannot be both
+	exchangeKeyed := make(map[string]bool)
+	for _, route := range c.OutboxRoutes() {
+		keyed, seen := exchangeKeyed[route.Exchange]
+		if seen && keyed != (route.RoutingKey != "") {
+			problems = append(problems, fmt.Sprintf("OUTBOX_ROUTES uses exchange %s both with and without a routing key", route.Exchange))
+		}
+		exchangeKeyed[route.Exchange] = route.RoutingKey != ""
+	}
+
+	if c.Database.Driver == "mysql" && c.Database.MySQLURL == "" && len(c.Database.MySQLShardURLs) == 0 {
+		problems = append(problems, "DATABASE_DRIVER=mysql requires DATABASE_MYSQL_URL")
+	}
+	if c.Payroll.PreflightCron != "" {
+		if _, err := schedule.ParseCron(c.Payroll.PreflightCron); err != nil {
+			problems = append(problems, fmt.Sprintf("PAYROLL_PREFLIGHT_CRON: %v", err))
+		}
+	}
+
+	if c.Environment == EnvironmentLocal && c.Database.Driver != "postgres" {
+		problems = append(problems, fmt.Sprintf("ENVIRONMENT=local keeps every store in memory; DATABASE_DRIVER=%s is ignored", c.Database.Driver))
+	}
+
+	if c.Environment == "production" && c.Admin.APIKey != "" && len(c.Admin.APIKey) < 16 {
+		problems = append(problems, "ADMIN_API_KEY is shorter than 16 characters")
+	}
+	if c.Environment == "production" && len(c.Audit.ChainKey) < 32 {
+		problems = append(problems, "AUDIT_CHAIN_KEY is shorter than 32 characters")
+	}
+
+	return problems
+}
+
+// OutboxRoute is an OUTBOX_ROUTES entry: where the events of a type are
+// published
+type OutboxRoute struct {
+	EventType  string
+	Exchange   string
+	RoutingKey string // Empty for a fanout exchange
+}
+
+// ParseOutboxRoute parses EventType=exchange, or EventType=exchange:key for a
+// topic exchange, where an empty key stands for the event type
+func ParseOutboxRoute(entry string) (OutboxRoute, bool) {
+	eventType, target, ok := strings.Cut(entry, "=")
+	if !ok || eventType == "" || target == "" {
+		return OutboxRoute{}, false
+	}
+	exchange, key, topic := strings.Cut(target, ":")
+	if exchange == "" {
+		return OutboxRoute{}, false
+	}
+	if topic && key == "" {
+		key = eventType
+	}
+	return OutboxRoute{EventType: eventType, Exchange: exchange, RoutingKey: key}, true
+}
+
+// OutboxRoutes returns the OUTBOX_ROUTES entries that parse; the others are
+// reported by Inconsistencies
+func (c *Config) OutboxRoutes() []OutboxRoute {
+	var routes []OutboxRoute
+	for _, entry := range c.Outbox.Routes {
+		if route, ok := ParseOutboxRoute(entry); ok {
+			routes = append(routes, route)
+		}
+	}
+	return routes
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/caarlos0/env/v10"
 	"github.com/go-playground/validator/v10"
@@ -14,55 +15,529 @@ type Config struct {
 	}
 
 	Database struct {
-		URL               string `env:"DATABASE_URL" validate:"required"`
-		MaxConnections    int    `env:"DB_MAX_CONN" envDefault:"25"`
-		ConnectionTimeout int    `env:"DB_CONN_TIMEOUT" envDefault:"5"`
+		URL string `env:"DATABASE_URL" validate:"required"`
+		// Pool limits of each database; connections are recycled after
+		// ConnMaxLifetimeMin so failovers and DNS changes are picked up
+		MaxConnections     int `env:"DB_MAX_CONN" envDefault:"25" validate:"min=1"`
+		MaxIdleConnections int `env:"DB_MAX_IDLE_CONN" envDefault:"5" validate:"min=0"`
+		ConnMaxLifetimeMin int `env:"DB_CONN_MAX_LIFETIME_MIN" envDefault:"30" validate:"min=0"`
+		// Seconds each startup ping may take; a database that does not answer
+		// is retried with backoff up to ConnectAttempts times
+		ConnectionTimeout int `env:"DB_CONN_TIMEOUT" envDefault:"5" validate:"min=1"`
+		ConnectAttempts   int `env:"DB_CONNECT_ATTEMPTS" envDefault:"10" validate:"min=1"`
+		// Time record operations failing with a serialization failure,
+		// deadlock or lost connection are tried up to RetryAttempts times,
+		// waiting RetryBaseDelayMs, doubling up to RetryMaxDelayMs, in between
+		RetryAttempts    int `env:"DB_RETRY_ATTEMPTS" envDefault:"3" validate:"min=1"`
+		RetryBaseDelayMs int `env:"DB_RETRY_BASE_DELAY_MS" envDefault:"50" validate:"min=1"`
+		RetryMaxDelayMs  int `env:"DB_RETRY_MAX_DELAY_MS" envDefault:"1000" validate:"min=1"`
+		// Apply pending schema migrations on startup; when off, run
+		// cmd/migrate before deploying
+		AutoMigrate bool `env:"DATABASE_AUTO_MIGRATE" envDefault:"true"`
+		// Time records and their outbox events are sharded by employee ID
+		// across these databases; DATABASE_URL alone is used when empty
+		ShardURLs []string `env:"DATABASE_SHARD_URLS" envSeparator:","`
+		// Optional read-only replica of DATABASE_URL for consumer-side lookups
+		// (consents, roster); reads fall back to the primary while it lags more
+		// than ReplicaMaxLagSec or cannot be reached
+		ReplicaURL            string `env:"DATABASE_REPLICA_URL"`
+		ReplicaMaxLagSec      int    `env:"DATABASE_REPLICA_MAX_LAG_SEC" envDefault:"10" validate:"min=0"`
+		ReplicaCheckIntervalS int    `env:"DATABASE_REPLICA_CHECK_INTERVAL_SEC" envDefault:"5" validate:"min=1"`
+		// Store of time records and their outbox: postgres, or mysql to keep
+		// them in DATABASE_MYSQL_URL (sharded across DATABASE_MYSQL_SHARD_URLS
+		// when set); everything else stays in DATABASE_URL
+		Driver         string   `env:"DATABASE_DRIVER" envDefault:"postgres" validate:"oneof=postgres mysql"`
+		MySQLURL       string   `env:"DATABASE_MYSQL_URL"`
+		MySQLShardURLs []string `env:"DATABASE_MYSQL_SHARD_URLS" envSeparator:","`
+		// Postgres time_records is partitioned by month of check-in; the
+		// maintenance job keeps this many months ahead of the current one
+		// created, checking every PartitionCheckIntervalM minutes
+		PartitionMonthsAhead    int `env:"DATABASE_PARTITION_MONTHS_AHEAD" envDefault:"3" validate:"min=1"`
+		PartitionCheckIntervalM int `env:"DATABASE_PARTITION_CHECK_INTERVAL_MIN" envDefault:"360" validate:"min=1"`
+		// Longest completed shift range reads look back for, so they skip the
+		// partitions before the range; open records are found at any age
+		MaxShiftHours int `env:"DATABASE_MAX_SHIFT_HOURS" envDefault:"168" validate:"min=1"`
+	}
+
+	Messaging struct {
+		// Broker the outbox publishes to and the workers consume from; kafka
+		// needs a build with -tags kafka, inprocess needs no broker at all
+		Backend string `env:"MESSAGING_BACKEND" envDefault:"rabbitmq" validate:"oneof=rabbitmq kafka inprocess"`
+		// Check events against their JSON Schema before publishing and
+		// before handling; those that fail go to QuarantineQueue (a topic
+		// with Kafka) instead
+		ValidateSchemas bool   `env:"EVENT_SCHEMA_VALIDATION" envDefault:"true"`
+		QuarantineQueue string `env:"EVENT_QUARANTINE_QUEUE" envDefault:"events-quarantine" validate:"required"`
+	}
+
+	Kafka struct {
+		Brokers []string `env:"KAFKA_BROKERS" envSeparator:","`
+		// Each worker reads in the consumer group <GroupPrefix>.<queue name>
+		GroupPrefix string `env:"KAFKA_GROUP_PREFIX" envDefault:"check-in-service" validate:"required"`
+		// Wait before handling a failed message again, doubled after each
+		// failure up to the max
+		RetryInitialMs int `env:"KAFKA_RETRY_INITIAL_MS" envDefault:"500" validate:"min=1"`
+		RetryMaxSec    int `env:"KAFKA_RETRY_MAX_SEC" envDefault:"30" validate:"min=1"`
+	}
+
+	// In-process bus of MESSAGING_BACKEND=inprocess, for single-node
+	// deployments
+	InProcess struct {
+		// Events each queue holds; publishing to a full queue fails and the
+		// outbox retries it
+		QueueSize int `env:"INPROCESS_QUEUE_SIZE" envDefault:"10000" validate:"min=1"`
+		// Messages each consumer handles at the same time
+		Workers int `env:"INPROCESS_WORKERS" envDefault:"1" validate:"min=1"`
+		// Wait before handling a failed message again, doubled after each
+		// failure up to the max; RABBITMQ_MAX_DELIVERIES bounds the attempts
+		RetryInitialMs int `env:"INPROCESS_RETRY_INITIAL_MS" envDefault:"500" validate:"min=1"`
+		RetryMaxSec    int `env:"INPROCESS_RETRY_MAX_SEC" envDefault:"30" validate:"min=1"`
 	}
 
 	RabbitMQ struct {
-		URL           string `env:"RABBITMQ_URL" validate:"required"`
-		Workers       int    `env:"RABBITMQ_WORKERS" envDefault:"5"`
-		DLQTTL        int    `env:"RABBITMQ_DLQ_TTL_MS" envDefault:"30000"`
-		PrefetchCount int    `env:"RABBITMQ_PREFETCH_COUNT" envDefault:"1"`
+		URL string `env:"RABBITMQ_URL" validate:"required"`
+		// Messages each consumer handles at the same time, unless set per
+		// consumer below (0 uses Workers); above 1, events of one aggregate
+		// may be handled out of order
+		Workers       int `env:"RABBITMQ_WORKERS" envDefault:"1" validate:"min=1"`
+		DLQTTL        int `env:"RABBITMQ_DLQ_TTL_MS" envDefault:"30000"`
+		PrefetchCount int `env:"RABBITMQ_PREFETCH_COUNT" envDefault:"1"`
+		// Per-consumer batch acknowledgement: ack after this many successful
+		// messages or milliseconds, whichever comes first; 0 acks each message
+		LaborCostAckBatchSize int `env:"RABBITMQ_LABOR_COST_ACK_BATCH_SIZE" envDefault:"0" validate:"min=0"`
+		LaborCostAckBatchMs   int `env:"RABBITMQ_LABOR_COST_ACK_BATCH_MS" envDefault:"200" validate:"min=0"`
+		EmailAckBatchSize     int `env:"RABBITMQ_EMAIL_ACK_BATCH_SIZE" envDefault:"0" validate:"min=0"`
+		EmailAckBatchMs       int `env:"RABBITMQ_EMAIL_ACK_BATCH_MS" envDefault:"200" validate:"min=0"`
+		LaborCostWorkers      int `env:"RABBITMQ_LABOR_COST_WORKERS" envDefault:"0" validate:"min=0"`
+		EmailWorkers          int `env:"RABBITMQ_EMAIL_WORKERS" envDefault:"0" validate:"min=0"`
+		// Wait before reconnecting the publisher or restarting a consumer
+		// after the broker went away, doubled after each failure up to the max
+		ReconnectInitialMs int `env:"RABBITMQ_RECONNECT_INITIAL_MS" envDefault:"500" validate:"min=1"`
+		ReconnectMaxSec    int `env:"RABBITMQ_RECONNECT_MAX_SEC" envDefault:"30" validate:"min=1"`
+		// At shutdown, how long consumers wait for the messages being handled
+		// before cancelling them
+		DrainTimeoutSec int `env:"RABBITMQ_DRAIN_TIMEOUT_SEC" envDefault:"15" validate:"min=1"`
+		// Failed deliveries after which a message goes to the DLQ; messages
+		// that cannot be decoded go at once. 0 requeues them until the TTL.
+		MaxDeliveries int `env:"RABBITMQ_MAX_DELIVERIES" envDefault:"5" validate:"min=0"`
+		// Arguments of the consumer queues and their DLQs: classic or
+		// replicated quorum queues, lazy (classic only) and a cap on ready
+		// messages with what to do beyond it. QueueOptions overrides them per
+		// queue as queue:key=value;... entries. The broker refuses to change
+		// an existing queue's type; see the README.
+		QueueType      string   `env:"RABBITMQ_QUEUE_TYPE" envDefault:"classic"`
+		QueueLazy      bool     `env:"RABBITMQ_QUEUE_LAZY" envDefault:"false"`
+		QueueMaxLength int      `env:"RABBITMQ_QUEUE_MAX_LENGTH" envDefault:"0" validate:"min=0"`
+		QueueOverflow  string   `env:"RABBITMQ_QUEUE_OVERFLOW" envDefault:"drop-head"`
+		QueueOptions   []string `env:"RABBITMQ_QUEUE_OPTIONS" envSeparator:","`
+		// TLS of amqps:// connections: CA bundle to verify the broker with
+		// (system roots when empty), client certificate and key for mutual
+		// TLS, and the name the broker certificate must carry when it is not
+		// the URL's host. ExternalAuth logs in with the client certificate
+		// (EXTERNAL mechanism) instead of the URL's credentials.
+		TLSCAFile             string `env:"RABBITMQ_TLS_CA_FILE"`
+		TLSCertFile           string `env:"RABBITMQ_TLS_CERT_FILE"`
+		TLSKeyFile            string `env:"RABBITMQ_TLS_KEY_FILE"`
+		TLSServerName         string `env:"RABBITMQ_TLS_SERVER_NAME"`
+		TLSInsecureSkipVerify bool   `env:"RABBITMQ_TLS_INSECURE_SKIP_VERIFY" envDefault:"false"`
+		TLSExternalAuth       bool   `env:"RABBITMQ_TLS_EXTERNAL_AUTH" envDefault:"false"`
 	}
 
 	LegacyAPI struct {
-		URL              string `env:"LEGACY_API_URL" validate:"required"`
-		Timeout          int    `env:"LEGACY_API_TIMEOUT" envDefault:"30"`
-		TimeoutSec       int    `env:"LEGACY_API_TIMEOUT_SEC" envDefault:"30"`
-		RateLimit        int    `env:"LEGACY_API_RATE_LIMIT" envDefault:"100"`
-		CircuitThreshold int    `env:"LEGACY_API_CIRCUIT_THRESHOLD" envDefault:"5"`
+		URL        string `env:"LEGACY_API_URL" validate:"required"`
+		Timeout    int    `env:"LEGACY_API_TIMEOUT" envDefault:"30"`
+		TimeoutSec int    `env:"LEGACY_API_TIMEOUT_SEC" envDefault:"30"`
+		// Calls a minute to the legacy API across the workers of this
+		// instance, in bursts of up to as many; 0 for no limit
+		RateLimit        int `env:"LEGACY_API_RATE_LIMIT" envDefault:"100" validate:"min=0"`
+		CircuitThreshold int `env:"LEGACY_API_CIRCUIT_THRESHOLD" envDefault:"5"`
+		// Calls to the legacy API in flight at once from this instance, 0 for
+		// no limit; a call beyond waits up to LEGACY_API_QUEUE_TIMEOUT_MS for
+		// a slot, then fails and its message is retried
+		MaxConcurrent  int `env:"LEGACY_API_MAX_CONCURRENT" envDefault:"10" validate:"min=0"`
+		QueueTimeoutMs int `env:"LEGACY_API_QUEUE_TIMEOUT_MS" envDefault:"5000" validate:"min=0"`
+		// Credentials sent to the legacy API: none, a static "api-key" in
+		// LEGACY_API_KEY_HEADER, "basic" auth, or an "oauth2" bearer token
+		// obtained from LEGACY_API_TOKEN_URL with the client credentials grant
+		Auth         string   `env:"LEGACY_API_AUTH" envDefault:"none" validate:"oneof=none api-key basic oauth2"`
+		APIKey       string   `env:"LEGACY_API_KEY" validate:"required_if=Auth api-key"`
+		APIKeyHeader string   `env:"LEGACY_API_KEY_HEADER" envDefault:"X-API-Key" validate:"required_if=Auth api-key"`
+		Username     string   `env:"LEGACY_API_USERNAME" validate:"required_if=Auth basic"`
+		Password     string   `env:"LEGACY_API_PASSWORD"`
+		TokenURL     string   `env:"LEGACY_API_TOKEN_URL" validate:"required_if=Auth oauth2,omitempty,url"`
+		ClientID     string   `env:"LEGACY_API_CLIENT_ID" validate:"required_if=Auth oauth2"`
+		ClientSecret string   `env:"LEGACY_API_CLIENT_SECRET" validate:"required_if=Auth oauth2"`
+		Scopes       []string `env:"LEGACY_API_SCOPES" envSeparator:","`
 	}
 
 	Outbox struct {
 		PollIntervalSec int `env:"OUTBOX_POLL_INTERVAL_SEC" envDefault:"2"`
 		FetchLimit      int `env:"OUTBOX_FETCH_LIMIT" envDefault:"100"`
+		// Events fetched in one poll are published as a batch; those the
+		// broker has not confirmed within ConfirmTimeoutMs count as failed.
+		// Events published one by one wait as long for their confirm.
+		ConfirmTimeoutMs int `env:"OUTBOX_CONFIRM_TIMEOUT_MS" envDefault:"5000" validate:"min=1"`
+		// Preview events (serialization and routing) in the log without
+		// publishing or marking them; toggled at runtime by the admin API
+		DryRun bool `env:"OUTBOX_DRY_RUN" envDefault:"false"`
+		// Events are published by priority (payroll before routine emails);
+		// one pending longer than PriorityAgingSec goes ahead of all of them
+		PriorityAgingSec int `env:"OUTBOX_PRIORITY_AGING_SEC" envDefault:"300" validate:"min=1"`
+		// Events fetched by one instance are claimed for ClaimLeaseSec, so
+		// other instances skip them; a crashed instance's claims run out
+		ClaimLeaseSec int `env:"OUTBOX_CLAIM_LEASE_SEC" envDefault:"60" validate:"min=1"`
+		// EventType=exchange pairs, e.g. EmployeeCheckedIn=checkin-events,
+		// sending an event type to its own fanout exchange, or
+		// EventType=exchange:routing.key to publish it to a topic exchange
+		// with that key (the event type when left empty); every other type
+		// goes to checkout-events
+		Routes []string `env:"OUTBOX_ROUTES" envSeparator:","`
+		// An event that failed to publish MaxRetries times is marked failed
+		// and left alone until requeued; 0 retries it forever
+		MaxRetries int `env:"OUTBOX_MAX_RETRIES" envDefault:"10" validate:"min=0"`
+		// Delete published events older than RetentionHours, at most
+		// PruneBatchSize per shard and statement; 0 keeps them forever
+		RetentionHours   int `env:"OUTBOX_RETENTION_HOURS" envDefault:"168" validate:"min=0"`
+		PruneBatchSize   int `env:"OUTBOX_PRUNE_BATCH_SIZE" envDefault:"1000" validate:"min=1"`
+		PruneIntervalMin int `env:"OUTBOX_PRUNE_INTERVAL_MIN" envDefault:"15" validate:"min=1"`
+		// How often the backlog size and age are reported as gauges
+		HealthIntervalSec int `env:"OUTBOX_HEALTH_INTERVAL_SEC" envDefault:"30" validate:"min=1"`
+	}
+
+	ShadowEvents struct {
+		// Also publish the next version of events, paired with the current
+		// one, to Exchange where the parity checker compares them field by field
+		Enabled  bool   `env:"SHADOW_EVENTS_ENABLED" envDefault:"false"`
+		Exchange string `env:"SHADOW_EVENTS_EXCHANGE" envDefault:"checkout-events-shadow" validate:"required"`
+	}
+
+	CheckInHooks struct {
+		// Ordered name=url pairs called after every check-in, e.g.
+		// lockers=http://facilities/api/assignments; each response body is
+		// returned under its name in the check-in response metadata
+		URLs      []string `env:"CHECKIN_HOOK_URLS" envSeparator:","`
+		Token     string   `env:"CHECKIN_HOOK_TOKEN"`
+		TimeoutMs int      `env:"CHECKIN_HOOK_TIMEOUT_MS" envDefault:"2000" validate:"min=1"`
 	}
 
 	CircuitBreaker struct {
 		MaxFailures   int `env:"CB_MAX_FAILURES" envDefault:"5"`
 		ResetTimeoutS int `env:"CB_RESET_TIMEOUT_SEC" envDefault:"60"`
+		// Trial calls let through at once once CB_RESET_TIMEOUT_SEC passed;
+		// the first success closes the breaker, a failure opens it again
+		HalfOpenRequests int `env:"CB_HALF_OPEN_MAX_REQUESTS" envDefault:"1" validate:"min=1"`
+		// What trips a breaker: "consecutive" failures (CB_MAX_FAILURES) or
+		// the failure "rate" of the calls of the last CB_WINDOW_SEC, once
+		// there were CB_MIN_REQUESTS calls in it
+		Mode        string  `env:"CB_MODE" envDefault:"consecutive" validate:"oneof=consecutive rate"`
+		FailureRate float64 `env:"CB_FAILURE_RATE" envDefault:"50" validate:"gt=0,lte=100"`
+		WindowSec   int     `env:"CB_WINDOW_SEC" envDefault:"60" validate:"min=1"`
+		MinRequests int     `env:"CB_MIN_REQUESTS" envDefault:"20" validate:"min=1"`
 	}
 
 	SMTP struct {
 		Host string `env:"SMTP_HOST" envDefault:""`
 		Port int    `env:"SMTP_PORT" envDefault:"1025"`
+		// Seconds one email may take to deliver before it counts as a failure
+		// of the SMTP circuit breaker; 0 for no limit
+		TimeoutSec int `env:"SMTP_TIMEOUT_SEC" envDefault:"10" validate:"min=0"`
 	}
 
 	CheckOut struct {
 		DuplicateWindowSec int `env:"CHECKOUT_DUPLICATE_WINDOW_SEC" envDefault:"60"`
 	}
 
+	Admin struct {
+		APIKey string `env:"ADMIN_API_KEY" envDefault:""`
+	}
+
+	Overtime struct {
+		DailyThresholdHours  float64 `env:"OVERTIME_DAILY_HOURS" envDefault:"8"`
+		WeeklyThresholdHours float64 `env:"OVERTIME_WEEKLY_HOURS" envDefault:"40"`
+	}
+
+	// Policy chain applied to worked hours at check-out: breaks, rounding, night split
+	Hours struct {
+		BreakAfterHours float64 `env:"HOURS_BREAK_AFTER_HOURS" envDefault:"6"`
+		BreakMinutes    int     `env:"HOURS_BREAK_MINUTES" envDefault:"0"`
+		RoundingMinutes int     `env:"HOURS_ROUNDING_MINUTES" envDefault:"0"`
+		RoundingMode    string  `env:"HOURS_ROUNDING_MODE" envDefault:"nearest" validate:"oneof=nearest up down"`
+		NightStartHour  int     `env:"HOURS_NIGHT_START_HOUR" envDefault:"22" validate:"min=0,max=23"`
+		NightEndHour    int     `env:"HOURS_NIGHT_END_HOUR" envDefault:"6" validate:"min=0,max=23"`
+		// Split shifts crossing local midnight into per-day segments; when off
+		// the whole shift counts towards its business date (the check-in day)
+		SplitOvernight bool `env:"HOURS_SPLIT_OVERNIGHT" envDefault:"false"`
+	}
+
+	// Time zone for check-ins without an explicit or location time zone
+	DefaultTimeZone string `env:"DEFAULT_TIME_ZONE" envDefault:"UTC"`
+
+	InboundEmail struct {
+		// Shared secret the email provider's inbound webhook sends in
+		// X-Inbound-Token; inbound email is disabled when empty
+		Token string `env:"INBOUND_EMAIL_TOKEN" envDefault:""`
+	}
+
+	Idempotency struct {
+		// How long responses are kept for replay under an Idempotency-Key,
+		// and how long inbound email message IDs are remembered
+		TTLHours int `env:"IDEMPOTENCY_TTL_HOURS" envDefault:"24" validate:"min=1"`
+		// How long a key stays locked while its first request runs
+		LockTimeoutSec     int `env:"IDEMPOTENCY_LOCK_TIMEOUT_SEC" envDefault:"60" validate:"min=1"`
+		CleanupIntervalMin int `env:"IDEMPOTENCY_CLEANUP_INTERVAL_MIN" envDefault:"15" validate:"min=1"`
+	}
+
+	Inbox struct {
+		// How long consumers remember processed event IDs; keep it longer
+		// than an event can sit in a queue or dead-letter queue
+		RetentionHours     int `env:"INBOX_RETENTION_HOURS" envDefault:"168" validate:"min=1"`
+		CleanupIntervalMin int `env:"INBOX_CLEANUP_INTERVAL_MIN" envDefault:"60" validate:"min=1"`
+		// How long a delivery's claim on an event lasts; another delivery
+		// takes over after it, so keep it longer than a handler may run
+		ClaimTimeoutSec int `env:"INBOX_CLAIM_TIMEOUT_SEC" envDefault:"300" validate:"min=1"`
+	}
+
+	Notifications struct {
+		// How long the email worker caches the summary email settings
+		SettingsCacheSec int `env:"NOTIFICATION_SETTINGS_CACHE_SEC" envDefault:"60" validate:"min=0"`
+	}
+
+	LaborCost struct {
+		// Currency of hourly rates on the roster that do not name one (ISO 4217)
+		Currency string `env:"LABOR_COST_CURRENCY" envDefault:"USD" validate:"len=3,uppercase"`
+		// Where labor cost entries go: the "legacy" API, appended to the
+		// LABOR_COST_EXPORT_PATH "file", posted to LABOR_COST_WEBHOOK_URL
+		// ("webhook"), or dropped ("none") in regions without payroll
+		// integration
+		Backend          string `env:"LABOR_COST_BACKEND" envDefault:"legacy" validate:"oneof=legacy file webhook none"`
+		ExportPath       string `env:"LABOR_COST_EXPORT_PATH" envDefault:"labor-cost.jsonl" validate:"required_if=Backend file"`
+		WebhookURL       string `env:"LABOR_COST_WEBHOOK_URL" validate:"required_if=Backend webhook,omitempty,url"`
+		WebhookToken     string `env:"LABOR_COST_WEBHOOK_TOKEN"`
+		WebhookTimeoutMs int    `env:"LABOR_COST_WEBHOOK_TIMEOUT_MS" envDefault:"10000" validate:"min=1"`
+		// Entries sent together to the legacy API's batch endpoint; 0 sends
+		// each on its own. A batch goes out when full or LABOR_COST_BATCH_FLUSH_MS
+		// after its first entry.
+		BatchSize    int `env:"LABOR_COST_BATCH_SIZE" envDefault:"0" validate:"min=0"`
+		BatchFlushMs int `env:"LABOR_COST_BATCH_FLUSH_MS" envDefault:"200" validate:"min=1"`
+		// Park the legacy API's entries in the database while its circuit is
+		// open, instead of requeueing their messages, and submit them every
+		// LABOR_COST_DRAIN_INTERVAL_SEC once it closes
+		Parking          bool `env:"LABOR_COST_PARKING" envDefault:"true"`
+		DrainIntervalSec int  `env:"LABOR_COST_DRAIN_INTERVAL_SEC" envDefault:"30" validate:"min=1"`
+		DrainBatchSize   int  `env:"LABOR_COST_DRAIN_BATCH_SIZE" envDefault:"100" validate:"min=1"`
+	}
+
+	Holidays struct {
+		// Holidays imported into the calendar at startup, as YYYY-MM-DD=Name
+		Import []string `env:"HOLIDAYS" envSeparator:","`
+		// Days whose hours are classified as weekend hours
+		WeekendDays []string `env:"WEEKEND_DAYS" envDefault:"saturday,sunday" envSeparator:"," validate:"dive,oneof=monday tuesday wednesday thursday friday saturday sunday"`
+	}
+
+	Devices struct {
+		// Reject check-ins that do not come from an enrolled kiosk; when off,
+		// device credentials are still checked if a request sends them
+		RequireAuth        bool `env:"DEVICE_AUTH_REQUIRED" envDefault:"false"`
+		EnrollmentTTLMin   int  `env:"DEVICE_ENROLLMENT_TTL_MIN" envDefault:"60" validate:"min=1"`
+		CredentialTTLHours int  `env:"DEVICE_CREDENTIAL_TTL_HOURS" envDefault:"720" validate:"min=1"`
+		// Kiosks are told to rotate during the last hours of a credential's lifetime
+		RotateBeforeHours int `env:"DEVICE_ROTATE_BEFORE_HOURS" envDefault:"168" validate:"min=0"`
+		// How long the previous secret keeps working after a rotation
+		RotationGraceMin int `env:"DEVICE_ROTATION_GRACE_MIN" envDefault:"60" validate:"min=0"`
+	}
+
+	Audit struct {
+		// Secret the audit chain is sealed with (HMAC-SHA256); changing it
+		// makes every existing entry fail verification
+		ChainKey string `env:"AUDIT_CHAIN_KEY" validate:"required"`
+		// Minutes between audit chain anchors; 0 disables anchoring
+		AnchorIntervalMin int `env:"AUDIT_ANCHOR_INTERVAL_MIN" envDefault:"60" validate:"min=0"`
+		// Object storage prefix anchors are PUT to; anchors stay in the database only when empty
+		AnchorURL   string `env:"AUDIT_ANCHOR_URL"`
+		AnchorToken string `env:"AUDIT_ANCHOR_TOKEN"`
+	}
+
+	Reports struct {
+		// First day of the week in weekly reports; monday follows ISO 8601
+		WeekStart string `env:"REPORT_WEEK_START" envDefault:"monday" validate:"oneof=monday sunday"`
+		// Records per page of the records report when no limit is given
+		PageSize int `env:"REPORT_PAGE_SIZE" envDefault:"500" validate:"min=1,max=5000"`
+	}
+
+	PayPeriod struct {
+		// weekly, biweekly or semimonthly, in DEFAULT_TIME_ZONE
+		Frequency string `env:"PAY_PERIOD_FREQUENCY" envDefault:"weekly" validate:"oneof=weekly biweekly semimonthly"`
+		// First day (YYYY-MM-DD) of any weekly or bi-weekly period; required for
+		// bi-weekly, weekly periods start on REPORT_WEEK_START without it
+		Anchor string `env:"PAY_PERIOD_ANCHOR"`
+		// Days of the month the two semi-monthly periods start on
+		SemimonthlyDays []int `env:"PAY_PERIOD_SEMIMONTHLY_DAYS" envDefault:"1,16" envSeparator:","`
+	}
+
+	AntiPassback struct {
+		// off, reject (refuse a badge at another site while checked in) or
+		// transfer (check out at the old site and in at the new one)
+		Mode string `env:"ANTI_PASSBACK_MODE" envDefault:"off" validate:"oneof=off reject transfer"`
+		// Minutes after checking out at one site before a check-in at another
+		// is accepted; 0 disables the rule
+		MinTransitMin int `env:"ANTI_PASSBACK_MIN_TRANSIT_MIN" envDefault:"0" validate:"min=0"`
+	}
+
+	MissedCheckout struct {
+		// Hours after check-in a record still open counts as a missed
+		// check-out and the employee is reminded; 0 disables the check
+		AfterHours      float64 `env:"MISSED_CHECKOUT_AFTER_HOURS" envDefault:"12" validate:"min=0"`
+		ScanIntervalMin int     `env:"MISSED_CHECKOUT_SCAN_INTERVAL_MIN" envDefault:"15" validate:"min=1"`
+		// Most records flagged per scan and shard
+		BatchSize int `env:"MISSED_CHECKOUT_BATCH_SIZE" envDefault:"200" validate:"min=1"`
+	}
+
+	Exceptions struct {
+		// A scheduled shift without a check-in becomes a no-show this many
+		// minutes after it was due to end
+		GraceMin        int `env:"EXCEPTIONS_GRACE_MIN" envDefault:"30" validate:"min=0"`
+		ScanIntervalMin int `env:"EXCEPTIONS_SCAN_INTERVAL_MIN" envDefault:"60" validate:"min=1"`
+	}
+
+	Timesheets struct {
+		// Close pay periods automatically this many days after they end,
+		// locking their records; off by default, periods are then closed
+		// through the admin API
+		AutoClose        bool `env:"TIMESHEET_AUTO_CLOSE" envDefault:"false"`
+		CutoffDays       int  `env:"TIMESHEET_CUTOFF_DAYS" envDefault:"3" validate:"min=0"`
+		CloseIntervalMin int  `env:"TIMESHEET_CLOSE_INTERVAL_MIN" envDefault:"60" validate:"min=1"`
+	}
+
+	Payroll struct {
+		// Cron expression (minute hour day month weekday, in DEFAULT_TIME_ZONE)
+		// of the preflight checking that the last pay period's events are
+		// published and processed; empty runs it only through the admin API
+		PreflightCron string `env:"PAYROLL_PREFLIGHT_CRON"`
+		// Refuse to close a pay period while its preflight fails
+		BlockClose bool `env:"PAYROLL_PREFLIGHT_BLOCK_CLOSE" envDefault:"true"`
+	}
+
+	Recovery struct {
+		// POST /api/admin/recover resets circuit breakers open for at least
+		// this long; shorter outages are left to the breaker's own timeout
+		BreakerOpenSec int `env:"RECOVERY_BREAKER_OPEN_SEC" envDefault:"300" validate:"min=0"`
+	}
+
+	Leader struct {
+		// Singleton jobs (pruning, detectors, closers, preflight) run on the
+		// instance holding this Postgres advisory lock; the others campaign
+		// for it every RenewIntervalSec and take over when it is released
+		LockKey          string `env:"LEADER_LOCK_KEY" envDefault:"check-in-service/singleton-jobs" validate:"required"`
+		RenewIntervalSec int    `env:"LEADER_RENEW_INTERVAL_SEC" envDefault:"5" validate:"min=1"`
+	}
+
+	Roster struct {
+		// Reject check-ins from employees missing from the roster or inactive
+		RequireActiveEmployee bool `env:"ROSTER_REQUIRE_ACTIVE_EMPLOYEE" envDefault:"true"`
+	}
+
+	Consent struct {
+		RequireExplicit bool `env:"CONSENT_REQUIRE_EXPLICIT" envDefault:"false"`
+	}
+
+	// Optional statsd push metrics for legacy monitoring (disabled when STATSD_ADDR is empty)
+	StatsD struct {
+		Addr   string `env:"STATSD_ADDR" envDefault:""`
+		Prefix string `env:"STATSD_PREFIX" envDefault:"check_in_service"`
+	}
+
 	OpenTelemetry struct {
 		Exporter     string `env:"OTEL_EXPORTER" envDefault:""`
 		OtlpEndpoint string `env:"OTEL_EXPORTER_OTLP_ENDPOINT" envDefault:""`
 	}
 
+	// STARTUP_CHECK_MODE: strict (refuse to start on mismatch), degraded (log and start) or off
+	StartupCheckMode string `env:"STARTUP_CHECK_MODE" envDefault:"degraded" validate:"oneof=strict degraded off"`
+
+	// GET /ready answers 503 until a warm-up passed; a failed one is retried
+	// every RetrySec seconds, each step waiting at most StepTimeoutSec
+	WarmUp struct {
+		RetrySec       int `env:"WARMUP_RETRY_SEC" envDefault:"5" validate:"min=1"`
+		StepTimeoutSec int `env:"WARMUP_STEP_TIMEOUT_SEC" envDefault:"10" validate:"min=1"`
+	}
+
+	// After the warm-up, GET /ready reports the cached result of pinging the
+	// database shards and the broker every IntervalSec seconds, give or take
+	// up to JitterSec, each round waiting at most TimeoutSec
+	HealthCheck struct {
+		IntervalSec int `env:"HEALTH_CHECK_INTERVAL_SEC" envDefault:"15" validate:"min=1"`
+		JitterSec   int `env:"HEALTH_CHECK_JITTER_SEC" envDefault:"5" validate:"min=0"`
+		TimeoutSec  int `env:"HEALTH_CHECK_TIMEOUT_SEC" envDefault:"3" validate:"min=1"`
+	}
+
+	// Incidents are opened and resolved in PagerDuty or Opsgenie, checked
+	// every CheckIntervalSec seconds; each alert type goes to its own
+	// provider (pagerduty, opsgenie or none) with its own severity
+	Incidents struct {
+		CheckIntervalSec    int    `env:"INCIDENTS_CHECK_INTERVAL_SEC" envDefault:"30" validate:"min=1"`
+		Source              string `env:"INCIDENTS_SOURCE" envDefault:"check-in-service"`
+		PagerDutyURL        string `env:"INCIDENTS_PAGERDUTY_URL" envDefault:"https://events.pagerduty.com/v2/enqueue" validate:"url"`
+		PagerDutyRoutingKey string `env:"INCIDENTS_PAGERDUTY_ROUTING_KEY"`
+		OpsgenieURL         string `env:"INCIDENTS_OPSGENIE_URL" envDefault:"https://api.opsgenie.com" validate:"url"`
+		OpsgenieAPIKey      string `env:"INCIDENTS_OPSGENIE_API_KEY"`
+		// A circuit breaker is open
+		BreakerOpenProvider string `env:"INCIDENTS_BREAKER_OPEN_PROVIDER" envDefault:"none" validate:"oneof=none pagerduty opsgenie"`
+		BreakerOpenSeverity string `env:"INCIDENTS_BREAKER_OPEN_SEVERITY" envDefault:"critical" validate:"oneof=critical error warning info"`
+		// A dead letter queue holds at least DLQThreshold messages
+		DLQProvider  string `env:"INCIDENTS_DLQ_PROVIDER" envDefault:"none" validate:"oneof=none pagerduty opsgenie"`
+		DLQSeverity  string `env:"INCIDENTS_DLQ_SEVERITY" envDefault:"error" validate:"oneof=critical error warning info"`
+		DLQThreshold int    `env:"INCIDENTS_DLQ_THRESHOLD" envDefault:"100" validate:"min=1"`
+		// At least OutboxThreshold events are pending, or the oldest has been
+		// for OutboxMaxLagSec seconds (0 to alert on the count only)
+		OutboxProvider  string `env:"INCIDENTS_OUTBOX_PROVIDER" envDefault:"none" validate:"oneof=none pagerduty opsgenie"`
+		OutboxSeverity  string `env:"INCIDENTS_OUTBOX_SEVERITY" envDefault:"error" validate:"oneof=critical error warning info"`
+		OutboxThreshold int    `env:"INCIDENTS_OUTBOX_THRESHOLD" envDefault:"1000" validate:"min=1"`
+		OutboxMaxLagSec int    `env:"INCIDENTS_OUTBOX_MAX_LAG_SEC" envDefault:"300" validate:"min=0"`
+		// An outbox event ran out of retries and waits to be requeued
+		OutboxFailedProvider string `env:"INCIDENTS_OUTBOX_FAILED_PROVIDER" envDefault:"none" validate:"oneof=none pagerduty opsgenie"`
+		OutboxFailedSeverity string `env:"INCIDENTS_OUTBOX_FAILED_SEVERITY" envDefault:"error" validate:"oneof=critical error warning info"`
+	}
+
+	// ENVIRONMENT=local keeps every store and published events in memory and
+	// starts no RabbitMQ consumers, see EnvironmentLocal
 	Environment string `env:"ENVIRONMENT" envDefault:"development"`
 	LogLevel    string `env:"LOG_LEVEL" envDefault:"info"`
 	MetricsPort int    `env:"METRICS_PORT" envDefault:"9090"`
 }
 
+// EnvironmentLocal runs the HTTP API without a database, RabbitMQ or the
+// legacy API; nothing survives a restart
+const EnvironmentLocal = "local"
+
+// Circuit breaker modes
+const (
+	BreakerConsecutive = "consecutive"
+	BreakerRate        = "rate"
+)
+
+// Labor cost backends
+const (
+	LaborCostLegacy  = "legacy"
+	LaborCostFile    = "file"
+	LaborCostWebhook = "webhook"
+	LaborCostNone    = "none"
+)
+
+// Legacy API authentication modes
+const (
+	LegacyAuthNone   = "none"
+	LegacyAuthAPIKey = "api-key"
+	LegacyAuthBasic  = "basic"
+	LegacyAuthOAuth2 = "oauth2"
+)
+
+// Messaging backends
+const (
+	MessagingRabbitMQ  = "rabbitmq"
+	MessagingKafka     = "kafka"
+	MessagingInProcess = "inprocess"
+)
+
 var Cfg *Config
 
 func LoadConfig() (*Config, error) {
@@ -71,8 +546,25 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("invalid config: %w", err)
 	}
 
+	// Local mode keeps its stores and events in memory and runs no consumers,
+	// so the database, the broker and the legacy API need no address, and its
+	// audit log does not outlive the process to need a key
+	var exempt []string
+	if cfg.Environment == EnvironmentLocal {
+		exempt = []string{"Database.URL", "RabbitMQ.URL", "LegacyAPI.URL", "Audit.ChainKey"}
+	} else if cfg.Messaging.Backend != MessagingRabbitMQ {
+		exempt = []string{"RabbitMQ.URL"}
+	}
+	// Neither does another labor cost backend
+	if cfg.LaborCost.Backend != LaborCostLegacy && !slices.Contains(exempt, "LegacyAPI.URL") {
+		exempt = append(exempt, "LegacyAPI.URL")
+	}
+
 	validate := validator.New()
-	if err := validate.Struct(cfg); err != nil {
+	if err := validate.StructExcept(cfg, exempt...); err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
+	}
+	if err := cfg.checkQueueArgs(); err != nil {
 		return nil, fmt.Errorf("config validation failed: %w", err)
 	}
 
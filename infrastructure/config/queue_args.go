@@ -0,0 +1,118 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// RabbitMQ queue types
+const (
+	QueueTypeClassic = "classic"
+	QueueTypeQuorum  = "quorum"
+)
+
+// QueueArgs are the arguments a consumer queue is declared with
+type QueueArgs struct {
+	Type      string // classic or quorum
+	Lazy      bool   // keep messages on disk rather than in memory (classic only)
+	MaxLength int    // ready messages kept at most, 0 for no limit
+	// What happens to a message published beyond MaxLength: drop-head,
+	// reject-publish or reject-publish-dlx (classic only)
+	Overflow string
+}
+
+// QueueArgs returns the arguments of a queue: the RABBITMQ_QUEUE_* defaults
+// with the queue's RABBITMQ_QUEUE_OPTIONS entry applied. LoadConfig already
+// rejected options that do not parse.
+func (c *Config) QueueArgs(queue string) QueueArgs {
+	args, _ := c.queueArgs(queue)
+	return args
+}
+
+func (c *Config) queueArgs(queue string) (QueueArgs, error) {
+	args := QueueArgs{
+		Type:      c.RabbitMQ.QueueType,
+		Lazy:      c.RabbitMQ.QueueLazy,
+		MaxLength: c.RabbitMQ.QueueMaxLength,
+		Overflow:  c.RabbitMQ.QueueOverflow,
+	}
+
+	for _, entry := range c.RabbitMQ.QueueOptions {
+		name, options, ok := strings.Cut(entry, ":")
+		if !ok || name == "" {
+			return args, fmt.Errorf("RABBITMQ_QUEUE_OPTIONS entry %q is not queue:key=value;...", entry)
+		}
+		if name != queue {
+			continue
+		}
+		for _, option := range strings.Split(options, ";") {
+			if err := args.set(option); err != nil {
+				return args, fmt.Errorf("RABBITMQ_QUEUE_OPTIONS entry %q: %w", entry, err)
+			}
+		}
+	}
+	return args, args.check()
+}
+
+func (a *QueueArgs) set(option string) error {
+	key, value, ok := strings.Cut(strings.TrimSpace(option), "=")
+	if !ok {
+		return fmt.Errorf("option %q is not key=value", option)
+	}
+
+	var err error
+	switch key {
+	case "type":
+		a.Type = value
+	case "lazy":
+		a.Lazy, err = strconv.ParseBool(value)
+	case "max-length":
+		a.MaxLength, err = strconv.Atoi(value)
+	case "overflow":
+		a.Overflow = value
+	default:
+		return fmt.Errorf("unknown option %q, want type, lazy, max-length or overflow", key)
+	}
+	if err != nil {
+		return fmt.Errorf("option %s: %w", key, err)
+	}
+	return nil
+}
+
+// check rejects arguments RabbitMQ would refuse
+func (a QueueArgs) check() error {
+	if !slices.Contains([]string{QueueTypeClassic, QueueTypeQuorum}, a.Type) {
+		return fmt.Errorf("queue type %q is not classic or quorum", a.Type)
+	}
+	if !slices.Contains([]string{"drop-head", "reject-publish", "reject-publish-dlx"}, a.Overflow) {
+		return fmt.Errorf("overflow %q is not drop-head, reject-publish or reject-publish-dlx", a.Overflow)
+	}
+	if a.MaxLength < 0 {
+		return fmt.Errorf("max-length %d is negative", a.MaxLength)
+	}
+	if a.Type == QueueTypeQuorum && a.Lazy {
+		return fmt.Errorf("quorum queues cannot be lazy")
+	}
+	if a.Type == QueueTypeQuorum && a.Overflow == "reject-publish-dlx" {
+		return fmt.Errorf("quorum queues do not support overflow reject-publish-dlx")
+	}
+	return nil
+}
+
+// checkQueueArgs validates the arguments of every queue with options, and
+// the defaults
+func (c *Config) checkQueueArgs() error {
+	queues := []string{""}
+	for _, entry := range c.RabbitMQ.QueueOptions {
+		name, _, _ := strings.Cut(entry, ":")
+		queues = append(queues, name)
+	}
+	for _, queue := range queues {
+		if _, err := c.queueArgs(queue); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package external
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"go.uber.org/zap"
+)
+
+// AnchorStore uploads audit anchors to object storage with a plain HTTP PUT of
+// <baseURL>/<anchor id>.json. That works with S3/GCS presigned or
+// bucket-policy URLs and MinIO; use a write-once (object lock) bucket so
+// exported anchors cannot be rewritten together with the database.
+type AnchorStore struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+func NewAnchorStore(baseURL, token string) *AnchorStore {
+	return &AnchorStore{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+func (s *AnchorStore) Export(ctx context.Context, anchor *entities.AuditAnchor) error {
+	body, err := json.Marshal(anchor)
+	if err != nil {
+		return fmt.Errorf("failed to marshal anchor: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.baseURL+"/"+anchor.ID+".json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload anchor: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	config.Logger.Info("Audit anchor exported", zap.String("anchor_id", anchor.ID), zap.String("digest", anchor.Digest))
+	return nil
+}
@@ -0,0 +1,91 @@
+package external
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+// maxHookResponseBytes caps the metadata a hook may return
+const maxHookResponseBytes = 64 << 10
+
+// HTTPCheckInHook posts every check-in to an external service, e.g. the
+// facilities locker or desk assignment API, and returns its JSON response
+// (such as {"locker": "B-112"}) as the check-in metadata
+type HTTPCheckInHook struct {
+	name       string
+	url        string
+	token      string
+	httpClient *http.Client
+}
+
+func NewHTTPCheckInHook(name, url, token string) *HTTPCheckInHook {
+	return &HTTPCheckInHook{
+		name:  name,
+		url:   url,
+		token: token,
+		// Calls are bounded by the context deadline set per hook
+		httpClient: &http.Client{},
+	}
+}
+
+type checkInHookRequest struct {
+	EmployeeID string    `json:"employee_id"`
+	RecordID   string    `json:"record_id"`
+	LocationID string    `json:"location_id,omitempty"`
+	DeviceID   string    `json:"device_id,omitempty"`
+	CheckInAt  time.Time `json:"check_in_at"`
+}
+
+func (h *HTTPCheckInHook) Name() string {
+	return h.name
+}
+
+// AfterCheckIn returns nil metadata when the service answers 204 No Content
+func (h *HTTPCheckInHook) AfterCheckIn(ctx context.Context, record *entities.TimeRecord) (interface{}, error) {
+	body, err := json.Marshal(checkInHookRequest{
+		EmployeeID: record.EmployeeID,
+		RecordID:   record.ID,
+		LocationID: record.LocationID,
+		DeviceID:   record.DeviceID,
+		CheckInAt:  record.CheckInAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal check-in: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", record.ID)
+	if h.token != "" {
+		req.Header.Set("Authorization", "Bearer "+h.token)
+	}
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call %s hook: %w", h.name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+
+	var metadata interface{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxHookResponseBytes)).Decode(&metadata); err != nil {
+		return nil, fmt.Errorf("failed to decode %s hook response: %w", h.name, err)
+	}
+	return metadata, nil
+}
@@ -1,17 +1,26 @@
 package external
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
 	"fmt"
+	"mime/multipart"
+	"net"
 	"net/smtp"
+	"net/textproto"
+	"time"
 
 	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"github.com/leo-andrei/check-in-service/infrastructure/resilience"
 	"go.uber.org/zap"
 )
 
 type EmailClient struct {
 	smtpHost string
 	smtpPort int
+	// Guards the SMTP server, when set
+	circuitBreaker *resilience.CircuitBreaker
 }
 
 func NewEmailClient(smtpHost string, smtpPort int) *EmailClient {
@@ -21,18 +30,65 @@ func NewEmailClient(smtpHost string, smtpPort int) *EmailClient {
 	}
 }
 
-func (c *EmailClient) SendEmail(ctx context.Context, employeeID, subject, body string) error {
+// WithCircuitBreaker stops sending while the SMTP server keeps failing;
+// share one breaker between the clients of the same server
+func (c *EmailClient) WithCircuitBreaker(cb *resilience.CircuitBreaker) *EmailClient {
+	c.circuitBreaker = cb
+	return c
+}
+
+// SendEmail sends a plain text email. A non-empty idempotencyKey must be the
+// same on every attempt to send the email; see idempotencyHeaders.
+func (c *EmailClient) SendEmail(ctx context.Context, idempotencyKey, employeeID, subject, body string) error {
+	msg := fmt.Sprintf("%sSubject: %s\r\n\r\n%s", idempotencyHeaders(idempotencyKey), subject, body)
+	return c.send(ctx, employeeID, subject, []byte(msg))
+}
+
+// SendEmailWithHTML sends a plain text body with an HTML alternative, for
+// mail clients that render it
+func (c *EmailClient) SendEmailWithHTML(ctx context.Context, idempotencyKey, employeeID, subject, text, html string) error {
+	var parts bytes.Buffer
+	writer := multipart.NewWriter(&parts)
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", html},
+	} {
+		w, err := writer.CreatePart(textproto.MIMEHeader{"Content-Type": {part.contentType}})
+		if err != nil {
+			return fmt.Errorf("failed to build email: %w", err)
+		}
+		w.Write([]byte(part.body))
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to build email: %w", err)
+	}
+
+	msg := fmt.Sprintf("%sSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: multipart/alternative; boundary=%q\r\n\r\n", idempotencyHeaders(idempotencyKey), subject, writer.Boundary())
+	return c.send(ctx, employeeID, subject, append([]byte(msg), parts.Bytes()...))
+}
+
+// idempotencyHeaders derives the Message-ID from the idempotency key, so a
+// retry after a timeout is recognized by providers that drop messages with a
+// Message-ID they already accepted, and passes the key itself to providers
+// that read X-Idempotency-Key
+func idempotencyHeaders(key string) string {
+	if key == "" {
+		return ""
+	}
+	return fmt.Sprintf("Message-ID: <%s@company.com>\r\nX-Idempotency-Key: %s\r\n", key, key)
+}
+
+func (c *EmailClient) send(ctx context.Context, employeeID, subject string, msg []byte) error {
 	config.Logger.Info("Sending email", zap.String("employee_id", employeeID), zap.String("subject", subject))
 
-	// Connect to Mailhog SMTP server
-	addr := fmt.Sprintf("%s:%d", c.smtpHost, c.smtpPort)
-	err := smtp.SendMail(
-		addr,
-		nil, // no authentication for Mailhog
-		"noreply@company.com",
-		[]string{fmt.Sprintf("%s@company.com", employeeID)},
-		[]byte(fmt.Sprintf("Subject: %s\r\n\r\n%s", subject, body)),
-	)
+	var err error
+	if c.circuitBreaker == nil {
+		err = c.deliver(ctx, employeeID, msg)
+	} else {
+		err = c.circuitBreaker.Execute(ctx, func(ctx context.Context) error {
+			return c.deliver(ctx, employeeID, msg)
+		})
+	}
 
 	if err != nil {
 		config.Logger.Error("Failed to send email", zap.String("employee_id", employeeID), zap.Error(err))
@@ -42,3 +98,52 @@ func (c *EmailClient) SendEmail(ctx context.Context, employeeID, subject, body s
 	config.Logger.Info("Email sent", zap.String("employee_id", employeeID), zap.String("subject", subject))
 	return nil
 }
+
+// deliver hands msg to the SMTP server, giving up when ctx ends
+func (c *EmailClient) deliver(ctx context.Context, employeeID string, msg []byte) error {
+	// Connect to Mailhog SMTP server
+	addr := fmt.Sprintf("%s:%d", c.smtpHost, c.smtpPort)
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	// Unblock the exchange below when ctx is cancelled without a deadline
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, c.smtpHost)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	// Upgrade like smtp.SendMail does when the server offers it
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: c.smtpHost}); err != nil {
+			return err
+		}
+	}
+	// no authentication for Mailhog
+	if err := client.Mail("noreply@company.com"); err != nil {
+		return err
+	}
+	if err := client.Rcpt(fmt.Sprintf("%s@company.com", employeeID)); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
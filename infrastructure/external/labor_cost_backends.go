@@ -0,0 +1,118 @@
+package external
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"go.uber.org/zap"
+)
+
+// FileLaborCostExporter appends labor cost entries to a file, one JSON object
+// a line, for regions whose payroll imports files. A retried message may
+// append an entry twice; the importer drops duplicates by idempotency_key.
+type FileLaborCostExporter struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileLaborCostExporter(path string) *FileLaborCostExporter {
+	return &FileLaborCostExporter{path: path}
+}
+
+func (e *FileLaborCostExporter) RecordLaborCost(ctx context.Context, req LaborCostRequest) error {
+	if req.RecordedAt == "" {
+		req.RecordedAt = time.Now().Format(time.RFC3339)
+	}
+	line, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal labor cost entry: %w", err)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	// Opened per entry so the file can be rotated or collected between writes
+	f, err := os.OpenFile(e.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to open labor cost export: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write labor cost export: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to sync labor cost export: %w", err)
+	}
+	return f.Close()
+}
+
+// NopLaborCostClient drops labor cost entries, for regions without a payroll
+// integration
+type NopLaborCostClient struct{}
+
+func (NopLaborCostClient) RecordLaborCost(ctx context.Context, req LaborCostRequest) error {
+	config.Logger.Debug("Labor cost not reported, no backend", zap.String("employee_id", req.EmployeeID), zap.String("record_id", req.RecordID))
+	return nil
+}
+
+// WebhookLaborCostClient posts labor cost entries as JSON to a URL, with an
+// optional bearer token and the Idempotency-Key header. Any 2xx answer is a
+// success, and so is 409 for an entry the receiver already has. Other client
+// errors are permanent, like the legacy API's.
+type WebhookLaborCostClient struct {
+	url        string
+	token      string
+	httpClient *http.Client
+}
+
+func NewWebhookLaborCostClient(url, token string, timeout time.Duration) *WebhookLaborCostClient {
+	return &WebhookLaborCostClient{
+		url:        url,
+		token:      token,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+func (c *WebhookLaborCostClient) RecordLaborCost(ctx context.Context, req LaborCostRequest) error {
+	if req.RecordedAt == "" {
+		req.RecordedAt = time.Now().Format(time.RFC3339)
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal labor cost entry: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if req.IdempotencyKey != "" {
+		httpReq.Header.Set("Idempotency-Key", req.IdempotencyKey)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to call labor cost webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusConflict && req.IdempotencyKey != "" {
+		return nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newStatusError("labor cost webhook", resp)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package external
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"github.com/leo-andrei/check-in-service/infrastructure/metrics"
+	"go.uber.org/zap"
+)
+
+// LaborCostBatcher collects the labor cost entries of concurrent callers and
+// sends them to the legacy API's batch endpoint, once maxSize entries are
+// waiting or the first of them waited flushInterval. Each caller gets the
+// result of its own entry, so a rejected entry does not fail the others.
+type LaborCostBatcher struct {
+	client        *LegacyLaborCostClient
+	maxSize       int
+	flushInterval time.Duration
+	entries       chan *batchEntry
+}
+
+type batchEntry struct {
+	req  LaborCostRequest
+	done chan error
+}
+
+func NewLaborCostBatcher(client *LegacyLaborCostClient, maxSize int, flushInterval time.Duration) *LaborCostBatcher {
+	return &LaborCostBatcher{
+		client:        client,
+		maxSize:       maxSize,
+		flushInterval: flushInterval,
+		entries:       make(chan *batchEntry),
+	}
+}
+
+// RecordLaborCost queues the entry for the next batch and waits for its
+// result. A caller that gives up may still see its entry sent; the
+// idempotency key makes the next attempt a duplicate.
+func (b *LaborCostBatcher) RecordLaborCost(ctx context.Context, req LaborCostRequest) error {
+	entry := &batchEntry{req: req, done: make(chan error, 1)}
+	select {
+	case b.entries <- entry:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-entry.done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Run sends batches until ctx is done; entries still waiting then fail with
+// the context's error. Batches are sent one at a time.
+func (b *LaborCostBatcher) Run(ctx context.Context) {
+	var pending []*batchEntry
+	timer := time.NewTimer(b.flushInterval)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case entry := <-b.entries:
+			pending = append(pending, entry)
+			if len(pending) == 1 {
+				timer.Reset(b.flushInterval)
+			}
+			if len(pending) < b.maxSize {
+				continue
+			}
+			timer.Stop()
+		case <-timer.C:
+		case <-ctx.Done():
+			for _, entry := range pending {
+				entry.done <- ctx.Err()
+			}
+			return
+		}
+		b.flush(ctx, pending)
+		pending = nil
+	}
+}
+
+func (b *LaborCostBatcher) flush(ctx context.Context, batch []*batchEntry) {
+	reqs := make([]LaborCostRequest, len(batch))
+	for i, entry := range batch {
+		reqs[i] = entry.req
+	}
+
+	metrics.Incr("legacy_api.batches", 1)
+	metrics.Gauge("legacy_api.batch_size", float64(len(batch)))
+
+	start := time.Now()
+	results, err := b.client.RecordLaborCostBatch(ctx, reqs)
+	metrics.Timing("legacy_api.batch_duration", time.Since(start))
+	if err != nil {
+		config.Logger.Warn("Labor cost batch failed", zap.Int("entries", len(batch)), zap.Error(err))
+		for _, entry := range batch {
+			entry.done <- fmt.Errorf("labor cost batch: %w", err)
+		}
+		return
+	}
+
+	failed := 0
+	for i, entry := range batch {
+		if results[i] != nil {
+			failed++
+		}
+		entry.done <- results[i]
+	}
+	if failed > 0 {
+		metrics.Incr("legacy_api.batch_entries_failed", int64(failed))
+	}
+	config.Logger.Info("Labor cost batch sent", zap.Int("entries", len(batch)), zap.Int("failed", failed))
+}
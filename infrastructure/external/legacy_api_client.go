@@ -8,17 +8,26 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/leo-andrei/check-in-service/domain/errors"
 	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"github.com/leo-andrei/check-in-service/infrastructure/metrics"
+	"github.com/leo-andrei/check-in-service/infrastructure/resilience"
 	"go.uber.org/zap"
 )
 
 type LegacyLaborCostClient struct {
 	baseURL        string
 	httpClient     *http.Client
-	circuitBreaker *CircuitBreaker
+	circuitBreaker *resilience.CircuitBreaker
+	// Throttles the calls to the legacy API, when set
+	limiter *RateLimiter
+	// Caps the calls in flight, when set
+	bulkhead *resilience.Bulkhead
+	// Adds the credentials to the requests, when set
+	auth RequestAuthorizer
 }
 
-func NewLegacyLaborCostClient(baseURL string, cb *CircuitBreaker) *LegacyLaborCostClient {
+func NewLegacyLaborCostClient(baseURL string, cb *resilience.CircuitBreaker) *LegacyLaborCostClient {
 	timeoutSec := 30
 	if v, ok := interface{}(cb).(interface{ TimeoutSec() int }); ok {
 		timeoutSec = v.TimeoutSec()
@@ -35,29 +44,66 @@ func NewLegacyLaborCostClient(baseURL string, cb *CircuitBreaker) *LegacyLaborCo
 	}
 }
 
+// WithAuth sends the credentials of auth with every call
+func (c *LegacyLaborCostClient) WithAuth(auth RequestAuthorizer) *LegacyLaborCostClient {
+	c.auth = auth
+	return c
+}
+
+// WithBulkhead caps the calls in flight with bulkhead; share one bulkhead
+// between the clients calling the same API
+func (c *LegacyLaborCostClient) WithBulkhead(bulkhead *resilience.Bulkhead) *LegacyLaborCostClient {
+	c.bulkhead = bulkhead
+	return c
+}
+
+// WithRateLimiter throttles the calls with limiter; share one limiter between
+// the clients calling the same API
+func (c *LegacyLaborCostClient) WithRateLimiter(limiter *RateLimiter) *LegacyLaborCostClient {
+	c.limiter = limiter
+	return c
+}
+
+const (
+	HourTypeRegular  = "regular"
+	HourTypeOvertime = "overtime"
+
+	AdjustmentCorrection = "correction"
+	AdjustmentVoid       = "void"
+)
+
 type LaborCostRequest struct {
 	EmployeeID  string  `json:"employee_id"`
 	HoursWorked float64 `json:"hours_worked"`
 	RecordedAt  string  `json:"recorded_at"`
+	RecordID    string  `json:"record_id,omitempty"`
+	HourType    string  `json:"hour_type,omitempty"` // regular or overtime
+	ProjectCode string  `json:"project_code,omitempty"`
+	// Hours of the record worked on holidays and weekends, for premium rates
+	HolidayHours float64 `json:"holiday_hours,omitempty"`
+	WeekendHours float64 `json:"weekend_hours,omitempty"`
+	// Money value of HoursWorked as a decimal in Currency; empty when unpriced
+	Cost       string  `json:"cost,omitempty"`
+	Currency   string  `json:"currency,omitempty"`
+	HourlyRate float64 `json:"hourly_rate,omitempty"`
+	// Set on compensating entries for a record reported earlier (correction or
+	// void); their hours and cost are the signed difference
+	Adjustment string `json:"adjustment,omitempty"`
+	// The same on every attempt to send the entry, so the API records it once
+	// however often it is retried or redelivered; also sent as the
+	// Idempotency-Key header
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
 }
 
-func (c *LegacyLaborCostClient) RecordLaborCost(ctx context.Context, employeeID string, hours float64) error {
+func (c *LegacyLaborCostClient) RecordLaborCost(ctx context.Context, reqBody LaborCostRequest) error {
+	employeeID := reqBody.EmployeeID
+	hours := reqBody.HoursWorked
+
 	// Log request
-	config.Logger.Info("Sending labor cost to legacy API", zap.String("employee_id", employeeID), zap.Float64("hours", hours))
-	if c.circuitBreaker != nil {
-		canExecute, err := c.circuitBreaker.CanExecute()
-		if err != nil {
-			return fmt.Errorf("circuit breaker error: %w", err)
-		}
-		if !canExecute {
-			return fmt.Errorf("circuit breaker open: legacy API temporarily unavailable")
-		}
-	}
+	config.Logger.Info("Sending labor cost to legacy API", zap.String("employee_id", employeeID), zap.Float64("hours", hours), zap.String("hour_type", reqBody.HourType))
 
-	reqBody := LaborCostRequest{
-		EmployeeID:  employeeID,
-		HoursWorked: hours,
-		RecordedAt:  time.Now().Format(time.RFC3339),
+	if reqBody.RecordedAt == "" {
+		reqBody.RecordedAt = time.Now().Format(time.RFC3339)
 	}
 
 	jsonBody, err := json.Marshal(reqBody)
@@ -66,36 +112,160 @@ func (c *LegacyLaborCostClient) RecordLaborCost(ctx context.Context, employeeID
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/labor-cost", bytes.NewBuffer(jsonBody))
+	if err := c.call(ctx, "/api/labor-cost", reqBody.IdempotencyKey, jsonBody, nil); err != nil {
+		return err
+	}
+	config.Logger.Info("Labor cost sent successfully", zap.String("employee_id", employeeID), zap.Float64("hours", hours))
+	return nil
+}
+
+type laborCostBatchRequest struct {
+	Entries []LaborCostRequest `json:"entries"`
+}
+
+// laborCostBatchResponse has one result per entry, in the order sent
+type laborCostBatchResponse struct {
+	Results []laborCostBatchResult `json:"results"`
+}
+
+type laborCostBatchResult struct {
+	// recorded, duplicate (already recorded under its idempotency key),
+	// rejected (invalid, permanent) or failed (may be retried)
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// RecordLaborCostBatch sends entries in one call to the batch endpoint. The
+// error is the call's; when it is nil, results holds the outcome of each
+// entry, nil for those recorded.
+func (c *LegacyLaborCostClient) RecordLaborCostBatch(ctx context.Context, entries []LaborCostRequest) ([]error, error) {
+	config.Logger.Info("Sending labor cost batch to legacy API", zap.Int("entries", len(entries)))
+
+	now := time.Now().Format(time.RFC3339)
+	for i := range entries {
+		if entries[i].RecordedAt == "" {
+			entries[i].RecordedAt = now
+		}
+	}
+	jsonBody, err := json.Marshal(laborCostBatchRequest{Entries: entries})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal batch: %w", err)
+	}
+
+	var batch laborCostBatchResponse
+	err = c.call(ctx, "/api/labor-cost/batch", "", jsonBody, func(resp *http.Response) error {
+		if err := json.NewDecoder(resp.Body).Decode(&batch); err != nil {
+			return fmt.Errorf("failed to decode batch response: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]error, len(entries))
+	for i := range entries {
+		if i >= len(batch.Results) {
+			results[i] = fmt.Errorf("no result for entry %d of the batch", i)
+			continue
+		}
+		switch result := batch.Results[i]; result.Status {
+		case "recorded":
+		case "duplicate":
+			metrics.Incr("legacy_api.duplicates", 1)
+		case "rejected":
+			results[i] = errors.Permanent(fmt.Errorf("legacy API rejected the entry: %s", result.Error))
+		default:
+			results[i] = fmt.Errorf("legacy API failed to record the entry: %s", result.Error)
+		}
+	}
+	return results, nil
+}
+
+// call posts body to path through the rate limiter, the bulkhead and the
+// circuit breaker; decode reads a successful answer, when given
+func (c *LegacyLaborCostClient) call(ctx context.Context, path, idempotencyKey string, body []byte, decode func(resp *http.Response) error) error {
+	if c.limiter != nil {
+		waited, err := c.limiter.Wait(ctx)
+		if err != nil {
+			metrics.Incr("legacy_api.rate_limited", 1)
+			return fmt.Errorf("rate limited: %w", err)
+		}
+		if waited > 0 {
+			metrics.Incr("legacy_api.throttled", 1)
+			metrics.Timing("legacy_api.throttle_wait", waited)
+		}
+	}
+
+	if c.bulkhead != nil {
+		release, waited, err := c.bulkhead.Acquire(ctx)
+		if waited > 0 {
+			metrics.Timing("legacy_api.bulkhead_wait", waited)
+		}
+		if err != nil {
+			metrics.Incr("legacy_api.bulkhead_rejected", 1)
+			return fmt.Errorf("bulkhead: %w", err)
+		}
+		defer release()
+		metrics.Gauge("legacy_api.in_flight", float64(c.bulkhead.InFlight()))
+	}
+
+	if c.circuitBreaker == nil {
+		return c.post(ctx, path, idempotencyKey, body, decode)
+	}
+	return c.circuitBreaker.Execute(ctx, func(ctx context.Context) error {
+		return c.post(ctx, path, idempotencyKey, body, decode)
+	})
+}
+
+// post sends one request; any error is a failure of the legacy API. The API
+// answers 409 to an entry whose idempotency key it already recorded, which is
+// a success: an earlier attempt got through.
+func (c *LegacyLaborCostClient) post(ctx context.Context, path, idempotencyKey string, jsonBody []byte, decode func(resp *http.Response) error) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+path, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		config.Logger.Error("Failed to create labor cost request", zap.Error(err))
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+	if c.auth != nil {
+		if err := c.auth.Authorize(ctx, req); err != nil {
+			config.Logger.Error("Failed to authorize labor cost request", zap.Error(err))
+			return fmt.Errorf("failed to authorize request: %w", err)
+		}
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		if c.circuitBreaker != nil {
-			c.circuitBreaker.RecordFailure()
-		}
 		config.Logger.Error("Failed to send labor cost request", zap.Error(err))
 		return fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		if c.circuitBreaker != nil {
-			c.circuitBreaker.RecordFailure()
+	if resp.StatusCode == http.StatusUnauthorized {
+		// A revoked or expired token is requested again on the retry, so
+		// unlike other client errors this one is worth retrying
+		if token, ok := c.auth.(*ClientCredentialsAuth); ok {
+			token.Invalidate()
+			config.Logger.Warn("Legacy API refused the token, requesting a new one on retry")
+			return &StatusError{Service: "legacy API", StatusCode: resp.StatusCode}
 		}
+	}
+	if resp.StatusCode == http.StatusConflict && idempotencyKey != "" {
+		metrics.Incr("legacy_api.duplicates", 1)
+		config.Logger.Info("Labor cost already recorded by the legacy API", zap.String("idempotency_key", idempotencyKey))
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
 		config.Logger.Error("Unexpected status code from legacy API", zap.Int("status_code", resp.StatusCode))
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return newStatusError("legacy API", resp)
 	}
-	config.Logger.Info("Labor cost sent successfully", zap.String("employee_id", employeeID), zap.Float64("hours", hours))
-
-	if c.circuitBreaker != nil {
-		c.circuitBreaker.RecordSuccess()
+	if decode != nil {
+		return decode(resp)
 	}
-
 	return nil
 }
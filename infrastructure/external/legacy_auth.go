@@ -0,0 +1,171 @@
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+)
+
+// tokenExpirySkew renews OAuth2 tokens this long before they expire, so a
+// token does not run out during a call
+const tokenExpirySkew = 30 * time.Second
+
+// RequestAuthorizer adds credentials to the requests to an API
+type RequestAuthorizer interface {
+	Authorize(ctx context.Context, req *http.Request) error
+}
+
+// NewLegacyAuth returns the authorizer LEGACY_API_AUTH selects, nil for none
+func NewLegacyAuth() RequestAuthorizer {
+	settings := config.Cfg.LegacyAPI
+	switch settings.Auth {
+	case config.LegacyAuthAPIKey:
+		return &APIKeyAuth{Header: settings.APIKeyHeader, Key: settings.APIKey}
+	case config.LegacyAuthBasic:
+		return &BasicAuth{Username: settings.Username, Password: settings.Password}
+	case config.LegacyAuthOAuth2:
+		return NewClientCredentialsAuth(settings.TokenURL, settings.ClientID, settings.ClientSecret, settings.Scopes)
+	default:
+		return nil
+	}
+}
+
+// APIKeyAuth sends a static key in a header
+type APIKeyAuth struct {
+	Header string
+	Key    string
+}
+
+func (a *APIKeyAuth) Authorize(_ context.Context, req *http.Request) error {
+	req.Header.Set(a.Header, a.Key)
+	return nil
+}
+
+// BasicAuth sends a username and password
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+func (a *BasicAuth) Authorize(_ context.Context, req *http.Request) error {
+	req.SetBasicAuth(a.Username, a.Password)
+	return nil
+}
+
+// ClientCredentialsAuth sends a bearer token obtained with the OAuth2 client
+// credentials grant. The token is cached until shortly before it expires, and
+// requested again after the API refused it.
+type ClientCredentialsAuth struct {
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	scopes       []string
+	httpClient   *http.Client
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+func NewClientCredentialsAuth(tokenURL, clientID, clientSecret string, scopes []string) *ClientCredentialsAuth {
+	return &ClientCredentialsAuth{
+		tokenURL:     tokenURL,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		scopes:       scopes,
+		// Token requests are bounded by the context of the call
+		httpClient: &http.Client{},
+	}
+}
+
+func (a *ClientCredentialsAuth) Authorize(ctx context.Context, req *http.Request) error {
+	token, err := a.Token(ctx)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// Token returns the cached token, requesting a new one when there is none or
+// it is about to expire. Concurrent callers wait for a single request.
+func (a *ClientCredentialsAuth) Token(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token != "" && (a.expiresAt.IsZero() || time.Now().Before(a.expiresAt)) {
+		return a.token, nil
+	}
+
+	token, expiresIn, err := a.requestToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	a.token = token
+	// A token without expires_in is used until the API refuses it
+	a.expiresAt = time.Time{}
+	if expiresIn > 0 {
+		lifetime := time.Duration(expiresIn) * time.Second
+		a.expiresAt = time.Now().Add(lifetime - min(tokenExpirySkew, lifetime/2))
+	}
+	return a.token, nil
+}
+
+// Invalidate drops the cached token, e.g. after the API answered 401 because
+// it was revoked
+func (a *ClientCredentialsAuth) Invalidate() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.token = ""
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+func (a *ClientCredentialsAuth) requestToken(ctx context.Context) (string, int, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(a.scopes) > 0 {
+		form.Set("scope", strings.Join(a.scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", a.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(a.clientID), url.QueryEscape(a.clientSecret))
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to request token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", 0, fmt.Errorf("token endpoint answered %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var token tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", 0, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", 0, fmt.Errorf("token response has no access_token")
+	}
+	if token.TokenType != "" && !strings.EqualFold(token.TokenType, "bearer") {
+		return "", 0, fmt.Errorf("token type %q is not bearer", token.TokenType)
+	}
+	return token.AccessToken, token.ExpiresIn, nil
+}
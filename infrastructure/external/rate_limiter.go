@@ -1,11 +1,15 @@
 package external
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
 )
 
+// RateLimiter is a token bucket: it allows requestsPerMinute requests a
+// minute, in bursts of up to that many. It is safe for concurrent use and
+// never sleeps while holding its lock.
 type RateLimiter struct {
 	tokens       float64
 	maxTokens    float64
@@ -27,44 +31,84 @@ func NewRateLimiter(requestsPerMinute int) *RateLimiter {
 	}
 }
 
-// WaitForToken blocks until token available, returns wait duration
-func (rl *RateLimiter) WaitForToken(maxWait time.Duration) (time.Duration, error) {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-
-	// Refill based on time elapsed
-	now := time.Now()
+// refill adds the tokens earned since the last refill; callers hold mu
+func (rl *RateLimiter) refill(now time.Time) {
 	elapsed := now.Sub(rl.lastRefillAt).Seconds()
-	tokensToAdd := elapsed * rl.refillRate
-	rl.tokens = min(rl.tokens+tokensToAdd, rl.maxTokens)
+	rl.tokens = min(rl.tokens+elapsed*rl.refillRate, rl.maxTokens)
 	rl.lastRefillAt = now
+}
 
-	// If token available, use it immediately
-	if rl.tokens >= 1.0 {
-		rl.tokens -= 1.0
-		return 0, nil
+// Allow takes a token if one is available right now
+func (rl *RateLimiter) Allow() bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.refill(time.Now())
+	if rl.tokens < 1 {
+		return false
 	}
+	rl.tokens--
+	return true
+}
+
+// Reservation is a token taken ahead of time, usable after Delay
+type Reservation struct {
+	limiter *RateLimiter
+	delay   time.Duration
+}
 
-	// Calculate wait time for next token
-	tokenDeficit := 1.0 - rl.tokens
-	waitSeconds := tokenDeficit / rl.refillRate
-	waitDuration := time.Duration(waitSeconds * float64(time.Second))
+// Delay is how long to wait before acting on the reservation
+func (r *Reservation) Delay() time.Duration {
+	return r.delay
+}
 
-	// Too long to wait?
-	if waitDuration > maxWait {
-		return 0, fmt.Errorf("rate limit requires %.1fs wait, max allowed: %.1fs",
-			waitDuration.Seconds(), maxWait.Seconds())
-	}
+// Cancel gives the token back, for a reservation that will not be used
+func (r *Reservation) Cancel() {
+	rl := r.limiter
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
 
-	// Wait and recurse
-	time.Sleep(waitDuration + 100*time.Millisecond) // Small buffer
-	additionalWait, err := rl.WaitForToken(maxWait - waitDuration)
-	return waitDuration + 100*time.Millisecond + additionalWait, err
+	rl.refill(time.Now())
+	rl.tokens = min(rl.tokens+1, rl.maxTokens)
 }
 
-func min(a, b float64) float64 {
-	if a < b {
-		return a
+// Reserve takes the next token, even one not earned yet, and tells how long
+// to wait before it is. Later callers queue behind it.
+func (rl *RateLimiter) Reserve() *Reservation {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.refill(time.Now())
+	rl.tokens--
+	var delay time.Duration
+	if rl.tokens < 0 {
+		delay = time.Duration(-rl.tokens / rl.refillRate * float64(time.Second))
+	}
+	return &Reservation{limiter: rl, delay: delay}
+}
+
+// Wait blocks until a token is available, or until ctx is done, and returns
+// how long it waited. When ctx would expire before the token, it gives up at
+// once rather than wait in vain.
+func (rl *RateLimiter) Wait(ctx context.Context) (time.Duration, error) {
+	reservation := rl.Reserve()
+	delay := reservation.Delay()
+	if delay == 0 {
+		return 0, nil
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		reservation.Cancel()
+		return 0, fmt.Errorf("rate limit requires %.1fs wait, beyond the context deadline", delay.Seconds())
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return delay, nil
+	case <-ctx.Done():
+		reservation.Cancel()
+		return 0, ctx.Err()
 	}
-	return b
 }
@@ -0,0 +1,72 @@
+package external
+
+import (
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/errors"
+)
+
+// StatusError is an unexpected answer of an external service
+type StatusError struct {
+	Service    string
+	StatusCode int
+	// How long the service asked to wait before the next attempt, from its
+	// Retry-After header; 0 when it did not say
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code from %s: %d", e.Service, e.StatusCode)
+}
+
+// newStatusError classifies an unexpected answer: server errors, 408 and 429
+// may go away on a later attempt, other client errors are permanent
+func newStatusError(service string, resp *http.Response) error {
+	err := &StatusError{
+		Service:    service,
+		StatusCode: resp.StatusCode,
+		RetryAfter: retryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+	if retryableStatus(resp.StatusCode) {
+		return err
+	}
+	return errors.Permanent(err)
+}
+
+func retryableStatus(code int) bool {
+	return code >= 500 || code == http.StatusTooManyRequests || code == http.StatusRequestTimeout
+}
+
+// retryAfter reads a Retry-After header, either seconds or an HTTP date
+func retryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0)
+	}
+	return 0
+}
+
+// RetryAfter returns how long the service behind err asked to wait, 0 when
+// it did not
+func RetryAfter(err error) time.Duration {
+	var statusErr *StatusError
+	if stderrors.As(err, &statusErr) {
+		return statusErr.RetryAfter
+	}
+	return 0
+}
+
+// IsServiceFailure tells whether err means the service is failing, for its
+// circuit breaker: a request it refused as invalid says it is up
+func IsServiceFailure(err error) bool {
+	return !errors.IsPermanent(err)
+}
@@ -0,0 +1,71 @@
+// Package incidents opens and resolves incidents in PagerDuty or Opsgenie when
+// an operational condition (open circuit breaker, growing dead letter queue,
+// outbox backlog) crosses its threshold.
+package incidents
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	ProviderNone      = "none"
+	ProviderPagerDuty = "pagerduty"
+	ProviderOpsgenie  = "opsgenie"
+)
+
+// Incident is one triggered alert. DedupKey identifies the condition, not
+// the replica, so every replica seeing it updates the same incident.
+type Incident struct {
+	DedupKey string
+	Summary  string
+	Severity string // critical, error, warning or info
+	Source   string
+	Details  map[string]interface{}
+}
+
+// Sink creates and resolves incidents in an incident management service
+type Sink interface {
+	Trigger(ctx context.Context, incident Incident) error
+	Resolve(ctx context.Context, dedupKey string) error
+}
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// postJSON sends body and accepts any 2xx answer, plus the extra statuses
+// given (e.g. 404 when closing an alert that no longer exists)
+func postJSON(ctx context.Context, url string, headers map[string]string, body interface{}, accepted ...int) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	for _, status := range accepted {
+		if resp.StatusCode == status {
+			return nil
+		}
+	}
+	return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+}
@@ -0,0 +1,210 @@
+package incidents
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"github.com/leo-andrei/check-in-service/infrastructure/messaging"
+	"github.com/leo-andrei/check-in-service/infrastructure/resilience"
+	"go.uber.org/zap"
+)
+
+// Alert types, each with its own Rule
+const (
+	AlertBreakerOpen   = "breaker_open"
+	AlertDLQDepth      = "dlq_depth"
+	AlertOutboxBacklog = "outbox_backlog"
+	AlertOutboxFailed  = "outbox_failed"
+)
+
+// Rule routes an alert type to a sink; a nil Sink turns the alert off.
+// Threshold is the DLQ depth or the pending outbox events that trigger it.
+type Rule struct {
+	Sink      Sink
+	Severity  string
+	Threshold int
+	// MaxLag also triggers the outbox alert when the oldest pending event is
+	// older; 0 disables it
+	MaxLag time.Duration
+}
+
+// Breaker is a circuit breaker guarding an external service
+type Breaker interface {
+	GetState() resilience.CircuitState
+	OpenFor() time.Duration
+}
+
+// QueueDepths reports the depth of the consumer queues and their DLQs
+type QueueDepths interface {
+	Depths(ctx context.Context) ([]messaging.QueueDepth, error)
+}
+
+// OutboxBacklog counts the events the outbox relay still has to publish
+type OutboxBacklog interface {
+	Backlog(ctx context.Context) (repositories.OutboxBacklog, error)
+}
+
+// Monitor evaluates the alert conditions and triggers or resolves their
+// incidents when a condition changes. Conditions it could not evaluate (e.g.
+// the broker is unreachable) keep their incident as it is.
+type Monitor struct {
+	source   string
+	rules    map[string]Rule
+	breakers map[string]Breaker
+	queues   QueueDepths // nil when there is no broker (local mode)
+	outbox   OutboxBacklog
+
+	// Whether the incident of each dedup key is open; keys not seen since
+	// startup are unknown and get resolved once if their condition is clear
+	open map[string]bool
+}
+
+func NewMonitor(source string, rules map[string]Rule, breakers map[string]Breaker, queues QueueDepths, outbox OutboxBacklog) *Monitor {
+	return &Monitor{
+		source:   source,
+		rules:    rules,
+		breakers: breakers,
+		queues:   queues,
+		outbox:   outbox,
+		open:     make(map[string]bool),
+	}
+}
+
+// condition is the state of one alert condition at a check
+type condition struct {
+	alertType string
+	firing    bool
+	incident  Incident
+}
+
+// Check evaluates every condition once
+func (m *Monitor) Check(ctx context.Context) {
+	for _, c := range m.conditions(ctx) {
+		rule := m.rules[c.alertType]
+		if rule.Sink == nil {
+			continue
+		}
+
+		key := c.incident.DedupKey
+		open, known := m.open[key]
+		switch {
+		case c.firing && !open:
+			c.incident.Severity = rule.Severity
+			c.incident.Source = m.source
+			if err := rule.Sink.Trigger(ctx, c.incident); err != nil {
+				config.Logger.Error("Failed to trigger incident", zap.String("dedup_key", key), zap.Error(err))
+				continue
+			}
+			m.open[key] = true
+			config.Logger.Warn("Incident triggered",
+				zap.String("alert", c.alertType),
+				zap.String("dedup_key", key),
+				zap.String("summary", c.incident.Summary),
+				zap.Any("details", c.incident.Details))
+
+		case !c.firing && (open || !known):
+			if err := rule.Sink.Resolve(ctx, key); err != nil {
+				config.Logger.Error("Failed to resolve incident", zap.String("dedup_key", key), zap.Error(err))
+				continue
+			}
+			m.open[key] = false
+			if open {
+				config.Logger.Info("Incident resolved", zap.String("alert", c.alertType), zap.String("dedup_key", key))
+			}
+		}
+	}
+}
+
+func (m *Monitor) conditions(ctx context.Context) []condition {
+	var conditions []condition
+
+	for name, breaker := range m.breakers {
+		state := breaker.GetState()
+		conditions = append(conditions, condition{
+			alertType: AlertBreakerOpen,
+			firing:    state == resilience.StateOpen,
+			incident: Incident{
+				DedupKey: m.source + "/" + AlertBreakerOpen + "/" + name,
+				Summary:  fmt.Sprintf("Circuit breaker for %s is open", name),
+				Details: map[string]interface{}{
+					"breaker":      name,
+					"state":        string(state),
+					"open_for_sec": int(breaker.OpenFor().Seconds()),
+				},
+			},
+		})
+	}
+
+	if m.queues != nil && m.rules[AlertDLQDepth].Sink != nil {
+		threshold := m.rules[AlertDLQDepth].Threshold
+		depths, err := m.queues.Depths(ctx)
+		if err != nil {
+			config.Logger.Warn("Failed to read queue depths for incidents", zap.Error(err))
+		}
+		for _, depth := range depths {
+			conditions = append(conditions, condition{
+				alertType: AlertDLQDepth,
+				firing:    depth.DLQMessages >= threshold,
+				incident: Incident{
+					DedupKey: m.source + "/" + AlertDLQDepth + "/" + depth.Queue,
+					Summary:  fmt.Sprintf("%d messages in dead letter queue %s", depth.DLQMessages, depth.DLQ),
+					Details: map[string]interface{}{
+						"queue":        depth.Queue,
+						"dlq":          depth.DLQ,
+						"dlq_messages": depth.DLQMessages,
+						"threshold":    threshold,
+					},
+				},
+			})
+		}
+	}
+
+	rule, failedRule := m.rules[AlertOutboxBacklog], m.rules[AlertOutboxFailed]
+	if rule.Sink == nil && failedRule.Sink == nil {
+		return conditions
+	}
+	backlog, err := m.outbox.Backlog(ctx)
+	if err != nil {
+		config.Logger.Warn("Failed to read outbox backlog for incidents", zap.Error(err))
+		return conditions
+	}
+
+	if rule.Sink != nil {
+		var lag time.Duration
+		if backlog.OldestAt != nil {
+			lag = time.Since(*backlog.OldestAt)
+		}
+		conditions = append(conditions, condition{
+			alertType: AlertOutboxBacklog,
+			firing:    backlog.Pending >= rule.Threshold || (rule.MaxLag > 0 && lag >= rule.MaxLag),
+			incident: Incident{
+				DedupKey: m.source + "/" + AlertOutboxBacklog,
+				Summary:  fmt.Sprintf("%d outbox events pending, oldest %s old", backlog.Pending, lag.Truncate(time.Second)),
+				Details: map[string]interface{}{
+					"pending":     backlog.Pending,
+					"lag_seconds": int(lag.Seconds()),
+					"threshold":   rule.Threshold,
+				},
+			},
+		})
+	}
+
+	if failedRule.Sink != nil {
+		conditions = append(conditions, condition{
+			alertType: AlertOutboxFailed,
+			firing:    backlog.Failed > 0,
+			incident: Incident{
+				DedupKey: m.source + "/" + AlertOutboxFailed,
+				Summary:  fmt.Sprintf("%d outbox events out of retries, waiting to be requeued", backlog.Failed),
+				Details: map[string]interface{}{
+					"failed": backlog.Failed,
+				},
+			},
+		})
+	}
+
+	return conditions
+}
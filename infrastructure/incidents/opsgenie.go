@@ -0,0 +1,72 @@
+package incidents
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Opsgenie creates and closes alerts through the Alert API; the dedup key is
+// the alert alias, which Opsgenie deduplicates open alerts on
+type Opsgenie struct {
+	baseURL string
+	apiKey  string
+}
+
+func NewOpsgenie(baseURL, apiKey string) *Opsgenie {
+	return &Opsgenie{baseURL: baseURL, apiKey: apiKey}
+}
+
+type opsgenieAlert struct {
+	Message     string                 `json:"message"`
+	Alias       string                 `json:"alias"`
+	Description string                 `json:"description,omitempty"`
+	Source      string                 `json:"source,omitempty"`
+	Priority    string                 `json:"priority"`
+	Details     map[string]interface{} `json:"details,omitempty"`
+}
+
+type opsgenieClose struct {
+	Source string `json:"source,omitempty"`
+	Note   string `json:"note,omitempty"`
+}
+
+// opsgeniePriorities maps incident severities to alert priorities
+var opsgeniePriorities = map[string]string{
+	"critical": "P1",
+	"error":    "P2",
+	"warning":  "P3",
+	"info":     "P5",
+}
+
+func (o *Opsgenie) Trigger(ctx context.Context, incident Incident) error {
+	priority, ok := opsgeniePriorities[incident.Severity]
+	if !ok {
+		priority = "P3"
+	}
+
+	// Opsgenie details are string values only
+	details := make(map[string]interface{}, len(incident.Details))
+	for key, value := range incident.Details {
+		details[key] = fmt.Sprint(value)
+	}
+
+	return postJSON(ctx, o.baseURL+"/v2/alerts", o.headers(), opsgenieAlert{
+		Message:  incident.Summary,
+		Alias:    incident.DedupKey,
+		Source:   incident.Source,
+		Priority: priority,
+		Details:  details,
+	})
+}
+
+// Resolve closes the alert; an alias with no alert is not an error
+func (o *Opsgenie) Resolve(ctx context.Context, dedupKey string) error {
+	closeURL := o.baseURL + "/v2/alerts/" + url.PathEscape(dedupKey) + "/close?identifierType=alias"
+	return postJSON(ctx, closeURL, o.headers(), opsgenieClose{Source: "check-in-service", Note: "Condition cleared"}, http.StatusNotFound)
+}
+
+func (o *Opsgenie) headers() map[string]string {
+	return map[string]string{"Authorization": "GenieKey " + o.apiKey}
+}
@@ -0,0 +1,56 @@
+package incidents
+
+import (
+	"context"
+	"time"
+)
+
+// PagerDuty sends Events API v2 events to one integration
+type PagerDuty struct {
+	url        string
+	routingKey string
+}
+
+func NewPagerDuty(url, routingKey string) *PagerDuty {
+	return &PagerDuty{url: url, routingKey: routingKey}
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string                 `json:"summary"`
+	Source        string                 `json:"source"`
+	Severity      string                 `json:"severity"`
+	Timestamp     string                 `json:"timestamp"`
+	CustomDetails map[string]interface{} `json:"custom_details,omitempty"`
+}
+
+// Trigger opens the incident, or adds to the one open under its dedup key
+func (p *PagerDuty) Trigger(ctx context.Context, incident Incident) error {
+	return postJSON(ctx, p.url, nil, pagerDutyEvent{
+		RoutingKey:  p.routingKey,
+		EventAction: "trigger",
+		DedupKey:    incident.DedupKey,
+		Payload: &pagerDutyPayload{
+			Summary:       incident.Summary,
+			Source:        incident.Source,
+			Severity:      incident.Severity,
+			Timestamp:     time.Now().UTC().Format(time.RFC3339),
+			CustomDetails: incident.Details,
+		},
+	})
+}
+
+// Resolve is accepted even when no incident is open under the key
+func (p *PagerDuty) Resolve(ctx context.Context, dedupKey string) error {
+	return postJSON(ctx, p.url, nil, pagerDutyEvent{
+		RoutingKey:  p.routingKey,
+		EventAction: "resolve",
+		DedupKey:    dedupKey,
+	})
+}
@@ -0,0 +1,168 @@
+// Package leader elects one instance among the replicas of the service to
+// run the background jobs that must not run twice at once.
+package leader
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"github.com/leo-andrei/check-in-service/infrastructure/metrics"
+	"go.uber.org/zap"
+)
+
+// Lock is held by at most one instance at a time
+type Lock interface {
+	// TryAcquire takes the lock unless another instance holds it
+	TryAcquire(ctx context.Context) (bool, error)
+	// Check fails once the lock may have been lost, e.g. with the
+	// connection holding it
+	Check(ctx context.Context) error
+	Release(ctx context.Context) error
+}
+
+// SingleInstance is the lock of a deployment that runs one instance, e.g.
+// local mode: it is always granted
+type SingleInstance struct{}
+
+func (SingleInstance) TryAcquire(ctx context.Context) (bool, error) { return true, nil }
+func (SingleInstance) Check(ctx context.Context) error              { return nil }
+func (SingleInstance) Release(ctx context.Context) error            { return nil }
+
+// Elector campaigns for the lock every interval and checks it still holds it
+// while leading. Jobs started with RunWhileLeader run for one term: they are
+// cancelled when the lock is lost and started again on the next term, on
+// whichever instance wins it.
+type Elector struct {
+	lock     Lock
+	interval time.Duration
+
+	mu      sync.Mutex
+	term    context.Context // Current term, nil while not leading
+	endTerm context.CancelFunc
+	changed chan struct{} // Closed and replaced when a term starts
+}
+
+func NewElector(lock Lock, interval time.Duration) *Elector {
+	return &Elector{
+		lock:     lock,
+		interval: interval,
+		changed:  make(chan struct{}),
+	}
+}
+
+// Run campaigns until ctx is done, then steps down and releases the lock
+func (e *Elector) Run(ctx context.Context) {
+	metrics.Gauge("leader.is_leader", 0)
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		e.campaign(ctx)
+
+		select {
+		case <-ctx.Done():
+			if e.IsLeader() {
+				e.stepDown("shutting down")
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Elector) campaign(ctx context.Context) {
+	if e.IsLeader() {
+		if err := e.lock.Check(ctx); err != nil && ctx.Err() == nil {
+			e.stepDown(err.Error())
+		}
+		return
+	}
+
+	acquired, err := e.lock.TryAcquire(ctx)
+	if err != nil {
+		config.Logger.Warn("Failed to campaign for leadership", zap.Error(err))
+		return
+	}
+	if !acquired {
+		return
+	}
+
+	e.mu.Lock()
+	e.term, e.endTerm = context.WithCancel(context.Background())
+	close(e.changed)
+	e.changed = make(chan struct{})
+	e.mu.Unlock()
+
+	metrics.Gauge("leader.is_leader", 1)
+	metrics.Incr("leader.elected", 1)
+	config.Logger.Info("Elected leader, starting singleton jobs")
+}
+
+// stepDown ends the term before releasing the lock, so the jobs are stopping
+// by the time another instance can win it
+func (e *Elector) stepDown(reason string) {
+	e.mu.Lock()
+	e.endTerm()
+	e.term, e.endTerm = nil, nil
+	e.mu.Unlock()
+
+	if err := e.lock.Release(context.Background()); err != nil {
+		config.Logger.Warn("Failed to release leader lock", zap.Error(err))
+	}
+
+	metrics.Gauge("leader.is_leader", 0)
+	metrics.Incr("leader.lost", 1)
+	config.Logger.Warn("Leadership lost, stopping singleton jobs", zap.String("reason", reason))
+}
+
+func (e *Elector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.term != nil
+}
+
+// RunWhileLeader runs job during every term of this instance until ctx is
+// done. The job's context ends with the term; a job that returns on its own
+// (e.g. disabled by its config) is not started again.
+func (e *Elector) RunWhileLeader(ctx context.Context, name string, job func(ctx context.Context)) {
+	for {
+		term, ok := e.awaitTerm(ctx)
+		if !ok {
+			return
+		}
+
+		jobCtx, cancel := context.WithCancel(ctx)
+		stop := context.AfterFunc(term, cancel)
+		config.Logger.Info("Singleton job started", zap.String("job", name))
+		job(jobCtx)
+		stop()
+		cancel()
+
+		if ctx.Err() != nil || term.Err() == nil {
+			return
+		}
+		config.Logger.Info("Singleton job stopped", zap.String("job", name))
+	}
+}
+
+// awaitTerm returns the current term once this instance leads; ok is false
+// when ctx is done first
+func (e *Elector) awaitTerm(ctx context.Context) (term context.Context, ok bool) {
+	for {
+		e.mu.Lock()
+		term, changed := e.term, e.changed
+		e.mu.Unlock()
+		if term != nil {
+			return term, true
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-changed:
+		}
+	}
+}
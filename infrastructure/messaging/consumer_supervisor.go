@@ -0,0 +1,148 @@
+package messaging
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"github.com/leo-andrei/check-in-service/infrastructure/metrics"
+)
+
+// ConsumerWorker connects a consumer and consumes its queue until ctx is done
+// or the connection dies
+type ConsumerWorker func(ctx context.Context) error
+
+// ConsumerSupervisor runs the consumer workers and restarts those that stop,
+// e.g. after the broker closed their channel, waiting backoff between
+// restarts. Each restart connects again and declares the queue's topology.
+type ConsumerSupervisor struct {
+	mu      sync.Mutex
+	backoff Backoff
+	workers map[string]*supervisedWorker
+	// Tracks the supervising goroutines, which end with their worker's ctx
+	stopped sync.WaitGroup
+}
+
+type supervisedWorker struct {
+	ctx     context.Context
+	run     ConsumerWorker
+	running bool
+	lastErr error
+	// Cuts the wait before the next restart short
+	wake chan struct{}
+}
+
+func NewConsumerSupervisor(backoff Backoff) *ConsumerSupervisor {
+	return &ConsumerSupervisor{
+		backoff: backoff,
+		workers: make(map[string]*supervisedWorker),
+	}
+}
+
+// Start runs the worker in the background under name until ctx is done
+func (s *ConsumerSupervisor) Start(ctx context.Context, name string, run ConsumerWorker) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	worker := &supervisedWorker{ctx: ctx, run: run, running: true, wake: make(chan struct{}, 1)}
+	s.workers[name] = worker
+	s.stopped.Add(1)
+	go s.supervise(name, worker)
+}
+
+// Wait waits until every worker returned after its ctx was done, e.g. once
+// the consumers drained at shutdown, or until ctx is done
+func (s *ConsumerSupervisor) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.stopped.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *ConsumerSupervisor) supervise(name string, worker *supervisedWorker) {
+	defer s.stopped.Done()
+	attempt := 0
+	for {
+		started := time.Now()
+		err := worker.run(worker.ctx)
+
+		s.mu.Lock()
+		worker.running = false
+		worker.lastErr = err
+		s.mu.Unlock()
+
+		if worker.ctx.Err() != nil {
+			return
+		}
+
+		// A worker that ran for a while had connected; start the backoff over
+		if time.Since(started) > s.backoff.Max {
+			attempt = 0
+		}
+		delay := s.backoff.Delay(attempt)
+		attempt++
+		config.Logger.Error("Consumer stopped, restarting",
+			zap.String("consumer", name),
+			zap.Duration("backoff", delay),
+			zap.Error(err))
+		metrics.Incr("consumer."+name+".restarts", 1)
+
+		select {
+		case <-worker.ctx.Done():
+			return
+		case <-time.After(delay):
+		case <-worker.wake:
+		}
+
+		s.mu.Lock()
+		worker.running = true
+		s.mu.Unlock()
+	}
+}
+
+// Resume restarts the workers waiting to be restarted right away, except
+// during shutdown, and returns their names
+func (s *ConsumerSupervisor) Resume() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var resumed []string
+	for name, worker := range s.workers {
+		if worker.running || worker.ctx.Err() != nil {
+			continue
+		}
+		select {
+		case worker.wake <- struct{}{}:
+		default:
+		}
+		resumed = append(resumed, name)
+		config.Logger.Info("Consumer resumed", zap.String("consumer", name), zap.NamedError("stopped_by", worker.lastErr))
+	}
+	sort.Strings(resumed)
+	return resumed
+}
+
+// Running returns how many workers are running, out of all started
+func (s *ConsumerSupervisor) Running() (running, total int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, worker := range s.workers {
+		if worker.running {
+			running++
+		}
+	}
+	return running, len(s.workers)
+}
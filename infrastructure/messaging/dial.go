@@ -0,0 +1,67 @@
+package messaging
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Dial connects to RabbitMQ. An amqps:// URL is dialled over TLS with the
+// RABBITMQ_TLS_* settings: the CA to verify the broker with (the system pool
+// when unset), the client certificate for mutual TLS and the server name to
+// expect. With RABBITMQ_TLS_EXTERNAL_AUTH the client certificate is also the
+// login, through the EXTERNAL mechanism, instead of the URL's credentials.
+func Dial(rabbitURL string) (*amqp.Connection, error) {
+	if !strings.HasPrefix(rabbitURL, "amqps://") {
+		return amqp.Dial(rabbitURL)
+	}
+
+	tlsConfig, err := brokerTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	if config.Cfg.RabbitMQ.TLSExternalAuth {
+		return amqp.DialTLS_ExternalAuth(rabbitURL, tlsConfig)
+	}
+	return amqp.DialTLS(rabbitURL, tlsConfig)
+}
+
+// brokerTLSConfig builds the TLS configuration of broker connections, reading
+// the files again on every dial so renewed certificates are picked up on the
+// next reconnection
+func brokerTLSConfig() (*tls.Config, error) {
+	settings := config.Cfg.RabbitMQ
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         settings.TLSServerName,
+		InsecureSkipVerify: settings.TLSInsecureSkipVerify,
+	}
+
+	if settings.TLSCAFile != "" {
+		ca, err := os.ReadFile(settings.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read RabbitMQ CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificate found in RabbitMQ CA file %s", settings.TLSCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if settings.TLSCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(settings.TLSCertFile, settings.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load RabbitMQ client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
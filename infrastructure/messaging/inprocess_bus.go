@@ -0,0 +1,375 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/leo-andrei/check-in-service/domain/events"
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"github.com/leo-andrei/check-in-service/infrastructure/metrics"
+)
+
+// InProcessBus replaces the broker in single-node deployments: published
+// events go to buffered channels, one per queue bound to the exchange, and
+// InProcessConsumer handles them in the same process. Queues live as long as
+// the process; events still queued at exit are lost, but they were marked
+// published, so the outbox does not resend them: use it where a lost
+// notification is acceptable, or requeue from the outbox after a crash.
+type InProcessBus struct {
+	exchangeName string
+	queueSize    int
+	dryRun       atomic.Bool
+
+	mu     sync.RWMutex
+	routes map[string]EventRoute
+	// Queues by name, and the queues bound to each exchange
+	queues   map[string]*inProcessQueue
+	bindings map[string][]*inProcessQueue
+
+	quarantineMu sync.Mutex
+	quarantined  []PublishedMessage
+}
+
+// inProcessQueue is the counterpart of a durable queue: it outlives the
+// consumers, which the supervisor may restart, and keeps what they did not
+// take. Messages that failed too often are kept in dead.
+type inProcessQueue struct {
+	name     string
+	messages chan inProcessMessage
+
+	mu   sync.Mutex
+	dead []PublishedMessage
+}
+
+type inProcessMessage struct {
+	eventType string
+	body      []byte
+}
+
+func NewInProcessBus(exchangeName string, queueSize int) *InProcessBus {
+	return &InProcessBus{
+		exchangeName: exchangeName,
+		queueSize:    queueSize,
+		queues:       make(map[string]*inProcessQueue),
+		bindings:     make(map[string][]*inProcessQueue),
+	}
+}
+
+func (b *InProcessBus) Publish(ctx context.Context, event events.DomainEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	return b.PublishRaw(ctx, event.EventType(), body)
+}
+
+func (b *InProcessBus) PublishRaw(ctx context.Context, eventType string, body []byte) error {
+	return b.PublishBatch(ctx, []RawEvent{{Type: eventType, Body: body}})[0]
+}
+
+// PublishBatch queues every event on each queue bound to its exchange. An
+// event fails when one of those queues is full; the queues that took it keep
+// it, so it reaches them twice once the outbox retries it.
+func (b *InProcessBus) PublishBatch(ctx context.Context, batch []RawEvent) []error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	errs := make([]error, len(batch))
+	for i, event := range batch {
+		message := inProcessMessage{eventType: event.Type, body: slices.Clone(event.Body)}
+		for _, queue := range b.bindings[b.routeFor(event.Type).Exchange] {
+			select {
+			case queue.messages <- message:
+			default:
+				errs[i] = fmt.Errorf("in-process queue %s is full", queue.name)
+				metrics.Incr("inprocess."+queue.name+".full", 1)
+			}
+		}
+	}
+	return errs
+}
+
+// Preview checks the payload like RabbitMQPublisher.Preview
+func (b *InProcessBus) Preview(eventType string, body []byte) (PublishPreview, error) {
+	payloadType, err := events.TypeOf(body)
+	if err != nil {
+		return PublishPreview{}, fmt.Errorf("invalid event payload: %w", err)
+	}
+	if payloadType != eventType {
+		return PublishPreview{}, fmt.Errorf("payload is a %s event, not %s", payloadType, eventType)
+	}
+
+	b.mu.RLock()
+	route := b.routeFor(eventType)
+	b.mu.RUnlock()
+	msg := publishing(eventType, body)
+	return PublishPreview{
+		Exchange:    route.Exchange,
+		RoutingKey:  route.RoutingKey,
+		Type:        msg.Type,
+		ContentType: msg.ContentType,
+		Bytes:       len(msg.Body),
+	}, nil
+}
+
+// Route sends the events of a type to another exchange. Routing keys are
+// kept for Preview only: every queue bound to the exchange gets the event.
+func (b *InProcessBus) Route(eventType, exchange, routingKey string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.routes == nil {
+		b.routes = make(map[string]EventRoute)
+	}
+	b.routes[eventType] = EventRoute{Exchange: exchange, RoutingKey: routingKey}
+	return nil
+}
+
+func (b *InProcessBus) routeFor(eventType string) EventRoute {
+	if route, ok := b.routes[eventType]; ok {
+		return route
+	}
+	return EventRoute{Exchange: b.exchangeName}
+}
+
+// EnableQuarantine has no queue to declare
+func (b *InProcessBus) EnableQuarantine(queue string) error {
+	return nil
+}
+
+// Quarantine keeps an event that failed validation apart, in memory
+func (b *InProcessBus) Quarantine(ctx context.Context, eventType string, body []byte, reason string) error {
+	b.quarantineMu.Lock()
+	defer b.quarantineMu.Unlock()
+
+	b.quarantined = append(b.quarantined, PublishedMessage{
+		Type:        eventType,
+		Body:        slices.Clone(body),
+		PublishedAt: time.Now().UTC(),
+	})
+	metrics.Incr("inprocess.quarantined", 1)
+	return nil
+}
+
+// Quarantined returns the events quarantined so far, oldest first
+func (b *InProcessBus) Quarantined() []PublishedMessage {
+	b.quarantineMu.Lock()
+	defer b.quarantineMu.Unlock()
+
+	return slices.Clone(b.quarantined)
+}
+
+// DeadLetters returns the messages of a queue that failed too often
+func (b *InProcessBus) DeadLetters(queue string) []PublishedMessage {
+	b.mu.RLock()
+	q, ok := b.queues[queue]
+	b.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return slices.Clone(q.dead)
+}
+
+// Reconnect has nothing to reconnect to
+func (b *InProcessBus) Reconnect() (bool, error) {
+	return false, nil
+}
+
+func (b *InProcessBus) Connected() bool {
+	return true
+}
+
+// KeepConnected returns at once, as there is no connection to lose
+func (b *InProcessBus) KeepConnected(ctx context.Context, backoff Backoff) {}
+
+func (b *InProcessBus) DryRun() bool {
+	return b.dryRun.Load()
+}
+
+func (b *InProcessBus) SetDryRun(enabled bool) {
+	b.dryRun.Store(enabled)
+}
+
+// Close reports the events left in the queues, which are lost
+func (b *InProcessBus) Close() error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, queue := range b.queues {
+		if left := len(queue.messages); left > 0 {
+			config.Logger.Warn("In-process queue closed with unhandled events", zap.String("queue", queue.name), zap.Int("events", left))
+		}
+	}
+	return nil
+}
+
+// Declare creates the queues of the topology, bound to their exchanges, so
+// they collect events before their consumers start
+func (b *InProcessBus) Declare(topology Topology) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, queue := range topology.Queues {
+		b.bind(queue.Exchange, queue.Queue)
+	}
+}
+
+// bind returns the queue, creating it bound to exchange on first use
+func (b *InProcessBus) bind(exchange, queue string) *inProcessQueue {
+	q, ok := b.queues[queue]
+	if !ok {
+		q = &inProcessQueue{name: queue, messages: make(chan inProcessMessage, b.queueSize)}
+		b.queues[queue] = q
+		b.bindings[exchange] = append(b.bindings[exchange], q)
+	}
+	return q
+}
+
+// Consumer returns a consumer of queue, bound to exchange. Events published
+// before the queue was declared or first consumed never reach it, like with
+// a broker.
+func (b *InProcessBus) Consumer(exchange, queue string) *InProcessConsumer {
+	b.mu.Lock()
+	q := b.bind(exchange, queue)
+	b.mu.Unlock()
+
+	return &InProcessConsumer{
+		bus:           b,
+		queue:         q,
+		concurrency:   config.Cfg.InProcess.Workers,
+		maxDeliveries: config.Cfg.RabbitMQ.MaxDeliveries,
+		drainTimeout:  time.Duration(config.Cfg.RabbitMQ.DrainTimeoutSec) * time.Second,
+		backoff: Backoff{
+			Initial: time.Duration(config.Cfg.InProcess.RetryInitialMs) * time.Millisecond,
+			Max:     time.Duration(config.Cfg.InProcess.RetryMaxSec) * time.Second,
+		},
+	}
+}
+
+// InProcessConsumer handles the messages of an in-process queue with a pool
+// of workers. A failed message is retried in place with backoff, holding its
+// worker, up to maxDeliveries times (0 for no limit) and then dead-lettered.
+type InProcessConsumer struct {
+	bus           *InProcessBus
+	queue         *inProcessQueue
+	concurrency   int
+	maxDeliveries int
+	drainTimeout  time.Duration
+	backoff       Backoff
+	validate      Validator
+}
+
+// WithQuarantine checks every message with validate before handling it and
+// moves the ones that fail to the bus's quarantine
+func (c *InProcessConsumer) WithQuarantine(queue string, validate Validator) error {
+	c.validate = validate
+	return nil
+}
+
+// Consume handles the queue's messages until ctx is done. The workers then
+// stop taking messages and those running get the drain timeout to finish
+// before their context is cancelled; messages not taken stay queued for the
+// next consumer.
+func (c *InProcessConsumer) Consume(ctx context.Context, handler MessageHandler) error {
+	handlerCtx, cancelHandlers := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelHandlers()
+
+	config.Logger.Info("Consumer started", zap.String("queue", c.queue.name), zap.Int("concurrency", c.concurrency))
+
+	var workers sync.WaitGroup
+	for i := 0; i < c.concurrency; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case msg := <-c.queue.messages:
+					c.handle(ctx, handlerCtx, handler, msg)
+				}
+			}
+		}()
+	}
+
+	<-ctx.Done()
+	stopped := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(c.drainTimeout):
+		config.Logger.Warn("Drain timed out, cancelling handlers", zap.String("queue", c.queue.name))
+		cancelHandlers()
+		<-stopped
+	}
+	config.Logger.Info("Consumer shutting down", zap.String("queue", c.queue.name), zap.Int("queued", len(c.queue.messages)))
+	return ctx.Err()
+}
+
+// handle runs the handler on one message until it succeeds, the message is
+// dead-lettered or ctx is done, which puts the message back in the queue
+func (c *InProcessConsumer) handle(ctx, handlerCtx context.Context, handler MessageHandler, msg inProcessMessage) {
+	name := c.queue.name
+	if c.validate != nil {
+		if err := c.validate(msg.body); err != nil {
+			config.Logger.Warn("Invalid message quarantined", zap.String("queue", name), zap.Error(err))
+			c.bus.Quarantine(handlerCtx, msg.eventType, msg.body, err.Error())
+			metrics.Incr("consumer."+name+".quarantined", 1)
+			return
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		started := time.Now()
+		err := handler(handlerCtx, msg.body)
+		metrics.Timing("consumer."+name+".duration", time.Since(started))
+		if err == nil {
+			metrics.Incr("consumer."+name+".ack", 1)
+			return
+		}
+
+		failures := attempt + 1
+		if reason := poisonReason(err, failures, c.maxDeliveries); reason != "" {
+			c.queue.mu.Lock()
+			c.queue.dead = append(c.queue.dead, PublishedMessage{Type: msg.eventType, Body: msg.body, PublishedAt: time.Now().UTC()})
+			c.queue.mu.Unlock()
+			config.Logger.Warn("Message dead-lettered", zap.String("queue", name), zap.String("reason", reason), zap.Int("failures", failures), zap.Error(err))
+			metrics.Incr("consumer."+name+".poisoned", 1)
+			return
+		}
+
+		delay := c.backoff.Delay(attempt)
+		config.Logger.Error("Error processing message, retrying", zap.Error(err), zap.String("queue", name), zap.Duration("backoff", delay))
+		metrics.Incr("consumer."+name+".retried", 1)
+		select {
+		case <-ctx.Done():
+			// Left for the next consumer of the queue, if there is room
+			select {
+			case c.queue.messages <- msg:
+			default:
+				config.Logger.Error("In-process queue full, dropping failed message", zap.String("queue", name))
+			}
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// Close has no connection to close; the queue outlives the consumer
+func (c *InProcessConsumer) Close() error {
+	return nil
+}
@@ -0,0 +1,68 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestInProcessConsumerOrder(t *testing.T) {
+	bus := NewInProcessBus("checkin-events", 10)
+	consumer := bus.Consumer("checkin-events", "email")
+	consumer.concurrency = 1
+	consumer.backoff = Backoff{Initial: time.Millisecond, Max: time.Millisecond}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		attempts []string
+	)
+	handled := make(chan struct{}, 3)
+	failedOnce := false
+	go consumer.Consume(ctx, func(ctx context.Context, body []byte) error {
+		var event struct {
+			EventID string `json:"event_id"`
+		}
+		if err := json.Unmarshal(body, &event); err != nil {
+			return err
+		}
+
+		mu.Lock()
+		attempts = append(attempts, event.EventID)
+		fail := event.EventID == "evt-2" && !failedOnce
+		failedOnce = failedOnce || fail
+		mu.Unlock()
+		if fail {
+			return errors.New("smtp down")
+		}
+		handled <- struct{}{}
+		return nil
+	})
+
+	for _, id := range []string{"evt-1", "evt-2", "evt-3"} {
+		body := []byte(`{"event_id":"` + id + `","event_type":"employee.checked_in","employee_id":"emp-1"}`)
+		if err := bus.PublishRaw(ctx, "employee.checked_in", body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case <-handled:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the consumer")
+		}
+	}
+
+	// The failed event is retried in place, before the next one
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []string{"evt-1", "evt-2", "evt-2", "evt-3"}; !slices.Equal(attempts, want) {
+		t.Fatalf("attempts = %v, want %v", attempts, want)
+	}
+}
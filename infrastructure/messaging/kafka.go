@@ -0,0 +1,23 @@
+package messaging
+
+import "encoding/json"
+
+// PartitionKey is the Kafka message key of an event: its employee, so every
+// event of an employee lands on the same partition and is consumed in the
+// order it was published. Events without an employee are keyed by their ID.
+func PartitionKey(body []byte) []byte {
+	var keys struct {
+		EventID    string `json:"event_id"`
+		EmployeeID string `json:"employee_id"`
+	}
+	if err := json.Unmarshal(body, &keys); err != nil {
+		return nil
+	}
+	if keys.EmployeeID != "" {
+		return []byte(keys.EmployeeID)
+	}
+	if keys.EventID != "" {
+		return []byte(keys.EventID)
+	}
+	return nil
+}
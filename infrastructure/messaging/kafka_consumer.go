@@ -0,0 +1,149 @@
+//go:build kafka
+
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"github.com/leo-andrei/check-in-service/infrastructure/metrics"
+)
+
+// KafkaConsumer reads a topic in a consumer group, the counterpart of a
+// queue bound to a fanout exchange: every group receives every event, and
+// the members of a group share the partitions
+type KafkaConsumer struct {
+	reader  *kafka.Reader
+	name    string
+	backoff Backoff
+	// Messages failing validate are written to quarantine unhandled; off
+	// when nil
+	validate   Validator
+	quarantine *kafka.Writer
+}
+
+func NewKafkaConsumer(brokers []string, topic, groupID string, backoff Backoff) (*KafkaConsumer, error) {
+	if len(brokers) == 0 {
+		return nil, fmt.Errorf("no Kafka brokers configured")
+	}
+
+	return &KafkaConsumer{
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers: brokers,
+			GroupID: groupID,
+			Topic:   topic,
+		}),
+		name:    groupID,
+		backoff: backoff,
+	}, nil
+}
+
+// WithQuarantine checks every message with validate before handling it and
+// writes the ones that fail to the quarantine topic, with the reason, instead
+// of retrying them in place
+func (c *KafkaConsumer) WithQuarantine(topic string, validate Validator) error {
+	c.validate = validate
+	c.quarantine = &kafka.Writer{
+		Addr:         kafka.TCP(c.reader.Config().Brokers...),
+		Topic:        topic,
+		RequiredAcks: kafka.RequireAll,
+		BatchTimeout: 10 * time.Millisecond,
+	}
+	return nil
+}
+
+// quarantined reports whether msg failed validation and was moved to the
+// quarantine topic; a message that could not be moved is handled as usual
+func (c *KafkaConsumer) quarantined(ctx context.Context, msg kafka.Message) bool {
+	if c.validate == nil {
+		return false
+	}
+	invalid := c.validate(msg.Value)
+	if invalid == nil {
+		return false
+	}
+
+	copied := kafka.Message{
+		Key:   msg.Key,
+		Value: msg.Value,
+		Headers: append(msg.Headers,
+			kafka.Header{Key: errorHeader, Value: []byte(invalid.Error())},
+			kafka.Header{Key: quarantinedByHeader, Value: []byte(c.name)},
+		),
+	}
+	if err := c.quarantine.WriteMessages(ctx, copied); err != nil {
+		config.Logger.Error("Failed to quarantine message", zap.Error(err), zap.String("group", c.name))
+		return false
+	}
+	config.Logger.Warn("Invalid message quarantined", zap.String("group", c.name), zap.String("quarantine", c.quarantine.Topic), zap.Error(invalid))
+	metrics.Incr("consumer."+c.name+".quarantined", 1)
+	return true
+}
+
+// Consume handles the messages of the partitions assigned to this member one
+// at a time and commits each offset once it was handled, until ctx is done.
+// Kafka cannot requeue a single message, so a failing one is retried in
+// place with backoff and holds back the rest of its partition, keeping the
+// events of an employee in order. The handler finishes the message it is
+// handling when ctx is done, like RabbitMQConsumer's drain.
+func (c *KafkaConsumer) Consume(ctx context.Context, handler MessageHandler) error {
+	handlerCtx, cancelHandler := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelHandler()
+
+	config.Logger.Info("Consumer started", zap.String("group", c.name), zap.String("topic", c.reader.Config().Topic))
+
+	for {
+		msg, err := c.reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				config.Logger.Info("Consumer shutting down", zap.String("group", c.name))
+				return ctx.Err()
+			}
+			return fmt.Errorf("failed to fetch message: %w", err)
+		}
+
+		// Each attempt checks the message again, so an invalid one still
+		// reaches the quarantine when writing it there failed before
+		for attempt := 0; !c.quarantined(handlerCtx, msg); attempt++ {
+			started := time.Now()
+			err := handler(handlerCtx, msg.Value)
+			metrics.Timing("consumer."+c.name+".duration", time.Since(started))
+			if err == nil {
+				break
+			}
+
+			delay := c.backoff.Delay(attempt)
+			config.Logger.Error("Error processing message, retrying",
+				zap.Error(err),
+				zap.String("group", c.name),
+				zap.Int("partition", msg.Partition),
+				zap.Int64("offset", msg.Offset),
+				zap.Duration("backoff", delay))
+			metrics.Incr("consumer."+c.name+".retry", 1)
+
+			select {
+			case <-ctx.Done():
+				// Not committed: the next member of the group handles it again
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+
+		if err := c.reader.CommitMessages(handlerCtx, msg); err != nil {
+			return fmt.Errorf("failed to commit offset: %w", err)
+		}
+		metrics.Incr("consumer."+c.name+".ack", 1)
+	}
+}
+
+func (c *KafkaConsumer) Close() error {
+	if c.quarantine != nil {
+		c.quarantine.Close()
+	}
+	return c.reader.Close()
+}
@@ -0,0 +1,192 @@
+//go:build kafka
+
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/leo-andrei/check-in-service/domain/events"
+	"github.com/leo-andrei/check-in-service/infrastructure/metrics"
+)
+
+// KafkaPublisher publishes events to Kafka topics, the counterpart of the
+// fanout exchanges: the default topic, or the one an event type is routed
+// to. Messages are keyed by PartitionKey and written with acks=all.
+type KafkaPublisher struct {
+	writer       *kafka.Writer
+	defaultTopic string
+	// Topic per event type; other types go to defaultTopic
+	routes map[string]string
+	dryRun atomic.Bool
+	// Topic receiving events that failed validation, empty when off
+	quarantineTopic string
+}
+
+func NewKafkaPublisher(brokers []string, defaultTopic string) (*KafkaPublisher, error) {
+	if len(brokers) == 0 {
+		return nil, fmt.Errorf("no Kafka brokers configured")
+	}
+
+	return &KafkaPublisher{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			// Writes are synchronous; do not hold them back to fill batches
+			BatchTimeout: 10 * time.Millisecond,
+		},
+		defaultTopic: defaultTopic,
+	}, nil
+}
+
+func (p *KafkaPublisher) Publish(ctx context.Context, event events.DomainEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	return p.PublishRaw(ctx, event.EventType(), body)
+}
+
+func (p *KafkaPublisher) PublishRaw(ctx context.Context, eventType string, body []byte) error {
+	return p.PublishBatch(ctx, []RawEvent{{Type: eventType, Body: body}})[0]
+}
+
+// PublishBatch writes the events in one request per partition and returns an
+// error per event, nil for those every in-sync replica acknowledged
+func (p *KafkaPublisher) PublishBatch(ctx context.Context, batch []RawEvent) []error {
+	errs := make([]error, len(batch))
+	messages := make([]kafka.Message, len(batch))
+	for i, event := range batch {
+		messages[i] = p.message(event.Type, event.Body)
+	}
+
+	err := p.writer.WriteMessages(ctx, messages...)
+	var writeErrs kafka.WriteErrors
+	switch {
+	case err == nil:
+	case errors.As(err, &writeErrs):
+		for i, writeErr := range writeErrs {
+			if writeErr != nil {
+				errs[i] = fmt.Errorf("failed to publish event: %w", writeErr)
+			}
+		}
+	default:
+		for i := range errs {
+			errs[i] = fmt.Errorf("failed to publish event: %w", err)
+		}
+	}
+	return errs
+}
+
+func (p *KafkaPublisher) message(eventType string, body []byte) kafka.Message {
+	return kafka.Message{
+		Topic: p.topicFor(eventType),
+		Key:   PartitionKey(body),
+		Value: body,
+		Headers: []kafka.Header{
+			{Key: "type", Value: []byte(eventType)},
+			{Key: "content-type", Value: []byte("application/json")},
+		},
+	}
+}
+
+// Route sends the events of a type to their own topic. Topics are the
+// streams consumers subscribe to, so there is no routing key and routingKey
+// is ignored. Set routes before publishing; topics are not created by the
+// service.
+func (p *KafkaPublisher) Route(eventType, topic, routingKey string) error {
+	if p.routes == nil {
+		p.routes = make(map[string]string)
+	}
+	p.routes[eventType] = topic
+	return nil
+}
+
+func (p *KafkaPublisher) topicFor(eventType string) string {
+	if topic, ok := p.routes[eventType]; ok {
+		return topic
+	}
+	return p.defaultTopic
+}
+
+// Preview builds the message for an event exactly like PublishRaw, checking
+// that the payload decodes as the event type, without sending anything
+func (p *KafkaPublisher) Preview(eventType string, body []byte) (PublishPreview, error) {
+	payloadType, err := events.TypeOf(body)
+	if err != nil {
+		return PublishPreview{}, fmt.Errorf("invalid event payload: %w", err)
+	}
+	if payloadType != eventType {
+		return PublishPreview{}, fmt.Errorf("payload is a %s event, not %s", payloadType, eventType)
+	}
+
+	msg := p.message(eventType, body)
+	return PublishPreview{
+		Exchange:    msg.Topic,
+		RoutingKey:  string(msg.Key),
+		Type:        eventType,
+		ContentType: "application/json",
+		Bytes:       len(msg.Value),
+	}, nil
+}
+
+// EnableQuarantine sets the topic Quarantine writes to; like routed topics,
+// it is not created by the service
+func (p *KafkaPublisher) EnableQuarantine(topic string) error {
+	p.quarantineTopic = topic
+	return nil
+}
+
+// Quarantine writes an event that failed validation to the quarantine topic,
+// with the reason in a header, instead of its topic
+func (p *KafkaPublisher) Quarantine(ctx context.Context, eventType string, body []byte, reason string) error {
+	if p.quarantineTopic == "" {
+		return fmt.Errorf("no quarantine topic configured")
+	}
+
+	msg := p.message(eventType, body)
+	msg.Topic = p.quarantineTopic
+	msg.Headers = append(msg.Headers,
+		kafka.Header{Key: errorHeader, Value: []byte(reason)},
+		kafka.Header{Key: quarantinedByHeader, Value: []byte("publisher")},
+	)
+	if err := p.writer.WriteMessages(ctx, msg); err != nil {
+		return fmt.Errorf("failed to quarantine event: %w", err)
+	}
+	metrics.Incr("kafka.publisher.quarantined", 1)
+	return nil
+}
+
+func (p *KafkaPublisher) DryRun() bool {
+	return p.dryRun.Load()
+}
+
+func (p *KafkaPublisher) SetDryRun(enabled bool) {
+	p.dryRun.Store(enabled)
+}
+
+// Reconnect has nothing to do: the writer dials the partition leaders again
+// on its own
+func (p *KafkaPublisher) Reconnect() (bool, error) {
+	return false, nil
+}
+
+// Connected is always true; failed writes are retried by the outbox relay
+func (p *KafkaPublisher) Connected() bool {
+	return true
+}
+
+// KeepConnected returns at once; see Reconnect
+func (p *KafkaPublisher) KeepConnected(ctx context.Context, backoff Backoff) {}
+
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
@@ -0,0 +1,24 @@
+package messaging
+
+import "testing"
+
+func TestPartitionKey(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "employee event", body: `{"event_id":"evt-1","employee_id":"emp-1"}`, want: "emp-1"},
+		{name: "another event of the employee", body: `{"event_id":"evt-2","employee_id":"emp-1"}`, want: "emp-1"},
+		{name: "event without employee", body: `{"event_id":"evt-3"}`, want: "evt-3"},
+		{name: "invalid payload", body: `not json`, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(PartitionKey([]byte(tt.body))); got != tt.want {
+				t.Fatalf("PartitionKey = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
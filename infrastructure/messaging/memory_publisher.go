@@ -0,0 +1,158 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/events"
+)
+
+// PublishedMessage is an event kept by MemoryPublisher
+type PublishedMessage struct {
+	Type        string
+	Body        []byte
+	PublishedAt time.Time
+}
+
+// MemoryPublisher keeps published events in memory instead of sending them to
+// RabbitMQ, for ENVIRONMENT=local and tests. Nothing consumes them.
+type MemoryPublisher struct {
+	exchangeName string
+	routes       map[string]EventRoute
+	dryRun       atomic.Bool
+
+	mu          sync.Mutex
+	published   []PublishedMessage
+	quarantined []PublishedMessage
+}
+
+func NewMemoryPublisher(exchangeName string) *MemoryPublisher {
+	return &MemoryPublisher{exchangeName: exchangeName}
+}
+
+func (p *MemoryPublisher) Publish(ctx context.Context, event events.DomainEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	return p.PublishRaw(ctx, event.EventType(), body)
+}
+
+func (p *MemoryPublisher) PublishRaw(ctx context.Context, eventType string, body []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.published = append(p.published, PublishedMessage{
+		Type:        eventType,
+		Body:        slices.Clone(body),
+		PublishedAt: time.Now().UTC(),
+	})
+	return nil
+}
+
+// PublishBatch keeps every event; nothing can fail to be confirmed
+func (p *MemoryPublisher) PublishBatch(ctx context.Context, batch []RawEvent) []error {
+	for _, event := range batch {
+		p.PublishRaw(ctx, event.Type, event.Body)
+	}
+	return make([]error, len(batch))
+}
+
+// Preview checks the payload like RabbitMQPublisher.Preview
+func (p *MemoryPublisher) Preview(eventType string, body []byte) (PublishPreview, error) {
+	payloadType, err := events.TypeOf(body)
+	if err != nil {
+		return PublishPreview{}, fmt.Errorf("invalid event payload: %w", err)
+	}
+	if payloadType != eventType {
+		return PublishPreview{}, fmt.Errorf("payload is a %s event, not %s", payloadType, eventType)
+	}
+
+	route, ok := p.routes[eventType]
+	if !ok {
+		route = EventRoute{Exchange: p.exchangeName}
+	}
+
+	msg := publishing(eventType, body)
+	return PublishPreview{
+		Exchange:    route.Exchange,
+		RoutingKey:  route.RoutingKey,
+		Type:        msg.Type,
+		ContentType: msg.ContentType,
+		Bytes:       len(msg.Body),
+	}, nil
+}
+
+// Route records the exchange and routing key of an event type for Preview;
+// published events are kept together whatever their route
+func (p *MemoryPublisher) Route(eventType, exchange, routingKey string) error {
+	if p.routes == nil {
+		p.routes = make(map[string]EventRoute)
+	}
+	p.routes[eventType] = EventRoute{Exchange: exchange, RoutingKey: routingKey}
+	return nil
+}
+
+// EnableQuarantine has no queue to declare
+func (p *MemoryPublisher) EnableQuarantine(queue string) error {
+	return nil
+}
+
+// Quarantine keeps an event that failed validation apart from the published ones
+func (p *MemoryPublisher) Quarantine(ctx context.Context, eventType string, body []byte, reason string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.quarantined = append(p.quarantined, PublishedMessage{
+		Type:        eventType,
+		Body:        slices.Clone(body),
+		PublishedAt: time.Now().UTC(),
+	})
+	return nil
+}
+
+// Quarantined returns the events quarantined so far, oldest first
+func (p *MemoryPublisher) Quarantined() []PublishedMessage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return slices.Clone(p.quarantined)
+}
+
+// Published returns the events published so far, oldest first
+func (p *MemoryPublisher) Published() []PublishedMessage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return slices.Clone(p.published)
+}
+
+// Reconnect has nothing to reconnect to
+func (p *MemoryPublisher) Reconnect() (bool, error) {
+	return false, nil
+}
+
+func (p *MemoryPublisher) Connected() bool {
+	return true
+}
+
+// KeepConnected returns at once, as there is no connection to lose
+func (p *MemoryPublisher) KeepConnected(ctx context.Context, backoff Backoff) {}
+
+func (p *MemoryPublisher) DryRun() bool {
+	return p.dryRun.Load()
+}
+
+func (p *MemoryPublisher) SetDryRun(enabled bool) {
+	p.dryRun.Store(enabled)
+}
+
+func (p *MemoryPublisher) Close() error {
+	return nil
+}
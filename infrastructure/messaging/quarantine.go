@@ -0,0 +1,38 @@
+package messaging
+
+import (
+	"fmt"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Validator checks a message body before it is published or handled;
+// events.ValidatePayload checks events against their JSON Schema
+type Validator func(body []byte) error
+
+// Headers of quarantined messages, next to errorHeader with the reason
+const (
+	// publisher, or the queue of the consumer that refused the message
+	quarantinedByHeader = "x-quarantined-by"
+)
+
+// declareQuarantine declares the queue holding the messages that failed
+// validation. It has no TTL and no consumer: messages wait there until an
+// operator fixes the producer and replays or purges them. Its type is
+// configured like a consumer queue's.
+func declareQuarantine(ch *amqp.Channel, queue string) error {
+	_, err := ch.QueueDeclare(
+		queue,
+		true,  // durable
+		false, // delete when unused
+		false, // exclusive
+		false, // no-wait
+		QueueTopology{Queue: queue, Args: config.Cfg.QueueArgs(queue)}.typeArgs(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare quarantine queue: %w", err)
+	}
+	return nil
+}
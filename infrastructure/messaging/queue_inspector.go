@@ -0,0 +1,250 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/errors"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// QueueDepth is the number of messages waiting in a consumer queue and in its
+// dead letter queue
+type QueueDepth struct {
+	Queue       string
+	Messages    int
+	Consumers   int
+	DLQ         string
+	DLQMessages int
+}
+
+// QueueInspector reports queue depths and moves dead letters back for the
+// admin API. It connects per call, since it is used rarely and by hand.
+type QueueInspector struct {
+	rabbitURL string
+	topology  Topology
+}
+
+func NewQueueInspector(rabbitURL string, topology Topology) *QueueInspector {
+	return &QueueInspector{
+		rabbitURL: rabbitURL,
+		topology:  topology,
+	}
+}
+
+// Depths returns the depth of every queue of the topology. Queues are only
+// inspected, never declared; a missing queue fails the call.
+func (i *QueueInspector) Depths(ctx context.Context) ([]QueueDepth, error) {
+	conn, err := Dial(i.rabbitURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+	defer conn.Close()
+
+	depths := make([]QueueDepth, 0, len(i.topology.Queues))
+	for _, q := range i.topology.Queues {
+		queue, err := inspectQueue(conn, q.Queue)
+		if err != nil {
+			return nil, err
+		}
+		dlq, err := inspectQueue(conn, q.DLQName())
+		if err != nil {
+			return nil, err
+		}
+		depths = append(depths, QueueDepth{
+			Queue:       q.Queue,
+			Messages:    queue.Messages,
+			Consumers:   queue.Consumers,
+			DLQ:         q.DLQName(),
+			DLQMessages: dlq.Messages,
+		})
+	}
+	return depths, nil
+}
+
+// inspectQueue uses its own channel, since a failed passive declare closes it
+func inspectQueue(conn *amqp.Connection, name string) (amqp.Queue, error) {
+	ch, err := conn.Channel()
+	if err != nil {
+		return amqp.Queue{}, fmt.Errorf("failed to open channel: %w", err)
+	}
+	defer ch.Close()
+
+	queue, err := ch.QueueDeclarePassive(name, true, false, false, false, nil)
+	if err != nil {
+		return amqp.Queue{}, fmt.Errorf("failed to inspect queue %s: %w", name, err)
+	}
+	return queue, nil
+}
+
+// ReplayDLQ moves up to limit messages from the queue's DLQ back to the queue
+// itself (not its exchange, so other consumers do not see them twice). Each
+// message is acknowledged on the DLQ only after the broker confirmed the
+// republish. Returns how many messages were moved.
+func (i *QueueInspector) ReplayDLQ(ctx context.Context, queue string, limit int) (int, error) {
+	topology, err := i.queue(queue)
+	if err != nil {
+		return 0, err
+	}
+
+	conn, err := Dial(i.rabbitURL)
+	if err != nil {
+		return 0, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+	defer conn.Close()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return 0, fmt.Errorf("failed to open channel: %w", err)
+	}
+	defer ch.Close()
+
+	if err := ch.Confirm(false); err != nil {
+		return 0, fmt.Errorf("failed to enable publisher confirms: %w", err)
+	}
+
+	moved := 0
+	for moved < limit {
+		msg, ok, err := ch.Get(topology.DLQName(), false)
+		if err != nil {
+			return moved, fmt.Errorf("failed to read from %s: %w", topology.DLQName(), err)
+		}
+		if !ok {
+			break // DLQ is empty
+		}
+
+		// A replayed message gets its deliveries back
+		headers := amqp.Table{}
+		for key, value := range msg.Headers {
+			if key != retryCountHeader && key != deadReasonHeader {
+				headers[key] = value
+			}
+		}
+		confirm, err := ch.PublishWithDeferredConfirmWithContext(ctx, "", queue, false, false, amqp.Publishing{
+			ContentType:  msg.ContentType,
+			Body:         msg.Body,
+			DeliveryMode: amqp.Persistent,
+			Type:         msg.Type,
+			MessageId:    msg.MessageId,
+			Headers:      headers,
+		})
+		if err == nil {
+			var acked bool
+			if acked, err = confirm.WaitContext(ctx); err == nil && !acked {
+				err = fmt.Errorf("broker did not confirm the message")
+			}
+		}
+		if err != nil {
+			msg.Nack(false, true) // Leave it in the DLQ
+			return moved, fmt.Errorf("failed to republish to %s: %w", queue, err)
+		}
+
+		if err := msg.Ack(false); err != nil {
+			return moved, fmt.Errorf("failed to remove message from %s: %w", topology.DLQName(), err)
+		}
+		moved++
+	}
+
+	return moved, nil
+}
+
+// queue returns the topology of one of the consumer queues
+func (i *QueueInspector) queue(name string) (*QueueTopology, error) {
+	for _, q := range i.topology.Queues {
+		if q.Queue == name {
+			return &q, nil
+		}
+	}
+	return nil, errors.ErrUnknownQueueConst
+}
+
+// DeadLetter is a message waiting in a dead letter queue
+type DeadLetter struct {
+	Type      string
+	MessageID string
+	Body      []byte
+	// From the broker's x-death header: why and when the message was
+	// dead-lettered, and how many times it has been. Messages the consumer
+	// dead-lettered have a Reason of poison, permanent or max-deliveries instead.
+	Reason     string
+	DeadSince  time.Time
+	DeathCount int64
+	// Last error of the handler, for messages the consumer dead-lettered
+	Error string
+}
+
+// PeekDLQ returns up to limit messages from the head of the queue's DLQ
+// without removing them: they are read unacknowledged and requeued, in
+// their order, when the channel closes
+func (i *QueueInspector) PeekDLQ(ctx context.Context, queue string, limit int) ([]DeadLetter, error) {
+	topology, err := i.queue(queue)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := Dial(i.rabbitURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+	defer conn.Close()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open channel: %w", err)
+	}
+	defer ch.Close()
+
+	letters := make([]DeadLetter, 0, limit)
+	for len(letters) < limit {
+		msg, ok, err := ch.Get(topology.DLQName(), false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read from %s: %w", topology.DLQName(), err)
+		}
+		if !ok {
+			break // DLQ is empty
+		}
+
+		letter := DeadLetter{Type: msg.Type, MessageID: msg.MessageId, Body: msg.Body}
+		if deaths, ok := msg.Headers["x-death"].([]interface{}); ok && len(deaths) > 0 {
+			if death, ok := deaths[0].(amqp.Table); ok {
+				letter.Reason, _ = death["reason"].(string)
+				letter.DeadSince, _ = death["time"].(time.Time)
+				letter.DeathCount, _ = death["count"].(int64)
+			}
+		}
+		if reason, ok := msg.Headers[deadReasonHeader].(string); ok {
+			letter.Reason = reason
+		}
+		letter.Error, _ = msg.Headers[errorHeader].(string)
+		letters = append(letters, letter)
+	}
+	return letters, nil
+}
+
+// PurgeDLQ deletes every message of the queue's DLQ and returns how many
+func (i *QueueInspector) PurgeDLQ(ctx context.Context, queue string) (int, error) {
+	topology, err := i.queue(queue)
+	if err != nil {
+		return 0, err
+	}
+
+	conn, err := Dial(i.rabbitURL)
+	if err != nil {
+		return 0, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+	defer conn.Close()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return 0, fmt.Errorf("failed to open channel: %w", err)
+	}
+	defer ch.Close()
+
+	purged, err := ch.QueuePurge(topology.DLQName(), false)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge %s: %w", topology.DLQName(), err)
+	}
+	return purged, nil
+}
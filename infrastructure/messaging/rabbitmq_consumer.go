@@ -2,11 +2,18 @@ package messaging
 
 import (
 	"context"
+	"encoding/json"
+	stderrors "errors"
 	"fmt"
+	"sync"
+	"time"
 
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 
+	"github.com/leo-andrei/check-in-service/domain/errors"
 	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"github.com/leo-andrei/check-in-service/infrastructure/metrics"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 )
@@ -17,92 +24,54 @@ type RabbitMQConsumer struct {
 	conn      *amqp.Connection
 	channel   *amqp.Channel
 	queueName string
+	// Batch acknowledgement, off when ackBatchSize is 0
+	ackBatchSize     int
+	ackBatchInterval time.Duration
+	// Messages handled at the same time
+	concurrency int
+	// How long handlers still running at shutdown may take to finish
+	drainTimeout time.Duration
+	// Failed deliveries after which a message is dead-lettered, 0 for none
+	maxDeliveries int
+	topology      QueueTopology
+	// Messages failing validate go to quarantineQueue unhandled; off when nil
+	validate        Validator
+	quarantineQueue string
 }
 
 func NewRabbitMQConsumer(rabbitURL, exchangeName, queueName string) (*RabbitMQConsumer, error) {
-	conn, err := amqp.Dial(rabbitURL)
+	conn, err := Dial(rabbitURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
 	}
 
 	ch, err := conn.Channel()
 	if err != nil {
+		conn.Close()
 		return nil, fmt.Errorf("failed to open channel: %w", err)
 	}
 
-	// Declare dead letter exchange for DLQ
-	dlqExchangeName := queueName + "-dlx"
-	err = ch.ExchangeDeclare(
-		dlqExchangeName,
-		"direct", // type
-		true,     // durable
-		false,    // auto-delete
-		false,    // internal
-		false,    // no-wait
-		nil,      // args
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to declare DLX: %w", err)
-	}
-
-	// Declare DLQ
-	dlqName := queueName + "-dlq"
-	_, err = ch.QueueDeclare(
-		dlqName,
-		true,  // durable
-		false, // delete when unused
-		false, // exclusive
-		false, // no-wait
-		nil,   // no additional args for DLQ
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to declare DLQ: %w", err)
-	}
-
-	// Bind DLQ to DLX
-	err = ch.QueueBind(
-		dlqName,
-		dlqName, // routing key
-		dlqExchangeName,
-		false,
-		nil,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to bind DLQ: %w", err)
-	}
-
-	dlqTTL := config.Cfg.RabbitMQ.DLQTTL
 	prefetchCount := config.Cfg.RabbitMQ.PrefetchCount
 
-	// Declare main queue with DLX and TTL
-	args := amqp.Table{
-		"x-dead-letter-exchange":    dlqExchangeName,
-		"x-dead-letter-routing-key": dlqName,
-		"x-message-ttl":             int64(dlqTTL),
+	// Declare the exchange too, so a consumer connecting to a broker that
+	// lost its definitions does not wait for the publisher to reconnect
+	if err := declareFanoutExchange(ch, exchangeName); err != nil {
+		conn.Close()
+		return nil, err
 	}
 
-	_, err = ch.QueueDeclare(
-		queueName,
-		true,  // durable
-		false, // delete when unused
-		false, // exclusive
-		false, // no-wait
-		args,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to declare queue: %w", err)
+	// Declare queue, DLX, DLQ and bindings
+	topology := queueTopology(exchangeName, queueName, config.Cfg.RabbitMQ.DLQTTL)
+	if err := topology.Declare(ch); err != nil {
+		conn.Close()
+		return nil, err
 	}
 
-	// Bind queue to exchange
-	err = ch.QueueBind(
-		queueName,
-		"",           // routing key
-		exchangeName, // exchange
-		false,
-		nil,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to bind queue: %w", err)
+	// Failed messages are republished; confirms tell when the copy is safe
+	// and the original can be acknowledged
+	if err := ch.Confirm(false); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to put channel in confirm mode: %w", err)
 	}
 
 	// Set prefetch count (QoS)
@@ -112,20 +81,95 @@ func NewRabbitMQConsumer(rabbitURL, exchangeName, queueName string) (*RabbitMQCo
 		false,         // global
 	)
 	if err != nil {
+		conn.Close()
 		return nil, fmt.Errorf("failed to set QoS: %w", err)
 	}
 
 	return &RabbitMQConsumer{
-		conn:      conn,
-		channel:   ch,
-		queueName: queueName,
+		conn:          conn,
+		channel:       ch,
+		queueName:     queueName,
+		concurrency:   1,
+		drainTimeout:  time.Duration(config.Cfg.RabbitMQ.DrainTimeoutSec) * time.Second,
+		maxDeliveries: config.Cfg.RabbitMQ.MaxDeliveries,
+		topology:      topology,
 	}, nil
 }
 
+// WithBatchAck acknowledges successful messages together (multiple=true) once
+// size of them are pending or interval has passed since the last flush,
+// instead of one by one. Failed messages are still rejected individually, after
+// the successes before them were acknowledged. A size of 0 or 1 keeps per-message
+// acks. Acks still pending when the channel dies are lost and those messages are
+// redelivered, so handlers must tolerate duplicates.
+func (c *RabbitMQConsumer) WithBatchAck(size int, interval time.Duration) *RabbitMQConsumer {
+	if size <= 1 {
+		return c
+	}
+	// Without a timer an idle queue would leave the tail of a batch unacked
+	if interval <= 0 {
+		interval = time.Second
+	}
+	c.ackBatchSize = size
+	c.ackBatchInterval = interval
+	return c
+}
+
+// WithConcurrency handles up to n messages at the same time, each on its own
+// goroutine, instead of one after the other. Messages are still settled in
+// delivery order: a message finished early waits for the ones delivered
+// before it. Messages of one aggregate may then be handled out of order, and
+// the prefetch count caps how many messages are in flight.
+func (c *RabbitMQConsumer) WithConcurrency(n int) *RabbitMQConsumer {
+	if n > 1 {
+		c.concurrency = n
+	}
+	return c
+}
+
+// WithQuarantine checks every message with validate before handling it.
+// Messages that fail are moved to the quarantine queue, with the reason,
+// instead of being retried: a bad producer must not hold up the queue.
+func (c *RabbitMQConsumer) WithQuarantine(queue string, validate Validator) error {
+	if err := declareQuarantine(c.channel, queue); err != nil {
+		return err
+	}
+	c.validate = validate
+	c.quarantineQueue = queue
+	return nil
+}
+
+// invalidMessage is the error of a message that failed validation
+type invalidMessage struct {
+	err error
+}
+
+func (e *invalidMessage) Error() string {
+	return "invalid message: " + e.err.Error()
+}
+
+func (e *invalidMessage) Unwrap() error {
+	return e.err
+}
+
+// delivery is a message being handled, or handled and not settled yet
+type delivery struct {
+	msg  amqp.Delivery
+	done bool
+	err  error
+}
+
+// Consume handles the queue's messages until ctx is done or the broker closes
+// the channel, and returns why it stopped. When ctx is done it drains: it
+// stops the deliveries, gives the running handlers up to the drain timeout
+// to finish before cancelling their context, settles every message they
+// handled and requeues the ones delivered but never handled.
 func (c *RabbitMQConsumer) Consume(ctx context.Context, handler MessageHandler) error {
+	closed := c.channel.NotifyClose(make(chan *amqp.Error, 1))
+	tag := c.queueName + "-" + uuid.NewString()
 	msgs, err := c.channel.Consume(
 		c.queueName,
-		"",    // consumer tag
+		tag,   // consumer tag
 		false, // auto-ack (we'll manually ack)
 		false, // exclusive
 		false, // no-local
@@ -136,36 +180,353 @@ func (c *RabbitMQConsumer) Consume(ctx context.Context, handler MessageHandler)
 		return fmt.Errorf("failed to register consumer: %w", err)
 	}
 
-	config.Logger.Info("Consumer started", zap.String("queue", c.queueName))
+	config.Logger.Info("Consumer started", zap.String("queue", c.queueName), zap.Int("ack_batch_size", c.ackBatchSize), zap.Int("concurrency", c.concurrency))
+
+	// Handlers outlive ctx during the drain; their own context is only
+	// cancelled once the drain timed out
+	handlerCtx, cancelHandlers := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelHandlers()
+
+	// Workers handle the messages and report back; both channels hold one
+	// message per worker, so neither side ever blocks the other
+	jobs := make(chan *delivery, c.concurrency)
+	results := make(chan *delivery, c.concurrency)
+	var workers sync.WaitGroup
+	for i := 0; i < c.concurrency; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for d := range jobs {
+				if c.validate != nil {
+					if err := c.validate(d.msg.Body); err != nil {
+						d.err = &invalidMessage{err: err}
+						results <- d
+						continue
+					}
+				}
+				started := time.Now()
+				d.err = handler(handlerCtx, d.msg.Body)
+				metrics.Timing("consumer."+c.queueName+".duration", time.Since(started))
+				results <- d
+			}
+		}()
+	}
+	// Set when the drain gave up on handlers that ignore their context
+	abandoned := false
+	defer func() {
+		close(jobs)
+		if !abandoned {
+			workers.Wait()
+		}
+	}()
+
+	// Successful deliveries not acknowledged yet; they are settled in
+	// delivery order, so acking the last one with multiple=true covers all
+	var (
+		pending int
+		last    amqp.Delivery
+		flushC  <-chan time.Time
+	)
+	if c.ackBatchSize > 0 {
+		ticker := time.NewTicker(c.ackBatchInterval)
+		defer ticker.Stop()
+		flushC = ticker.C
+	}
+	flush := func() {
+		if pending == 0 {
+			return
+		}
+		if err := last.Ack(true); err != nil {
+			config.Logger.Error("Failed to acknowledge batch", zap.Error(err), zap.String("queue", c.queueName), zap.Int("messages", pending))
+		} else {
+			metrics.Incr("consumer."+c.queueName+".ack", int64(pending))
+			metrics.Incr("consumer."+c.queueName+".ack_batch", 1)
+		}
+		pending = 0
+	}
+
+	// Deliveries in flight, oldest first; the finished ones at the front are
+	// settled as soon as nothing delivered before them is still running
+	var inFlight []*delivery
+	settle := func() {
+		for len(inFlight) > 0 && inFlight[0].done {
+			d := inFlight[0]
+			inFlight = inFlight[1:]
+
+			if d.err != nil {
+				config.Logger.Error("Error processing message", zap.Error(d.err), zap.String("queue", c.queueName))
+				// Settle the successes before it so only this message is redelivered
+				flush()
+				var invalid *invalidMessage
+				if stderrors.As(d.err, &invalid) {
+					c.quarantine(d.msg, invalid)
+				} else {
+					c.reject(d.msg, d.err)
+				}
+				continue
+			}
+
+			if c.ackBatchSize == 0 {
+				// Acknowledge successful processing
+				d.msg.Ack(false)
+				metrics.Incr("consumer."+c.queueName+".ack", 1)
+				continue
+			}
+
+			pending++
+			last = d.msg
+			if pending >= c.ackBatchSize {
+				flush()
+			}
+		}
+	}
 
 	for {
+		// Take a new message only when a worker is free for it
+		next := msgs
+		if len(inFlight) >= c.concurrency {
+			next = nil
+		}
+
 		select {
 		case <-ctx.Done():
+			abandoned = !c.drain(tag, msgs, results, &inFlight, settle, cancelHandlers)
+			flush()
 			config.Logger.Info("Consumer shutting down", zap.String("queue", c.queueName))
 			return ctx.Err()
 
-		case msg, ok := <-msgs:
+		case <-flushC:
+			flush()
+
+		case d := <-results:
+			d.done = true
+			settle()
+
+		case msg, ok := <-next:
 			if !ok {
+				select {
+				case reason := <-closed:
+					if reason != nil {
+						return fmt.Errorf("channel closed: %w", reason)
+					}
+				default:
+				}
 				return fmt.Errorf("channel closed")
 			}
 
-			// Process message
-			err := handler(ctx, msg.Body)
-			if err != nil {
-				config.Logger.Error("Error processing message", zap.Error(err), zap.String("queue", c.queueName))
-				// Reject and requeue - message will stay in queue until TTL expires, then move to DLQ
+			d := &delivery{msg: msg}
+			inFlight = append(inFlight, d)
+			jobs <- d
+		}
+	}
+}
+
+// Headers of messages the consumer puts back in its queue or dead-letters
+const (
+	retryCountHeader = "x-retry-count"
+	errorHeader      = "x-error"
+	// Why the consumer dead-lettered a message: poison, permanent or
+	// max-deliveries.
+	// Messages dead-lettered by the broker carry x-death instead.
+	deadReasonHeader = "x-dead-letter-reason"
+)
+
+// republishTimeout bounds the wait for the broker to confirm a copy
+const republishTimeout = 5 * time.Second
+
+// cancelGrace is how long the drain waits for handlers once their context is
+// cancelled; it fits in the 5 seconds the process waits beyond the drain
+const cancelGrace = 3 * time.Second
+
+// reject settles a message its handler failed on. A message that cannot be
+// decoded, whose handler failed permanently, or that failed maxDeliveries
+// times, is poison: it goes to the DLQ right away with the error attached.
+// Others go back to the tail of the queue with their failure count. Without
+// a limit, or when the copy cannot be published, the message is requeued as
+// is and reaches the DLQ when its TTL expires.
+func (c *RabbitMQConsumer) reject(msg amqp.Delivery, cause error) {
+	if c.maxDeliveries > 0 || errors.IsPermanent(cause) {
+		failures := deliveryFailures(msg) + 1
+		headers := amqp.Table{}
+		for key, value := range msg.Headers {
+			headers[key] = value
+		}
+		headers[retryCountHeader] = int64(failures)
+		headers[errorHeader] = cause.Error()
+
+		exchange, key, outcome := "", c.queueName, "retried"
+		if reason := poisonReason(cause, failures, c.maxDeliveries); reason != "" {
+			headers[deadReasonHeader] = reason
+			exchange, key, outcome = c.topology.DLXName(), c.topology.DLQName(), "poisoned"
+		}
+
+		err := c.republish(msg, exchange, key, headers)
+		if err == nil {
+			if err := msg.Ack(false); err != nil {
+				config.Logger.Error("Failed to acknowledge republished message", zap.Error(err), zap.String("queue", c.queueName))
+			}
+			if outcome == "poisoned" {
+				config.Logger.Warn("Poison message sent to the DLQ", zap.String("queue", c.queueName), zap.Int("failures", failures), zap.Error(cause))
+			}
+			metrics.Incr("consumer."+c.queueName+"."+outcome, 1)
+			return
+		}
+		config.Logger.Error("Failed to republish message, requeueing it", zap.Error(err), zap.String("queue", c.queueName))
+	}
+
+	// Reject and requeue - message will stay in queue until TTL expires, then move to DLQ
+	msg.Nack(false, true)
+	metrics.Incr("consumer."+c.queueName+".nack", 1)
+}
+
+// quarantine moves a message that failed validation to the quarantine queue.
+// When the copy cannot be published it is rejected like a failed message.
+func (c *RabbitMQConsumer) quarantine(msg amqp.Delivery, cause *invalidMessage) {
+	headers := amqp.Table{}
+	for key, value := range msg.Headers {
+		headers[key] = value
+	}
+	headers[errorHeader] = cause.err.Error()
+	headers[quarantinedByHeader] = c.queueName
+
+	if err := c.republish(msg, "", c.quarantineQueue, headers); err != nil {
+		config.Logger.Error("Failed to quarantine message", zap.Error(err), zap.String("queue", c.queueName))
+		c.reject(msg, cause)
+		return
+	}
+	if err := msg.Ack(false); err != nil {
+		config.Logger.Error("Failed to acknowledge quarantined message", zap.Error(err), zap.String("queue", c.queueName))
+	}
+	config.Logger.Warn("Invalid message quarantined", zap.String("queue", c.queueName), zap.String("quarantine", c.quarantineQueue), zap.Error(cause.err))
+	metrics.Incr("consumer."+c.queueName+".quarantined", 1)
+}
+
+func (c *RabbitMQConsumer) republish(msg amqp.Delivery, exchange, key string, headers amqp.Table) error {
+	ctx, cancel := context.WithTimeout(context.Background(), republishTimeout)
+	defer cancel()
+
+	confirm, err := c.channel.PublishWithDeferredConfirmWithContext(ctx, exchange, key, false, false, amqp.Publishing{
+		ContentType:  msg.ContentType,
+		Body:         msg.Body,
+		DeliveryMode: amqp.Persistent,
+		Type:         msg.Type,
+		MessageId:    msg.MessageId,
+		Headers:      headers,
+	})
+	if err != nil {
+		return err
+	}
+	acked, err := confirm.WaitContext(ctx)
+	if err == nil && !acked {
+		err = fmt.Errorf("broker did not confirm the message")
+	}
+	return err
+}
+
+// deliveryFailures is how many times the message failed before: counted by
+// this consumer, or by the broker for quorum queues
+func deliveryFailures(msg amqp.Delivery) int {
+	return max(headerInt(msg.Headers[retryCountHeader]), headerInt(msg.Headers["x-delivery-count"]))
+}
+
+func headerInt(value interface{}) int {
+	switch v := value.(type) {
+	case int64:
+		return int(v)
+	case int32:
+		return int(v)
+	case int:
+		return v
+	}
+	return 0
+}
+
+// poisonReason tells why a message should not be retried, or "" when it may
+// be: it cannot be decoded (poison), its handler failed for good
+// (permanent), or it failed maxDeliveries times, when there is a limit
+func poisonReason(cause error, failures, maxDeliveries int) string {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if stderrors.As(cause, &syntaxErr) || stderrors.As(cause, &typeErr) {
+		return "poison"
+	}
+	if errors.IsPermanent(cause) {
+		return "permanent"
+	}
+	if maxDeliveries > 0 && failures >= maxDeliveries {
+		return "max-deliveries"
+	}
+	return ""
+}
+
+// drain stops the deliveries, settles the messages in flight and requeues
+// the ones the broker delivered but no worker took. It returns false when
+// handlers still ran cancelGrace after their context was cancelled: their
+// messages are left unsettled, and closing the channel redelivers them.
+func (c *RabbitMQConsumer) drain(tag string, msgs <-chan amqp.Delivery, results <-chan *delivery, inFlight *[]*delivery, settle func(), cancelHandlers context.CancelFunc) bool {
+	if err := c.channel.Cancel(tag, false); err != nil {
+		config.Logger.Warn("Failed to stop deliveries", zap.Error(err), zap.String("queue", c.queueName))
+	}
+
+	started := time.Now()
+	deadline := time.NewTimer(c.drainTimeout)
+	defer deadline.Stop()
+	var giveUp <-chan time.Time
+	for len(*inFlight) > 0 {
+		select {
+		case d := <-results:
+			d.done = true
+			settle()
+		case <-deadline.C:
+			// Handlers that honour their context fail, and are requeued
+			config.Logger.Warn("Drain timed out, cancelling handlers", zap.String("queue", c.queueName), zap.Int("in_flight", len(*inFlight)))
+			cancelHandlers()
+			grace := time.NewTimer(cancelGrace)
+			defer grace.Stop()
+			giveUp = grace.C
+		case <-giveUp:
+			for _, d := range *inFlight {
+				config.Logger.Error("Handler ignored its cancellation, leaving the message to be redelivered",
+					zap.String("queue", c.queueName), zap.String("message_id", d.msg.MessageId),
+					zap.Uint64("delivery_tag", d.msg.DeliveryTag), zap.Bool("done", d.done))
+			}
+			metrics.Incr("consumer."+c.queueName+".abandoned", int64(len(*inFlight)))
+			return false
+		}
+	}
+	metrics.Timing("consumer."+c.queueName+".drain", time.Since(started))
+
+	// Once cancelled the broker sends nothing more, and the library closes
+	// msgs after handing over what was already delivered
+	requeued := 0
+	for {
+		select {
+		case msg, ok := <-msgs:
+			if ok {
 				msg.Nack(false, true)
-			} else {
-				// Acknowledge successful processing
-				msg.Ack(false)
+				requeued++
+				continue
 			}
+		case <-time.After(time.Second):
 		}
+		break
+	}
+	if requeued > 0 {
+		metrics.Incr("consumer."+c.queueName+".requeued", int64(requeued))
+		config.Logger.Info("Requeued undelivered messages", zap.String("queue", c.queueName), zap.Int("count", requeued))
 	}
+	return true
 }
 
+// Close closes the channel and the connection; the connection is closed
+// even when the channel already died, so a resumed consumer does not leak it
 func (c *RabbitMQConsumer) Close() error {
-	if err := c.channel.Close(); err != nil {
+	chErr := c.channel.Close()
+	if err := c.conn.Close(); err != nil && err != amqp.ErrClosed {
 		return err
 	}
-	return c.conn.Close()
+	if chErr != nil && chErr != amqp.ErrClosed {
+		return chErr
+	}
+	return nil
 }
@@ -4,46 +4,61 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
 
 	"github.com/leo-andrei/check-in-service/domain/events"
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"github.com/leo-andrei/check-in-service/infrastructure/metrics"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
 type RabbitMQPublisher struct {
+	rabbitURL string
+	// Guards conn, channel and returns, which Reconnect replaces
+	mu           sync.RWMutex
 	conn         *amqp.Connection
 	channel      *amqp.Channel
+	returns      *returnedMessages
 	exchangeName string
+	// In dry-run the outbox relay previews events instead of publishing them
+	dryRun atomic.Bool
+	// Exchange receiving both versions of events that have a next version,
+	// empty when shadow mode is off
+	shadowExchange string
+	// Exchange per event type; other types go to exchangeName
+	routes map[string]EventRoute
+	// Queue receiving events that failed validation, empty when off
+	quarantineQueue string
 }
 
 func NewRabbitMQPublisher(rabbitURL, exchangeName string) (*RabbitMQPublisher, error) {
-	conn, err := amqp.Dial(rabbitURL)
+	conn, err := Dial(rabbitURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
 	}
 
-	ch, err := conn.Channel()
+	ch, err := confirmChannel(conn)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open channel: %w", err)
+		return nil, err
 	}
 
 	// Declare exchange
-	err = ch.ExchangeDeclare(
-		exchangeName, // name
-		"fanout",     // type
-		true,         // durable
-		false,        // auto-deleted
-		false,        // internal
-		false,        // no-wait
-		nil,          // arguments
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to declare exchange: %w", err)
+	if err := declareFanoutExchange(ch, exchangeName); err != nil {
+		return nil, err
 	}
 
 	return &RabbitMQPublisher{
+		rabbitURL:    rabbitURL,
 		conn:         conn,
 		channel:      ch,
+		returns:      watchReturns(ch),
 		exchangeName: exchangeName,
 	}, nil
 }
@@ -57,29 +72,421 @@ func (p *RabbitMQPublisher) Publish(ctx context.Context, event events.DomainEven
 	return p.PublishRaw(ctx, event.EventType(), body)
 }
 
+// PublishRaw sends one event and waits up to OUTBOX_CONFIRM_TIMEOUT_MS for
+// the broker to confirm it; see PublishBatch
 func (p *RabbitMQPublisher) PublishRaw(ctx context.Context, eventType string, body []byte) error {
-	err := p.channel.PublishWithContext(
-		ctx,
-		p.exchangeName, // exchange
-		"",             // routing key (ignored for fanout)
-		false,          // mandatory
-		false,          // immediate
-		amqp.Publishing{
-			ContentType:  "application/json",
-			Body:         body,
-			DeliveryMode: amqp.Persistent, // Make message persistent
-			Type:         eventType,
-		},
-	)
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(config.Cfg.Outbox.ConfirmTimeoutMs)*time.Millisecond)
+	defer cancel()
+
+	return p.PublishBatch(ctx, []RawEvent{{Type: eventType, Body: body}})[0]
+}
+
+// RawEvent is a serialized event, as stored in the outbox
+type RawEvent struct {
+	Type string
+	Body []byte
+}
+
+// PublishBatch sends every event before waiting for any confirmation, then
+// waits for the broker to confirm each one until ctx is done. It returns an
+// error per event, nil for those the broker confirmed. Events are mandatory:
+// one the exchange could not route to any queue is returned by the broker
+// and fails too. An event nacked or not confirmed in time may still have
+// been delivered; it is sent again when retried.
+func (p *RabbitMQPublisher) PublishBatch(ctx context.Context, batch []RawEvent) []error {
+	errs := make([]error, len(batch))
+	confirms := make([]*amqp.DeferredConfirmation, len(batch))
+	messageIDs := make([]string, len(batch))
+
+	ch, returns := p.current()
+	for i, event := range batch {
+		msg := publishing(event.Type, event.Body)
+		msg.MessageId = uuid.NewString()
+		messageIDs[i] = msg.MessageId
+		route := p.routeFor(event.Type)
+		confirm, err := ch.PublishWithDeferredConfirmWithContext(ctx, route.Exchange, route.RoutingKey, true, false, msg)
+		if err != nil {
+			errs[i] = fmt.Errorf("failed to publish event: %w", err)
+			continue
+		}
+		confirms[i] = confirm
+
+		if p.shadowExchange != "" {
+			p.publishShadow(ctx, ch, event.Type, event.Body)
+		}
+	}
+
+	for i, confirm := range confirms {
+		if confirm == nil {
+			continue
+		}
+		acked, err := confirm.WaitContext(ctx)
+		switch {
+		case err != nil:
+			errs[i] = fmt.Errorf("event not confirmed by the broker: %w", err)
+		case !acked:
+			errs[i] = fmt.Errorf("event rejected by the broker")
+		default:
+			if returned, ok := returns.take(messageIDs[i]); ok {
+				errs[i] = fmt.Errorf("event not routed to any queue by %s: %s", returned.Exchange, returned.ReplyText)
+				metrics.Incr("rabbitmq.publisher.returned", 1)
+			}
+		}
+	}
+	return errs
+}
 
+// returnBuffer must hold every message returned while a batch waits for its
+// confirms; the connection stalls when it is full
+const returnBuffer = 1024
+
+// returnedMessages collects the mandatory messages the broker could not
+// route. RabbitMQ sends basic.return before the message's confirm, so once
+// a message is confirmed its return, if any, is already buffered.
+type returnedMessages struct {
+	mu       sync.Mutex
+	notify   chan amqp.Return
+	returned map[string]amqp.Return
+}
+
+func watchReturns(ch *amqp.Channel) *returnedMessages {
+	return &returnedMessages{
+		notify:   ch.NotifyReturn(make(chan amqp.Return, returnBuffer)),
+		returned: make(map[string]amqp.Return),
+	}
+}
+
+// take reports whether the message with messageID was returned
+func (r *returnedMessages) take(messageID string) (amqp.Return, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for drained := false; !drained; {
+		select {
+		case returned, ok := <-r.notify:
+			if !ok {
+				drained = true
+				break
+			}
+			r.returned[returned.MessageId] = returned
+		default:
+			drained = true
+		}
+	}
+
+	returned, ok := r.returned[messageID]
+	delete(r.returned, messageID)
+	return returned, ok
+}
+
+// confirmChannel opens a channel in confirm mode, where the broker
+// acknowledges every message once it has taken responsibility for it
+func confirmChannel(conn *amqp.Connection) (*amqp.Channel, error) {
+	ch, err := conn.Channel()
 	if err != nil {
-		return fmt.Errorf("failed to publish event: %w", err)
+		return nil, fmt.Errorf("failed to open channel: %w", err)
+	}
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		return nil, fmt.Errorf("failed to put channel in confirm mode: %w", err)
+	}
+	return ch, nil
+}
+
+func (p *RabbitMQPublisher) currentChannel() *amqp.Channel {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.channel
+}
+
+func (p *RabbitMQPublisher) current() (*amqp.Channel, *returnedMessages) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.channel, p.returns
+}
+
+// Reconnect replaces the connection and channel when either was closed, e.g.
+// by a broker restart, and declares the exchanges again. It reports whether
+// it had to reconnect.
+func (p *RabbitMQPublisher) Reconnect() (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.conn.IsClosed() && !p.channel.IsClosed() {
+		return false, nil
+	}
+
+	conn, err := Dial(p.rabbitURL)
+	if err != nil {
+		return false, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+	ch, err := confirmChannel(conn)
+	if err != nil {
+		conn.Close()
+		return false, err
+	}
+	for _, exchange := range p.exchanges() {
+		if err := declareExchange(ch, exchange.Exchange, exchange.kind()); err != nil {
+			conn.Close()
+			return false, err
+		}
+	}
+	if p.quarantineQueue != "" {
+		if err := declareQuarantine(ch, p.quarantineQueue); err != nil {
+			conn.Close()
+			return false, err
+		}
+	}
+
+	p.conn.Close()
+	p.conn, p.channel, p.returns = conn, ch, watchReturns(ch)
+	config.Logger.Info("Publisher reconnected to RabbitMQ", zap.String("exchange", p.exchangeName))
+	metrics.Incr("rabbitmq.publisher.reconnects", 1)
+	return true, nil
+}
+
+// Connected reports whether the connection and channel are open
+func (p *RabbitMQPublisher) Connected() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return !p.conn.IsClosed() && !p.channel.IsClosed()
+}
+
+// KeepConnected reconnects, waiting backoff between failed attempts, whenever
+// the broker closes the channel or the connection, until ctx is done. Start
+// it once the routes are set.
+func (p *RabbitMQPublisher) KeepConnected(ctx context.Context, backoff Backoff) {
+	for {
+		// Closing the connection closes its channels, so the channel
+		// notification covers both. The buffer keeps the library from
+		// blocking on a notification nobody reads after a manual Reconnect.
+		closed := p.currentChannel().NotifyClose(make(chan *amqp.Error, 1))
+		select {
+		case <-ctx.Done():
+			return
+		case err := <-closed:
+			if err != nil {
+				config.Logger.Warn("Publisher lost its RabbitMQ channel", zap.Error(err))
+			}
+		}
+
+		for attempt := 0; ; attempt++ {
+			_, err := p.Reconnect()
+			if err == nil {
+				break
+			}
+			delay := backoff.Delay(attempt)
+			config.Logger.Warn("Publisher failed to reconnect to RabbitMQ, retrying",
+				zap.Int("attempt", attempt+1),
+				zap.Duration("backoff", delay),
+				zap.Error(err))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+		}
+	}
+}
+
+// EventRoute is where the events of a type are published: a fanout
+// exchange, or a topic exchange with a routing key consumers bind to
+// selectively, e.g. checkin.# or *.EmployeeCheckedOut
+type EventRoute struct {
+	Exchange   string
+	RoutingKey string
+}
+
+// kind is the type of the route's exchange: topic when it has a routing key
+func (r EventRoute) kind() string {
+	if r.RoutingKey != "" {
+		return amqp.ExchangeTopic
+	}
+	return amqp.ExchangeFanout
+}
+
+// Route sends the events of a type to their own exchange, declaring it,
+// instead of the publisher's exchange: a fanout exchange, or a topic
+// exchange when routingKey is set. Set routes before publishing.
+func (p *RabbitMQPublisher) Route(eventType, exchange, routingKey string) error {
+	route := EventRoute{Exchange: exchange, RoutingKey: routingKey}
+	if err := declareExchange(p.currentChannel(), exchange, route.kind()); err != nil {
+		return err
+	}
+	if p.routes == nil {
+		p.routes = make(map[string]EventRoute)
+	}
+	p.routes[eventType] = route
+	return nil
+}
+
+func (p *RabbitMQPublisher) routeFor(eventType string) EventRoute {
+	if route, ok := p.routes[eventType]; ok {
+		return route
+	}
+	return EventRoute{Exchange: p.exchangeName}
+}
+
+// exchanges lists every exchange the publisher sends to, once each, as the
+// route of one of its events
+func (p *RabbitMQPublisher) exchanges() []EventRoute {
+	exchanges := []EventRoute{{Exchange: p.exchangeName}}
+	if p.shadowExchange != "" {
+		exchanges = append(exchanges, EventRoute{Exchange: p.shadowExchange})
+	}
+	for _, route := range p.routes {
+		if !slices.ContainsFunc(exchanges, func(r EventRoute) bool { return r.Exchange == route.Exchange }) {
+			exchanges = append(exchanges, route)
+		}
+	}
+	return exchanges
+}
+
+// EnableShadow turns on shadow mode: every published event that has a next
+// version is also sent, together with that version, to the verification
+// exchange for the parity checker
+func (p *RabbitMQPublisher) EnableShadow(exchangeName string) error {
+	if err := declareFanoutExchange(p.currentChannel(), exchangeName); err != nil {
+		return err
+	}
+	p.shadowExchange = exchangeName
+	return nil
+}
+
+// EnableQuarantine declares the queue Quarantine sends to
+func (p *RabbitMQPublisher) EnableQuarantine(queue string) error {
+	if err := declareQuarantine(p.currentChannel(), queue); err != nil {
+		return err
+	}
+	p.quarantineQueue = queue
+	return nil
+}
+
+// Quarantine sends an event that failed validation to the quarantine queue,
+// with the reason, instead of its exchange, and waits for the broker to
+// confirm it
+func (p *RabbitMQPublisher) Quarantine(ctx context.Context, eventType string, body []byte, reason string) error {
+	if p.quarantineQueue == "" {
+		return fmt.Errorf("no quarantine queue configured")
 	}
 
+	msg := publishing(eventType, body)
+	msg.MessageId = uuid.NewString()
+	msg.Headers = amqp.Table{
+		errorHeader:         reason,
+		quarantinedByHeader: "publisher",
+	}
+	confirm, err := p.currentChannel().PublishWithDeferredConfirmWithContext(ctx, "", p.quarantineQueue, false, false, msg)
+	if err != nil {
+		return fmt.Errorf("failed to quarantine event: %w", err)
+	}
+	acked, err := confirm.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("quarantined event not confirmed by the broker: %w", err)
+	}
+	if !acked {
+		return fmt.Errorf("quarantined event rejected by the broker")
+	}
+	metrics.Incr("rabbitmq.publisher.quarantined", 1)
 	return nil
 }
 
+// publishShadow sends the shadow copy of an event. Failures are only logged:
+// shadow traffic must never hold back the real event.
+func (p *RabbitMQPublisher) publishShadow(ctx context.Context, ch *amqp.Channel, eventType string, body []byte) {
+	envelope, ok, err := shadowEnvelope(eventType, body)
+	if !ok {
+		return
+	}
+	if err == nil {
+		err = ch.PublishWithContext(ctx, p.shadowExchange, "", false, false, publishing(eventType, envelope))
+	}
+	if err != nil {
+		config.Logger.Warn("Failed to publish shadow event", zap.String("type", eventType), zap.Error(err))
+		metrics.Incr("shadow.publish_failures", 1)
+		return
+	}
+	metrics.Incr("shadow.published", 1)
+}
+
+// shadowEnvelope pairs an event with its next version, or returns false when
+// the event type has none
+func shadowEnvelope(eventType string, body []byte) ([]byte, bool, error) {
+	next, ok, err := events.UpgradeForShadow(eventType, body)
+	if !ok || err != nil {
+		return nil, ok, err
+	}
+	nextBody, err := json.Marshal(next)
+	if err != nil {
+		return nil, true, err
+	}
+
+	var header events.EventHeader
+	if err := json.Unmarshal(body, &header); err != nil {
+		return nil, true, err
+	}
+	envelope, err := json.Marshal(events.ShadowEnvelope{
+		EventID:   header.EventID,
+		EventType: eventType,
+		Current:   body,
+		Next:      nextBody,
+	})
+	return envelope, true, err
+}
+
+func publishing(eventType string, body []byte) amqp.Publishing {
+	return amqp.Publishing{
+		ContentType:  "application/json",
+		Body:         body,
+		DeliveryMode: amqp.Persistent, // Make message persistent
+		Type:         eventType,
+	}
+}
+
+// PublishPreview describes the message PublishRaw would send
+type PublishPreview struct {
+	Exchange    string
+	RoutingKey  string
+	Type        string
+	ContentType string
+	Bytes       int
+}
+
+// Preview builds the message for an event exactly like PublishRaw, checking
+// that the payload decodes as the event type, without sending anything
+func (p *RabbitMQPublisher) Preview(eventType string, body []byte) (PublishPreview, error) {
+	payloadType, err := events.TypeOf(body)
+	if err != nil {
+		return PublishPreview{}, fmt.Errorf("invalid event payload: %w", err)
+	}
+	if payloadType != eventType {
+		return PublishPreview{}, fmt.Errorf("payload is a %s event, not %s", payloadType, eventType)
+	}
+
+	msg := publishing(eventType, body)
+	route := p.routeFor(eventType)
+	return PublishPreview{
+		Exchange:    route.Exchange,
+		RoutingKey:  route.RoutingKey,
+		Type:        msg.Type,
+		ContentType: msg.ContentType,
+		Bytes:       len(msg.Body),
+	}, nil
+}
+
+// DryRun reports whether the outbox relay should preview instead of publish
+func (p *RabbitMQPublisher) DryRun() bool {
+	return p.dryRun.Load()
+}
+
+func (p *RabbitMQPublisher) SetDryRun(enabled bool) {
+	p.dryRun.Store(enabled)
+}
+
 func (p *RabbitMQPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
 	if err := p.channel.Close(); err != nil {
 		return err
 	}
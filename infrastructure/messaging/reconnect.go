@@ -0,0 +1,20 @@
+package messaging
+
+import "time"
+
+// Backoff is the wait between attempts to reach the broker again: Initial
+// after the first failure, doubled after each one up to Max
+type Backoff struct {
+	Initial time.Duration
+	Max     time.Duration
+}
+
+// Delay returns the wait before retrying after attempt failures in a row,
+// counting from 0
+func (b Backoff) Delay(attempt int) time.Duration {
+	delay := b.Initial
+	for i := 0; i < attempt && delay < b.Max; i++ {
+		delay *= 2
+	}
+	return min(delay, b.Max)
+}
@@ -0,0 +1,54 @@
+package messaging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// RoundTrip publishes body to an exclusive, server-named queue through the
+// default exchange and waits until it is delivered back. The event exchanges
+// are not involved, so no worker ever sees the message.
+func RoundTrip(ctx context.Context, conn *amqp.Connection, eventType string, body []byte) error {
+	ch, err := conn.Channel()
+	if err != nil {
+		return fmt.Errorf("failed to open channel: %w", err)
+	}
+	defer ch.Close()
+
+	queue, err := ch.QueueDeclare(
+		"",    // server-named
+		false, // durable
+		true,  // delete when unused
+		true,  // exclusive
+		false, // no-wait
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare round-trip queue: %w", err)
+	}
+
+	deliveries, err := ch.Consume(queue.Name, "", true, true, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to consume round-trip queue: %w", err)
+	}
+
+	if err := ch.PublishWithContext(ctx, "", queue.Name, false, false, publishing(eventType, body)); err != nil {
+		return fmt.Errorf("failed to publish round-trip message: %w", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("round-trip message not delivered: %w", ctx.Err())
+	case delivery, ok := <-deliveries:
+		if !ok {
+			return fmt.Errorf("round-trip channel closed")
+		}
+		if delivery.Type != eventType || !bytes.Equal(delivery.Body, body) {
+			return fmt.Errorf("round-trip delivered a different message")
+		}
+		return nil
+	}
+}
@@ -0,0 +1,207 @@
+package messaging
+
+import (
+	"fmt"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// QueueTopology is the declarative definition of a consumer queue bound to a
+// fanout exchange, with its dead letter exchange and queue
+type QueueTopology struct {
+	Exchange   string
+	Queue      string
+	MessageTTL int // ms before unprocessed messages move to the DLQ
+	// Type, mode and length limit; the DLQ gets the same type
+	Args config.QueueArgs
+}
+
+// queueTopology is the topology of a consumer queue with the configured
+// arguments
+func queueTopology(exchange, queue string, dlqTTL int) QueueTopology {
+	return QueueTopology{
+		Exchange:   exchange,
+		Queue:      queue,
+		MessageTTL: dlqTTL,
+		Args:       config.Cfg.QueueArgs(queue),
+	}
+}
+
+// typeArgs declares a quorum queue; classic queues need no argument
+func (q QueueTopology) typeArgs() amqp.Table {
+	args := amqp.Table{}
+	if q.Args.Type == config.QueueTypeQuorum {
+		args["x-queue-type"] = config.QueueTypeQuorum
+	}
+	return args
+}
+
+func (q QueueTopology) DLXName() string {
+	return q.Queue + "-dlx"
+}
+
+func (q QueueTopology) DLQName() string {
+	return q.Queue + "-dlq"
+}
+
+// Declare declares (or verifies, when it already exists) the queue, its DLX,
+// DLQ and bindings. RabbitMQ rejects the declaration with PRECONDITION_FAILED
+// when an existing queue has different arguments, which closes the channel.
+func (q QueueTopology) Declare(ch *amqp.Channel) error {
+	// Declare dead letter exchange for DLQ
+	err := ch.ExchangeDeclare(
+		q.DLXName(),
+		"direct", // type
+		true,     // durable
+		false,    // auto-delete
+		false,    // internal
+		false,    // no-wait
+		nil,      // args
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare DLX: %w", err)
+	}
+
+	// Declare DLQ
+	_, err = ch.QueueDeclare(
+		q.DLQName(),
+		true,  // durable
+		false, // delete when unused
+		false, // exclusive
+		false, // no-wait
+		q.typeArgs(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare DLQ: %w", err)
+	}
+
+	// Bind DLQ to DLX
+	err = ch.QueueBind(
+		q.DLQName(),
+		q.DLQName(), // routing key
+		q.DLXName(),
+		false,
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to bind DLQ: %w", err)
+	}
+
+	// Declare main queue with DLX and TTL
+	args := q.typeArgs()
+	args["x-dead-letter-exchange"] = q.DLXName()
+	args["x-dead-letter-routing-key"] = q.DLQName()
+	args["x-message-ttl"] = int64(q.MessageTTL)
+	if q.Args.Lazy {
+		args["x-queue-mode"] = "lazy"
+	}
+	if q.Args.MaxLength > 0 {
+		args["x-max-length"] = int64(q.Args.MaxLength)
+		args["x-overflow"] = q.Args.Overflow
+	}
+
+	_, err = ch.QueueDeclare(
+		q.Queue,
+		true,  // durable
+		false, // delete when unused
+		false, // exclusive
+		false, // no-wait
+		args,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare queue: %w", err)
+	}
+
+	// Bind queue to exchange
+	err = ch.QueueBind(
+		q.Queue,
+		"",         // routing key
+		q.Exchange, // exchange
+		false,
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to bind queue: %w", err)
+	}
+
+	return nil
+}
+
+func declareFanoutExchange(ch *amqp.Channel, exchangeName string) error {
+	return declareExchange(ch, exchangeName, amqp.ExchangeFanout)
+}
+
+// declareExchange declares a durable exchange of the given kind, fanout or topic
+func declareExchange(ch *amqp.Channel, exchangeName, kind string) error {
+	err := ch.ExchangeDeclare(
+		exchangeName, // name
+		kind,         // type
+		true,         // durable
+		false,        // auto-deleted
+		false,        // internal
+		false,        // no-wait
+		nil,          // arguments
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare exchange: %w", err)
+	}
+	return nil
+}
+
+// Topology is the full broker layout the service expects
+type Topology struct {
+	Exchanges []string // fanout exchanges
+	Queues    []QueueTopology
+}
+
+// DefaultTopology is the layout used by the publisher and the workers
+func DefaultTopology(dlqTTL int) Topology {
+	return Topology{
+		Exchanges: []string{"checkout-events"},
+		Queues: []QueueTopology{
+			queueTopology("checkout-events", "labor-cost-queue", dlqTTL),
+			queueTopology("checkout-events", "email-queue", dlqTTL),
+			queueTopology("checkout-events", "reminder-queue", dlqTTL),
+		},
+	}
+}
+
+// TopologyMismatch describes an entity whose declaration was rejected by the broker
+type TopologyMismatch struct {
+	Entity string
+	Err    error
+}
+
+// Verify declares every entity of the topology on its own channel and
+// returns the ones the broker rejected. Missing entities are created.
+func (t Topology) Verify(conn *amqp.Connection) []TopologyMismatch {
+	var mismatches []TopologyMismatch
+
+	withChannel := func(entity string, declare func(ch *amqp.Channel) error) {
+		ch, err := conn.Channel()
+		if err != nil {
+			mismatches = append(mismatches, TopologyMismatch{Entity: entity, Err: err})
+			return
+		}
+		defer ch.Close()
+
+		if err := declare(ch); err != nil {
+			mismatches = append(mismatches, TopologyMismatch{Entity: entity, Err: err})
+		}
+	}
+
+	for _, exchange := range t.Exchanges {
+		exchange := exchange
+		withChannel("exchange "+exchange, func(ch *amqp.Channel) error {
+			return declareFanoutExchange(ch, exchange)
+		})
+	}
+
+	for _, queue := range t.Queues {
+		withChannel("queue "+queue.Queue, queue.Declare)
+	}
+
+	return mismatches
+}
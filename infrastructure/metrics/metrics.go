@@ -0,0 +1,45 @@
+// Package metrics emits operational counters and gauges (outbox lag, publish
+// failures, consumer outcomes) to the configured backend.
+package metrics
+
+import (
+	"time"
+)
+
+// Recorder receives metrics. Names are dot-separated, e.g. "outbox.published".
+type Recorder interface {
+	Incr(name string, value int64)
+	Gauge(name string, value float64)
+	Timing(name string, d time.Duration)
+	Close() error
+}
+
+// Default is the process-wide recorder; it discards everything until Init
+// installs a backend
+var Default Recorder = Nop{}
+
+// Nop discards all metrics
+type Nop struct{}
+
+func (Nop) Incr(string, int64)           {}
+func (Nop) Gauge(string, float64)        {}
+func (Nop) Timing(string, time.Duration) {}
+func (Nop) Close() error                 { return nil }
+
+// Init installs the statsd recorder when an address is configured
+func Init(statsdAddr, prefix string) error {
+	if statsdAddr == "" {
+		return nil
+	}
+
+	recorder, err := NewStatsD(statsdAddr, prefix)
+	if err != nil {
+		return err
+	}
+	Default = recorder
+	return nil
+}
+
+func Incr(name string, value int64)       { Default.Incr(name, value) }
+func Gauge(name string, value float64)    { Default.Gauge(name, value) }
+func Timing(name string, d time.Duration) { Default.Timing(name, d) }
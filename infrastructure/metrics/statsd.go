@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// StatsD pushes metrics over UDP in the plain statsd line format
+// ("name:value|type"), which our legacy monitoring stack understands. Sends
+// are fire-and-forget: a lost packet never affects the caller.
+type StatsD struct {
+	mu     sync.Mutex
+	conn   net.Conn
+	prefix string
+}
+
+func NewStatsD(addr, prefix string) (*StatsD, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open statsd connection: %w", err)
+	}
+
+	if prefix != "" {
+		prefix += "."
+	}
+	return &StatsD{conn: conn, prefix: prefix}, nil
+}
+
+func (s *StatsD) Incr(name string, value int64) {
+	s.send(name, strconv.FormatInt(value, 10), "c")
+}
+
+func (s *StatsD) Gauge(name string, value float64) {
+	s.send(name, strconv.FormatFloat(value, 'f', -1, 64), "g")
+}
+
+func (s *StatsD) Timing(name string, d time.Duration) {
+	s.send(name, strconv.FormatInt(d.Milliseconds(), 10), "ms")
+}
+
+func (s *StatsD) send(name, value, kind string) {
+	line := s.prefix + name + ":" + value + "|" + kind
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conn.Write([]byte(line))
+}
+
+func (s *StatsD) Close() error {
+	return s.conn.Close()
+}
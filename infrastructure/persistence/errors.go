@@ -0,0 +1,20 @@
+package persistence
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// isUniqueViolation reports whether err is a unique or primary key violation.
+// The MySQL driver is only linked with its build tag, so its errors are
+// recognized by message.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23505"
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "Error 1062")
+}
@@ -0,0 +1,87 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+)
+
+// PostgresLeaderLock is a session advisory lock held on one dedicated
+// connection. Postgres releases it when the session ends, so when the leader
+// crashes or loses its connection another instance acquires it.
+type PostgresLeaderLock struct {
+	db  *sql.DB
+	key string
+
+	mu   sync.Mutex
+	conn *sql.Conn // Holding the lock, nil while not held
+}
+
+func NewPostgresLeaderLock(db *sql.DB, key string) *PostgresLeaderLock {
+	return &PostgresLeaderLock{db: db, key: key}
+}
+
+func (l *PostgresLeaderLock) TryAcquire(ctx context.Context) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn != nil {
+		return true, nil
+	}
+
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`, l.key).Scan(&acquired); err != nil {
+		conn.Close()
+		return false, fmt.Errorf("failed to try leader lock: %w", err)
+	}
+	if !acquired {
+		conn.Close()
+		return false, nil
+	}
+
+	l.conn = conn
+	return true, nil
+}
+
+// Check pings the session holding the lock; a session that still answers
+// still holds it, as the connection never reconnects
+func (l *PostgresLeaderLock) Check(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn == nil {
+		return fmt.Errorf("leader lock not held")
+	}
+	if err := l.conn.PingContext(ctx); err != nil {
+		return fmt.Errorf("lost the connection holding the leader lock: %w", err)
+	}
+	return nil
+}
+
+// Release unlocks and returns the connection; closing a broken connection
+// is enough, as its session is gone with the lock
+func (l *PostgresLeaderLock) Release(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn == nil {
+		return nil
+	}
+	conn := l.conn
+	l.conn = nil
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock(hashtext($1))`, l.key); err != nil {
+		// Drop the session rather than pool it with the lock still held
+		conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		return fmt.Errorf("failed to release leader lock: %w", err)
+	}
+	return nil
+}
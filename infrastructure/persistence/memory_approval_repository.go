@@ -0,0 +1,109 @@
+package persistence
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/events"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+)
+
+// MemoryApprovalRepository keeps approvals in process memory, for
+// ENVIRONMENT=local and tests. Decisions change the records of its
+// MemoryTimeRecordRepository, like the SQL store does in one transaction.
+type MemoryApprovalRepository struct {
+	mu        sync.Mutex
+	approvals map[string]*entities.Approval
+	records   *MemoryTimeRecordRepository
+}
+
+func NewMemoryApprovalRepository(records *MemoryTimeRecordRepository) *MemoryApprovalRepository {
+	return &MemoryApprovalRepository{
+		approvals: make(map[string]*entities.Approval),
+		records:   records,
+	}
+}
+
+func copyApproval(approval *entities.Approval) *entities.Approval {
+	copied := *approval
+	if approval.DecidedAt != nil {
+		decidedAt := *approval.DecidedAt
+		copied.DecidedAt = &decidedAt
+	}
+	return &copied
+}
+
+func (r *MemoryApprovalRepository) SaveWithEvent(ctx context.Context, approval *entities.Approval, event events.DomainEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.records.outbox.append(approval.ID, []events.DomainEvent{event}); err != nil {
+		return err
+	}
+	r.approvals[approval.ID] = copyApproval(approval)
+	return nil
+}
+
+func (r *MemoryApprovalRepository) FindByID(ctx context.Context, id string) (*entities.Approval, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	approval, ok := r.approvals[id]
+	if !ok {
+		return nil, nil
+	}
+	return copyApproval(approval), nil
+}
+
+func (r *MemoryApprovalRepository) FindPending(ctx context.Context, managerID string) ([]*entities.Approval, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var approvals []*entities.Approval
+	for _, approval := range r.approvals {
+		if approval.IsPending() && (managerID == "" || approval.ManagerID == managerID) {
+			approvals = append(approvals, copyApproval(approval))
+		}
+	}
+	sort.SliceStable(approvals, func(i, j int) bool {
+		return approvals[i].CreatedAt.Before(approvals[j].CreatedAt)
+	})
+	return approvals, nil
+}
+
+// SaveDecision holds the approval lock while the record changes, so of two
+// concurrent decisions only the first changes anything
+func (r *MemoryApprovalRepository) SaveDecision(ctx context.Context, approval *entities.Approval, record *entities.TimeRecord, entries []*entities.AuditEntry, evts []events.DomainEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.approvals[approval.ID]
+	if !ok || !stored.IsPending() {
+		return repositories.ErrAlreadyDecided
+	}
+
+	if record != nil {
+		if err := r.records.SaveAllWithAudit(ctx, []*entities.TimeRecord{record}, entries, evts); err != nil {
+			return err
+		}
+	} else if err := r.records.outbox.append(approval.ID, evts); err != nil {
+		return err
+	}
+
+	r.approvals[approval.ID] = copyApproval(approval)
+	return nil
+}
+
+// rekey moves the source's approvals to the target, for employee merges
+func (r *MemoryApprovalRepository) rekey(sourceID, targetID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, approval := range r.approvals {
+		if approval.EmployeeID == sourceID {
+			approval.EmployeeID = targetID
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package persistence
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+// MemoryAuditRepository reads the audit chains a MemoryTimeRecordRepository
+// keeps with its records, and keeps the anchors in process memory
+type MemoryAuditRepository struct {
+	records *MemoryTimeRecordRepository
+
+	mu      sync.RWMutex
+	anchors []*entities.AuditAnchor
+}
+
+func NewMemoryAuditRepository(records *MemoryTimeRecordRepository) *MemoryAuditRepository {
+	return &MemoryAuditRepository{records: records}
+}
+
+func (r *MemoryAuditRepository) FindAll(ctx context.Context) ([]*entities.AuditEntry, error) {
+	r.records.mu.RLock()
+	defer r.records.mu.RUnlock()
+
+	var entries []*entities.AuditEntry
+	for _, chain := range r.records.audit {
+		entries = append(entries, chain...)
+	}
+	return entries, nil
+}
+
+func (r *MemoryAuditRepository) FindByRecords(ctx context.Context, employeeID string, recordIDs []string) ([]*entities.AuditEntry, error) {
+	r.records.mu.RLock()
+	defer r.records.mu.RUnlock()
+
+	var entries []*entities.AuditEntry
+	for _, recordID := range slices.Compact(slices.Sorted(slices.Values(recordIDs))) {
+		entries = append(entries, r.records.audit[recordID]...)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+	})
+	return entries, nil
+}
+
+func (r *MemoryAuditRepository) SaveAnchor(ctx context.Context, anchor *entities.AuditAnchor) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *anchor
+	r.anchors = append(r.anchors, &copied)
+	return nil
+}
+
+func (r *MemoryAuditRepository) FindAnchors(ctx context.Context) ([]*entities.AuditAnchor, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	anchors := make([]*entities.AuditAnchor, 0, len(r.anchors))
+	for _, anchor := range r.anchors {
+		copied := *anchor
+		anchors = append(anchors, &copied)
+	}
+	sort.SliceStable(anchors, func(i, j int) bool {
+		return anchors[i].UpTo.Before(anchors[j].UpTo)
+	})
+	return anchors, nil
+}
@@ -0,0 +1,87 @@
+package persistence
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+// MemoryConsentRepository keeps consents in process memory, for
+// ENVIRONMENT=local and tests
+type MemoryConsentRepository struct {
+	mu sync.RWMutex
+	// Consents by employee and purpose, like the (employee_id, purpose) key
+	consents map[string]map[entities.ConsentPurpose]*entities.Consent
+}
+
+func NewMemoryConsentRepository() *MemoryConsentRepository {
+	return &MemoryConsentRepository{consents: make(map[string]map[entities.ConsentPurpose]*entities.Consent)}
+}
+
+func (r *MemoryConsentRepository) Save(ctx context.Context, consent *entities.Consent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	byPurpose, ok := r.consents[consent.EmployeeID]
+	if !ok {
+		byPurpose = make(map[entities.ConsentPurpose]*entities.Consent)
+		r.consents[consent.EmployeeID] = byPurpose
+	}
+	copied := *consent
+	if stored, ok := byPurpose[consent.Purpose]; ok {
+		// The first consent of a purpose keeps its ID
+		copied.ID = stored.ID
+	}
+	byPurpose[consent.Purpose] = &copied
+	return nil
+}
+
+func (r *MemoryConsentRepository) FindByEmployeeAndPurpose(ctx context.Context, employeeID string, purpose entities.ConsentPurpose) (*entities.Consent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	consent, ok := r.consents[employeeID][purpose]
+	if !ok {
+		return nil, nil
+	}
+	copied := *consent
+	return &copied, nil
+}
+
+func (r *MemoryConsentRepository) FindByEmployee(ctx context.Context, employeeID string) ([]*entities.Consent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var consents []*entities.Consent
+	for _, consent := range r.consents[employeeID] {
+		copied := *consent
+		consents = append(consents, &copied)
+	}
+	sort.Slice(consents, func(i, j int) bool {
+		return consents[i].Purpose < consents[j].Purpose
+	})
+	return consents, nil
+}
+
+// merge moves the source's consents the target does not have yet, like
+// PostgresEmployeeMergeRepository does; the target's own choices win
+func (r *MemoryConsentRepository) merge(sourceID, targetID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for purpose, consent := range r.consents[sourceID] {
+		if _, ok := r.consents[targetID][purpose]; ok {
+			continue
+		}
+		if r.consents[targetID] == nil {
+			r.consents[targetID] = make(map[entities.ConsentPurpose]*entities.Consent)
+		}
+		consent.EmployeeID = targetID
+		consent.UpdatedAt = time.Now().UTC()
+		r.consents[targetID][purpose] = consent
+	}
+	delete(r.consents, sourceID)
+}
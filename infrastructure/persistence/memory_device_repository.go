@@ -0,0 +1,56 @@
+package persistence
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+// MemoryDeviceRepository keeps kiosks in process memory, for
+// ENVIRONMENT=local and tests
+type MemoryDeviceRepository struct {
+	mu      sync.RWMutex
+	devices map[string]*entities.Device
+}
+
+func NewMemoryDeviceRepository() *MemoryDeviceRepository {
+	return &MemoryDeviceRepository{devices: make(map[string]*entities.Device)}
+}
+
+func (r *MemoryDeviceRepository) Save(ctx context.Context, device *entities.Device) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *device
+	r.devices[device.ID] = &copied
+	return nil
+}
+
+func (r *MemoryDeviceRepository) FindByID(ctx context.Context, id string) (*entities.Device, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	device, ok := r.devices[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *device
+	return &copied, nil
+}
+
+func (r *MemoryDeviceRepository) FindAll(ctx context.Context) ([]*entities.Device, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var devices []*entities.Device
+	for _, device := range r.devices {
+		copied := *device
+		devices = append(devices, &copied)
+	}
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].Name < devices[j].Name
+	})
+	return devices, nil
+}
@@ -0,0 +1,39 @@
+package persistence
+
+import (
+	"context"
+	"sync"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+// MemoryEmailSettingsRepository keeps the email settings in process memory,
+// for ENVIRONMENT=local and tests
+type MemoryEmailSettingsRepository struct {
+	mu       sync.RWMutex
+	checkOut *entities.CheckOutEmailSettings
+}
+
+func NewMemoryEmailSettingsRepository() *MemoryEmailSettingsRepository {
+	return &MemoryEmailSettingsRepository{}
+}
+
+func (r *MemoryEmailSettingsRepository) FindCheckOutEmail(ctx context.Context) (*entities.CheckOutEmailSettings, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.checkOut == nil {
+		return nil, nil
+	}
+	copied := *r.checkOut
+	return &copied, nil
+}
+
+func (r *MemoryEmailSettingsRepository) SaveCheckOutEmail(ctx context.Context, settings *entities.CheckOutEmailSettings) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *settings
+	r.checkOut = &copied
+	return nil
+}
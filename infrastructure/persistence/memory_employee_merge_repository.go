@@ -0,0 +1,96 @@
+package persistence
+
+import (
+	"context"
+	"sync"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/events"
+)
+
+// MemoryEmployeeMergeRepository re-attributes an employee's data held by the
+// other in-memory repositories and keeps the aliases, for ENVIRONMENT=local
+// and tests
+type MemoryEmployeeMergeRepository struct {
+	records    *MemoryTimeRecordRepository
+	consents   *MemoryConsentRepository
+	approvals  *MemoryApprovalRepository
+	exceptions *MemoryShiftExceptionRepository
+
+	mu sync.RWMutex
+	// Canonical ID by merged employee ID
+	aliases map[string]string
+}
+
+func NewMemoryEmployeeMergeRepository(records *MemoryTimeRecordRepository, consents *MemoryConsentRepository, approvals *MemoryApprovalRepository, exceptions *MemoryShiftExceptionRepository) *MemoryEmployeeMergeRepository {
+	return &MemoryEmployeeMergeRepository{
+		records:    records,
+		consents:   consents,
+		approvals:  approvals,
+		exceptions: exceptions,
+		aliases:    make(map[string]string),
+	}
+}
+
+func (r *MemoryEmployeeMergeRepository) Merge(ctx context.Context, merge *entities.EmployeeMerge, event events.DomainEvent) (int, error) {
+	moved, err := r.moveRecords(ctx, merge, event)
+	if err != nil {
+		return 0, err
+	}
+	r.approvals.rekey(merge.SourceID, merge.TargetID)
+	r.exceptions.rekey(merge.SourceID, merge.TargetID)
+	r.consents.merge(merge.SourceID, merge.TargetID)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Earlier merges into the source now point at the new canonical ID
+	for alias, canonicalID := range r.aliases {
+		if canonicalID == merge.SourceID {
+			r.aliases[alias] = merge.TargetID
+		}
+	}
+	r.aliases[merge.SourceID] = merge.TargetID
+	return moved, nil
+}
+
+// moveRecords re-keys the source's records with their notes, audit entries
+// and change log, and queues the event, under the records' lock
+func (r *MemoryEmployeeMergeRepository) moveRecords(ctx context.Context, merge *entities.EmployeeMerge, event events.DomainEvent) (int, error) {
+	records := r.records
+	records.mu.Lock()
+	defer records.mu.Unlock()
+
+	if err := records.outbox.append(merge.TargetID, []events.DomainEvent{event}); err != nil {
+		return 0, err
+	}
+	moved := 0
+	for _, record := range records.records {
+		if record.EmployeeID != merge.SourceID {
+			continue
+		}
+		for _, change := range records.changes[record.ID] {
+			change.EmployeeID = merge.TargetID
+		}
+		renamed := copyTimeRecord(record)
+		renamed.EmployeeID = merge.TargetID
+		records.store(ctx, renamed)
+		for _, note := range records.notes[record.ID] {
+			note.EmployeeID = merge.TargetID
+		}
+		for _, entry := range records.audit[record.ID] {
+			entry.EmployeeID = merge.TargetID
+		}
+		moved++
+	}
+	return moved, nil
+}
+
+func (r *MemoryEmployeeMergeRepository) ResolveEmployeeID(ctx context.Context, employeeID string) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if canonicalID, ok := r.aliases[employeeID]; ok {
+		return canonicalID, nil
+	}
+	return employeeID, nil
+}
@@ -0,0 +1,114 @@
+package persistence
+
+import (
+	"context"
+	"testing"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/events"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+)
+
+func TestMemoryEmployeeMerge(t *testing.T) {
+	ctx := repositories.WithChangeOrigin(context.Background(), repositories.ChangeOrigin{Actor: "admin:alice", Source: "merge"})
+	records := NewMemoryTimeRecordRepository(NewMemoryOutboxRepository())
+	consents := NewMemoryConsentRepository()
+	approvals := NewMemoryApprovalRepository(records)
+	exceptions := NewMemoryShiftExceptionRepository(records)
+	merges := NewMemoryEmployeeMergeRepository(records, consents, approvals, exceptions)
+	notes := NewMemoryNoteRepository(records)
+	changes := NewMemoryRecordChangeRepository(records)
+
+	record, err := entities.NewTimeRecord("emp-old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := record.AddNote(entities.NoteOnCheckIn, "forgot my badge"); err != nil {
+		t.Fatal(err)
+	}
+	if err := records.Save(ctx, record); err != nil {
+		t.Fatal(err)
+	}
+	for _, consent := range []struct {
+		employeeID string
+		purpose    entities.ConsentPurpose
+		source     string
+	}{
+		{"emp-old", entities.PurposeNotifications, "kiosk"},
+		{"emp-old", entities.PurposeAnalytics, "kiosk"},
+		{"emp-new", entities.PurposeNotifications, "hr-portal"},
+	} {
+		c, err := entities.NewConsent(consent.employeeID, consent.purpose, consent.source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := consents.Save(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	merge, err := entities.NewEmployeeMerge("emp-old", "emp-new", "admin:alice", "duplicate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	moved, err := merges.Merge(ctx, merge, events.EmployeesMergedEvent{SourceEmployeeID: "emp-old", TargetEmployeeID: "emp-new"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved != 1 {
+		t.Errorf("moved = %d, want 1", moved)
+	}
+
+	tests := []struct {
+		name string
+		got  func() (interface{}, error)
+		want interface{}
+	}{
+		{"alias resolves", func() (interface{}, error) { return merges.ResolveEmployeeID(ctx, "emp-old") }, "emp-new"},
+		{"record moved", func() (interface{}, error) {
+			moved, err := records.FindByID(ctx, record.ID)
+			if err != nil {
+				return nil, err
+			}
+			return moved.EmployeeID, nil
+		}, "emp-new"},
+		{"note moved", func() (interface{}, error) {
+			found, err := notes.FindByRecords(ctx, "emp-new", []string{record.ID})
+			return len(found), err
+		}, 1},
+		{"target's consent wins", func() (interface{}, error) {
+			consent, err := consents.FindByEmployeeAndPurpose(ctx, "emp-new", entities.PurposeNotifications)
+			if err != nil || consent == nil {
+				return nil, err
+			}
+			return consent.Source, nil
+		}, "hr-portal"},
+		{"missing consent moved", func() (interface{}, error) {
+			found, err := consents.FindByEmployee(ctx, "emp-new")
+			return len(found), err
+		}, 2},
+		{"source consents dropped", func() (interface{}, error) {
+			found, err := consents.FindByEmployee(ctx, "emp-old")
+			return len(found), err
+		}, 0},
+		{"rename logged", func() (interface{}, error) {
+			logged, err := changes.FindByRecordID(ctx, record.ID)
+			if err != nil || len(logged) != 2 {
+				return len(logged), err
+			}
+			rename := logged[1]
+			return string(rename.Operation) + " " + string(rename.New["employee_id"]) + " by " + rename.Actor, nil
+		}, `UPDATE "emp-new" by admin:alice`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.got()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package persistence
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+// MemoryEmployeeRepository keeps the roster in process memory, for
+// ENVIRONMENT=local and tests
+type MemoryEmployeeRepository struct {
+	mu        sync.RWMutex
+	employees map[string]*entities.Employee
+}
+
+func NewMemoryEmployeeRepository() *MemoryEmployeeRepository {
+	return &MemoryEmployeeRepository{employees: make(map[string]*entities.Employee)}
+}
+
+func (r *MemoryEmployeeRepository) Save(ctx context.Context, employee *entities.Employee) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *employee
+	if stored, ok := r.employees[employee.ID]; ok {
+		copied.CreatedAt = stored.CreatedAt
+	}
+	r.employees[employee.ID] = &copied
+	return nil
+}
+
+func (r *MemoryEmployeeRepository) FindByID(ctx context.Context, id string) (*entities.Employee, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	employee, ok := r.employees[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *employee
+	return &copied, nil
+}
+
+// FindByEmail matches case-insensitively and prefers active employees, then
+// the lowest ID, like the SQL store
+func (r *MemoryEmployeeRepository) FindByEmail(ctx context.Context, email string) (*entities.Employee, error) {
+	employees, _ := r.FindAll(ctx)
+
+	var found *entities.Employee
+	for _, employee := range employees {
+		if employee.Email == "" || !strings.EqualFold(employee.Email, email) {
+			continue
+		}
+		if found == nil || (employee.Active && !found.Active) {
+			found = employee
+		}
+	}
+	return found, nil
+}
+
+func (r *MemoryEmployeeRepository) FindAll(ctx context.Context) ([]*entities.Employee, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var employees []*entities.Employee
+	for _, employee := range r.employees {
+		copied := *employee
+		employees = append(employees, &copied)
+	}
+	sort.Slice(employees, func(i, j int) bool {
+		return employees[i].ID < employees[j].ID
+	})
+	return employees, nil
+}
+
+func (r *MemoryEmployeeRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.employees, id)
+	return nil
+}
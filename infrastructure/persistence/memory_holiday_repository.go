@@ -0,0 +1,60 @@
+package persistence
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+// MemoryHolidayRepository keeps the holiday calendar in process memory, for
+// ENVIRONMENT=local and tests
+type MemoryHolidayRepository struct {
+	mu       sync.RWMutex
+	holidays map[string]*entities.Holiday
+}
+
+func NewMemoryHolidayRepository() *MemoryHolidayRepository {
+	return &MemoryHolidayRepository{holidays: make(map[string]*entities.Holiday)}
+}
+
+func (r *MemoryHolidayRepository) Save(ctx context.Context, holiday *entities.Holiday) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *holiday
+	if stored, ok := r.holidays[holiday.Date]; ok {
+		copied.CreatedAt = stored.CreatedAt
+	}
+	r.holidays[holiday.Date] = &copied
+	return nil
+}
+
+func (r *MemoryHolidayRepository) Delete(ctx context.Context, date string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.holidays[date]
+	delete(r.holidays, date)
+	return ok, nil
+}
+
+// FindInRange compares the YYYY-MM-DD dates as strings, which orders them
+// like dates
+func (r *MemoryHolidayRepository) FindInRange(ctx context.Context, from, to string) ([]*entities.Holiday, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var holidays []*entities.Holiday
+	for date, holiday := range r.holidays {
+		if date >= from && date <= to {
+			copied := *holiday
+			holidays = append(holidays, &copied)
+		}
+	}
+	sort.Slice(holidays, func(i, j int) bool {
+		return holidays[i].Date < holidays[j].Date
+	})
+	return holidays, nil
+}
@@ -0,0 +1,70 @@
+package persistence
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+)
+
+// MemoryIdempotencyRepository keeps idempotency keys in process memory, for
+// ENVIRONMENT=local and tests
+type MemoryIdempotencyRepository struct {
+	mu   sync.Mutex
+	keys map[string]*repositories.IdempotencyRecord
+}
+
+func NewMemoryIdempotencyRepository() *MemoryIdempotencyRepository {
+	return &MemoryIdempotencyRepository{keys: make(map[string]*repositories.IdempotencyRecord)}
+}
+
+// Reserve takes over a key only when its previous claim expired
+func (r *MemoryIdempotencyRepository) Reserve(ctx context.Context, key, fingerprint string, expiresAt time.Time) (*repositories.IdempotencyRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if stored, ok := r.keys[key]; ok && stored.ExpiresAt.After(time.Now()) {
+		copied := *stored
+		copied.Body = slices.Clone(stored.Body)
+		return &copied, nil
+	}
+	r.keys[key] = &repositories.IdempotencyRecord{Key: key, Fingerprint: fingerprint, ExpiresAt: expiresAt}
+	return nil, nil
+}
+
+func (r *MemoryIdempotencyRepository) Complete(ctx context.Context, key string, statusCode int, contentType string, body []byte, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if stored, ok := r.keys[key]; ok {
+		stored.StatusCode = statusCode
+		stored.ContentType = contentType
+		stored.Body = slices.Clone(body)
+		stored.ExpiresAt = expiresAt
+	}
+	return nil
+}
+
+func (r *MemoryIdempotencyRepository) Release(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.keys, key)
+	return nil
+}
+
+func (r *MemoryIdempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for key, stored := range r.keys {
+		if !stored.ExpiresAt.After(now) {
+			delete(r.keys, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,59 @@
+package persistence
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+// MemoryLocationRepository keeps locations in process memory, for
+// ENVIRONMENT=local and tests
+type MemoryLocationRepository struct {
+	mu        sync.RWMutex
+	locations map[string]*entities.Location
+}
+
+func NewMemoryLocationRepository() *MemoryLocationRepository {
+	return &MemoryLocationRepository{locations: make(map[string]*entities.Location)}
+}
+
+func (r *MemoryLocationRepository) Save(ctx context.Context, location *entities.Location) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *location
+	if stored, ok := r.locations[location.ID]; ok {
+		copied.CreatedAt = stored.CreatedAt
+	}
+	r.locations[location.ID] = &copied
+	return nil
+}
+
+func (r *MemoryLocationRepository) FindByID(ctx context.Context, id string) (*entities.Location, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	location, ok := r.locations[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *location
+	return &copied, nil
+}
+
+func (r *MemoryLocationRepository) FindAll(ctx context.Context) ([]*entities.Location, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var locations []*entities.Location
+	for _, location := range r.locations {
+		copied := *location
+		locations = append(locations, &copied)
+	}
+	sort.Slice(locations, func(i, j int) bool {
+		return locations[i].Name < locations[j].Name
+	})
+	return locations, nil
+}
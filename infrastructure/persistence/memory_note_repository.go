@@ -0,0 +1,50 @@
+package persistence
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+// MemoryNoteRepository reads and appends the notes a MemoryTimeRecordRepository
+// keeps with its records
+type MemoryNoteRepository struct {
+	records *MemoryTimeRecordRepository
+}
+
+func NewMemoryNoteRepository(records *MemoryTimeRecordRepository) *MemoryNoteRepository {
+	return &MemoryNoteRepository{records: records}
+}
+
+func (r *MemoryNoteRepository) Save(ctx context.Context, note *entities.RecordNote) error {
+	r.records.mu.Lock()
+	defer r.records.mu.Unlock()
+
+	copied := *note
+	r.records.notes[note.RecordID] = append(r.records.notes[note.RecordID], &copied)
+	return nil
+}
+
+func (r *MemoryNoteRepository) FindByRecords(ctx context.Context, employeeID string, recordIDs []string) ([]*entities.RecordNote, error) {
+	r.records.mu.RLock()
+	defer r.records.mu.RUnlock()
+
+	var notes []*entities.RecordNote
+	for _, recordID := range slices.Compact(slices.Sorted(slices.Values(recordIDs))) {
+		for _, note := range r.records.notes[recordID] {
+			if note.EmployeeID == employeeID {
+				copied := *note
+				notes = append(notes, &copied)
+			}
+		}
+	}
+	sort.SliceStable(notes, func(i, j int) bool {
+		if !notes[i].CreatedAt.Equal(notes[j].CreatedAt) {
+			return notes[i].CreatedAt.Before(notes[j].CreatedAt)
+		}
+		return notes[i].ID < notes[j].ID
+	})
+	return notes, nil
+}
@@ -0,0 +1,106 @@
+package persistence
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+// MemoryParkedLaborCostRepository keeps parked labor cost entries in process
+// memory, for ENVIRONMENT=local and tests
+type MemoryParkedLaborCostRepository struct {
+	mu     sync.Mutex
+	nextID int64
+	// Entries in parking order; failed ones stay but are not listed
+	parked []*entities.ParkedLaborCost
+	failed map[int64]bool
+}
+
+func NewMemoryParkedLaborCostRepository() *MemoryParkedLaborCostRepository {
+	return &MemoryParkedLaborCostRepository{failed: make(map[int64]bool)}
+}
+
+// Park ignores an entry whose idempotency key is parked already, as a
+// redelivered message parks the same entry again
+func (r *MemoryParkedLaborCostRepository) Park(ctx context.Context, parked *entities.ParkedLaborCost) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if parked.IdempotencyKey != "" && slices.ContainsFunc(r.parked, func(p *entities.ParkedLaborCost) bool {
+		return p.IdempotencyKey == parked.IdempotencyKey
+	}) {
+		return nil
+	}
+	r.nextID++
+	r.parked = append(r.parked, &entities.ParkedLaborCost{
+		ID:             r.nextID,
+		IdempotencyKey: parked.IdempotencyKey,
+		Entry:          slices.Clone(parked.Entry),
+		Reason:         parked.Reason,
+		ParkedAt:       time.Now(),
+	})
+	return nil
+}
+
+func (r *MemoryParkedLaborCostRepository) ListParked(ctx context.Context, limit int) ([]*entities.ParkedLaborCost, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var parked []*entities.ParkedLaborCost
+	for _, p := range r.parked {
+		if len(parked) == limit {
+			break
+		}
+		if !r.failed[p.ID] {
+			copied := *p
+			parked = append(parked, &copied)
+		}
+	}
+	return parked, nil
+}
+
+func (r *MemoryParkedLaborCostRepository) DeleteParked(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.parked = slices.DeleteFunc(r.parked, func(p *entities.ParkedLaborCost) bool { return p.ID == id })
+	delete(r.failed, id)
+	return nil
+}
+
+func (r *MemoryParkedLaborCostRepository) RecordParkedAttempt(ctx context.Context, id int64, lastError string) error {
+	r.update(id, lastError, false)
+	return nil
+}
+
+func (r *MemoryParkedLaborCostRepository) MarkParkedFailed(ctx context.Context, id int64, lastError string) error {
+	r.update(id, lastError, true)
+	return nil
+}
+
+func (r *MemoryParkedLaborCostRepository) CountParked(ctx context.Context) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.parked) - len(r.failed), nil
+}
+
+// update counts a failed submission of the entry, and gives up on it when
+// failed is set
+func (r *MemoryParkedLaborCostRepository) update(id int64, lastError string, failed bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, p := range r.parked {
+		if p.ID == id {
+			p.Attempts++
+			p.LastError = lastError
+			if failed {
+				r.failed[id] = true
+			}
+			return
+		}
+	}
+}
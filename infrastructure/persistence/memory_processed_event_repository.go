@@ -0,0 +1,77 @@
+package persistence
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryProcessedEventRepository is an in-memory inbox, for ENVIRONMENT=local
+// and tests
+type MemoryProcessedEventRepository struct {
+	mu sync.Mutex
+	// Processing time by consumer and event ID
+	processed map[[2]string]time.Time
+	// Deliveries being handled, closed once they are done
+	running map[[2]string]chan struct{}
+}
+
+func NewMemoryProcessedEventRepository() *MemoryProcessedEventRepository {
+	return &MemoryProcessedEventRepository{
+		processed: make(map[[2]string]time.Time),
+		running:   make(map[[2]string]chan struct{}),
+	}
+}
+
+// ProcessOnce runs fn unless the event was processed already. A second
+// delivery waits for the one being handled, and takes over when it failed.
+func (r *MemoryProcessedEventRepository) ProcessOnce(ctx context.Context, consumer, eventID string, fn func(ctx context.Context) error) (bool, error) {
+	key := [2]string{consumer, eventID}
+	for {
+		r.mu.Lock()
+		if _, ok := r.processed[key]; ok {
+			r.mu.Unlock()
+			return false, nil
+		}
+		running, ok := r.running[key]
+		if !ok {
+			break
+		}
+		r.mu.Unlock()
+
+		select {
+		case <-running:
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+	done := make(chan struct{})
+	r.running[key] = done
+	r.mu.Unlock()
+
+	err := fn(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.running, key)
+	close(done)
+	if err != nil {
+		return true, err
+	}
+	r.processed[key] = time.Now()
+	return true, nil
+}
+
+func (r *MemoryProcessedEventRepository) DeleteProcessedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for key, processedAt := range r.processed {
+		if processedAt.Before(cutoff) {
+			delete(r.processed, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,59 @@
+package persistence
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+// MemoryProjectRepository keeps project codes in process memory, for
+// ENVIRONMENT=local and tests
+type MemoryProjectRepository struct {
+	mu       sync.RWMutex
+	projects map[string]*entities.Project
+}
+
+func NewMemoryProjectRepository() *MemoryProjectRepository {
+	return &MemoryProjectRepository{projects: make(map[string]*entities.Project)}
+}
+
+func (r *MemoryProjectRepository) Save(ctx context.Context, project *entities.Project) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *project
+	if stored, ok := r.projects[project.Code]; ok {
+		copied.CreatedAt = stored.CreatedAt
+	}
+	r.projects[project.Code] = &copied
+	return nil
+}
+
+func (r *MemoryProjectRepository) FindByCode(ctx context.Context, code string) (*entities.Project, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	project, ok := r.projects[code]
+	if !ok {
+		return nil, nil
+	}
+	copied := *project
+	return &copied, nil
+}
+
+func (r *MemoryProjectRepository) FindAll(ctx context.Context) ([]*entities.Project, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var projects []*entities.Project
+	for _, project := range r.projects {
+		copied := *project
+		projects = append(projects, &copied)
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		return projects[i].Code < projects[j].Code
+	})
+	return projects, nil
+}
@@ -0,0 +1,25 @@
+package persistence
+
+import (
+	"context"
+	"slices"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+// MemoryRecordChangeRepository reads the change log a MemoryTimeRecordRepository
+// writes as its records change
+type MemoryRecordChangeRepository struct {
+	records *MemoryTimeRecordRepository
+}
+
+func NewMemoryRecordChangeRepository(records *MemoryTimeRecordRepository) *MemoryRecordChangeRepository {
+	return &MemoryRecordChangeRepository{records: records}
+}
+
+func (r *MemoryRecordChangeRepository) FindByRecordID(ctx context.Context, recordID string) ([]*entities.RecordChange, error) {
+	r.records.mu.RLock()
+	defer r.records.mu.RUnlock()
+
+	return slices.Clone(r.records.changes[recordID]), nil
+}
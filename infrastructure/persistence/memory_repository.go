@@ -0,0 +1,787 @@
+package persistence
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/events"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+
+	"github.com/google/uuid"
+)
+
+// MemoryTimeRecordRepository keeps time records in process memory, for
+// ENVIRONMENT=local and tests. Events are appended to its MemoryOutboxRepository
+// like the SQL stores write them in the record's transaction. Records are
+// copied in and out, so callers cannot change stored records by accident, and
+// like the SQL stores it does not load back calculations or notes.
+type MemoryTimeRecordRepository struct {
+	mu      sync.RWMutex
+	records map[string]*entities.TimeRecord
+	// Notes by record, written with their record
+	notes map[string][]*entities.RecordNote
+	// Change log by record, like the time_record_changes trigger writes it
+	changes map[string][]*entities.RecordChange
+	// Records flagged by MarkMissedCheckout
+	missedCheckouts map[string]bool
+	// Deletion time of the records hidden by SoftDelete
+	deleted map[string]time.Time
+	// Audit entries by record, in chain order
+	audit  map[string][]*entities.AuditEntry
+	outbox *MemoryOutboxRepository
+}
+
+func NewMemoryTimeRecordRepository(outbox *MemoryOutboxRepository) *MemoryTimeRecordRepository {
+	return &MemoryTimeRecordRepository{
+		records:         make(map[string]*entities.TimeRecord),
+		notes:           make(map[string][]*entities.RecordNote),
+		changes:         make(map[string][]*entities.RecordChange),
+		missedCheckouts: make(map[string]bool),
+		deleted:         make(map[string]time.Time),
+		audit:           make(map[string][]*entities.AuditEntry),
+		outbox:          outbox,
+	}
+}
+
+// copyTimeRecord copies the stored fields of a record
+func copyTimeRecord(record *entities.TimeRecord) *entities.TimeRecord {
+	copied := &entities.TimeRecord{
+		ID:               record.ID,
+		EmployeeID:       record.EmployeeID,
+		CheckInAt:        record.CheckInAt,
+		Status:           record.Status,
+		HoursWorked:      record.HoursWorked,
+		RegularHours:     record.RegularHours,
+		OvertimeHours:    record.OvertimeHours,
+		LocationID:       record.LocationID,
+		TimeZone:         record.TimeZone,
+		ProjectCode:      record.ProjectCode,
+		BusinessDate:     record.BusinessDate,
+		Segments:         slices.Clone(record.Segments),
+		HolidayHours:     record.HolidayHours,
+		WeekendHours:     record.WeekendHours,
+		Source:           record.Source,
+		DeviceID:         record.DeviceID,
+		CheckOutSource:   record.CheckOutSource,
+		CheckOutDeviceID: record.CheckOutDeviceID,
+	}
+	if record.CheckOutAt != nil {
+		checkOutAt := *record.CheckOutAt
+		copied.CheckOutAt = &checkOutAt
+	}
+	if record.VoidedAt != nil {
+		voidedAt := *record.VoidedAt
+		copied.VoidedAt = &voidedAt
+	}
+	if record.LaborCost != nil {
+		cost := *record.LaborCost
+		copied.LaborCost = &cost
+	}
+	return copied
+}
+
+// recordColumns is a record as the time_records columns the change log holds
+func recordColumns(record *entities.TimeRecord) map[string]json.RawMessage {
+	row := struct {
+		ID               string                    `json:"id"`
+		EmployeeID       string                    `json:"employee_id"`
+		CheckInAt        time.Time                 `json:"check_in_at"`
+		CheckOutAt       *time.Time                `json:"check_out_at"`
+		Status           entities.TimeRecordStatus `json:"status"`
+		HoursWorked      float64                   `json:"hours_worked"`
+		RegularHours     float64                   `json:"regular_hours"`
+		OvertimeHours    float64                   `json:"overtime_hours"`
+		LocationID       string                    `json:"location_id"`
+		TimeZone         string                    `json:"time_zone"`
+		ProjectCode      string                    `json:"project_code"`
+		BusinessDate     string                    `json:"business_date"`
+		Segments         []entities.DaySegment     `json:"segments"`
+		HolidayHours     float64                   `json:"holiday_hours"`
+		WeekendHours     float64                   `json:"weekend_hours"`
+		LaborCost        *entities.LaborCost       `json:"labor_cost"`
+		Source           entities.PunchSource      `json:"source"`
+		DeviceID         string                    `json:"device_id"`
+		CheckOutSource   entities.PunchSource      `json:"check_out_source"`
+		CheckOutDeviceID string                    `json:"check_out_device_id"`
+		VoidedAt         *time.Time                `json:"voided_at"`
+	}{
+		record.ID, record.EmployeeID, record.CheckInAt, record.CheckOutAt, record.Status, record.HoursWorked,
+		record.RegularHours, record.OvertimeHours, record.LocationID, record.TimeZone, record.ProjectCode,
+		record.BusinessDate, record.Segments, record.HolidayHours, record.WeekendHours, record.LaborCost,
+		record.Source, record.DeviceID, record.CheckOutSource, record.CheckOutDeviceID, record.VoidedAt,
+	}
+	data, _ := json.Marshal(row)
+	var columns map[string]json.RawMessage
+	_ = json.Unmarshal(data, &columns)
+	return columns
+}
+
+// logChange appends a write to the change log, keeping the changed columns
+// only for updates and skipping updates that change nothing. Callers hold mu.
+func (r *MemoryTimeRecordRepository) logChange(ctx context.Context, recordID, employeeID string, before, after map[string]json.RawMessage) {
+	change := &entities.RecordChange{
+		ID:         uuid.New().String(),
+		RecordID:   recordID,
+		EmployeeID: employeeID,
+		Old:        before,
+		New:        after,
+		ChangedAt:  time.Now().UTC(),
+	}
+	switch {
+	case before == nil:
+		change.Operation = entities.ChangeInsert
+	case after == nil:
+		change.Operation = entities.ChangeDelete
+	default:
+		change.Operation = entities.ChangeUpdate
+		change.Old, change.New = make(map[string]json.RawMessage), make(map[string]json.RawMessage)
+		for column, value := range after {
+			if !bytes.Equal(before[column], value) {
+				change.Old[column], change.New[column] = before[column], value
+			}
+		}
+		if len(change.New) == 0 {
+			return
+		}
+	}
+	origin := repositories.ChangeOriginFrom(ctx)
+	change.Actor, change.Source = origin.Actor, origin.Source
+	r.changes[recordID] = append(r.changes[recordID], change)
+}
+
+// isDeleted reports whether SoftDelete hid the record. Callers hold mu.
+func (r *MemoryTimeRecordRepository) isDeleted(id string) bool {
+	_, deleted := r.deleted[id]
+	return deleted
+}
+
+// deletedAtColumn is the deleted_at column for the change log, null when
+// deletedAt is nil
+func deletedAtColumn(deletedAt *time.Time) map[string]json.RawMessage {
+	value, _ := json.Marshal(deletedAt)
+	return map[string]json.RawMessage{"deleted_at": value}
+}
+
+// store saves a copy of record with the notes written with it and logs the
+// change. Callers hold mu.
+func (r *MemoryTimeRecordRepository) store(ctx context.Context, record *entities.TimeRecord) {
+	var before map[string]json.RawMessage
+	if stored, ok := r.records[record.ID]; ok {
+		before = recordColumns(stored)
+	}
+	r.records[record.ID] = copyTimeRecord(record)
+	r.logChange(ctx, record.ID, record.EmployeeID, before, recordColumns(record))
+	for _, note := range record.Notes {
+		copied := *note
+		r.notes[record.ID] = append(r.notes[record.ID], &copied)
+	}
+}
+
+// conflicts reports whether saving record would give its employee a second
+// open record, which the SQL schemas reject with a unique index. Callers hold mu.
+func (r *MemoryTimeRecordRepository) conflicts(record *entities.TimeRecord) bool {
+	if record.Status != entities.StatusCheckedIn {
+		return false
+	}
+	for _, other := range r.records {
+		if other.ID != record.ID && other.EmployeeID == record.EmployeeID && other.Status == entities.StatusCheckedIn && !r.isDeleted(other.ID) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *MemoryTimeRecordRepository) Save(ctx context.Context, record *entities.TimeRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.conflicts(record) {
+		return repositories.ErrConflict
+	}
+	r.store(ctx, record)
+	return nil
+}
+
+func (r *MemoryTimeRecordRepository) SaveWithEvent(ctx context.Context, record *entities.TimeRecord, event events.DomainEvent) error {
+	return r.SaveWithEvents(ctx, record, []events.DomainEvent{event})
+}
+
+// SaveWithEvents stores the record and its events under one lock, so readers
+// never see one without the other
+func (r *MemoryTimeRecordRepository) SaveWithEvents(ctx context.Context, record *entities.TimeRecord, evts []events.DomainEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.conflicts(record) {
+		return repositories.ErrConflict
+	}
+	if err := r.outbox.append(record.ID, evts); err != nil {
+		return err
+	}
+	r.store(ctx, record)
+	return nil
+}
+
+// SaveTransfer stores both records and the events under one lock, and
+// neither record when the opened one conflicts
+func (r *MemoryTimeRecordRepository) SaveTransfer(ctx context.Context, closed, opened *entities.TimeRecord, evts []events.DomainEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// The closed record no longer counts as open once the transfer is stored
+	for _, other := range r.records {
+		if other.ID != closed.ID && other.ID != opened.ID && other.EmployeeID == opened.EmployeeID &&
+			other.Status == entities.StatusCheckedIn && !r.isDeleted(other.ID) {
+			return repositories.ErrConflict
+		}
+	}
+	for _, event := range evts {
+		if err := r.outbox.append(recordEventAggregateID(event, closed.ID), []events.DomainEvent{event}); err != nil {
+			return err
+		}
+	}
+	r.store(ctx, closed)
+	r.store(ctx, opened)
+	return nil
+}
+
+func (r *MemoryTimeRecordRepository) FindActiveByEmployeeID(ctx context.Context, employeeID string) (*entities.TimeRecord, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var active *entities.TimeRecord
+	for _, record := range r.records {
+		if record.EmployeeID != employeeID || record.Status != entities.StatusCheckedIn || r.isDeleted(record.ID) {
+			continue
+		}
+		if active == nil || record.CheckInAt.After(active.CheckInAt) {
+			active = record
+		}
+	}
+	if active == nil {
+		return nil, nil
+	}
+	return copyTimeRecord(active), nil
+}
+
+func (r *MemoryTimeRecordRepository) FindByID(ctx context.Context, id string) (*entities.TimeRecord, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	record, ok := r.records[id]
+	if !ok || r.isDeleted(id) {
+		return nil, repositories.ErrRecordNotFound
+	}
+	return copyTimeRecord(record), nil
+}
+
+func (r *MemoryTimeRecordRepository) FindByEmployeeInRange(ctx context.Context, employeeID string, from, to time.Time) ([]*entities.TimeRecord, error) {
+	return r.find(func(record *entities.TimeRecord) bool {
+		return record.EmployeeID == employeeID && overlaps(record, from, to)
+	}), nil
+}
+
+func (r *MemoryTimeRecordRepository) FindPageByEmployeeInRange(ctx context.Context, employeeID string, from, to time.Time, source entities.PunchSource, after *repositories.RecordCursor, limit int) ([]*entities.TimeRecord, error) {
+	records := r.find(func(record *entities.TimeRecord) bool {
+		if record.EmployeeID != employeeID || !overlaps(record, from, to) {
+			return false
+		}
+		if source != "" && record.Source != source && record.CheckOutSource != source {
+			return false
+		}
+		return after == nil || record.CheckInAt.After(after.CheckInAt) ||
+			(record.CheckInAt.Equal(after.CheckInAt) && record.ID > after.ID)
+	})
+	if len(records) > limit {
+		records = records[:limit]
+	}
+	return records, nil
+}
+
+func (r *MemoryTimeRecordRepository) FindActive(ctx context.Context, locationID string) ([]*entities.TimeRecord, error) {
+	return r.find(func(record *entities.TimeRecord) bool {
+		return record.Status == entities.StatusCheckedIn && (locationID == "" || record.LocationID == locationID)
+	}), nil
+}
+
+func (r *MemoryTimeRecordRepository) SumHoursWorked(ctx context.Context, employeeID string, from, to time.Time) (float64, error) {
+	records := r.find(func(record *entities.TimeRecord) bool {
+		if record.EmployeeID != employeeID || !record.IsCompleted() || !record.CheckInAt.Before(to) {
+			return false
+		}
+		return !record.CheckInAt.Before(from) ||
+			(record.Segments != nil && record.CheckOutAt != nil && record.CheckOutAt.After(from))
+	})
+	return sumRecordHours(records, from, to), nil
+}
+
+func (r *MemoryTimeRecordRepository) AggregateHours(ctx context.Context, from, to time.Time, timeZone, period string, weekStart time.Weekday, source entities.PunchSource) ([]repositories.HoursAggregate, error) {
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q: %w", timeZone, err)
+	}
+
+	records := r.find(func(record *entities.TimeRecord) bool {
+		if !record.IsCompleted() || record.CheckInAt.Before(from) || !record.CheckInAt.Before(to) {
+			return false
+		}
+		return source == "" || record.Source == source || record.CheckOutSource == source
+	})
+
+	buckets := newHoursBuckets(loc, period, weekStart)
+	for _, record := range records {
+		err := buckets.add(record.EmployeeID, record.LocationID, record.CheckInAt, record.HoursWorked, record.RegularHours, record.OvertimeHours)
+		if err != nil {
+			return nil, fmt.Errorf("failed to aggregate hours: %w", err)
+		}
+	}
+	return buckets.aggregates(), nil
+}
+
+// SaveAllWithAudit stores the records, chains their audit entries and queues
+// the events under one lock
+func (r *MemoryTimeRecordRepository) SaveAllWithAudit(ctx context.Context, records []*entities.TimeRecord, entries []*entities.AuditEntry, evts []events.DomainEvent) error {
+	if len(records) == 0 {
+		return nil
+	}
+	return r.saveAll(ctx, records, entries, evts, records[0].EmployeeID)
+}
+
+// SaveRepair saves the records unless one of those read changed since, under
+// the same lock
+func (r *MemoryTimeRecordRepository) SaveRepair(ctx context.Context, read, records []*entities.TimeRecord, entries []*entities.AuditEntry, evts []events.DomainEvent) error {
+	if len(records) == 0 {
+		return nil
+	}
+	return r.saveAllUnchanged(ctx, read, records, entries, evts, records[0].EmployeeID)
+}
+
+// saveAll is SaveAllWithAudit with the aggregate of events that are not
+// about a record
+func (r *MemoryTimeRecordRepository) saveAll(ctx context.Context, records []*entities.TimeRecord, entries []*entities.AuditEntry, evts []events.DomainEvent, aggregateID string) error {
+	return r.saveAllUnchanged(ctx, nil, records, entries, evts, aggregateID)
+}
+
+// saveAllUnchanged is saveAll that first checks the records read are as
+// they were
+func (r *MemoryTimeRecordRepository) saveAllUnchanged(ctx context.Context, read, records []*entities.TimeRecord, entries []*entities.AuditEntry, evts []events.DomainEvent, aggregateID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, record := range read {
+		stored, ok := r.records[record.ID]
+		if !ok || r.isDeleted(record.ID) {
+			return fmt.Errorf("time record %s was deleted: %w", record.ID, repositories.ErrConflict)
+		}
+		if !sameRecordState(record, stored.CheckInAt, stored.CheckOutAt, stored.Status) {
+			return fmt.Errorf("time record %s changed: %w", record.ID, repositories.ErrConflict)
+		}
+	}
+
+	for _, record := range records {
+		if r.conflicts(record) {
+			return repositories.ErrConflict
+		}
+	}
+	for _, event := range evts {
+		if err := r.outbox.append(recordEventAggregateID(event, aggregateID), []events.DomainEvent{event}); err != nil {
+			return err
+		}
+	}
+	for _, record := range records {
+		r.store(ctx, record)
+	}
+	for _, entry := range entries {
+		chain := r.audit[entry.RecordID]
+		head := ""
+		if len(chain) > 0 {
+			head = chain[len(chain)-1].Hash
+		}
+		entry.Chain(head, auditChainKey())
+		r.audit[entry.RecordID] = append(chain, entry)
+	}
+	return nil
+}
+
+// AuditEntries returns the audit chain of a record, oldest first
+func (r *MemoryTimeRecordRepository) AuditEntries(recordID string) []*entities.AuditEntry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return slices.Clone(r.audit[recordID])
+}
+
+func (r *MemoryTimeRecordRepository) FindMissedCheckouts(ctx context.Context, openedBefore time.Time, limit int) ([]*entities.TimeRecord, error) {
+	records := r.find(func(record *entities.TimeRecord) bool {
+		return record.Status == entities.StatusCheckedIn && record.CheckInAt.Before(openedBefore) && !r.missedCheckouts[record.ID]
+	})
+	if len(records) > limit {
+		records = records[:limit]
+	}
+	return records, nil
+}
+
+func (r *MemoryTimeRecordRepository) MarkMissedCheckout(ctx context.Context, record *entities.TimeRecord, event events.DomainEvent) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.records[record.ID]
+	if !ok || stored.Status != entities.StatusCheckedIn || r.missedCheckouts[record.ID] || r.isDeleted(record.ID) {
+		return false, nil
+	}
+	if err := r.outbox.append(record.ID, []events.DomainEvent{event}); err != nil {
+		return false, err
+	}
+	r.missedCheckouts[record.ID] = true
+	return true, nil
+}
+
+func (r *MemoryTimeRecordRepository) SoftDelete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.records[id]; !ok || r.isDeleted(id) {
+		return repositories.ErrRecordNotFound
+	}
+	deletedAt := time.Now().UTC()
+	r.deleted[id] = deletedAt
+	r.logChange(ctx, id, r.records[id].EmployeeID, deletedAtColumn(nil), deletedAtColumn(&deletedAt))
+	return nil
+}
+
+func (r *MemoryTimeRecordRepository) Restore(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record, ok := r.records[id]
+	if !ok || !r.isDeleted(id) {
+		return repositories.ErrRecordNotFound
+	}
+	if r.conflicts(record) {
+		return repositories.ErrConflict
+	}
+	deletedAt := r.deleted[id]
+	r.logChange(ctx, id, record.EmployeeID, deletedAtColumn(&deletedAt), deletedAtColumn(nil))
+	delete(r.deleted, id)
+	return nil
+}
+
+// find returns copies of the matching non-deleted records ordered by (check_in_at, id);
+// match runs under the read lock
+func (r *MemoryTimeRecordRepository) find(match func(record *entities.TimeRecord) bool) []*entities.TimeRecord {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var records []*entities.TimeRecord
+	for _, record := range r.records {
+		if !r.isDeleted(record.ID) && match(record) {
+			records = append(records, copyTimeRecord(record))
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if !records[i].CheckInAt.Equal(records[j].CheckInAt) {
+			return records[i].CheckInAt.Before(records[j].CheckInAt)
+		}
+		return records[i].ID < records[j].ID
+	})
+	return records
+}
+
+// overlaps reports whether the record overlaps [from, to); open records
+// overlap everything after their check-in
+func overlaps(record *entities.TimeRecord, from, to time.Time) bool {
+	return record.CheckInAt.Before(to) && (record.CheckOutAt == nil || record.CheckOutAt.After(from))
+}
+
+// MemoryOutboxRepository is the outbox of MemoryTimeRecordRepository
+type MemoryOutboxRepository struct {
+	mu     sync.Mutex
+	events []repositories.OutboxEvent
+	// Last publish error per event, like outbox_events.last_error
+	lastErrors map[string]string
+	// Publish time per event, like outbox_events.published_at
+	publishedAt map[string]time.Time
+	// Lease end per claimed event, like outbox_events.claimed_until
+	claimedUntil map[string]time.Time
+	// Events out of retries, like outbox_events.failed_at
+	failedAt map[string]time.Time
+}
+
+func NewMemoryOutboxRepository() *MemoryOutboxRepository {
+	return &MemoryOutboxRepository{
+		lastErrors:   make(map[string]string),
+		publishedAt:  make(map[string]time.Time),
+		claimedUntil: make(map[string]time.Time),
+		failedAt:     make(map[string]time.Time),
+	}
+}
+
+// SaveEvent queues an event that is not written with a record
+func (r *MemoryOutboxRepository) SaveEvent(ctx context.Context, event events.DomainEvent) error {
+	return r.append(recordEventAggregateID(event, events.EmployeeOf(event)), []events.DomainEvent{event})
+}
+
+func (r *MemoryOutboxRepository) append(aggregateID string, evts []events.DomainEvent) error {
+	queued := make([]repositories.OutboxEvent, 0, len(evts))
+	now := time.Now().UTC()
+	for _, event := range evts {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal event: %w", err)
+		}
+		queued = append(queued, repositories.OutboxEvent{
+			ID:          uuid.New().String(),
+			EventType:   event.EventType(),
+			AggregateID: aggregateID,
+			Payload:     payload,
+			CreatedAt:   now,
+			Priority:    events.PriorityOf(event),
+		})
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, queued...)
+	return nil
+}
+
+func (r *MemoryOutboxRepository) ClaimUnpublished(ctx context.Context, claimant string, limit int, lease time.Duration) ([]repositories.OutboxEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	agedBefore := outboxAgingCutoff(now)
+
+	// Only the first pending event of an aggregate may be claimed; events are
+	// kept in the order they were queued, like outbox_events.seq
+	first := make(map[string]string)
+	for _, event := range r.events {
+		if _, ok := first[event.AggregateID]; !event.Published && !ok {
+			first[event.AggregateID] = event.ID
+		}
+	}
+
+	var pending []rankedOutboxEvent
+	for _, event := range r.events {
+		_, failed := r.failedAt[event.ID]
+		if event.Published || failed || now.Before(r.claimedUntil[event.ID]) || first[event.AggregateID] != event.ID {
+			continue
+		}
+		rank := event.Priority
+		if event.CreatedAt.Before(agedBefore) {
+			rank = 0
+		}
+		pending = append(pending, rankedOutboxEvent{OutboxEvent: event, rank: rank})
+	}
+
+	claimed := mergeRankedOutboxEvents(pending)
+	if len(claimed) > limit {
+		claimed = claimed[:limit]
+	}
+	for _, event := range claimed {
+		r.claimedUntil[event.ID] = now.Add(lease)
+	}
+	return claimed, nil
+}
+
+func (r *MemoryOutboxRepository) MarkAsPublished(ctx context.Context, eventIDs []string) error {
+	for _, eventID := range eventIDs {
+		err := r.update(eventID, func(event *repositories.OutboxEvent) {
+			event.Published = true
+			r.publishedAt[eventID] = time.Now().UTC()
+			delete(r.claimedUntil, eventID)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MemoryOutboxRepository) IncrementRetryCount(ctx context.Context, eventID string, errorMsg string, maxRetries int) (bool, error) {
+	var failed bool
+	err := r.update(eventID, func(event *repositories.OutboxEvent) {
+		event.RetryCount++
+		r.lastErrors[eventID] = errorMsg
+		delete(r.claimedUntil, eventID)
+		if maxRetries > 0 && event.RetryCount >= maxRetries {
+			r.failedAt[eventID] = time.Now().UTC()
+			failed = true
+		}
+	})
+	return failed, err
+}
+
+func (r *MemoryOutboxRepository) ReleaseClaims(ctx context.Context, eventIDs []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range eventIDs {
+		delete(r.claimedUntil, id)
+	}
+	return nil
+}
+
+func (r *MemoryOutboxRepository) Backlog(ctx context.Context) (repositories.OutboxBacklog, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var backlog repositories.OutboxBacklog
+	for _, event := range r.events {
+		if event.Published {
+			continue
+		}
+		if _, failed := r.failedAt[event.ID]; failed {
+			backlog.Failed++
+			continue
+		}
+		if backlog.Pending == 0 {
+			oldest := event.CreatedAt
+			backlog.OldestAt = &oldest
+		}
+		backlog.Pending++
+	}
+	return backlog, nil
+}
+
+func (r *MemoryOutboxRepository) PrunePublished(ctx context.Context, publishedBefore time.Time, limit int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.events[:0]
+	deleted := 0
+	for _, event := range r.events {
+		if publishedAt, ok := r.publishedAt[event.ID]; ok && deleted < limit && publishedAt.Before(publishedBefore) {
+			delete(r.publishedAt, event.ID)
+			delete(r.lastErrors, event.ID)
+			deleted++
+			continue
+		}
+		kept = append(kept, event)
+	}
+	r.events = kept
+	return deleted, nil
+}
+
+func (r *MemoryOutboxRepository) Size(ctx context.Context) (repositories.OutboxSize, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return repositories.OutboxSize{Rows: int64(len(r.events))}, nil
+}
+
+func (r *MemoryOutboxRepository) FindByAggregates(ctx context.Context, employeeID string, aggregateIDs []string) ([]repositories.OutboxEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var found []repositories.OutboxEvent
+	for _, event := range r.events {
+		if slices.Contains(aggregateIDs, event.AggregateID) {
+			found = append(found, event)
+		}
+	}
+	return found, nil
+}
+
+// FindFailed leaves employee IDs empty; the outbox does not see the records
+func (r *MemoryOutboxRepository) FindFailed(ctx context.Context, filter repositories.OutboxFailureFilter, after *repositories.OutboxCursor, limit int) ([]repositories.FailedOutboxEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var found []repositories.FailedOutboxEvent
+	for _, event := range r.events {
+		if event.Published || event.RetryCount < filter.MinRetries {
+			continue
+		}
+		failedAt, failed := r.failedAt[event.ID]
+		if (filter.Status == repositories.OutboxStatusFailed && !failed) || (filter.Status == repositories.OutboxStatusPending && failed) {
+			continue
+		}
+		if (filter.From != nil && event.CreatedAt.Before(*filter.From)) || (filter.To != nil && !event.CreatedAt.Before(*filter.To)) {
+			continue
+		}
+		if filter.EventType != "" && event.EventType != filter.EventType {
+			continue
+		}
+		if after != nil && (event.CreatedAt.Before(after.CreatedAt) ||
+			(event.CreatedAt.Equal(after.CreatedAt) && event.ID <= after.ID)) {
+			continue
+		}
+		unpublished := repositories.FailedOutboxEvent{
+			ID:          event.ID,
+			EventType:   event.EventType,
+			AggregateID: event.AggregateID,
+			CreatedAt:   event.CreatedAt,
+			RetryCount:  event.RetryCount,
+			LastError:   r.lastErrors[event.ID],
+		}
+		if failed {
+			unpublished.FailedAt = &failedAt
+		}
+		found = append(found, unpublished)
+	}
+	return firstFailedOutboxEvents(found, limit), nil
+}
+
+func (r *MemoryOutboxRepository) Requeue(ctx context.Context, eventIDs []string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	requeued := 0
+	for i := range r.events {
+		if !r.events[i].Published && slices.Contains(eventIDs, r.events[i].ID) {
+			r.requeue(&r.events[i])
+			requeued++
+		}
+	}
+	return requeued, nil
+}
+
+func (r *MemoryOutboxRepository) RequeueFailed(ctx context.Context, eventType string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	requeued := 0
+	for i := range r.events {
+		event := &r.events[i]
+		if _, failed := r.failedAt[event.ID]; event.Published || !failed || (eventType != "" && event.EventType != eventType) {
+			continue
+		}
+		r.requeue(event)
+		requeued++
+	}
+	return requeued, nil
+}
+
+// requeue resets an event's retries; the caller holds mu
+func (r *MemoryOutboxRepository) requeue(event *repositories.OutboxEvent) {
+	event.RetryCount = 0
+	delete(r.failedAt, event.ID)
+}
+
+// Events returns every queued event, published or not, oldest first
+func (r *MemoryOutboxRepository) Events() []repositories.OutboxEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.events)
+}
+
+func (r *MemoryOutboxRepository) update(eventID string, apply func(event *repositories.OutboxEvent)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.events {
+		if r.events[i].ID == eventID {
+			apply(&r.events[i])
+			return nil
+		}
+	}
+	return fmt.Errorf("outbox event %s not found", eventID)
+}
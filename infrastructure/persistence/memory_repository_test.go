@@ -0,0 +1,230 @@
+package persistence
+
+import (
+	"context"
+	stderrors "errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/events"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+)
+
+func TestMemorySaveRepair(t *testing.T) {
+	checkIn := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		// change runs after the record was read
+		change  func(ctx context.Context, records *MemoryTimeRecordRepository, record *entities.TimeRecord) error
+		wantErr error
+	}{
+		{name: "unchanged record"},
+		{
+			name: "record corrected meanwhile",
+			change: func(ctx context.Context, records *MemoryTimeRecordRepository, record *entities.TimeRecord) error {
+				if err := record.Correct(record.CheckInAt, record.CheckInAt.Add(2*time.Hour)); err != nil {
+					return err
+				}
+				return records.Save(ctx, record)
+			},
+			wantErr: repositories.ErrConflict,
+		},
+		{
+			name: "record deleted meanwhile",
+			change: func(ctx context.Context, records *MemoryTimeRecordRepository, record *entities.TimeRecord) error {
+				return records.SoftDelete(ctx, record.ID)
+			},
+			wantErr: repositories.ErrConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			records := NewMemoryTimeRecordRepository(NewMemoryOutboxRepository())
+			record, err := entities.NewManualTimeRecord("emp-1", checkIn, checkIn.Add(8*time.Hour), "UTC")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := records.Save(ctx, record); err != nil {
+				t.Fatal(err)
+			}
+
+			read, err := records.FindByID(ctx, record.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.change != nil {
+				changed, err := records.FindByID(ctx, record.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := tt.change(ctx, records, changed); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			voided := *read
+			if err := voided.Void(); err != nil {
+				t.Fatal(err)
+			}
+			err = records.SaveRepair(ctx, []*entities.TimeRecord{read}, []*entities.TimeRecord{&voided}, nil, nil)
+			if !stderrors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("SaveRepair error = %v, want %v", err, tt.wantErr)
+			}
+
+			stored, err := records.FindByID(ctx, record.ID)
+			if err != nil && err != repositories.ErrRecordNotFound {
+				t.Fatal(err)
+			}
+			if tt.wantErr == nil && (stored == nil || stored.Status != entities.StatusVoided) {
+				t.Fatalf("repair was not saved")
+			}
+			if tt.wantErr != nil && stored != nil && stored.Status == entities.StatusVoided {
+				t.Fatalf("repair was saved over a changed record")
+			}
+		})
+	}
+}
+
+// queueEvent queues a check-in event of record at createdAt
+func queueEvent(t *testing.T, outbox *MemoryOutboxRepository, recordID string, priority int, createdAt time.Time) string {
+	t.Helper()
+	event := events.EmployeeCheckedInEvent{
+		EventHeader: events.EventHeader{EventID: recordID, EventType: events.EventTypeEmployeeCheckedIn, Version: 1, Timestamp: createdAt, Priority: priority},
+		EmployeeID:  "emp-1",
+		RecordID:    recordID,
+	}
+	if err := outbox.append(recordID, []events.DomainEvent{event}); err != nil {
+		t.Fatal(err)
+	}
+	outbox.mu.Lock()
+	defer outbox.mu.Unlock()
+	queued := &outbox.events[len(outbox.events)-1]
+	queued.CreatedAt = createdAt
+	return queued.ID
+}
+
+func claimedIDs(t *testing.T, outbox *MemoryOutboxRepository, claimant string, lease time.Duration) []string {
+	t.Helper()
+	claimed, err := outbox.ClaimUnpublished(context.Background(), claimant, 10, lease)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]string, len(claimed))
+	for i, event := range claimed {
+		ids[i] = event.ID
+	}
+	return ids
+}
+
+func TestMemoryClaimUnpublished(t *testing.T) {
+	ctx := context.Background()
+	start := time.Now().UTC().Add(-time.Minute)
+
+	t.Run("claimed events are skipped until released", func(t *testing.T) {
+		outbox := NewMemoryOutboxRepository()
+		id := queueEvent(t, outbox, "rec-1", events.PriorityNormal, start)
+
+		if got := claimedIDs(t, outbox, "relay-1", time.Minute); !slices.Equal(got, []string{id}) {
+			t.Fatalf("relay-1 claimed %v, want %v", got, []string{id})
+		}
+		if got := claimedIDs(t, outbox, "relay-2", time.Minute); len(got) != 0 {
+			t.Fatalf("relay-2 claimed %v while relay-1 holds it", got)
+		}
+		if err := outbox.ReleaseClaims(ctx, []string{id}); err != nil {
+			t.Fatal(err)
+		}
+		if got := claimedIDs(t, outbox, "relay-2", time.Minute); !slices.Equal(got, []string{id}) {
+			t.Fatalf("relay-2 claimed %v after the release, want %v", got, []string{id})
+		}
+	})
+
+	t.Run("expired lease is claimed again", func(t *testing.T) {
+		outbox := NewMemoryOutboxRepository()
+		id := queueEvent(t, outbox, "rec-1", events.PriorityNormal, start)
+
+		claimedIDs(t, outbox, "relay-1", time.Millisecond)
+		time.Sleep(5 * time.Millisecond)
+		if got := claimedIDs(t, outbox, "relay-2", time.Minute); !slices.Equal(got, []string{id}) {
+			t.Fatalf("relay-2 claimed %v after the lease ran out, want %v", got, []string{id})
+		}
+	})
+
+	t.Run("payroll events ahead of older routine ones", func(t *testing.T) {
+		outbox := NewMemoryOutboxRepository()
+		routine := queueEvent(t, outbox, "rec-1", events.PriorityRoutine, start)
+		payroll := queueEvent(t, outbox, "rec-2", events.PriorityPayroll, start.Add(time.Second))
+
+		if got := claimedIDs(t, outbox, "relay-1", time.Minute); !slices.Equal(got, []string{payroll, routine}) {
+			t.Fatalf("claimed %v, want %v", got, []string{payroll, routine})
+		}
+	})
+}
+
+func TestMemoryClaimUnpublishedInAggregateOrder(t *testing.T) {
+	ctx := context.Background()
+	start := time.Now().UTC().Add(-time.Minute)
+
+	t.Run("oldest event of an aggregate first", func(t *testing.T) {
+		outbox := NewMemoryOutboxRepository()
+		first := queueEvent(t, outbox, "rec-1", events.PriorityNormal, start)
+		second := queueEvent(t, outbox, "rec-1", events.PriorityNormal, start.Add(time.Second))
+
+		if got := claimedIDs(t, outbox, "relay-1", time.Minute); !slices.Equal(got, []string{first}) {
+			t.Fatalf("claimed %v, want %v", got, []string{first})
+		}
+		if err := outbox.MarkAsPublished(ctx, []string{first}); err != nil {
+			t.Fatal(err)
+		}
+		if got := claimedIDs(t, outbox, "relay-1", time.Minute); !slices.Equal(got, []string{second}) {
+			t.Fatalf("claimed %v, want %v", got, []string{second})
+		}
+	})
+
+	t.Run("events written together keep their order across priorities", func(t *testing.T) {
+		outbox := NewMemoryOutboxRepository()
+		header := events.EventHeader{EventType: events.EventTypeEmployeeCheckedIn, Version: 1, Timestamp: start}
+		normal, payroll := header, header
+		normal.EventID, normal.Priority = "evt-1", events.PriorityNormal
+		payroll.EventID, payroll.Priority = "evt-2", events.PriorityPayroll
+		err := outbox.append("rec-1", []events.DomainEvent{
+			events.EmployeeCheckedInEvent{EventHeader: normal, EmployeeID: "emp-1", RecordID: "rec-1"},
+			events.EmployeeCheckedInEvent{EventHeader: payroll, EmployeeID: "emp-1", RecordID: "rec-1"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		first, second := outbox.events[0], outbox.events[1]
+		if !first.CreatedAt.Equal(second.CreatedAt) {
+			t.Fatalf("created_at %v and %v, want one timestamp per write", first.CreatedAt, second.CreatedAt)
+		}
+
+		if got := claimedIDs(t, outbox, "relay-1", time.Minute); !slices.Equal(got, []string{first.ID}) {
+			t.Fatalf("claimed %v, want %v", got, []string{first.ID})
+		}
+		if err := outbox.MarkAsPublished(ctx, []string{first.ID}); err != nil {
+			t.Fatal(err)
+		}
+		if got := claimedIDs(t, outbox, "relay-1", time.Minute); !slices.Equal(got, []string{second.ID}) {
+			t.Fatalf("claimed %v, want %v", got, []string{second.ID})
+		}
+	})
+
+	t.Run("failed event holds back its aggregate", func(t *testing.T) {
+		outbox := NewMemoryOutboxRepository()
+		failed := queueEvent(t, outbox, "rec-1", events.PriorityNormal, start)
+		queueEvent(t, outbox, "rec-1", events.PriorityNormal, start.Add(time.Second))
+		other := queueEvent(t, outbox, "rec-2", events.PriorityNormal, start.Add(2*time.Second))
+
+		if _, err := outbox.IncrementRetryCount(ctx, failed, "broker down", 1); err != nil {
+			t.Fatal(err)
+		}
+		if got := claimedIDs(t, outbox, "relay-1", time.Minute); !slices.Equal(got, []string{other}) {
+			t.Fatalf("claimed %v, want only %v", got, []string{other})
+		}
+	})
+}
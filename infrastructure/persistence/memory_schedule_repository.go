@@ -0,0 +1,81 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+// MemoryScheduleRepository keeps the shift schedules in process memory, for
+// ENVIRONMENT=local and tests
+type MemoryScheduleRepository struct {
+	mu     sync.RWMutex
+	shifts map[string][]*entities.ScheduledShift
+}
+
+func NewMemoryScheduleRepository() *MemoryScheduleRepository {
+	return &MemoryScheduleRepository{shifts: make(map[string][]*entities.ScheduledShift)}
+}
+
+func (r *MemoryScheduleRepository) ReplaceForEmployee(ctx context.Context, employeeID string, shifts []*entities.ScheduledShift) error {
+	type start struct {
+		weekday time.Weekday
+		at      string
+	}
+	starts := make(map[start]bool, len(shifts))
+	replaced := make([]*entities.ScheduledShift, 0, len(shifts))
+	for _, shift := range shifts {
+		key := start{shift.Weekday, shift.Start}
+		if starts[key] {
+			return fmt.Errorf("failed to save shift: two shifts start at %s on weekday %d", shift.Start, shift.Weekday)
+		}
+		starts[key] = true
+		copied := *shift
+		copied.EmployeeID = employeeID
+		replaced = append(replaced, &copied)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.shifts[employeeID] = replaced
+	return nil
+}
+
+func (r *MemoryScheduleRepository) FindByEmployee(ctx context.Context, employeeID string) ([]*entities.ScheduledShift, error) {
+	return r.find(func(shift *entities.ScheduledShift) bool { return shift.EmployeeID == employeeID }), nil
+}
+
+func (r *MemoryScheduleRepository) FindByWeekday(ctx context.Context, weekday time.Weekday) ([]*entities.ScheduledShift, error) {
+	return r.find(func(shift *entities.ScheduledShift) bool { return shift.Weekday == weekday }), nil
+}
+
+// find returns copies of the matching shifts ordered by employee, weekday
+// and start time
+func (r *MemoryScheduleRepository) find(match func(shift *entities.ScheduledShift) bool) []*entities.ScheduledShift {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var shifts []*entities.ScheduledShift
+	for _, employeeShifts := range r.shifts {
+		for _, shift := range employeeShifts {
+			if match(shift) {
+				copied := *shift
+				shifts = append(shifts, &copied)
+			}
+		}
+	}
+	sort.Slice(shifts, func(i, j int) bool {
+		if shifts[i].EmployeeID != shifts[j].EmployeeID {
+			return shifts[i].EmployeeID < shifts[j].EmployeeID
+		}
+		if shifts[i].Weekday != shifts[j].Weekday {
+			return shifts[i].Weekday < shifts[j].Weekday
+		}
+		return shifts[i].Start < shifts[j].Start
+	})
+	return shifts
+}
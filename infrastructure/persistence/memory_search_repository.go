@@ -0,0 +1,128 @@
+package persistence
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+)
+
+// MemorySearchRepository searches the records and audit entries of a
+// MemoryTimeRecordRepository, matching like the ILIKE filters of the SQL store
+type MemorySearchRepository struct {
+	records *MemoryTimeRecordRepository
+}
+
+func NewMemorySearchRepository(records *MemoryTimeRecordRepository) *MemorySearchRepository {
+	return &MemorySearchRepository{records: records}
+}
+
+func (r *MemorySearchRepository) Search(ctx context.Context, query repositories.SearchQuery, after *repositories.SearchCursor, limit int) ([]repositories.SearchHit, error) {
+	r.records.mu.RLock()
+	defer r.records.mu.RUnlock()
+
+	inRange := func(at time.Time, id string) bool {
+		if (!query.From.IsZero() && at.Before(query.From)) || (!query.To.IsZero() && !at.Before(query.To)) {
+			return false
+		}
+		return after == nil || at.Before(after.At) || (at.Equal(after.At) && id < after.ID)
+	}
+
+	var hits []repositories.SearchHit
+	if searchesType(query.Types, repositories.SearchTypeRecord) {
+		for _, record := range r.records.records {
+			if r.records.isDeleted(record.ID) || !inRange(record.CheckInAt, record.ID) {
+				continue
+			}
+			devices := []string{record.DeviceID, record.CheckOutDeviceID}
+			text := append([]string{record.EmployeeID}, devices...)
+			if !r.matchesText(query.Text, record.ID, text...) || !containsFold(query.EmployeeID, record.EmployeeID) ||
+				!containsFold(query.DeviceID, devices...) || !r.matchesNote(query.Note, record.ID) {
+				continue
+			}
+			hits = append(hits, repositories.SearchHit{
+				Type:       repositories.SearchTypeRecord,
+				ID:         record.ID,
+				EmployeeID: record.EmployeeID,
+				RecordID:   record.ID,
+				At:         record.CheckInAt.UTC(),
+				Status:     string(record.Status),
+				DeviceID:   record.DeviceID,
+			})
+		}
+	}
+	if searchesType(query.Types, repositories.SearchTypeAudit) {
+		for recordID, chain := range r.records.audit {
+			var devices []string
+			if record, ok := r.records.records[recordID]; ok {
+				devices = []string{record.DeviceID, record.CheckOutDeviceID}
+			}
+			for _, entry := range chain {
+				if !inRange(entry.CreatedAt, entry.ID) {
+					continue
+				}
+				if !containsFold(query.Text, entry.EmployeeID, entry.Reason) || !containsFold(query.EmployeeID, entry.EmployeeID) ||
+					!containsFold(query.DeviceID, devices...) || !r.matchesNote(query.Note, recordID) {
+					continue
+				}
+				hits = append(hits, repositories.SearchHit{
+					Type:       repositories.SearchTypeAudit,
+					ID:         entry.ID,
+					EmployeeID: entry.EmployeeID,
+					RecordID:   entry.RecordID,
+					At:         entry.CreatedAt.UTC(),
+					Action:     string(entry.Action),
+					Actor:      entry.Actor,
+					Reason:     entry.Reason,
+				})
+			}
+		}
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if !hits[i].At.Equal(hits[j].At) {
+			return hits[i].At.After(hits[j].At)
+		}
+		return hits[i].ID > hits[j].ID
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// matchesText reports whether text is in one of values or a note of the
+// record. Callers hold the records' lock.
+func (r *MemorySearchRepository) matchesText(text, recordID string, values ...string) bool {
+	return containsFold(text, values...) || (text != "" && r.matchesNote(text, recordID))
+}
+
+// matchesNote reports whether text is in a note of the record, or is empty.
+// Callers hold the records' lock.
+func (r *MemorySearchRepository) matchesNote(text, recordID string) bool {
+	if text == "" {
+		return true
+	}
+	for _, note := range r.records.notes[recordID] {
+		if containsFold(text, note.Body) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFold reports whether one of values contains s case-insensitively;
+// empty s matches everything
+func containsFold(s string, values ...string) bool {
+	if s == "" {
+		return true
+	}
+	for _, value := range values {
+		if strings.Contains(strings.ToLower(value), strings.ToLower(s)) {
+			return true
+		}
+	}
+	return false
+}
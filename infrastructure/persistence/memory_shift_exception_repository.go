@@ -0,0 +1,73 @@
+package persistence
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/events"
+)
+
+// MemoryShiftExceptionRepository keeps shift exceptions in process memory and
+// queues their events in the outbox of a MemoryTimeRecordRepository
+type MemoryShiftExceptionRepository struct {
+	mu         sync.RWMutex
+	exceptions []*entities.ShiftException
+	records    *MemoryTimeRecordRepository
+}
+
+func NewMemoryShiftExceptionRepository(records *MemoryTimeRecordRepository) *MemoryShiftExceptionRepository {
+	return &MemoryShiftExceptionRepository{records: records}
+}
+
+// SaveWithEvent stores the exception unless one of its kind was already
+// recorded for the same shift, like the unique index of the SQL store
+func (r *MemoryShiftExceptionRepository) SaveWithEvent(ctx context.Context, exception *entities.ShiftException, event events.DomainEvent) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, stored := range r.exceptions {
+		if stored.EmployeeID == exception.EmployeeID && stored.Kind == exception.Kind && stored.ScheduledStart.Equal(exception.ScheduledStart) {
+			return false, nil
+		}
+	}
+	if err := r.records.outbox.append(exception.ID, []events.DomainEvent{event}); err != nil {
+		return false, err
+	}
+	copied := *exception
+	r.exceptions = append(r.exceptions, &copied)
+	return true, nil
+}
+
+func (r *MemoryShiftExceptionRepository) FindByDate(ctx context.Context, date string) ([]*entities.ShiftException, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var exceptions []*entities.ShiftException
+	for _, exception := range r.exceptions {
+		if exception.BusinessDate == date {
+			copied := *exception
+			exceptions = append(exceptions, &copied)
+		}
+	}
+	sort.SliceStable(exceptions, func(i, j int) bool {
+		if !exceptions[i].ScheduledStart.Equal(exceptions[j].ScheduledStart) {
+			return exceptions[i].ScheduledStart.Before(exceptions[j].ScheduledStart)
+		}
+		return exceptions[i].EmployeeID < exceptions[j].EmployeeID
+	})
+	return exceptions, nil
+}
+
+// rekey moves the source's exceptions to the target, for employee merges
+func (r *MemoryShiftExceptionRepository) rekey(sourceID, targetID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, exception := range r.exceptions {
+		if exception.EmployeeID == sourceID {
+			exception.EmployeeID = targetID
+		}
+	}
+}
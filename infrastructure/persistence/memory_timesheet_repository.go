@@ -0,0 +1,76 @@
+package persistence
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/events"
+)
+
+// MemoryTimesheetRepository keeps timesheets in process memory, for
+// ENVIRONMENT=local and tests. Closing a period locks the records of its
+// MemoryTimeRecordRepository, like the SQL store does in one transaction.
+type MemoryTimesheetRepository struct {
+	mu      sync.RWMutex
+	sheets  map[string]*entities.Timesheet
+	records *MemoryTimeRecordRepository
+}
+
+func NewMemoryTimesheetRepository(records *MemoryTimeRecordRepository) *MemoryTimesheetRepository {
+	return &MemoryTimesheetRepository{
+		sheets:  make(map[string]*entities.Timesheet),
+		records: records,
+	}
+}
+
+func copyTimesheet(sheet *entities.Timesheet) *entities.Timesheet {
+	copied := *sheet
+	copied.RecordIDs = slices.Clone(sheet.RecordIDs)
+	return &copied
+}
+
+func (r *MemoryTimesheetRepository) Save(ctx context.Context, sheet *entities.Timesheet) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sheets[sheet.ID] = copyTimesheet(sheet)
+	return nil
+}
+
+func (r *MemoryTimesheetRepository) FindByPeriod(ctx context.Context, employeeID, periodStart string) (*entities.Timesheet, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, sheet := range r.sheets {
+		if sheet.EmployeeID == employeeID && sheet.PeriodStart == periodStart {
+			return copyTimesheet(sheet), nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *MemoryTimesheetRepository) FindByID(ctx context.Context, id string) (*entities.Timesheet, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sheet, ok := r.sheets[id]
+	if !ok {
+		return nil, nil
+	}
+	return copyTimesheet(sheet), nil
+}
+
+// SaveClosed holds the timesheet lock while the records change, so the sheet
+// is only stored with its records
+func (r *MemoryTimesheetRepository) SaveClosed(ctx context.Context, sheet *entities.Timesheet, records []*entities.TimeRecord, entries []*entities.AuditEntry, evts []events.DomainEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.records.saveAll(ctx, records, entries, evts, sheet.ID); err != nil {
+		return err
+	}
+	r.sheets[sheet.ID] = copyTimesheet(sheet)
+	return nil
+}
@@ -0,0 +1,86 @@
+-- Time records, their outbox and the tables written in the same transactions.
+-- Times are DATETIME(6) in UTC; connect with parseTime=true&loc=UTC.
+
+CREATE TABLE IF NOT EXISTS time_records (
+	id VARCHAR(255) PRIMARY KEY,
+	employee_id VARCHAR(255) NOT NULL,
+	check_in_at DATETIME(6) NOT NULL,
+	check_out_at DATETIME(6),
+	status VARCHAR(50) NOT NULL,
+	hours_worked DECIMAL(10, 2) DEFAULT 0,
+	regular_hours DECIMAL(10, 2) DEFAULT 0,
+	overtime_hours DECIMAL(10, 2) DEFAULT 0,
+	location_id VARCHAR(255),
+	time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+	project_code VARCHAR(50),
+	business_date DATE,
+	day_segments JSON,
+	holiday_hours DECIMAL(10, 2) NOT NULL DEFAULT 0,
+	weekend_hours DECIMAL(10, 2) NOT NULL DEFAULT 0,
+	hourly_rate DECIMAL(10, 2),
+	currency CHAR(3),
+	regular_cost BIGINT,
+	overtime_cost BIGINT,
+	source VARCHAR(20),
+	device_id VARCHAR(255),
+	check_out_source VARCHAR(20),
+	check_out_device_id VARCHAR(255),
+	missed_checkout_at DATETIME(6),
+	created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+	updated_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+	INDEX idx_employee_status (employee_id, status),
+	INDEX idx_employee_business_date (employee_id, business_date),
+	INDEX idx_status_location (status, location_id),
+	INDEX idx_employee_check_in (employee_id, check_in_at, id),
+	INDEX idx_status_check_in (status, check_in_at)
+);
+
+-- No partial indexes in MySQL: index every row on (published, created_at)
+CREATE TABLE IF NOT EXISTS outbox_events (
+	id VARCHAR(255) PRIMARY KEY,
+	event_type VARCHAR(100) NOT NULL,
+	aggregate_id VARCHAR(255) NOT NULL,
+	payload JSON NOT NULL,
+	created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+	published BOOLEAN DEFAULT FALSE,
+	published_at DATETIME(6),
+	retry_count INT DEFAULT 0,
+	last_error TEXT,
+	INDEX idx_outbox_unpublished (published, created_at)
+);
+
+CREATE TABLE IF NOT EXISTS audit_entries (
+	id VARCHAR(255) PRIMARY KEY,
+	record_id VARCHAR(255) NOT NULL,
+	employee_id VARCHAR(255) NOT NULL,
+	action VARCHAR(50) NOT NULL,
+	actor VARCHAR(255) NOT NULL,
+	reason TEXT,
+	`before` JSON,
+	`after` JSON,
+	created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+	prev_hash VARCHAR(64),
+	hash VARCHAR(64),
+	INDEX idx_audit_record (record_id, created_at)
+);
+
+CREATE TABLE IF NOT EXISTS time_record_notes (
+	id VARCHAR(255) PRIMARY KEY,
+	record_id VARCHAR(255) NOT NULL,
+	employee_id VARCHAR(255) NOT NULL,
+	kind VARCHAR(20) NOT NULL,
+	author VARCHAR(255) NOT NULL,
+	body TEXT NOT NULL,
+	created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+	INDEX idx_notes_record (employee_id, record_id)
+);
+
+CREATE TABLE IF NOT EXISTS hours_calculations (
+	record_id VARCHAR(255) PRIMARY KEY,
+	employee_id VARCHAR(255) NOT NULL,
+	inputs JSON NOT NULL,
+	outputs JSON NOT NULL,
+	gross_hours DECIMAL(10, 2) NOT NULL,
+	payable_hours DECIMAL(10, 2) NOT NULL,
+	created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+);
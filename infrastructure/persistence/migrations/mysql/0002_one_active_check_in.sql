@@ -0,0 +1,5 @@
+-- At most one open record per employee. MySQL has no partial indexes, so the
+-- unique key is on a generated column that is NULL for closed records.
+ALTER TABLE time_records
+	ADD COLUMN active_employee_id VARCHAR(255) AS (CASE WHEN status = 'CHECKED_IN' THEN employee_id END) STORED,
+	ADD UNIQUE INDEX idx_one_active_check_in (active_employee_id);
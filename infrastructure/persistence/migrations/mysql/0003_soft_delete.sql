@@ -0,0 +1,8 @@
+-- Voided and deleted records stay in the table for the audit log. Deleted
+-- records are hidden from every read and no longer hold the open record slot.
+ALTER TABLE time_records
+	ADD COLUMN voided_at DATETIME(6),
+	ADD COLUMN deleted_at DATETIME(6);
+UPDATE time_records SET voided_at = updated_at WHERE status = 'VOIDED';
+ALTER TABLE time_records
+	MODIFY COLUMN active_employee_id VARCHAR(255) AS (CASE WHEN status = 'CHECKED_IN' AND deleted_at IS NULL THEN employee_id END) STORED;
@@ -0,0 +1,2 @@
+-- The pruning job deletes published events oldest first
+CREATE INDEX idx_outbox_published ON outbox_events (published, published_at);
@@ -0,0 +1,2 @@
+-- Publishing order of pending events; lower goes first (see events.PriorityNormal)
+ALTER TABLE outbox_events ADD COLUMN priority SMALLINT NOT NULL DEFAULT 5;
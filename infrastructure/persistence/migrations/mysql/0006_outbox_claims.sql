@@ -0,0 +1,3 @@
+-- Relay instance holding a pending event while it publishes it; other
+-- instances skip the event until claimed_until (UTC) has passed
+ALTER TABLE outbox_events ADD COLUMN claimed_by VARCHAR(255) NULL, ADD COLUMN claimed_until DATETIME(6) NULL;
@@ -0,0 +1,3 @@
+-- The relay only claims the oldest pending event of each aggregate, so it
+-- looks up earlier pending events by aggregate
+CREATE INDEX idx_outbox_pending_aggregate ON outbox_events (aggregate_id, published, created_at);
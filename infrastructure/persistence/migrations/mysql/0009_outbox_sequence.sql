@@ -0,0 +1,12 @@
+-- created_at is the clock of whichever replica wrote an event, and the events
+-- of one transaction share it, so the relay orders an aggregate's events by
+-- seq, the order they were written in. Pending events are numbered in
+-- created_at order.
+ALTER TABLE outbox_events ADD COLUMN seq BIGINT NULL;
+UPDATE outbox_events o
+JOIN (SELECT id, ROW_NUMBER() OVER (ORDER BY created_at, id) AS n FROM outbox_events) ordered ON ordered.id = o.id
+SET o.seq = ordered.n;
+ALTER TABLE outbox_events MODIFY seq BIGINT NOT NULL AUTO_INCREMENT, ADD UNIQUE KEY uq_outbox_seq (seq);
+
+DROP INDEX idx_outbox_pending_aggregate ON outbox_events;
+CREATE INDEX idx_outbox_pending_aggregate ON outbox_events (aggregate_id, published, seq);
@@ -0,0 +1,18 @@
+-- Drops every table of the baseline, with its data
+DROP TABLE IF EXISTS timesheets;
+DROP TABLE IF EXISTS approvals;
+DROP TABLE IF EXISTS hours_calculations;
+DROP TABLE IF EXISTS time_record_notes;
+DROP TABLE IF EXISTS employee_aliases;
+DROP TABLE IF EXISTS employees;
+DROP TABLE IF EXISTS audit_anchors;
+DROP TABLE IF EXISTS devices;
+DROP TABLE IF EXISTS checkout_email_settings;
+DROP TABLE IF EXISTS idempotency_keys;
+DROP TABLE IF EXISTS holidays;
+DROP TABLE IF EXISTS audit_entries;
+DROP TABLE IF EXISTS employee_consents;
+DROP TABLE IF EXISTS outbox_events;
+DROP TABLE IF EXISTS projects;
+DROP TABLE IF EXISTS locations;
+DROP TABLE IF EXISTS time_records;
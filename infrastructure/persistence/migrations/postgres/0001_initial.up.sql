@@ -0,0 +1,290 @@
+-- Baseline: the schema initDatabase created before migrations existed. Every
+-- statement is idempotent so databases created by it adopt this version as is.
+
+CREATE TABLE IF NOT EXISTS time_records (
+	id VARCHAR(255) PRIMARY KEY,
+	employee_id VARCHAR(255) NOT NULL,
+	check_in_at TIMESTAMPTZ NOT NULL,
+	check_out_at TIMESTAMPTZ,
+	status VARCHAR(50) NOT NULL,
+	hours_worked DECIMAL(10, 2) DEFAULT 0,
+	regular_hours DECIMAL(10, 2) DEFAULT 0,
+	overtime_hours DECIMAL(10, 2) DEFAULT 0,
+	location_id VARCHAR(255),
+	time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_employee_status ON time_records(employee_id, status);
+
+ALTER TABLE time_records ADD COLUMN IF NOT EXISTS regular_hours DECIMAL(10, 2) DEFAULT 0;
+ALTER TABLE time_records ADD COLUMN IF NOT EXISTS overtime_hours DECIMAL(10, 2) DEFAULT 0;
+ALTER TABLE time_records ADD COLUMN IF NOT EXISTS location_id VARCHAR(255);
+ALTER TABLE time_records ADD COLUMN IF NOT EXISTS time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+ALTER TABLE time_records ADD COLUMN IF NOT EXISTS project_code VARCHAR(50);
+ALTER TABLE time_records ADD COLUMN IF NOT EXISTS business_date DATE;
+ALTER TABLE time_records ADD COLUMN IF NOT EXISTS day_segments JSONB;
+ALTER TABLE time_records ADD COLUMN IF NOT EXISTS holiday_hours DECIMAL(10, 2) NOT NULL DEFAULT 0;
+ALTER TABLE time_records ADD COLUMN IF NOT EXISTS weekend_hours DECIMAL(10, 2) NOT NULL DEFAULT 0;
+
+-- Labor cost at the employee's rate when checked out, in minor units of currency
+-- (NULL currency when the employee had no rate)
+ALTER TABLE time_records ADD COLUMN IF NOT EXISTS hourly_rate DECIMAL(10, 2);
+ALTER TABLE time_records ADD COLUMN IF NOT EXISTS currency CHAR(3);
+ALTER TABLE time_records ADD COLUMN IF NOT EXISTS regular_cost BIGINT;
+ALTER TABLE time_records ADD COLUMN IF NOT EXISTS overtime_cost BIGINT;
+
+-- Channel (kiosk, mobile, web, api, import) and terminal of each punch
+ALTER TABLE time_records ADD COLUMN IF NOT EXISTS source VARCHAR(20);
+ALTER TABLE time_records ADD COLUMN IF NOT EXISTS device_id VARCHAR(255);
+ALTER TABLE time_records ADD COLUMN IF NOT EXISTS check_out_source VARCHAR(20);
+ALTER TABLE time_records ADD COLUMN IF NOT EXISTS check_out_device_id VARCHAR(255);
+
+-- When the record was reported as a missed check-out, so it is reported once
+ALTER TABLE time_records ADD COLUMN IF NOT EXISTS missed_checkout_at TIMESTAMP;
+
+-- Business date is the local date of the check-in; backfill older rows
+UPDATE time_records SET business_date = (check_in_at AT TIME ZONE time_zone)::date WHERE business_date IS NULL;
+CREATE INDEX IF NOT EXISTS idx_employee_business_date ON time_records(employee_id, business_date);
+
+-- Older databases stored local server time in TIMESTAMP columns. Convert
+-- them once to TIMESTAMPTZ; existing values are read in the session's
+-- TimeZone, so run the first start with PGTZ set to the old server zone.
+DO $$
+DECLARE
+	col TEXT;
+BEGIN
+	FOREACH col IN ARRAY ARRAY['check_in_at', 'check_out_at', 'created_at', 'updated_at'] LOOP
+		IF EXISTS (
+			SELECT 1 FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = 'time_records'
+				AND column_name = col AND data_type = 'timestamp without time zone'
+		) THEN
+			EXECUTE format('ALTER TABLE time_records ALTER COLUMN %I TYPE TIMESTAMPTZ', col);
+		END IF;
+	END LOOP;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_status_location ON time_records(status, location_id);
+CREATE INDEX IF NOT EXISTS idx_employee_check_in ON time_records(employee_id, check_in_at, id);
+CREATE INDEX IF NOT EXISTS idx_status_check_in ON time_records(status, check_in_at);
+
+-- Company sites employees badge in at
+CREATE TABLE IF NOT EXISTS locations (
+	id VARCHAR(255) PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	address TEXT NOT NULL DEFAULT '',
+	active BOOLEAN NOT NULL DEFAULT TRUE,
+	time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE locations ADD COLUMN IF NOT EXISTS time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+
+-- Project/cost codes hours can be attributed to
+CREATE TABLE IF NOT EXISTS projects (
+	code VARCHAR(50) PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	active BOOLEAN NOT NULL DEFAULT TRUE,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Outbox pattern table for guaranteed event delivery
+CREATE TABLE IF NOT EXISTS outbox_events (
+	id VARCHAR(255) PRIMARY KEY,
+	event_type VARCHAR(100) NOT NULL,
+	aggregate_id VARCHAR(255) NOT NULL,
+	payload JSONB NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	published BOOLEAN DEFAULT FALSE,
+	published_at TIMESTAMP,
+	retry_count INT DEFAULT 0,
+	last_error TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_unpublished ON outbox_events(published, created_at) WHERE published = FALSE;
+
+-- Employee consent per processing purpose (GDPR)
+CREATE TABLE IF NOT EXISTS employee_consents (
+	id VARCHAR(255) PRIMARY KEY,
+	employee_id VARCHAR(255) NOT NULL,
+	purpose VARCHAR(50) NOT NULL,
+	source VARCHAR(50) NOT NULL,
+	granted_at TIMESTAMP NOT NULL,
+	withdrawn_at TIMESTAMP,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (employee_id, purpose)
+);
+
+-- Audit trail of changes made to time records
+CREATE TABLE IF NOT EXISTS audit_entries (
+	id VARCHAR(255) PRIMARY KEY,
+	record_id VARCHAR(255) NOT NULL,
+	employee_id VARCHAR(255) NOT NULL,
+	action VARCHAR(50) NOT NULL,
+	actor VARCHAR(255) NOT NULL,
+	reason TEXT,
+	before JSONB,
+	after JSONB,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_record ON audit_entries(record_id, created_at);
+
+-- Each entry hashes its content with the previous entry of the same record
+ALTER TABLE audit_entries ADD COLUMN IF NOT EXISTS prev_hash VARCHAR(64);
+ALTER TABLE audit_entries ADD COLUMN IF NOT EXISTS hash VARCHAR(64);
+
+-- Company holiday calendar; hours worked on these dates are paid at a premium
+CREATE TABLE IF NOT EXISTS holidays (
+	date DATE PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Deduplication log shared by all API instances; a NULL status_code
+-- means the first request for the key is still running
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+	key TEXT PRIMARY KEY,
+	fingerprint VARCHAR(64) NOT NULL,
+	status_code INT,
+	content_type VARCHAR(255),
+	body BYTEA,
+	expires_at TIMESTAMPTZ NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
+
+-- Customization of the check-out summary email; a single row per deployment
+CREATE TABLE IF NOT EXISTS checkout_email_settings (
+	id SMALLINT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+	logo_url TEXT,
+	footer_text TEXT,
+	show_hours BOOLEAN NOT NULL DEFAULT TRUE,
+	show_cost BOOLEAN NOT NULL DEFAULT FALSE,
+	show_overtime BOOLEAN NOT NULL DEFAULT FALSE,
+	updated_by VARCHAR(255),
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Check-in kiosks; only hashes of enrollment codes and secrets are stored
+CREATE TABLE IF NOT EXISTS devices (
+	id VARCHAR(255) PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	location_id VARCHAR(255),
+	status VARCHAR(20) NOT NULL,
+	enrollment_code_hash VARCHAR(64),
+	enrollment_expires_at TIMESTAMPTZ,
+	secret_hash VARCHAR(64),
+	secret_expires_at TIMESTAMPTZ,
+	previous_secret_hash VARCHAR(64),
+	previous_expires_at TIMESTAMPTZ,
+	revoked_at TIMESTAMPTZ,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Digests of all audit chains, also exported to object storage
+CREATE TABLE IF NOT EXISTS audit_anchors (
+	id VARCHAR(255) PRIMARY KEY,
+	digest VARCHAR(64) NOT NULL,
+	records INTEGER NOT NULL,
+	entries INTEGER NOT NULL,
+	up_to TIMESTAMPTZ NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Employee roster; only active employees can check in
+CREATE TABLE IF NOT EXISTS employees (
+	id VARCHAR(255) PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	email VARCHAR(255) NOT NULL DEFAULT '',
+	manager_id VARCHAR(255),
+	hourly_rate DECIMAL(10, 2) NOT NULL DEFAULT 0,
+	active BOOLEAN NOT NULL DEFAULT TRUE,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_employees_manager ON employees(manager_id);
+
+-- Currency of the hourly rate (ISO 4217); NULL means LABOR_COST_CURRENCY
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS currency CHAR(3);
+
+-- Employee IDs merged into another (canonical) ID, for historical queries
+CREATE TABLE IF NOT EXISTS employee_aliases (
+	employee_id VARCHAR(255) PRIMARY KEY,
+	canonical_id VARCHAR(255) NOT NULL,
+	actor VARCHAR(255) NOT NULL,
+	reason TEXT,
+	merged_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_aliases_canonical ON employee_aliases(canonical_id);
+
+-- Free-text notes on time records (append-only)
+CREATE TABLE IF NOT EXISTS time_record_notes (
+	id VARCHAR(255) PRIMARY KEY,
+	record_id VARCHAR(255) NOT NULL,
+	employee_id VARCHAR(255) NOT NULL,
+	kind VARCHAR(20) NOT NULL,
+	author VARCHAR(255) NOT NULL,
+	body TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notes_record ON time_record_notes(employee_id, record_id);
+
+-- Inputs and outputs of the hours calculation made at check-out
+CREATE TABLE IF NOT EXISTS hours_calculations (
+	record_id VARCHAR(255) PRIMARY KEY,
+	employee_id VARCHAR(255) NOT NULL,
+	inputs JSONB NOT NULL,
+	outputs JSONB NOT NULL,
+	gross_hours DECIMAL(10, 2) NOT NULL,
+	payable_hours DECIMAL(10, 2) NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Corrections and manual entries waiting for (or decided by) a manager
+CREATE TABLE IF NOT EXISTS approvals (
+	id VARCHAR(255) PRIMARY KEY,
+	employee_id VARCHAR(255) NOT NULL,
+	manager_id VARCHAR(255),
+	kind VARCHAR(20) NOT NULL,
+	record_id VARCHAR(255),
+	proposed_check_in_at TIMESTAMPTZ NOT NULL,
+	proposed_check_out_at TIMESTAMPTZ NOT NULL,
+	time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+	note TEXT NOT NULL DEFAULT '',
+	status VARCHAR(20) NOT NULL,
+	decided_by VARCHAR(255),
+	decision_comment TEXT,
+	decided_at TIMESTAMPTZ,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_approvals_pending ON approvals(status, manager_id);
+
+-- Records of an employee per pay period; closing one locks its records
+CREATE TABLE IF NOT EXISTS timesheets (
+	id VARCHAR(255) PRIMARY KEY,
+	employee_id VARCHAR(255) NOT NULL,
+	period_start DATE NOT NULL,
+	period_end DATE NOT NULL,
+	status VARCHAR(20) NOT NULL,
+	record_ids TEXT[] NOT NULL DEFAULT '{}',
+	hours_worked DECIMAL(10, 2) NOT NULL DEFAULT 0,
+	regular_hours DECIMAL(10, 2) NOT NULL DEFAULT 0,
+	overtime_hours DECIMAL(10, 2) NOT NULL DEFAULT 0,
+	submitted_at TIMESTAMPTZ,
+	decided_by VARCHAR(255),
+	decision_comment TEXT,
+	decided_at TIMESTAMPTZ,
+	closed_at TIMESTAMPTZ,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (employee_id, period_start)
+);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_audit_created;
+DROP INDEX IF EXISTS idx_records_check_in_desc;
+DROP INDEX IF EXISTS idx_audit_reason_trgm;
+DROP INDEX IF EXISTS idx_audit_employee_trgm;
+DROP INDEX IF EXISTS idx_notes_body_trgm;
+DROP INDEX IF EXISTS idx_records_checkout_device_trgm;
+DROP INDEX IF EXISTS idx_records_device_trgm;
+DROP INDEX IF EXISTS idx_records_employee_trgm;
@@ -0,0 +1,10 @@
+-- Trigram indexes for partial matches in GET /api/search
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS idx_records_employee_trgm ON time_records USING GIN (employee_id gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_records_device_trgm ON time_records USING GIN (device_id gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_records_checkout_device_trgm ON time_records USING GIN (check_out_device_id gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_notes_body_trgm ON time_record_notes USING GIN (body gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_audit_employee_trgm ON audit_entries USING GIN (employee_id gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_audit_reason_trgm ON audit_entries USING GIN (reason gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_records_check_in_desc ON time_records(check_in_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_created ON audit_entries(created_at DESC, id DESC);
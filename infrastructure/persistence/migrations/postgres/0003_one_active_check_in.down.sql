@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_one_active_check_in;
@@ -0,0 +1,5 @@
+-- At most one open record per employee, so concurrent check-ins cannot both
+-- succeed. Fails while an employee has several open records; close the extra
+-- ones first (SELECT employee_id FROM time_records WHERE status = 'CHECKED_IN'
+-- GROUP BY employee_id HAVING COUNT(*) > 1).
+CREATE UNIQUE INDEX IF NOT EXISTS idx_one_active_check_in ON time_records(employee_id) WHERE status = 'CHECKED_IN';
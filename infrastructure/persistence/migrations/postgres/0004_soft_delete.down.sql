@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_one_active_check_in;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_one_active_check_in ON time_records(employee_id) WHERE status = 'CHECKED_IN';
+ALTER TABLE time_records DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE time_records DROP COLUMN IF EXISTS voided_at;
@@ -0,0 +1,8 @@
+-- Voided and deleted records stay in the table for the audit log. Deleted
+-- records are hidden from every read and no longer hold the one open record
+-- per employee slot.
+ALTER TABLE time_records ADD COLUMN IF NOT EXISTS voided_at TIMESTAMPTZ;
+ALTER TABLE time_records ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+UPDATE time_records SET voided_at = updated_at WHERE status = 'VOIDED' AND voided_at IS NULL;
+DROP INDEX IF EXISTS idx_one_active_check_in;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_one_active_check_in ON time_records(employee_id) WHERE status = 'CHECKED_IN' AND deleted_at IS NULL;
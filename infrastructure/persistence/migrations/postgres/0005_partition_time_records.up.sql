@@ -0,0 +1,91 @@
+-- Range-partition time_records by month of check_in_at (UTC). Unique keys of a
+-- partitioned table must include the partition key, so:
+--   - the primary key becomes (id, check_in_at); IDs are UUIDs and stay unique
+--   - the one open record per employee rule moves from a partial unique index
+--     to open_check_ins, kept in sync by a trigger
+-- The table is rebuilt and its rows copied; on large databases run it in a
+-- maintenance window with DATABASE_AUTO_MIGRATE=false and cmd/migrate.
+
+-- Creates the monthly partitions from from_month through months_ahead months
+-- after the current one and returns how many it created. Rows that landed in
+-- the default partition meanwhile are moved into their new partition.
+CREATE OR REPLACE FUNCTION ensure_time_record_partitions(from_month DATE, months_ahead INT) RETURNS INT AS $$
+DECLARE
+	month_start DATE := date_trunc('month', from_month)::date;
+	last_month DATE := (date_trunc('month', now() AT TIME ZONE 'UTC') + make_interval(months => months_ahead))::date;
+	lower_bound TIMESTAMPTZ;
+	upper_bound TIMESTAMPTZ;
+	partition_name TEXT;
+	created INT := 0;
+BEGIN
+	WHILE month_start <= last_month LOOP
+		partition_name := format('time_records_%s', to_char(month_start, 'YYYY_MM'));
+		lower_bound := month_start::timestamp AT TIME ZONE 'UTC';
+		upper_bound := (month_start + INTERVAL '1 month')::timestamp AT TIME ZONE 'UTC';
+
+		IF to_regclass(partition_name) IS NULL THEN
+			IF EXISTS (SELECT 1 FROM time_records_default WHERE check_in_at >= lower_bound AND check_in_at < upper_bound) THEN
+				EXECUTE format('CREATE TABLE %I (LIKE time_records INCLUDING DEFAULTS)', partition_name);
+				EXECUTE format(
+					'WITH moved AS (DELETE FROM time_records_default WHERE check_in_at >= $1 AND check_in_at < $2 RETURNING *)
+					INSERT INTO %I SELECT * FROM moved', partition_name) USING lower_bound, upper_bound;
+				EXECUTE format('ALTER TABLE time_records ATTACH PARTITION %I FOR VALUES FROM (%L) TO (%L)',
+					partition_name, lower_bound, upper_bound);
+				-- The delete from the default partition released their open slots
+				EXECUTE format(
+					'INSERT INTO open_check_ins (employee_id, record_id, check_in_at)
+					SELECT employee_id, id, check_in_at FROM %I WHERE status = ''CHECKED_IN'' AND deleted_at IS NULL', partition_name);
+			ELSE
+				EXECUTE format('CREATE TABLE %I PARTITION OF time_records FOR VALUES FROM (%L) TO (%L)',
+					partition_name, lower_bound, upper_bound);
+			END IF;
+			created := created + 1;
+		END IF;
+
+		month_start := (month_start + INTERVAL '1 month')::date;
+	END LOOP;
+	RETURN created;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TABLE IF NOT EXISTS open_check_ins (
+	employee_id VARCHAR(255) PRIMARY KEY,
+	record_id VARCHAR(255) NOT NULL UNIQUE,
+	check_in_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE OR REPLACE FUNCTION track_open_check_in() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP <> 'INSERT' THEN
+		DELETE FROM open_check_ins WHERE record_id = OLD.id;
+	END IF;
+	IF TG_OP <> 'DELETE' AND NEW.status = 'CHECKED_IN' AND NEW.deleted_at IS NULL THEN
+		INSERT INTO open_check_ins (employee_id, record_id, check_in_at) VALUES (NEW.employee_id, NEW.id, NEW.check_in_at);
+	END IF;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE time_records RENAME TO time_records_unpartitioned;
+
+CREATE TABLE time_records (LIKE time_records_unpartitioned INCLUDING DEFAULTS) PARTITION BY RANGE (check_in_at);
+CREATE TABLE time_records_default PARTITION OF time_records DEFAULT;
+SELECT ensure_time_record_partitions(
+	COALESCE((SELECT MIN(check_in_at) AT TIME ZONE 'UTC' FROM time_records_unpartitioned)::date, CURRENT_DATE), 3);
+
+CREATE TRIGGER trg_track_open_check_in AFTER INSERT OR UPDATE OR DELETE ON time_records
+	FOR EACH ROW EXECUTE FUNCTION track_open_check_in();
+
+INSERT INTO time_records SELECT * FROM time_records_unpartitioned;
+DROP TABLE time_records_unpartitioned;
+
+ALTER TABLE time_records ADD PRIMARY KEY (id, check_in_at);
+CREATE INDEX IF NOT EXISTS idx_employee_status ON time_records(employee_id, status);
+CREATE INDEX IF NOT EXISTS idx_employee_business_date ON time_records(employee_id, business_date);
+CREATE INDEX IF NOT EXISTS idx_status_location ON time_records(status, location_id);
+CREATE INDEX IF NOT EXISTS idx_employee_check_in ON time_records(employee_id, check_in_at, id);
+CREATE INDEX IF NOT EXISTS idx_status_check_in ON time_records(status, check_in_at);
+CREATE INDEX IF NOT EXISTS idx_records_employee_trgm ON time_records USING GIN (employee_id gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_records_device_trgm ON time_records USING GIN (device_id gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_records_checkout_device_trgm ON time_records USING GIN (check_out_device_id gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_records_check_in_desc ON time_records(check_in_at DESC, id DESC);
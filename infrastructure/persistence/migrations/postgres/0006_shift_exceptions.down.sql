@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS shift_exceptions;
+DROP TABLE IF EXISTS work_schedules;
@@ -0,0 +1,32 @@
+-- Weekly shift schedule per employee; start and end are local HH:MM times in
+-- time_zone, an end before the start ends the next day
+CREATE TABLE IF NOT EXISTS work_schedules (
+	employee_id VARCHAR(255) NOT NULL,
+	weekday SMALLINT NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+	start_time VARCHAR(5) NOT NULL,
+	end_time VARCHAR(5) NOT NULL,
+	location_id VARCHAR(255),
+	time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+	created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (employee_id, weekday, start_time)
+);
+
+CREATE INDEX IF NOT EXISTS idx_work_schedules_weekday ON work_schedules(weekday);
+
+-- Scheduled shifts the employee never checked in for, stored next to their
+-- time records; one row per shift
+CREATE TABLE IF NOT EXISTS shift_exceptions (
+	id VARCHAR(255) PRIMARY KEY,
+	employee_id VARCHAR(255) NOT NULL,
+	manager_id VARCHAR(255),
+	kind VARCHAR(20) NOT NULL,
+	business_date DATE NOT NULL,
+	scheduled_start TIMESTAMPTZ NOT NULL,
+	scheduled_end TIMESTAMPTZ NOT NULL,
+	location_id VARCHAR(255),
+	time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+	created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (employee_id, scheduled_start, kind)
+);
+
+CREATE INDEX IF NOT EXISTS idx_shift_exceptions_date ON shift_exceptions(business_date);
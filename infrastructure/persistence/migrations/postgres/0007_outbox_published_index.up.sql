@@ -0,0 +1,2 @@
+-- The pruning job deletes published events oldest first
+CREATE INDEX IF NOT EXISTS idx_outbox_published ON outbox_events(published_at) WHERE published = TRUE;
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS trg_log_time_record_change ON time_records;
+DROP FUNCTION IF EXISTS log_time_record_change();
+DROP TABLE IF EXISTS time_record_changes;
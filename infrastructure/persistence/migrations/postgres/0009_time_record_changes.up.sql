@@ -0,0 +1,69 @@
+-- Change log of time records, written by a trigger in the transaction of each
+-- write. The writer names itself with the transaction-local settings
+-- app.change_actor and app.change_source; writes that do not are logged as
+-- made by the system. Rows moved between shards or partitions are not changes
+-- and are skipped while app.change_source is 'maintenance'.
+CREATE TABLE IF NOT EXISTS time_record_changes (
+	id VARCHAR(255) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+	record_id VARCHAR(255) NOT NULL,
+	employee_id VARCHAR(255) NOT NULL,
+	operation VARCHAR(10) NOT NULL,
+	old_values JSONB,
+	new_values JSONB,
+	actor VARCHAR(255) NOT NULL,
+	source VARCHAR(100) NOT NULL,
+	changed_at TIMESTAMPTZ NOT NULL DEFAULT clock_timestamp()
+);
+
+CREATE INDEX IF NOT EXISTS idx_record_changes_record ON time_record_changes(record_id, changed_at);
+CREATE INDEX IF NOT EXISTS idx_record_changes_employee ON time_record_changes(employee_id);
+
+CREATE OR REPLACE FUNCTION log_time_record_change() RETURNS trigger AS $$
+DECLARE
+	old_row JSONB;
+	new_row JSONB;
+	old_values JSONB;
+	new_values JSONB;
+	col TEXT;
+BEGIN
+	IF current_setting('app.change_source', true) = 'maintenance' THEN
+		RETURN NULL;
+	END IF;
+
+	IF TG_OP = 'INSERT' THEN
+		new_values := to_jsonb(NEW);
+	ELSIF TG_OP = 'DELETE' THEN
+		old_values := to_jsonb(OLD);
+	ELSE
+		old_row := to_jsonb(OLD);
+		new_row := to_jsonb(NEW);
+		old_values := '{}';
+		new_values := '{}';
+		FOR col IN SELECT jsonb_object_keys(new_row) LOOP
+			IF col <> 'updated_at' AND old_row -> col IS DISTINCT FROM new_row -> col THEN
+				old_values := old_values || jsonb_build_object(col, old_row -> col);
+				new_values := new_values || jsonb_build_object(col, new_row -> col);
+			END IF;
+		END LOOP;
+		-- Upserts rewriting a record unchanged are not changes
+		IF new_values = '{}' THEN
+			RETURN NULL;
+		END IF;
+	END IF;
+
+	INSERT INTO time_record_changes (record_id, employee_id, operation, old_values, new_values, actor, source)
+	VALUES (
+		CASE WHEN TG_OP = 'DELETE' THEN OLD.id ELSE NEW.id END,
+		CASE WHEN TG_OP = 'DELETE' THEN OLD.employee_id ELSE NEW.employee_id END,
+		TG_OP,
+		old_values,
+		new_values,
+		COALESCE(NULLIF(current_setting('app.change_actor', true), ''), 'system'),
+		COALESCE(NULLIF(current_setting('app.change_source', true), ''), 'system'));
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_log_time_record_change ON time_records;
+CREATE TRIGGER trg_log_time_record_change AFTER INSERT OR UPDATE OR DELETE ON time_records
+	FOR EACH ROW EXECUTE FUNCTION log_time_record_change();
@@ -0,0 +1,4 @@
+-- Relay instance holding a pending event while it publishes it; other
+-- instances skip the event until claimed_until has passed
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS claimed_by VARCHAR(255);
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS claimed_until TIMESTAMPTZ;
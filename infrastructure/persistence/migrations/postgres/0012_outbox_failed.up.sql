@@ -0,0 +1,3 @@
+-- Events still failing after OUTBOX_MAX_RETRIES attempts are marked failed and
+-- no longer polled until an operator requeues them
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS failed_at TIMESTAMPTZ;
@@ -0,0 +1,10 @@
+-- Events each RabbitMQ consumer has already handled, so a redelivered event
+-- does not repeat its side effects; rows are purged after INBOX_RETENTION_HOURS
+CREATE TABLE IF NOT EXISTS processed_events (
+	consumer VARCHAR(64) NOT NULL,
+	event_id VARCHAR(255) NOT NULL,
+	processed_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (consumer, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_processed_events_processed_at ON processed_events(processed_at);
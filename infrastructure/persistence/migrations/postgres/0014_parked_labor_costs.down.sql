@@ -0,0 +1 @@
+DROP TABLE IF EXISTS parked_labor_costs;
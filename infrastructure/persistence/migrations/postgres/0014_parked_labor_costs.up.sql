@@ -0,0 +1,16 @@
+-- Labor cost entries set aside while the legacy API's circuit breaker is open,
+-- submitted again by the labor cost drainer once it closes
+CREATE TABLE IF NOT EXISTS parked_labor_costs (
+	id BIGSERIAL PRIMARY KEY,
+	idempotency_key VARCHAR(255),
+	entry JSONB NOT NULL,
+	reason TEXT NOT NULL DEFAULT '',
+	attempts INT NOT NULL DEFAULT 0,
+	last_error TEXT NOT NULL DEFAULT '',
+	parked_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	-- Entries the legacy API refused are kept for an operator, not resubmitted
+	failed_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_parked_labor_costs_key ON parked_labor_costs(idempotency_key) WHERE idempotency_key IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_parked_labor_costs_pending ON parked_labor_costs(parked_at) WHERE failed_at IS NULL;
@@ -0,0 +1,4 @@
+DELETE FROM processed_events WHERE status <> 'done';
+ALTER TABLE processed_events DROP COLUMN IF EXISTS claimed_until;
+ALTER TABLE processed_events DROP COLUMN IF EXISTS claimed_by;
+ALTER TABLE processed_events DROP COLUMN IF EXISTS status;
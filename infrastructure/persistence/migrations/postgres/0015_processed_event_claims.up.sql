@@ -0,0 +1,6 @@
+-- Consumers claim an event with a pending row and mark it done once handled,
+-- instead of holding a transaction open while the handler runs. A claim left
+-- pending past claimed_until (a crashed worker) may be taken over.
+ALTER TABLE processed_events ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'done';
+ALTER TABLE processed_events ADD COLUMN IF NOT EXISTS claimed_by VARCHAR(64);
+ALTER TABLE processed_events ADD COLUMN IF NOT EXISTS claimed_until TIMESTAMPTZ;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_outbox_pending_aggregate;
+CREATE INDEX IF NOT EXISTS idx_outbox_pending_aggregate ON outbox_events(aggregate_id, created_at) WHERE published = FALSE;
+ALTER TABLE outbox_events DROP COLUMN IF EXISTS seq;
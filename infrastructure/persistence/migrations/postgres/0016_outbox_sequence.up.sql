@@ -0,0 +1,15 @@
+-- created_at is the clock of whichever replica wrote an event, and the events
+-- of one transaction share it, so the relay orders an aggregate's events by
+-- seq, the order they were written in. Pending events are numbered in
+-- created_at order.
+CREATE SEQUENCE IF NOT EXISTS outbox_events_seq_seq;
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS seq BIGINT;
+UPDATE outbox_events o SET seq = ordered.n
+FROM (SELECT id, row_number() OVER (ORDER BY created_at, id) AS n FROM outbox_events) ordered
+WHERE o.id = ordered.id;
+SELECT setval('outbox_events_seq_seq', COALESCE(MAX(seq), 0) + 1, false) FROM outbox_events;
+ALTER TABLE outbox_events ALTER COLUMN seq SET DEFAULT nextval('outbox_events_seq_seq'), ALTER COLUMN seq SET NOT NULL;
+ALTER SEQUENCE outbox_events_seq_seq OWNED BY outbox_events.seq;
+
+DROP INDEX IF EXISTS idx_outbox_pending_aggregate;
+CREATE INDEX IF NOT EXISTS idx_outbox_pending_aggregate ON outbox_events(aggregate_id, seq) WHERE published = FALSE;
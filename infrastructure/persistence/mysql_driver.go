@@ -0,0 +1,6 @@
+//go:build mysql
+
+package persistence
+
+// The MySQL driver is opt-in so Postgres-only builds do not carry it
+import _ "github.com/go-sql-driver/mysql"
@@ -0,0 +1,52 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+)
+
+// PartitionMaintainer creates the monthly time_records partitions ahead of
+// time on every shard. Check-ins for a month without its partition land in
+// the default partition and are moved out once the partition is created.
+type PartitionMaintainer struct {
+	shards *ShardSet
+}
+
+func NewPartitionMaintainer(shards *ShardSet) *PartitionMaintainer {
+	return &PartitionMaintainer{shards: shards}
+}
+
+// EnsureMonthsAhead creates the partitions from the current month through
+// monthsAhead months later that do not exist yet and returns how many it
+// created across the shards
+func (m *PartitionMaintainer) EnsureMonthsAhead(ctx context.Context, monthsAhead int) (int, error) {
+	created := 0
+	for i, db := range m.shards.All() {
+		n, err := ensurePartitions(ctx, db, monthsAhead)
+		if err != nil {
+			return created, fmt.Errorf("failed to create partitions on shard %d: %w", i, err)
+		}
+		created += n
+	}
+	return created, nil
+}
+
+// ensurePartitions runs as maintenance, as records moved out of the default
+// partition are not changes to them
+func ensurePartitions(ctx context.Context, db *sql.DB, monthsAhead int) (int, error) {
+	ctx = repositories.WithChangeOrigin(ctx, repositories.ChangeOrigin{Source: changeSourceMaintenance})
+	tx, err := beginChangeTx(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() // Rollback if not committed
+
+	var n int
+	if err := tx.QueryRowContext(ctx, `SELECT ensure_time_record_partitions(CURRENT_DATE, $1)`, monthsAhead).Scan(&n); err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
@@ -0,0 +1,74 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"go.uber.org/zap"
+)
+
+// PoolSettings sizes the connection pool of each database
+type PoolSettings struct {
+	MaxOpen     int
+	MaxIdle     int
+	MaxLifetime time.Duration
+	// Each connection attempt at startup gives up after ConnectTimeout and
+	// is retried up to ConnectAttempts times with exponential backoff
+	ConnectTimeout  time.Duration
+	ConnectAttempts int
+}
+
+// maxConnectBackoff caps the wait between connection attempts
+const maxConnectBackoff = 30 * time.Second
+
+// Configure applies the pool limits to db
+func (p PoolSettings) Configure(db *sql.DB) {
+	db.SetMaxOpenConns(p.MaxOpen)
+	db.SetMaxIdleConns(min(p.MaxIdle, p.MaxOpen))
+	db.SetConnMaxLifetime(p.MaxLifetime)
+}
+
+// Connect configures db and pings it until it answers, so the service waits
+// out a database that is still starting instead of failing its first queries
+func (p PoolSettings) Connect(ctx context.Context, name string, db *sql.DB) error {
+	p.Configure(db)
+
+	backoff := time.Second
+	for attempt := 1; ; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, p.ConnectTimeout)
+		err := db.PingContext(pingCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if attempt >= p.ConnectAttempts {
+			return fmt.Errorf("failed to connect to %s after %d attempts: %w", name, attempt, err)
+		}
+
+		config.Logger.Warn("Database not reachable, retrying",
+			zap.String("database", name),
+			zap.Int("attempt", attempt),
+			zap.Duration("backoff", backoff),
+			zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxConnectBackoff)
+	}
+}
+
+// Connect connects every shard; see PoolSettings.Connect
+func (s *ShardSet) Connect(ctx context.Context, pool PoolSettings) error {
+	for i, db := range s.dbs {
+		if err := pool.Connect(ctx, fmt.Sprintf("shard %d", i), db); err != nil {
+			return err
+		}
+	}
+	return nil
+}
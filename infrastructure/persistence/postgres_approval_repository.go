@@ -0,0 +1,259 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/events"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+)
+
+type PostgresApprovalRepository struct {
+	shards *ShardSet
+}
+
+// NewShardedApprovalRepository stores approvals on the shard owning the employee's records
+func NewShardedApprovalRepository(shards *ShardSet) *PostgresApprovalRepository {
+	return &PostgresApprovalRepository{shards: shards}
+}
+
+const approvalColumns = `id, employee_id, COALESCE(manager_id, ''), kind, COALESCE(record_id, ''), proposed_check_in_at,
+	proposed_check_out_at, time_zone, note, status, COALESCE(decided_by, ''), COALESCE(decision_comment, ''), decided_at, created_at`
+
+const upsertApprovalQuery = `
+	INSERT INTO approvals (id, employee_id, manager_id, kind, record_id, proposed_check_in_at, proposed_check_out_at,
+		time_zone, note, status, decided_by, decision_comment, decided_at, created_at)
+	VALUES ($1, $2, NULLIF($3, ''), $4, NULLIF($5, ''), $6, $7, $8, $9, $10, NULLIF($11, ''), NULLIF($12, ''), $13, $14)
+	ON CONFLICT (id) DO UPDATE SET
+		status = EXCLUDED.status,
+		decided_by = EXCLUDED.decided_by,
+		decision_comment = EXCLUDED.decision_comment,
+		decided_at = EXCLUDED.decided_at
+`
+
+// decideApprovalQuery only updates a pending approval. The row lock it takes
+// makes a concurrent decision wait, then match nothing once this one commits.
+const decideApprovalQuery = `
+	UPDATE approvals
+	SET status = $2, decided_by = NULLIF($3, ''), decision_comment = NULLIF($4, ''), decided_at = $5
+	WHERE id = $1 AND status = $6
+`
+
+func scanApproval(row rowScanner) (*entities.Approval, error) {
+	var approval entities.Approval
+	err := row.Scan(
+		&approval.ID,
+		&approval.EmployeeID,
+		&approval.ManagerID,
+		&approval.Kind,
+		&approval.RecordID,
+		&approval.ProposedCheckInAt,
+		&approval.ProposedCheckOutAt,
+		&approval.TimeZone,
+		&approval.Note,
+		&approval.Status,
+		&approval.DecidedBy,
+		&approval.DecisionComment,
+		&approval.DecidedAt,
+		&approval.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &approval, nil
+}
+
+func upsertApproval(ctx context.Context, tx *sql.Tx, approval *entities.Approval) error {
+	_, err := tx.ExecContext(ctx, upsertApprovalQuery,
+		approval.ID,
+		approval.EmployeeID,
+		approval.ManagerID,
+		approval.Kind,
+		approval.RecordID,
+		approval.ProposedCheckInAt,
+		approval.ProposedCheckOutAt,
+		approval.TimeZone,
+		approval.Note,
+		approval.Status,
+		approval.DecidedBy,
+		approval.DecisionComment,
+		approval.DecidedAt,
+		approval.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save approval: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresApprovalRepository) SaveWithEvent(ctx context.Context, approval *entities.Approval, event events.DomainEvent) error {
+	tx, err := r.shards.For(approval.EmployeeID).BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := upsertApproval(ctx, tx, approval); err != nil {
+		return err
+	}
+
+	if err := insertOutboxEvent(ctx, tx, approval.ID, event); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresApprovalRepository) FindByID(ctx context.Context, id string) (*entities.Approval, error) {
+	query := `
+		SELECT ` + approvalColumns + `
+		FROM approvals
+		WHERE id = $1
+	`
+
+	// The owning shard is unknown from the ID alone, so ask all of them
+	var (
+		mu    sync.Mutex
+		found *entities.Approval
+	)
+	err := r.shards.fanOut(ctx, func(ctx context.Context, _ int, db *sql.DB) error {
+		approval, err := scanApproval(db.QueryRowContext(ctx, query, id))
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		found = approval
+		mu.Unlock()
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to find approval: %w", err)
+	}
+
+	return found, nil
+}
+
+func (r *PostgresApprovalRepository) FindPending(ctx context.Context, managerID string) ([]*entities.Approval, error) {
+	query := `
+		SELECT ` + approvalColumns + `
+		FROM approvals
+		WHERE status = $1 AND ($2 = '' OR manager_id = $2)
+		ORDER BY created_at ASC
+	`
+
+	var (
+		mu        sync.Mutex
+		approvals []*entities.Approval
+	)
+	err := r.shards.fanOut(ctx, func(ctx context.Context, _ int, db *sql.DB) error {
+		rows, err := db.QueryContext(ctx, query, entities.ApprovalPending, managerID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		var shardApprovals []*entities.Approval
+		for rows.Next() {
+			approval, err := scanApproval(rows)
+			if err != nil {
+				return err
+			}
+			shardApprovals = append(shardApprovals, approval)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		mu.Lock()
+		approvals = append(approvals, shardApprovals...)
+		mu.Unlock()
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to query approvals: %w", err)
+	}
+
+	sort.SliceStable(approvals, func(i, j int) bool {
+		return approvals[i].CreatedAt.Before(approvals[j].CreatedAt)
+	})
+
+	return approvals, nil
+}
+
+func (r *PostgresApprovalRepository) SaveDecision(ctx context.Context, approval *entities.Approval, record *entities.TimeRecord, entries []*entities.AuditEntry, evts []events.DomainEvent) error {
+	tx, err := beginChangeTx(ctx, r.shards.For(approval.EmployeeID))
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Decide first, so a second approver gives up before changing any record
+	result, err := tx.ExecContext(ctx, decideApprovalQuery,
+		approval.ID,
+		approval.Status,
+		approval.DecidedBy,
+		approval.DecisionComment,
+		approval.DecidedAt,
+		entities.ApprovalPending,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save approval decision: %w", err)
+	}
+	decided, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to save approval decision: %w", err)
+	}
+	if decided == 0 {
+		return repositories.ErrAlreadyDecided
+	}
+
+	if record != nil {
+		if err := upsertTimeRecord(ctx, tx, record); err != nil {
+			return fmt.Errorf("failed to save time record: %w", err)
+		}
+		if record.Calculation != nil {
+			if err := insertHoursCalculation(ctx, tx, record); err != nil {
+				return err
+			}
+		}
+		for _, note := range record.Notes {
+			if err := insertNote(ctx, tx, note); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := insertAuditEntries(ctx, tx, entries); err != nil {
+		return err
+	}
+
+	// Events belong to the changed record when there is one
+	aggregateID := approval.ID
+	if record != nil {
+		aggregateID = record.ID
+	}
+	for _, event := range evts {
+		if err := insertOutboxEvent(ctx, tx, aggregateID, event); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/lib/pq"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+// PostgresAuditRepository reads audit entries from every shard; anchors are
+// kept on the primary
+type PostgresAuditRepository struct {
+	shards *ShardSet
+}
+
+func NewShardedAuditRepository(shards *ShardSet) *PostgresAuditRepository {
+	return &PostgresAuditRepository{shards: shards}
+}
+
+const auditEntryColumns = `id, record_id, employee_id, action, actor, COALESCE(reason, ''), before, after, created_at,
+			COALESCE(prev_hash, ''), COALESCE(hash, '')`
+
+// FindAll loads the whole audit log. Chains never span shards, since all of a
+// record's entries move with its employee.
+func (r *PostgresAuditRepository) FindAll(ctx context.Context) ([]*entities.AuditEntry, error) {
+	query := `
+		SELECT ` + auditEntryColumns + `
+		FROM audit_entries
+	`
+
+	var (
+		mu      sync.Mutex
+		entries []*entities.AuditEntry
+	)
+	err := r.shards.fanOut(ctx, func(ctx context.Context, _ int, db *sql.DB) error {
+		rows, err := db.QueryContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		shardEntries, err := scanAuditEntries(rows)
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		entries = append(entries, shardEntries...)
+		mu.Unlock()
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit entries: %w", err)
+	}
+	return entries, nil
+}
+
+// FindByRecords reads the employee's shard only, where all of their records'
+// entries are kept
+func (r *PostgresAuditRepository) FindByRecords(ctx context.Context, employeeID string, recordIDs []string) ([]*entities.AuditEntry, error) {
+	if len(recordIDs) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		SELECT ` + auditEntryColumns + `
+		FROM audit_entries
+		WHERE record_id = ANY($1)
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.shards.For(employeeID).QueryContext(ctx, query, pq.Array(recordIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries, err := scanAuditEntries(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit entries: %w", err)
+	}
+	return entries, nil
+}
+
+func scanAuditEntries(rows *sql.Rows) ([]*entities.AuditEntry, error) {
+	var entries []*entities.AuditEntry
+	for rows.Next() {
+		var (
+			entry         entities.AuditEntry
+			before, after []byte
+		)
+		err := rows.Scan(&entry.ID, &entry.RecordID, &entry.EmployeeID, &entry.Action, &entry.Actor, &entry.Reason,
+			&before, &after, &entry.CreatedAt, &entry.PrevHash, &entry.Hash)
+		if err != nil {
+			return nil, err
+		}
+		if err := unmarshalSnapshot(before, &entry.Before); err != nil {
+			return nil, err
+		}
+		if err := unmarshalSnapshot(after, &entry.After); err != nil {
+			return nil, err
+		}
+		entry.CreatedAt = entry.CreatedAt.UTC()
+		entries = append(entries, &entry)
+	}
+	return entries, rows.Err()
+}
+
+func unmarshalSnapshot(data []byte, snapshot **entities.RecordSnapshot) error {
+	if data == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return fmt.Errorf("failed to decode audit snapshot: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresAuditRepository) SaveAnchor(ctx context.Context, anchor *entities.AuditAnchor) error {
+	query := `
+		INSERT INTO audit_anchors (id, digest, records, entries, up_to, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	_, err := r.shards.Primary().ExecContext(ctx, query,
+		anchor.ID,
+		anchor.Digest,
+		anchor.Records,
+		anchor.Entries,
+		anchor.UpTo,
+		anchor.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save audit anchor: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresAuditRepository) FindAnchors(ctx context.Context) ([]*entities.AuditAnchor, error) {
+	query := `
+		SELECT id, digest, records, entries, up_to, created_at
+		FROM audit_anchors
+		ORDER BY up_to ASC
+	`
+
+	rows, err := r.shards.Primary().QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit anchors: %w", err)
+	}
+	defer rows.Close()
+
+	var anchors []*entities.AuditAnchor
+	for rows.Next() {
+		var anchor entities.AuditAnchor
+		if err := rows.Scan(&anchor.ID, &anchor.Digest, &anchor.Records, &anchor.Entries, &anchor.UpTo, &anchor.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit anchor: %w", err)
+		}
+		anchor.UpTo = anchor.UpTo.UTC()
+		anchors = append(anchors, &anchor)
+	}
+
+	return anchors, rows.Err()
+}
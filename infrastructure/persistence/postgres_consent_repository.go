@@ -0,0 +1,108 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+type PostgresConsentRepository struct {
+	db dbtx
+}
+
+func NewPostgresConsentRepository(db dbtx) *PostgresConsentRepository {
+	return &PostgresConsentRepository{db: db}
+}
+
+func (r *PostgresConsentRepository) Save(ctx context.Context, consent *entities.Consent) error {
+	query := `
+		INSERT INTO employee_consents (id, employee_id, purpose, source, granted_at, withdrawn_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (employee_id, purpose) DO UPDATE SET
+			source = EXCLUDED.source,
+			granted_at = EXCLUDED.granted_at,
+			withdrawn_at = EXCLUDED.withdrawn_at,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		consent.ID,
+		consent.EmployeeID,
+		consent.Purpose,
+		consent.Source,
+		consent.GrantedAt,
+		consent.WithdrawnAt,
+		consent.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save consent: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresConsentRepository) FindByEmployeeAndPurpose(ctx context.Context, employeeID string, purpose entities.ConsentPurpose) (*entities.Consent, error) {
+	query := `
+		SELECT id, employee_id, purpose, source, granted_at, withdrawn_at, updated_at
+		FROM employee_consents
+		WHERE employee_id = $1 AND purpose = $2
+	`
+
+	var consent entities.Consent
+	err := r.db.QueryRowContext(ctx, query, employeeID, purpose).Scan(
+		&consent.ID,
+		&consent.EmployeeID,
+		&consent.Purpose,
+		&consent.Source,
+		&consent.GrantedAt,
+		&consent.WithdrawnAt,
+		&consent.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to find consent: %w", err)
+	}
+
+	return &consent, nil
+}
+
+func (r *PostgresConsentRepository) FindByEmployee(ctx context.Context, employeeID string) ([]*entities.Consent, error) {
+	query := `
+		SELECT id, employee_id, purpose, source, granted_at, withdrawn_at, updated_at
+		FROM employee_consents
+		WHERE employee_id = $1
+		ORDER BY purpose ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, employeeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query consents: %w", err)
+	}
+	defer rows.Close()
+
+	var consents []*entities.Consent
+	for rows.Next() {
+		var consent entities.Consent
+		err := rows.Scan(
+			&consent.ID,
+			&consent.EmployeeID,
+			&consent.Purpose,
+			&consent.Source,
+			&consent.GrantedAt,
+			&consent.WithdrawnAt,
+			&consent.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan consent: %w", err)
+		}
+		consents = append(consents, &consent)
+	}
+
+	return consents, rows.Err()
+}
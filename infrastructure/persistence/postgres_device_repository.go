@@ -0,0 +1,117 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+type PostgresDeviceRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresDeviceRepository(db *sql.DB) *PostgresDeviceRepository {
+	return &PostgresDeviceRepository{db: db}
+}
+
+const deviceColumns = `id, name, COALESCE(location_id, ''), status, COALESCE(enrollment_code_hash, ''), enrollment_expires_at,
+	COALESCE(secret_hash, ''), secret_expires_at, COALESCE(previous_secret_hash, ''), previous_expires_at, revoked_at, created_at`
+
+func scanDevice(row rowScanner) (*entities.Device, error) {
+	var device entities.Device
+	err := row.Scan(
+		&device.ID,
+		&device.Name,
+		&device.LocationID,
+		&device.Status,
+		&device.EnrollmentCodeHash,
+		&device.EnrollmentExpiresAt,
+		&device.SecretHash,
+		&device.SecretExpiresAt,
+		&device.PreviousSecretHash,
+		&device.PreviousExpiresAt,
+		&device.RevokedAt,
+		&device.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &device, nil
+}
+
+func (r *PostgresDeviceRepository) Save(ctx context.Context, device *entities.Device) error {
+	query := `
+		INSERT INTO devices (id, name, location_id, status, enrollment_code_hash, enrollment_expires_at,
+			secret_hash, secret_expires_at, previous_secret_hash, previous_expires_at, revoked_at, created_at, updated_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, NULLIF($5, ''), $6, NULLIF($7, ''), $8, NULLIF($9, ''), $10, $11, $12, CURRENT_TIMESTAMP)
+		ON CONFLICT (id) DO UPDATE SET
+			name = EXCLUDED.name,
+			location_id = EXCLUDED.location_id,
+			status = EXCLUDED.status,
+			enrollment_code_hash = EXCLUDED.enrollment_code_hash,
+			enrollment_expires_at = EXCLUDED.enrollment_expires_at,
+			secret_hash = EXCLUDED.secret_hash,
+			secret_expires_at = EXCLUDED.secret_expires_at,
+			previous_secret_hash = EXCLUDED.previous_secret_hash,
+			previous_expires_at = EXCLUDED.previous_expires_at,
+			revoked_at = EXCLUDED.revoked_at,
+			updated_at = CURRENT_TIMESTAMP
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		device.ID,
+		device.Name,
+		device.LocationID,
+		device.Status,
+		device.EnrollmentCodeHash,
+		device.EnrollmentExpiresAt,
+		device.SecretHash,
+		device.SecretExpiresAt,
+		device.PreviousSecretHash,
+		device.PreviousExpiresAt,
+		device.RevokedAt,
+		device.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save device: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresDeviceRepository) FindByID(ctx context.Context, id string) (*entities.Device, error) {
+	query := `SELECT ` + deviceColumns + ` FROM devices WHERE id = $1`
+
+	device, err := scanDevice(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find device: %w", err)
+	}
+
+	return device, nil
+}
+
+func (r *PostgresDeviceRepository) FindAll(ctx context.Context) ([]*entities.Device, error) {
+	query := `SELECT ` + deviceColumns + ` FROM devices ORDER BY name ASC`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query devices: %w", err)
+	}
+	defer rows.Close()
+
+	var devices []*entities.Device
+	for rows.Next() {
+		device, err := scanDevice(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan device: %w", err)
+		}
+		devices = append(devices, device)
+	}
+
+	return devices, rows.Err()
+}
@@ -0,0 +1,75 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+type PostgresEmailSettingsRepository struct {
+	db dbtx
+}
+
+func NewPostgresEmailSettingsRepository(db dbtx) *PostgresEmailSettingsRepository {
+	return &PostgresEmailSettingsRepository{db: db}
+}
+
+func (r *PostgresEmailSettingsRepository) FindCheckOutEmail(ctx context.Context) (*entities.CheckOutEmailSettings, error) {
+	query := `
+		SELECT COALESCE(logo_url, ''), COALESCE(footer_text, ''), show_hours, show_cost, show_overtime,
+			COALESCE(updated_by, ''), updated_at
+		FROM checkout_email_settings
+		WHERE id = 1
+	`
+
+	var settings entities.CheckOutEmailSettings
+	err := r.db.QueryRowContext(ctx, query).Scan(
+		&settings.LogoURL,
+		&settings.FooterText,
+		&settings.ShowHours,
+		&settings.ShowCost,
+		&settings.ShowOvertime,
+		&settings.UpdatedBy,
+		&settings.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find check-out email settings: %w", err)
+	}
+
+	return &settings, nil
+}
+
+func (r *PostgresEmailSettingsRepository) SaveCheckOutEmail(ctx context.Context, settings *entities.CheckOutEmailSettings) error {
+	query := `
+		INSERT INTO checkout_email_settings (id, logo_url, footer_text, show_hours, show_cost, show_overtime, updated_by, updated_at)
+		VALUES (1, NULLIF($1, ''), NULLIF($2, ''), $3, $4, $5, $6, $7)
+		ON CONFLICT (id) DO UPDATE SET
+			logo_url = EXCLUDED.logo_url,
+			footer_text = EXCLUDED.footer_text,
+			show_hours = EXCLUDED.show_hours,
+			show_cost = EXCLUDED.show_cost,
+			show_overtime = EXCLUDED.show_overtime,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		settings.LogoURL,
+		settings.FooterText,
+		settings.ShowHours,
+		settings.ShowCost,
+		settings.ShowOvertime,
+		settings.UpdatedBy,
+		settings.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save check-out email settings: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,193 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/events"
+)
+
+// PostgresEmployeeMergeRepository moves employee-scoped rows between IDs (and
+// shards, when the two IDs live on different ones). Consents and the alias
+// mapping live on the primary database.
+type PostgresEmployeeMergeRepository struct {
+	shards *ShardSet
+}
+
+func NewPostgresEmployeeMergeRepository(shards *ShardSet) *PostgresEmployeeMergeRepository {
+	return &PostgresEmployeeMergeRepository{shards: shards}
+}
+
+func (r *PostgresEmployeeMergeRepository) Merge(ctx context.Context, merge *entities.EmployeeMerge, event events.DomainEvent) (int, error) {
+	var (
+		moved int
+		err   error
+	)
+	if r.shards.Index(merge.SourceID) == r.shards.Index(merge.TargetID) {
+		moved, err = r.renameInPlace(ctx, merge, event)
+	} else {
+		moved, err = r.moveAcrossShards(ctx, merge, event)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if err := r.mergeConsentsAndAlias(ctx, merge); err != nil {
+		return moved, err
+	}
+
+	return moved, nil
+}
+
+// renameInPlace re-keys the rows when both IDs live on the same shard
+func (r *PostgresEmployeeMergeRepository) renameInPlace(ctx context.Context, merge *entities.EmployeeMerge, event events.DomainEvent) (int, error) {
+	tx, err := beginChangeTx(ctx, r.shards.For(merge.TargetID))
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	moved := 0
+	for _, table := range employeeScopedTables {
+		if !table.keyed {
+			continue
+		}
+		result, err := tx.ExecContext(ctx, `UPDATE `+table.name+` SET employee_id = $2 WHERE `+table.filter, merge.SourceID, merge.TargetID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to re-attribute %s: %w", table.name, err)
+		}
+		if table.name == "time_records" {
+			n, _ := result.RowsAffected()
+			moved = int(n)
+		}
+	}
+
+	if err := insertOutboxEvent(ctx, tx, merge.TargetID, event); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return moved, nil
+}
+
+// moveAcrossShards copies the rows to the target's shard under the new ID,
+// then deletes them from the source shard (like ShardRebalancer.Move)
+func (r *PostgresEmployeeMergeRepository) moveAcrossShards(ctx context.Context, merge *entities.EmployeeMerge, event events.DomainEvent) (int, error) {
+	srcTx, err := beginChangeTx(ctx, r.shards.For(merge.SourceID))
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin source transaction: %w", err)
+	}
+	defer srcTx.Rollback()
+
+	var moved int
+	err = srcTx.QueryRowContext(ctx, `
+		WITH locked AS (SELECT id FROM time_records WHERE employee_id = $1 FOR UPDATE)
+		SELECT COUNT(*) FROM locked
+	`, merge.SourceID).Scan(&moved)
+	if err != nil {
+		return 0, fmt.Errorf("failed to lock records: %w", err)
+	}
+
+	dstTx, err := beginChangeTx(ctx, r.shards.For(merge.TargetID))
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin target transaction: %w", err)
+	}
+	defer dstTx.Rollback()
+
+	for _, table := range employeeScopedTables {
+		if err := copyRows(ctx, srcTx, dstTx, table.name, table.filter, merge.SourceID, merge.TargetID); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := insertOutboxEvent(ctx, dstTx, merge.TargetID, event); err != nil {
+		return 0, err
+	}
+
+	if err := dstTx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit target shard: %w", err)
+	}
+
+	// Delete in reverse order so the record subquery still matches
+	for i := len(employeeScopedTables) - 1; i >= 0; i-- {
+		table := employeeScopedTables[i]
+		if _, err := srcTx.ExecContext(ctx, `DELETE FROM `+table.name+` WHERE `+table.filter, merge.SourceID); err != nil {
+			return 0, fmt.Errorf("failed to delete %s from source shard: %w", table.name, err)
+		}
+	}
+
+	if err := srcTx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit source shard: %w", err)
+	}
+
+	return moved, nil
+}
+
+// mergeConsentsAndAlias moves consents the target doesn't have yet (the
+// target's own choices win), drops the rest and records the alias
+func (r *PostgresEmployeeMergeRepository) mergeConsentsAndAlias(ctx context.Context, merge *entities.EmployeeMerge) error {
+	tx, err := r.shards.Primary().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE employee_consents SET employee_id = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE employee_id = $1
+			AND purpose NOT IN (SELECT purpose FROM employee_consents WHERE employee_id = $2)
+	`, merge.SourceID, merge.TargetID)
+	if err != nil {
+		return fmt.Errorf("failed to merge consents: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM employee_consents WHERE employee_id = $1`, merge.SourceID); err != nil {
+		return fmt.Errorf("failed to delete merged consents: %w", err)
+	}
+
+	// Earlier merges into the source now point at the new canonical ID
+	if _, err := tx.ExecContext(ctx, `UPDATE employee_aliases SET canonical_id = $2 WHERE canonical_id = $1`, merge.SourceID, merge.TargetID); err != nil {
+		return fmt.Errorf("failed to update aliases: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO employee_aliases (employee_id, canonical_id, actor, reason, merged_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (employee_id) DO UPDATE SET
+			canonical_id = EXCLUDED.canonical_id,
+			actor = EXCLUDED.actor,
+			reason = EXCLUDED.reason,
+			merged_at = EXCLUDED.merged_at
+	`, merge.SourceID, merge.TargetID, merge.Actor, merge.Reason, merge.MergedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save alias: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresEmployeeMergeRepository) ResolveEmployeeID(ctx context.Context, employeeID string) (string, error) {
+	var canonicalID string
+	err := r.shards.Primary().QueryRowContext(ctx,
+		`SELECT canonical_id FROM employee_aliases WHERE employee_id = $1`, employeeID,
+	).Scan(&canonicalID)
+
+	if err == sql.ErrNoRows {
+		return employeeID, nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve employee ID: %w", err)
+	}
+
+	return canonicalID, nil
+}
@@ -0,0 +1,142 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+type PostgresEmployeeRepository struct {
+	db dbtx
+}
+
+func NewPostgresEmployeeRepository(db dbtx) *PostgresEmployeeRepository {
+	return &PostgresEmployeeRepository{db: db}
+}
+
+const employeeColumns = `id, name, email, COALESCE(manager_id, ''), hourly_rate, COALESCE(currency, ''), active, created_at, updated_at`
+
+func scanEmployee(row rowScanner) (*entities.Employee, error) {
+	var employee entities.Employee
+	err := row.Scan(
+		&employee.ID,
+		&employee.Name,
+		&employee.Email,
+		&employee.ManagerID,
+		&employee.HourlyRate,
+		&employee.Currency,
+		&employee.Active,
+		&employee.CreatedAt,
+		&employee.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &employee, nil
+}
+
+func (r *PostgresEmployeeRepository) Save(ctx context.Context, employee *entities.Employee) error {
+	query := `
+		INSERT INTO employees (id, name, email, manager_id, hourly_rate, currency, active, created_at, updated_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, NULLIF($6, ''), $7, $8, $9)
+		ON CONFLICT (id) DO UPDATE SET
+			name = EXCLUDED.name,
+			email = EXCLUDED.email,
+			manager_id = EXCLUDED.manager_id,
+			hourly_rate = EXCLUDED.hourly_rate,
+			currency = EXCLUDED.currency,
+			active = EXCLUDED.active,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		employee.ID,
+		employee.Name,
+		employee.Email,
+		employee.ManagerID,
+		employee.HourlyRate,
+		employee.Currency,
+		employee.Active,
+		employee.CreatedAt,
+		employee.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save employee: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresEmployeeRepository) FindByID(ctx context.Context, id string) (*entities.Employee, error) {
+	query := `
+		SELECT ` + employeeColumns + `
+		FROM employees
+		WHERE id = $1
+	`
+
+	employee, err := scanEmployee(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to find employee: %w", err)
+	}
+
+	return employee, nil
+}
+
+func (r *PostgresEmployeeRepository) FindByEmail(ctx context.Context, email string) (*entities.Employee, error) {
+	query := `
+		SELECT ` + employeeColumns + `
+		FROM employees
+		WHERE lower(email) = lower($1) AND email <> ''
+		ORDER BY active DESC, id ASC
+		LIMIT 1
+	`
+
+	employee, err := scanEmployee(r.db.QueryRowContext(ctx, query, email))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to find employee by email: %w", err)
+	}
+
+	return employee, nil
+}
+
+func (r *PostgresEmployeeRepository) FindAll(ctx context.Context) ([]*entities.Employee, error) {
+	query := `
+		SELECT ` + employeeColumns + `
+		FROM employees
+		ORDER BY id ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query employees: %w", err)
+	}
+	defer rows.Close()
+
+	var employees []*entities.Employee
+	for rows.Next() {
+		employee, err := scanEmployee(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan employee: %w", err)
+		}
+		employees = append(employees, employee)
+	}
+
+	return employees, rows.Err()
+}
+
+func (r *PostgresEmployeeRepository) Delete(ctx context.Context, id string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM employees WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete employee: %w", err)
+	}
+	return nil
+}
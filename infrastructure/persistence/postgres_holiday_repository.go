@@ -0,0 +1,70 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+type PostgresHolidayRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresHolidayRepository(db *sql.DB) *PostgresHolidayRepository {
+	return &PostgresHolidayRepository{db: db}
+}
+
+func (r *PostgresHolidayRepository) Save(ctx context.Context, holiday *entities.Holiday) error {
+	query := `
+		INSERT INTO holidays (date, name, created_at)
+		VALUES ($1::date, $2, $3)
+		ON CONFLICT (date) DO UPDATE SET name = EXCLUDED.name
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, holiday.Date, holiday.Name, holiday.CreatedAt); err != nil {
+		return fmt.Errorf("failed to save holiday: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresHolidayRepository) Delete(ctx context.Context, date string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM holidays WHERE date = $1::date`, date)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete holiday: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete holiday: %w", err)
+	}
+	return deleted > 0, nil
+}
+
+func (r *PostgresHolidayRepository) FindInRange(ctx context.Context, from, to string) ([]*entities.Holiday, error) {
+	query := `
+		SELECT to_char(date, 'YYYY-MM-DD'), name, created_at
+		FROM holidays
+		WHERE date BETWEEN $1::date AND $2::date
+		ORDER BY date ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query holidays: %w", err)
+	}
+	defer rows.Close()
+
+	var holidays []*entities.Holiday
+	for rows.Next() {
+		var holiday entities.Holiday
+		if err := rows.Scan(&holiday.Date, &holiday.Name, &holiday.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan holiday: %w", err)
+		}
+		holidays = append(holidays, &holiday)
+	}
+
+	return holidays, rows.Err()
+}
@@ -0,0 +1,120 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+)
+
+// PostgresIdempotencyRepository keeps the deduplication log on the primary
+// database so every API instance sees the same keys
+type PostgresIdempotencyRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresIdempotencyRepository(db *sql.DB) *PostgresIdempotencyRepository {
+	return &PostgresIdempotencyRepository{db: db}
+}
+
+func (r *PostgresIdempotencyRepository) Reserve(ctx context.Context, key, fingerprint string, expiresAt time.Time) (*repositories.IdempotencyRecord, error) {
+	// Take over the key only when its previous claim expired; otherwise the
+	// conflict leaves the row alone and nothing is returned
+	query := `
+		INSERT INTO idempotency_keys (key, fingerprint, expires_at, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (key) DO UPDATE SET
+			fingerprint = EXCLUDED.fingerprint,
+			status_code = NULL,
+			content_type = NULL,
+			body = NULL,
+			expires_at = EXCLUDED.expires_at,
+			created_at = EXCLUDED.created_at
+		WHERE idempotency_keys.expires_at <= EXCLUDED.created_at
+		RETURNING key
+	`
+
+	// The existing row may expire and be purged between the two statements;
+	// one more attempt then claims it
+	for attempt := 0; attempt < 2; attempt++ {
+		var claimed string
+		err := r.db.QueryRowContext(ctx, query, key, fingerprint, expiresAt, time.Now()).Scan(&claimed)
+		if err == nil {
+			return nil, nil
+		}
+		if err != sql.ErrNoRows {
+			return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+		}
+
+		record, err := r.find(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if record != nil {
+			return record, nil
+		}
+	}
+
+	return nil, fmt.Errorf("failed to reserve idempotency key %q", key)
+}
+
+func (r *PostgresIdempotencyRepository) find(ctx context.Context, key string) (*repositories.IdempotencyRecord, error) {
+	query := `
+		SELECT key, fingerprint, COALESCE(status_code, 0), COALESCE(content_type, ''), body, expires_at
+		FROM idempotency_keys
+		WHERE key = $1
+	`
+
+	var record repositories.IdempotencyRecord
+	err := r.db.QueryRowContext(ctx, query, key).Scan(
+		&record.Key,
+		&record.Fingerprint,
+		&record.StatusCode,
+		&record.ContentType,
+		&record.Body,
+		&record.ExpiresAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find idempotency key: %w", err)
+	}
+
+	return &record, nil
+}
+
+func (r *PostgresIdempotencyRepository) Complete(ctx context.Context, key string, statusCode int, contentType string, body []byte, expiresAt time.Time) error {
+	query := `
+		UPDATE idempotency_keys
+		SET status_code = $2, content_type = $3, body = $4, expires_at = $5
+		WHERE key = $1
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, key, statusCode, contentType, body, expiresAt); err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresIdempotencyRepository) Release(ctx context.Context, key string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE key = $1`, key); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresIdempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,104 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+type PostgresLocationRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresLocationRepository(db *sql.DB) *PostgresLocationRepository {
+	return &PostgresLocationRepository{db: db}
+}
+
+func (r *PostgresLocationRepository) Save(ctx context.Context, location *entities.Location) error {
+	query := `
+		INSERT INTO locations (id, name, address, active, time_zone, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (id) DO UPDATE SET
+			name = EXCLUDED.name,
+			address = EXCLUDED.address,
+			active = EXCLUDED.active,
+			time_zone = EXCLUDED.time_zone
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		location.ID,
+		location.Name,
+		location.Address,
+		location.Active,
+		location.TimeZone,
+		location.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save location: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresLocationRepository) FindByID(ctx context.Context, id string) (*entities.Location, error) {
+	query := `
+		SELECT id, name, address, active, time_zone, created_at
+		FROM locations
+		WHERE id = $1
+	`
+
+	var location entities.Location
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&location.ID,
+		&location.Name,
+		&location.Address,
+		&location.Active,
+		&location.TimeZone,
+		&location.CreatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to find location: %w", err)
+	}
+
+	return &location, nil
+}
+
+func (r *PostgresLocationRepository) FindAll(ctx context.Context) ([]*entities.Location, error) {
+	query := `
+		SELECT id, name, address, active, time_zone, created_at
+		FROM locations
+		ORDER BY name ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query locations: %w", err)
+	}
+	defer rows.Close()
+
+	var locations []*entities.Location
+	for rows.Next() {
+		var location entities.Location
+		err := rows.Scan(
+			&location.ID,
+			&location.Name,
+			&location.Address,
+			&location.Active,
+			&location.TimeZone,
+			&location.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan location: %w", err)
+		}
+		locations = append(locations, &location)
+	}
+
+	return locations, rows.Err()
+}
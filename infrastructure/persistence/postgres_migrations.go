@@ -0,0 +1,217 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/postgres/*.sql
+var postgresMigrations embed.FS
+
+// migrationLockKey serializes migrations between instances starting together
+const migrationLockKey = "schema_migrations"
+
+// migration is one versioned schema change, e.g. 0002_search_indexes.up.sql
+// and its .down.sql
+type migration struct {
+	version string
+	up      string
+	down    string
+}
+
+// MigrationStatus tells whether a migration has been applied
+type MigrationStatus struct {
+	Version   string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// PostgresMigrator applies the embedded Postgres migrations and records them
+// in schema_migrations. Each migration runs in its own transaction.
+type PostgresMigrator struct {
+	db         *sql.DB
+	migrations []migration
+}
+
+func NewPostgresMigrator(db *sql.DB) (*PostgresMigrator, error) {
+	migrations, err := loadPostgresMigrations()
+	if err != nil {
+		return nil, err
+	}
+	return &PostgresMigrator{db: db, migrations: migrations}, nil
+}
+
+// MigratePostgres applies every pending migration
+func MigratePostgres(ctx context.Context, db *sql.DB) error {
+	migrator, err := NewPostgresMigrator(db)
+	if err != nil {
+		return err
+	}
+	_, err = migrator.Up(ctx)
+	return err
+}
+
+func loadPostgresMigrations() ([]migration, error) {
+	files, err := fs.Glob(postgresMigrations, "migrations/postgres/*.up.sql")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	migrations := make([]migration, 0, len(files))
+	for _, file := range files {
+		up, err := postgresMigrations.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		downFile := strings.TrimSuffix(file, ".up.sql") + ".down.sql"
+		down, err := postgresMigrations.ReadFile(downFile)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s has no down script: %w", file, err)
+		}
+		migrations = append(migrations, migration{
+			version: strings.TrimSuffix(file[strings.LastIndex(file, "/")+1:], ".up.sql"),
+			up:      string(up),
+			down:    string(down),
+		})
+	}
+	return migrations, nil
+}
+
+// Up applies the pending migrations in version order and returns their versions
+func (m *PostgresMigrator) Up(ctx context.Context) ([]string, error) {
+	var versions []string
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, step := range m.migrations {
+			if _, ok := applied[step.version]; ok {
+				continue
+			}
+			err := runMigration(ctx, conn, step.up, `INSERT INTO schema_migrations (version) VALUES ($1)`, step.version)
+			if err != nil {
+				return fmt.Errorf("migration %s failed: %w", step.version, err)
+			}
+			versions = append(versions, step.version)
+		}
+		return nil
+	})
+	return versions, err
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns
+// their versions
+func (m *PostgresMigrator) Down(ctx context.Context, steps int) ([]string, error) {
+	var versions []string
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && len(versions) < steps; i-- {
+			step := m.migrations[i]
+			if _, ok := applied[step.version]; !ok {
+				continue
+			}
+			err := runMigration(ctx, conn, step.down, `DELETE FROM schema_migrations WHERE version = $1`, step.version)
+			if err != nil {
+				return fmt.Errorf("reverting migration %s failed: %w", step.version, err)
+			}
+			versions = append(versions, step.version)
+		}
+		return nil
+	})
+	return versions, err
+}
+
+// Status lists every known migration and whether it has been applied
+func (m *PostgresMigrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, step := range m.migrations {
+			appliedAt, ok := applied[step.version]
+			statuses = append(statuses, MigrationStatus{Version: step.version, Applied: ok, AppliedAt: appliedAt})
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// locked runs fn on one connection holding a session advisory lock, so two
+// instances starting at once do not apply the same migration twice
+func (m *PostgresMigrator) locked(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock(hashtext($1))`, migrationLockKey); err != nil {
+		return fmt.Errorf("failed to lock migrations: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock(hashtext($1))`, migrationLockKey)
+
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version VARCHAR(255) PRIMARY KEY,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	return fn(conn)
+}
+
+func appliedMigrations(ctx context.Context, conn *sql.Conn) (map[string]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[string]time.Time)
+	for rows.Next() {
+		var (
+			version   string
+			appliedAt time.Time
+		)
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt.UTC()
+	}
+	return applied, rows.Err()
+}
+
+// runMigration runs a script and its bookkeeping statement in one transaction.
+// Scripts go through the simple query protocol, which accepts several
+// statements at once.
+func runMigration(ctx context.Context, conn *sql.Conn, script, record, version string) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // Rollback if not committed
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,89 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+
+	"github.com/lib/pq"
+)
+
+type PostgresNoteRepository struct {
+	shards *ShardSet
+}
+
+// NewShardedNoteRepository stores notes on the shard owning the employee's records
+func NewShardedNoteRepository(shards *ShardSet) *PostgresNoteRepository {
+	return &PostgresNoteRepository{shards: shards}
+}
+
+const insertNoteQuery = `
+	INSERT INTO time_record_notes (id, record_id, employee_id, kind, author, body, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	ON CONFLICT (id) DO NOTHING
+`
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func insertNote(ctx context.Context, db execer, note *entities.RecordNote) error {
+	_, err := db.ExecContext(ctx, insertNoteQuery,
+		note.ID,
+		note.RecordID,
+		note.EmployeeID,
+		note.Kind,
+		note.Author,
+		note.Body,
+		note.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save note: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresNoteRepository) Save(ctx context.Context, note *entities.RecordNote) error {
+	return insertNote(ctx, r.shards.For(note.EmployeeID), note)
+}
+
+func (r *PostgresNoteRepository) FindByRecords(ctx context.Context, employeeID string, recordIDs []string) ([]*entities.RecordNote, error) {
+	if len(recordIDs) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		SELECT id, record_id, employee_id, kind, author, body, created_at
+		FROM time_record_notes
+		WHERE employee_id = $1 AND record_id = ANY($2)
+		ORDER BY created_at ASC, id ASC
+	`
+
+	rows, err := r.shards.For(employeeID).QueryContext(ctx, query, employeeID, pq.Array(recordIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notes: %w", err)
+	}
+	defer rows.Close()
+
+	var notes []*entities.RecordNote
+	for rows.Next() {
+		var note entities.RecordNote
+		err := rows.Scan(
+			&note.ID,
+			&note.RecordID,
+			&note.EmployeeID,
+			&note.Kind,
+			&note.Author,
+			&note.Body,
+			&note.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan note: %w", err)
+		}
+		notes = append(notes, &note)
+	}
+
+	return notes, rows.Err()
+}
@@ -0,0 +1,95 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+// PostgresParkedLaborCostRepository keeps the parked labor cost entries on
+// the primary database, so any worker instance can drain them
+type PostgresParkedLaborCostRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresParkedLaborCostRepository(db *sql.DB) *PostgresParkedLaborCostRepository {
+	return &PostgresParkedLaborCostRepository{db: db}
+}
+
+func (r *PostgresParkedLaborCostRepository) Park(ctx context.Context, parked *entities.ParkedLaborCost) error {
+	// A redelivered message parks the same entry again
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO parked_labor_costs (idempotency_key, entry, reason, parked_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (idempotency_key) WHERE idempotency_key IS NOT NULL DO NOTHING
+	`, sql.NullString{String: parked.IdempotencyKey, Valid: parked.IdempotencyKey != ""}, []byte(parked.Entry), parked.Reason, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to park labor cost entry: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresParkedLaborCostRepository) ListParked(ctx context.Context, limit int) ([]*entities.ParkedLaborCost, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, COALESCE(idempotency_key, ''), entry, reason, attempts, last_error, parked_at
+		FROM parked_labor_costs
+		WHERE failed_at IS NULL
+		ORDER BY parked_at, id
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list parked labor cost entries: %w", err)
+	}
+	defer rows.Close()
+
+	var parked []*entities.ParkedLaborCost
+	for rows.Next() {
+		var p entities.ParkedLaborCost
+		var entry []byte
+		if err := rows.Scan(&p.ID, &p.IdempotencyKey, &entry, &p.Reason, &p.Attempts, &p.LastError, &p.ParkedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan parked labor cost entry: %w", err)
+		}
+		p.Entry = entry
+		parked = append(parked, &p)
+	}
+	return parked, rows.Err()
+}
+
+func (r *PostgresParkedLaborCostRepository) DeleteParked(ctx context.Context, id int64) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM parked_labor_costs WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete parked labor cost entry: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresParkedLaborCostRepository) RecordParkedAttempt(ctx context.Context, id int64, lastError string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE parked_labor_costs SET attempts = attempts + 1, last_error = $2 WHERE id = $1
+	`, id, lastError)
+	if err != nil {
+		return fmt.Errorf("failed to record parked labor cost attempt: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresParkedLaborCostRepository) MarkParkedFailed(ctx context.Context, id int64, lastError string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE parked_labor_costs SET attempts = attempts + 1, last_error = $2, failed_at = $3 WHERE id = $1
+	`, id, lastError, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to mark parked labor cost entry failed: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresParkedLaborCostRepository) CountParked(ctx context.Context) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM parked_labor_costs WHERE failed_at IS NULL`).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count parked labor cost entries: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,128 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Status of a processed_events row
+const (
+	processedEventPending = "pending"
+	processedEventDone    = "done"
+)
+
+// processedEventPollInterval is how often a second delivery checks whether
+// the first one finished
+const processedEventPollInterval = 250 * time.Millisecond
+
+// PostgresProcessedEventRepository keeps the consumers' inbox on the primary
+// database so every worker instance sees the same processed events
+type PostgresProcessedEventRepository struct {
+	db *sql.DB
+	// How long a claim holds the event before another delivery may take over
+	claimTimeout time.Duration
+}
+
+func NewPostgresProcessedEventRepository(db *sql.DB, claimTimeout time.Duration) *PostgresProcessedEventRepository {
+	return &PostgresProcessedEventRepository{
+		db:           db,
+		claimTimeout: claimTimeout,
+	}
+}
+
+// ProcessOnce claims the event with a pending row, runs fn outside any
+// transaction and marks the row done, or deletes it when fn fails so a
+// redelivery takes over at once
+func (r *PostgresProcessedEventRepository) ProcessOnce(ctx context.Context, consumer, eventID string, fn func(ctx context.Context) error) (bool, error) {
+	claimID := uuid.New().String()
+	for {
+		claimed, err := r.claim(ctx, consumer, eventID, claimID)
+		if err != nil {
+			return false, err
+		}
+		if claimed {
+			break
+		}
+
+		var status string
+		err = r.db.QueryRowContext(ctx, `
+			SELECT status FROM processed_events WHERE consumer = $1 AND event_id = $2
+		`, consumer, eventID).Scan(&status)
+		if err != nil && err != sql.ErrNoRows {
+			return false, fmt.Errorf("failed to read processed event: %w", err)
+		}
+		if status == processedEventDone {
+			return false, nil
+		}
+		if err == sql.ErrNoRows {
+			// The other delivery failed and released its claim
+			continue
+		}
+
+		select {
+		case <-time.After(processedEventPollInterval):
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+
+	if err := fn(ctx); err != nil {
+		// Released even when the delivery was cancelled, so the redelivery
+		// does not wait for the claim to expire
+		if _, releaseErr := r.db.ExecContext(context.WithoutCancel(ctx), `
+			DELETE FROM processed_events WHERE consumer = $1 AND event_id = $2 AND claimed_by = $3
+		`, consumer, eventID, claimID); releaseErr != nil {
+			return true, fmt.Errorf("%w (and failed to release processed event: %v)", err, releaseErr)
+		}
+		return true, err
+	}
+
+	// A claim that expired meanwhile was taken over by another delivery,
+	// which then handles the event again; nothing is left to mark
+	if _, err := r.db.ExecContext(ctx, `
+		UPDATE processed_events
+		SET status = $4, processed_at = now(), claimed_by = NULL, claimed_until = NULL
+		WHERE consumer = $1 AND event_id = $2 AND claimed_by = $3
+	`, consumer, eventID, claimID, processedEventDone); err != nil {
+		return true, fmt.Errorf("failed to record processed event: %w", err)
+	}
+	return true, nil
+}
+
+// claim inserts a pending row for the event, or takes over a pending one
+// whose claim expired. It reports false when the event is processed or
+// claimed by another delivery.
+func (r *PostgresProcessedEventRepository) claim(ctx context.Context, consumer, eventID, claimID string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		INSERT INTO processed_events (consumer, event_id, processed_at, status, claimed_by, claimed_until)
+		VALUES ($1, $2, now(), $3, $4, now() + $5 * interval '1 millisecond')
+		ON CONFLICT (consumer, event_id) DO UPDATE
+		SET processed_at = EXCLUDED.processed_at, claimed_by = EXCLUDED.claimed_by, claimed_until = EXCLUDED.claimed_until
+		WHERE processed_events.status = $3 AND processed_events.claimed_until < now()
+	`, consumer, eventID, processedEventPending, claimID, r.claimTimeout.Milliseconds())
+	if err != nil {
+		return false, fmt.Errorf("failed to claim processed event: %w", err)
+	}
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim processed event: %w", err)
+	}
+	return claimed > 0, nil
+}
+
+func (r *PostgresProcessedEventRepository) DeleteProcessedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM processed_events WHERE processed_at < $1`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete processed events: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete processed events: %w", err)
+	}
+	return deleted, nil
+}
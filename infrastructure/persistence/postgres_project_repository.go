@@ -0,0 +1,96 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+type PostgresProjectRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresProjectRepository(db *sql.DB) *PostgresProjectRepository {
+	return &PostgresProjectRepository{db: db}
+}
+
+func (r *PostgresProjectRepository) Save(ctx context.Context, project *entities.Project) error {
+	query := `
+		INSERT INTO projects (code, name, active, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (code) DO UPDATE SET
+			name = EXCLUDED.name,
+			active = EXCLUDED.active
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		project.Code,
+		project.Name,
+		project.Active,
+		project.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save project: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresProjectRepository) FindByCode(ctx context.Context, code string) (*entities.Project, error) {
+	query := `
+		SELECT code, name, active, created_at
+		FROM projects
+		WHERE code = $1
+	`
+
+	var project entities.Project
+	err := r.db.QueryRowContext(ctx, query, code).Scan(
+		&project.Code,
+		&project.Name,
+		&project.Active,
+		&project.CreatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to find project: %w", err)
+	}
+
+	return &project, nil
+}
+
+func (r *PostgresProjectRepository) FindAll(ctx context.Context) ([]*entities.Project, error) {
+	query := `
+		SELECT code, name, active, created_at
+		FROM projects
+		ORDER BY code ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query projects: %w", err)
+	}
+	defer rows.Close()
+
+	var projects []*entities.Project
+	for rows.Next() {
+		var project entities.Project
+		err := rows.Scan(
+			&project.Code,
+			&project.Name,
+			&project.Active,
+			&project.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan project: %w", err)
+		}
+		projects = append(projects, &project)
+	}
+
+	return projects, rows.Err()
+}
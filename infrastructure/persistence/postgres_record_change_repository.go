@@ -0,0 +1,131 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+)
+
+// changeSourceMaintenance marks transactions that move rows between shards or
+// partitions; the change log trigger skips their writes
+const changeSourceMaintenance = "maintenance"
+
+// beginChangeTx begins a transaction that writes time records and names the
+// origin of ctx to the change log trigger, see migration 0009
+func beginChangeTx(ctx context.Context, db *sql.DB) (*sql.Tx, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := setChangeOrigin(ctx, tx, repositories.ChangeOriginFrom(ctx)); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return tx, nil
+}
+
+// setChangeOrigin sets the origin for the rest of the transaction
+func setChangeOrigin(ctx context.Context, tx *sql.Tx, origin repositories.ChangeOrigin) error {
+	_, err := tx.ExecContext(ctx, `SELECT set_config('app.change_actor', $1, true), set_config('app.change_source', $2, true)`,
+		origin.Actor, origin.Source)
+	if err != nil {
+		return fmt.Errorf("failed to set change origin: %w", err)
+	}
+	return nil
+}
+
+type PostgresRecordChangeRepository struct {
+	shards *ShardSet
+}
+
+// NewShardedRecordChangeRepository reads the change log kept next to the
+// records on each shard
+func NewShardedRecordChangeRepository(shards *ShardSet) *PostgresRecordChangeRepository {
+	return &PostgresRecordChangeRepository{shards: shards}
+}
+
+// FindByRecordID reads all shards: a record's changes stay on the shard it
+// was on when they were made, e.g. before its employee was merged into one
+// owned by another shard
+func (r *PostgresRecordChangeRepository) FindByRecordID(ctx context.Context, recordID string) ([]*entities.RecordChange, error) {
+	query := `
+		SELECT id, record_id, employee_id, operation, old_values, new_values, actor, source, changed_at
+		FROM time_record_changes
+		WHERE record_id = $1
+		ORDER BY changed_at ASC, id ASC
+	`
+
+	var (
+		mu      sync.Mutex
+		changes []*entities.RecordChange
+	)
+	err := r.shards.fanOut(ctx, func(ctx context.Context, shard int, db *sql.DB) error {
+		rows, err := db.QueryContext(ctx, query, recordID)
+		if err != nil {
+			return fmt.Errorf("failed to query record changes on shard %d: %w", shard, err)
+		}
+		defer rows.Close()
+
+		var found []*entities.RecordChange
+		for rows.Next() {
+			var (
+				change        entities.RecordChange
+				before, after []byte
+			)
+			err := rows.Scan(
+				&change.ID,
+				&change.RecordID,
+				&change.EmployeeID,
+				&change.Operation,
+				&before,
+				&after,
+				&change.Actor,
+				&change.Source,
+				&change.ChangedAt,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to scan record change: %w", err)
+			}
+			if change.Old, err = unmarshalChangeValues(before); err != nil {
+				return err
+			}
+			if change.New, err = unmarshalChangeValues(after); err != nil {
+				return err
+			}
+			found = append(found, &change)
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to read record changes: %w", err)
+		}
+
+		mu.Lock()
+		changes = append(changes, found...)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].ChangedAt.Before(changes[j].ChangedAt)
+	})
+	return changes, nil
+}
+
+func unmarshalChangeValues(data []byte) (map[string]json.RawMessage, error) {
+	if data == nil {
+		return nil, nil
+	}
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to decode record change values: %w", err)
+	}
+	return values, nil
+}
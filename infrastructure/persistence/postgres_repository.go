@@ -5,133 +5,329 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/leo-andrei/check-in-service/domain/entities"
 	"github.com/leo-andrei/check-in-service/domain/events"
+	"github.com/leo-andrei/check-in-service/domain/hours"
 	"github.com/leo-andrei/check-in-service/domain/repositories"
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
 
 	"github.com/google/uuid"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 type PostgresTimeRecordRepository struct {
-	db *sql.DB
+	shards *ShardSet
 }
 
 func NewPostgresTimeRecordRepository(db *sql.DB) *PostgresTimeRecordRepository {
-	return &PostgresTimeRecordRepository{db: db}
+	return &PostgresTimeRecordRepository{shards: NewShardSet(db)}
 }
 
-func (r *PostgresTimeRecordRepository) Save(ctx context.Context, record *entities.TimeRecord) error {
-	query := `
-		INSERT INTO time_records (id, employee_id, check_in_at, check_out_at, status, hours_worked)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		ON CONFLICT (id) DO UPDATE SET
-			check_out_at = EXCLUDED.check_out_at,
-			status = EXCLUDED.status,
-			hours_worked = EXCLUDED.hours_worked
-	`
+// NewShardedTimeRecordRepository stores each employee's records on the shard
+// owning their employee ID
+func NewShardedTimeRecordRepository(shards *ShardSet) *PostgresTimeRecordRepository {
+	return &PostgresTimeRecordRepository{shards: shards}
+}
+
+// timeRecordColumns is the column list matching scanTimeRecord
+const timeRecordColumns = `id, employee_id, check_in_at, check_out_at, status, hours_worked, regular_hours, overtime_hours,
+	COALESCE(location_id, ''), time_zone, COALESCE(project_code, ''), COALESCE(to_char(business_date, 'YYYY-MM-DD'), ''),
+	day_segments, holiday_hours, weekend_hours, hourly_rate, currency, regular_cost, overtime_cost,
+	COALESCE(source, ''), COALESCE(device_id, ''), COALESCE(check_out_source, ''), COALESCE(check_out_device_id, ''),
+	voided_at`
+
+const upsertTimeRecordQuery = `
+	INSERT INTO time_records (id, employee_id, check_in_at, check_out_at, status, hours_worked, regular_hours, overtime_hours,
+		location_id, time_zone, project_code, business_date, day_segments, holiday_hours, weekend_hours,
+		hourly_rate, currency, regular_cost, overtime_cost, source, device_id, check_out_source, check_out_device_id, voided_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), $10, NULLIF($11, ''), NULLIF($12, '')::date, $13, $14, $15,
+		$16, $17, $18, $19, NULLIF($20, ''), NULLIF($21, ''), NULLIF($22, ''), NULLIF($23, ''), $24)
+	ON CONFLICT (id, check_in_at) DO UPDATE SET
+		check_out_at = EXCLUDED.check_out_at,
+		status = EXCLUDED.status,
+		hours_worked = EXCLUDED.hours_worked,
+		regular_hours = EXCLUDED.regular_hours,
+		overtime_hours = EXCLUDED.overtime_hours,
+		business_date = EXCLUDED.business_date,
+		day_segments = EXCLUDED.day_segments,
+		holiday_hours = EXCLUDED.holiday_hours,
+		weekend_hours = EXCLUDED.weekend_hours,
+		hourly_rate = EXCLUDED.hourly_rate,
+		currency = EXCLUDED.currency,
+		regular_cost = EXCLUDED.regular_cost,
+		overtime_cost = EXCLUDED.overtime_cost,
+		check_out_source = EXCLUDED.check_out_source,
+		check_out_device_id = EXCLUDED.check_out_device_id,
+		voided_at = EXCLUDED.voided_at,
+		updated_at = CURRENT_TIMESTAMP
+`
+
+// moveTimeRecordQuery changes the check-in of a stored record before its
+// upsert. check_in_at is part of the primary key and picks the monthly
+// partition, so a corrected check-in moves the row rather than conflicting.
+const moveTimeRecordQuery = `
+	UPDATE time_records SET check_in_at = $2, updated_at = CURRENT_TIMESTAMP
+	WHERE id = $1 AND check_in_at <> $2
+`
+
+// upsertTimeRecord saves the record; db should be a transaction so the move
+// and the upsert apply together
+func upsertTimeRecord(ctx context.Context, db execer, record *entities.TimeRecord) error {
+	if _, err := db.ExecContext(ctx, moveTimeRecordQuery, record.ID, record.CheckInAt); err != nil {
+		return err
+	}
+	_, err := db.ExecContext(ctx, upsertTimeRecordQuery, upsertTimeRecordArgs(record)...)
+	return err
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTimeRecord(row rowScanner) (*entities.TimeRecord, error) {
+	var (
+		record       entities.TimeRecord
+		segments     []byte
+		hourlyRate   sql.NullFloat64
+		currency     sql.NullString
+		regularCost  sql.NullInt64
+		overtimeCost sql.NullInt64
+	)
+	err := row.Scan(
+		&record.ID,
+		&record.EmployeeID,
+		&record.CheckInAt,
+		&record.CheckOutAt,
+		&record.Status,
+		&record.HoursWorked,
+		&record.RegularHours,
+		&record.OvertimeHours,
+		&record.LocationID,
+		&record.TimeZone,
+		&record.ProjectCode,
+		&record.BusinessDate,
+		&segments,
+		&record.HolidayHours,
+		&record.WeekendHours,
+		&hourlyRate,
+		&currency,
+		&regularCost,
+		&overtimeCost,
+		&record.Source,
+		&record.DeviceID,
+		&record.CheckOutSource,
+		&record.CheckOutDeviceID,
+		&record.VoidedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if currency.Valid {
+		record.LaborCost = &entities.LaborCost{
+			HourlyRate: hourlyRate.Float64,
+			Regular:    entities.Money{Amount: regularCost.Int64, Currency: currency.String},
+			Overtime:   entities.Money{Amount: overtimeCost.Int64, Currency: currency.String},
+		}
+	}
+
+	if segments != nil {
+		if err := json.Unmarshal(segments, &record.Segments); err != nil {
+			return nil, fmt.Errorf("failed to decode day segments: %w", err)
+		}
+	}
+
+	// TIMESTAMPTZ values come back in the session zone; keep the entity in UTC
+	record.CheckInAt = record.CheckInAt.UTC()
+	if record.CheckOutAt != nil {
+		checkOutAt := record.CheckOutAt.UTC()
+		record.CheckOutAt = &checkOutAt
+	}
+	if record.VoidedAt != nil {
+		voidedAt := record.VoidedAt.UTC()
+		record.VoidedAt = &voidedAt
+	}
+	return &record, nil
+}
+
+func upsertTimeRecordArgs(record *entities.TimeRecord) []interface{} {
+	// Records that were not split store NULL segments
+	var segments []byte
+	if len(record.Segments) > 0 {
+		segments, _ = json.Marshal(record.Segments)
+	}
+
+	// Unpriced records store NULL cost columns
+	var hourlyRate, currency, regularCost, overtimeCost interface{}
+	if cost := record.LaborCost; cost != nil {
+		hourlyRate, currency = cost.HourlyRate, cost.Currency()
+		regularCost, overtimeCost = cost.Regular.Amount, cost.Overtime.Amount
+	}
 
-	_, err := r.db.ExecContext(ctx, query,
+	return []interface{}{
 		record.ID,
 		record.EmployeeID,
 		record.CheckInAt,
 		record.CheckOutAt,
 		record.Status,
 		record.HoursWorked,
-	)
+		record.RegularHours,
+		record.OvertimeHours,
+		record.LocationID,
+		record.TimeZone,
+		record.ProjectCode,
+		record.BusinessDate,
+		segments,
+		record.HolidayHours,
+		record.WeekendHours,
+		hourlyRate,
+		currency,
+		regularCost,
+		overtimeCost,
+		record.Source,
+		record.DeviceID,
+		record.CheckOutSource,
+		record.CheckOutDeviceID,
+		record.VoidedAt,
+	}
+}
 
+func (r *PostgresTimeRecordRepository) Save(ctx context.Context, record *entities.TimeRecord) error {
+	tx, err := beginChangeTx(ctx, r.shards.For(record.EmployeeID))
 	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback if not committed
+
+	if err := upsertTimeRecord(ctx, tx, record); err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("failed to save time record: %w", repositories.ErrConflict)
+		}
 		return fmt.Errorf("failed to save time record: %w", err)
 	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return nil
 }
 
 // SaveWithEvent - Transactional Outbox Pattern Implementation
 func (r *PostgresTimeRecordRepository) SaveWithEvent(ctx context.Context, record *entities.TimeRecord, event events.DomainEvent) error {
-	// Start transaction
-	tx, err := r.db.BeginTx(ctx, nil)
+	return r.SaveWithEvents(ctx, record, []events.DomainEvent{event})
+}
+
+// SaveWithEvents saves the record and all its events to the outbox in one transaction
+func (r *PostgresTimeRecordRepository) SaveWithEvents(ctx context.Context, record *entities.TimeRecord, evts []events.DomainEvent) error {
+	// Start transaction on the employee's shard
+	tx, err := beginChangeTx(ctx, r.shards.For(record.EmployeeID))
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback() // Rollback if not committed
 
 	// 1. Save the time record
-	query := `
-		INSERT INTO time_records (id, employee_id, check_in_at, check_out_at, status, hours_worked)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		ON CONFLICT (id) DO UPDATE SET
-			check_out_at = EXCLUDED.check_out_at,
-			status = EXCLUDED.status,
-			hours_worked = EXCLUDED.hours_worked,
-			updated_at = CURRENT_TIMESTAMP
-	`
+	if err := upsertTimeRecord(ctx, tx, record); err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("failed to save time record: %w", repositories.ErrConflict)
+		}
+		return fmt.Errorf("failed to save time record: %w", err)
+	}
 
-	_, err = tx.ExecContext(ctx, query,
-		record.ID,
-		record.EmployeeID,
-		record.CheckInAt,
-		record.CheckOutAt,
-		record.Status,
-		record.HoursWorked,
-	)
+	// 2. Save the hours calculation for audit, when one was made
+	if record.Calculation != nil {
+		if err := insertHoursCalculation(ctx, tx, record); err != nil {
+			return err
+		}
+	}
 
-	if err != nil {
-		return fmt.Errorf("failed to save time record: %w", err)
+	// 3. Save notes written with this check-in/check-out
+	for _, note := range record.Notes {
+		if err := insertNote(ctx, tx, note); err != nil {
+			return err
+		}
+	}
+
+	// 4. Save the events to outbox table (same transaction)
+	for _, event := range evts {
+		if err := insertOutboxEvent(ctx, tx, record.ID, event); err != nil {
+			return err
+		}
+	}
+
+	// 5. Commit transaction - both or neither
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// recordEventAggregateID returns the record an event is about, or fallback
+// for events that are not about a single record
+func recordEventAggregateID(event events.DomainEvent, fallback string) string {
+	switch e := event.(type) {
+	case events.TimeRecordCorrectedEvent:
+		return e.RecordID
+	case events.TimeRecordVoidedEvent:
+		return e.RecordID
+	case events.EmployeeCheckedOutEvent:
+		return e.RecordID
+	case events.TimeRecordStatusChangedEvent:
+		return e.RecordID
+	case events.EmployeeMissedCheckoutEvent:
+		return e.RecordID
+	case events.EmployeeCheckedInEvent:
+		return e.RecordID
+	case events.EmployeeOvertimeDetectedEvent:
+		return e.RecordID
 	}
+	return fallback
+}
 
-	// 2. Save the event to outbox table (same transaction)
+func insertOutboxEvent(ctx context.Context, tx *sql.Tx, aggregateID string, event events.DomainEvent) error {
 	eventPayload, err := json.Marshal(event)
 	if err != nil {
 		return fmt.Errorf("failed to marshal event: %w", err)
 	}
 
 	outboxQuery := `
-		INSERT INTO outbox_events (id, event_type, aggregate_id, payload, created_at, published)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO outbox_events (id, event_type, aggregate_id, payload, created_at, published, priority)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 	`
 
 	_, err = tx.ExecContext(ctx, outboxQuery,
 		uuid.New().String(),
 		event.EventType(),
-		record.ID,
+		aggregateID,
 		eventPayload,
-		time.Now(),
+		time.Now().UTC(),
 		false,
+		events.PriorityOf(event),
 	)
-
 	if err != nil {
 		return fmt.Errorf("failed to save outbox event: %w", err)
 	}
 
-	// 3. Commit transaction - both or neither
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
-	}
-
 	return nil
 }
 
 func (r *PostgresTimeRecordRepository) FindActiveByEmployeeID(ctx context.Context, employeeID string) (*entities.TimeRecord, error) {
 	query := `
-		SELECT id, employee_id, check_in_at, check_out_at, status, hours_worked
+		SELECT ` + timeRecordColumns + `
 		FROM time_records
-		WHERE employee_id = $1 AND status = $2
+		WHERE employee_id = $1 AND status = $2 AND deleted_at IS NULL
+			AND check_in_at = (SELECT check_in_at FROM open_check_ins WHERE employee_id = $1)
 		ORDER BY check_in_at DESC
 		LIMIT 1
 	`
 
-	var record entities.TimeRecord
-	err := r.db.QueryRowContext(ctx, query, employeeID, entities.StatusCheckedIn).Scan(
-		&record.ID,
-		&record.EmployeeID,
-		&record.CheckInAt,
-		&record.CheckOutAt,
-		&record.Status,
-		&record.HoursWorked,
-	)
+	record, err := scanTimeRecord(r.shards.For(employeeID).QueryRowContext(ctx, query, employeeID, entities.StatusCheckedIn))
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -141,59 +337,762 @@ func (r *PostgresTimeRecordRepository) FindActiveByEmployeeID(ctx context.Contex
 		return nil, fmt.Errorf("failed to find active record: %w", err)
 	}
 
-	return &record, nil
+	return record, nil
 }
 
 func (r *PostgresTimeRecordRepository) FindByID(ctx context.Context, id string) (*entities.TimeRecord, error) {
 	query := `
-		SELECT id, employee_id, check_in_at, check_out_at, status, hours_worked
+		SELECT ` + timeRecordColumns + `
 		FROM time_records
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
-	var record entities.TimeRecord
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&record.ID,
-		&record.EmployeeID,
-		&record.CheckInAt,
-		&record.CheckOutAt,
-		&record.Status,
-		&record.HoursWorked,
+	// The owning shard is unknown from the ID alone, so ask all of them
+	var (
+		mu    sync.Mutex
+		found *entities.TimeRecord
 	)
+	err := r.shards.fanOut(ctx, func(ctx context.Context, _ int, db *sql.DB) error {
+		record, err := scanTimeRecord(db.QueryRowContext(ctx, query, id))
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		found = record
+		mu.Unlock()
+		return nil
+	})
 
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("record not found")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find record: %w", err)
+	}
+
+	if found == nil {
+		return nil, repositories.ErrRecordNotFound
 	}
 
+	return found, nil
+}
+
+func (r *PostgresTimeRecordRepository) FindByEmployeeInRange(ctx context.Context, employeeID string, from, to time.Time) ([]*entities.TimeRecord, error) {
+	query := `
+		SELECT ` + timeRecordColumns + `
+		FROM time_records
+		WHERE employee_id = $1 AND deleted_at IS NULL
+			AND check_in_at < $3
+			AND check_in_at >= ` + rangeLowerBound + `
+			AND (check_out_at IS NULL OR check_out_at > $2)
+		ORDER BY check_in_at ASC, id ASC
+	`
+
+	rows, err := r.shards.For(employeeID).QueryContext(ctx, query, employeeID, from, to, maxShiftHours())
 	if err != nil {
-		return nil, fmt.Errorf("failed to find record: %w", err)
+		return nil, fmt.Errorf("failed to query records: %w", err)
 	}
+	return scanTimeRecords(rows)
+}
 
-	return &record, nil
+// rangeLowerBound keeps the range reads of an employee ($1) from $2 to the
+// partitions a shift of at most $4 hours could start in, or the month of the
+// employee's open record when it is older. LEAST ignores the NULL of an
+// employee who is not checked in.
+const rangeLowerBound = `LEAST($2::timestamptz - $4 * interval '1 hour',
+				(SELECT check_in_at FROM open_check_ins WHERE employee_id = $1))`
+
+// pageRangeLowerBound is rangeLowerBound with the hours in $8
+const pageRangeLowerBound = `LEAST($2::timestamptz - $8 * interval '1 hour',
+				(SELECT check_in_at FROM open_check_ins WHERE employee_id = $1))`
+
+// FindPageByEmployeeInRange seeks past the cursor on (check_in_at, id) instead
+// of using OFFSET, so deep pages cost the same as the first and rows inserted
+// behind the cursor do not shift later pages
+func (r *PostgresTimeRecordRepository) FindPageByEmployeeInRange(ctx context.Context, employeeID string, from, to time.Time, source entities.PunchSource, after *repositories.RecordCursor, limit int) ([]*entities.TimeRecord, error) {
+	query := `
+		SELECT ` + timeRecordColumns + `
+		FROM time_records
+		WHERE employee_id = $1 AND deleted_at IS NULL
+			AND check_in_at < $3
+			AND check_in_at >= ` + pageRangeLowerBound + `
+			AND (check_out_at IS NULL OR check_out_at > $2)
+			AND ($4::timestamptz IS NULL OR (check_in_at, id) > ($4, $5))
+			AND ($7 = '' OR source = $7 OR check_out_source = $7)
+		ORDER BY check_in_at ASC, id ASC
+		LIMIT $6
+	`
+
+	var afterAt *time.Time
+	afterID := ""
+	if after != nil {
+		afterAt = &after.CheckInAt
+		afterID = after.ID
+	}
+
+	rows, err := r.shards.For(employeeID).QueryContext(ctx, query, employeeID, from, to, afterAt, afterID, limit, source, maxShiftHours())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query records: %w", err)
+	}
+	return scanTimeRecords(rows)
+}
+
+func scanTimeRecords(rows *sql.Rows) ([]*entities.TimeRecord, error) {
+	defer rows.Close()
+
+	var records []*entities.TimeRecord
+	for rows.Next() {
+		record, err := scanTimeRecord(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan record: %w", err)
+		}
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
+
+// completedStatuses is the status filter for records that count as worked time
+func completedStatuses() interface{} {
+	statuses := make([]string, len(entities.CompletedStatuses))
+	for i, status := range entities.CompletedStatuses {
+		statuses[i] = string(status)
+	}
+	return pq.Array(statuses)
+}
+
+// FindActive returns everyone currently checked in, optionally only at one location
+func (r *PostgresTimeRecordRepository) FindActive(ctx context.Context, locationID string) ([]*entities.TimeRecord, error) {
+	query := `
+		SELECT ` + timeRecordColumns + `
+		FROM time_records
+		WHERE status = $1 AND deleted_at IS NULL AND ($2 = '' OR location_id = $2)
+			AND check_in_at >= (SELECT MIN(check_in_at) FROM open_check_ins)
+		ORDER BY check_in_at ASC
+	`
+
+	var (
+		mu      sync.Mutex
+		records []*entities.TimeRecord
+	)
+	err := r.shards.fanOut(ctx, func(ctx context.Context, _ int, db *sql.DB) error {
+		rows, err := db.QueryContext(ctx, query, entities.StatusCheckedIn, locationID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			record, err := scanTimeRecord(rows)
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			records = append(records, record)
+			mu.Unlock()
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query active records: %w", err)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].CheckInAt.Before(records[j].CheckInAt)
+	})
+
+	return records, nil
+}
+
+// SumHoursWorked returns the hours of completed records that started in [from, to)
+func (r *PostgresTimeRecordRepository) SumHoursWorked(ctx context.Context, employeeID string, from, to time.Time) (float64, error) {
+	// Records split at midnight only count the segments starting in the range,
+	// so an overnight shift adds to both of the days it touches
+	query := `
+		SELECT COALESCE(SUM(
+			CASE WHEN day_segments IS NULL THEN hours_worked
+			ELSE (
+				SELECT COALESCE(SUM((s->>'hours')::numeric), 0)
+				FROM jsonb_array_elements(day_segments) s
+				WHERE (s->>'start_at')::timestamptz >= $3 AND (s->>'start_at')::timestamptz < $4
+			) END
+		), 0)
+		FROM time_records
+		WHERE employee_id = $1 AND status = ANY($2) AND deleted_at IS NULL AND check_in_at < $4
+			AND (check_in_at >= $3 OR (day_segments IS NOT NULL AND check_out_at > $3))
+	`
+
+	var total float64
+	err := r.shards.For(employeeID).QueryRowContext(ctx, query, employeeID, completedStatuses(), from, to).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("failed to sum hours worked: %w", err)
+	}
+
+	return total, nil
+}
+
+// AggregateHours buckets records by the local time of their check-in, so an
+// overnight shift counts towards the period it started in
+func (r *PostgresTimeRecordRepository) AggregateHours(ctx context.Context, from, to time.Time, timeZone, period string, weekStart time.Weekday, source entities.PunchSource) ([]repositories.HoursAggregate, error) {
+	// date_trunc weeks start on Monday; shift the dates for other week starts
+	offset := 0
+	if period == hours.PeriodWeek {
+		offset = (int(time.Monday) - int(weekStart) + 7) % 7
+	}
+
+	query := `
+		SELECT employee_id, COALESCE(location_id, ''),
+			to_char(date_trunc($4, (check_in_at AT TIME ZONE $5) + make_interval(days => $6))
+				- make_interval(days => $6), 'YYYY-MM-DD') AS period_start,
+			COUNT(*), COALESCE(SUM(hours_worked), 0), COALESCE(SUM(regular_hours), 0), COALESCE(SUM(overtime_hours), 0)
+		FROM time_records
+		WHERE status = ANY($1) AND deleted_at IS NULL AND check_in_at >= $2 AND check_in_at < $3
+			AND ($7 = '' OR source = $7 OR check_out_source = $7)
+		GROUP BY 1, 2, 3
+	`
+
+	var (
+		mu         sync.Mutex
+		aggregates []repositories.HoursAggregate
+	)
+	err := r.shards.fanOut(ctx, func(ctx context.Context, _ int, db *sql.DB) error {
+		rows, err := db.QueryContext(ctx, query, completedStatuses(), from, to, period, timeZone, offset, source)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		var shardAggregates []repositories.HoursAggregate
+		for rows.Next() {
+			var a repositories.HoursAggregate
+			if err := rows.Scan(&a.EmployeeID, &a.LocationID, &a.PeriodStart, &a.Records, &a.HoursWorked, &a.RegularHours, &a.OvertimeHours); err != nil {
+				return err
+			}
+			shardAggregates = append(shardAggregates, a)
+		}
+
+		mu.Lock()
+		aggregates = append(aggregates, shardAggregates...)
+		mu.Unlock()
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate hours: %w", err)
+	}
+
+	return aggregates, nil
+}
+
+// SaveAllWithAudit updates several records and writes their audit trail and
+// outbox events atomically. All records must belong to the same shard.
+func (r *PostgresTimeRecordRepository) SaveAllWithAudit(ctx context.Context, records []*entities.TimeRecord, entries []*entities.AuditEntry, evts []events.DomainEvent) error {
+	return r.saveAllWithAudit(ctx, nil, records, entries, evts)
+}
+
+// lockLiveRecordQuery locks a record that is not deleted and reads the
+// columns a repair is computed from
+const lockLiveRecordQuery = `
+	SELECT check_in_at, check_out_at, status FROM time_records
+	WHERE id = $1 AND deleted_at IS NULL
+	FOR UPDATE
+`
+
+func (r *PostgresTimeRecordRepository) SaveRepair(ctx context.Context, read, records []*entities.TimeRecord, entries []*entities.AuditEntry, evts []events.DomainEvent) error {
+	return r.saveAllWithAudit(ctx, read, records, entries, evts)
+}
+
+// saveAllWithAudit is SaveAllWithAudit that first locks the records read,
+// which share the shard of records
+func (r *PostgresTimeRecordRepository) saveAllWithAudit(ctx context.Context, read, records []*entities.TimeRecord, entries []*entities.AuditEntry, evts []events.DomainEvent) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	shard := r.shards.Index(records[0].EmployeeID)
+	for _, record := range records[1:] {
+		if r.shards.Index(record.EmployeeID) != shard {
+			return fmt.Errorf("records span multiple shards")
+		}
+	}
+
+	tx, err := beginChangeTx(ctx, r.shards.All()[shard])
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback if not committed
+
+	if err := lockUnchanged(ctx, tx, lockLiveRecordQuery, read); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if err := upsertTimeRecord(ctx, tx, record); err != nil {
+			if isUniqueViolation(err) {
+				err = repositories.ErrConflict
+			}
+			return fmt.Errorf("failed to update time record %s: %w", record.ID, err)
+		}
+	}
+
+	if err := insertAuditEntries(ctx, tx, entries); err != nil {
+		return err
+	}
+
+	for _, event := range evts {
+		if err := insertOutboxEvent(ctx, tx, recordEventAggregateID(event, records[0].EmployeeID), event); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// lockUnchanged locks every record read with query, which selects the
+// check-in, check-out and status of a live record by ID, and fails with
+// ErrConflict when one of them was changed or deleted since it was read
+func lockUnchanged(ctx context.Context, tx *sql.Tx, query string, read []*entities.TimeRecord) error {
+	for _, record := range read {
+		var (
+			checkInAt  time.Time
+			checkOutAt *time.Time
+			status     entities.TimeRecordStatus
+		)
+		err := tx.QueryRowContext(ctx, query, record.ID).Scan(&checkInAt, &checkOutAt, &status)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("time record %s was deleted: %w", record.ID, repositories.ErrConflict)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to lock time record %s: %w", record.ID, err)
+		}
+		if !sameRecordState(record, checkInAt, checkOutAt, status) {
+			return fmt.Errorf("time record %s changed: %w", record.ID, repositories.ErrConflict)
+		}
+	}
+	return nil
+}
+
+// sameRecordState reports whether record still has the given times and status
+func sameRecordState(record *entities.TimeRecord, checkInAt time.Time, checkOutAt *time.Time, status entities.TimeRecordStatus) bool {
+	if !record.CheckInAt.Equal(checkInAt) || record.Status != status {
+		return false
+	}
+	if record.CheckOutAt == nil || checkOutAt == nil {
+		return record.CheckOutAt == nil && checkOutAt == nil
+	}
+	return record.CheckOutAt.Equal(*checkOutAt)
+}
+
+// SaveTransfer closes the old record before inserting the new one, so the
+// unique index on open records only rejects a third, concurrent record
+func (r *PostgresTimeRecordRepository) SaveTransfer(ctx context.Context, closed, opened *entities.TimeRecord, evts []events.DomainEvent) error {
+	tx, err := beginChangeTx(ctx, r.shards.For(closed.EmployeeID))
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback if not committed
+
+	for _, record := range []*entities.TimeRecord{closed, opened} {
+		if err := upsertTimeRecord(ctx, tx, record); err != nil {
+			if isUniqueViolation(err) {
+				err = repositories.ErrConflict
+			}
+			return fmt.Errorf("failed to save time record %s: %w", record.ID, err)
+		}
+		if record.Calculation != nil {
+			if err := insertHoursCalculation(ctx, tx, record); err != nil {
+				return err
+			}
+		}
+		for _, note := range record.Notes {
+			if err := insertNote(ctx, tx, note); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, event := range evts {
+		if err := insertOutboxEvent(ctx, tx, recordEventAggregateID(event, closed.ID), event); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// FindMissedCheckouts returns open records that started before openedBefore
+// and have no missed check-out flag yet
+func (r *PostgresTimeRecordRepository) FindMissedCheckouts(ctx context.Context, openedBefore time.Time, limit int) ([]*entities.TimeRecord, error) {
+	query := `
+		SELECT ` + timeRecordColumns + `
+		FROM time_records
+		WHERE status = $1 AND check_in_at < $2 AND missed_checkout_at IS NULL AND deleted_at IS NULL
+			AND check_in_at >= (SELECT MIN(check_in_at) FROM open_check_ins)
+		ORDER BY check_in_at ASC
+		LIMIT $3
+	`
+
+	var (
+		mu      sync.Mutex
+		records []*entities.TimeRecord
+	)
+	err := r.shards.fanOut(ctx, func(ctx context.Context, _ int, db *sql.DB) error {
+		rows, err := db.QueryContext(ctx, query, entities.StatusCheckedIn, openedBefore, limit)
+		if err != nil {
+			return err
+		}
+		shardRecords, err := scanTimeRecords(rows)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		records = append(records, shardRecords...)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query missed check-outs: %w", err)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].CheckInAt.Before(records[j].CheckInAt)
+	})
+	return records, nil
+}
+
+// MarkMissedCheckout sets missed_checkout_at on a record that is still open
+// and unflagged and stores the event in the same transaction, so concurrent
+// scanners publish one event per record
+func (r *PostgresTimeRecordRepository) MarkMissedCheckout(ctx context.Context, record *entities.TimeRecord, event events.DomainEvent) (bool, error) {
+	tx, err := beginChangeTx(ctx, r.shards.For(record.EmployeeID))
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback if not committed
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE time_records SET missed_checkout_at = NOW()
+		WHERE id = $1 AND check_in_at = $3 AND status = $2 AND missed_checkout_at IS NULL AND deleted_at IS NULL
+	`, record.ID, entities.StatusCheckedIn, record.CheckInAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to flag missed check-out: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return false, nil
+	}
+
+	if err := insertOutboxEvent(ctx, tx, record.ID, event); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// SoftDelete sets deleted_at on the record; the owning shard is unknown from
+// the ID alone, so it is tried on all of them
+func (r *PostgresTimeRecordRepository) SoftDelete(ctx context.Context, id string) error {
+	return r.setDeletedAt(ctx, id, `
+		UPDATE time_records SET deleted_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+	`)
+}
+
+func (r *PostgresTimeRecordRepository) Restore(ctx context.Context, id string) error {
+	return r.setDeletedAt(ctx, id, `
+		UPDATE time_records SET deleted_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NOT NULL
+	`)
+}
+
+func (r *PostgresTimeRecordRepository) setDeletedAt(ctx context.Context, id, query string) error {
+	var (
+		mu      sync.Mutex
+		updated int64
+	)
+	err := r.shards.fanOut(ctx, func(ctx context.Context, _ int, db *sql.DB) error {
+		tx, err := beginChangeTx(ctx, db)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback() // Rollback if not committed
+
+		result, err := tx.ExecContext(ctx, query, id)
+		if err != nil {
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		n, _ := result.RowsAffected()
+		mu.Lock()
+		updated += n
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("failed to restore record: %w", repositories.ErrConflict)
+		}
+		return fmt.Errorf("failed to update record: %w", err)
+	}
+	if updated == 0 {
+		return repositories.ErrRecordNotFound
+	}
+	return nil
+}
+
+// auditChainHeadQuery finds the last entry of a record's chain: the hashed
+// entry no other entry points back to
+const auditChainHeadQuery = `
+	SELECT a.hash
+	FROM audit_entries a
+	WHERE a.record_id = $1 AND a.hash IS NOT NULL
+		AND NOT EXISTS (SELECT 1 FROM audit_entries b WHERE b.record_id = a.record_id AND b.prev_hash = a.hash)
+	LIMIT 1
+`
+
+// insertAuditEntries appends the entries to their records' hash chains. An
+// advisory lock per record keeps concurrent writers from forking a chain.
+func insertAuditEntries(ctx context.Context, tx *sql.Tx, entries []*entities.AuditEntry) error {
+	query := `
+		INSERT INTO audit_entries (id, record_id, employee_id, action, actor, reason, before, after, created_at, prev_hash, hash)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+
+	for _, entry := range entries {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, entry.RecordID); err != nil {
+			return fmt.Errorf("failed to lock audit chain: %w", err)
+		}
+		var head string
+		err := tx.QueryRowContext(ctx, auditChainHeadQuery, entry.RecordID).Scan(&head)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("failed to find audit chain head: %w", err)
+		}
+		entry.Chain(head, auditChainKey())
+
+		before, err := json.Marshal(entry.Before)
+		if err != nil {
+			return fmt.Errorf("failed to marshal audit snapshot: %w", err)
+		}
+		after, err := json.Marshal(entry.After)
+		if err != nil {
+			return fmt.Errorf("failed to marshal audit snapshot: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, query,
+			entry.ID,
+			entry.RecordID,
+			entry.EmployeeID,
+			entry.Action,
+			entry.Actor,
+			entry.Reason,
+			before,
+			after,
+			entry.CreatedAt,
+			entry.PrevHash,
+			entry.Hash,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save audit entry: %w", err)
+		}
+	}
+
+	return nil
 }
 
 // Outbox Repository Implementation
+// Outbox rows live on the same shard as the record they were written with.
 type PostgresOutboxRepository struct {
-	db *sql.DB
+	shards *ShardSet
 }
 
 func NewPostgresOutboxRepository(db *sql.DB) *PostgresOutboxRepository {
-	return &PostgresOutboxRepository{db: db}
+	return &PostgresOutboxRepository{shards: NewShardSet(db)}
+}
+
+func NewShardedOutboxRepository(shards *ShardSet) *PostgresOutboxRepository {
+	return &PostgresOutboxRepository{shards: shards}
 }
 
-func (r *PostgresOutboxRepository) GetUnpublishedEvents(ctx context.Context, limit int) ([]repositories.OutboxEvent, error) {
+// SaveEvent stores an event that is not written with a record, e.g. a
+// reminder, on the shard of its employee, next to the employee's other events
+func (r *PostgresOutboxRepository) SaveEvent(ctx context.Context, event events.DomainEvent) error {
+	employeeID := events.EmployeeOf(event)
+	tx, err := r.shards.For(employeeID).BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback if not committed
+
+	if err := insertOutboxEvent(ctx, tx, recordEventAggregateID(event, employeeID), event); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ClaimUnpublished picks the most urgent events, in write order within a
+// priority (see outboxAgingCutoff), and claims them in the same statement, so
+// the row locks of SKIP LOCKED are held until the claim is written. Events
+// with an earlier pending event of their aggregate, by seq, wait for it to be
+// published; they live on the same shard, as an aggregate is one employee's.
+func (r *PostgresOutboxRepository) ClaimUnpublished(ctx context.Context, claimant string, limit int, lease time.Duration) ([]repositories.OutboxEvent, error) {
+	query := `
+		UPDATE outbox_events o
+		SET claimed_by = $3, claimed_until = NOW() + make_interval(secs => $4)
+		FROM (
+			SELECT id, CASE WHEN created_at < $2 THEN 0 ELSE priority END AS publish_rank
+			FROM outbox_events e
+			WHERE published = FALSE AND failed_at IS NULL AND (claimed_until IS NULL OR claimed_until < NOW())
+				AND NOT EXISTS (
+					SELECT 1 FROM outbox_events p
+					WHERE p.aggregate_id = e.aggregate_id AND p.published = FALSE AND p.seq < e.seq
+				)
+			ORDER BY publish_rank, seq ASC
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		) c
+		WHERE o.id = c.id
+		RETURNING o.id, o.event_type, o.aggregate_id, o.payload, o.created_at, o.published, o.retry_count, o.priority,
+			c.publish_rank
+	`
+
+	agedBefore := outboxAgingCutoff(time.Now().UTC())
+
+	var (
+		mu        sync.Mutex
+		allEvents []rankedOutboxEvent
+	)
+	err := r.shards.fanOut(ctx, func(ctx context.Context, _ int, db *sql.DB) error {
+		rows, err := db.QueryContext(ctx, query, limit, agedBefore, claimant, lease.Seconds())
+		if err != nil {
+			return fmt.Errorf("failed to claim unpublished events: %w", err)
+		}
+		defer rows.Close()
+
+		events, err := scanRankedOutboxEvents(rows)
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		allEvents = append(allEvents, events...)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeRankedOutboxEvents(allEvents), nil
+}
+
+// auditChainKey is the key audit entries are sealed with
+func auditChainKey() []byte {
+	if config.Cfg == nil {
+		return nil
+	}
+	return []byte(config.Cfg.Audit.ChainKey)
+}
+
+// maxShiftHours is how far before a range its reads look for completed
+// records that overlap it
+func maxShiftHours() int {
+	if config.Cfg == nil {
+		return 168
+	}
+	return config.Cfg.Database.MaxShiftHours
+}
+
+// outboxAgingCutoff is the creation time before which a pending event is
+// published ahead of every priority, so a steady flow of urgent events cannot
+// starve routine ones
+func outboxAgingCutoff(now time.Time) time.Time {
+	aging := defaultOutboxPriorityAging
+	if config.Cfg != nil {
+		aging = time.Duration(config.Cfg.Outbox.PriorityAgingSec) * time.Second
+	}
+	return now.Add(-aging)
+}
+
+const defaultOutboxPriorityAging = 5 * time.Minute
+
+// rankedOutboxEvent is a pending event with its publishing rank: its
+// priority, or 0 once it is older than the aging cutoff
+type rankedOutboxEvent struct {
+	repositories.OutboxEvent
+	rank int
+}
+
+func scanRankedOutboxEvents(rows *sql.Rows) ([]rankedOutboxEvent, error) {
+	var events []rankedOutboxEvent
+	for rows.Next() {
+		var event rankedOutboxEvent
+		err := rows.Scan(
+			&event.ID,
+			&event.EventType,
+			&event.AggregateID,
+			&event.Payload,
+			&event.CreatedAt,
+			&event.Published,
+			&event.RetryCount,
+			&event.Priority,
+			&event.rank,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// mergeRankedOutboxEvents merges the shards' events in rank then creation
+// order. All of them are kept: they are claimed, and events left out would
+// wait for their lease to run out.
+func mergeRankedOutboxEvents(ranked []rankedOutboxEvent) []repositories.OutboxEvent {
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].rank != ranked[j].rank {
+			return ranked[i].rank < ranked[j].rank
+		}
+		return ranked[i].CreatedAt.Before(ranked[j].CreatedAt)
+	})
+
+	events := make([]repositories.OutboxEvent, len(ranked))
+	for i, event := range ranked {
+		events[i] = event.OutboxEvent
+	}
+	return events
+}
+
+func (r *PostgresOutboxRepository) FindByAggregates(ctx context.Context, employeeID string, aggregateIDs []string) ([]repositories.OutboxEvent, error) {
+	if len(aggregateIDs) == 0 {
+		return nil, nil
+	}
+
 	query := `
 		SELECT id, event_type, aggregate_id, payload, created_at, published, retry_count
 		FROM outbox_events
-		WHERE published = FALSE AND event_type = $1
-		ORDER BY created_at ASC
-		LIMIT $2
-		FOR UPDATE SKIP LOCKED
+		WHERE aggregate_id = ANY($1)
+		ORDER BY created_at ASC, id ASC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, events.EventTypeEmployeeCheckedOut, limit)
+	rows, err := r.shards.For(employeeID).QueryContext(ctx, query, pq.Array(aggregateIDs))
 	if err != nil {
-		return nil, fmt.Errorf("failed to query unpublished events: %w", err)
+		return nil, fmt.Errorf("failed to query outbox events: %w", err)
 	}
 	defer rows.Close()
 
@@ -215,17 +1114,25 @@ func (r *PostgresOutboxRepository) GetUnpublishedEvents(ctx context.Context, lim
 		events = append(events, event)
 	}
 
-	return events, nil
+	return events, rows.Err()
 }
 
-func (r *PostgresOutboxRepository) MarkAsPublished(ctx context.Context, eventID string) error {
+func (r *PostgresOutboxRepository) MarkAsPublished(ctx context.Context, eventIDs []string) error {
+	if len(eventIDs) == 0 {
+		return nil
+	}
+
 	query := `
 		UPDATE outbox_events
-		SET published = TRUE, published_at = $1
-		WHERE id = $2
+		SET published = TRUE, published_at = $1, claimed_by = NULL, claimed_until = NULL
+		WHERE id = ANY($2)
 	`
 
-	_, err := r.db.ExecContext(ctx, query, time.Now(), eventID)
+	// Event IDs are unique across shards, so updating every shard is safe
+	err := r.shards.fanOut(ctx, func(ctx context.Context, _ int, db *sql.DB) error {
+		_, err := db.ExecContext(ctx, query, time.Now().UTC(), pq.Array(eventIDs))
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to mark event as published: %w", err)
 	}
@@ -233,16 +1140,340 @@ func (r *PostgresOutboxRepository) MarkAsPublished(ctx context.Context, eventID
 	return nil
 }
 
-func (r *PostgresOutboxRepository) IncrementRetryCount(ctx context.Context, eventID string, errorMsg string) error {
+func (r *PostgresOutboxRepository) IncrementRetryCount(ctx context.Context, eventID string, errorMsg string, maxRetries int) (bool, error) {
 	query := `
 		UPDATE outbox_events
-		SET retry_count = retry_count + 1, last_error = $1
+		SET retry_count = retry_count + 1, last_error = $1, claimed_by = NULL, claimed_until = NULL,
+			failed_at = CASE WHEN $3::int > 0 AND retry_count + 1 >= $3::int THEN NOW() ELSE failed_at END
 		WHERE id = $2
+		RETURNING failed_at IS NOT NULL
+	`
+
+	var failed atomic.Bool
+	err := r.shards.fanOut(ctx, func(ctx context.Context, _ int, db *sql.DB) error {
+		var shardFailed bool
+		err := db.QueryRowContext(ctx, query, errorMsg, eventID, maxRetries).Scan(&shardFailed)
+		if err == sql.ErrNoRows {
+			return nil // The event lives on another shard
+		}
+		if err != nil {
+			return err
+		}
+		failed.Store(shardFailed)
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to increment retry count: %w", err)
+	}
+
+	return failed.Load(), nil
+}
+
+func (r *PostgresOutboxRepository) ReleaseClaims(ctx context.Context, eventIDs []string) error {
+	if len(eventIDs) == 0 {
+		return nil
+	}
+
+	query := `UPDATE outbox_events SET claimed_by = NULL, claimed_until = NULL WHERE id = ANY($1)`
+	err := r.shards.fanOut(ctx, func(ctx context.Context, _ int, db *sql.DB) error {
+		_, err := db.ExecContext(ctx, query, pq.Array(eventIDs))
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to release outbox claims: %w", err)
+	}
+
+	return nil
+}
+
+// FindFailed reads the next page from every shard and keeps the first limit
+// events overall. created_at is a UTC timestamp without time zone, like
+// audit_entries.created_at in search.
+func (r *PostgresOutboxRepository) FindFailed(ctx context.Context, filter repositories.OutboxFailureFilter, after *repositories.OutboxCursor, limit int) ([]repositories.FailedOutboxEvent, error) {
+	query := `
+		SELECT o.id, o.event_type, o.aggregate_id, COALESCE(t.employee_id, a.employee_id, ''), o.created_at,
+			o.retry_count, COALESCE(o.last_error, ''), o.failed_at
+		FROM outbox_events o
+		LEFT JOIN time_records t ON t.id = o.aggregate_id
+		LEFT JOIN approvals a ON a.id = o.aggregate_id
+		WHERE o.published = FALSE AND o.retry_count >= $1
+			AND ($2::timestamptz IS NULL OR o.created_at >= ($2::timestamptz AT TIME ZONE 'UTC'))
+			AND ($3::timestamptz IS NULL OR o.created_at < ($3::timestamptz AT TIME ZONE 'UTC'))
+			AND ($4 = '' OR o.event_type = $4)
+			AND ($5::timestamptz IS NULL OR (o.created_at, o.id) > ($5::timestamptz AT TIME ZONE 'UTC', $6))
+			AND ($8 <> 'failed' OR o.failed_at IS NOT NULL)
+			AND ($8 <> 'pending' OR o.failed_at IS NULL)
+		ORDER BY o.created_at ASC, o.id ASC
+		LIMIT $7
+	`
+
+	var afterAt *time.Time
+	afterID := ""
+	if after != nil {
+		afterAt = &after.CreatedAt
+		afterID = after.ID
+	}
+
+	var (
+		mu     sync.Mutex
+		failed []repositories.FailedOutboxEvent
+	)
+	err := r.shards.fanOut(ctx, func(ctx context.Context, _ int, db *sql.DB) error {
+		rows, err := db.QueryContext(ctx, query, filter.MinRetries, filter.From, filter.To, filter.EventType, afterAt, afterID, limit, filter.Status)
+		if err != nil {
+			return err
+		}
+		shardFailed, err := scanFailedOutboxEvents(rows)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		failed = append(failed, shardFailed...)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query failed outbox events: %w", err)
+	}
+
+	return firstFailedOutboxEvents(failed, limit), nil
+}
+
+func (r *PostgresOutboxRepository) Requeue(ctx context.Context, eventIDs []string) (int, error) {
+	if len(eventIDs) == 0 {
+		return 0, nil
+	}
+
+	query := `UPDATE outbox_events SET failed_at = NULL, retry_count = 0 WHERE published = FALSE AND id = ANY($1)`
+	return outboxRequeue(ctx, r.shards, query, pq.Array(eventIDs))
+}
+
+func (r *PostgresOutboxRepository) RequeueFailed(ctx context.Context, eventType string) (int, error) {
+	query := `
+		UPDATE outbox_events SET failed_at = NULL, retry_count = 0
+		WHERE published = FALSE AND failed_at IS NOT NULL AND ($1 = '' OR event_type = $1)
+	`
+	return outboxRequeue(ctx, r.shards, query, eventType)
+}
+
+// outboxRequeue runs a requeue statement on every shard and sums the events
+// it reset
+func outboxRequeue(ctx context.Context, shards *ShardSet, query string, args ...interface{}) (int, error) {
+	var requeued atomic.Int64
+	err := shards.fanOut(ctx, func(ctx context.Context, _ int, db *sql.DB) error {
+		res, err := db.ExecContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		requeued.Add(n)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue outbox events: %w", err)
+	}
+
+	return int(requeued.Load()), nil
+}
+
+func scanFailedOutboxEvents(rows *sql.Rows) ([]repositories.FailedOutboxEvent, error) {
+	defer rows.Close()
+
+	var failed []repositories.FailedOutboxEvent
+	for rows.Next() {
+		var (
+			event    repositories.FailedOutboxEvent
+			failedAt sql.NullTime
+		)
+		err := rows.Scan(&event.ID, &event.EventType, &event.AggregateID, &event.EmployeeID, &event.CreatedAt,
+			&event.RetryCount, &event.LastError, &failedAt)
+		if err != nil {
+			return nil, err
+		}
+		event.CreatedAt = event.CreatedAt.UTC()
+		if failedAt.Valid {
+			at := failedAt.Time.UTC()
+			event.FailedAt = &at
+		}
+		failed = append(failed, event)
+	}
+	return failed, rows.Err()
+}
+
+// firstFailedOutboxEvents orders the shards' pages by (created_at, id) and
+// keeps the first limit events
+func firstFailedOutboxEvents(failed []repositories.FailedOutboxEvent, limit int) []repositories.FailedOutboxEvent {
+	sort.Slice(failed, func(i, j int) bool {
+		if !failed[i].CreatedAt.Equal(failed[j].CreatedAt) {
+			return failed[i].CreatedAt.Before(failed[j].CreatedAt)
+		}
+		return failed[i].ID < failed[j].ID
+	})
+	if len(failed) > limit {
+		failed = failed[:limit]
+	}
+	return failed
+}
+
+// Backlog counts the pending and failed events on every shard
+func (r *PostgresOutboxRepository) Backlog(ctx context.Context) (repositories.OutboxBacklog, error) {
+	query := `
+		SELECT COUNT(*) FILTER (WHERE failed_at IS NULL), MIN(created_at) FILTER (WHERE failed_at IS NULL),
+			COUNT(*) FILTER (WHERE failed_at IS NOT NULL)
+		FROM outbox_events
+		WHERE published = FALSE
+	`
+
+	return outboxBacklog(ctx, r.shards, query)
+}
+
+func outboxBacklog(ctx context.Context, shards *ShardSet, query string, args ...interface{}) (repositories.OutboxBacklog, error) {
+	var (
+		mu      sync.Mutex
+		backlog repositories.OutboxBacklog
+	)
+	err := shards.fanOut(ctx, func(ctx context.Context, _ int, db *sql.DB) error {
+		var (
+			pending, failed int
+			oldest          sql.NullTime
+		)
+		if err := db.QueryRowContext(ctx, query, args...).Scan(&pending, &oldest, &failed); err != nil {
+			return err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		backlog.Pending += pending
+		backlog.Failed += failed
+		if oldest.Valid && (backlog.OldestAt == nil || oldest.Time.Before(*backlog.OldestAt)) {
+			at := oldest.Time.UTC()
+			backlog.OldestAt = &at
+		}
+		return nil
+	})
+	if err != nil {
+		return repositories.OutboxBacklog{}, fmt.Errorf("failed to count outbox backlog: %w", err)
+	}
+
+	return backlog, nil
+}
+
+// PrunePublished deletes the oldest published events first; published_at is
+// written in UTC by MarkAsPublished, so the cutoff is too
+func (r *PostgresOutboxRepository) PrunePublished(ctx context.Context, publishedBefore time.Time, limit int) (int, error) {
+	query := `
+		DELETE FROM outbox_events
+		WHERE id IN (
+			SELECT id FROM outbox_events
+			WHERE published = TRUE AND published_at < $1
+			ORDER BY published_at
+			LIMIT $2
+		)
 	`
 
-	_, err := r.db.ExecContext(ctx, query, errorMsg, eventID)
+	return pruneOutbox(ctx, r.shards, query, publishedBefore.UTC(), limit)
+}
+
+func pruneOutbox(ctx context.Context, shards *ShardSet, query string, args ...interface{}) (int, error) {
+	var (
+		mu      sync.Mutex
+		deleted int
+	)
+	err := shards.fanOut(ctx, func(ctx context.Context, _ int, db *sql.DB) error {
+		result, err := db.ExecContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		deleted += int(n)
+		return nil
+	})
+	if err != nil {
+		// Batches deleted on the other shards stay deleted
+		return deleted, fmt.Errorf("failed to prune outbox events: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// Size reads the planner's row estimate, kept by autovacuum, rather than
+// counting a table that may be large
+func (r *PostgresOutboxRepository) Size(ctx context.Context) (repositories.OutboxSize, error) {
+	query := `
+		SELECT GREATEST(reltuples, 0)::BIGINT, pg_total_relation_size(oid)
+		FROM pg_class
+		WHERE oid = 'outbox_events'::regclass
+	`
+
+	return outboxSize(ctx, r.shards, query)
+}
+
+func outboxSize(ctx context.Context, shards *ShardSet, query string) (repositories.OutboxSize, error) {
+	var (
+		mu   sync.Mutex
+		size repositories.OutboxSize
+	)
+	err := shards.fanOut(ctx, func(ctx context.Context, _ int, db *sql.DB) error {
+		var rows, bytes int64
+		if err := db.QueryRowContext(ctx, query).Scan(&rows, &bytes); err != nil {
+			return err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		size.Rows += rows
+		size.Bytes += bytes
+		return nil
+	})
+	if err != nil {
+		return repositories.OutboxSize{}, fmt.Errorf("failed to measure outbox: %w", err)
+	}
+
+	return size, nil
+}
+
+// insertHoursCalculation stores the inputs and outputs of the record's hours
+// calculation, replacing an earlier one for the same record
+func insertHoursCalculation(ctx context.Context, tx *sql.Tx, record *entities.TimeRecord) error {
+	calc := record.Calculation
+
+	inputs, err := json.Marshal(calc.Inputs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal hours calculation inputs: %w", err)
+	}
+	outputs, err := json.Marshal(calc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal hours calculation: %w", err)
+	}
+
+	query := `
+		INSERT INTO hours_calculations (record_id, employee_id, inputs, outputs, gross_hours, payable_hours, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (record_id) DO UPDATE SET
+			inputs = EXCLUDED.inputs,
+			outputs = EXCLUDED.outputs,
+			gross_hours = EXCLUDED.gross_hours,
+			payable_hours = EXCLUDED.payable_hours,
+			created_at = EXCLUDED.created_at
+	`
+
+	_, err = tx.ExecContext(ctx, query,
+		record.ID,
+		record.EmployeeID,
+		inputs,
+		outputs,
+		calc.GrossHours,
+		calc.PayableHours,
+		time.Now(),
+	)
 	if err != nil {
-		return fmt.Errorf("failed to increment retry count: %w", err)
+		return fmt.Errorf("failed to save hours calculation: %w", err)
 	}
 
 	return nil
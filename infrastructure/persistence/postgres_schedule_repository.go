@@ -0,0 +1,93 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+)
+
+type PostgresScheduleRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresScheduleRepository(db *sql.DB) *PostgresScheduleRepository {
+	return &PostgresScheduleRepository{db: db}
+}
+
+const scheduledShiftColumns = `employee_id, weekday, start_time, end_time, COALESCE(location_id, ''), time_zone`
+
+func (r *PostgresScheduleRepository) ReplaceForEmployee(ctx context.Context, employeeID string, shifts []*entities.ScheduledShift) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback if not committed
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM work_schedules WHERE employee_id = $1`, employeeID); err != nil {
+		return fmt.Errorf("failed to clear schedule: %w", err)
+	}
+
+	query := `
+		INSERT INTO work_schedules (employee_id, weekday, start_time, end_time, location_id, time_zone)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6)
+	`
+	for _, shift := range shifts {
+		_, err := tx.ExecContext(ctx, query, employeeID, int(shift.Weekday), shift.Start, shift.End, shift.LocationID, shift.TimeZone)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return fmt.Errorf("failed to save shift: two shifts start at %s on weekday %d", shift.Start, shift.Weekday)
+			}
+			return fmt.Errorf("failed to save shift: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresScheduleRepository) FindByEmployee(ctx context.Context, employeeID string) ([]*entities.ScheduledShift, error) {
+	query := `
+		SELECT ` + scheduledShiftColumns + `
+		FROM work_schedules
+		WHERE employee_id = $1
+		ORDER BY weekday, start_time
+	`
+	return r.query(ctx, query, employeeID)
+}
+
+func (r *PostgresScheduleRepository) FindByWeekday(ctx context.Context, weekday time.Weekday) ([]*entities.ScheduledShift, error) {
+	query := `
+		SELECT ` + scheduledShiftColumns + `
+		FROM work_schedules
+		WHERE weekday = $1
+		ORDER BY employee_id, start_time
+	`
+	return r.query(ctx, query, int(weekday))
+}
+
+func (r *PostgresScheduleRepository) query(ctx context.Context, query string, args ...interface{}) ([]*entities.ScheduledShift, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schedules: %w", err)
+	}
+	defer rows.Close()
+
+	var shifts []*entities.ScheduledShift
+	for rows.Next() {
+		var (
+			shift   entities.ScheduledShift
+			weekday int
+		)
+		if err := rows.Scan(&shift.EmployeeID, &weekday, &shift.Start, &shift.End, &shift.LocationID, &shift.TimeZone); err != nil {
+			return nil, fmt.Errorf("failed to scan shift: %w", err)
+		}
+		shift.Weekday = time.Weekday(weekday)
+		shifts = append(shifts, &shift)
+	}
+	return shifts, rows.Err()
+}
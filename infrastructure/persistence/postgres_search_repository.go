@@ -0,0 +1,179 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+)
+
+// PostgresSearchRepository searches time records and audit entries on every
+// shard. Partial matches use ILIKE, served by the trigram indexes.
+type PostgresSearchRepository struct {
+	shards *ShardSet
+}
+
+func NewShardedSearchRepository(shards *ShardSet) *PostgresSearchRepository {
+	return &PostgresSearchRepository{shards: shards}
+}
+
+const searchRecordsQuery = `
+	SELECT r.id, r.employee_id, r.check_in_at, r.status, COALESCE(r.device_id, '')
+	FROM time_records r
+	WHERE r.deleted_at IS NULL
+		AND ($1 = '' OR r.employee_id ILIKE $1 OR r.device_id ILIKE $1 OR r.check_out_device_id ILIKE $1
+			OR EXISTS (SELECT 1 FROM time_record_notes n WHERE n.record_id = r.id AND n.body ILIKE $1))
+		AND ($2 = '' OR r.employee_id ILIKE $2)
+		AND ($3 = '' OR r.device_id ILIKE $3 OR r.check_out_device_id ILIKE $3)
+		AND ($4 = '' OR EXISTS (SELECT 1 FROM time_record_notes n WHERE n.record_id = r.id AND n.body ILIKE $4))
+		AND ($5::timestamptz IS NULL OR r.check_in_at >= $5)
+		AND ($6::timestamptz IS NULL OR r.check_in_at < $6)
+		AND ($7::timestamptz IS NULL OR (r.check_in_at, r.id) < ($7, $8))
+	ORDER BY r.check_in_at DESC, r.id DESC
+	LIMIT $9
+`
+
+// audit_entries.created_at holds UTC without a zone, so bounds are converted
+// to UTC before comparing
+const searchAuditQuery = `
+	SELECT a.id, a.employee_id, a.record_id, a.created_at, a.action, a.actor, COALESCE(a.reason, '')
+	FROM audit_entries a
+	WHERE ($1 = '' OR a.employee_id ILIKE $1 OR a.reason ILIKE $1)
+		AND ($2 = '' OR a.employee_id ILIKE $2)
+		AND ($3 = '' OR EXISTS (SELECT 1 FROM time_records r WHERE r.id = a.record_id
+			AND (r.device_id ILIKE $3 OR r.check_out_device_id ILIKE $3)))
+		AND ($4 = '' OR EXISTS (SELECT 1 FROM time_record_notes n WHERE n.record_id = a.record_id AND n.body ILIKE $4))
+		AND ($5::timestamptz IS NULL OR a.created_at >= ($5::timestamptz AT TIME ZONE 'UTC'))
+		AND ($6::timestamptz IS NULL OR a.created_at < ($6::timestamptz AT TIME ZONE 'UTC'))
+		AND ($7::timestamptz IS NULL OR (a.created_at, a.id) < ($7::timestamptz AT TIME ZONE 'UTC', $8))
+	ORDER BY a.created_at DESC, a.id DESC
+	LIMIT $9
+`
+
+// Search asks every shard for up to limit hits of each type and keeps the
+// newest limit of them
+func (r *PostgresSearchRepository) Search(ctx context.Context, query repositories.SearchQuery, after *repositories.SearchCursor, limit int) ([]repositories.SearchHit, error) {
+	var from, to, afterAt *time.Time
+	if !query.From.IsZero() {
+		from = &query.From
+	}
+	if !query.To.IsZero() {
+		to = &query.To
+	}
+	afterID := ""
+	if after != nil {
+		afterAt = &after.At
+		afterID = after.ID
+	}
+	args := []interface{}{
+		likePattern(query.Text), likePattern(query.EmployeeID), likePattern(query.DeviceID), likePattern(query.Note),
+		from, to, afterAt, afterID, limit,
+	}
+
+	var (
+		mu   sync.Mutex
+		hits []repositories.SearchHit
+	)
+	err := r.shards.fanOut(ctx, func(ctx context.Context, _ int, db *sql.DB) error {
+		var shardHits []repositories.SearchHit
+		if searchesType(query.Types, repositories.SearchTypeRecord) {
+			found, err := searchRecords(ctx, db, args)
+			if err != nil {
+				return err
+			}
+			shardHits = append(shardHits, found...)
+		}
+		if searchesType(query.Types, repositories.SearchTypeAudit) {
+			found, err := searchAudit(ctx, db, args)
+			if err != nil {
+				return err
+			}
+			shardHits = append(shardHits, found...)
+		}
+
+		mu.Lock()
+		hits = append(hits, shardHits...)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if !hits[i].At.Equal(hits[j].At) {
+			return hits[i].At.After(hits[j].At)
+		}
+		return hits[i].ID > hits[j].ID
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+func searchRecords(ctx context.Context, db *sql.DB, args []interface{}) ([]repositories.SearchHit, error) {
+	rows, err := db.QueryContext(ctx, searchRecordsQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hits []repositories.SearchHit
+	for rows.Next() {
+		hit := repositories.SearchHit{Type: repositories.SearchTypeRecord}
+		if err := rows.Scan(&hit.ID, &hit.EmployeeID, &hit.At, &hit.Status, &hit.DeviceID); err != nil {
+			return nil, err
+		}
+		hit.RecordID = hit.ID
+		hit.At = hit.At.UTC()
+		hits = append(hits, hit)
+	}
+	return hits, rows.Err()
+}
+
+func searchAudit(ctx context.Context, db *sql.DB, args []interface{}) ([]repositories.SearchHit, error) {
+	rows, err := db.QueryContext(ctx, searchAuditQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hits []repositories.SearchHit
+	for rows.Next() {
+		hit := repositories.SearchHit{Type: repositories.SearchTypeAudit}
+		if err := rows.Scan(&hit.ID, &hit.EmployeeID, &hit.RecordID, &hit.At, &hit.Action, &hit.Actor, &hit.Reason); err != nil {
+			return nil, err
+		}
+		hit.At = hit.At.UTC()
+		hits = append(hits, hit)
+	}
+	return hits, rows.Err()
+}
+
+func searchesType(types []string, t string) bool {
+	if len(types) == 0 {
+		return true
+	}
+	for _, candidate := range types {
+		if candidate == t {
+			return true
+		}
+	}
+	return false
+}
+
+// likePattern matches s anywhere in a value, with ILIKE wildcards in s
+// escaped. Empty stays empty so the filter is skipped.
+func likePattern(s string) string {
+	if s == "" {
+		return ""
+	}
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+	return "%" + escaped + "%"
+}
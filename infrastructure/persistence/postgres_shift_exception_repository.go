@@ -0,0 +1,97 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/events"
+)
+
+type PostgresShiftExceptionRepository struct {
+	shards *ShardSet
+}
+
+// NewShardedShiftExceptionRepository stores exceptions on the shard owning the employee's records
+func NewShardedShiftExceptionRepository(shards *ShardSet) *PostgresShiftExceptionRepository {
+	return &PostgresShiftExceptionRepository{shards: shards}
+}
+
+func (r *PostgresShiftExceptionRepository) SaveWithEvent(ctx context.Context, exception *entities.ShiftException, event events.DomainEvent) (bool, error) {
+	tx, err := r.shards.For(exception.EmployeeID).BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback if not committed
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO shift_exceptions (id, employee_id, manager_id, kind, business_date, scheduled_start, scheduled_end,
+			location_id, time_zone, created_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5::date, $6, $7, NULLIF($8, ''), $9, $10)
+		ON CONFLICT (employee_id, scheduled_start, kind) DO NOTHING
+	`, exception.ID, exception.EmployeeID, exception.ManagerID, exception.Kind, exception.BusinessDate,
+		exception.ScheduledStart, exception.ScheduledEnd, exception.LocationID, exception.TimeZone, exception.CreatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to save shift exception: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return false, nil
+	}
+
+	if err := insertOutboxEvent(ctx, tx, exception.ID, event); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+func (r *PostgresShiftExceptionRepository) FindByDate(ctx context.Context, date string) ([]*entities.ShiftException, error) {
+	query := `
+		SELECT id, employee_id, COALESCE(manager_id, ''), kind, to_char(business_date, 'YYYY-MM-DD'),
+			scheduled_start, scheduled_end, COALESCE(location_id, ''), time_zone, created_at
+		FROM shift_exceptions
+		WHERE business_date = $1::date
+	`
+
+	var (
+		mu         sync.Mutex
+		exceptions []*entities.ShiftException
+	)
+	err := r.shards.fanOut(ctx, func(ctx context.Context, _ int, db *sql.DB) error {
+		rows, err := db.QueryContext(ctx, query, date)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var e entities.ShiftException
+			err := rows.Scan(&e.ID, &e.EmployeeID, &e.ManagerID, &e.Kind, &e.BusinessDate,
+				&e.ScheduledStart, &e.ScheduledEnd, &e.LocationID, &e.TimeZone, &e.CreatedAt)
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			exceptions = append(exceptions, &e)
+			mu.Unlock()
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query shift exceptions: %w", err)
+	}
+
+	sort.SliceStable(exceptions, func(i, j int) bool {
+		if !exceptions[i].ScheduledStart.Equal(exceptions[j].ScheduledStart) {
+			return exceptions[i].ScheduledStart.Before(exceptions[j].ScheduledStart)
+		}
+		return exceptions[i].EmployeeID < exceptions[j].EmployeeID
+	})
+	return exceptions, nil
+}
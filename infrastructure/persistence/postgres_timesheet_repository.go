@@ -0,0 +1,191 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/events"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+
+	"github.com/lib/pq"
+)
+
+type PostgresTimesheetRepository struct {
+	shards *ShardSet
+}
+
+// NewShardedTimesheetRepository stores timesheets on the shard owning the employee's records
+func NewShardedTimesheetRepository(shards *ShardSet) *PostgresTimesheetRepository {
+	return &PostgresTimesheetRepository{shards: shards}
+}
+
+const timesheetColumns = `id, employee_id, to_char(period_start, 'YYYY-MM-DD'), to_char(period_end, 'YYYY-MM-DD'), status,
+	record_ids, hours_worked, regular_hours, overtime_hours, submitted_at, COALESCE(decided_by, ''),
+	COALESCE(decision_comment, ''), decided_at, closed_at, created_at, updated_at`
+
+const upsertTimesheetQuery = `
+	INSERT INTO timesheets (id, employee_id, period_start, period_end, status, record_ids, hours_worked, regular_hours,
+		overtime_hours, submitted_at, decided_by, decision_comment, decided_at, closed_at, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, ''), NULLIF($12, ''), $13, $14, $15, $16)
+	ON CONFLICT (id) DO UPDATE SET
+		status = EXCLUDED.status,
+		record_ids = EXCLUDED.record_ids,
+		hours_worked = EXCLUDED.hours_worked,
+		regular_hours = EXCLUDED.regular_hours,
+		overtime_hours = EXCLUDED.overtime_hours,
+		submitted_at = EXCLUDED.submitted_at,
+		decided_by = EXCLUDED.decided_by,
+		decision_comment = EXCLUDED.decision_comment,
+		decided_at = EXCLUDED.decided_at,
+		closed_at = EXCLUDED.closed_at,
+		updated_at = EXCLUDED.updated_at
+`
+
+func scanTimesheet(row rowScanner) (*entities.Timesheet, error) {
+	var (
+		sheet     entities.Timesheet
+		recordIDs pq.StringArray
+	)
+	err := row.Scan(
+		&sheet.ID,
+		&sheet.EmployeeID,
+		&sheet.PeriodStart,
+		&sheet.PeriodEnd,
+		&sheet.Status,
+		&recordIDs,
+		&sheet.HoursWorked,
+		&sheet.RegularHours,
+		&sheet.OvertimeHours,
+		&sheet.SubmittedAt,
+		&sheet.DecidedBy,
+		&sheet.DecisionComment,
+		&sheet.DecidedAt,
+		&sheet.ClosedAt,
+		&sheet.CreatedAt,
+		&sheet.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	sheet.RecordIDs = []string(recordIDs)
+	return &sheet, nil
+}
+
+func upsertTimesheet(ctx context.Context, db execer, sheet *entities.Timesheet) error {
+	_, err := db.ExecContext(ctx, upsertTimesheetQuery,
+		sheet.ID,
+		sheet.EmployeeID,
+		sheet.PeriodStart,
+		sheet.PeriodEnd,
+		sheet.Status,
+		pq.Array(sheet.RecordIDs),
+		sheet.HoursWorked,
+		sheet.RegularHours,
+		sheet.OvertimeHours,
+		sheet.SubmittedAt,
+		sheet.DecidedBy,
+		sheet.DecisionComment,
+		sheet.DecidedAt,
+		sheet.ClosedAt,
+		sheet.CreatedAt,
+		sheet.UpdatedAt,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("failed to save timesheet: %w", repositories.ErrConflict)
+		}
+		return fmt.Errorf("failed to save timesheet: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresTimesheetRepository) Save(ctx context.Context, sheet *entities.Timesheet) error {
+	return upsertTimesheet(ctx, r.shards.For(sheet.EmployeeID), sheet)
+}
+
+func (r *PostgresTimesheetRepository) FindByPeriod(ctx context.Context, employeeID, periodStart string) (*entities.Timesheet, error) {
+	query := `
+		SELECT ` + timesheetColumns + `
+		FROM timesheets
+		WHERE employee_id = $1 AND period_start = $2
+	`
+
+	sheet, err := scanTimesheet(r.shards.For(employeeID).QueryRowContext(ctx, query, employeeID, periodStart))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find timesheet: %w", err)
+	}
+	return sheet, nil
+}
+
+func (r *PostgresTimesheetRepository) FindByID(ctx context.Context, id string) (*entities.Timesheet, error) {
+	query := `
+		SELECT ` + timesheetColumns + `
+		FROM timesheets
+		WHERE id = $1
+	`
+
+	// The owning shard is unknown from the ID alone, so ask all of them
+	var (
+		mu    sync.Mutex
+		found *entities.Timesheet
+	)
+	err := r.shards.fanOut(ctx, func(ctx context.Context, _ int, db *sql.DB) error {
+		sheet, err := scanTimesheet(db.QueryRowContext(ctx, query, id))
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		found = sheet
+		mu.Unlock()
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to find timesheet: %w", err)
+	}
+
+	return found, nil
+}
+
+func (r *PostgresTimesheetRepository) SaveClosed(ctx context.Context, sheet *entities.Timesheet, records []*entities.TimeRecord, entries []*entities.AuditEntry, evts []events.DomainEvent) error {
+	tx, err := beginChangeTx(ctx, r.shards.For(sheet.EmployeeID))
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback if not committed
+
+	if err := upsertTimesheet(ctx, tx, sheet); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if err := upsertTimeRecord(ctx, tx, record); err != nil {
+			return fmt.Errorf("failed to lock time record %s: %w", record.ID, err)
+		}
+	}
+
+	if err := insertAuditEntries(ctx, tx, entries); err != nil {
+		return err
+	}
+
+	for _, event := range evts {
+		if err := insertOutboxEvent(ctx, tx, recordEventAggregateID(event, sheet.ID), event); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"sync/atomic"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"github.com/leo-andrei/check-in-service/infrastructure/metrics"
+	"go.uber.org/zap"
+)
+
+// dbtx is the subset of *sql.DB repositories need, so a repository can run
+// against a plain pool or a ReadReplica
+type dbtx interface {
+	execer
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// replicaLagQuery reports how far the replica is behind, in seconds. A replica
+// that has replayed everything it received is treated as current even when the
+// last replayed transaction is old (an idle primary).
+const replicaLagQuery = `
+	SELECT CASE
+		WHEN NOT pg_is_in_recovery() THEN 0
+		WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+	END
+`
+
+// ReadReplica sends reads to a read-only replica of the primary database and
+// falls back to the primary while the replica is unreachable or lags more than
+// maxLag. Writes always go to the primary. It is meant for consumer-side
+// enrichment lookups that can tolerate slightly stale data.
+type ReadReplica struct {
+	primary *sql.DB
+	replica *sql.DB
+	maxLag  time.Duration
+	healthy atomic.Bool
+}
+
+// NewReadReplica starts out on the primary until the first lag check passes
+func NewReadReplica(primary, replica *sql.DB, maxLag time.Duration) *ReadReplica {
+	return &ReadReplica{
+		primary: primary,
+		replica: replica,
+		maxLag:  maxLag,
+	}
+}
+
+// DB returns the database reads should currently use
+func (r *ReadReplica) DB() *sql.DB {
+	if r.replica != nil && r.healthy.Load() {
+		return r.replica
+	}
+	return r.primary
+}
+
+func (r *ReadReplica) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return r.DB().QueryContext(ctx, query, args...)
+}
+
+func (r *ReadReplica) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return r.DB().QueryRowContext(ctx, query, args...)
+}
+
+func (r *ReadReplica) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return r.primary.ExecContext(ctx, query, args...)
+}
+
+// Monitor checks the replica lag every interval until ctx is done
+func (r *ReadReplica) Monitor(ctx context.Context, interval time.Duration) {
+	if r.replica == nil {
+		return
+	}
+
+	r.check(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.check(ctx)
+		}
+	}
+}
+
+func (r *ReadReplica) check(ctx context.Context) {
+	checkCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var lagSeconds float64
+	err := r.replica.QueryRowContext(checkCtx, replicaLagQuery).Scan(&lagSeconds)
+	lag := time.Duration(lagSeconds * float64(time.Second))
+	healthy := err == nil && lag <= r.maxLag
+
+	if err == nil {
+		metrics.Gauge("db.replica.lag_seconds", lagSeconds)
+	}
+
+	if r.healthy.Swap(healthy) != healthy {
+		if healthy {
+			config.Logger.Info("Read replica in use", zap.Duration("lag", lag))
+		} else {
+			config.Logger.Warn("Read replica unavailable or lagging, falling back to primary",
+				zap.Duration("lag", lag), zap.Duration("max_lag", r.maxLag), zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package persistence
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"github.com/leo-andrei/check-in-service/infrastructure/metrics"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// RetryPolicy retries operations failing with a transient database error:
+// serialization failures, deadlocks and lost connections, as seen during
+// failovers. Attempt n waits BaseDelay*2^(n-1), capped at MaxDelay, with
+// jitter so retrying kiosks do not hit the new primary in lockstep.
+type RetryPolicy struct {
+	MaxAttempts int // 1 disables retries
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// Do runs fn until it succeeds, fails with an error that is not transient,
+// or MaxAttempts is reached; it returns fn's last error
+func (p RetryPolicy) Do(ctx context.Context, op string, fn func() error) error {
+	backoff := p.BaseDelay
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.MaxAttempts || !isTransient(err) {
+			return err
+		}
+
+		delay := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		metrics.Incr("db.retries", 1)
+		config.Logger.Warn("Transient database error, retrying",
+			zap.String("operation", op),
+			zap.Int("attempt", attempt),
+			zap.Duration("backoff", delay),
+			zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		backoff = min(backoff*2, p.MaxDelay)
+	}
+}
+
+// isTransient reports whether retrying the whole operation may succeed. A
+// connection lost while committing is not retried: the commit may have been
+// applied, and running the operation again would store its events twice.
+// The MySQL driver is only linked with its build tag, so its errors are
+// recognized by message.
+func isTransient(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	msg := err.Error()
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "40001", // serialization_failure
+			"40P01": // deadlock_detected
+			return true
+		case "57P01", // admin_shutdown, e.g. the primary stepping down
+			"57P03": // cannot_connect_now, e.g. still starting up
+			return !strings.Contains(msg, "failed to commit")
+		}
+		return pqErr.Code.Class() == "08" && !strings.Contains(msg, "failed to commit") // connection_exception
+	}
+
+	if strings.Contains(msg, "Error 1213") || // MySQL deadlock
+		strings.Contains(msg, "Error 1205") { // MySQL lock wait timeout
+		return true
+	}
+
+	return isConnectionError(err) && !strings.Contains(msg, "failed to commit")
+}
+
+func isConnectionError(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.As(err, &netErr) ||
+		strings.Contains(err.Error(), "invalid connection") // MySQL
+}
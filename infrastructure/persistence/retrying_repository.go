@@ -0,0 +1,141 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/events"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+)
+
+// RetryingTimeRecordRepository retries the operations of a time record
+// repository that fail with a transient error, see RetryPolicy. Every
+// operation runs in its own transaction, so a retry starts it over.
+type RetryingTimeRecordRepository struct {
+	repo   repositories.TimeRecordRepository
+	policy RetryPolicy
+}
+
+func NewRetryingTimeRecordRepository(repo repositories.TimeRecordRepository, policy RetryPolicy) *RetryingTimeRecordRepository {
+	return &RetryingTimeRecordRepository{repo: repo, policy: policy}
+}
+
+func (r *RetryingTimeRecordRepository) Save(ctx context.Context, record *entities.TimeRecord) error {
+	return r.policy.Do(ctx, "save_time_record", func() error {
+		return r.repo.Save(ctx, record)
+	})
+}
+
+func (r *RetryingTimeRecordRepository) SaveWithEvent(ctx context.Context, record *entities.TimeRecord, event events.DomainEvent) error {
+	return r.policy.Do(ctx, "save_time_record", func() error {
+		return r.repo.SaveWithEvent(ctx, record, event)
+	})
+}
+
+func (r *RetryingTimeRecordRepository) SaveWithEvents(ctx context.Context, record *entities.TimeRecord, evts []events.DomainEvent) error {
+	return r.policy.Do(ctx, "save_time_record", func() error {
+		return r.repo.SaveWithEvents(ctx, record, evts)
+	})
+}
+
+func (r *RetryingTimeRecordRepository) FindActiveByEmployeeID(ctx context.Context, employeeID string) (record *entities.TimeRecord, err error) {
+	err = r.policy.Do(ctx, "find_active_record", func() error {
+		record, err = r.repo.FindActiveByEmployeeID(ctx, employeeID)
+		return err
+	})
+	return record, err
+}
+
+func (r *RetryingTimeRecordRepository) FindByID(ctx context.Context, id string) (record *entities.TimeRecord, err error) {
+	err = r.policy.Do(ctx, "find_record", func() error {
+		record, err = r.repo.FindByID(ctx, id)
+		return err
+	})
+	return record, err
+}
+
+func (r *RetryingTimeRecordRepository) FindByEmployeeInRange(ctx context.Context, employeeID string, from, to time.Time) (records []*entities.TimeRecord, err error) {
+	err = r.policy.Do(ctx, "find_records", func() error {
+		records, err = r.repo.FindByEmployeeInRange(ctx, employeeID, from, to)
+		return err
+	})
+	return records, err
+}
+
+func (r *RetryingTimeRecordRepository) FindPageByEmployeeInRange(ctx context.Context, employeeID string, from, to time.Time, source entities.PunchSource, after *repositories.RecordCursor, limit int) (records []*entities.TimeRecord, err error) {
+	err = r.policy.Do(ctx, "find_records", func() error {
+		records, err = r.repo.FindPageByEmployeeInRange(ctx, employeeID, from, to, source, after, limit)
+		return err
+	})
+	return records, err
+}
+
+func (r *RetryingTimeRecordRepository) FindActive(ctx context.Context, locationID string) (records []*entities.TimeRecord, err error) {
+	err = r.policy.Do(ctx, "find_active_records", func() error {
+		records, err = r.repo.FindActive(ctx, locationID)
+		return err
+	})
+	return records, err
+}
+
+func (r *RetryingTimeRecordRepository) SumHoursWorked(ctx context.Context, employeeID string, from, to time.Time) (hours float64, err error) {
+	err = r.policy.Do(ctx, "sum_hours", func() error {
+		hours, err = r.repo.SumHoursWorked(ctx, employeeID, from, to)
+		return err
+	})
+	return hours, err
+}
+
+func (r *RetryingTimeRecordRepository) AggregateHours(ctx context.Context, from, to time.Time, timeZone, period string, weekStart time.Weekday, source entities.PunchSource) (aggregates []repositories.HoursAggregate, err error) {
+	err = r.policy.Do(ctx, "aggregate_hours", func() error {
+		aggregates, err = r.repo.AggregateHours(ctx, from, to, timeZone, period, weekStart, source)
+		return err
+	})
+	return aggregates, err
+}
+
+func (r *RetryingTimeRecordRepository) SaveAllWithAudit(ctx context.Context, records []*entities.TimeRecord, entries []*entities.AuditEntry, evts []events.DomainEvent) error {
+	return r.policy.Do(ctx, "save_records_with_audit", func() error {
+		return r.repo.SaveAllWithAudit(ctx, records, entries, evts)
+	})
+}
+
+func (r *RetryingTimeRecordRepository) SaveRepair(ctx context.Context, read, records []*entities.TimeRecord, entries []*entities.AuditEntry, evts []events.DomainEvent) error {
+	return r.policy.Do(ctx, "save_repair", func() error {
+		return r.repo.SaveRepair(ctx, read, records, entries, evts)
+	})
+}
+
+func (r *RetryingTimeRecordRepository) SaveTransfer(ctx context.Context, closed, opened *entities.TimeRecord, evts []events.DomainEvent) error {
+	return r.policy.Do(ctx, "save_transfer", func() error {
+		return r.repo.SaveTransfer(ctx, closed, opened, evts)
+	})
+}
+
+func (r *RetryingTimeRecordRepository) FindMissedCheckouts(ctx context.Context, openedBefore time.Time, limit int) (records []*entities.TimeRecord, err error) {
+	err = r.policy.Do(ctx, "find_missed_checkouts", func() error {
+		records, err = r.repo.FindMissedCheckouts(ctx, openedBefore, limit)
+		return err
+	})
+	return records, err
+}
+
+func (r *RetryingTimeRecordRepository) MarkMissedCheckout(ctx context.Context, record *entities.TimeRecord, event events.DomainEvent) (marked bool, err error) {
+	err = r.policy.Do(ctx, "mark_missed_checkout", func() error {
+		marked, err = r.repo.MarkMissedCheckout(ctx, record, event)
+		return err
+	})
+	return marked, err
+}
+
+// SoftDelete is not retried: it tries every shard, and a retry after the
+// owning shard committed would report the record as not found
+func (r *RetryingTimeRecordRepository) SoftDelete(ctx context.Context, id string) error {
+	return r.repo.SoftDelete(ctx, id)
+}
+
+// Restore is not retried, like SoftDelete
+func (r *RetryingTimeRecordRepository) Restore(ctx context.Context, id string) error {
+	return r.repo.Restore(ctx, id)
+}
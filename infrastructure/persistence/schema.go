@@ -0,0 +1,130 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+)
+
+// ExpectedSchema lists the tables and columns this binary reads and writes.
+// Keep it in sync with migrations/postgres when adding columns.
+var ExpectedSchema = map[string][]string{
+	"time_records": {
+		"id", "employee_id", "check_in_at", "check_out_at", "status", "hours_worked",
+		"regular_hours", "overtime_hours", "location_id", "time_zone", "project_code", "created_at", "updated_at",
+		"business_date", "day_segments", "holiday_hours", "weekend_hours",
+		"hourly_rate", "currency", "regular_cost", "overtime_cost",
+		"source", "device_id", "check_out_source", "check_out_device_id",
+		"missed_checkout_at", "voided_at", "deleted_at",
+	},
+	"outbox_events": {
+		"id", "event_type", "aggregate_id", "payload", "created_at", "published",
+		"published_at", "retry_count", "last_error", "priority", "claimed_by", "claimed_until",
+		"failed_at", "seq",
+	},
+	"employee_consents": {
+		"id", "employee_id", "purpose", "source", "granted_at", "withdrawn_at", "updated_at",
+	},
+	"audit_entries": {
+		"id", "record_id", "employee_id", "action", "actor", "reason", "before", "after", "created_at",
+		"prev_hash", "hash",
+	},
+	"locations": {
+		"id", "name", "address", "active", "time_zone", "created_at",
+	},
+	"projects": {
+		"code", "name", "active", "created_at",
+	},
+	"employees": {
+		"id", "name", "email", "manager_id", "hourly_rate", "currency", "active", "created_at", "updated_at",
+	},
+	"employee_aliases": {
+		"employee_id", "canonical_id", "actor", "reason", "merged_at",
+	},
+	"time_record_changes": {
+		"id", "record_id", "employee_id", "operation", "old_values", "new_values", "actor", "source", "changed_at",
+	},
+	"time_record_notes": {
+		"id", "record_id", "employee_id", "kind", "author", "body", "created_at",
+	},
+	"hours_calculations": {
+		"record_id", "employee_id", "inputs", "outputs", "gross_hours", "payable_hours", "created_at",
+	},
+	"holidays": {
+		"date", "name", "created_at",
+	},
+	"checkout_email_settings": {
+		"id", "logo_url", "footer_text", "show_hours", "show_cost", "show_overtime", "updated_by", "updated_at",
+	},
+	"idempotency_keys": {
+		"key", "fingerprint", "status_code", "content_type", "body", "expires_at", "created_at",
+	},
+	"processed_events": {
+		"consumer", "event_id", "processed_at",
+	},
+	"devices": {
+		"id", "name", "location_id", "status", "enrollment_code_hash", "enrollment_expires_at", "secret_hash",
+		"secret_expires_at", "previous_secret_hash", "previous_expires_at", "revoked_at", "created_at", "updated_at",
+	},
+	"audit_anchors": {
+		"id", "digest", "records", "entries", "up_to", "created_at",
+	},
+	"approvals": {
+		"id", "employee_id", "manager_id", "kind", "record_id", "proposed_check_in_at", "proposed_check_out_at",
+		"time_zone", "note", "status", "decided_by", "decision_comment", "decided_at", "created_at",
+	},
+	"timesheets": {
+		"id", "employee_id", "period_start", "period_end", "status", "record_ids", "hours_worked", "regular_hours",
+		"overtime_hours", "submitted_at", "decided_by", "decision_comment", "decided_at", "closed_at", "created_at", "updated_at",
+	},
+	"open_check_ins": {
+		"employee_id", "record_id", "check_in_at",
+	},
+	"work_schedules": {
+		"employee_id", "weekday", "start_time", "end_time", "location_id", "time_zone", "created_at",
+	},
+	"shift_exceptions": {
+		"id", "employee_id", "manager_id", "kind", "business_date", "scheduled_start", "scheduled_end",
+		"location_id", "time_zone", "created_at",
+	},
+}
+
+// VerifySchema returns the expected "table.column" entries missing from the database
+func VerifySchema(ctx context.Context, db *sql.DB) ([]string, error) {
+	query := `
+		SELECT table_name, column_name
+		FROM information_schema.columns
+		WHERE table_schema = current_schema()
+	`
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema: %w", err)
+	}
+	defer rows.Close()
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			return nil, fmt.Errorf("failed to scan schema: %w", err)
+		}
+		existing[table+"."+column] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	for table, columns := range ExpectedSchema {
+		for _, column := range columns {
+			if !existing[table+"."+column] {
+				missing = append(missing, table+"."+column)
+			}
+		}
+	}
+	sort.Strings(missing)
+
+	return missing, nil
+}
@@ -0,0 +1,166 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+)
+
+// ShardMove describes an employee whose data lives on the wrong shard,
+// typically after shards were added or removed
+type ShardMove struct {
+	EmployeeID string `json:"employee_id"`
+	From       int    `json:"from_shard"`
+	To         int    `json:"to_shard"`
+	Records    int    `json:"records"`
+}
+
+// employeeScopedTables are moved together with an employee, in this order.
+// The filter selects the rows belonging to the employee ($1); keyed tables
+// carry the employee_id column themselves. The change log comes first so it
+// is deleted last, with the entries the deletes of the records add to it.
+var employeeScopedTables = []struct {
+	name   string
+	filter string
+	keyed  bool
+}{
+	{"time_record_changes", "employee_id = $1", true},
+	{"time_records", "employee_id = $1", true},
+	{"approvals", "employee_id = $1", true},
+	{"shift_exceptions", "employee_id = $1", true},
+	{"outbox_events", "aggregate_id IN (SELECT id FROM time_records WHERE employee_id = $1 UNION ALL SELECT id FROM approvals WHERE employee_id = $1" +
+		" UNION ALL SELECT id FROM shift_exceptions WHERE employee_id = $1)", false},
+	{"audit_entries", "employee_id = $1", true},
+	{"hours_calculations", "employee_id = $1", true},
+	{"time_record_notes", "employee_id = $1", true},
+}
+
+// ShardRebalancer moves employee-scoped rows to the shard that owns them
+// under the current shard layout. Moves are idempotent: rows are copied with
+// ON CONFLICT DO NOTHING before being deleted from the source, so an
+// interrupted move can simply be re-run. Run it during a maintenance window,
+// as check-ins for a moving employee may land on either shard meanwhile.
+type ShardRebalancer struct {
+	shards *ShardSet
+}
+
+func NewShardRebalancer(shards *ShardSet) *ShardRebalancer {
+	return &ShardRebalancer{shards: shards}
+}
+
+// Plan lists the employees that are stored on a shard other than their owner
+func (b *ShardRebalancer) Plan(ctx context.Context) ([]ShardMove, error) {
+	query := `
+		SELECT employee_id, COUNT(*)
+		FROM time_records
+		GROUP BY employee_id
+		ORDER BY employee_id
+	`
+
+	var moves []ShardMove
+	for i, db := range b.shards.All() {
+		rows, err := db.QueryContext(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list employees on shard %d: %w", i, err)
+		}
+
+		for rows.Next() {
+			var move ShardMove
+			if err := rows.Scan(&move.EmployeeID, &move.Records); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan employee on shard %d: %w", i, err)
+			}
+			move.From = i
+			move.To = b.shards.Index(move.EmployeeID)
+			if move.From != move.To {
+				moves = append(moves, move)
+			}
+		}
+		if err := rows.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	return moves, nil
+}
+
+// Move copies the employee's rows to the target shard, then removes them from the source
+func (b *ShardRebalancer) Move(ctx context.Context, move ShardMove) error {
+	src := b.shards.All()[move.From]
+	dst := b.shards.All()[move.To]
+
+	// Moved rows are not changes to the records
+	maintenance := repositories.WithChangeOrigin(ctx, repositories.ChangeOrigin{Source: changeSourceMaintenance})
+
+	srcTx, err := beginChangeTx(maintenance, src)
+	if err != nil {
+		return fmt.Errorf("failed to begin source transaction: %w", err)
+	}
+	defer srcTx.Rollback()
+
+	// Lock the employee's records so they don't change while being copied
+	if _, err := srcTx.ExecContext(ctx, `SELECT id FROM time_records WHERE employee_id = $1 FOR UPDATE`, move.EmployeeID); err != nil {
+		return fmt.Errorf("failed to lock records: %w", err)
+	}
+
+	dstTx, err := beginChangeTx(maintenance, dst)
+	if err != nil {
+		return fmt.Errorf("failed to begin target transaction: %w", err)
+	}
+	defer dstTx.Rollback()
+
+	for _, table := range employeeScopedTables {
+		if err := copyRows(ctx, srcTx, dstTx, table.name, table.filter, move.EmployeeID, ""); err != nil {
+			return err
+		}
+	}
+
+	if err := dstTx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit target shard: %w", err)
+	}
+
+	// Delete in reverse order so the record subquery still matches
+	for i := len(employeeScopedTables) - 1; i >= 0; i-- {
+		table := employeeScopedTables[i]
+		if _, err := srcTx.ExecContext(ctx, `DELETE FROM `+table.name+` WHERE `+table.filter, move.EmployeeID); err != nil {
+			return fmt.Errorf("failed to delete %s from source shard: %w", table.name, err)
+		}
+	}
+
+	if err := srcTx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit source shard: %w", err)
+	}
+
+	return nil
+}
+
+// copyRows copies rows between shards as JSON, so it keeps working as columns
+// are added (all shards share the same schema). A non-empty renameTo rewrites
+// the employee_id of the copied rows (tables without the column ignore it).
+func copyRows(ctx context.Context, srcTx, dstTx *sql.Tx, table, filter, employeeID, renameTo string) error {
+	var rowsJSON []byte
+	var err error
+	if renameTo == "" {
+		selectQuery := `SELECT COALESCE(json_agg(t), '[]'::json) FROM ` + table + ` t WHERE ` + filter
+		err = srcTx.QueryRowContext(ctx, selectQuery, employeeID).Scan(&rowsJSON)
+	} else {
+		selectQuery := `SELECT COALESCE(jsonb_agg(jsonb_set(to_jsonb(t), '{employee_id}', to_jsonb($2::text))), '[]'::jsonb) FROM ` + table + ` t WHERE ` + filter
+		err = srcTx.QueryRowContext(ctx, selectQuery, employeeID, renameTo).Scan(&rowsJSON)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", table, err)
+	}
+
+	insertQuery := `
+		INSERT INTO ` + table + `
+		SELECT * FROM json_populate_recordset(NULL::` + table + `, $1::json)
+		ON CONFLICT DO NOTHING
+	`
+	if _, err := dstTx.ExecContext(ctx, insertQuery, string(rowsJSON)); err != nil {
+		return fmt.Errorf("failed to copy %s: %w", table, err)
+	}
+
+	return nil
+}
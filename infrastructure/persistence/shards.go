@@ -0,0 +1,118 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"sync"
+)
+
+// ShardSet routes employee-scoped data to one of several databases by hashing
+// the employee ID. A set with a single database behaves like no sharding.
+type ShardSet struct {
+	dbs []*sql.DB
+}
+
+func NewShardSet(dbs ...*sql.DB) *ShardSet {
+	return &ShardSet{dbs: dbs}
+}
+
+// OpenShardSet opens one connection pool per shard URL, in order. The order
+// determines ownership, so it must be the same on every instance.
+func OpenShardSet(urls []string) (*ShardSet, error) {
+	return openShardSet("postgres", urls)
+}
+
+// OpenMySQLShardSet opens MySQL shards like OpenShardSet. The MySQL driver is
+// only compiled in with the mysql build tag.
+func OpenMySQLShardSet(urls []string) (*ShardSet, error) {
+	if !slices.Contains(sql.Drivers(), "mysql") {
+		return nil, fmt.Errorf("MySQL driver not compiled in, build with -tags mysql")
+	}
+	return openShardSet("mysql", urls)
+}
+
+func openShardSet(driver string, urls []string) (*ShardSet, error) {
+	dbs := make([]*sql.DB, 0, len(urls))
+	for i, url := range urls {
+		db, err := sql.Open(driver, url)
+		if err != nil {
+			for _, opened := range dbs {
+				opened.Close()
+			}
+			return nil, fmt.Errorf("failed to open shard %d: %w", i, err)
+		}
+		dbs = append(dbs, db)
+	}
+	return NewShardSet(dbs...), nil
+}
+
+// Close closes every shard
+func (s *ShardSet) Close() error {
+	var firstErr error
+	for _, db := range s.dbs {
+		if err := db.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Index returns the shard index owning the employee's records
+func (s *ShardSet) Index(employeeID string) int {
+	if len(s.dbs) == 1 {
+		return 0
+	}
+	h := fnv.New32a()
+	h.Write([]byte(employeeID))
+	return int(h.Sum32() % uint32(len(s.dbs)))
+}
+
+// For returns the database owning the employee's records
+func (s *ShardSet) For(employeeID string) *sql.DB {
+	return s.dbs[s.Index(employeeID)]
+}
+
+// Primary returns the first shard, used for data that is not employee-scoped
+func (s *ShardSet) Primary() *sql.DB {
+	return s.dbs[0]
+}
+
+func (s *ShardSet) All() []*sql.DB {
+	return s.dbs
+}
+
+func (s *ShardSet) Len() int {
+	return len(s.dbs)
+}
+
+// fanOut runs fn against every shard concurrently and returns the first error
+func (s *ShardSet) fanOut(ctx context.Context, fn func(ctx context.Context, shard int, db *sql.DB) error) error {
+	if len(s.dbs) == 1 {
+		return fn(ctx, 0, s.dbs[0])
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	for i, db := range s.dbs {
+		wg.Add(1)
+		go func(i int, db *sql.DB) {
+			defer wg.Done()
+			if err := fn(ctx, i, db); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}(i, db)
+	}
+	wg.Wait()
+
+	return firstErr
+}
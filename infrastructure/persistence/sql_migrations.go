@@ -0,0 +1,95 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+//go:embed migrations/mysql/*.sql
+var mysqlMigrations embed.FS
+
+// MigrateMySQL applies the embedded MySQL migrations that are not recorded in
+// schema_migrations yet, in file name order. MySQL commits DDL implicitly, so
+// a migration that fails halfway is not rolled back; its statements are written
+// to be safe to run again.
+func MigrateMySQL(ctx context.Context, db *sql.DB) error {
+	return migrateScripts(ctx, db, mysqlMigrations, "migrations/mysql/*.sql", `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version VARCHAR(255) PRIMARY KEY,
+			applied_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+		)
+	`)
+}
+
+// migrateScripts runs the scripts matching pattern that schema_migrations does
+// not list yet, one statement at a time, and records each of them
+func migrateScripts(ctx context.Context, db *sql.DB, migrations embed.FS, pattern, createTable string) error {
+	if _, err := db.ExecContext(ctx, createTable); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	files, err := fs.Glob(migrations, pattern)
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		version := strings.TrimSuffix(file[strings.LastIndex(file, "/")+1:], ".sql")
+
+		var applied int
+		if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM schema_migrations WHERE version = ?`, version).Scan(&applied); err != nil {
+			return fmt.Errorf("failed to check migration %s: %w", version, err)
+		}
+		if applied > 0 {
+			continue
+		}
+
+		script, err := migrations.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		// The MySQL driver runs one statement per call unless multiStatements is set
+		for _, statement := range splitSQLStatements(string(script)) {
+			if _, err := db.ExecContext(ctx, statement); err != nil {
+				return fmt.Errorf("migration %s failed: %w", version, err)
+			}
+		}
+
+		if _, err := db.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES (?)`, version); err != nil {
+			return fmt.Errorf("failed to record migration %s: %w", version, err)
+		}
+	}
+
+	return nil
+}
+
+// splitSQLStatements splits a script at semicolons ending a line, skipping
+// comment-only lines; statements must not contain such semicolons themselves
+func splitSQLStatements(script string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+	)
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSuffix(strings.TrimSpace(current.String()), ";"))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
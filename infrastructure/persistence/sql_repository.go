@@ -0,0 +1,1167 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/events"
+	"github.com/leo-andrei/check-in-service/domain/hours"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+
+	"github.com/google/uuid"
+)
+
+// SQLTimeRecordRepository keeps time records in MySQL 8.0.19+ (8.0 for SKIP
+// LOCKED, 8.0.19 for the row alias of upserts). It differs from Postgres in
+// the places this file works around:
+//   - ? placeholders, which cannot be reused, so repeated values are passed twice
+//   - no arrays: status and event type lists are expanded into IN (?, ...)
+//   - no partial indexes, JSONB operators or AT TIME ZONE: segment sums and
+//     period buckets are computed in Go from the matching rows
+//   - no advisory locks: audit chains are serialized by the record's row lock
+//
+// Times are stored in UTC; DSNs must set parseTime=true&loc=UTC.
+type SQLTimeRecordRepository struct {
+	shards *ShardSet
+}
+
+func NewMySQLTimeRecordRepository(db *sql.DB) *SQLTimeRecordRepository {
+	return &SQLTimeRecordRepository{shards: NewShardSet(db)}
+}
+
+// NewShardedMySQLTimeRecordRepository stores each employee's records on the
+// shard owning their employee ID
+func NewShardedMySQLTimeRecordRepository(shards *ShardSet) *SQLTimeRecordRepository {
+	return &SQLTimeRecordRepository{shards: shards}
+}
+
+// sqlTimeRecordColumns is the time record column list matching scanTimeRecord
+const sqlTimeRecordColumns = `id, employee_id, check_in_at, check_out_at, status, hours_worked, regular_hours, overtime_hours,
+	COALESCE(location_id, ''), time_zone, COALESCE(project_code, ''), COALESCE(DATE_FORMAT(business_date, '%Y-%m-%d'), ''),
+	day_segments, holiday_hours, weekend_hours, hourly_rate, currency, regular_cost, overtime_cost,
+	COALESCE(source, ''), COALESCE(device_id, ''), COALESCE(check_out_source, ''), COALESCE(check_out_device_id, ''),
+	voided_at`
+
+const sqlInsertTimeRecord = `
+	INSERT INTO time_records (id, employee_id, check_in_at, check_out_at, status, hours_worked, regular_hours, overtime_hours,
+		location_id, time_zone, project_code, business_date, day_segments, holiday_hours, weekend_hours,
+		hourly_rate, currency, regular_cost, overtime_cost, source, device_id, check_out_source, check_out_device_id, voided_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''), ?, NULLIF(?, ''), NULLIF(?, ''), ?, ?, ?,
+		?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), ?)`
+
+const sqlInsertHoursCalculation = `
+	INSERT INTO hours_calculations (record_id, employee_id, inputs, outputs, gross_hours, payable_hours, created_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+// upsertedTimeRecordColumns are overwritten when a record is saved again
+var upsertedTimeRecordColumns = []string{
+	"check_in_at", "check_out_at", "status", "hours_worked", "regular_hours", "overtime_hours",
+	"business_date", "day_segments", "holiday_hours", "weekend_hours", "hourly_rate", "currency",
+	"regular_cost", "overtime_cost", "check_out_source", "check_out_device_id", "voided_at",
+}
+
+var upsertedHoursCalculationColumns = []string{"inputs", "outputs", "gross_hours", "payable_hours", "created_at"}
+
+// sqlUpsertTimeRecord and sqlUpsertHoursCalculation overwrite the row with the
+// same key through the row alias
+var (
+	sqlUpsertTimeRecord = sqlInsertTimeRecord + " AS new\n\tON DUPLICATE KEY UPDATE\n" +
+		sqlAssignments(upsertedTimeRecordColumns, "new.") + ",\n\t\tupdated_at = CURRENT_TIMESTAMP(6)"
+	sqlUpsertHoursCalculation = sqlInsertHoursCalculation + " AS new\n\tON DUPLICATE KEY UPDATE\n" +
+		sqlAssignments(upsertedHoursCalculationColumns, "new.")
+)
+
+// sqlAssignments returns "column = <prefix>column" for each column
+func sqlAssignments(columns []string, prefix string) string {
+	assignments := make([]string, len(columns))
+	for i, column := range columns {
+		assignments[i] = "\t\t" + column + " = " + prefix + column
+	}
+	return strings.Join(assignments, ",\n")
+}
+
+// sqlUpsertTimeRecordArgs are the Postgres upsert arguments with JSON passed
+// as text, since MySQL refuses JSON from a binary string
+func sqlUpsertTimeRecordArgs(record *entities.TimeRecord) []interface{} {
+	args := upsertTimeRecordArgs(record)
+	if segments, ok := args[12].([]byte); ok && segments != nil {
+		args[12] = string(segments)
+	} else {
+		args[12] = nil
+	}
+	return args
+}
+
+// inPlaceholders returns "?, ?, ..." for n values
+func inPlaceholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// sqlCompletedStatuses returns the IN list and arguments of the statuses
+// that count as worked time
+func sqlCompletedStatuses() (string, []interface{}) {
+	args := make([]interface{}, len(entities.CompletedStatuses))
+	for i, status := range entities.CompletedStatuses {
+		args[i] = string(status)
+	}
+	return inPlaceholders(len(args)), args
+}
+
+func (r *SQLTimeRecordRepository) Save(ctx context.Context, record *entities.TimeRecord) error {
+	_, err := r.shards.For(record.EmployeeID).ExecContext(ctx, sqlUpsertTimeRecord, sqlUpsertTimeRecordArgs(record)...)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("failed to save time record: %w", repositories.ErrConflict)
+		}
+		return fmt.Errorf("failed to save time record: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SQLTimeRecordRepository) SaveWithEvent(ctx context.Context, record *entities.TimeRecord, event events.DomainEvent) error {
+	return r.SaveWithEvents(ctx, record, []events.DomainEvent{event})
+}
+
+// SaveWithEvents saves the record, its hours calculation, notes and events in one transaction
+func (r *SQLTimeRecordRepository) SaveWithEvents(ctx context.Context, record *entities.TimeRecord, evts []events.DomainEvent) error {
+	tx, err := r.shards.For(record.EmployeeID).BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback if not committed
+
+	if _, err := tx.ExecContext(ctx, sqlUpsertTimeRecord, sqlUpsertTimeRecordArgs(record)...); err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("failed to save time record: %w", repositories.ErrConflict)
+		}
+		return fmt.Errorf("failed to save time record: %w", err)
+	}
+
+	if record.Calculation != nil {
+		if err := r.insertHoursCalculation(ctx, tx, record); err != nil {
+			return err
+		}
+	}
+
+	for _, note := range record.Notes {
+		if err := r.insertNote(ctx, tx, note); err != nil {
+			return err
+		}
+	}
+
+	for _, event := range evts {
+		if err := sqlInsertOutboxEvent(ctx, tx, record.ID, event); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SQLTimeRecordRepository) FindActiveByEmployeeID(ctx context.Context, employeeID string) (*entities.TimeRecord, error) {
+	query := `
+		SELECT ` + sqlTimeRecordColumns + `
+		FROM time_records
+		WHERE employee_id = ? AND status = ? AND deleted_at IS NULL
+		ORDER BY check_in_at DESC
+		LIMIT 1
+	`
+
+	record, err := scanTimeRecord(r.shards.For(employeeID).QueryRowContext(ctx, query, employeeID, entities.StatusCheckedIn))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find active record: %w", err)
+	}
+
+	return record, nil
+}
+
+func (r *SQLTimeRecordRepository) FindByID(ctx context.Context, id string) (*entities.TimeRecord, error) {
+	query := `
+		SELECT ` + sqlTimeRecordColumns + `
+		FROM time_records
+		WHERE id = ? AND deleted_at IS NULL
+	`
+
+	var (
+		mu    sync.Mutex
+		found *entities.TimeRecord
+	)
+	err := r.shards.fanOut(ctx, func(ctx context.Context, _ int, db *sql.DB) error {
+		record, err := scanTimeRecord(db.QueryRowContext(ctx, query, id))
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		found = record
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find record: %w", err)
+	}
+
+	if found == nil {
+		return nil, repositories.ErrRecordNotFound
+	}
+
+	return found, nil
+}
+
+func (r *SQLTimeRecordRepository) FindByEmployeeInRange(ctx context.Context, employeeID string, from, to time.Time) ([]*entities.TimeRecord, error) {
+	query := `
+		SELECT ` + sqlTimeRecordColumns + `
+		FROM time_records
+		WHERE employee_id = ? AND deleted_at IS NULL
+			AND check_in_at < ?
+			AND (check_out_at IS NULL OR check_out_at > ?)
+		ORDER BY check_in_at ASC, id ASC
+	`
+
+	rows, err := r.shards.For(employeeID).QueryContext(ctx, query, employeeID, to.UTC(), from.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query records: %w", err)
+	}
+	return scanTimeRecords(rows)
+}
+
+// FindPageByEmployeeInRange seeks past the cursor on (check_in_at, id), like
+// the Postgres implementation
+func (r *SQLTimeRecordRepository) FindPageByEmployeeInRange(ctx context.Context, employeeID string, from, to time.Time, source entities.PunchSource, after *repositories.RecordCursor, limit int) ([]*entities.TimeRecord, error) {
+	query := `
+		SELECT ` + sqlTimeRecordColumns + `
+		FROM time_records
+		WHERE employee_id = ? AND deleted_at IS NULL
+			AND check_in_at < ?
+			AND (check_out_at IS NULL OR check_out_at > ?)
+			AND (? IS NULL OR (check_in_at, id) > (?, ?))
+			AND (? = '' OR source = ? OR check_out_source = ?)
+		ORDER BY check_in_at ASC, id ASC
+		LIMIT ?
+	`
+
+	var afterAt *time.Time
+	afterID := ""
+	if after != nil {
+		at := after.CheckInAt.UTC()
+		afterAt = &at
+		afterID = after.ID
+	}
+
+	rows, err := r.shards.For(employeeID).QueryContext(ctx, query,
+		employeeID, to.UTC(), from.UTC(),
+		afterAt, afterAt, afterID,
+		source, source, source,
+		limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query records: %w", err)
+	}
+	return scanTimeRecords(rows)
+}
+
+func (r *SQLTimeRecordRepository) FindActive(ctx context.Context, locationID string) ([]*entities.TimeRecord, error) {
+	query := `
+		SELECT ` + sqlTimeRecordColumns + `
+		FROM time_records
+		WHERE status = ? AND deleted_at IS NULL AND (? = '' OR location_id = ?)
+		ORDER BY check_in_at ASC
+	`
+
+	records, err := r.queryAllShards(ctx, query, entities.StatusCheckedIn, locationID, locationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query active records: %w", err)
+	}
+	return records, nil
+}
+
+// queryAllShards runs a record query on every shard and merges the results
+// oldest check-in first
+func (r *SQLTimeRecordRepository) queryAllShards(ctx context.Context, query string, args ...interface{}) ([]*entities.TimeRecord, error) {
+	var (
+		mu      sync.Mutex
+		records []*entities.TimeRecord
+	)
+	err := r.shards.fanOut(ctx, func(ctx context.Context, _ int, db *sql.DB) error {
+		rows, err := db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		shardRecords, err := scanTimeRecords(rows)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		records = append(records, shardRecords...)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].CheckInAt.Before(records[j].CheckInAt)
+	})
+	return records, nil
+}
+
+// SumHoursWorked returns the hours of completed records that started in
+// [from, to); records split at midnight only count their segments in the range
+func (r *SQLTimeRecordRepository) SumHoursWorked(ctx context.Context, employeeID string, from, to time.Time) (float64, error) {
+	statuses, statusArgs := sqlCompletedStatuses()
+	query := `
+		SELECT ` + sqlTimeRecordColumns + `
+		FROM time_records
+		WHERE employee_id = ? AND status IN (` + statuses + `) AND deleted_at IS NULL AND check_in_at < ?
+			AND (check_in_at >= ? OR (day_segments IS NOT NULL AND check_out_at > ?))
+	`
+
+	args := append([]interface{}{employeeID}, statusArgs...)
+	args = append(args, to.UTC(), from.UTC(), from.UTC())
+	rows, err := r.shards.For(employeeID).QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to sum hours worked: %w", err)
+	}
+	records, err := scanTimeRecords(rows)
+	if err != nil {
+		return 0, fmt.Errorf("failed to sum hours worked: %w", err)
+	}
+
+	return sumRecordHours(records, from, to), nil
+}
+
+// sumRecordHours adds up the hours of the records, counting only the segments
+// of split records that start in [from, to)
+func sumRecordHours(records []*entities.TimeRecord, from, to time.Time) float64 {
+	var total float64
+	for _, record := range records {
+		if record.Segments == nil {
+			total += record.HoursWorked
+			continue
+		}
+		for _, segment := range record.Segments {
+			if !segment.StartAt.Before(from) && segment.StartAt.Before(to) {
+				total += segment.Hours
+			}
+		}
+	}
+	return total
+}
+
+// AggregateHours buckets completed records by the local time of their
+// check-in; MySQL time zone tables are often not loaded, so the bucketing is
+// done here rather than with CONVERT_TZ
+func (r *SQLTimeRecordRepository) AggregateHours(ctx context.Context, from, to time.Time, timeZone, period string, weekStart time.Weekday, source entities.PunchSource) ([]repositories.HoursAggregate, error) {
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q: %w", timeZone, err)
+	}
+
+	statuses, statusArgs := sqlCompletedStatuses()
+	query := `
+		SELECT employee_id, COALESCE(location_id, ''), check_in_at, hours_worked, regular_hours, overtime_hours
+		FROM time_records
+		WHERE status IN (` + statuses + `) AND deleted_at IS NULL AND check_in_at >= ? AND check_in_at < ?
+			AND (? = '' OR source = ? OR check_out_source = ?)
+	`
+	args := append(statusArgs, from.UTC(), to.UTC(), source, source, source)
+
+	var (
+		mu      sync.Mutex
+		buckets = newHoursBuckets(loc, period, weekStart)
+	)
+	err = r.shards.fanOut(ctx, func(ctx context.Context, _ int, db *sql.DB) error {
+		rows, err := db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				employeeID string
+				locationID string
+				checkInAt  time.Time
+				worked     float64
+				regular    float64
+				overtime   float64
+			)
+			if err := rows.Scan(&employeeID, &locationID, &checkInAt, &worked, &regular, &overtime); err != nil {
+				return err
+			}
+
+			mu.Lock()
+			err := buckets.add(employeeID, locationID, checkInAt, worked, regular, overtime)
+			mu.Unlock()
+			if err != nil {
+				return err
+			}
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate hours: %w", err)
+	}
+
+	return buckets.aggregates(), nil
+}
+
+type hoursBucketKey struct{ employeeID, locationID, periodStart string }
+
+// hoursBuckets sums completed records per employee, location and the local
+// period of their check-in, for stores that cannot bucket in SQL
+type hoursBuckets struct {
+	loc       *time.Location
+	period    string
+	weekStart time.Weekday
+	buckets   map[hoursBucketKey]*repositories.HoursAggregate
+}
+
+func newHoursBuckets(loc *time.Location, period string, weekStart time.Weekday) *hoursBuckets {
+	return &hoursBuckets{
+		loc:       loc,
+		period:    period,
+		weekStart: weekStart,
+		buckets:   make(map[hoursBucketKey]*repositories.HoursAggregate),
+	}
+}
+
+func (b *hoursBuckets) add(employeeID, locationID string, checkInAt time.Time, worked, regular, overtime float64) error {
+	start, _, err := hours.PeriodBounds(b.period, checkInAt.In(b.loc), b.weekStart)
+	if err != nil {
+		return err
+	}
+	key := hoursBucketKey{employeeID: employeeID, locationID: locationID, periodStart: start.Format(entities.BusinessDateLayout)}
+
+	bucket, ok := b.buckets[key]
+	if !ok {
+		bucket = &repositories.HoursAggregate{EmployeeID: key.employeeID, LocationID: key.locationID, PeriodStart: key.periodStart}
+		b.buckets[key] = bucket
+	}
+	bucket.Records++
+	bucket.HoursWorked += worked
+	bucket.RegularHours += regular
+	bucket.OvertimeHours += overtime
+	return nil
+}
+
+func (b *hoursBuckets) aggregates() []repositories.HoursAggregate {
+	aggregates := make([]repositories.HoursAggregate, 0, len(b.buckets))
+	for _, bucket := range b.buckets {
+		aggregates = append(aggregates, *bucket)
+	}
+	return aggregates
+}
+
+// SaveAllWithAudit updates several records and writes their audit trail and
+// outbox events atomically. All records must belong to the same shard.
+func (r *SQLTimeRecordRepository) SaveAllWithAudit(ctx context.Context, records []*entities.TimeRecord, entries []*entities.AuditEntry, evts []events.DomainEvent) error {
+	return r.saveAllWithAudit(ctx, nil, records, entries, evts)
+}
+
+func (r *SQLTimeRecordRepository) SaveRepair(ctx context.Context, read, records []*entities.TimeRecord, entries []*entities.AuditEntry, evts []events.DomainEvent) error {
+	return r.saveAllWithAudit(ctx, read, records, entries, evts)
+}
+
+// saveAllWithAudit is SaveAllWithAudit that first locks the records read,
+// which share the shard of records
+func (r *SQLTimeRecordRepository) saveAllWithAudit(ctx context.Context, read, records []*entities.TimeRecord, entries []*entities.AuditEntry, evts []events.DomainEvent) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	shard := r.shards.Index(records[0].EmployeeID)
+	for _, record := range records[1:] {
+		if r.shards.Index(record.EmployeeID) != shard {
+			return fmt.Errorf("records span multiple shards")
+		}
+	}
+
+	tx, err := r.shards.All()[shard].BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback if not committed
+
+	lockQuery := `SELECT check_in_at, check_out_at, status FROM time_records WHERE id = ? AND deleted_at IS NULL FOR UPDATE`
+	if err := lockUnchanged(ctx, tx, lockQuery, read); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if _, err := tx.ExecContext(ctx, sqlUpsertTimeRecord, sqlUpsertTimeRecordArgs(record)...); err != nil {
+			if isUniqueViolation(err) {
+				err = repositories.ErrConflict
+			}
+			return fmt.Errorf("failed to update time record %s: %w", record.ID, err)
+		}
+	}
+
+	if err := r.insertAuditEntries(ctx, tx, entries); err != nil {
+		return err
+	}
+
+	for _, event := range evts {
+		if err := sqlInsertOutboxEvent(ctx, tx, recordEventAggregateID(event, records[0].EmployeeID), event); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SQLTimeRecordRepository) SaveTransfer(ctx context.Context, closed, opened *entities.TimeRecord, evts []events.DomainEvent) error {
+	tx, err := r.shards.For(closed.EmployeeID).BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback if not committed
+
+	for _, record := range []*entities.TimeRecord{closed, opened} {
+		if _, err := tx.ExecContext(ctx, sqlUpsertTimeRecord, sqlUpsertTimeRecordArgs(record)...); err != nil {
+			if isUniqueViolation(err) {
+				err = repositories.ErrConflict
+			}
+			return fmt.Errorf("failed to save time record %s: %w", record.ID, err)
+		}
+		if record.Calculation != nil {
+			if err := r.insertHoursCalculation(ctx, tx, record); err != nil {
+				return err
+			}
+		}
+		for _, note := range record.Notes {
+			if err := r.insertNote(ctx, tx, note); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, event := range evts {
+		if err := sqlInsertOutboxEvent(ctx, tx, recordEventAggregateID(event, closed.ID), event); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// FindMissedCheckouts returns open records that started before openedBefore
+// and have no missed check-out flag yet
+func (r *SQLTimeRecordRepository) FindMissedCheckouts(ctx context.Context, openedBefore time.Time, limit int) ([]*entities.TimeRecord, error) {
+	query := `
+		SELECT ` + sqlTimeRecordColumns + `
+		FROM time_records
+		WHERE status = ? AND check_in_at < ? AND missed_checkout_at IS NULL AND deleted_at IS NULL
+		ORDER BY check_in_at ASC
+		LIMIT ?
+	`
+
+	records, err := r.queryAllShards(ctx, query, entities.StatusCheckedIn, openedBefore.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query missed check-outs: %w", err)
+	}
+	return records, nil
+}
+
+// MarkMissedCheckout flags the record and stores the event in one transaction
+func (r *SQLTimeRecordRepository) MarkMissedCheckout(ctx context.Context, record *entities.TimeRecord, event events.DomainEvent) (bool, error) {
+	tx, err := r.shards.For(record.EmployeeID).BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback if not committed
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE time_records SET missed_checkout_at = ?
+		WHERE id = ? AND status = ? AND missed_checkout_at IS NULL AND deleted_at IS NULL
+	`, time.Now().UTC(), record.ID, entities.StatusCheckedIn)
+	if err != nil {
+		return false, fmt.Errorf("failed to flag missed check-out: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return false, nil
+	}
+
+	if err := sqlInsertOutboxEvent(ctx, tx, record.ID, event); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// SoftDelete sets deleted_at on the record on whichever shard holds it
+func (r *SQLTimeRecordRepository) SoftDelete(ctx context.Context, id string) error {
+	return r.setDeletedAt(ctx, id, `
+		UPDATE time_records SET deleted_at = ?, updated_at = ?
+		WHERE id = ? AND deleted_at IS NULL
+	`, time.Now().UTC())
+}
+
+func (r *SQLTimeRecordRepository) Restore(ctx context.Context, id string) error {
+	return r.setDeletedAt(ctx, id, `
+		UPDATE time_records SET deleted_at = ?, updated_at = ?
+		WHERE id = ? AND deleted_at IS NOT NULL
+	`, nil)
+}
+
+func (r *SQLTimeRecordRepository) setDeletedAt(ctx context.Context, id, query string, deletedAt interface{}) error {
+	var (
+		mu      sync.Mutex
+		updated int64
+	)
+	err := r.shards.fanOut(ctx, func(ctx context.Context, _ int, db *sql.DB) error {
+		result, err := db.ExecContext(ctx, query, deletedAt, time.Now().UTC(), id)
+		if err != nil {
+			return err
+		}
+		n, _ := result.RowsAffected()
+		mu.Lock()
+		updated += n
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("failed to restore record: %w", repositories.ErrConflict)
+		}
+		return fmt.Errorf("failed to update record: %w", err)
+	}
+	if updated == 0 {
+		return repositories.ErrRecordNotFound
+	}
+	return nil
+}
+
+func sqlInsertOutboxEvent(ctx context.Context, tx *sql.Tx, aggregateID string, event events.DomainEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO outbox_events (id, event_type, aggregate_id, payload, created_at, published, priority)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`,
+		uuid.New().String(),
+		event.EventType(),
+		aggregateID,
+		string(payload),
+		time.Now().UTC(),
+		false,
+		events.PriorityOf(event),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save outbox event: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SQLTimeRecordRepository) insertNote(ctx context.Context, db execer, note *entities.RecordNote) error {
+	query := `INSERT IGNORE INTO time_record_notes (id, record_id, employee_id, kind, author, body, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := db.ExecContext(ctx, query,
+		note.ID,
+		note.RecordID,
+		note.EmployeeID,
+		note.Kind,
+		note.Author,
+		note.Body,
+		note.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save note: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLTimeRecordRepository) insertHoursCalculation(ctx context.Context, tx *sql.Tx, record *entities.TimeRecord) error {
+	calc := record.Calculation
+
+	inputs, err := json.Marshal(calc.Inputs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal hours calculation inputs: %w", err)
+	}
+	outputs, err := json.Marshal(calc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal hours calculation: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, sqlUpsertHoursCalculation,
+		record.ID,
+		record.EmployeeID,
+		string(inputs),
+		string(outputs),
+		calc.GrossHours,
+		calc.PayableHours,
+		time.Now().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save hours calculation: %w", err)
+	}
+
+	return nil
+}
+
+// insertAuditEntries appends the entries to their records' hash chains.
+// The records were written earlier in the same transaction, so their row locks
+// keep concurrent writers from forking a chain.
+func (r *SQLTimeRecordRepository) insertAuditEntries(ctx context.Context, tx *sql.Tx, entries []*entities.AuditEntry) error {
+	headQuery := `
+		SELECT a.hash
+		FROM audit_entries a
+		WHERE a.record_id = ? AND a.hash IS NOT NULL
+			AND NOT EXISTS (SELECT 1 FROM audit_entries b WHERE b.record_id = a.record_id AND b.prev_hash = a.hash)
+		LIMIT 1
+	`
+	insertQuery := "INSERT INTO audit_entries (id, record_id, employee_id, action, actor, reason, `before`, `after`, created_at, prev_hash, hash)" + `
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	for _, entry := range entries {
+		if _, err := tx.ExecContext(ctx, `SELECT id FROM time_records WHERE id = ? FOR UPDATE`, entry.RecordID); err != nil {
+			return fmt.Errorf("failed to lock audit chain: %w", err)
+		}
+		var head string
+		err := tx.QueryRowContext(ctx, headQuery, entry.RecordID).Scan(&head)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("failed to find audit chain head: %w", err)
+		}
+		entry.Chain(head, auditChainKey())
+
+		before, err := json.Marshal(entry.Before)
+		if err != nil {
+			return fmt.Errorf("failed to marshal audit snapshot: %w", err)
+		}
+		after, err := json.Marshal(entry.After)
+		if err != nil {
+			return fmt.Errorf("failed to marshal audit snapshot: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, insertQuery,
+			entry.ID,
+			entry.RecordID,
+			entry.EmployeeID,
+			entry.Action,
+			entry.Actor,
+			entry.Reason,
+			string(before),
+			string(after),
+			entry.CreatedAt.UTC(),
+			entry.PrevHash,
+			entry.Hash,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save audit entry: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// SQLOutboxRepository reads the outbox written by SQLTimeRecordRepository
+type SQLOutboxRepository struct {
+	shards *ShardSet
+}
+
+func NewMySQLOutboxRepository(db *sql.DB) *SQLOutboxRepository {
+	return &SQLOutboxRepository{shards: NewShardSet(db)}
+}
+
+func NewShardedMySQLOutboxRepository(shards *ShardSet) *SQLOutboxRepository {
+	return &SQLOutboxRepository{shards: shards}
+}
+
+// SaveEvent stores an event that is not written with a record on the shard of
+// its employee, like the Postgres outbox
+func (r *SQLOutboxRepository) SaveEvent(ctx context.Context, event events.DomainEvent) error {
+	employeeID := events.EmployeeOf(event)
+	tx, err := r.shards.For(employeeID).BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback if not committed
+
+	if err := sqlInsertOutboxEvent(ctx, tx, recordEventAggregateID(event, employeeID), event); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ClaimUnpublished selects the events with SKIP LOCKED, like the Postgres
+// outbox, and claims them before the transaction releases the row locks. As
+// on Postgres, only the first pending event of an aggregate, by seq, is
+// claimed.
+func (r *SQLOutboxRepository) ClaimUnpublished(ctx context.Context, claimant string, limit int, lease time.Duration) ([]repositories.OutboxEvent, error) {
+	query := `
+		SELECT id, event_type, aggregate_id, payload, created_at, published, retry_count, priority,
+			CASE WHEN created_at < ? THEN 0 ELSE priority END AS publish_rank
+		FROM outbox_events e
+		WHERE published = FALSE AND failed_at IS NULL AND (claimed_until IS NULL OR claimed_until < ?)
+			AND NOT EXISTS (
+				SELECT 1 FROM outbox_events p
+				WHERE p.aggregate_id = e.aggregate_id AND p.published = FALSE AND p.seq < e.seq
+			)
+		ORDER BY publish_rank, seq ASC
+		LIMIT ?
+		FOR UPDATE SKIP LOCKED
+	`
+
+	now := time.Now().UTC()
+	args := []interface{}{outboxAgingCutoff(now).UTC(), now, limit}
+
+	var (
+		mu        sync.Mutex
+		allEvents []rankedOutboxEvent
+	)
+	err := r.shards.fanOut(ctx, func(ctx context.Context, _ int, db *sql.DB) error {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer tx.Rollback() // Rollback if not committed
+
+		rows, err := tx.QueryContext(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("failed to query unpublished events: %w", err)
+		}
+		shardEvents, err := scanRankedOutboxEvents(rows)
+		rows.Close()
+		if err != nil {
+			return err
+		}
+		if len(shardEvents) == 0 {
+			return nil
+		}
+
+		claimArgs := []interface{}{claimant, now.Add(lease)}
+		for _, event := range shardEvents {
+			claimArgs = append(claimArgs, event.ID)
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE outbox_events SET claimed_by = ?, claimed_until = ? WHERE id IN (`+inPlaceholders(len(shardEvents))+`)`, claimArgs...)
+		if err != nil {
+			return fmt.Errorf("failed to claim unpublished events: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit transaction: %w", err)
+		}
+
+		mu.Lock()
+		allEvents = append(allEvents, shardEvents...)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeRankedOutboxEvents(allEvents), nil
+}
+
+func (r *SQLOutboxRepository) FindByAggregates(ctx context.Context, employeeID string, aggregateIDs []string) ([]repositories.OutboxEvent, error) {
+	if len(aggregateIDs) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		SELECT id, event_type, aggregate_id, payload, created_at, published, retry_count
+		FROM outbox_events
+		WHERE aggregate_id IN (` + inPlaceholders(len(aggregateIDs)) + `)
+		ORDER BY created_at ASC, id ASC
+	`
+
+	args := make([]interface{}, len(aggregateIDs))
+	for i, id := range aggregateIDs {
+		args[i] = id
+	}
+
+	rows, err := r.shards.For(employeeID).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query outbox events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []repositories.OutboxEvent
+	for rows.Next() {
+		var event repositories.OutboxEvent
+		err := rows.Scan(
+			&event.ID,
+			&event.EventType,
+			&event.AggregateID,
+			&event.Payload,
+			&event.CreatedAt,
+			&event.Published,
+			&event.RetryCount,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+func (r *SQLOutboxRepository) MarkAsPublished(ctx context.Context, eventIDs []string) error {
+	if len(eventIDs) == 0 {
+		return nil
+	}
+
+	args := []interface{}{time.Now().UTC()}
+	for _, id := range eventIDs {
+		args = append(args, id)
+	}
+	query := `UPDATE outbox_events SET published = TRUE, published_at = ?, claimed_by = NULL, claimed_until = NULL WHERE id IN (` + inPlaceholders(len(eventIDs)) + `)`
+	err := r.shards.fanOut(ctx, func(ctx context.Context, _ int, db *sql.DB) error {
+		_, err := db.ExecContext(ctx, query, args...)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark event as published: %w", err)
+	}
+
+	return nil
+}
+
+// IncrementRetryCount sets failed_at before retry_count: MySQL evaluates SET
+// assignments in order, with the values already assigned
+func (r *SQLOutboxRepository) IncrementRetryCount(ctx context.Context, eventID string, errorMsg string, maxRetries int) (bool, error) {
+	query := `
+		UPDATE outbox_events
+		SET failed_at = CASE WHEN ? > 0 AND retry_count + 1 >= ? THEN ? ELSE failed_at END,
+			retry_count = retry_count + 1, last_error = ?, claimed_by = NULL, claimed_until = NULL
+		WHERE id = ?
+	`
+
+	var failed atomic.Bool
+	err := r.shards.fanOut(ctx, func(ctx context.Context, _ int, db *sql.DB) error {
+		res, err := db.ExecContext(ctx, query, maxRetries, maxRetries, time.Now().UTC(), errorMsg, eventID)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			return err // The event lives on another shard
+		}
+		var shardFailed bool
+		if err := db.QueryRowContext(ctx, `SELECT failed_at IS NOT NULL FROM outbox_events WHERE id = ?`, eventID).Scan(&shardFailed); err != nil {
+			return err
+		}
+		failed.Store(shardFailed)
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to increment retry count: %w", err)
+	}
+
+	return failed.Load(), nil
+}
+
+func (r *SQLOutboxRepository) ReleaseClaims(ctx context.Context, eventIDs []string) error {
+	if len(eventIDs) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, len(eventIDs))
+	for i, id := range eventIDs {
+		args[i] = id
+	}
+	query := `UPDATE outbox_events SET claimed_by = NULL, claimed_until = NULL WHERE id IN (` + inPlaceholders(len(eventIDs)) + `)`
+	err := r.shards.fanOut(ctx, func(ctx context.Context, _ int, db *sql.DB) error {
+		_, err := db.ExecContext(ctx, query, args...)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to release outbox claims: %w", err)
+	}
+
+	return nil
+}
+
+// FindFailed pages through failed events like the Postgres implementation;
+// only time records carry an employee ID here
+func (r *SQLOutboxRepository) FindFailed(ctx context.Context, filter repositories.OutboxFailureFilter, after *repositories.OutboxCursor, limit int) ([]repositories.FailedOutboxEvent, error) {
+	query := `
+		SELECT o.id, o.event_type, o.aggregate_id, COALESCE(t.employee_id, ''), o.created_at,
+			o.retry_count, COALESCE(o.last_error, ''), o.failed_at
+		FROM outbox_events o
+		LEFT JOIN time_records t ON t.id = o.aggregate_id
+		WHERE o.published = FALSE AND o.retry_count >= ?
+			AND (? IS NULL OR o.created_at >= ?)
+			AND (? IS NULL OR o.created_at < ?)
+			AND (? = '' OR o.event_type = ?)
+			AND (? IS NULL OR (o.created_at, o.id) > (?, ?))
+			AND (? <> 'failed' OR o.failed_at IS NOT NULL)
+			AND (? <> 'pending' OR o.failed_at IS NULL)
+		ORDER BY o.created_at ASC, o.id ASC
+		LIMIT ?
+	`
+
+	from, to := utcTime(filter.From), utcTime(filter.To)
+	var afterAt interface{}
+	afterID := ""
+	if after != nil {
+		afterAt = after.CreatedAt.UTC()
+		afterID = after.ID
+	}
+
+	var (
+		mu     sync.Mutex
+		failed []repositories.FailedOutboxEvent
+	)
+	err := r.shards.fanOut(ctx, func(ctx context.Context, _ int, db *sql.DB) error {
+		rows, err := db.QueryContext(ctx, query,
+			filter.MinRetries,
+			from, from,
+			to, to,
+			filter.EventType, filter.EventType,
+			afterAt, afterAt, afterID,
+			filter.Status, filter.Status,
+			limit)
+		if err != nil {
+			return err
+		}
+		shardFailed, err := scanFailedOutboxEvents(rows)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		failed = append(failed, shardFailed...)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query failed outbox events: %w", err)
+	}
+
+	return firstFailedOutboxEvents(failed, limit), nil
+}
+
+// utcTime returns the time in UTC, or nil for a nil time
+func utcTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
+
+func (r *SQLOutboxRepository) Requeue(ctx context.Context, eventIDs []string) (int, error) {
+	if len(eventIDs) == 0 {
+		return 0, nil
+	}
+
+	args := make([]interface{}, len(eventIDs))
+	for i, id := range eventIDs {
+		args[i] = id
+	}
+	query := `UPDATE outbox_events SET failed_at = NULL, retry_count = 0 WHERE published = FALSE AND id IN (` + inPlaceholders(len(eventIDs)) + `)`
+	return outboxRequeue(ctx, r.shards, query, args...)
+}
+
+func (r *SQLOutboxRepository) RequeueFailed(ctx context.Context, eventType string) (int, error) {
+	query := `
+		UPDATE outbox_events SET failed_at = NULL, retry_count = 0
+		WHERE published = FALSE AND failed_at IS NOT NULL AND (? = '' OR event_type = ?)
+	`
+	return outboxRequeue(ctx, r.shards, query, eventType, eventType)
+}
+
+func (r *SQLOutboxRepository) Backlog(ctx context.Context) (repositories.OutboxBacklog, error) {
+	where := `
+		FROM outbox_events
+		WHERE published = FALSE AND failed_at IS NULL
+	`
+
+	var (
+		mu      sync.Mutex
+		backlog repositories.OutboxBacklog
+	)
+	err := r.shards.fanOut(ctx, func(ctx context.Context, _ int, db *sql.DB) error {
+		var failed int
+		if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM outbox_events WHERE published = FALSE AND failed_at IS NOT NULL`).Scan(&failed); err != nil {
+			return err
+		}
+		if failed > 0 {
+			mu.Lock()
+			backlog.Failed += failed
+			mu.Unlock()
+		}
+
+		var pending int
+		if err := db.QueryRowContext(ctx, `SELECT COUNT(*) `+where).Scan(&pending); err != nil {
+			return err
+		}
+		if pending == 0 {
+			return nil
+		}
+		var oldest time.Time
+		err := db.QueryRowContext(ctx, `SELECT created_at `+where+` ORDER BY created_at ASC LIMIT 1`).Scan(&oldest)
+		if err == sql.ErrNoRows {
+			return nil // Published in the meantime
+		}
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		backlog.Pending += pending
+		if backlog.OldestAt == nil || oldest.Before(*backlog.OldestAt) {
+			at := oldest.UTC()
+			backlog.OldestAt = &at
+		}
+		return nil
+	})
+	if err != nil {
+		return repositories.OutboxBacklog{}, fmt.Errorf("failed to count outbox backlog: %w", err)
+	}
+
+	return backlog, nil
+}
+
+func (r *SQLOutboxRepository) PrunePublished(ctx context.Context, publishedBefore time.Time, limit int) (int, error) {
+	// MySQL refuses LIMIT in an IN subquery but takes it on DELETE
+	query := `DELETE FROM outbox_events WHERE published = TRUE AND published_at < ? ORDER BY published_at LIMIT ?`
+	return pruneOutbox(ctx, r.shards, query, publishedBefore.UTC(), limit)
+}
+
+func (r *SQLOutboxRepository) Size(ctx context.Context) (repositories.OutboxSize, error) {
+	query := `SELECT COALESCE(TABLE_ROWS, 0), COALESCE(DATA_LENGTH + INDEX_LENGTH, 0)
+		FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'outbox_events'`
+	return outboxSize(ctx, r.shards, query)
+}
@@ -0,0 +1,62 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// hotQueries are the statements of the check-in and check-out path
+var hotQueries = map[string]string{
+	"find_record":        `SELECT ` + timeRecordColumns + ` FROM time_records WHERE id = $1 AND deleted_at IS NULL`,
+	"move_time_record":   moveTimeRecordQuery,
+	"upsert_time_record": upsertTimeRecordQuery,
+	"insert_note":        insertNoteQuery,
+	"audit_chain_head":   auditChainHeadQuery,
+}
+
+// PrepareHotQueries prepares the check-in and check-out statements, so a
+// schema they do not match fails the warm-up instead of the first check-in,
+// and their plans are cached before traffic arrives
+func PrepareHotQueries(ctx context.Context, db *sql.DB) error {
+	for name, query := range hotQueries {
+		stmt, err := db.PrepareContext(ctx, query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", name, err)
+		}
+		stmt.Close()
+	}
+	return nil
+}
+
+// OutboxRoundTrip writes a synthetic event of eventType to the outbox and
+// reads it back in a transaction that is rolled back, so nothing is left for
+// the relay. It returns the payload read back.
+func OutboxRoundTrip(ctx context.Context, db *sql.DB, eventType string, payload []byte) ([]byte, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Never committed
+
+	id := uuid.New().String()
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO outbox_events (id, event_type, aggregate_id, payload, created_at, published)
+		VALUES ($1, $2, $1, $3, $4, FALSE)
+	`, id, eventType, payload, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to write outbox event: %w", err)
+	}
+
+	var stored []byte
+	err = tx.QueryRowContext(ctx, `
+		SELECT payload FROM outbox_events WHERE id = $1 AND published = FALSE FOR UPDATE SKIP LOCKED
+	`, id).Scan(&stored)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read outbox event: %w", err)
+	}
+	return stored, nil
+}
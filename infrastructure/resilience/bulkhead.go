@@ -0,0 +1,62 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrBulkheadFull is returned, wrapped, when no slot freed up in time
+var ErrBulkheadFull = errors.New("bulkhead is full")
+
+// Bulkhead caps the calls in flight to a service, so a slow service ties up
+// at most that many goroutines. Calls beyond the cap queue for a slot, for up
+// to the queue timeout.
+type Bulkhead struct {
+	name         string
+	slots        chan struct{}
+	queueTimeout time.Duration
+}
+
+// NewBulkhead allows maxConcurrent calls at once; a call waits at most
+// queueTimeout for a slot, and not at all when it is 0
+func NewBulkhead(name string, maxConcurrent int, queueTimeout time.Duration) *Bulkhead {
+	return &Bulkhead{
+		name:         name,
+		slots:        make(chan struct{}, maxConcurrent),
+		queueTimeout: queueTimeout,
+	}
+}
+
+// Acquire takes a slot, waiting for one until the queue timeout or until ctx
+// is done, and returns how long it waited. Call release once the call ended.
+func (b *Bulkhead) Acquire(ctx context.Context) (release func(), waited time.Duration, err error) {
+	release = func() { <-b.slots }
+
+	select {
+	case b.slots <- struct{}{}:
+		return release, 0, nil
+	default:
+	}
+	if b.queueTimeout <= 0 {
+		return nil, 0, fmt.Errorf("%w - %d calls to %s in flight", ErrBulkheadFull, cap(b.slots), b.name)
+	}
+
+	start := time.Now()
+	timer := time.NewTimer(b.queueTimeout)
+	defer timer.Stop()
+	select {
+	case b.slots <- struct{}{}:
+		return release, time.Since(start), nil
+	case <-timer.C:
+		return nil, time.Since(start), fmt.Errorf("%w - %d calls to %s in flight for %s", ErrBulkheadFull, cap(b.slots), b.name, b.queueTimeout)
+	case <-ctx.Done():
+		return nil, time.Since(start), ctx.Err()
+	}
+}
+
+// InFlight returns the calls holding a slot
+func (b *Bulkhead) InFlight() int {
+	return len(b.slots)
+}
@@ -0,0 +1,385 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type CircuitState string
+
+const (
+	StateClosed CircuitState = "CLOSED" // Normal operation
+	StateOpen   CircuitState = "OPEN"   // Failing, reject requests
+	StateHalf   CircuitState = "HALF"   // Testing if service recovered
+	// Set by an operator, e.g. for planned maintenance of the service; calls
+	// do not move the breaker out of them
+	StateForcedOpen   CircuitState = "FORCED_OPEN"   // Reject requests
+	StateForcedClosed CircuitState = "FORCED_CLOSED" // Let every request through
+)
+
+// ErrOpen is returned by Execute, wrapped, when the breaker rejects the call
+var ErrOpen = errors.New("circuit breaker is open")
+
+// StateChange is a transition of a breaker, passed to its callbacks
+type StateChange struct {
+	Name   string
+	From   CircuitState
+	To     CircuitState
+	At     time.Time
+	Reason string
+}
+
+// StateChangeFunc is called after every transition, outside the breaker's
+// lock, so it may read the breaker
+type StateChangeFunc func(change StateChange)
+
+// CircuitBreaker prevents cascading failures to external services. All of
+// its state changes under one mutex, and it is safe for concurrent use.
+type CircuitBreaker struct {
+	name             string
+	state            CircuitState
+	failureCount     int
+	successCount     int
+	openedAt         time.Time
+	failureThreshold int
+	successThreshold int
+	timeout          time.Duration
+	// Trial calls let through at once while half-open, and those in flight
+	maxTrials int
+	trials    int
+	// Deadline of each call made through Execute, 0 for none
+	callTimeout time.Duration
+	// Tells which errors of Execute's calls are failures of the service; all
+	// of them when nil
+	isFailure func(err error) bool
+	// Set when the breaker trips on the failure rate of the calls in a
+	// rolling window rather than on consecutive failures
+	window      *rollingWindow
+	failureRate float64 // percent
+	minRequests int
+	onChange    []StateChangeFunc
+	// Tells the time; time.Now unless WithClock replaced it
+	clock func() time.Time
+	mu    sync.Mutex
+}
+
+// NewCircuitBreaker creates a closed breaker, named after the service it
+// guards, that opens after failureThreshold consecutive failures and closes
+// again after successThreshold successful trials once timeout has passed.
+// One trial runs at a time unless WithHalfOpenRequests allows more.
+func NewCircuitBreaker(name string, failureThreshold, successThreshold int, timeout time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		name:             name,
+		state:            StateClosed,
+		failureThreshold: failureThreshold,
+		successThreshold: successThreshold,
+		timeout:          timeout,
+		maxTrials:        1,
+		clock:            time.Now,
+	}
+}
+
+// WithHalfOpenRequests lets up to n trial calls run at once while half-open;
+// the calls beyond are rejected like while open
+func (cb *CircuitBreaker) WithHalfOpenRequests(n int) *CircuitBreaker {
+	cb.maxTrials = n
+	return cb
+}
+
+// WithFailureRate makes the breaker trip when at least rate percent of the
+// calls of the last period failed, counting only once there were minRequests
+// calls in it, instead of after failureThreshold consecutive failures. A
+// handful of failures among many successes, as in a partial brownout, then
+// leave it closed while a mostly failing service still opens it.
+func (cb *CircuitBreaker) WithFailureRate(rate float64, period time.Duration, minRequests int) *CircuitBreaker {
+	cb.window = newRollingWindow(period)
+	cb.failureRate = rate
+	cb.minRequests = minRequests
+	return cb
+}
+
+// WithCallTimeout bounds every call made through Execute; a call running out
+// of time counts as a failure
+func (cb *CircuitBreaker) WithCallTimeout(timeout time.Duration) *CircuitBreaker {
+	cb.callTimeout = timeout
+	return cb
+}
+
+// WithFailureFilter counts only the errors isFailure accepts as failures of
+// the service. The others, e.g. a request the service refused as invalid,
+// show it answering and count as successes.
+func (cb *CircuitBreaker) WithFailureFilter(isFailure func(err error) bool) *CircuitBreaker {
+	cb.isFailure = isFailure
+	return cb
+}
+
+// WithClock makes the breaker tell the time with now, e.g. to let the
+// timeout pass in tests without waiting for it
+func (cb *CircuitBreaker) WithClock(now func() time.Time) *CircuitBreaker {
+	cb.clock = now
+	return cb
+}
+
+// OnStateChange registers fn to be told about transitions; register
+// callbacks before the breaker is shared
+func (cb *CircuitBreaker) OnStateChange(fn StateChangeFunc) *CircuitBreaker {
+	cb.onChange = append(cb.onChange, fn)
+	return cb
+}
+
+// Name returns the name of the guarded service
+func (cb *CircuitBreaker) Name() string {
+	return cb.name
+}
+
+// Execute calls fn when the breaker allows it and records the outcome. fn
+// gets a context bounded by the call timeout. Calls rejected by the breaker
+// return an error wrapping ErrOpen; calls abandoned because ctx itself ended
+// are neither successes nor failures of the service.
+func (cb *CircuitBreaker) Execute(ctx context.Context, fn func(ctx context.Context) error) error {
+	trial, err := cb.admit()
+	if err != nil {
+		return err
+	}
+
+	callCtx := ctx
+	if cb.callTimeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, cb.callTimeout)
+		defer cancel()
+	}
+
+	err = fn(callCtx)
+	switch {
+	case err == nil:
+		cb.record(true, trial)
+	case ctx.Err() != nil:
+		// The caller gave up, the service did not fail
+		cb.abandon(trial)
+	case cb.isFailure != nil && !cb.isFailure(err):
+		cb.record(true, trial)
+	default:
+		cb.record(false, trial)
+	}
+	return err
+}
+
+// CanExecute checks if a request can be attempted. A request let through
+// must be followed by RecordSuccess or RecordFailure, which end the trial it
+// may be while half-open.
+func (cb *CircuitBreaker) CanExecute() (bool, error) {
+	if _, err := cb.admit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RecordSuccess records a successful call
+func (cb *CircuitBreaker) RecordSuccess() {
+	cb.record(true, true)
+}
+
+// RecordFailure records a failed call
+func (cb *CircuitBreaker) RecordFailure() {
+	cb.record(false, true)
+}
+
+// admit decides whether a call may go ahead, and whether it is a trial of a
+// half-open breaker. The first call after the timeout moves an open breaker
+// to half-open.
+func (cb *CircuitBreaker) admit() (trial bool, err error) {
+	cb.mu.Lock()
+	var change *StateChange
+	defer func() {
+		cb.mu.Unlock()
+		cb.notify(change)
+	}()
+
+	switch cb.state {
+	case StateClosed, StateForcedClosed:
+		return false, nil
+
+	case StateOpen:
+		if cb.clock().Sub(cb.openedAt) < cb.timeout {
+			return false, fmt.Errorf("%w - %s unavailable", ErrOpen, cb.name)
+		}
+		change = cb.setState(StateHalf, "testing recovery")
+		fallthrough
+
+	case StateHalf:
+		if cb.trials >= cb.maxTrials {
+			return false, fmt.Errorf("%w - %s is being tested for recovery", ErrOpen, cb.name)
+		}
+		cb.trials++
+		return true, nil
+
+	case StateForcedOpen:
+		return false, fmt.Errorf("%w by an operator - %s unavailable", ErrOpen, cb.name)
+
+	default:
+		return false, fmt.Errorf("unknown circuit breaker state: %s", cb.state)
+	}
+}
+
+// record counts the outcome of a call. While half-open only the outcome of a
+// trial counts: calls let through before the breaker opened say nothing
+// about the recovery. While open or forced, outcomes are ignored.
+func (cb *CircuitBreaker) record(success, trial bool) {
+	cb.mu.Lock()
+	var change *StateChange
+	now := cb.clock()
+
+	switch cb.state {
+	case StateClosed:
+		if cb.window != nil {
+			cb.window.record(now, !success)
+		}
+		if success {
+			cb.failureCount = 0
+		} else {
+			cb.failureCount++
+			if cb.tripped(now) {
+				change = cb.open(now, "too many failures ("+strconv.Itoa(cb.failureCount)+")")
+			}
+		}
+
+	case StateHalf:
+		if !trial {
+			break
+		}
+		cb.trials = max(cb.trials-1, 0)
+		if !success {
+			change = cb.open(now, "trial failed")
+			break
+		}
+		cb.successCount++
+		if cb.successCount >= cb.successThreshold {
+			change = cb.setState(StateClosed, "service recovered")
+		}
+	}
+	cb.mu.Unlock()
+	cb.notify(change)
+}
+
+// abandon frees the slot of a trial that ended without an outcome
+func (cb *CircuitBreaker) abandon(trial bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if trial && cb.state == StateHalf {
+		cb.trials = max(cb.trials-1, 0)
+	}
+}
+
+// tripped tells whether the failure just recorded opens a closed breaker;
+// callers hold mu
+func (cb *CircuitBreaker) tripped(now time.Time) bool {
+	if cb.window == nil {
+		return cb.failureCount >= cb.failureThreshold
+	}
+	calls, failures := cb.window.counts(now)
+	return calls >= cb.minRequests && float64(failures)*100 >= cb.failureRate*float64(calls)
+}
+
+// open moves the breaker to open from now; callers hold mu
+func (cb *CircuitBreaker) open(now time.Time, reason string) *StateChange {
+	cb.openedAt = now
+	return cb.setState(StateOpen, reason)
+}
+
+// OpenFor returns how long the breaker has been open, 0 when it is not
+func (cb *CircuitBreaker) OpenFor() time.Duration {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state != StateOpen {
+		return 0
+	}
+	return cb.clock().Sub(cb.openedAt)
+}
+
+// Reset closes the breaker, e.g. after the service was fixed by hand, so
+// calls go through without waiting for the timeout. It also ends a forced
+// state, handing the breaker back to the calls.
+func (cb *CircuitBreaker) Reset() {
+	cb.force(StateClosed, "reset")
+}
+
+// ForceOpen rejects every call until Reset or ForceClose, e.g. while the
+// service is down for planned maintenance
+func (cb *CircuitBreaker) ForceOpen() {
+	cb.force(StateForcedOpen, "forced open")
+}
+
+// ForceClose lets every call through, whatever fails, until Reset or
+// ForceOpen
+func (cb *CircuitBreaker) ForceClose() {
+	cb.force(StateForcedClosed, "forced closed")
+}
+
+func (cb *CircuitBreaker) force(state CircuitState, reason string) {
+	cb.mu.Lock()
+	change := cb.setState(state, reason)
+	cb.failureCount = 0
+	cb.mu.Unlock()
+	cb.notify(change)
+}
+
+// Ready tells whether a call made now would be let through, without letting
+// one through. Unlike GetState it sees an open breaker whose timeout passed
+// as ready: the breaker only moves to half-open on the next call, so callers
+// waiting for it to leave the open state would wait forever without traffic.
+func (cb *CircuitBreaker) Ready() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case StateClosed, StateForcedClosed:
+		return true
+	case StateOpen:
+		return cb.clock().Sub(cb.openedAt) >= cb.timeout
+	case StateHalf:
+		return cb.trials < cb.maxTrials
+	default:
+		return false
+	}
+}
+
+// GetState returns the current state
+func (cb *CircuitBreaker) GetState() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
+
+// setState moves the breaker to state and returns the transition, nil when
+// it was already there; callers hold mu and pass the result to notify once
+// they released it
+func (cb *CircuitBreaker) setState(state CircuitState, reason string) *StateChange {
+	if cb.state == state {
+		return nil
+	}
+	change := &StateChange{Name: cb.name, From: cb.state, To: state, At: cb.clock(), Reason: reason}
+	cb.state = state
+	// Every state starts its counts afresh
+	cb.failureCount = 0
+	cb.successCount = 0
+	cb.trials = 0
+	if cb.window != nil {
+		cb.window.reset()
+	}
+	return change
+}
+
+func (cb *CircuitBreaker) notify(change *StateChange) {
+	if change == nil {
+		return
+	}
+	for _, fn := range cb.onChange {
+		fn(*change)
+	}
+}
@@ -0,0 +1,137 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock tests move by hand
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+var errUnavailable = errors.New("service unavailable")
+
+func fail(ctx context.Context) error    { return errUnavailable }
+func succeed(ctx context.Context) error { return nil }
+
+// trip opens the breaker with consecutive failures
+func trip(t *testing.T, cb *CircuitBreaker) {
+	t.Helper()
+	for range cb.failureThreshold {
+		_ = cb.Execute(context.Background(), fail)
+	}
+	if state := cb.GetState(); state != StateOpen {
+		t.Fatalf("breaker is %s after %d failures, want %s", state, cb.failureThreshold, StateOpen)
+	}
+}
+
+func TestCircuitBreakerRecovery(t *testing.T) {
+	tests := []struct {
+		name      string
+		elapsed   time.Duration
+		trial     func(ctx context.Context) error
+		wantReady bool
+		wantErr   error
+		wantState CircuitState
+	}{
+		{name: "before the timeout", elapsed: 29 * time.Second, trial: succeed, wantErr: ErrOpen, wantState: StateOpen},
+		{name: "successful trial", elapsed: 30 * time.Second, trial: succeed, wantReady: true, wantState: StateClosed},
+		{name: "failed trial", elapsed: time.Minute, trial: fail, wantReady: true, wantErr: errUnavailable, wantState: StateOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			cb := NewCircuitBreaker("legacy-api", 3, 1, 30*time.Second).WithClock(clock.Now)
+			trip(t, cb)
+
+			clock.Advance(tt.elapsed)
+			if ready := cb.Ready(); ready != tt.wantReady {
+				t.Fatalf("Ready() = %v, want %v", ready, tt.wantReady)
+			}
+			// Ready does not move the breaker; only the next call does
+			if state := cb.GetState(); state != StateOpen {
+				t.Fatalf("Ready() moved the breaker to %s", state)
+			}
+
+			err := cb.Execute(context.Background(), tt.trial)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Execute() = %v, want %v", err, tt.wantErr)
+			}
+			if state := cb.GetState(); state != tt.wantState {
+				t.Fatalf("breaker is %s, want %s", state, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerHalfOpenTrials(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxTrials int
+	}{
+		{name: "one trial", maxTrials: 1},
+		{name: "three trials", maxTrials: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			cb := NewCircuitBreaker("legacy-api", 1, 10, time.Second).WithClock(clock.Now).WithHalfOpenRequests(tt.maxTrials)
+			trip(t, cb)
+			clock.Advance(time.Second)
+
+			// Hold the trials in flight: every other call is rejected
+			const callers = 10
+			release := make(chan struct{})
+			results := make(chan error, callers)
+			for range callers {
+				go func() {
+					results <- cb.Execute(context.Background(), func(ctx context.Context) error {
+						<-release
+						return nil
+					})
+				}()
+			}
+			for range callers - tt.maxTrials {
+				if err := <-results; !errors.Is(err, ErrOpen) {
+					t.Fatalf("call beyond the trials returned %v, want %v", err, ErrOpen)
+				}
+			}
+			if cb.Ready() {
+				t.Fatalf("Ready() with all %d trials in flight", tt.maxTrials)
+			}
+
+			close(release)
+			for range tt.maxTrials {
+				if err := <-results; err != nil {
+					t.Fatalf("trial returned %v", err)
+				}
+			}
+			if state := cb.GetState(); state != StateHalf {
+				t.Fatalf("breaker is %s, want %s", state, StateHalf)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package resilience
+
+import "time"
+
+// windowBuckets is the number of slices a rolling window is kept in; calls
+// expire a slice at a time
+const windowBuckets = 10
+
+// rollingWindow counts the outcomes of the calls made over the last period.
+// It is not safe for concurrent use; the breaker guards it with its lock.
+type rollingWindow struct {
+	period  time.Duration
+	buckets [windowBuckets]windowBucket
+}
+
+type windowBucket struct {
+	start     time.Time
+	successes int
+	failures  int
+}
+
+func newRollingWindow(period time.Duration) *rollingWindow {
+	return &rollingWindow{period: period}
+}
+
+// record counts the outcome of a call ending at now
+func (w *rollingWindow) record(now time.Time, failed bool) {
+	width := w.period / windowBuckets
+	start := now.Truncate(width)
+	bucket := &w.buckets[int(start.UnixNano()/int64(width))%windowBuckets]
+	if !bucket.start.Equal(start) {
+		*bucket = windowBucket{start: start}
+	}
+	if failed {
+		bucket.failures++
+	} else {
+		bucket.successes++
+	}
+}
+
+// counts returns the calls and failures of the period ending at now
+func (w *rollingWindow) counts(now time.Time) (calls, failures int) {
+	for _, bucket := range w.buckets {
+		if now.Sub(bucket.start) >= w.period {
+			continue
+		}
+		calls += bucket.successes + bucket.failures
+		failures += bucket.failures
+	}
+	return calls, failures
+}
+
+func (w *rollingWindow) reset() {
+	w.buckets = [windowBuckets]windowBucket{}
+}
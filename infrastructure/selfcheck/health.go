@@ -0,0 +1,116 @@
+package selfcheck
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"go.uber.org/zap"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// DependencyHealth caches whether the database shards and the broker answer.
+// Probes read the cached report, so however often they hit a replica, each
+// replica checks its dependencies once per interval; the jitter keeps
+// replicas started together from checking in lockstep.
+type DependencyHealth struct {
+	checker  *Checker
+	interval time.Duration
+	jitter   time.Duration
+	timeout  time.Duration
+
+	mu     sync.RWMutex
+	report *Report
+}
+
+func NewDependencyHealth(checker *Checker, interval, jitter, timeout time.Duration) *DependencyHealth {
+	return &DependencyHealth{
+		checker:  checker,
+		interval: interval,
+		jitter:   jitter,
+		timeout:  timeout,
+	}
+}
+
+// Run refreshes the report until ctx is done, starting right away
+func (h *DependencyHealth) Run(ctx context.Context) {
+	for {
+		report := h.Refresh(ctx)
+		if !report.Healthy {
+			config.Logger.Warn("Dependency check failed", zap.String("failed", report.Summary()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(h.nextDelay()):
+		}
+	}
+}
+
+// nextDelay is the interval shifted by up to jitter either way
+func (h *DependencyHealth) nextDelay() time.Duration {
+	if h.jitter <= 0 {
+		return h.interval
+	}
+	return max(h.interval+rand.N(2*h.jitter)-h.jitter, time.Second)
+}
+
+// Refresh checks every dependency now and caches the report. Without a
+// broker URL (local mode) the broker is not checked.
+func (h *DependencyHealth) Refresh(ctx context.Context) *Report {
+	report := &Report{
+		Mode:      "dependencies",
+		CheckedAt: time.Now(),
+		Healthy:   true,
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	report.add(h.checker.pingDatabase(ctx))
+	if h.checker.rabbitURL != "" {
+		report.add(h.checker.pingBroker(h.timeout))
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.report = report
+	return report
+}
+
+// Last returns the cached report, nil before the first check completed
+func (h *DependencyHealth) Last() *Report {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.report
+}
+
+func (c *Checker) pingDatabase(ctx context.Context) CheckResult {
+	result := CheckResult{Name: "database"}
+
+	for i, db := range c.shards.All() {
+		if err := db.PingContext(ctx); err != nil {
+			result.Problems = append(result.Problems, fmt.Sprintf("shard %d: %v", i, err))
+		}
+	}
+
+	return result
+}
+
+func (c *Checker) pingBroker(timeout time.Duration) CheckResult {
+	result := CheckResult{Name: "rabbitmq"}
+
+	conn, err := amqp.DialConfig(c.rabbitURL, amqp.Config{Dial: amqp.DefaultDial(timeout)})
+	if err != nil {
+		result.Problems = append(result.Problems, fmt.Sprintf("failed to connect: %v", err))
+		return result
+	}
+	conn.Close()
+
+	return result
+}
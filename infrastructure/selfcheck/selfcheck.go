@@ -0,0 +1,146 @@
+// Package selfcheck verifies on startup that the database schema, the broker
+// topology and the configuration match what this binary expects.
+package selfcheck
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"github.com/leo-andrei/check-in-service/infrastructure/messaging"
+	"github.com/leo-andrei/check-in-service/infrastructure/persistence"
+	"go.uber.org/zap"
+)
+
+const (
+	ModeStrict   = "strict"   // refuse to start on any failed check
+	ModeDegraded = "degraded" // log failures and start anyway
+	ModeOff      = "off"
+)
+
+type Status string
+
+const (
+	StatusOK     Status = "ok"
+	StatusFailed Status = "failed"
+)
+
+type CheckResult struct {
+	Name     string   `json:"name"`
+	Status   Status   `json:"status"`
+	Problems []string `json:"problems,omitempty"`
+}
+
+type Report struct {
+	Mode      string        `json:"mode"`
+	CheckedAt time.Time     `json:"checked_at"`
+	Healthy   bool          `json:"healthy"`
+	Checks    []CheckResult `json:"checks"`
+}
+
+type Checker struct {
+	shards    *persistence.ShardSet
+	rabbitURL string
+	topology  messaging.Topology
+	cfg       *config.Config
+}
+
+func NewChecker(shards *persistence.ShardSet, rabbitURL string, topology messaging.Topology, cfg *config.Config) *Checker {
+	return &Checker{
+		shards:    shards,
+		rabbitURL: rabbitURL,
+		topology:  topology,
+		cfg:       cfg,
+	}
+}
+
+// Run executes all checks and logs a structured report. Without a broker
+// URL (local mode, Kafka) the RabbitMQ topology is not checked.
+func (c *Checker) Run(ctx context.Context, mode string) *Report {
+	report := &Report{
+		Mode:      mode,
+		CheckedAt: time.Now(),
+		Healthy:   true,
+	}
+
+	report.add(c.checkSchema(ctx))
+	if c.rabbitURL != "" {
+		report.add(c.checkTopology())
+	}
+	report.add(c.checkConfig())
+
+	for _, check := range report.Checks {
+		if check.Status == StatusOK {
+			config.Logger.Info("Startup check passed", zap.String("check", check.Name))
+			continue
+		}
+		config.Logger.Error("Startup check failed", zap.String("check", check.Name), zap.Strings("problems", check.Problems))
+	}
+	config.Logger.Info("Startup self-check completed", zap.String("mode", mode), zap.Bool("healthy", report.Healthy))
+
+	return report
+}
+
+func (r *Report) add(result CheckResult) {
+	if len(result.Problems) > 0 {
+		result.Status = StatusFailed
+		r.Healthy = false
+	} else {
+		result.Status = StatusOK
+	}
+	r.Checks = append(r.Checks, result)
+}
+
+func (c *Checker) checkSchema(ctx context.Context) CheckResult {
+	result := CheckResult{Name: "database_schema"}
+
+	for i, db := range c.shards.All() {
+		missing, err := persistence.VerifySchema(ctx, db)
+		if err != nil {
+			result.Problems = append(result.Problems, fmt.Sprintf("shard %d: %v", i, err))
+			continue
+		}
+		for _, column := range missing {
+			result.Problems = append(result.Problems, fmt.Sprintf("shard %d: missing column %s", i, column))
+		}
+	}
+
+	return result
+}
+
+func (c *Checker) checkTopology() CheckResult {
+	result := CheckResult{Name: "rabbitmq_topology"}
+
+	conn, err := messaging.Dial(c.rabbitURL)
+	if err != nil {
+		result.Problems = append(result.Problems, fmt.Sprintf("failed to connect: %v", err))
+		return result
+	}
+	defer conn.Close()
+
+	for _, mismatch := range c.topology.Verify(conn) {
+		result.Problems = append(result.Problems, fmt.Sprintf("%s: %v", mismatch.Entity, mismatch.Err))
+	}
+
+	return result
+}
+
+func (c *Checker) checkConfig() CheckResult {
+	return CheckResult{
+		Name:     "config",
+		Problems: c.cfg.Inconsistencies(),
+	}
+}
+
+// Summary is a one-line description of the failed checks
+func (r *Report) Summary() string {
+	var failed []string
+	for _, check := range r.Checks {
+		if check.Status == StatusFailed {
+			failed = append(failed, fmt.Sprintf("%s (%s)", check.Name, strings.Join(check.Problems, "; ")))
+		}
+	}
+	return strings.Join(failed, ", ")
+}
@@ -0,0 +1,127 @@
+package selfcheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leo-andrei/check-in-service/infrastructure/messaging"
+	"github.com/leo-andrei/check-in-service/infrastructure/persistence"
+)
+
+// warmUpEventType is never relayed: its outbox row is rolled back before the
+// relay could read it, and the round-trip message goes to a private queue
+const warmUpEventType = "warmup.ping"
+
+// WarmUp verifies the schema, prepares the hot queries, declares the broker
+// topology and sends one synthetic event through the outbox and the broker,
+// each step waiting at most timeout. Without a broker URL (local mode) the
+// broker steps are skipped.
+func (c *Checker) WarmUp(ctx context.Context, timeout time.Duration) *Report {
+	report := &Report{
+		Mode:      "warmup",
+		CheckedAt: time.Now(),
+		Healthy:   true,
+	}
+
+	step := func(check func(ctx context.Context) CheckResult) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		report.add(check(ctx))
+	}
+
+	step(c.checkSchema)
+	step(c.prepareHotQueries)
+	if c.rabbitURL != "" {
+		step(func(context.Context) CheckResult { return c.checkTopology() })
+	}
+	step(c.outboxRoundTrip)
+
+	return report
+}
+
+func (c *Checker) prepareHotQueries(ctx context.Context) CheckResult {
+	result := CheckResult{Name: "prepared_statements"}
+
+	for i, db := range c.shards.All() {
+		if err := persistence.PrepareHotQueries(ctx, db); err != nil {
+			result.Problems = append(result.Problems, fmt.Sprintf("shard %d: %v", i, err))
+		}
+	}
+
+	return result
+}
+
+// outboxRoundTrip writes the synthetic event to the outbox of every shard
+// and publishes what was read back to the broker's test route
+func (c *Checker) outboxRoundTrip(ctx context.Context) CheckResult {
+	result := CheckResult{Name: "outbox_round_trip"}
+
+	payload, err := json.Marshal(map[string]string{"id": uuid.New().String(), "at": time.Now().UTC().Format(time.RFC3339Nano)})
+	if err != nil {
+		result.Problems = append(result.Problems, err.Error())
+		return result
+	}
+
+	for i, db := range c.shards.All() {
+		stored, err := persistence.OutboxRoundTrip(ctx, db, warmUpEventType, payload)
+		if err != nil {
+			result.Problems = append(result.Problems, fmt.Sprintf("shard %d: %v", i, err))
+			continue
+		}
+		payload = stored
+	}
+	if len(result.Problems) > 0 || c.rabbitURL == "" {
+		return result
+	}
+
+	conn, err := messaging.Dial(c.rabbitURL)
+	if err != nil {
+		result.Problems = append(result.Problems, fmt.Sprintf("failed to connect: %v", err))
+		return result
+	}
+	defer conn.Close()
+
+	if err := messaging.RoundTrip(ctx, conn, warmUpEventType, payload); err != nil {
+		result.Problems = append(result.Problems, err.Error())
+	}
+
+	return result
+}
+
+// Readiness holds the warm-up outcome behind the readiness probe. Once a
+// warm-up succeeded the service stays ready.
+type Readiness struct {
+	mu     sync.RWMutex
+	report *Report
+	ready  bool
+}
+
+func NewReadiness() *Readiness {
+	return &Readiness{}
+}
+
+// Record stores the report of a warm-up attempt
+func (r *Readiness) Record(report *Report) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.report = report
+	r.ready = r.ready || report.Healthy
+}
+
+func (r *Readiness) Ready() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.ready
+}
+
+// Last returns the report of the last warm-up attempt, nil before the first
+// one completed
+func (r *Readiness) Last() *Report {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.report
+}
@@ -0,0 +1,21 @@
+package http
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// adminUIPage is a single static page calling the admin API from the browser
+// with the key the operator enters; it holds no data itself
+//
+//go:embed admin_ui/index.html
+var adminUIPage []byte
+
+// AdminUI handles GET /admin/ui
+func AdminUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("Content-Security-Policy", "default-src 'self'; script-src 'unsafe-inline'; style-src 'unsafe-inline'")
+	w.Write(adminUIPage)
+}
@@ -0,0 +1,178 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/leo-andrei/check-in-service/application/services"
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+)
+
+type ApprovalHandler struct {
+	approvalService *services.ApprovalService
+	commands        *services.CommandBus
+}
+
+func NewApprovalHandler(approvalService *services.ApprovalService, commands *services.CommandBus) *ApprovalHandler {
+	return &ApprovalHandler{
+		approvalService: approvalService,
+		commands:        commands,
+	}
+}
+
+type ApprovalRequest struct {
+	Kind       string    `json:"kind" validate:"required,oneof=CORRECTION MANUAL_ENTRY"`
+	RecordID   string    `json:"record_id" validate:"required_if=Kind CORRECTION,omitempty,uuid"`
+	CheckInAt  time.Time `json:"check_in_at" validate:"required"`
+	CheckOutAt time.Time `json:"check_out_at" validate:"required"`
+	TimeZone   string    `json:"time_zone" validate:"max=64"`
+	Note       string    `json:"note" validate:"max=1000"`
+}
+
+type DecisionRequest struct {
+	Comment string `json:"comment" validate:"max=1000"`
+}
+
+type ApprovalResponse struct {
+	ID                 string         `json:"id"`
+	EmployeeID         string         `json:"employee_id"`
+	ManagerID          string         `json:"manager_id,omitempty"`
+	Kind               string         `json:"kind"`
+	RecordID           string         `json:"record_id,omitempty"`
+	ProposedCheckInAt  time.Time      `json:"proposed_check_in_at"`
+	ProposedCheckOutAt time.Time      `json:"proposed_check_out_at"`
+	TimeZone           string         `json:"time_zone"`
+	Note               string         `json:"note,omitempty"`
+	Status             string         `json:"status"`
+	DecidedBy          string         `json:"decided_by,omitempty"`
+	DecisionComment    string         `json:"decision_comment,omitempty"`
+	DecidedAt          *time.Time     `json:"decided_at,omitempty"`
+	CreatedAt          time.Time      `json:"created_at"`
+	RecordNotes        []NoteResponse `json:"record_notes,omitempty"`
+}
+
+func toApprovalResponse(a *entities.Approval) ApprovalResponse {
+	return ApprovalResponse{
+		ID:                 a.ID,
+		EmployeeID:         a.EmployeeID,
+		ManagerID:          a.ManagerID,
+		Kind:               string(a.Kind),
+		RecordID:           a.RecordID,
+		ProposedCheckInAt:  a.ProposedCheckInAt,
+		ProposedCheckOutAt: a.ProposedCheckOutAt,
+		TimeZone:           a.TimeZone,
+		Note:               a.Note,
+		Status:             string(a.Status),
+		DecidedBy:          a.DecidedBy,
+		DecisionComment:    a.DecisionComment,
+		DecidedAt:          a.DecidedAt,
+		CreatedAt:          a.CreatedAt,
+	}
+}
+
+// SubmitApproval handles POST /api/employees/{id}/approvals
+func (h *ApprovalHandler) SubmitApproval(w http.ResponseWriter, r *http.Request) {
+	var req ApprovalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, errors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	if err := validator.New().Struct(&req); err != nil {
+		http.Error(w, errors.ErrInvalidApproval, http.StatusBadRequest)
+		return
+	}
+
+	approval, err := h.commands.CorrectRecord(r.Context(), services.CorrectRecordCommand{
+		EmployeeID: r.PathValue("id"),
+		Submission: services.ApprovalSubmission{
+			Kind:       entities.ApprovalKind(req.Kind),
+			RecordID:   req.RecordID,
+			CheckInAt:  req.CheckInAt,
+			CheckOutAt: req.CheckOutAt,
+			TimeZone:   req.TimeZone,
+			Note:       req.Note,
+		},
+	})
+	if err != nil {
+		writeApprovalError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, toApprovalResponse(approval))
+}
+
+// ListPending handles GET /api/admin/approvals?manager_id=
+func (h *ApprovalHandler) ListPending(w http.ResponseWriter, r *http.Request) {
+	approvals, err := h.approvalService.Pending(r.Context(), r.URL.Query().Get("manager_id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]ApprovalResponse, 0, len(approvals))
+	for _, a := range approvals {
+		item := toApprovalResponse(a)
+		notes, err := h.approvalService.Notes(r.Context(), a)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for _, n := range notes {
+			item.RecordNotes = append(item.RecordNotes, toNoteResponse(n))
+		}
+		resp = append(resp, item)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Approve handles POST /api/admin/approvals/{id}/approve
+func (h *ApprovalHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, true)
+}
+
+// Reject handles POST /api/admin/approvals/{id}/reject
+func (h *ApprovalHandler) Reject(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, false)
+}
+
+func (h *ApprovalHandler) decide(w http.ResponseWriter, r *http.Request, approve bool) {
+	var req DecisionRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, errors.ErrInvalidRequestBody, http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := validator.New().Struct(&req); err != nil {
+		http.Error(w, errors.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	approval, err := h.approvalService.Decide(r.Context(), r.PathValue("id"), approve, actorFromContext(r.Context()), req.Comment)
+	if err != nil {
+		writeApprovalError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toApprovalResponse(approval))
+}
+
+func writeApprovalError(w http.ResponseWriter, err error) {
+	switch err {
+	case errors.ErrApprovalNotFoundConst, errors.ErrRecordNotFoundConst:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.ErrInvalidApprovalConst, errors.ErrInvalidTimeZoneConst, errors.ErrInvalidCommandConst:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.ErrNotApproverConst, errors.ErrCommandForbiddenConst:
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.ErrApprovalAlreadyDecidedConst:
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		writeRepositoryError(w, err)
+	}
+}
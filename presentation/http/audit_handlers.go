@@ -0,0 +1,71 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+)
+
+type AuditHandler struct {
+	changes repositories.RecordChangeRepository
+}
+
+func NewAuditHandler(changes repositories.RecordChangeRepository) *AuditHandler {
+	return &AuditHandler{changes: changes}
+}
+
+type RecordChangeResponse struct {
+	ID         string                     `json:"id"`
+	EmployeeID string                     `json:"employee_id"`
+	Operation  string                     `json:"operation"`
+	Old        map[string]json.RawMessage `json:"old,omitempty"`
+	New        map[string]json.RawMessage `json:"new,omitempty"`
+	Actor      string                     `json:"actor"`
+	Source     string                     `json:"source"`
+	ChangedAt  time.Time                  `json:"changed_at"`
+}
+
+type RecordChangesResponse struct {
+	RecordID string                 `json:"record_id"`
+	Changes  []RecordChangeResponse `json:"changes"`
+}
+
+func toRecordChangeResponse(c *entities.RecordChange) RecordChangeResponse {
+	return RecordChangeResponse{
+		ID:         c.ID,
+		EmployeeID: c.EmployeeID,
+		Operation:  string(c.Operation),
+		Old:        c.Old,
+		New:        c.New,
+		Actor:      c.Actor,
+		Source:     c.Source,
+		ChangedAt:  c.ChangedAt,
+	}
+}
+
+// RecordChanges handles GET /api/admin/audit?record_id=
+// It lists every write to the record, oldest first, with the columns it
+// changed, who made it and through which part of the service.
+func (h *AuditHandler) RecordChanges(w http.ResponseWriter, r *http.Request) {
+	recordID := r.URL.Query().Get("record_id")
+	if recordID == "" {
+		http.Error(w, errors.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	changes, err := h.changes.FindByRecordID(r.Context(), recordID)
+	if err != nil {
+		writeRepositoryError(w, err)
+		return
+	}
+
+	response := RecordChangesResponse{RecordID: recordID, Changes: make([]RecordChangeResponse, 0, len(changes))}
+	for _, change := range changes {
+		response.Changes = append(response.Changes, toRecordChangeResponse(change))
+	}
+	writeJSON(w, http.StatusOK, response)
+}
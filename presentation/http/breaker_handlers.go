@@ -0,0 +1,68 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/leo-andrei/check-in-service/domain/errors"
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+	"github.com/leo-andrei/check-in-service/infrastructure/resilience"
+)
+
+// ForcibleBreaker is a circuit breaker an operator can hold open or closed
+type ForcibleBreaker interface {
+	GetState() resilience.CircuitState
+	ForceOpen()
+	ForceClose()
+	Reset()
+}
+
+type BreakerHandler struct {
+	breakers map[string]ForcibleBreaker
+}
+
+func NewBreakerHandler(breakers map[string]ForcibleBreaker) *BreakerHandler {
+	return &BreakerHandler{
+		breakers: breakers,
+	}
+}
+
+type BreakerStateRequest struct {
+	State string `json:"state"` // open, closed or auto
+}
+
+// SetState handles PUT /api/admin/breakers/{name}
+// open rejects every call to the service and closed lets every call through,
+// until auto hands the breaker back to the calls, starting closed. The
+// override only applies to this instance.
+func (h *BreakerHandler) SetState(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	breaker, ok := h.breakers[name]
+	if !ok {
+		http.Error(w, errors.ErrUnknownBreaker, http.StatusNotFound)
+		return
+	}
+
+	var req BreakerStateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, errors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+	switch req.State {
+	case "open":
+		breaker.ForceOpen()
+	case "closed":
+		breaker.ForceClose()
+	case "auto":
+		breaker.Reset()
+	default:
+		http.Error(w, errors.ErrInvalidBreakerState, http.StatusBadRequest)
+		return
+	}
+
+	config.Logger.Info("Circuit breaker overridden", zap.String("breaker", name), zap.String("state", req.State),
+		zap.String("actor", actorFromContext(r.Context())))
+	writeJSON(w, http.StatusOK, CircuitStateResponse{Name: name, State: string(breaker.GetState())})
+}
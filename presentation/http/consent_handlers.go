@@ -0,0 +1,101 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/leo-andrei/check-in-service/application/services"
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+)
+
+type ConsentHandler struct {
+	consentService *services.ConsentService
+}
+
+func NewConsentHandler(consentService *services.ConsentService) *ConsentHandler {
+	return &ConsentHandler{
+		consentService: consentService,
+	}
+}
+
+type ConsentRequest struct {
+	Source string `json:"source" validate:"required,max=50"`
+}
+
+type ConsentResponse struct {
+	EmployeeID  string     `json:"employee_id"`
+	Purpose     string     `json:"purpose"`
+	Active      bool       `json:"active"`
+	Source      string     `json:"source"`
+	GrantedAt   time.Time  `json:"granted_at"`
+	WithdrawnAt *time.Time `json:"withdrawn_at,omitempty"`
+}
+
+func toConsentResponse(c *entities.Consent) ConsentResponse {
+	return ConsentResponse{
+		EmployeeID:  c.EmployeeID,
+		Purpose:     string(c.Purpose),
+		Active:      c.IsActive(),
+		Source:      c.Source,
+		GrantedAt:   c.GrantedAt,
+		WithdrawnAt: c.WithdrawnAt,
+	}
+}
+
+// ListConsents handles GET /api/employees/{id}/consents
+func (h *ConsentHandler) ListConsents(w http.ResponseWriter, r *http.Request) {
+	employeeID := r.PathValue("id")
+
+	consents, err := h.consentService.List(r.Context(), employeeID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]ConsentResponse, 0, len(consents))
+	for _, c := range consents {
+		resp = append(resp, toConsentResponse(c))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// GrantConsent handles PUT /api/employees/{id}/consents/{purpose}
+func (h *ConsentHandler) GrantConsent(w http.ResponseWriter, r *http.Request) {
+	h.changeConsent(w, r, h.consentService.Grant)
+}
+
+// WithdrawConsent handles DELETE /api/employees/{id}/consents/{purpose}
+func (h *ConsentHandler) WithdrawConsent(w http.ResponseWriter, r *http.Request) {
+	h.changeConsent(w, r, h.consentService.Withdraw)
+}
+
+type consentChange func(ctx context.Context, employeeID string, purpose entities.ConsentPurpose, source string) (*entities.Consent, error)
+
+func (h *ConsentHandler) changeConsent(w http.ResponseWriter, r *http.Request, change consentChange) {
+	var req ConsentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, errors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	if err := validator.New().Struct(&req); err != nil {
+		http.Error(w, errors.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	consent, err := change(r.Context(), r.PathValue("id"), entities.ConsentPurpose(r.PathValue("purpose")), req.Source)
+	if err != nil {
+		if err == errors.ErrInvalidConsentPurposeConst {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toConsentResponse(consent))
+}
@@ -0,0 +1,189 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/leo-andrei/check-in-service/application/services"
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+)
+
+type DeviceHandler struct {
+	deviceService *services.DeviceService
+}
+
+func NewDeviceHandler(deviceService *services.DeviceService) *DeviceHandler {
+	return &DeviceHandler{
+		deviceService: deviceService,
+	}
+}
+
+type DeviceRequest struct {
+	Name       string `json:"name" validate:"required,max=255"`
+	LocationID string `json:"location_id" validate:"omitempty,max=50"`
+}
+
+type EnrollRequest struct {
+	Code string `json:"code" validate:"required,max=64"`
+}
+
+type DeviceResponse struct {
+	ID                  string     `json:"id"`
+	Name                string     `json:"name"`
+	LocationID          string     `json:"location_id,omitempty"`
+	Status              string     `json:"status"`
+	CredentialsExpireAt *time.Time `json:"credentials_expire_at,omitempty"`
+	RevokedAt           *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	EnrollmentCode      string     `json:"enrollment_code,omitempty"`
+	EnrollmentExpiresAt *time.Time `json:"enrollment_expires_at,omitempty"`
+}
+
+type DeviceCredentialsResponse struct {
+	DeviceID  string    `json:"device_id"`
+	Secret    string    `json:"secret"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func toDeviceResponse(d *entities.Device) DeviceResponse {
+	return DeviceResponse{
+		ID:                  d.ID,
+		Name:                d.Name,
+		LocationID:          d.LocationID,
+		Status:              string(d.Status),
+		CredentialsExpireAt: d.SecretExpiresAt,
+		RevokedAt:           d.RevokedAt,
+		CreatedAt:           d.CreatedAt,
+		EnrollmentExpiresAt: d.EnrollmentExpiresAt,
+	}
+}
+
+func toEnrollmentResponse(e *services.Enrollment) DeviceResponse {
+	resp := toDeviceResponse(e.Device)
+	resp.EnrollmentCode = e.Code
+	return resp
+}
+
+func toCredentialsResponse(c *services.DeviceCredentials) DeviceCredentialsResponse {
+	return DeviceCredentialsResponse{
+		DeviceID:  c.DeviceID,
+		Secret:    c.Secret,
+		ExpiresAt: c.ExpiresAt,
+	}
+}
+
+// CreateDevice handles POST /api/admin/devices
+func (h *DeviceHandler) CreateDevice(w http.ResponseWriter, r *http.Request) {
+	var req DeviceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, errors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	if err := validator.New().Struct(&req); err != nil {
+		http.Error(w, errors.ErrInvalidDevice, http.StatusBadRequest)
+		return
+	}
+
+	enrollment, err := h.deviceService.Create(r.Context(), req.Name, req.LocationID)
+	if err != nil {
+		writeDeviceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, toEnrollmentResponse(enrollment))
+}
+
+// ListDevices handles GET /api/admin/devices
+func (h *DeviceHandler) ListDevices(w http.ResponseWriter, r *http.Request) {
+	devices, err := h.deviceService.List(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]DeviceResponse, 0, len(devices))
+	for _, d := range devices {
+		resp = append(resp, toDeviceResponse(d))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// ResetEnrollment handles POST /api/admin/devices/{id}/enrollment
+func (h *DeviceHandler) ResetEnrollment(w http.ResponseWriter, r *http.Request) {
+	enrollment, err := h.deviceService.ResetEnrollment(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeDeviceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toEnrollmentResponse(enrollment))
+}
+
+// RevokeDevice handles POST /api/admin/devices/{id}/revoke
+func (h *DeviceHandler) RevokeDevice(w http.ResponseWriter, r *http.Request) {
+	device, err := h.deviceService.Revoke(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeDeviceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toDeviceResponse(device))
+}
+
+// Enroll handles POST /api/devices/{id}/enroll, called once by the kiosk
+func (h *DeviceHandler) Enroll(w http.ResponseWriter, r *http.Request) {
+	var req EnrollRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, errors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	if err := validator.New().Struct(&req); err != nil {
+		http.Error(w, errors.ErrInvalidEnrollmentCode, http.StatusBadRequest)
+		return
+	}
+
+	creds, err := h.deviceService.Enroll(r.Context(), r.PathValue("id"), req.Code)
+	if err != nil {
+		writeDeviceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toCredentialsResponse(creds))
+}
+
+// Rotate handles POST /api/devices/rotate, authenticated with the current credentials
+func (h *DeviceHandler) Rotate(w http.ResponseWriter, r *http.Request) {
+	device := deviceFromContext(r.Context())
+	if device == nil {
+		http.Error(w, errors.ErrDeviceUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	creds, err := h.deviceService.Rotate(r.Context(), device)
+	if err != nil {
+		writeDeviceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toCredentialsResponse(creds))
+}
+
+func writeDeviceError(w http.ResponseWriter, err error) {
+	switch err {
+	case errors.ErrDeviceNotFoundConst:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.ErrInvalidDeviceConst, errors.ErrUnknownLocationConst:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.ErrInvalidEnrollmentCodeConst, errors.ErrDeviceUnauthorizedConst:
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case errors.ErrDeviceRevokedConst:
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
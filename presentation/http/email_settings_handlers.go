@@ -0,0 +1,99 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/leo-andrei/check-in-service/application/services"
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+)
+
+type EmailSettingsHandler struct {
+	settingsService *services.EmailSettingsService
+}
+
+func NewEmailSettingsHandler(settingsService *services.EmailSettingsService) *EmailSettingsHandler {
+	return &EmailSettingsHandler{
+		settingsService: settingsService,
+	}
+}
+
+// CheckOutEmailSettingsRequest replaces all settings; omitted toggles are off
+type CheckOutEmailSettingsRequest struct {
+	LogoURL      string `json:"logo_url" validate:"omitempty,url,max=2048"`
+	FooterText   string `json:"footer_text" validate:"max=2000"`
+	ShowHours    bool   `json:"show_hours"`
+	ShowCost     bool   `json:"show_cost"`
+	ShowOvertime bool   `json:"show_overtime"`
+}
+
+type CheckOutEmailSettingsResponse struct {
+	LogoURL      string     `json:"logo_url"`
+	FooterText   string     `json:"footer_text"`
+	ShowHours    bool       `json:"show_hours"`
+	ShowCost     bool       `json:"show_cost"`
+	ShowOvertime bool       `json:"show_overtime"`
+	UpdatedBy    string     `json:"updated_by,omitempty"`
+	UpdatedAt    *time.Time `json:"updated_at,omitempty"` // Absent while the defaults apply
+}
+
+func toCheckOutEmailSettingsResponse(s *entities.CheckOutEmailSettings) CheckOutEmailSettingsResponse {
+	resp := CheckOutEmailSettingsResponse{
+		LogoURL:      s.LogoURL,
+		FooterText:   s.FooterText,
+		ShowHours:    s.ShowHours,
+		ShowCost:     s.ShowCost,
+		ShowOvertime: s.ShowOvertime,
+		UpdatedBy:    s.UpdatedBy,
+	}
+	if !s.UpdatedAt.IsZero() {
+		resp.UpdatedAt = &s.UpdatedAt
+	}
+	return resp
+}
+
+// GetCheckOutEmail handles GET /api/admin/notifications/checkout-email
+func (h *EmailSettingsHandler) GetCheckOutEmail(w http.ResponseWriter, r *http.Request) {
+	settings, err := h.settingsService.CheckOutEmail(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toCheckOutEmailSettingsResponse(settings))
+}
+
+// SaveCheckOutEmail handles PUT /api/admin/notifications/checkout-email
+func (h *EmailSettingsHandler) SaveCheckOutEmail(w http.ResponseWriter, r *http.Request) {
+	var req CheckOutEmailSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, errors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	if err := validator.New().Struct(&req); err != nil {
+		http.Error(w, errors.ErrInvalidEmailSettings, http.StatusBadRequest)
+		return
+	}
+
+	settings, err := h.settingsService.SaveCheckOutEmail(r.Context(), services.CheckOutEmailDetails{
+		LogoURL:      req.LogoURL,
+		FooterText:   req.FooterText,
+		ShowHours:    req.ShowHours,
+		ShowCost:     req.ShowCost,
+		ShowOvertime: req.ShowOvertime,
+	}, actorFromContext(r.Context()))
+	if err != nil {
+		if err == errors.ErrInvalidEmailSettingsConst {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toCheckOutEmailSettingsResponse(settings))
+}
@@ -0,0 +1,136 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/leo-andrei/check-in-service/application/services"
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+)
+
+type EmployeeHandler struct {
+	employeeService *services.EmployeeService
+}
+
+func NewEmployeeHandler(employeeService *services.EmployeeService) *EmployeeHandler {
+	return &EmployeeHandler{
+		employeeService: employeeService,
+	}
+}
+
+type EmployeeRequest struct {
+	Name       string  `json:"name" validate:"required,max=255"`
+	Email      string  `json:"email" validate:"omitempty,email,max=255"`
+	ManagerID  string  `json:"manager_id" validate:"omitempty,max=50"`
+	HourlyRate float64 `json:"hourly_rate" validate:"gte=0"`
+	Currency   string  `json:"currency" validate:"omitempty,len=3,uppercase"`
+	Active     *bool   `json:"active"`
+}
+
+type EmployeeResponse struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Email      string    `json:"email,omitempty"`
+	ManagerID  string    `json:"manager_id,omitempty"`
+	HourlyRate float64   `json:"hourly_rate"`
+	Currency   string    `json:"currency,omitempty"`
+	Active     bool      `json:"active"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func toEmployeeResponse(e *entities.Employee) EmployeeResponse {
+	return EmployeeResponse{
+		ID:         e.ID,
+		Name:       e.Name,
+		Email:      e.Email,
+		ManagerID:  e.ManagerID,
+		HourlyRate: e.HourlyRate,
+		Currency:   e.Currency,
+		Active:     e.Active,
+		UpdatedAt:  e.UpdatedAt,
+	}
+}
+
+// ListEmployees handles GET /api/admin/employees
+func (h *EmployeeHandler) ListEmployees(w http.ResponseWriter, r *http.Request) {
+	employees, err := h.employeeService.List(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]EmployeeResponse, 0, len(employees))
+	for _, e := range employees {
+		resp = append(resp, toEmployeeResponse(e))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// GetEmployee handles GET /api/admin/employees/{id}
+func (h *EmployeeHandler) GetEmployee(w http.ResponseWriter, r *http.Request) {
+	employee, err := h.employeeService.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeEmployeeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toEmployeeResponse(employee))
+}
+
+// SaveEmployee handles PUT /api/admin/employees/{id}
+func (h *EmployeeHandler) SaveEmployee(w http.ResponseWriter, r *http.Request) {
+	var req EmployeeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, errors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	if err := validator.New().Struct(&req); err != nil {
+		http.Error(w, errors.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	employee, err := h.employeeService.Save(r.Context(), r.PathValue("id"), services.EmployeeDetails{
+		Name:       req.Name,
+		Email:      req.Email,
+		ManagerID:  req.ManagerID,
+		HourlyRate: req.HourlyRate,
+		Currency:   req.Currency,
+		Active:     active,
+	})
+	if err != nil {
+		writeEmployeeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toEmployeeResponse(employee))
+}
+
+// DeleteEmployee handles DELETE /api/admin/employees/{id}
+func (h *EmployeeHandler) DeleteEmployee(w http.ResponseWriter, r *http.Request) {
+	if err := h.employeeService.Delete(r.Context(), r.PathValue("id")); err != nil {
+		writeEmployeeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeEmployeeError(w http.ResponseWriter, err error) {
+	switch err {
+	case errors.ErrEmployeeNotFoundConst:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.ErrInvalidEmployeeConst, errors.ErrUnknownManagerConst:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,164 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/leo-andrei/check-in-service/application/services"
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+)
+
+type ExceptionHandler struct {
+	exceptionService *services.ExceptionService
+}
+
+func NewExceptionHandler(exceptionService *services.ExceptionService) *ExceptionHandler {
+	return &ExceptionHandler{
+		exceptionService: exceptionService,
+	}
+}
+
+type ShiftRequest struct {
+	Weekday    int    `json:"weekday" validate:"min=0,max=6"` // 0 is Sunday
+	Start      string `json:"start" validate:"required,len=5"`
+	End        string `json:"end" validate:"required,len=5"`
+	LocationID string `json:"location_id" validate:"omitempty,max=50"`
+	TimeZone   string `json:"time_zone" validate:"omitempty,max=64"`
+}
+
+type ScheduleRequest struct {
+	Shifts []ShiftRequest `json:"shifts" validate:"max=50,dive"`
+}
+
+type ShiftResponse struct {
+	Weekday    int    `json:"weekday"`
+	Start      string `json:"start"`
+	End        string `json:"end"`
+	LocationID string `json:"location_id,omitempty"`
+	TimeZone   string `json:"time_zone"`
+}
+
+type ScheduleResponse struct {
+	EmployeeID string          `json:"employee_id"`
+	Shifts     []ShiftResponse `json:"shifts"`
+}
+
+type ExceptionResponse struct {
+	ID             string    `json:"id"`
+	EmployeeID     string    `json:"employee_id"`
+	ManagerID      string    `json:"manager_id,omitempty"`
+	Kind           string    `json:"kind"`
+	BusinessDate   string    `json:"business_date"`
+	ScheduledStart time.Time `json:"scheduled_start"`
+	ScheduledEnd   time.Time `json:"scheduled_end"`
+	LocationID     string    `json:"location_id,omitempty"`
+	TimeZone       string    `json:"time_zone"`
+	DetectedAt     time.Time `json:"detected_at"`
+}
+
+type ExceptionReportResponse struct {
+	Date       string              `json:"date"`
+	Exceptions []ExceptionResponse `json:"exceptions"`
+}
+
+func toScheduleResponse(employeeID string, shifts []*entities.ScheduledShift) ScheduleResponse {
+	resp := ScheduleResponse{EmployeeID: employeeID, Shifts: make([]ShiftResponse, 0, len(shifts))}
+	for _, shift := range shifts {
+		resp.Shifts = append(resp.Shifts, ShiftResponse{
+			Weekday:    int(shift.Weekday),
+			Start:      shift.Start,
+			End:        shift.End,
+			LocationID: shift.LocationID,
+			TimeZone:   shift.TimeZone,
+		})
+	}
+	return resp
+}
+
+// GetSchedule handles GET /api/employees/{id}/schedule
+func (h *ExceptionHandler) GetSchedule(w http.ResponseWriter, r *http.Request) {
+	employeeID := r.PathValue("id")
+	shifts, err := h.exceptionService.Schedule(r.Context(), employeeID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, toScheduleResponse(employeeID, shifts))
+}
+
+// SetSchedule handles PUT /api/admin/employees/{id}/schedule, replacing the
+// whole weekly schedule
+func (h *ExceptionHandler) SetSchedule(w http.ResponseWriter, r *http.Request) {
+	var req ScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, errors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	if err := validator.New().Struct(&req); err != nil {
+		http.Error(w, errors.ErrInvalidSchedule, http.StatusBadRequest)
+		return
+	}
+
+	inputs := make([]services.ShiftInput, 0, len(req.Shifts))
+	for _, shift := range req.Shifts {
+		inputs = append(inputs, services.ShiftInput{
+			Weekday:    time.Weekday(shift.Weekday),
+			Start:      shift.Start,
+			End:        shift.End,
+			LocationID: shift.LocationID,
+			TimeZone:   shift.TimeZone,
+		})
+	}
+
+	employeeID := r.PathValue("id")
+	shifts, err := h.exceptionService.SetSchedule(r.Context(), employeeID, inputs)
+	if err != nil {
+		switch err {
+		case errors.ErrEmployeeNotFoundConst:
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.ErrInvalidScheduleConst:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			writeRepositoryError(w, err)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toScheduleResponse(employeeID, shifts))
+}
+
+// ExceptionReport handles GET /api/reports/exceptions?date=YYYY-MM-DD: the
+// shifts scheduled on that date nobody checked in for
+func (h *ExceptionHandler) ExceptionReport(w http.ResponseWriter, r *http.Request) {
+	date := r.URL.Query().Get("date")
+	exceptions, err := h.exceptionService.Report(r.Context(), date)
+	if err != nil {
+		if err == errors.ErrInvalidExceptionDateConst {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeRepositoryError(w, err)
+		return
+	}
+
+	resp := ExceptionReportResponse{Date: date, Exceptions: make([]ExceptionResponse, 0, len(exceptions))}
+	for _, e := range exceptions {
+		resp.Exceptions = append(resp.Exceptions, ExceptionResponse{
+			ID:             e.ID,
+			EmployeeID:     e.EmployeeID,
+			ManagerID:      e.ManagerID,
+			Kind:           string(e.Kind),
+			BusinessDate:   e.BusinessDate,
+			ScheduledStart: e.ScheduledStart,
+			ScheduledEnd:   e.ScheduledEnd,
+			LocationID:     e.LocationID,
+			TimeZone:       e.TimeZone,
+			DetectedAt:     e.CreatedAt,
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
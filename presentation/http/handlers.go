@@ -1,31 +1,42 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/leo-andrei/check-in-service/application/services"
+	"github.com/leo-andrei/check-in-service/domain/entities"
 	"github.com/leo-andrei/check-in-service/domain/errors"
 )
 
 type CheckInHandler struct {
-	checkInService  *services.CheckInService
-	checkOutService *services.CheckOutService
+	commands        *services.CommandBus
+	passbackService *services.AntiPassbackService
 }
 
 func NewCheckInHandler(
-	checkInService *services.CheckInService,
-	checkOutService *services.CheckOutService,
+	commands *services.CommandBus,
+	passbackService *services.AntiPassbackService,
 ) *CheckInHandler {
 	return &CheckInHandler{
-		checkInService:  checkInService,
-		checkOutService: checkOutService,
+		commands:        commands,
+		passbackService: passbackService,
 	}
 }
 
 type CheckInRequest struct {
-	EmployeeID string `json:"employee_id" validate:"required,min=3,max=50,alphanum"`
+	EmployeeID  string `json:"employee_id" validate:"required,min=3,max=50,alphanum"`
+	LocationID  string `json:"location_id" validate:"omitempty,max=50"`
+	TimeZone    string `json:"time_zone" validate:"omitempty,max=64"`
+	ProjectCode string `json:"project_code" validate:"omitempty,max=50"`
+	Note        string `json:"note" validate:"omitempty,max=1000"`
+	// Channel of the punch (defaults to api) and the terminal it came from.
+	// Only kiosks authenticated by RequireDevice may send source kiosk or a
+	// device ID, and both are taken from their credentials.
+	Source   string `json:"source" validate:"omitempty,oneof=kiosk mobile web api import"`
+	DeviceID string `json:"device_id" validate:"omitempty,max=255"`
 }
 
 func validateRequest(req *CheckInRequest) error {
@@ -37,8 +48,12 @@ type CheckInResponse struct {
 	Success     bool    `json:"success"`
 	Message     string  `json:"message"`
 	RecordID    string  `json:"record_id,omitempty"`
-	Action      string  `json:"action"` // "checked_in" or "checked_out"
+	Action      string  `json:"action"` // "checked_in", "checked_out" or "transferred"
 	HoursWorked float64 `json:"hours_worked,omitempty"`
+	// Record closed at the previous location when the badge transferred the employee
+	TransferredFrom string `json:"transferred_from,omitempty"`
+	// Results of the check-in hooks (e.g. an assigned locker), by hook name
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
 }
 
 func (h *CheckInHandler) HandleCheckIn(w http.ResponseWriter, r *http.Request) {
@@ -65,8 +80,58 @@ func (h *CheckInHandler) HandleCheckIn(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
 
+	source := entities.PunchSource(req.Source)
+	if source == "" {
+		source = entities.SourceAPI
+	}
+
+	device := deviceFromContext(ctx)
+	if err := checkPunchProvenance(req, device); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if device != nil {
+		// Enrolled kiosks identify themselves; do not trust the body for it
+		ctx = services.WithActor(ctx, services.Actor{Kind: services.ActorDevice, ID: device.ID})
+		source = entities.SourceKiosk
+		req.DeviceID = device.ID
+
+		// Kiosks bound to a location record every check-in there
+		if device.LocationID != "" {
+			req.LocationID = device.LocationID
+		}
+	}
+
+	// Badging at another site than the open record's is rejected or turns
+	// into a transfer, depending on the anti-passback policy
+	decision, err := h.passbackService.Check(ctx, req.EmployeeID, req.LocationID)
+	if err != nil {
+		if err == errors.ErrAntiPassbackConst || err == errors.ErrAntiPassbackTransitConst {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	checkOutOpts := services.CheckOutOptions{
+		Note:     req.Note,
+		Source:   source,
+		DeviceID: req.DeviceID,
+	}
+	if decision == entities.PassbackMove {
+		// A record closed meanwhile, or a double badge, is handled like any
+		// other badge below
+		if h.transfer(ctx, w, req, source, checkOutOpts) {
+			return
+		}
+	}
+
 	// Try to check out first (if already checked in)
-	record, err := h.checkOutService.CheckOut(ctx, req.EmployeeID)
+	record, err := h.commands.CheckOut(ctx, services.CheckOutCommand{
+		EmployeeID: req.EmployeeID,
+		Options:    checkOutOpts,
+	})
 	if err == nil {
 		// Successfully checked out
 		resp := CheckInResponse{
@@ -80,15 +145,15 @@ func (h *CheckInHandler) HandleCheckIn(w http.ResponseWriter, r *http.Request) {
 		json.NewEncoder(w).Encode(resp)
 		return
 	}
+	if err == errors.ErrInvalidNoteConst || err == errors.ErrInvalidCommandConst {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Not checked out, so check in
-	record, err = h.checkInService.CheckIn(ctx, req.EmployeeID)
+	record, metadata, err := h.checkIn(ctx, req, source)
 	if err != nil {
-		if err == errors.ErrEmployeeAlreadyCheckedInConst {
-			http.Error(w, err.Error(), http.StatusConflict)
-			return
-		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeCheckInError(w, err)
 		return
 	}
 
@@ -97,10 +162,91 @@ func (h *CheckInHandler) HandleCheckIn(w http.ResponseWriter, r *http.Request) {
 		Message:  "Successfully checked in",
 		RecordID: record.ID,
 		Action:   "checked_in",
+		Metadata: metadata,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// checkPunchProvenance rejects a body claiming kiosk provenance without the
+// kiosk's credentials, or naming another device than the authenticated one
+func checkPunchProvenance(req CheckInRequest, device *entities.Device) error {
+	if device == nil && (entities.PunchSource(req.Source) == entities.SourceKiosk || req.DeviceID != "") {
+		return errors.ErrDeviceNotAuthenticatedConst
+	}
+	if device != nil && req.DeviceID != "" && req.DeviceID != device.ID {
+		return errors.ErrDeviceNotAuthenticatedConst
+	}
+	return nil
+}
+
+func (h *CheckInHandler) checkIn(ctx context.Context, req CheckInRequest, source entities.PunchSource) (*entities.TimeRecord, map[string]interface{}, error) {
+	return h.commands.CheckIn(ctx, services.CheckInCommand{
+		EmployeeID: req.EmployeeID,
+		Options:    checkInOptions(req, source),
+	})
+}
+
+func checkInOptions(req CheckInRequest, source entities.PunchSource) services.CheckInOptions {
+	return services.CheckInOptions{
+		LocationID:  req.LocationID,
+		TimeZone:    req.TimeZone,
+		ProjectCode: req.ProjectCode,
+		Note:        req.Note,
+		Source:      source,
+		DeviceID:    req.DeviceID,
+	}
+}
+
+// transfer closes the employee's record at the previous location and checks
+// them in at the new one, both or neither. It returns false without writing
+// a response when the employee has no record to close (any more) or badged
+// twice, so the caller handles the badge as usual.
+func (h *CheckInHandler) transfer(ctx context.Context, w http.ResponseWriter, req CheckInRequest, source entities.PunchSource, checkOutOpts services.CheckOutOptions) bool {
+	result, err := h.commands.Transfer(ctx, services.TransferCommand{
+		EmployeeID: req.EmployeeID,
+		CheckOut:   checkOutOpts,
+		CheckIn:    checkInOptions(req, source),
+	})
+	if err == errors.ErrNoActiveCheckInFoundConst || err == errors.ErrDuplicateCheckInConst {
+		return false
+	}
+	if err != nil {
+		writeCheckInError(w, err)
+		return true
+	}
+
+	resp := CheckInResponse{
+		Success:         true,
+		Message:         "Successfully transferred to " + result.Record.LocationID,
+		RecordID:        result.Record.ID,
+		Action:          "transferred",
+		HoursWorked:     result.Closed.HoursWorked,
+		TransferredFrom: result.Closed.ID,
+		Metadata:        result.Metadata,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
+	return true
+}
+
+func writeCheckInError(w http.ResponseWriter, err error) {
+	if err == errors.ErrEmployeeAlreadyCheckedInConst {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err == errors.ErrUnknownEmployeeConst {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err == errors.ErrUnknownLocationConst || err == errors.ErrInvalidTimeZoneConst || err == errors.ErrUnknownProjectConst ||
+		err == errors.ErrInvalidNoteConst || err == errors.ErrInvalidCommandConst {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeRepositoryError(w, err)
 }
 
 func (h *CheckInHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,62 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+)
+
+func TestCheckPunchProvenance(t *testing.T) {
+	kiosk := &entities.Device{ID: "kiosk-1", Status: entities.DeviceActive}
+
+	tests := []struct {
+		name    string
+		req     CheckInRequest
+		device  *entities.Device
+		wantErr error
+	}{
+		{name: "api client", req: CheckInRequest{}},
+		{name: "mobile client", req: CheckInRequest{Source: "mobile"}},
+		{name: "forged kiosk source", req: CheckInRequest{Source: "kiosk"}, wantErr: errors.ErrDeviceNotAuthenticatedConst},
+		{name: "forged device ID", req: CheckInRequest{Source: "mobile", DeviceID: "kiosk-1"}, wantErr: errors.ErrDeviceNotAuthenticatedConst},
+		{name: "authenticated kiosk", req: CheckInRequest{}, device: kiosk},
+		{name: "authenticated kiosk naming itself", req: CheckInRequest{Source: "kiosk", DeviceID: "kiosk-1"}, device: kiosk},
+		{name: "authenticated kiosk naming another", req: CheckInRequest{DeviceID: "kiosk-2"}, device: kiosk, wantErr: errors.ErrDeviceNotAuthenticatedConst},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkPunchProvenance(tt.req, tt.device); err != tt.wantErr {
+				t.Fatalf("checkPunchProvenance() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHandleCheckInRejectsForgedProvenance(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "kiosk source", body: `{"employee_id": "EMP001", "source": "kiosk"}`},
+		{name: "device ID", body: `{"employee_id": "EMP001", "device_id": "kiosk-1"}`},
+	}
+
+	// Rejected before any service is called
+	handler := NewCheckInHandler(nil, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/api/checkin", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			handler.HandleCheckIn(rec, req)
+			if rec.Code != http.StatusForbidden {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusForbidden)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/leo-andrei/check-in-service/application/services"
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+)
+
+type HolidayHandler struct {
+	holidayService *services.HolidayService
+}
+
+func NewHolidayHandler(holidayService *services.HolidayService) *HolidayHandler {
+	return &HolidayHandler{
+		holidayService: holidayService,
+	}
+}
+
+type HolidayRequest struct {
+	Name string `json:"name" validate:"required,max=255"`
+}
+
+type HolidayResponse struct {
+	Date string `json:"date"`
+	Name string `json:"name"`
+}
+
+func toHolidayResponse(h *entities.Holiday) HolidayResponse {
+	return HolidayResponse{
+		Date: h.Date,
+		Name: h.Name,
+	}
+}
+
+// ListHolidays handles GET /api/holidays?year=2026 (defaults to the current year)
+func (h *HolidayHandler) ListHolidays(w http.ResponseWriter, r *http.Request) {
+	year := time.Now().Year()
+	if raw := r.URL.Query().Get("year"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > 9999 {
+			http.Error(w, errors.ErrInvalidRequest, http.StatusBadRequest)
+			return
+		}
+		year = parsed
+	}
+
+	holidays, err := h.holidayService.List(r.Context(), year)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]HolidayResponse, 0, len(holidays))
+	for _, holiday := range holidays {
+		resp = append(resp, toHolidayResponse(holiday))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// SaveHoliday handles PUT /api/admin/holidays/{date}
+func (h *HolidayHandler) SaveHoliday(w http.ResponseWriter, r *http.Request) {
+	var req HolidayRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, errors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	if err := validator.New().Struct(&req); err != nil {
+		http.Error(w, errors.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	holiday, err := h.holidayService.Save(r.Context(), r.PathValue("date"), req.Name)
+	if err != nil {
+		if err == errors.ErrInvalidHolidayConst {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toHolidayResponse(holiday))
+}
+
+// DeleteHoliday handles DELETE /api/admin/holidays/{date}
+func (h *HolidayHandler) DeleteHoliday(w http.ResponseWriter, r *http.Request) {
+	if err := h.holidayService.Delete(r.Context(), r.PathValue("date")); err != nil {
+		if err == errors.ErrHolidayNotFoundConst {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,94 @@
+package http
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/leo-andrei/check-in-service/application/services"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+)
+
+type InboundEmailHandler struct {
+	inboundService *services.InboundEmailService
+	token          string
+}
+
+// NewInboundEmailHandler accepts webhooks carrying token in X-Inbound-Token;
+// the endpoint is disabled when token is empty
+func NewInboundEmailHandler(inboundService *services.InboundEmailService, token string) *InboundEmailHandler {
+	return &InboundEmailHandler{
+		inboundService: inboundService,
+		token:          token,
+	}
+}
+
+// InboundEmailRequest is the parsed message posted by the email provider
+type InboundEmailRequest struct {
+	MessageID string `json:"message_id" validate:"max=998"`
+	From      string `json:"from" validate:"required,max=512"`
+	Subject   string `json:"subject" validate:"max=1000"`
+	Text      string `json:"text" validate:"max=100000"`
+}
+
+type InboundEmailResponse struct {
+	ApprovalID string `json:"approval_id"`
+	RecordID   string `json:"record_id"`
+	CheckOutAt string `json:"check_out_at"`
+	Status     string `json:"status"`
+}
+
+// ReceiveEmail handles POST /api/inbound/email
+func (h *InboundEmailHandler) ReceiveEmail(w http.ResponseWriter, r *http.Request) {
+	if h.token == "" {
+		http.Error(w, errors.ErrInboundEmailDisabled, http.StatusForbidden)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Inbound-Token")), []byte(h.token)) != 1 {
+		http.Error(w, errors.ErrUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	var req InboundEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, errors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	if err := validator.New().Struct(&req); err != nil {
+		http.Error(w, errors.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	approval, err := h.inboundService.Process(r.Context(), services.InboundEmail{
+		MessageID:  req.MessageID,
+		From:       req.From,
+		Text:       req.Text,
+		ReceivedAt: time.Now(),
+	})
+	if err == errors.ErrDuplicateInboundEmailConst {
+		// Acknowledge redeliveries so the provider stops retrying
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if err != nil {
+		switch err {
+		case errors.ErrUnknownSenderConst, errors.ErrNoActiveCheckInFoundConst:
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.ErrInvalidEmailCommandConst, errors.ErrInvalidApprovalConst:
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, InboundEmailResponse{
+		ApprovalID: approval.ID,
+		RecordID:   approval.RecordID,
+		CheckOutAt: approval.ProposedCheckOutAt.Format(time.RFC3339),
+		Status:     string(approval.Status),
+	})
+}
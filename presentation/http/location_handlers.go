@@ -0,0 +1,131 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/leo-andrei/check-in-service/application/services"
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+)
+
+type LocationHandler struct {
+	locationService *services.LocationService
+}
+
+func NewLocationHandler(locationService *services.LocationService) *LocationHandler {
+	return &LocationHandler{
+		locationService: locationService,
+	}
+}
+
+type LocationRequest struct {
+	Name     string `json:"name" validate:"required,max=255"`
+	Address  string `json:"address" validate:"max=500"`
+	TimeZone string `json:"time_zone" validate:"omitempty,max=64"`
+	Active   *bool  `json:"active"`
+}
+
+type LocationResponse struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Address  string `json:"address,omitempty"`
+	TimeZone string `json:"time_zone"`
+	Active   bool   `json:"active"`
+}
+
+type PresenceEntry struct {
+	EmployeeID string    `json:"employee_id"`
+	RecordID   string    `json:"record_id"`
+	LocationID string    `json:"location_id,omitempty"`
+	CheckInAt  time.Time `json:"check_in_at"` // In the record's time zone
+	TimeZone   string    `json:"time_zone"`
+}
+
+func toLocationResponse(l *entities.Location) LocationResponse {
+	return LocationResponse{
+		ID:       l.ID,
+		Name:     l.Name,
+		Address:  l.Address,
+		TimeZone: l.TimeZone,
+		Active:   l.Active,
+	}
+}
+
+// ListLocations handles GET /api/locations
+func (h *LocationHandler) ListLocations(w http.ResponseWriter, r *http.Request) {
+	locations, err := h.locationService.List(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]LocationResponse, 0, len(locations))
+	for _, l := range locations {
+		resp = append(resp, toLocationResponse(l))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// SaveLocation handles PUT /api/admin/locations/{id}
+func (h *LocationHandler) SaveLocation(w http.ResponseWriter, r *http.Request) {
+	var req LocationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, errors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	if err := validator.New().Struct(&req); err != nil {
+		http.Error(w, errors.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	timeZone := req.TimeZone
+	if timeZone == "" {
+		timeZone = "UTC"
+	}
+
+	location, err := h.locationService.Save(r.Context(), r.PathValue("id"), req.Name, req.Address, timeZone, active)
+	if err != nil {
+		if err == errors.ErrInvalidTimeZoneConst {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toLocationResponse(location))
+}
+
+// Presence handles GET /api/presence?location_id=
+func (h *LocationHandler) Presence(w http.ResponseWriter, r *http.Request) {
+	records, err := h.locationService.Presence(r.Context(), r.URL.Query().Get("location_id"))
+	if err != nil {
+		if err == errors.ErrUnknownLocationConst {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]PresenceEntry, 0, len(records))
+	for _, record := range records {
+		resp = append(resp, PresenceEntry{
+			EmployeeID: record.EmployeeID,
+			RecordID:   record.ID,
+			LocationID: record.LocationID,
+			CheckInAt:  record.Local(record.CheckInAt),
+			TimeZone:   record.TimeZone,
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,54 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/leo-andrei/check-in-service/application/services"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+)
+
+type MergeHandler struct {
+	mergeService *services.EmployeeMergeService
+}
+
+func NewMergeHandler(mergeService *services.EmployeeMergeService) *MergeHandler {
+	return &MergeHandler{
+		mergeService: mergeService,
+	}
+}
+
+type MergeRequest struct {
+	Into   string `json:"into" validate:"required,min=3,max=50,alphanum"`
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+// HandleMerge handles POST /api/admin/employees/{id}/merge, folding {id} into "into"
+func (h *MergeHandler) HandleMerge(w http.ResponseWriter, r *http.Request) {
+	var req MergeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, errors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	if err := validator.New().Struct(&req); err != nil {
+		http.Error(w, errors.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.mergeService.Merge(r.Context(), r.PathValue("id"), req.Into, actorFromContext(r.Context()), req.Reason)
+	if err != nil {
+		switch err {
+		case errors.ErrInvalidMergeConst:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.ErrMergeConflictConst:
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,180 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/application/services"
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+)
+
+type contextKey string
+
+const (
+	actorContextKey  contextKey = "actor"
+	deviceContextKey contextKey = "device"
+)
+
+// RequireAdmin protects admin endpoints with a static API key sent in the
+// X-Admin-Key header. Admin endpoints are disabled when no key is configured.
+// The acting operator is taken from X-Admin-User and used in audit entries.
+func RequireAdmin(apiKey string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if apiKey == "" {
+			http.Error(w, errors.ErrAdminAPIDisabled, http.StatusForbidden)
+			return
+		}
+
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Admin-Key")), []byte(apiKey)) != 1 {
+			http.Error(w, errors.ErrUnauthorized, http.StatusUnauthorized)
+			return
+		}
+
+		actor := r.Header.Get("X-Admin-User")
+		if actor == "" {
+			actor = "admin"
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), actorContextKey, actor)))
+	}
+}
+
+// actorFromContext returns the operator set by RequireAdmin
+func actorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorContextKey).(string); ok {
+		return actor
+	}
+	return "system"
+}
+
+// RequireDevice authenticates kiosks by the X-Device-ID and X-Device-Secret
+// headers. Requests without device headers pass through unless required is
+// set; requests with them must carry valid, unrevoked credentials. Responses
+// tell the kiosk when its credentials expire and when it should rotate them.
+func RequireDevice(devices *services.DeviceService, required bool, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		deviceID := r.Header.Get("X-Device-ID")
+		if deviceID == "" {
+			if required {
+				http.Error(w, errors.ErrDeviceUnauthorized, http.StatusUnauthorized)
+				return
+			}
+			next(w, r)
+			return
+		}
+
+		device, err := devices.Authenticate(r.Context(), deviceID, r.Header.Get("X-Device-Secret"))
+		if err != nil {
+			if err == errors.ErrDeviceUnauthorizedConst {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if device.SecretExpiresAt != nil {
+			w.Header().Set("X-Device-Credentials-Expire-At", device.SecretExpiresAt.Format(time.RFC3339))
+		}
+		if devices.RotationDue(device) {
+			w.Header().Set("X-Device-Rotate", "true")
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), deviceContextKey, device)))
+	}
+}
+
+// deviceFromContext returns the kiosk authenticated by RequireDevice, if any
+func deviceFromContext(ctx context.Context) *entities.Device {
+	device, _ := ctx.Value(deviceContextKey).(*entities.Device)
+	return device
+}
+
+// Idempotent replays the stored response when a client retries a request with
+// the same Idempotency-Key header, on any API instance. Requests without the
+// header run as usual. Server errors free the key so the retry runs again.
+func Idempotent(idempotency *services.IdempotencyService, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" {
+			next(w, r)
+			return
+		}
+		if len(key) > 255 {
+			http.Error(w, errors.ErrInvalidIdempotencyKey, http.StatusBadRequest)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, errors.ErrInvalidRequestBody, http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Keys are scoped to the endpoint; the fingerprint catches a key
+		// reused for a request with another body
+		scopedKey := "http:" + r.Method + " " + r.URL.Path + ":" + key
+		sum := sha256.Sum256(body)
+		fingerprint := hex.EncodeToString(sum[:])
+
+		stored, err := idempotency.Begin(r.Context(), scopedKey, fingerprint)
+		switch err {
+		case nil:
+		case errors.ErrIdempotencyKeyInProgressConst:
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case errors.ErrIdempotencyKeyReusedConst:
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if stored != nil {
+			if stored.ContentType != "" {
+				w.Header().Set("Content-Type", stored.ContentType)
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(stored.StatusCode)
+			w.Write(stored.Body)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(recorder, r)
+
+		// Store the outcome even if the client went away meanwhile
+		ctx := context.WithoutCancel(r.Context())
+		if recorder.status >= http.StatusInternalServerError {
+			idempotency.Abandon(ctx, scopedKey)
+			return
+		}
+		idempotency.Finish(ctx, scopedKey, recorder.status, recorder.Header().Get("Content-Type"), recorder.body.Bytes())
+	}
+}
+
+// responseRecorder passes a response through while keeping a copy of it
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,76 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/leo-andrei/check-in-service/application/services"
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+)
+
+type NoteHandler struct {
+	noteService *services.NoteService
+}
+
+func NewNoteHandler(noteService *services.NoteService) *NoteHandler {
+	return &NoteHandler{
+		noteService: noteService,
+	}
+}
+
+type NoteRequest struct {
+	Body string `json:"body" validate:"required,max=1000"`
+}
+
+type NoteResponse struct {
+	ID        string    `json:"id"`
+	RecordID  string    `json:"record_id"`
+	Kind      string    `json:"kind"`
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func toNoteResponse(n *entities.RecordNote) NoteResponse {
+	return NoteResponse{
+		ID:        n.ID,
+		RecordID:  n.RecordID,
+		Kind:      string(n.Kind),
+		Author:    n.Author,
+		Body:      n.Body,
+		CreatedAt: n.CreatedAt,
+	}
+}
+
+// AppendNote handles POST /api/employees/{id}/records/{recordId}/notes
+func (h *NoteHandler) AppendNote(w http.ResponseWriter, r *http.Request) {
+	var req NoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, errors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	if err := validator.New().Struct(&req); err != nil {
+		http.Error(w, errors.ErrInvalidNote, http.StatusBadRequest)
+		return
+	}
+
+	employeeID := r.PathValue("id")
+	note, err := h.noteService.Append(r.Context(), employeeID, r.PathValue("recordId"), employeeID, req.Body)
+	if err != nil {
+		switch err {
+		case errors.ErrRecordNotFoundConst:
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.ErrInvalidNoteConst:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			writeRepositoryError(w, err)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, toNoteResponse(note))
+}
@@ -0,0 +1,242 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/domain/errors"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+	"github.com/leo-andrei/check-in-service/infrastructure/messaging"
+	"github.com/leo-andrei/check-in-service/infrastructure/resilience"
+)
+
+// maxDLQReplay caps how many dead letters one replay request moves
+const maxDLQReplay = 1000
+
+// maxDLQPeek caps how many dead letters one request shows
+const maxDLQPeek = 100
+
+// OutboxBacklogReader counts the events the outbox relay still has to publish
+type OutboxBacklogReader interface {
+	Backlog(ctx context.Context) (repositories.OutboxBacklog, error)
+}
+
+// QueueMonitor reports queue depths and shows, moves back or deletes dead letters
+type QueueMonitor interface {
+	Depths(ctx context.Context) ([]messaging.QueueDepth, error)
+	ReplayDLQ(ctx context.Context, queue string, limit int) (int, error)
+	PeekDLQ(ctx context.Context, queue string, limit int) ([]messaging.DeadLetter, error)
+	PurgeDLQ(ctx context.Context, queue string) (int, error)
+}
+
+// CircuitStateReader is a circuit breaker guarding an external service
+type CircuitStateReader interface {
+	GetState() resilience.CircuitState
+}
+
+type OpsHandler struct {
+	outbox   OutboxBacklogReader
+	queues   QueueMonitor
+	breakers map[string]CircuitStateReader
+}
+
+func NewOpsHandler(outbox OutboxBacklogReader, queues QueueMonitor, breakers map[string]CircuitStateReader) *OpsHandler {
+	return &OpsHandler{
+		outbox:   outbox,
+		queues:   queues,
+		breakers: breakers,
+	}
+}
+
+type OpsStatusResponse struct {
+	Outbox          OutboxBacklogResponse  `json:"outbox"`
+	Queues          []QueueDepthResponse   `json:"queues"`
+	QueueError      string                 `json:"queue_error,omitempty"`
+	CircuitBreakers []CircuitStateResponse `json:"circuit_breakers"`
+}
+
+type OutboxBacklogResponse struct {
+	Pending  int        `json:"pending"`
+	OldestAt *time.Time `json:"oldest_at,omitempty"`
+	Failed   int        `json:"failed"`
+}
+
+type QueueDepthResponse struct {
+	Queue       string `json:"queue"`
+	Messages    int    `json:"messages"`
+	Consumers   int    `json:"consumers"`
+	DLQ         string `json:"dlq"`
+	DLQMessages int    `json:"dlq_messages"`
+}
+
+type CircuitStateResponse struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+type DeadLettersResponse struct {
+	Queue    string               `json:"queue"`
+	Messages []DeadLetterResponse `json:"messages"`
+}
+
+type DeadLetterResponse struct {
+	Type       string     `json:"type"`
+	MessageID  string     `json:"message_id,omitempty"`
+	Reason     string     `json:"reason,omitempty"`
+	DeadSince  *time.Time `json:"dead_since,omitempty"`
+	DeathCount int64      `json:"death_count,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	// The event itself; bodies that are not JSON are returned as a string
+	Body json.RawMessage `json:"body"`
+}
+
+type PurgeResponse struct {
+	Queue  string `json:"queue"`
+	Purged int    `json:"purged"`
+}
+
+type ReplayResponse struct {
+	Queue    string `json:"queue"`
+	Replayed int    `json:"replayed"`
+	// Why the replay stopped before limit with messages left, if it did
+	Error string `json:"error,omitempty"`
+}
+
+// GetStatus handles GET /api/admin/ops/status
+// It reports the outbox backlog, queue and DLQ depths and the circuit breaker
+// states of this instance. An unreachable broker is reported in queue_error
+// rather than failing the whole status.
+func (h *OpsHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	backlog, err := h.outbox.Backlog(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := OpsStatusResponse{
+		Outbox:          OutboxBacklogResponse{Pending: backlog.Pending, OldestAt: backlog.OldestAt, Failed: backlog.Failed},
+		Queues:          []QueueDepthResponse{},
+		CircuitBreakers: make([]CircuitStateResponse, 0, len(h.breakers)),
+	}
+
+	depths, err := h.queues.Depths(r.Context())
+	if err != nil {
+		resp.QueueError = err.Error()
+	}
+	for _, d := range depths {
+		resp.Queues = append(resp.Queues, QueueDepthResponse{
+			Queue:       d.Queue,
+			Messages:    d.Messages,
+			Consumers:   d.Consumers,
+			DLQ:         d.DLQ,
+			DLQMessages: d.DLQMessages,
+		})
+	}
+
+	for name, breaker := range h.breakers {
+		resp.CircuitBreakers = append(resp.CircuitBreakers, CircuitStateResponse{Name: name, State: string(breaker.GetState())})
+	}
+	sort.Slice(resp.CircuitBreakers, func(i, j int) bool {
+		return resp.CircuitBreakers[i].Name < resp.CircuitBreakers[j].Name
+	})
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// ReplayDLQ handles POST /api/admin/queues/{queue}/dlq/replay?limit=
+// It moves up to limit (default 100) dead letters back to the queue.
+func (h *OpsHandler) ReplayDLQ(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		var err error
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxDLQReplay {
+			http.Error(w, errors.ErrInvalidRequest, http.StatusBadRequest)
+			return
+		}
+	}
+
+	queue := r.PathValue("queue")
+	replayed, err := h.queues.ReplayDLQ(r.Context(), queue, limit)
+	if err == errors.ErrUnknownQueueConst {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil && replayed == 0 {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	resp := ReplayResponse{Queue: queue, Replayed: replayed}
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// PeekDLQ handles GET /api/admin/queues/{queue}/dlq, showing the oldest dead
+// letters of a queue (limit, default 20) without removing them
+func (h *OpsHandler) PeekDLQ(w http.ResponseWriter, r *http.Request) {
+	limit := 20
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		var err error
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxDLQPeek {
+			http.Error(w, errors.ErrInvalidRequest, http.StatusBadRequest)
+			return
+		}
+	}
+
+	queue := r.PathValue("queue")
+	letters, err := h.queues.PeekDLQ(r.Context(), queue, limit)
+	if err == errors.ErrUnknownQueueConst {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	resp := DeadLettersResponse{Queue: queue, Messages: make([]DeadLetterResponse, 0, len(letters))}
+	for _, letter := range letters {
+		body := json.RawMessage(letter.Body)
+		if !json.Valid(letter.Body) {
+			body, _ = json.Marshal(string(letter.Body))
+		}
+		message := DeadLetterResponse{
+			Type:       letter.Type,
+			MessageID:  letter.MessageID,
+			Reason:     letter.Reason,
+			DeathCount: letter.DeathCount,
+			Error:      letter.Error,
+			Body:       body,
+		}
+		if !letter.DeadSince.IsZero() {
+			message.DeadSince = &letter.DeadSince
+		}
+		resp.Messages = append(resp.Messages, message)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// PurgeDLQ handles DELETE /api/admin/queues/{queue}/dlq, deleting every dead
+// letter of a queue for good
+func (h *OpsHandler) PurgeDLQ(w http.ResponseWriter, r *http.Request) {
+	queue := r.PathValue("queue")
+	purged, err := h.queues.PurgeDLQ(r.Context(), queue)
+	if err == errors.ErrUnknownQueueConst {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, PurgeResponse{Queue: queue, Purged: purged})
+}
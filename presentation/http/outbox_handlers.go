@@ -0,0 +1,309 @@
+package http
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/leo-andrei/check-in-service/domain/errors"
+	"github.com/leo-andrei/check-in-service/domain/hours"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+)
+
+// failedExportPageSize is how many failed events are read per query while
+// streaming an export
+const failedExportPageSize = 500
+
+const (
+	defaultOutboxPageSize = 100
+	maxOutboxPageSize     = 500
+)
+
+// DryRunSwitch turns the outbox relay's dry-run mode on and off
+type DryRunSwitch interface {
+	DryRun() bool
+	SetDryRun(enabled bool)
+}
+
+type OutboxHandler struct {
+	publisher DryRunSwitch
+	failures  repositories.OutboxFailures
+}
+
+func NewOutboxHandler(publisher DryRunSwitch, failures repositories.OutboxFailures) *OutboxHandler {
+	return &OutboxHandler{
+		publisher: publisher,
+		failures:  failures,
+	}
+}
+
+type DryRunRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+type DryRunResponse struct {
+	Enabled bool `json:"enabled"`
+}
+
+// GetDryRun handles GET /api/admin/outbox/dry-run
+func (h *OutboxHandler) GetDryRun(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, DryRunResponse{Enabled: h.publisher.DryRun()})
+}
+
+// SetDryRun handles PUT /api/admin/outbox/dry-run. While enabled, the relay
+// logs what it would publish and leaves events pending.
+func (h *OutboxHandler) SetDryRun(w http.ResponseWriter, r *http.Request) {
+	var req DryRunRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, errors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+	if req.Enabled == nil {
+		http.Error(w, errors.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	h.publisher.SetDryRun(*req.Enabled)
+
+	writeJSON(w, http.StatusOK, DryRunResponse{Enabled: *req.Enabled})
+}
+
+type OutboxEventResponse struct {
+	ID          string     `json:"id"`
+	EventType   string     `json:"event_type"`
+	AggregateID string     `json:"aggregate_id"`
+	EmployeeID  string     `json:"employee_id,omitempty"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	RetryCount  int        `json:"retry_count"`
+	LastError   string     `json:"last_error,omitempty"`
+	FailedAt    *time.Time `json:"failed_at,omitempty"`
+}
+
+type OutboxListResponse struct {
+	Events     []OutboxEventResponse `json:"events"`
+	NextCursor string                `json:"next_cursor,omitempty"`
+}
+
+// RequeueRequest names the events to requeue, or asks for every failed
+// event (optionally of one type) with all_failed
+type RequeueRequest struct {
+	IDs       []string `json:"ids"`
+	AllFailed bool     `json:"all_failed"`
+	EventType string   `json:"event_type"`
+}
+
+type RequeueResponse struct {
+	Requeued int `json:"requeued"`
+}
+
+// List handles GET /api/admin/outbox?status=failed|pending&event_type=&limit=&cursor=
+// It lists unpublished events oldest first, both statuses when status is
+// omitted. Pass next_cursor back as cursor for the next page.
+func (h *OutboxHandler) List(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := repositories.OutboxFailureFilter{EventType: query.Get("event_type"), Status: query.Get("status")}
+	switch filter.Status {
+	case "", repositories.OutboxStatusPending, repositories.OutboxStatusFailed:
+	default:
+		http.Error(w, errors.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	limit := defaultOutboxPageSize
+	if raw := query.Get("limit"); raw != "" {
+		var err error
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxOutboxPageSize {
+			http.Error(w, errors.ErrInvalidRequest, http.StatusBadRequest)
+			return
+		}
+	}
+
+	after, err := decodeOutboxCursor(query.Get("cursor"))
+	if err != nil {
+		http.Error(w, errors.ErrInvalidCursor, http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.failures.FindFailed(r.Context(), filter, after, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := OutboxListResponse{Events: make([]OutboxEventResponse, 0, len(page))}
+	for _, event := range page {
+		resp.Events = append(resp.Events, OutboxEventResponse{
+			ID:          event.ID,
+			EventType:   event.EventType,
+			AggregateID: event.AggregateID,
+			EmployeeID:  event.EmployeeID,
+			Status:      event.Status(),
+			CreatedAt:   event.CreatedAt,
+			RetryCount:  event.RetryCount,
+			LastError:   event.LastError,
+			FailedAt:    event.FailedAt,
+		})
+	}
+	if len(page) == limit {
+		last := page[len(page)-1]
+		resp.NextCursor = encodeOutboxCursor(repositories.OutboxCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Requeue handles POST /api/admin/outbox/{id}/requeue
+// It clears the event's failed mark and retry count, so the relay picks it
+// up again in its next poll.
+func (h *OutboxHandler) Requeue(w http.ResponseWriter, r *http.Request) {
+	requeued, err := h.failures.Requeue(r.Context(), []string{r.PathValue("id")})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if requeued == 0 {
+		http.Error(w, errors.ErrOutboxEventNotFound, http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, RequeueResponse{Requeued: requeued})
+}
+
+// RequeueBulk handles POST /api/admin/outbox/requeue
+// The body lists event ids, or sets all_failed to requeue every failed event
+// (of event_type, when given). Published and unknown ids are skipped.
+func (h *OutboxHandler) RequeueBulk(w http.ResponseWriter, r *http.Request) {
+	var req RequeueRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, errors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+	if (len(req.IDs) == 0) == !req.AllFailed || (req.EventType != "" && !req.AllFailed) {
+		http.Error(w, errors.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	var (
+		requeued int
+		err      error
+	)
+	if req.AllFailed {
+		requeued, err = h.failures.RequeueFailed(r.Context(), req.EventType)
+	} else {
+		requeued, err = h.failures.Requeue(r.Context(), req.IDs)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	config.Logger.Info("Outbox events requeued", zap.Int("requeued", requeued), zap.Bool("all_failed", req.AllFailed), zap.String("event_type", req.EventType))
+	writeJSON(w, http.StatusOK, RequeueResponse{Requeued: requeued})
+}
+
+func encodeOutboxCursor(cursor repositories.OutboxCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeOutboxCursor(cursor string) (*repositories.OutboxCursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+	var after repositories.OutboxCursor
+	if err := json.Unmarshal(data, &after); err != nil {
+		return nil, err
+	}
+	if after.ID == "" || after.CreatedAt.IsZero() {
+		return nil, fmt.Errorf("incomplete cursor")
+	}
+	return &after, nil
+}
+
+// ExportFailed handles GET /api/admin/outbox/failed/export?from=&to=&event_type=&min_retries=
+// It streams the unpublished events with at least min_retries (default 1)
+// failed publish attempts as CSV, oldest first, optionally only those created
+// in from..to (YYYY-MM-DD, inclusive, or RFC 3339; dates are UTC) or of one
+// event type. Events are read in keyset pages, so rows published or failing
+// during the export are neither repeated nor skipped.
+func (h *OutboxHandler) ExportFailed(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := repositories.OutboxFailureFilter{EventType: query.Get("event_type"), MinRetries: 1}
+
+	if from, to := query.Get("from"), query.Get("to"); from != "" || to != "" {
+		start, end, err := hours.ParseDateRange(from, to, time.UTC)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("%s: %s", errors.ErrInvalidDateRange, err), http.StatusBadRequest)
+			return
+		}
+		filter.From, filter.To = &start, &end
+	}
+	if value := query.Get("min_retries"); value != "" {
+		minRetries, err := strconv.Atoi(value)
+		if err != nil || minRetries < 1 {
+			http.Error(w, errors.ErrInvalidRequest, http.StatusBadRequest)
+			return
+		}
+		filter.MinRetries = minRetries
+	}
+
+	// Read the first page before answering, so a failing database is still a 500
+	page, err := h.failures.FindFailed(r.Context(), filter, nil, failedExportPageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now().UTC()
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="outbox-failed-%s.csv"`, now.Format("20060102T150405Z")))
+	w.WriteHeader(http.StatusOK)
+
+	out := csv.NewWriter(w)
+	out.Write([]string{"id", "event_type", "aggregate_id", "employee_id", "created_at", "age_seconds", "retry_count", "last_error"})
+
+	exported := 0
+	for len(page) > 0 {
+		for _, event := range page {
+			out.Write([]string{
+				event.ID,
+				event.EventType,
+				event.AggregateID,
+				event.EmployeeID,
+				event.CreatedAt.Format(time.RFC3339),
+				strconv.FormatInt(int64(now.Sub(event.CreatedAt).Seconds()), 10),
+				strconv.Itoa(event.RetryCount),
+				event.LastError,
+			})
+		}
+		exported += len(page)
+		out.Flush()
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+		if len(page) < failedExportPageSize {
+			break
+		}
+
+		last := page[len(page)-1]
+		page, err = h.failures.FindFailed(r.Context(), filter, &repositories.OutboxCursor{CreatedAt: last.CreatedAt, ID: last.ID}, failedExportPageSize)
+		if err != nil {
+			// The status is sent already; the truncated file is all the client gets
+			config.Logger.Error("Failed outbox export interrupted", zap.Int("exported", exported), zap.Error(err))
+			return
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/leo-andrei/check-in-service/application/services"
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+)
+
+type PayPeriodHandler struct {
+	payPeriodService *services.PayPeriodService
+}
+
+func NewPayPeriodHandler(payPeriodService *services.PayPeriodService) *PayPeriodHandler {
+	return &PayPeriodHandler{
+		payPeriodService: payPeriodService,
+	}
+}
+
+// PayPeriodResponse gives the period as local dates; end is exclusive
+type PayPeriodResponse struct {
+	Frequency string `json:"frequency"`
+	Start     string `json:"start"`
+	End       string `json:"end"`
+}
+
+// CurrentPeriod handles GET /api/pay-periods/current?date=
+// It returns the pay period containing date (defaults to today).
+func (h *PayPeriodHandler) CurrentPeriod(w http.ResponseWriter, r *http.Request) {
+	period, err := h.payPeriodService.ForDate(r.URL.Query().Get("date"))
+	if err != nil {
+		if err == errors.ErrInvalidPayPeriodDateConst {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, PayPeriodResponse{
+		Frequency: period.Frequency,
+		Start:     period.Start.Format(entities.BusinessDateLayout),
+		End:       period.End.Format(entities.BusinessDateLayout),
+	})
+}
@@ -0,0 +1,78 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/application/services"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+)
+
+type PayrollHandler struct {
+	preflightService *services.PayrollPreflightService
+}
+
+func NewPayrollHandler(preflightService *services.PayrollPreflightService) *PayrollHandler {
+	return &PayrollHandler{
+		preflightService: preflightService,
+	}
+}
+
+// PreflightResponse is the payroll go/no-go signal of a pay period; end is exclusive
+type PreflightResponse struct {
+	PeriodStart string                   `json:"period_start"`
+	PeriodEnd   string                   `json:"period_end"`
+	CheckedAt   time.Time                `json:"checked_at"`
+	Go          bool                     `json:"go"`
+	Checks      []PreflightCheckResponse `json:"checks"`
+}
+
+type PreflightCheckResponse struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail"`
+}
+
+// Preflight handles GET /api/admin/payroll/preflight?date=
+// It checks now that the events of the pay period containing date (defaults
+// to the last period that ended) are published and processed. A no-go is
+// still a 200; the answer is in go.
+func (h *PayrollHandler) Preflight(w http.ResponseWriter, r *http.Request) {
+	result, err := h.preflightService.Run(r.Context(), r.URL.Query().Get("date"))
+	if err != nil {
+		if err == errors.ErrInvalidPayPeriodDateConst {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toPreflightResponse(result))
+}
+
+// LastPreflight handles GET /api/admin/payroll/preflight/last
+// It returns the result of the last scheduled or requested preflight.
+func (h *PayrollHandler) LastPreflight(w http.ResponseWriter, r *http.Request) {
+	result := h.preflightService.Last()
+	if result == nil {
+		http.Error(w, "no payroll preflight has run yet", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toPreflightResponse(result))
+}
+
+func toPreflightResponse(result *services.PreflightResult) PreflightResponse {
+	resp := PreflightResponse{
+		PeriodStart: result.PeriodStart,
+		PeriodEnd:   result.PeriodEnd,
+		CheckedAt:   result.CheckedAt,
+		Go:          result.Go,
+		Checks:      make([]PreflightCheckResponse, 0, len(result.Checks)),
+	}
+	for _, check := range result.Checks {
+		resp.Checks = append(resp.Checks, PreflightCheckResponse{Name: check.Name, OK: check.OK, Detail: check.Detail})
+	}
+	return resp
+}
@@ -0,0 +1,82 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/leo-andrei/check-in-service/application/services"
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+)
+
+type ProjectHandler struct {
+	projectService *services.ProjectService
+}
+
+func NewProjectHandler(projectService *services.ProjectService) *ProjectHandler {
+	return &ProjectHandler{
+		projectService: projectService,
+	}
+}
+
+type ProjectRequest struct {
+	Name   string `json:"name" validate:"required,max=255"`
+	Active *bool  `json:"active"`
+}
+
+type ProjectResponse struct {
+	Code   string `json:"code"`
+	Name   string `json:"name"`
+	Active bool   `json:"active"`
+}
+
+func toProjectResponse(p *entities.Project) ProjectResponse {
+	return ProjectResponse{
+		Code:   p.Code,
+		Name:   p.Name,
+		Active: p.Active,
+	}
+}
+
+// ListProjects handles GET /api/projects
+func (h *ProjectHandler) ListProjects(w http.ResponseWriter, r *http.Request) {
+	projects, err := h.projectService.List(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]ProjectResponse, 0, len(projects))
+	for _, p := range projects {
+		resp = append(resp, toProjectResponse(p))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// SaveProject handles PUT /api/admin/projects/{code}
+func (h *ProjectHandler) SaveProject(w http.ResponseWriter, r *http.Request) {
+	var req ProjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, errors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	if err := validator.New().Struct(&req); err != nil {
+		http.Error(w, errors.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	project, err := h.projectService.Save(r.Context(), r.PathValue("code"), req.Name, active)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toProjectResponse(project))
+}
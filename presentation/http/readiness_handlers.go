@@ -0,0 +1,48 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/leo-andrei/check-in-service/infrastructure/selfcheck"
+)
+
+// ReadinessResponse names the warm-up steps that have not passed yet, or the
+// dependencies that failed their last check; their problems are in the logs,
+// as the probe is unauthenticated
+type ReadinessResponse struct {
+	Status  string   `json:"status"`
+	Pending []string `json:"pending,omitempty"`
+}
+
+// ReadinessCheck handles GET /ready: 503 until the warm-up succeeded, then
+// while the last cached dependency check failed. It never queries the
+// dependencies itself, so probes stay cheap. /health stays the liveness probe.
+func ReadinessCheck(readiness *selfcheck.Readiness, health *selfcheck.DependencyHealth) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if readiness.Ready() {
+			report := health.Last()
+			if report == nil || report.Healthy {
+				writeJSON(w, http.StatusOK, ReadinessResponse{Status: "ready"})
+				return
+			}
+			writeJSON(w, http.StatusServiceUnavailable, ReadinessResponse{Status: "unavailable", Pending: failedChecks(report)})
+			return
+		}
+
+		resp := ReadinessResponse{Status: "warming_up"}
+		if report := readiness.Last(); report != nil {
+			resp.Pending = failedChecks(report)
+		}
+		writeJSON(w, http.StatusServiceUnavailable, resp)
+	}
+}
+
+func failedChecks(report *selfcheck.Report) []string {
+	var failed []string
+	for _, check := range report.Checks {
+		if check.Status != selfcheck.StatusOK {
+			failed = append(failed, check.Name)
+		}
+	}
+	return failed
+}
@@ -0,0 +1,49 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/application/services"
+)
+
+type RecoveryHandler struct {
+	recoveryService *services.RecoveryService
+}
+
+func NewRecoveryHandler(recoveryService *services.RecoveryService) *RecoveryHandler {
+	return &RecoveryHandler{
+		recoveryService: recoveryService,
+	}
+}
+
+// RecoveryResponse reports each step of a recovery run in order
+type RecoveryResponse struct {
+	StartedAt time.Time              `json:"started_at"`
+	OK        bool                   `json:"ok"`
+	Steps     []RecoveryStepResponse `json:"steps"`
+}
+
+type RecoveryStepResponse struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail"`
+}
+
+// Recover handles POST /api/admin/recover
+// It reconnects the broker, resumes stopped consumers, resets long-open
+// circuit breakers and kicks the outbox relay. A failed step is still a 200;
+// the answer is in ok and the steps.
+func (h *RecoveryHandler) Recover(w http.ResponseWriter, r *http.Request) {
+	report := h.recoveryService.Recover(actorFromContext(r.Context()))
+
+	resp := RecoveryResponse{
+		StartedAt: report.StartedAt,
+		OK:        report.OK,
+		Steps:     make([]RecoveryStepResponse, 0, len(report.Steps)),
+	}
+	for _, step := range report.Steps {
+		resp.Steps = append(resp.Steps, RecoveryStepResponse{Name: step.Name, OK: step.OK, Detail: step.Detail})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,74 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/leo-andrei/check-in-service/application/services"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+)
+
+type RepairHandler struct {
+	repairService *services.RepairService
+}
+
+func NewRepairHandler(repairService *services.RepairService) *RepairHandler {
+	return &RepairHandler{
+		repairService: repairService,
+	}
+}
+
+type RepairRequest struct {
+	From    time.Time `json:"from" validate:"required"`
+	To      time.Time `json:"to" validate:"required"`
+	Confirm bool      `json:"confirm"`
+	// Hash of the previewed plan, required to confirm it
+	PlanHash string `json:"plan_hash" validate:"required_if=Confirm true"`
+	Reason   string `json:"reason" validate:"max=500"`
+}
+
+type RepairResponse struct {
+	Applied bool                 `json:"applied"`
+	Plan    *services.RepairPlan `json:"plan"`
+}
+
+// HandleRepair handles POST /api/admin/employees/{id}/repair. Without
+// "confirm": true it only returns the proposed resolution; confirming takes
+// the plan's hash and answers 409 when the plan changed since.
+func (h *RepairHandler) HandleRepair(w http.ResponseWriter, r *http.Request) {
+	var req RepairRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, errors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	if err := validator.New().Struct(&req); err != nil || !req.From.Before(req.To) {
+		http.Error(w, errors.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	employeeID := r.PathValue("id")
+
+	if !req.Confirm {
+		plan, err := h.repairService.Analyze(r.Context(), employeeID, req.From, req.To)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, RepairResponse{Applied: false, Plan: plan})
+		return
+	}
+
+	plan, err := h.repairService.Apply(r.Context(), employeeID, req.From, req.To, req.PlanHash, actorFromContext(r.Context()), req.Reason)
+	if err == errors.ErrRepairPlanChangedConst {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, RepairResponse{Applied: true, Plan: plan})
+}
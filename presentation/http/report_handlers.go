@@ -0,0 +1,504 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/application/services"
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+	"github.com/leo-andrei/check-in-service/domain/hours"
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+)
+
+type ReportHandler struct {
+	reportService      *services.ReportService
+	noteService        *services.NoteService
+	aggregationService *services.AggregationService
+	payPeriodService   *services.PayPeriodService
+}
+
+func NewReportHandler(reportService *services.ReportService, noteService *services.NoteService, aggregationService *services.AggregationService, payPeriodService *services.PayPeriodService) *ReportHandler {
+	return &ReportHandler{
+		reportService:      reportService,
+		noteService:        noteService,
+		aggregationService: aggregationService,
+		payPeriodService:   payPeriodService,
+	}
+}
+
+// RecordReportEntry renders a time record in the report's time zone (ISO 8601)
+type RecordReportEntry struct {
+	RecordID      string         `json:"record_id"`
+	Status        string         `json:"status"`
+	CheckInAt     time.Time      `json:"check_in_at"`
+	CheckOutAt    *time.Time     `json:"check_out_at,omitempty"`
+	HoursWorked   float64        `json:"hours_worked"`
+	RegularHours  float64        `json:"regular_hours"`
+	OvertimeHours float64        `json:"overtime_hours"`
+	HolidayHours  float64        `json:"holiday_hours,omitempty"`
+	WeekendHours  float64        `json:"weekend_hours,omitempty"`
+	LocationID    string         `json:"location_id,omitempty"`
+	ProjectCode   string         `json:"project_code,omitempty"`
+	TimeZone      string         `json:"record_time_zone"`
+	BusinessDate  string         `json:"business_date,omitempty"`
+	Segments      []DaySegment   `json:"day_segments,omitempty"`
+	LaborCost     *LaborCost     `json:"labor_cost,omitempty"`
+	Notes         []NoteResponse `json:"notes,omitempty"`
+	// Channel and terminal of each punch
+	Source           string `json:"source,omitempty"`
+	DeviceID         string `json:"device_id,omitempty"`
+	CheckOutSource   string `json:"check_out_source,omitempty"`
+	CheckOutDeviceID string `json:"check_out_device_id,omitempty"`
+}
+
+// LaborCost is the money value of a record's hours, as decimals in Currency
+type LaborCost struct {
+	Currency   string  `json:"currency"`
+	HourlyRate float64 `json:"hourly_rate"`
+	Regular    string  `json:"regular"`
+	Overtime   string  `json:"overtime"`
+	Total      string  `json:"total"`
+}
+
+// DaySegment is the part of an overnight shift worked on one business date
+type DaySegment struct {
+	BusinessDate string    `json:"business_date"`
+	StartAt      time.Time `json:"start_at"`
+	EndAt        time.Time `json:"end_at"`
+	Hours        float64   `json:"hours"`
+}
+
+type WeeklyReportEntry struct {
+	Week          string    `json:"week"`
+	WeekStart     time.Time `json:"week_start"`
+	Records       int       `json:"records"`
+	HoursWorked   float64   `json:"hours_worked"`
+	RegularHours  float64   `json:"regular_hours"`
+	OvertimeHours float64   `json:"overtime_hours"`
+}
+
+// HoursSummaryResponse is the figure managers sign off against for one period.
+// Scheduled hours and the variance of the hours worked from them are null for
+// employees without a schedule.
+type HoursSummaryResponse struct {
+	EmployeeID     string    `json:"employee_id"`
+	Period         string    `json:"period"`
+	Label          string    `json:"label"`
+	TimeZone       string    `json:"time_zone"`
+	Start          time.Time `json:"start"`
+	End            time.Time `json:"end"`
+	Records        int       `json:"records"`
+	HoursWorked    float64   `json:"hours_worked"`
+	RegularHours   float64   `json:"regular_hours"`
+	OvertimeHours  float64   `json:"overtime_hours"`
+	ScheduledHours *float64  `json:"scheduled_hours"`
+	VarianceHours  *float64  `json:"variance_hours"`
+}
+
+// HoursGroupEntry is the hours of one employee, team or location in one period
+type HoursGroupEntry struct {
+	Key               string    `json:"key"`
+	Period            string    `json:"period"`
+	PeriodStart       time.Time `json:"period_start"`
+	Employees         int       `json:"employees"`
+	Records           int       `json:"records"`
+	HoursWorked       float64   `json:"hours_worked"`
+	RegularHours      float64   `json:"regular_hours"`
+	OvertimeHours     float64   `json:"overtime_hours"`
+	AverageShiftHours float64   `json:"average_shift_hours"`
+}
+
+type HoursAggregateResponse struct {
+	GroupBy   string            `json:"group_by"`
+	Period    string            `json:"period"`
+	TimeZone  string            `json:"time_zone"`
+	WeekStart string            `json:"week_start,omitempty"`
+	Source    string            `json:"source,omitempty"`
+	From      time.Time         `json:"from"`
+	To        time.Time         `json:"to"`
+	Entries   []HoursGroupEntry `json:"entries"`
+}
+
+type ReportResponse struct {
+	EmployeeID string      `json:"employee_id"`
+	TimeZone   string      `json:"time_zone"`
+	WeekStart  string      `json:"week_start,omitempty"`
+	Source     string      `json:"source,omitempty"`
+	From       time.Time   `json:"from"`
+	To         time.Time   `json:"to"`
+	Entries    interface{} `json:"entries"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// maxReportPageSize caps the limit a client may ask for
+const maxReportPageSize = 5000
+
+// reportParams are the query parameters shared by report endpoints:
+// from and to (YYYY-MM-DD, inclusive, or RFC 3339), or pay_period (any date
+// of the pay period, YYYY-MM-DD) instead, and tz (IANA name, defaults to
+// DEFAULT_TIME_ZONE)
+type reportParams struct {
+	loc      *time.Location
+	timeZone string
+	from, to time.Time
+}
+
+func (h *ReportHandler) parseReportParams(r *http.Request) (*reportParams, error) {
+	query := r.URL.Query()
+
+	timeZone := query.Get("tz")
+	if timeZone == "" {
+		timeZone = config.Cfg.DefaultTimeZone
+	}
+	loc, err := entities.LoadTimeZone(timeZone)
+	if err != nil {
+		return nil, errors.ErrInvalidTimeZoneConst
+	}
+
+	if date := query.Get("pay_period"); date != "" {
+		at, err := time.ParseInLocation(entities.BusinessDateLayout, date, loc)
+		if err != nil {
+			return nil, errors.ErrInvalidPayPeriodDateConst
+		}
+		from, to := h.payPeriodService.Bounds(at)
+		return &reportParams{loc: loc, timeZone: timeZone, from: from, to: to}, nil
+	}
+
+	from, to, err := hours.ParseDateRange(query.Get("from"), query.Get("to"), loc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", errors.ErrInvalidDateRange, err)
+	}
+
+	return &reportParams{loc: loc, timeZone: timeZone, from: from, to: to}, nil
+}
+
+// parseSourceFilter reads the optional source query parameter
+func parseSourceFilter(r *http.Request) (entities.PunchSource, bool) {
+	source := entities.PunchSource(r.URL.Query().Get("source"))
+	return source, source == "" || source.IsValid()
+}
+
+// RecordsReport handles GET /api/reports/employees/{id}/records?from=&to=|pay_period=&tz=&source=&limit=&cursor=
+// Records come one page at a time; pass next_cursor back as cursor for the next page.
+func (h *ReportHandler) RecordsReport(w http.ResponseWriter, r *http.Request) {
+	params, err := h.parseReportParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	source, ok := parseSourceFilter(r)
+	if !ok {
+		http.Error(w, errors.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	limit := config.Cfg.Reports.PageSize
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxReportPageSize {
+			http.Error(w, errors.ErrInvalidRequest, http.StatusBadRequest)
+			return
+		}
+	}
+
+	employeeID, err := h.reportService.CanonicalEmployeeID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	records, nextCursor, err := h.reportService.RecordsPage(r.Context(), employeeID, params.from, params.to, source, r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		if err == errors.ErrInvalidCursorConst {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	notes, err := h.noteService.ForRecords(r.Context(), employeeID, records)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	entries := make([]RecordReportEntry, 0, len(records))
+	for _, record := range records {
+		entry := RecordReportEntry{
+			RecordID:      record.ID,
+			Status:        string(record.Status),
+			CheckInAt:     record.CheckInAt.In(params.loc),
+			HoursWorked:   record.HoursWorked,
+			RegularHours:  record.RegularHours,
+			OvertimeHours: record.OvertimeHours,
+			HolidayHours:  record.HolidayHours,
+			WeekendHours:  record.WeekendHours,
+			LocationID:    record.LocationID,
+			ProjectCode:   record.ProjectCode,
+			TimeZone:      record.TimeZone,
+			BusinessDate:  record.BusinessDate,
+
+			Source:           string(record.Source),
+			DeviceID:         record.DeviceID,
+			CheckOutSource:   string(record.CheckOutSource),
+			CheckOutDeviceID: record.CheckOutDeviceID,
+		}
+		for _, s := range record.Segments {
+			entry.Segments = append(entry.Segments, DaySegment{
+				BusinessDate: s.BusinessDate,
+				StartAt:      s.StartAt.In(params.loc),
+				EndAt:        s.EndAt.In(params.loc),
+				Hours:        s.Hours,
+			})
+		}
+		if cost := record.LaborCost; cost != nil {
+			entry.LaborCost = &LaborCost{
+				Currency:   cost.Currency(),
+				HourlyRate: cost.HourlyRate,
+				Regular:    cost.Regular.String(),
+				Overtime:   cost.Overtime.String(),
+				Total:      cost.Total().String(),
+			}
+		}
+		if record.CheckOutAt != nil {
+			checkOutAt := record.CheckOutAt.In(params.loc)
+			entry.CheckOutAt = &checkOutAt
+		}
+		for _, note := range notes[record.ID] {
+			n := toNoteResponse(note)
+			n.CreatedAt = n.CreatedAt.In(params.loc)
+			entry.Notes = append(entry.Notes, n)
+		}
+		entries = append(entries, entry)
+	}
+
+	writeJSON(w, http.StatusOK, ReportResponse{
+		EmployeeID: employeeID,
+		TimeZone:   params.timeZone,
+		Source:     string(source),
+		From:       params.from,
+		To:         params.to,
+		Entries:    entries,
+		NextCursor: nextCursor,
+	})
+}
+
+// WeeklyReport handles GET /api/reports/employees/{id}/weekly?from=&to=&tz=&week_start=
+// It answers 403 unless the employee consented to analytics.
+func (h *ReportHandler) WeeklyReport(w http.ResponseWriter, r *http.Request) {
+	params, err := h.parseReportParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	weekStartName := r.URL.Query().Get("week_start")
+	if weekStartName == "" {
+		weekStartName = config.Cfg.Reports.WeekStart
+	}
+	weekStart, ok := weekStarts[weekStartName]
+	if !ok {
+		http.Error(w, errors.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	employeeID, err := h.reportService.CanonicalEmployeeID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	weeks, err := h.reportService.WeeklyHours(r.Context(), employeeID, params.from, params.to, params.loc, weekStart)
+	if err == errors.ErrConsentNotFoundConst {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	entries := make([]WeeklyReportEntry, 0, len(weeks))
+	for _, week := range weeks {
+		entries = append(entries, WeeklyReportEntry{
+			Week:          week.Week,
+			WeekStart:     week.WeekStart,
+			Records:       week.Records,
+			HoursWorked:   week.HoursWorked,
+			RegularHours:  week.RegularHours,
+			OvertimeHours: week.OvertimeHours,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, ReportResponse{
+		EmployeeID: employeeID,
+		TimeZone:   params.timeZone,
+		WeekStart:  weekStartName,
+		From:       params.from,
+		To:         params.to,
+		Entries:    entries,
+	})
+}
+
+// HoursSummary handles GET /api/employees/{id}/hours?period=week|month|pay&date=&tz=&week_start=
+// It totals the week, month or pay period containing date (defaults to today in tz);
+// weeks and months answer 403 unless the employee consented to analytics.
+func (h *ReportHandler) HoursSummary(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	timeZone := query.Get("tz")
+	if timeZone == "" {
+		timeZone = config.Cfg.DefaultTimeZone
+	}
+	loc, err := entities.LoadTimeZone(timeZone)
+	if err != nil {
+		http.Error(w, errors.ErrInvalidTimeZone, http.StatusBadRequest)
+		return
+	}
+
+	period := query.Get("period")
+	if period == "" {
+		period = hours.PeriodMonth
+	}
+	if period != hours.PeriodWeek && period != hours.PeriodMonth && period != hours.PeriodPay {
+		http.Error(w, errors.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	at := time.Now().In(loc)
+	if date := query.Get("date"); date != "" {
+		at, err = time.ParseInLocation(entities.BusinessDateLayout, date, loc)
+		if err != nil {
+			http.Error(w, errors.ErrInvalidRequest, http.StatusBadRequest)
+			return
+		}
+	}
+
+	weekStartName := query.Get("week_start")
+	if weekStartName == "" {
+		weekStartName = config.Cfg.Reports.WeekStart
+	}
+	weekStart, ok := weekStarts[weekStartName]
+	if !ok {
+		http.Error(w, errors.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	employeeID, err := h.reportService.CanonicalEmployeeID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	totals, err := h.reportService.PeriodHours(r.Context(), employeeID, period, at, weekStart)
+	if err == errors.ErrConsentNotFoundConst {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, HoursSummaryResponse{
+		EmployeeID:     employeeID,
+		Period:         totals.Period,
+		Label:          totals.Label,
+		TimeZone:       timeZone,
+		Start:          totals.Start,
+		End:            totals.End,
+		Records:        totals.Records,
+		HoursWorked:    totals.HoursWorked,
+		RegularHours:   totals.RegularHours,
+		OvertimeHours:  totals.OvertimeHours,
+		ScheduledHours: totals.ScheduledHours,
+		VarianceHours:  totals.VarianceHours,
+	})
+}
+
+// HoursAggregate handles GET /api/reports/hours?group_by=employee|team|location&period=week|month|pay&from=&to=|pay_period=&tz=&week_start=&source=
+// Records count towards the period of their check-in; teams are keyed by manager ID.
+// Weeks and months leave out employees who did not consent to analytics.
+func (h *ReportHandler) HoursAggregate(w http.ResponseWriter, r *http.Request) {
+	params, err := h.parseReportParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	groupBy := query.Get("group_by")
+	if groupBy == "" {
+		groupBy = services.GroupByEmployee
+	}
+	if groupBy != services.GroupByEmployee && groupBy != services.GroupByTeam && groupBy != services.GroupByLocation {
+		http.Error(w, errors.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	period := query.Get("period")
+	if period == "" {
+		period = hours.PeriodWeek
+	}
+	if period != hours.PeriodWeek && period != hours.PeriodMonth && period != hours.PeriodPay {
+		http.Error(w, errors.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	weekStartName := query.Get("week_start")
+	if weekStartName == "" {
+		weekStartName = config.Cfg.Reports.WeekStart
+	}
+	weekStart, ok := weekStarts[weekStartName]
+	if !ok {
+		http.Error(w, errors.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	source, ok := parseSourceFilter(r)
+	if !ok {
+		http.Error(w, errors.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	groups, err := h.aggregationService.Aggregate(r.Context(), groupBy, period, params.from, params.to, params.loc, weekStart, source)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	entries := make([]HoursGroupEntry, 0, len(groups))
+	for _, group := range groups {
+		entries = append(entries, HoursGroupEntry{
+			Key:               group.Key,
+			Period:            group.Period,
+			PeriodStart:       group.PeriodStart,
+			Employees:         group.Employees,
+			Records:           group.Records,
+			HoursWorked:       group.HoursWorked,
+			RegularHours:      group.RegularHours,
+			OvertimeHours:     group.OvertimeHours,
+			AverageShiftHours: group.AverageShiftHours(),
+		})
+	}
+
+	resp := HoursAggregateResponse{
+		GroupBy:  groupBy,
+		Period:   period,
+		TimeZone: params.timeZone,
+		Source:   string(source),
+		From:     params.from,
+		To:       params.to,
+		Entries:  entries,
+	}
+	if period == hours.PeriodWeek {
+		resp.WeekStart = weekStartName
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+var weekStarts = map[string]time.Weekday{
+	"monday": time.Monday,
+	"sunday": time.Sunday,
+}
@@ -0,0 +1,35 @@
+package http
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+)
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeRepositoryError answers an error no domain error matched: 404 for a
+// missing row, 409 for a write rejected by a unique constraint, otherwise 500
+func writeRepositoryError(w http.ResponseWriter, err error) {
+	switch {
+	case stderrors.Is(err, repositories.ErrRecordNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case stderrors.Is(err, repositories.ErrConflict):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// StaticJSON serves a value computed once, e.g. the startup self-check report
+func StaticJSON(v interface{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, v)
+	}
+}
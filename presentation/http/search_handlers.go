@@ -0,0 +1,130 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/application/services"
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+	"github.com/leo-andrei/check-in-service/domain/hours"
+	"github.com/leo-andrei/check-in-service/domain/repositories"
+	"github.com/leo-andrei/check-in-service/infrastructure/config"
+)
+
+const (
+	defaultSearchPageSize = 50
+	maxSearchPageSize     = 200
+)
+
+type SearchHandler struct {
+	searchService *services.SearchService
+}
+
+func NewSearchHandler(searchService *services.SearchService) *SearchHandler {
+	return &SearchHandler{
+		searchService: searchService,
+	}
+}
+
+type SearchResponse struct {
+	Results    []SearchResult `json:"results"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// SearchResult is a record or audit entry, told apart by type
+type SearchResult struct {
+	Type       string    `json:"type"`
+	ID         string    `json:"id"`
+	EmployeeID string    `json:"employee_id"`
+	RecordID   string    `json:"record_id"`
+	At         time.Time `json:"at"`
+	Status     string    `json:"status,omitempty"`
+	DeviceID   string    `json:"device_id,omitempty"`
+	Action     string    `json:"action,omitempty"`
+	Actor      string    `json:"actor,omitempty"`
+	Reason     string    `json:"reason,omitempty"`
+}
+
+// Search handles GET /api/search?q=&employee=&device=&note=&date=|from=&to=&tz=&type=&limit=&cursor=
+// Text filters match anywhere in the value; date (or from and to) are local
+// dates in tz. type is record or audit, both when omitted. Pass next_cursor
+// back as cursor for the next page.
+func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	query := repositories.SearchQuery{
+		Text:       strings.TrimSpace(params.Get("q")),
+		EmployeeID: strings.TrimSpace(params.Get("employee")),
+		DeviceID:   strings.TrimSpace(params.Get("device")),
+		Note:       strings.TrimSpace(params.Get("note")),
+	}
+	if t := params.Get("type"); t != "" {
+		query.Types = []string{t}
+	}
+
+	from, to := params.Get("from"), params.Get("to")
+	if date := params.Get("date"); date != "" {
+		from, to = date, date
+	}
+	if from != "" || to != "" {
+		timeZone := params.Get("tz")
+		if timeZone == "" {
+			timeZone = config.Cfg.DefaultTimeZone
+		}
+		loc, err := entities.LoadTimeZone(timeZone)
+		if err != nil {
+			http.Error(w, errors.ErrInvalidTimeZone, http.StatusBadRequest)
+			return
+		}
+		query.From, query.To, err = hours.ParseDateRange(from, to, loc)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("%s: %v", errors.ErrInvalidDateRange, err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	limit := defaultSearchPageSize
+	if raw := params.Get("limit"); raw != "" {
+		var err error
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxSearchPageSize {
+			http.Error(w, errors.ErrInvalidRequest, http.StatusBadRequest)
+			return
+		}
+	}
+
+	hits, nextCursor, err := h.searchService.Search(r.Context(), query, params.Get("cursor"), limit)
+	if err != nil {
+		if err == errors.ErrInvalidSearchConst || err == errors.ErrInvalidCursorConst {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := SearchResponse{
+		Results:    make([]SearchResult, 0, len(hits)),
+		NextCursor: nextCursor,
+	}
+	for _, hit := range hits {
+		resp.Results = append(resp.Results, SearchResult{
+			Type:       hit.Type,
+			ID:         hit.ID,
+			EmployeeID: hit.EmployeeID,
+			RecordID:   hit.RecordID,
+			At:         hit.At,
+			Status:     hit.Status,
+			DeviceID:   hit.DeviceID,
+			Action:     hit.Action,
+			Actor:      hit.Actor,
+			Reason:     hit.Reason,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
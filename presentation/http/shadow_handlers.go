@@ -0,0 +1,86 @@
+package http
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/application/handlers"
+)
+
+// ParityReporter is the shadow event parity checker
+type ParityReporter interface {
+	Report() handlers.ParityReport
+}
+
+type ShadowHandler struct {
+	checker ParityReporter
+}
+
+func NewShadowHandler(checker ParityReporter) *ShadowHandler {
+	return &ShadowHandler{
+		checker: checker,
+	}
+}
+
+type ParityReportResponse struct {
+	Since      time.Time             `json:"since"`
+	Compared   int                   `json:"compared"`
+	Matched    int                   `json:"matched"`
+	Mismatched int                   `json:"mismatched"`
+	Fields     []FieldMismatchCount  `json:"field_mismatches"`
+	Samples    []ParityMismatchEntry `json:"samples"`
+}
+
+type FieldMismatchCount struct {
+	Field string `json:"field"`
+	Count int    `json:"count"`
+}
+
+type ParityMismatchEntry struct {
+	EventID   string      `json:"event_id"`
+	EventType string      `json:"event_type"`
+	Field     string      `json:"field"`
+	NextField string      `json:"next_field,omitempty"`
+	Current   interface{} `json:"current"`
+	Next      interface{} `json:"next"`
+	At        time.Time   `json:"at"`
+}
+
+// GetParityReport handles GET /api/admin/shadow/parity
+// It reports how many shadow events matched the current version on this
+// instance, the fields that differed and recent examples.
+func (h *ShadowHandler) GetParityReport(w http.ResponseWriter, r *http.Request) {
+	report := h.checker.Report()
+
+	resp := ParityReportResponse{
+		Since:      report.Since,
+		Compared:   report.Compared,
+		Matched:    report.Matched,
+		Mismatched: report.Mismatched,
+		Fields:     make([]FieldMismatchCount, 0, len(report.Fields)),
+		Samples:    make([]ParityMismatchEntry, 0, len(report.Samples)),
+	}
+	for field, count := range report.Fields {
+		resp.Fields = append(resp.Fields, FieldMismatchCount{Field: field, Count: count})
+	}
+	sort.Slice(resp.Fields, func(i, j int) bool {
+		if resp.Fields[i].Count != resp.Fields[j].Count {
+			return resp.Fields[i].Count > resp.Fields[j].Count
+		}
+		return resp.Fields[i].Field < resp.Fields[j].Field
+	})
+	for _, m := range report.Samples {
+		resp.Samples = append(resp.Samples, ParityMismatchEntry{
+			EventID:   m.EventID,
+			EventType: m.EventType,
+			Field:     m.Field,
+			NextField: m.NextField,
+			Current:   m.Current,
+			Next:      m.Next,
+			At:        m.At,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,77 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/leo-andrei/check-in-service/application/services"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+)
+
+type TimelineHandler struct {
+	timelineService *services.TimelineService
+}
+
+func NewTimelineHandler(timelineService *services.TimelineService) *TimelineHandler {
+	return &TimelineHandler{
+		timelineService: timelineService,
+	}
+}
+
+// TimelineResponse is everything that happened for an employee on a date, oldest first
+type TimelineResponse struct {
+	EmployeeID string                  `json:"employee_id"`
+	Date       string                  `json:"date"`
+	TimeZone   string                  `json:"time_zone"`
+	Entries    []TimelineEntryResponse `json:"entries"`
+}
+
+type TimelineEntryResponse struct {
+	At       time.Time         `json:"at"`
+	Kind     string            `json:"kind"`
+	RecordID string            `json:"record_id"`
+	Summary  string            `json:"summary"`
+	Details  map[string]string `json:"details,omitempty"`
+}
+
+// GetTimeline handles GET /api/employees/{id}/timeline?date=&tz=
+// It merges the taps, breaks, corrections, notes, notifications and anomalies
+// of the employee's records on date (YYYY-MM-DD) in tz, the default zone
+// when omitted.
+func (h *TimelineHandler) GetTimeline(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	timeline, err := h.timelineService.ForDate(r.Context(), r.PathValue("id"), query.Get("date"), query.Get("tz"))
+	if err != nil {
+		switch err {
+		case errors.ErrInvalidTimelineDateConst, errors.ErrInvalidTimeZoneConst:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			writeRepositoryError(w, err)
+		}
+		return
+	}
+
+	resp := TimelineResponse{
+		EmployeeID: timeline.EmployeeID,
+		Date:       timeline.Date,
+		TimeZone:   timeline.TimeZone,
+		Entries:    make([]TimelineEntryResponse, 0, len(timeline.Entries)),
+	}
+	for _, entry := range timeline.Entries {
+		details := make(map[string]string, len(entry.Details))
+		for key, value := range entry.Details {
+			if value != "" {
+				details[key] = value
+			}
+		}
+		resp.Entries = append(resp.Entries, TimelineEntryResponse{
+			At:       entry.At,
+			Kind:     string(entry.Kind),
+			RecordID: entry.RecordID,
+			Summary:  entry.Summary,
+			Details:  details,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,154 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/leo-andrei/check-in-service/application/services"
+	"github.com/leo-andrei/check-in-service/domain/entities"
+	"github.com/leo-andrei/check-in-service/domain/errors"
+)
+
+type TimesheetHandler struct {
+	timesheetService *services.TimesheetService
+}
+
+func NewTimesheetHandler(timesheetService *services.TimesheetService) *TimesheetHandler {
+	return &TimesheetHandler{
+		timesheetService: timesheetService,
+	}
+}
+
+type TimesheetResponse struct {
+	ID              string     `json:"id"`
+	EmployeeID      string     `json:"employee_id"`
+	PeriodStart     string     `json:"period_start"`
+	PeriodEnd       string     `json:"period_end"`
+	Status          string     `json:"status"`
+	RecordIDs       []string   `json:"record_ids"`
+	HoursWorked     float64    `json:"hours_worked"`
+	RegularHours    float64    `json:"regular_hours"`
+	OvertimeHours   float64    `json:"overtime_hours"`
+	SubmittedAt     *time.Time `json:"submitted_at,omitempty"`
+	DecidedBy       string     `json:"decided_by,omitempty"`
+	DecisionComment string     `json:"decision_comment,omitempty"`
+	DecidedAt       *time.Time `json:"decided_at,omitempty"`
+	ClosedAt        *time.Time `json:"closed_at,omitempty"`
+}
+
+type ClosePeriodResponse struct {
+	Closed int `json:"closed"`
+}
+
+func toTimesheetResponse(t *entities.Timesheet) TimesheetResponse {
+	return TimesheetResponse{
+		ID:              t.ID,
+		EmployeeID:      t.EmployeeID,
+		PeriodStart:     t.PeriodStart,
+		PeriodEnd:       t.PeriodEnd,
+		Status:          string(t.Status),
+		RecordIDs:       t.RecordIDs,
+		HoursWorked:     t.HoursWorked,
+		RegularHours:    t.RegularHours,
+		OvertimeHours:   t.OvertimeHours,
+		SubmittedAt:     t.SubmittedAt,
+		DecidedBy:       t.DecidedBy,
+		DecisionComment: t.DecisionComment,
+		DecidedAt:       t.DecidedAt,
+		ClosedAt:        t.ClosedAt,
+	}
+}
+
+// GetTimesheet handles GET /api/employees/{id}/timesheet?date=
+func (h *TimesheetHandler) GetTimesheet(w http.ResponseWriter, r *http.Request) {
+	sheet, err := h.timesheetService.ForDate(r.Context(), r.PathValue("id"), r.URL.Query().Get("date"))
+	if err != nil {
+		writeTimesheetError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toTimesheetResponse(sheet))
+}
+
+// SubmitTimesheet handles POST /api/employees/{id}/timesheet/submit?date=
+func (h *TimesheetHandler) SubmitTimesheet(w http.ResponseWriter, r *http.Request) {
+	sheet, err := h.timesheetService.Submit(r.Context(), r.PathValue("id"), r.URL.Query().Get("date"))
+	if err != nil {
+		writeTimesheetError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toTimesheetResponse(sheet))
+}
+
+// Approve handles POST /api/admin/timesheets/{id}/approve
+func (h *TimesheetHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, true)
+}
+
+// Reject handles POST /api/admin/timesheets/{id}/reject
+func (h *TimesheetHandler) Reject(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, false)
+}
+
+func (h *TimesheetHandler) decide(w http.ResponseWriter, r *http.Request, approve bool) {
+	var req DecisionRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, errors.ErrInvalidRequestBody, http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := validator.New().Struct(&req); err != nil {
+		http.Error(w, errors.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	sheet, err := h.timesheetService.Decide(r.Context(), r.PathValue("id"), approve, actorFromContext(r.Context()), req.Comment)
+	if err != nil {
+		writeTimesheetError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toTimesheetResponse(sheet))
+}
+
+// CloseTimesheet handles POST /api/admin/employees/{id}/timesheet/close?date=
+func (h *TimesheetHandler) CloseTimesheet(w http.ResponseWriter, r *http.Request) {
+	sheet, err := h.timesheetService.Close(r.Context(), r.PathValue("id"), r.URL.Query().Get("date"), actorFromContext(r.Context()))
+	if err != nil {
+		writeTimesheetError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toTimesheetResponse(sheet))
+}
+
+// ClosePeriod handles POST /api/admin/timesheets/close?date=
+func (h *TimesheetHandler) ClosePeriod(w http.ResponseWriter, r *http.Request) {
+	closed, err := h.timesheetService.ClosePeriod(r.Context(), r.URL.Query().Get("date"), actorFromContext(r.Context()))
+	if err != nil {
+		writeTimesheetError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ClosePeriodResponse{Closed: closed})
+}
+
+func writeTimesheetError(w http.ResponseWriter, err error) {
+	switch err {
+	case errors.ErrTimesheetNotFoundConst:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.ErrInvalidPayPeriodDateConst:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.ErrNotApproverConst:
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.ErrTimesheetTransitionConst, errors.ErrPayrollPreflightFailedConst:
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		writeRepositoryError(w, err)
+	}
+}